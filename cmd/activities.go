@@ -2,19 +2,25 @@ package cmd
 
 import (
 	"bytes"
+	"cmp"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"mime/multipart"
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 
 	"github.com/spf13/cobra"
 	genclient "github.com/Brainsoft-Raxat/strava-cli/internal/client"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/analysis"
 	"github.com/Brainsoft-Raxat/strava-cli/internal/output"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/store"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/tui"
 )
 
 var activitiesCmd = &cobra.Command{
@@ -23,10 +29,15 @@ var activitiesCmd = &cobra.Command{
 }
 
 var (
-	listBefore  int
-	listAfter   int
-	listPage    int
-	listPerPage int
+	listBefore       int
+	listAfter        int
+	listSports       string
+	listMinDistance  string
+	listMaxDistance  string
+	listCommute      bool
+	listNameContains string
+	listClassify     bool
+	listMeta         []string
 )
 
 var activitiesListCmd = &cobra.Command{
@@ -35,51 +46,124 @@ var activitiesListCmd = &cobra.Command{
 	Long: `List the authenticated athlete's activities.
 
 --before and --after accept Unix timestamps.
-Example: --after $(date -d '7 days ago' +%s)`,
+Example: --after $(date -d '7 days ago' +%s)
+
+The API cannot filter by sport, distance, commute or name, so --sport,
+--min-distance, --max-distance, --commute and --name-contains filter the
+fetched activities locally, as --meta does on the fields set with "strava
+meta" (key=value, or key alone for any value). Add --all to fetch (and filter) every page
+from --page on rather than just --page; if a page fails, the activities
+fetched so far are still printed along with the command that resumes from
+the failed page. Distances take a unit (10km, 5mi, 800m); a bare
+number is in your display units.
+
+--classify adds a class column labelling each session with heart rate by its
+time in your Strava heart rate zones: vo2 with a tenth of it in zone 5,
+threshold with a quarter in zones 4–5, tempo with 30% in zones 3–5, easy
+otherwise. Labels are kept in ~/.config/strava-cli/classes.json (--json
+output leaves them out), so each activity's streams are fetched once, and
+again only if your zones change.
+
+Examples:
+  strava activities list --sport Run,TrailRun --min-distance 15km --all
+  strava activities list --classify --sort class:desc
+  strava activities list --commute=false --sport Ride
+  strava activities list --meta shoes_rotation=B --columns id,name,distance,meta
+  strava activities list --name-contains tempo --all --after $(date -d '90 days ago' +%s)`,
 	RunE: runActivitiesList,
 }
 
+var (
+	searchSports string
+	searchMeta   []string
+)
+
+var activitiesSearchCmd = &cobra.Command{
+	Use:   "search <text>",
+	Short: "Search activity names and descriptions",
+	Long: `Search your activities for text. Every word must appear in the name
+or the description, ignoring case.
+
+Strava's API cannot search, so this reads the history stored by "strava
+sync", including the descriptions fetched with "strava sync --descriptions".
+Without a synced history it pages through the API instead and searches
+names only.
+
+Examples:
+  strava activities search tempo
+  strava activities search "hill repeats" --sport Run
+  strava activities search race --columns id,date,name,distance --sort date:desc`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runActivitiesSearch,
+}
+
 var activitiesGetCmd = &cobra.Command{
-	Use:   "get <id>",
+	Use:   "get [id]",
 	Short: "Get a specific activity by ID",
-	Args:  cobra.ExactArgs(1),
+	Args:  cobra.MaximumNArgs(1),
 	RunE:  runActivitiesGet,
+
+	ValidArgsFunction: completeActivityIDs,
 }
 
 var activitiesLapsCmd = &cobra.Command{
-	Use:   "laps <id>",
+	Use:   "laps [id]",
 	Short: "List laps for an activity",
-	Args:  cobra.ExactArgs(1),
+	Args:  cobra.MaximumNArgs(1),
 	RunE:  runActivitiesLaps,
+
+	ValidArgsFunction: completeActivityIDs,
+}
+
+var activitiesSegmentsCmd = &cobra.Command{
+	Use:   "segments [id]",
+	Short: "List segment efforts within an activity",
+	Long: `List every segment effort recorded in an activity, with elapsed time and
+your PR / KOM rank where the effort placed.
+
+Hidden efforts are included (the activity is fetched with include_all_efforts).`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runActivitiesSegments,
+
+	ValidArgsFunction: completeActivityIDs,
 }
 
 var activitiesZonesCmd = &cobra.Command{
-	Use:   "zones <id>",
+	Use:   "zones [id]",
 	Short: "Get heart rate and power zones for an activity",
-	Args:  cobra.ExactArgs(1),
+	Args:  cobra.MaximumNArgs(1),
 	RunE:  runActivitiesZones,
+
+	ValidArgsFunction: completeActivityIDs,
 }
 
 var activitiesCommentsCmd = &cobra.Command{
-	Use:   "comments <id>",
+	Use:   "comments [id]",
 	Short: "List comments on an activity",
-	Args:  cobra.ExactArgs(1),
+	Args:  cobra.MaximumNArgs(1),
 	RunE:  runActivitiesComments,
+
+	ValidArgsFunction: completeActivityIDs,
 }
 
 var activitiesKudosCmd = &cobra.Command{
-	Use:   "kudos <id>",
+	Use:   "kudos [id]",
 	Short: "List athletes who kudoed an activity",
-	Args:  cobra.ExactArgs(1),
+	Args:  cobra.MaximumNArgs(1),
 	RunE:  runActivitiesKudos,
+
+	ValidArgsFunction: completeActivityIDs,
 }
 
 var (
-	streamsKeys string
+	streamsKeys       string
+	streamsResolution string
+	streamsSeriesType string
+	streamsChunked    bool
 )
 
 var activitiesStreamsCmd = &cobra.Command{
-	Use:   "streams <id>",
+	Use:   "streams [id]",
 	Short: "Get data streams for an activity",
 	Long: `Fetch time-series data streams for an activity.
 
@@ -87,9 +171,83 @@ Available stream keys (comma-separated):
   time, distance, latlng, altitude, velocity_smooth, heartrate,
   cadence, watts, temp, moving, grade_smooth
 
-Example: strava activities streams 12345 --keys time,heartrate,watts`,
-	Args: cobra.ExactArgs(1),
+Without --keys, the keys suit the activity's sport: no power or altitude for
+a swim, just time and heart rate for gym sessions. --keys all requests every
+stream type.
+
+By default every recorded sample is returned. --resolution low, medium or
+high asks Strava for roughly 100, 1000 or 10000 points instead, spaced by
+time or, with --series-type distance, by distance (useful for comparing
+activities over the same course).
+
+Full-resolution streams of very long activities (20-hour ultras) make huge
+responses that can time out. When the request fails in transit, or with
+--chunked, each stream is fetched separately and retried on its own; every
+stream is saved under ~/.config/strava-cli/streams-partial as it arrives,
+so if the download still fails, running the command again resumes it.
+
+Examples:
+  strava activities streams 12345
+  strava activities streams 12345 --keys time,heartrate,watts
+  strava activities streams 12345 --keys all --json
+  strava activities streams 12345 --resolution medium --series-type distance --json
+  strava activities streams 12345 --keys all --chunked --json > ultra.json`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: runActivitiesStreams,
+
+	ValidArgsFunction: completeActivityIDs,
+}
+
+// pickerActivities is how many recent activities the ID picker offers.
+const pickerActivities = 50
+
+// ── chart ─────────────────────────────────────────────────────────────────────
+
+var chartMetric string
+
+// chartStreamKeys maps a chart metric to the stream it is drawn from.
+var chartStreamKeys = map[string]string{
+	"altitude":  "altitude",
+	"heartrate": "heartrate",
+	"watts":     "watts",
+	"pace":      "velocity_smooth",
+}
+
+var activitiesChartCmd = &cobra.Command{
+	Use:   "chart [id]",
+	Short: "Draw an activity stream as a terminal chart",
+	Long: `Fetch one stream of an activity and draw it as a line chart in the terminal,
+plotted against distance (or elapsed time for activities without GPS).
+
+Metrics: altitude, heartrate, watts, pace
+Pace is drawn with faster at the top; stopped periods are left blank.
+
+Examples:
+  strava activities chart 12345
+  strava activities chart 12345 --metric heartrate
+  strava activities chart 12345 --metric pace --units imperial`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runActivitiesChart,
+
+	ValidArgsFunction: completeActivityIDs,
+}
+
+var activitiesMapCmd = &cobra.Command{
+	Use:   "map [id]",
+	Short: "Draw an activity's map in the terminal or the browser",
+	Long: `Draw an activity's GPS track as a braille minimap in the terminal, north
+up, with S at the start and E at the end. --html writes an interactive map
+page instead (Leaflet on OpenStreetMap tiles), and --open opens it in the
+browser. --map-out draws it on OpenStreetMap tiles into a 1200×800 PNG.
+
+Examples:
+  strava activities map 12345
+  strava activities map 12345 --open
+  strava activities map 12345 --map-out ride.png`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runActivitiesMap,
+
+	ValidArgsFunction: completeActivityIDs,
 }
 
 // ── update ────────────────────────────────────────────────────────────────────
@@ -116,6 +274,39 @@ Examples:
   strava activities update 12345 --commute --hide --dry-run`,
 	Args: cobra.ExactArgs(1),
 	RunE: runActivitiesUpdate,
+
+	ValidArgsFunction: completeActivityIDs,
+}
+
+// ── set-gear ──────────────────────────────────────────────────────────────────
+
+var (
+	setGearID     string
+	setGearAfter  string
+	setGearBefore string
+	setGearSports string
+	setGearFrom   string
+)
+
+var activitiesSetGearCmd = &cobra.Command{
+	Use:   "set-gear",
+	Short: "Set the gear on every activity matching a filter",
+	Long: `Find your activities matching --after, --before, --sport and --from-gear,
+and set --gear-id on each (use "none" to clear it). Activities already on
+that gear are left alone, so an interrupted run can simply be repeated.
+
+--dry-run lists the activities that would change without calling the
+API. Run interactively, each change is shown (its gear, old → new) and can
+be made or skipped, or the rest all made or skipped, before any update is
+sent; --yes makes them all without asking. Each update is reported as it
+is made.
+
+Examples:
+  strava activities set-gear --gear-id b123 --after 2024-06-01 --sport Ride --dry-run
+  strava activities set-gear --gear-id b123 --after 2024-06-01 --sport Ride --yes
+  strava activities set-gear --gear-id g456 --from-gear none --sport Run,TrailRun`,
+	Args: cobra.NoArgs,
+	RunE: runActivitiesSetGear,
 }
 
 // ── upload ────────────────────────────────────────────────────────────────────
@@ -128,6 +319,11 @@ var (
 	uploadTrainer     bool
 	uploadCommute     bool
 	uploadWait        bool
+	uploadExternalIDF string
+	uploadForce       bool
+	uploadNoGzip      bool
+	uploadDir         string
+	uploadRecursive   bool
 )
 
 var activitiesUploadCmd = &cobra.Command{
@@ -141,45 +337,110 @@ If --data-type is omitted it is inferred from the file extension.
 Use --wait to poll until Strava finishes processing and prints the new
 activity ID. Requires --yes to skip the interactive confirmation prompt.
 
+Uploads are idempotent: unless --external-id is given, one is derived from the
+file's contents. Before uploading, the local upload ledger and your recent
+activities are checked, and a file that is already on Strava is reported as
+"already uploaded as activity N" instead of being sent again. Use --force to
+upload anyway.
+
+GPX and TCX files of 1 MB or more are compressed before sending and
+uploaded as gpx.gz or tcx.gz, which is much quicker on a slow connection;
+--no-gzip sends them as they are.
+
+--dir uploads every activity file in a directory (with --recursive, in its
+subdirectories too): files in the ledger are skipped, the rest are sent one
+after another, and then each is polled until Strava has processed it. A
+table summarises the outcome per file. Interrupted runs can be repeated;
+uploads still processing are picked up where they were left.
+
+Indoor trainer workouts saved as ERG files are uploaded with "activities
+upload erg", which can merge in heart rate from a FIT file.
+
 Examples:
   strava activities upload --file morning.gpx --name "Morning Run" --yes --wait
-  strava activities upload --file workout.fit --trainer --yes`,
+  strava activities upload --file workout.fit --trainer --yes
+  strava activities upload --dir ./garmin-export --recursive --dry-run`,
 	RunE: runActivitiesUpload,
 }
 
 func init() {
 	rootCmd.AddCommand(activitiesCmd)
 	activitiesCmd.AddCommand(activitiesListCmd)
+	activitiesCmd.AddCommand(activitiesSearchCmd)
 	activitiesCmd.AddCommand(activitiesGetCmd)
 	activitiesCmd.AddCommand(activitiesLapsCmd)
+	activitiesCmd.AddCommand(activitiesSegmentsCmd)
 	activitiesCmd.AddCommand(activitiesZonesCmd)
 	activitiesCmd.AddCommand(activitiesCommentsCmd)
 	activitiesCmd.AddCommand(activitiesKudosCmd)
 	activitiesCmd.AddCommand(activitiesStreamsCmd)
 	activitiesCmd.AddCommand(activitiesUpdateCmd)
+	activitiesCmd.AddCommand(activitiesSetGearCmd)
 	activitiesCmd.AddCommand(activitiesUploadCmd)
+	activitiesCmd.AddCommand(activitiesChartCmd)
+	activitiesCmd.AddCommand(activitiesMapCmd)
 
 	activitiesListCmd.Flags().IntVar(&listBefore, "before", 0, "Unix timestamp: only activities before this time")
 	activitiesListCmd.Flags().IntVar(&listAfter, "after", 0, "Unix timestamp: only activities after this time")
-	activitiesListCmd.Flags().IntVar(&listPage, "page", 1, "Page number")
-	activitiesListCmd.Flags().IntVar(&listPerPage, "per-page", 30, "Activities per page (max 200)")
-
-	activitiesStreamsCmd.Flags().StringVar(&streamsKeys, "keys",
-		"time,distance,altitude,heartrate,cadence,watts,velocity_smooth",
-		"Comma-separated stream keys to fetch")
+	addPageFlags(activitiesListCmd, "activities", true)
+	activitiesListCmd.Flags().StringVar(&listSports, "sport", "", "Only these sport types, comma-separated (e.g. Run,Ride)")
+	activitiesListCmd.Flags().StringVar(&listMinDistance, "min-distance", "", "Only activities at least this long (e.g. 10km)")
+	activitiesListCmd.Flags().StringVar(&listMaxDistance, "max-distance", "", "Only activities at most this long (e.g. 5mi)")
+	activitiesListCmd.Flags().BoolVar(&listCommute, "commute", false, "Only commutes (--commute=false: only non-commutes)")
+	activitiesListCmd.Flags().StringVar(&listNameContains, "name-contains", "", "Only activities whose name contains this text (case-insensitive)")
+	activitiesListCmd.Flags().StringArrayVar(&listMeta, "meta", nil, "Only activities with this local field, as key=value or key (repeatable)")
+	activitiesListCmd.Flags().BoolVar(&listClassify, "classify", false, "Label sessions easy, tempo, threshold or vo2 by time in heart rate zones")
+	activitiesListCmd.RegisterFlagCompletionFunc("sport", completeSportTypes)
+	activitiesListCmd.RegisterFlagCompletionFunc("meta", completeMetaFilter)
+	addListFlags(activitiesListCmd, "activities")
+
+	activitiesSearchCmd.Flags().StringVar(&searchSports, "sport", "", "Only these sport types, comma-separated (e.g. Run,Ride)")
+	activitiesSearchCmd.Flags().StringArrayVar(&searchMeta, "meta", nil, "Only activities with this local field, as key=value or key (repeatable)")
+	activitiesSearchCmd.RegisterFlagCompletionFunc("sport", completeSportTypes)
+	activitiesSearchCmd.RegisterFlagCompletionFunc("meta", completeMetaFilter)
+	addListFlags(activitiesSearchCmd, "activities")
+
+	activitiesStreamsCmd.Flags().StringVar(&streamsKeys, "keys", "",
+		"Comma-separated stream keys to fetch, or \"all\" (default: suited to the activity's sport)")
+	activitiesStreamsCmd.Flags().StringVar(&streamsResolution, "resolution", "",
+		"Downsample to low (~100), medium (~1000) or high (~10000) points (default: every sample)")
+	activitiesStreamsCmd.Flags().StringVar(&streamsSeriesType, "series-type", "",
+		"Space downsampled points by time or distance (default: time)")
+	activitiesStreamsCmd.Flags().BoolVar(&streamsChunked, "chunked", false,
+		"Fetch one stream at a time, resumably (automatic when a full download fails)")
+
+	activitiesChartCmd.Flags().StringVar(&chartMetric, "metric", "altitude",
+		"Stream to chart: altitude, heartrate, watts or pace")
+
+	addMapFlags(activitiesMapCmd)
 
 	// update flags
 	activitiesUpdateCmd.Flags().StringVar(&updateName, "name", "", "New activity name")
 	activitiesUpdateCmd.Flags().StringVar(&updateDescription, "description", "", "New description")
 	activitiesUpdateCmd.Flags().StringVar(&updateType, "type", "", "Sport type (e.g. Run, Ride, Walk)")
+	activitiesUpdateCmd.RegisterFlagCompletionFunc("type", completeSportTypes)
 	activitiesUpdateCmd.Flags().StringVar(&updateGearID, "gear-id", "", "Gear ID (e.g. b12345678 or none)")
 	activitiesUpdateCmd.Flags().BoolVar(&updateCommute, "commute", false, "Mark/unmark as commute (e.g. --commute or --commute=false)")
 	activitiesUpdateCmd.Flags().BoolVar(&updateHide, "hide", false, "Hide/unhide from home feed")
 	activitiesUpdateCmd.Flags().Bool("yes", false, "Skip interactive confirmation")
 	activitiesUpdateCmd.Flags().Bool("dry-run", false, "Print what would change without calling the API")
 
+	activitiesSetGearCmd.Flags().StringVar(&setGearID, "gear-id", "", "Gear ID to set (e.g. b12345678, or none to clear)")
+	activitiesSetGearCmd.MarkFlagRequired("gear-id")
+	activitiesSetGearCmd.RegisterFlagCompletionFunc("gear-id", completeGearIDs)
+	activitiesSetGearCmd.Flags().StringVar(&setGearAfter, "after", "", "Only activities after this date (YYYY-MM-DD)")
+	activitiesSetGearCmd.Flags().StringVar(&setGearBefore, "before", "", "Only activities before this date (YYYY-MM-DD)")
+	activitiesSetGearCmd.Flags().StringVar(&setGearSports, "sport", "", "Only these sport types, comma-separated (e.g. Run,Ride)")
+	activitiesSetGearCmd.RegisterFlagCompletionFunc("sport", completeSportTypes)
+	activitiesSetGearCmd.Flags().StringVar(&setGearFrom, "from-gear", "", "Only activities currently on this gear (none for activities without gear)")
+	activitiesSetGearCmd.RegisterFlagCompletionFunc("from-gear", completeGearIDs)
+	activitiesSetGearCmd.Flags().Bool("yes", false, "Skip interactive confirmation")
+	activitiesSetGearCmd.Flags().Bool("dry-run", false, "List the activities that would change without calling the API")
+
 	// upload flags
-	activitiesUploadCmd.Flags().StringVar(&uploadFile, "file", "", "Path to activity file (required)")
+	activitiesUploadCmd.Flags().StringVar(&uploadFile, "file", "", "Path to activity file")
+	activitiesUploadCmd.Flags().StringVar(&uploadDir, "dir", "", "Upload every FIT, TCX and GPX file in this directory")
+	activitiesUploadCmd.Flags().BoolVar(&uploadRecursive, "recursive", false, "With --dir, include subdirectories")
 	activitiesUploadCmd.Flags().StringVar(&uploadDataType, "data-type", "",
 		"File type: fit, fit.gz, tcx, tcx.gz, gpx, gpx.gz (inferred from extension if omitted)")
 	activitiesUploadCmd.Flags().StringVar(&uploadName, "name", "", "Activity name")
@@ -187,40 +448,217 @@ func init() {
 	activitiesUploadCmd.Flags().BoolVar(&uploadTrainer, "trainer", false, "Mark as indoor trainer activity")
 	activitiesUploadCmd.Flags().BoolVar(&uploadCommute, "commute", false, "Mark as commute")
 	activitiesUploadCmd.Flags().BoolVar(&uploadWait, "wait", false, "Poll until Strava finishes processing")
+	activitiesUploadCmd.Flags().StringVar(&uploadExternalIDF, "external-id", "",
+		"Identifier for the upload (default: derived from the file contents)")
+	activitiesUploadCmd.Flags().BoolVar(&uploadForce, "force", false, "Upload even if the file was uploaded before")
+	activitiesUploadCmd.Flags().BoolVar(&uploadNoGzip, "no-gzip", false, "Send large GPX/TCX files uncompressed")
 	activitiesUploadCmd.Flags().Bool("yes", false, "Skip interactive confirmation")
 	activitiesUploadCmd.Flags().Bool("dry-run", false, "Print what would be uploaded without calling the API")
-	_ = activitiesUploadCmd.MarkFlagRequired("file")
+	activitiesUploadCmd.MarkFlagsOneRequired("file", "dir")
+	activitiesUploadCmd.MarkFlagsMutuallyExclusive("file", "dir")
 }
 
 // ── read handlers ─────────────────────────────────────────────────────────────
 
 func runActivitiesList(cmd *cobra.Command, args []string) error {
-	api, _, err := apiClient(cmd)
+	printer := newPrinter()
+	filter, err := listFilter(cmd, printer.Units)
 	if err != nil {
 		return err
 	}
-	params := &genclient.GetLoggedInAthleteActivitiesParams{
-		Page:    intPtr(listPage),
-		PerPage: intPtr(listPerPage),
+	api, _, err := apiClient(cmd)
+	if err != nil {
+		return err
 	}
+	params := &genclient.GetLoggedInAthleteActivitiesParams{}
 	if listBefore > 0 {
 		params.Before = intPtr(listBefore)
 	}
 	if listAfter > 0 {
 		params.After = intPtr(listAfter)
 	}
-	resp, err := api.GetLoggedInAthleteActivitiesWithResponse(cmd.Context(), params)
+	return runList(cmd, printer, listSource[genclient.GetLoggedInAthleteActivitiesResponse]{
+		what: "activities",
+		fetch: func(ctx context.Context, page, perPage int) (*http.Response, []byte, error) {
+			params := *params // pages may be fetched at once
+			params.Page, params.PerPage = &page, &perPage
+			resp, err := api.GetLoggedInAthleteActivitiesWithResponse(ctx, &params)
+			if err != nil {
+				return nil, nil, err
+			}
+			return resp.HTTPResponse, resp.Body, nil
+		},
+		filter: func(items []json.RawMessage) ([]json.RawMessage, error) {
+			items, err := withMeta(items)
+			if err != nil {
+				return nil, err
+			}
+			items, err = filterActivities(items, filter)
+			if err != nil || !listClassify {
+				return items, err
+			}
+			if len(printer.Columns) == 0 {
+				cols := output.ListDefaults["activities"]
+				printer.Columns = append(slices.Clone(cols[:len(cols)-1]), "class", cols[len(cols)-1])
+			}
+			return classifyItems(cmd, api, items)
+		},
+		print: (*output.Printer).Activities,
+	})
+}
+
+func runActivitiesSearch(cmd *cobra.Command, args []string) error {
+	query := strings.Join(args, " ")
+	var filter analysis.Filter
+	if searchSports != "" {
+		filter.Sports = strings.Split(searchSports, ",")
+	}
+	meta, err := parseMetaFilter(searchMeta)
+	if err != nil {
+		return err
+	}
+	filter.Meta = meta
+	hist, err := store.OpenActivities("")
+	if err != nil {
+		return err
+	}
+	var items []json.RawMessage
+	if hist.Len() > 0 {
+		if items, err = hist.List(); err != nil {
+			return err
+		}
+	} else {
+		fmt.Fprintln(os.Stderr, "No synced history: searching names through the API (run strava sync to search offline)")
+		api, _, err := apiClient(cmd)
+		if err != nil {
+			return err
+		}
+		_, err = fetchPages(cmd.Context(), 1, historyPageSize, func(ctx context.Context, page int) ([]json.RawMessage, error) {
+			return fetchActivityPage(ctx, api, &genclient.GetLoggedInAthleteActivitiesParams{
+				Page: intPtr(page), PerPage: intPtr(historyPageSize)})
+		}, func(_ int, batch []json.RawMessage) error {
+			items = append(items, batch...)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if items, err = withMeta(items); err != nil {
+		return err
+	}
+	var matches []json.RawMessage
+	for _, raw := range items {
+		var a analysis.Activity
+		if err := json.Unmarshal(raw, &a); err != nil {
+			return fmt.Errorf("parse activity: %w", err)
+		}
+		desc, _ := hist.Description(a.ID)
+		if filter.Match(a) && analysis.MatchText(query, a.Name, desc) {
+			matches = append(matches, raw)
+		}
+	}
+	return printActivityItems(newPrinter(), matches)
+}
+
+// classifyItems sets "class" on raw summary activities, as classifyActivities
+// labels them.
+func classifyItems(cmd *cobra.Command, api *genclient.ClientWithResponses, items []json.RawMessage) ([]json.RawMessage, error) {
+	acts := make([]analysis.Activity, len(items))
+	for i, raw := range items {
+		if err := json.Unmarshal(raw, &acts[i]); err != nil {
+			return nil, fmt.Errorf("parse activity: %w", err)
+		}
+	}
+	classes, err := classifyActivities(cmd, api, acts)
+	if err != nil {
+		return nil, err
+	}
+	for i, a := range acts {
+		if c, ok := classes[a.ID]; ok && c.Class != "" {
+			if items[i], err = setJSONField(items[i], "class", c.Class); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return items, nil
+}
+
+// printActivityItems prints raw summary activities as the activities list
+// does, handing the printer a response as if the API had returned just these.
+func printActivityItems(printer *output.Printer, items []json.RawMessage) error {
+	resp, err := listResponse[genclient.GetLoggedInAthleteActivitiesResponse](items)
 	if err != nil {
-		return fmt.Errorf("fetch activities: %w", err)
+		return fmt.Errorf("parse activities: %w", err)
+	}
+	return printer.Activities(resp)
+}
+
+// fetchActivityPage fetches one page of /athlete/activities as raw JSON items.
+func fetchActivityPage(ctx context.Context, api *genclient.ClientWithResponses, params *genclient.GetLoggedInAthleteActivitiesParams) ([]json.RawMessage, error) {
+	resp, err := api.GetLoggedInAthleteActivitiesWithResponse(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("fetch activities (page %d): %w", *params.Page, err)
 	}
 	if resp.HTTPResponse.StatusCode != 200 {
-		return apiError(resp.HTTPResponse.StatusCode, resp.Body)
+		return nil, fmt.Errorf("fetch activities (page %d): %w", *params.Page,
+			apiError(resp.HTTPResponse.StatusCode, resp.Body))
+	}
+	var batch []json.RawMessage
+	if err := json.Unmarshal(resp.Body, &batch); err != nil {
+		return nil, fmt.Errorf("parse activities (page %d): %w", *params.Page, err)
 	}
-	return output.New(os.Stdout, jsonOutput).Activities(resp)
+	return batch, nil
+}
+
+// listFilter builds the client-side filter from the activities list flags.
+func listFilter(cmd *cobra.Command, units output.Units) (analysis.Filter, error) {
+	f := analysis.Filter{NameContains: listNameContains}
+	var err error
+	if f.Meta, err = parseMetaFilter(listMeta); err != nil {
+		return f, err
+	}
+	if listSports != "" {
+		f.Sports = strings.Split(listSports, ",")
+	}
+	if cmd.Flags().Changed("commute") {
+		f.Commute = boolPtr(listCommute)
+	}
+	if listMinDistance != "" {
+		if f.MinDistance, err = output.ParseDistance(listMinDistance, units); err != nil {
+			return f, fmt.Errorf("--min-distance: %w", err)
+		}
+	}
+	if listMaxDistance != "" {
+		if f.MaxDistance, err = output.ParseDistance(listMaxDistance, units); err != nil {
+			return f, fmt.Errorf("--max-distance: %w", err)
+		}
+	}
+	return f, nil
+}
+
+// filterActivities keeps the raw activities matching f, leaving their JSON
+// untouched so --json output still carries every field.
+func filterActivities(items []json.RawMessage, f analysis.Filter) ([]json.RawMessage, error) {
+	if f.IsZero() {
+		return items, nil
+	}
+	var kept []json.RawMessage
+	for _, raw := range items {
+		var a analysis.Activity
+		if err := json.Unmarshal(raw, &a); err != nil {
+			return nil, fmt.Errorf("parse activity: %w", err)
+		}
+		if f.Match(a) {
+			kept = append(kept, raw)
+		}
+	}
+	return kept, nil
 }
 
 func runActivitiesGet(cmd *cobra.Command, args []string) error {
-	id, err := parseID(args[0])
+	id, err := activityIDArg(cmd, args)
 	if err != nil {
 		return err
 	}
@@ -236,11 +674,11 @@ func runActivitiesGet(cmd *cobra.Command, args []string) error {
 	if resp.HTTPResponse.StatusCode != 200 {
 		return apiError(resp.HTTPResponse.StatusCode, resp.Body)
 	}
-	return output.New(os.Stdout, jsonOutput).Activity(resp)
+	return newPrinter().Activity(resp)
 }
 
 func runActivitiesLaps(cmd *cobra.Command, args []string) error {
-	id, err := parseID(args[0])
+	id, err := activityIDArg(cmd, args)
 	if err != nil {
 		return err
 	}
@@ -255,11 +693,13 @@ func runActivitiesLaps(cmd *cobra.Command, args []string) error {
 	if resp.HTTPResponse.StatusCode != 200 {
 		return apiError(resp.HTTPResponse.StatusCode, resp.Body)
 	}
-	return output.New(os.Stdout, jsonOutput).Laps(resp)
+	// Without the sport, which laps do not carry, they show speeds.
+	sport, _ := activitySport(cmd.Context(), api, id)
+	return newPrinter().Laps(resp, sport)
 }
 
-func runActivitiesZones(cmd *cobra.Command, args []string) error {
-	id, err := parseID(args[0])
+func runActivitiesSegments(cmd *cobra.Command, args []string) error {
+	id, err := activityIDArg(cmd, args)
 	if err != nil {
 		return err
 	}
@@ -267,6 +707,29 @@ func runActivitiesZones(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	resp, err := api.GetActivityByIdWithResponse(cmd.Context(), id,
+		&genclient.GetActivityByIdParams{IncludeAllEfforts: boolPtr(true)})
+	if err != nil {
+		return fmt.Errorf("fetch activity: %w", err)
+	}
+	if resp.HTTPResponse.StatusCode != 200 {
+		return apiError(resp.HTTPResponse.StatusCode, resp.Body)
+	}
+	return newPrinter().ActivitySegments(resp)
+}
+
+func runActivitiesZones(cmd *cobra.Command, args []string) error {
+	id, err := activityIDArg(cmd, args)
+	if err != nil {
+		return err
+	}
+	api, cfg, err := apiClient(cmd)
+	if err != nil {
+		return err
+	}
+	if err := precheckFeature(cfg, featureActivityZones); err != nil {
+		return err
+	}
 	resp, err := api.GetZonesByActivityIdWithResponse(cmd.Context(), id)
 	if err != nil {
 		return fmt.Errorf("fetch zones: %w", err)
@@ -274,11 +737,11 @@ func runActivitiesZones(cmd *cobra.Command, args []string) error {
 	if resp.HTTPResponse.StatusCode != 200 {
 		return apiError(resp.HTTPResponse.StatusCode, resp.Body)
 	}
-	return output.New(os.Stdout, jsonOutput).ActivityZones(resp)
+	return newPrinter().ActivityZones(resp)
 }
 
 func runActivitiesComments(cmd *cobra.Command, args []string) error {
-	id, err := parseID(args[0])
+	id, err := activityIDArg(cmd, args)
 	if err != nil {
 		return err
 	}
@@ -294,11 +757,11 @@ func runActivitiesComments(cmd *cobra.Command, args []string) error {
 	if resp.HTTPResponse.StatusCode != 200 {
 		return apiError(resp.HTTPResponse.StatusCode, resp.Body)
 	}
-	return output.New(os.Stdout, jsonOutput).Comments(resp)
+	return newPrinter().Comments(resp)
 }
 
 func runActivitiesKudos(cmd *cobra.Command, args []string) error {
-	id, err := parseID(args[0])
+	id, err := activityIDArg(cmd, args)
 	if err != nil {
 		return err
 	}
@@ -314,36 +777,182 @@ func runActivitiesKudos(cmd *cobra.Command, args []string) error {
 	if resp.HTTPResponse.StatusCode != 200 {
 		return apiError(resp.HTTPResponse.StatusCode, resp.Body)
 	}
-	return output.New(os.Stdout, jsonOutput).Kudos(resp)
+	return newPrinter().Kudos(resp)
 }
 
 func runActivitiesStreams(cmd *cobra.Command, args []string) error {
-	id, err := parseID(args[0])
+	sampling, err := streamSampling(streamsResolution, streamsSeriesType)
+	if err != nil {
+		return err
+	}
+	id, err := activityIDArg(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	api, _, err := apiClient(cmd)
 	if err != nil {
 		return err
 	}
 
-	keys := []genclient.GetActivityStreamsParamsKeys{}
-	for _, k := range strings.Split(streamsKeys, ",") {
-		k = strings.TrimSpace(k)
-		if k != "" {
-			keys = append(keys, genclient.GetActivityStreamsParamsKeys(k))
+	var names []string
+	switch strings.TrimSpace(streamsKeys) {
+	case "":
+		sport, err := activitySport(cmd.Context(), api, id)
+		if err != nil {
+			return err
+		}
+		names = analysis.DefaultStreamKeys(sport)
+	case "all":
+		names = analysis.StreamKeys
+	default:
+		names = strings.Split(streamsKeys, ",")
+	}
+	var keys []string
+	for _, k := range names {
+		if k = strings.TrimSpace(k); k != "" {
+			keys = append(keys, k)
 		}
 	}
 
+	if streamsResolution == "" && streamsSeriesType == "" {
+		body, err := streamsBody(cmd, api, id, keys, streamsChunked)
+		if err != nil {
+			return err
+		}
+		resp := &genclient.GetActivityStreamsResponse{Body: body}
+		if err := json.Unmarshal(body, &resp.JSON200); err != nil {
+			return fmt.Errorf("parse streams: %w", err)
+		}
+		return newPrinter().Streams(resp)
+	}
+	// Downsampled responses are small: one request.
+	params := &genclient.GetActivityStreamsParams{KeyByType: true}
+	for _, k := range keys {
+		params.Keys = append(params.Keys, genclient.GetActivityStreamsParamsKeys(k))
+	}
+	resp, err := api.GetActivityStreamsWithResponse(cmd.Context(), id, params, sampling)
+	if err != nil {
+		return fmt.Errorf("fetch streams: %w", err)
+	}
+	if resp.HTTPResponse.StatusCode != 200 {
+		return apiError(resp.HTTPResponse.StatusCode, resp.Body)
+	}
+	return newPrinter().Streams(resp)
+}
+
+// activitySport returns an activity's sport type: from the synced history
+// when it is there, else from the API.
+func activitySport(ctx context.Context, api *genclient.ClientWithResponses, id int64) (string, error) {
+	if hist, err := store.OpenActivities(""); err == nil {
+		var a struct {
+			SportType string `json:"sport_type"`
+		}
+		if raw, ok := hist.Get(id); ok && json.Unmarshal(raw, &a) == nil && a.SportType != "" {
+			return a.SportType, nil
+		}
+	}
+	resp, err := api.GetActivityByIdWithResponse(ctx, id,
+		&genclient.GetActivityByIdParams{IncludeAllEfforts: boolPtr(false)})
+	if err != nil {
+		return "", fmt.Errorf("fetch activity: %w", err)
+	}
+	if resp.HTTPResponse.StatusCode != 200 {
+		return "", apiError(resp.HTTPResponse.StatusCode, resp.Body)
+	}
+	if resp.JSON200 == nil || resp.JSON200.SportType == nil {
+		return "", nil
+	}
+	return string(*resp.JSON200.SportType), nil
+}
+
+func runActivitiesMap(cmd *cobra.Command, args []string) error {
+	id, err := activityIDArg(cmd, args)
+	if err != nil {
+		return err
+	}
 	api, _, err := apiClient(cmd)
 	if err != nil {
 		return err
 	}
-	resp, err := api.GetActivityStreamsWithResponse(cmd.Context(), id,
-		&genclient.GetActivityStreamsParams{Keys: keys, KeyByType: true})
+	resp, err := api.GetActivityByIdWithResponse(cmd.Context(), id,
+		&genclient.GetActivityByIdParams{IncludeAllEfforts: boolPtr(false)})
 	if err != nil {
-		return fmt.Errorf("fetch streams: %w", err)
+		return fmt.Errorf("fetch activity: %w", err)
 	}
 	if resp.HTTPResponse.StatusCode != 200 {
 		return apiError(resp.HTTPResponse.StatusCode, resp.Body)
 	}
-	return output.New(os.Stdout, jsonOutput).Streams(resp)
+	a := resp.JSON200
+	if a == nil {
+		return fmt.Errorf("unexpected empty response")
+	}
+	m := output.TrackMap{Name: derefStr(a.Name)}
+	if a.Distance != nil {
+		m.Distance = float64(*a.Distance)
+	}
+	var polyline string
+	if a.Map != nil {
+		polyline = cmp.Or(derefStr(a.Map.Polyline), derefStr(a.Map.SummaryPolyline))
+	}
+	return showMap(cmd.Context(), fmt.Sprintf("activity-%d", id), polyline, m)
+}
+
+func runActivitiesChart(cmd *cobra.Command, args []string) error {
+	id, err := activityIDArg(cmd, args)
+	if err != nil {
+		return err
+	}
+	key, ok := chartStreamKeys[chartMetric]
+	if !ok {
+		return fmt.Errorf("invalid --metric %q: must be altitude, heartrate, watts or pace", chartMetric)
+	}
+
+	api, _, err := apiClient(cmd)
+	if err != nil {
+		return err
+	}
+	s, err := fetchStreams(cmd, api, id, key, "distance", "time")
+	if err != nil {
+		return err
+	}
+
+	series := chartSeries(chartMetric, s)
+	if len(series.Values) == 0 {
+		return fmt.Errorf("activity %d has no %s stream", id, key)
+	}
+	return newPrinter().Chart(fmt.Sprintf("Activity %d — %s", id, chartMetric), series)
+}
+
+// chartSeries picks the values for metric out of s, plotted against distance
+// when the activity has one and against time otherwise.
+func chartSeries(metric string, s *analysis.Streams) output.Series {
+	series := output.Series{Metric: metric, Distance: s.Distance, Time: s.Time}
+	switch metric {
+	case "altitude":
+		series.Values = s.Altitude
+	case "pace":
+		series.Values = s.VelocitySmooth
+	case "heartrate":
+		series.Values = intsToFloats(s.Heartrate)
+	case "watts":
+		series.Values = intsToFloats(s.Watts)
+	}
+	if len(series.Distance) > 0 {
+		series.Time = nil
+	}
+	return series
+}
+
+func intsToFloats(in []int) []float64 {
+	if in == nil {
+		return nil
+	}
+	out := make([]float64, len(in))
+	for i, v := range in {
+		out[i] = float64(v)
+	}
+	return out
 }
 
 // ── write handlers ────────────────────────────────────────────────────────────
@@ -401,22 +1010,9 @@ func runActivitiesUpdate(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	url := fmt.Sprintf("https://www.strava.com/api/v3/activities/%d", id)
-	req, err := http.NewRequestWithContext(cmd.Context(), http.MethodPut, url, bytes.NewReader(bodyBytes))
+	respBody, err := putActivity(cmd.Context(), httpClient, id, bodyBytes)
 	if err != nil {
-		return fmt.Errorf("build request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("update activity: %w", err)
-	}
-	defer resp.Body.Close()
-
-	respBody, _ := io.ReadAll(resp.Body)
-	if resp.StatusCode != http.StatusOK {
-		return apiError(resp.StatusCode, respBody)
+		return err
 	}
 
 	if jsonOutput {
@@ -437,26 +1033,179 @@ func runActivitiesUpdate(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func runActivitiesSetGear(cmd *cobra.Command, args []string) error {
+	after, err := parseDate("after", setGearAfter)
+	if err != nil {
+		return err
+	}
+	before, err := parseDate("before", setGearBefore)
+	if err != nil {
+		return err
+	}
+	target := strings.TrimSpace(setGearID)
+	if target == "" {
+		return fmt.Errorf("--gear-id is empty; pass a gear ID, or none to clear the gear")
+	}
+	gear, err := athleteGear(cmd)
+	if err != nil {
+		return err
+	}
+	if target != "none" && !slices.ContainsFunc(gear, func(g analysis.Gear) bool { return g.ID == target }) {
+		return fmt.Errorf("no gear %s in your profile (see: strava gear list)", target)
+	}
+	stored := target
+	if target == "none" {
+		stored = ""
+	}
+	var sports []string
+	for _, s := range strings.Split(setGearSports, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			sports = append(sports, s)
+		}
+	}
+	from := strings.TrimSpace(setGearFrom)
+	if from == "none" {
+		from = ""
+	}
+
+	api, _, err := apiClient(cmd)
+	if err != nil {
+		return err
+	}
+	var items []json.RawMessage
+	var acts []analysis.Activity
+	_, err = fetchPages(cmd.Context(), 1, historyPageSize, func(ctx context.Context, page int) ([]json.RawMessage, error) {
+		params := &genclient.GetLoggedInAthleteActivitiesParams{Page: intPtr(page), PerPage: intPtr(historyPageSize)}
+		if !after.IsZero() {
+			params.After = intPtr(int(after.Unix()))
+		}
+		if !before.IsZero() {
+			params.Before = intPtr(int(before.Unix()))
+		}
+		return fetchActivityPage(ctx, api, params)
+	}, func(_ int, batch []json.RawMessage) error {
+		for _, raw := range batch {
+			var a analysis.Activity
+			if err := json.Unmarshal(raw, &a); err != nil {
+				return fmt.Errorf("parse activity: %w", err)
+			}
+			if len(sports) > 0 && !slices.ContainsFunc(sports, func(s string) bool { return strings.EqualFold(s, a.SportType) }) {
+				continue
+			}
+			if cmd.Flags().Changed("from-gear") && a.GearID != from {
+				continue
+			}
+			if a.GearID == target || (target == "none" && a.GearID == "") {
+				continue
+			}
+			items = append(items, raw)
+			acts = append(acts, a)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	printer := newPrinter()
+	if len(acts) == 0 {
+		fmt.Fprintln(os.Stderr, "No matching activities need changing.")
+		if jsonOutput || templateFlag != "" {
+			return printActivityItems(printer, nil)
+		}
+		return nil
+	}
+	desc := fmt.Sprintf("set gear_id=%s on %d activities", target, len(acts))
+	if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+		if err := printActivityItems(printer, items); err != nil {
+			return err
+		}
+	}
+	gearLabel := func(id string) string {
+		if id == "" {
+			return "none"
+		}
+		for _, g := range gear {
+			if g.ID == id {
+				return fmt.Sprintf("%s (%s)", id, g.Name)
+			}
+		}
+		return id
+	}
+	changes := make([]change, len(acts))
+	for i, a := range acts {
+		changes[i] = change{ID: a.ID, Name: a.Name, Date: a.StartDateLocal,
+			Fields: []fieldChange{{Field: "gear", Old: gearLabel(a.GearID), New: gearLabel(stored)}}}
+	}
+	picked, err := reviewMutation(cmd, desc, changes)
+	if err != nil || len(picked) == 0 {
+		return err
+	}
+
+	httpClient, _, err := rawClient(cmd)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(map[string]string{"gear_id": target})
+	if err != nil {
+		return fmt.Errorf("marshal body: %w", err)
+	}
+	hist, err := store.OpenActivities("")
+	if err != nil {
+		return err
+	}
+	updated := 0
+	for n, i := range picked {
+		a := acts[i]
+		fmt.Fprintf(os.Stderr, "[%d/%d] %d %s\n", n+1, len(picked), a.ID, a.Name)
+		if _, err := putActivity(cmd.Context(), httpClient, a.ID, body); err != nil {
+			if hist.Len() > 0 {
+				_ = hist.Save()
+			}
+			return fmt.Errorf("set gear on activity %d after %d of %d updated: %w; run the same command again to continue",
+				a.ID, updated, len(picked), err)
+		}
+		updated++
+		// Keep the synced history in step, so gear reports see the change.
+		if hist.Has(a.ID) {
+			if raw, err := setJSONField(items[i], "gear_id", stored); err == nil {
+				_, _, _ = hist.Put(raw)
+			}
+		}
+	}
+	if hist.Len() > 0 {
+		if err := hist.Save(); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(os.Stdout, "Set gear %s on %d activities\n", target, updated)
+	return nil
+}
+
+// setJSONField returns the JSON object raw with key set to value.
+func setJSONField(raw json.RawMessage, key string, value any) (json.RawMessage, error) {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return nil, err
+	}
+	v, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	obj[key] = v
+	return json.Marshal(obj)
+}
+
 func runActivitiesUpload(cmd *cobra.Command, args []string) error {
+	if uploadDir != "" {
+		return runUploadDir(cmd)
+	}
 	// Infer data_type from file extension if not specified.
 	dt := uploadDataType
 	if dt == "" {
-		base := strings.ToLower(uploadFile)
-		switch {
-		case strings.HasSuffix(base, ".fit.gz"):
-			dt = "fit.gz"
-		case strings.HasSuffix(base, ".tcx.gz"):
-			dt = "tcx.gz"
-		case strings.HasSuffix(base, ".gpx.gz"):
-			dt = "gpx.gz"
-		case strings.HasSuffix(base, ".fit"):
-			dt = "fit"
-		case strings.HasSuffix(base, ".tcx"):
-			dt = "tcx"
-		case strings.HasSuffix(base, ".gpx"):
-			dt = "gpx"
-		default:
-			return fmt.Errorf("cannot infer --data-type from %q; specify it explicitly", uploadFile)
+		var err error
+		if dt, err = inferDataType(uploadFile); err != nil {
+			return fmt.Errorf("%w; specify --data-type explicitly", err)
 		}
 	}
 
@@ -471,39 +1220,17 @@ func runActivitiesUpload(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	// Open file only after confirmation so dry-run doesn't need a real file.
-	f, err := os.Open(uploadFile)
+	hash, err := store.HashFile(uploadFile)
 	if err != nil {
 		return fmt.Errorf("open file: %w", err)
 	}
-	defer f.Close()
-
-	// Build multipart form.
-	var buf bytes.Buffer
-	mw := multipart.NewWriter(&buf)
-
-	part, err := mw.CreateFormFile("file", filepath.Base(uploadFile))
-	if err != nil {
-		return fmt.Errorf("create form file: %w", err)
-	}
-	if _, err := io.Copy(part, f); err != nil {
-		return fmt.Errorf("read file: %w", err)
+	externalID := uploadExternalIDF
+	if externalID == "" {
+		externalID = uploadExternalID(hash)
 	}
-	_ = mw.WriteField("data_type", dt)
-	if uploadName != "" {
-		_ = mw.WriteField("name", uploadName)
-	}
-	if uploadDescription != "" {
-		_ = mw.WriteField("description", uploadDescription)
-	}
-	if uploadTrainer {
-		_ = mw.WriteField("trainer", "1")
-	}
-	if uploadCommute {
-		_ = mw.WriteField("commute", "1")
-	}
-	if err := mw.Close(); err != nil {
-		return fmt.Errorf("close multipart writer: %w", err)
+	ledger, err := store.OpenLedger("")
+	if err != nil {
+		return err
 	}
 
 	httpClient, _, err := rawClient(cmd)
@@ -511,28 +1238,75 @@ func runActivitiesUpload(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	// Use *bytes.Buffer so http.NewRequestWithContext sets GetBody for safe retries.
-	req, err := http.NewRequestWithContext(cmd.Context(), http.MethodPost,
-		"https://www.strava.com/api/v3/uploads", &buf)
-	if err != nil {
-		return fmt.Errorf("build request: %w", err)
+	if !uploadForce {
+		e, found, err := findExistingUpload(cmd.Context(), httpClient, ledger, uploadFile, hash, externalID)
+		if err != nil {
+			return err
+		}
+		if found {
+			return reportExistingUpload(e)
+		}
 	}
-	req.Header.Set("Content-Type", mw.FormDataContentType())
 
-	resp, err := httpClient.Do(req)
+	abs, err := filepath.Abs(uploadFile)
 	if err != nil {
-		return fmt.Errorf("upload: %w", err)
+		return err
 	}
-	defer resp.Body.Close()
-
-	respBody, _ := io.ReadAll(resp.Body)
-	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
-		return apiError(resp.StatusCode, respBody)
+	queue, err := store.OpenUploadQueue("")
+	if err != nil {
+		return err
+	}
+	item := store.QueuedUpload{
+		Path:        abs,
+		Hash:        hash,
+		DataType:    dt,
+		Name:        uploadName,
+		Description: uploadDescription,
+		Trainer:     uploadTrainer,
+		Commute:     uploadCommute,
+		ExternalID:  externalID,
+		NoGzip:      uploadNoGzip,
+		Attempts:    1,
+	}
+	if err := queue.Put(item); err != nil {
+		return err
 	}
 
-	var u uploadStatus
-	if err := json.Unmarshal(respBody, &u); err != nil {
-		return fmt.Errorf("parse response: %w", err)
+	u, respBody, err := postUpload(cmd.Context(), httpClient, uploadRequest{
+		Path:        uploadFile,
+		DataType:    dt,
+		Name:        uploadName,
+		Description: uploadDescription,
+		Trainer:     uploadTrainer,
+		Commute:     uploadCommute,
+		ExternalID:  externalID,
+		Compress:    !uploadNoGzip && gzipWorthwhile(uploadFile, dt),
+		Progress:    uploadProgress(filepath.Base(uploadFile)),
+	})
+	if err != nil {
+		item.LastError = err.Error()
+		if qerr := queue.Put(item); qerr != nil {
+			return qerr
+		}
+		fmt.Fprintln(os.Stderr, "The file stays queued; retry with: strava uploads resume")
+		return err
+	}
+	entry := ledgerEntryFor(uploadFile, u)
+	if err := ledger.Record(hash, entry); err != nil {
+		return err
+	}
+	// Until Strava has processed it, resume can wait for it.
+	if u.Error == nil && u.ActivityID == nil {
+		item.UploadID = u.ID
+		err = queue.Put(item)
+	} else {
+		err = queue.Remove(hash)
+	}
+	if err != nil {
+		return err
+	}
+	if u.Error != nil && entry.ActivityID != 0 {
+		return reportExistingUpload(entry)
 	}
 
 	if jsonOutput {
@@ -548,11 +1322,119 @@ func runActivitiesUpload(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	return pollUpload(cmd, httpClient, u.ID)
+	u, err = pollUpload(cmd, httpClient, u.ID)
+	if u.ID != 0 {
+		e := ledgerEntryFor(uploadFile, u)
+		if rerr := ledger.Record(hash, e); rerr != nil && err == nil {
+			err = rerr
+		}
+		if rerr := queue.Remove(hash); rerr != nil && err == nil {
+			err = rerr
+		}
+		if u.Error != nil && e.ActivityID != 0 {
+			return fmt.Errorf("already uploaded as activity %d", e.ActivityID)
+		}
+	}
+	return err
+}
+
+// reportExistingUpload tells the user a file is already on Strava instead of
+// uploading it again.
+func reportExistingUpload(e store.LedgerEntry) error {
+	if jsonOutput {
+		data, _ := json.Marshal(map[string]any{"already_uploaded": true, "activity_id": e.ActivityID, "upload_id": e.UploadID})
+		fmt.Fprintln(os.Stdout, string(data))
+		return nil
+	}
+	if e.ActivityID == 0 {
+		fmt.Fprintf(os.Stdout, "Already submitted as upload %d (still processing); check with: strava uploads get %d\n",
+			e.UploadID, e.UploadID)
+		return nil
+	}
+	fmt.Fprintf(os.Stdout, "Already uploaded as activity %d (use --force to upload again)\n", e.ActivityID)
+	return nil
 }
 
 // ── helpers ───────────────────────────────────────────────────────────────────
 
+// putActivity sends a JSON-encoded UpdatableActivity to PUT /activities/{id} and
+// returns the raw response body.
+func putActivity(ctx context.Context, httpClient *http.Client, id int64, body []byte) ([]byte, error) {
+	url := fmt.Sprintf("https://www.strava.com/api/v3/activities/%d", id)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("update activity: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, apiError(resp.StatusCode, respBody)
+	}
+	return respBody, nil
+}
+
+// activityIDArg returns the activity ID given as the first argument. Without
+// one, it lets the user pick from their latest activities when running
+// interactively.
+func activityIDArg(cmd *cobra.Command, args []string) (int64, error) {
+	if len(args) > 0 {
+		return parseID(args[0])
+	}
+	if err := requireInteractive("choosing an activity",
+		fmt.Sprintf("pass the activity ID, e.g. %s 12345", cmd.CommandPath())); err != nil {
+		return 0, err
+	}
+	acts, err := recentActivities(cmd.Context(), cmd, pickerActivities)
+	if err != nil {
+		return 0, err
+	}
+	if len(acts) == 0 {
+		return 0, fmt.Errorf("no activities to choose from")
+	}
+
+	printer := newPrinter()
+	rows := make([]string, len(acts))
+	for i, a := range acts {
+		rows[i] = printer.ActivityRow(a)
+	}
+	i, err := tui.Pick(cmd.Context(), "Activity:", rows)
+	if errors.Is(err, tui.ErrCanceled) {
+		return 0, fmt.Errorf("no activity selected")
+	}
+	if err != nil {
+		return 0, err
+	}
+	return acts[i].ID, nil
+}
+
+// recentActivities fetches the athlete's n most recent activities.
+func recentActivities(ctx context.Context, cmd *cobra.Command, n int) ([]analysis.Activity, error) {
+	api, _, err := apiClient(cmd)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := api.GetLoggedInAthleteActivitiesWithResponse(ctx,
+		&genclient.GetLoggedInAthleteActivitiesParams{PerPage: intPtr(n)})
+	if err != nil {
+		return nil, fmt.Errorf("fetch activities: %w", err)
+	}
+	if resp.HTTPResponse.StatusCode != 200 {
+		return nil, apiError(resp.HTTPResponse.StatusCode, resp.Body)
+	}
+	var acts []analysis.Activity
+	if err := json.Unmarshal(resp.Body, &acts); err != nil {
+		return nil, fmt.Errorf("parse activities: %w", err)
+	}
+	return acts, nil
+}
+
 func parseID(s string) (int64, error) {
 	id, err := strconv.ParseInt(s, 10, 64)
 	if err != nil {
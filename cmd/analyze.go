@@ -0,0 +1,666 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/analysis"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/dem"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/output"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/geo"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/store"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/weather"
+)
+
+var analyzeCmd = &cobra.Command{
+	Use:   "analyze",
+	Short: "Analytics computed from your activity history",
+}
+
+var (
+	courseAfter     string
+	courseRadius    float64
+	courseTolerance float64
+	courseMax       int
+)
+
+var analyzeCourseCmd = &cobra.Command{
+	Use:   "course <route-id>",
+	Short: "Compare your activities on a route, split by split",
+	Long: `Find your activities that follow a route and compare them kilometer by
+kilometer — a personal course leaderboard.
+
+An activity matches when it starts and ends within --radius meters of the
+route's start and end and its distance is within --tolerance of the route's.
+The streams of each match are aligned by distance, and the output ranks the
+efforts and shows the best, worst and average time for every kilometer.
+
+Examples:
+  strava analyze course 12345678
+  strava analyze course 12345678 --after 2024-01-01 --max 10`,
+	Args: cobra.ExactArgs(1),
+	RunE: runAnalyzeCourse,
+}
+
+var (
+	weatherPeriod string
+	weatherSport  string
+	weatherAfter  string
+	weatherBefore string
+)
+
+var analyzeWeatherCmd = &cobra.Command{
+	Use:   "weather-trends",
+	Short: "Pace and power trends adjusted for temperature and wind",
+	Long: `Show average speed (pace for runs and walks) and power per period, both as
+recorded and adjusted to neutral weather, so that summer heat or a windy
+month does not look like lost fitness.
+
+Weather at each activity's start is looked up from the Open-Meteo historical
+archive (no API key needed) and cached in ~/.config/strava-cli/weather.json,
+so only new activities are looked up on later runs. Indoor activities, those
+without GPS and those from the last few days (not yet in the archive) are
+counted unadjusted.
+
+The adjustment is a rule of thumb: 0.4% per °C above 15°C, 0.2% per °C
+below 5°C, plus a wind penalty that grows with the square of wind speed
+(larger on a bike), each capped at 30%. Wind does not adjust power.
+
+Use --sport: mixing sports makes average speed meaningless.
+
+Examples:
+  strava analyze weather-trends --sport Run
+  strava analyze weather-trends --sport Ride --period week --after 2024-03-01`,
+	Args: cobra.NoArgs,
+	RunE: runAnalyzeWeather,
+}
+
+var (
+	elevAfter     string
+	elevBefore    string
+	elevSport     string
+	elevDataset   string
+	elevTolerance float64
+	elevMinDiff   float64
+	elevAll       bool
+)
+
+var analyzeElevationCmd = &cobra.Command{
+	Use:   "elevation-audit",
+	Short: "Check reported elevation gain against a terrain model",
+	Long: `Recompute each activity's elevation gain from a digital elevation model
+(DEM) along its route and flag activities whose reported gain is far off —
+typically a clogged or failing barometric altimeter — then total the
+climbing per year, as reported and with outliers replaced by the DEM value.
+
+An activity is an outlier when its reported gain differs from the DEM gain
+by more than --tolerance (a fraction of the DEM gain) and by more than
+--min-diff meters. The DEM sees the terrain, not bridges or tunnels, and is
+sampled along the summary polyline, so expect some disagreement everywhere:
+the audit is for finding gross errors, not correcting every meter.
+
+Heights come from the Open Topo Data API (no API key needed), which allows
+one request per second; the first run over a long history takes a while.
+Results are cached per dataset in ~/.config/strava-cli/elevation-<dataset>.json.
+Indoor, manual and GPS-less activities are skipped.
+
+Examples:
+  strava analyze elevation-audit --after 2025-01-01
+  strava analyze elevation-audit --sport Ride --dataset eudem25m
+  strava analyze elevation-audit --all --json`,
+	Args: cobra.NoArgs,
+	RunE: runAnalyzeElevation,
+}
+
+var (
+	recordsMetric string
+	recordsWindow string
+	recordsSport  string
+	recordsTop    int
+	recordsAfter  string
+	recordsBefore string
+)
+
+var analyzeRecordsCmd = &cobra.Command{
+	Use:   "records",
+	Short: "Your biggest blocks of training: best week, month, or any window",
+	Long: `Slide a window of days across your whole history and list the blocks with
+the most distance, moving time or climbing — your biggest 7 days ever,
+rather than your biggest calendar week.
+
+Blocks are counted by local start date and do not overlap, so the
+runner-up is another period and not the best one shifted by a day.
+
+Uses the history kept by "strava sync" when there is one, and otherwise
+fetches every activity.
+
+Examples:
+  strava analyze records
+  strava analyze records --metric elevation --window 30d --sport Ride
+  strava analyze records --metric time --window 4w --top 10`,
+	Args: cobra.NoArgs,
+	RunE: runAnalyzeRecords,
+}
+
+var (
+	hrrThreshold int
+	hrrMinEffort time.Duration
+	hrrPeriod    string
+	hrrSport     string
+	hrrAfter     string
+	hrrBefore    string
+)
+
+var analyzeHRRCmd = &cobra.Command{
+	Use:   "hrr [id]",
+	Short: "Heart rate recovery after hard efforts, per activity or over months",
+	Long: `Measure heart rate recovery (HRR60): how far heart rate drops in the minute
+after a hard effort ends. A faster drop for the same kind of effort is a
+sign of improving aerobic fitness; a slower one can mean fatigue or illness.
+
+An effort is at least --min-effort with heart rate at or above --threshold
+percent of the activity's highest. Its end is where heart rate peaks as the
+effort stops; an effort followed by another within the minute is skipped.
+Recovery standing still drops faster than recovery while moving, so each
+is marked stopped or active (from the moving stream).
+
+With an ID, list the efforts in that activity. Without one, measure every
+activity with heart rate in the range and show the mean per --period; this
+fetches each activity's streams once (cached in
+~/.config/strava-cli/hrr.json).
+
+Examples:
+  strava analyze hrr 12345678
+  strava analyze hrr --sport Run --after 2024-01-01
+  strava analyze hrr --threshold 90 --min-effort 3m --period week`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runAnalyzeHRR,
+
+	ValidArgsFunction: completeActivityIDs,
+}
+
+var (
+	polarMethod string
+	polarSport  string
+	polarAfter  string
+	polarBefore string
+)
+
+var analyzePolarizationCmd = &cobra.Command{
+	Use:   "polarization",
+	Short: "Share of training time at low, moderate and high intensity (80/20 check)",
+	Long: `Split your training time into the three intensity zones of the polarized
+model — low (heart rate zones 1–2), moderate (zone 3) and high (zones 4–5) —
+and say which model it fits: polarized, pyramidal, threshold or high. The
+80/20 rule of thumb is about 80% of the time at low intensity.
+
+--method zones counts every second of heart rate data in its own zone.
+--method session counts each session's whole moving time at the intensity
+of its class (as "strava activities list --classify" labels it): easy is
+low, tempo moderate, threshold and VO2 high. Warm-ups and recoveries make
+hard sessions look easier by zones, so the two methods often disagree.
+
+Zones are those on your Strava profile. Each activity's heart rate stream
+is fetched once; labels are cached in ~/.config/strava-cli/classes.json.
+Activities without heart rate are left out.
+
+Examples:
+  strava analyze polarization
+  strava analyze polarization --after 2024-01-01 --sport Run
+  strava analyze polarization --method session`,
+	Args: cobra.NoArgs,
+	RunE: runAnalyzePolarization,
+}
+
+// demSamples is how many points along each route are looked up: one batch.
+const demSamples = dem.BatchSize
+
+// datasetName matches Open Topo Data dataset names, including comma-separated
+// fallback lists such as "eudem25m,srtm30m".
+var datasetName = regexp.MustCompile(`^[a-z0-9_-]+(,[a-z0-9_-]+)*$`)
+
+func init() {
+	rootCmd.AddCommand(analyzeCmd)
+	analyzeCmd.AddCommand(analyzeCourseCmd)
+	analyzeCmd.AddCommand(analyzeWeatherCmd)
+	analyzeCmd.AddCommand(analyzeElevationCmd)
+	analyzeCmd.AddCommand(analyzeRecordsCmd)
+	analyzeCmd.AddCommand(analyzeHRRCmd)
+	analyzeCmd.AddCommand(analyzePolarizationCmd)
+
+	analyzeCourseCmd.Flags().StringVar(&courseAfter, "after", "", "Only consider activities after this date (YYYY-MM-DD)")
+	analyzeCourseCmd.Flags().Float64Var(&courseRadius, "radius", 300, "Max distance in meters between route and activity start/end")
+	analyzeCourseCmd.Flags().Float64Var(&courseTolerance, "tolerance", 0.1, "Allowed distance mismatch as a fraction of the route length")
+	analyzeCourseCmd.Flags().IntVar(&courseMax, "max", 20, "Compare at most this many (most recent) matching activities")
+
+	analyzeWeatherCmd.Flags().StringVar(&weatherPeriod, "period", "month", "Bucket size: week, month or year")
+	analyzeWeatherCmd.Flags().StringVar(&weatherSport, "sport", "", "Only count this sport type (e.g. Run, Ride)")
+	analyzeWeatherCmd.RegisterFlagCompletionFunc("sport", completeSportTypes)
+	analyzeWeatherCmd.Flags().StringVar(&weatherAfter, "after", "", "Start date (YYYY-MM-DD, default: one year ago)")
+	analyzeWeatherCmd.Flags().StringVar(&weatherBefore, "before", "", "End date (YYYY-MM-DD)")
+	detachable(analyzeWeatherCmd)
+
+	analyzeElevationCmd.Flags().StringVar(&elevAfter, "after", "", "Start date (YYYY-MM-DD, default: start of this year)")
+	analyzeElevationCmd.Flags().StringVar(&elevBefore, "before", "", "End date (YYYY-MM-DD)")
+	analyzeElevationCmd.Flags().StringVar(&elevSport, "sport", "", "Only check this sport type (e.g. Run, Ride)")
+	analyzeElevationCmd.RegisterFlagCompletionFunc("sport", completeSportTypes)
+	analyzeElevationCmd.Flags().StringVar(&elevDataset, "dataset", dem.DefaultDataset, "Open Topo Data dataset (e.g. srtm30m, eudem25m, ned10m)")
+	analyzeElevationCmd.Flags().Float64Var(&elevTolerance, "tolerance", 0.3, "Allowed difference as a fraction of the DEM gain")
+	analyzeElevationCmd.Flags().Float64Var(&elevMinDiff, "min-diff", 50, "Ignore differences smaller than this many meters")
+	analyzeElevationCmd.Flags().BoolVar(&elevAll, "all", false, "List every checked activity, not just outliers")
+	detachable(analyzeElevationCmd)
+
+	analyzeRecordsCmd.Flags().StringVar(&recordsMetric, "metric", "distance", "Rank by distance, time or elevation")
+	analyzeRecordsCmd.Flags().StringVar(&recordsWindow, "window", "7d", "Block length in days or weeks (e.g. 7d, 30d, 4w)")
+	analyzeRecordsCmd.Flags().StringVar(&recordsSport, "sport", "", "Only count this sport type (e.g. Run, Ride)")
+	analyzeRecordsCmd.RegisterFlagCompletionFunc("sport", completeSportTypes)
+	analyzeRecordsCmd.Flags().IntVar(&recordsTop, "top", 5, "Number of blocks to list")
+	analyzeRecordsCmd.Flags().StringVar(&recordsAfter, "after", "", "Start date (YYYY-MM-DD)")
+	analyzeRecordsCmd.Flags().StringVar(&recordsBefore, "before", "", "End date (YYYY-MM-DD)")
+
+	analyzeHRRCmd.Flags().IntVar(&hrrThreshold, "threshold", 85, "Effort heart rate, in percent of the activity's highest")
+	analyzeHRRCmd.Flags().DurationVar(&hrrMinEffort, "min-effort", time.Minute, "Shortest effort to measure recovery after")
+	analyzeHRRCmd.Flags().StringVar(&hrrPeriod, "period", "month", "Bucket size without an ID: week, month or year")
+	analyzeHRRCmd.Flags().StringVar(&hrrSport, "sport", "", "Only count this sport type (e.g. Run, Ride)")
+	analyzeHRRCmd.RegisterFlagCompletionFunc("sport", completeSportTypes)
+	analyzeHRRCmd.Flags().StringVar(&hrrAfter, "after", "", "Start date (YYYY-MM-DD, default: one year ago)")
+	analyzeHRRCmd.Flags().StringVar(&hrrBefore, "before", "", "End date (YYYY-MM-DD)")
+	detachable(analyzeHRRCmd)
+
+	analyzePolarizationCmd.Flags().StringVar(&polarMethod, "method", "zones", "Count time by heart rate zones or by session goal: zones or session")
+	analyzePolarizationCmd.Flags().StringVar(&polarSport, "sport", "", "Only count this sport type (e.g. Run, Ride)")
+	analyzePolarizationCmd.RegisterFlagCompletionFunc("sport", completeSportTypes)
+	analyzePolarizationCmd.Flags().StringVar(&polarAfter, "after", "", "Start date (YYYY-MM-DD, default: 12 weeks ago)")
+	analyzePolarizationCmd.Flags().StringVar(&polarBefore, "before", "", "End date (YYYY-MM-DD)")
+	detachable(analyzePolarizationCmd)
+}
+
+func runAnalyzeCourse(cmd *cobra.Command, args []string) error {
+	id, err := parseID(args[0])
+	if err != nil {
+		return err
+	}
+	after, err := parseDate("after", courseAfter)
+	if err != nil {
+		return err
+	}
+	api, _, err := apiClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	route, err := api.GetRouteByIdWithResponse(cmd.Context(), id)
+	if err != nil {
+		return fmt.Errorf("fetch route: %w", err)
+	}
+	if route.HTTPResponse.StatusCode != 200 {
+		return apiError(route.HTTPResponse.StatusCode, route.Body)
+	}
+	if route.JSON200 == nil || route.JSON200.Map == nil {
+		return fmt.Errorf("route %d has no map data", id)
+	}
+	poly := derefStr(route.JSON200.Map.SummaryPolyline)
+	if poly == "" {
+		poly = derefStr(route.JSON200.Map.Polyline)
+	}
+	pts := geo.DecodePolyline(poly)
+	if len(pts) < 2 {
+		return fmt.Errorf("route %d has no usable polyline", id)
+	}
+	routeDist := 0.0
+	if route.JSON200.Distance != nil {
+		routeDist = float64(*route.JSON200.Distance)
+	}
+
+	acts, err := fetchActivities(cmd, api, after, time.Time{})
+	if err != nil {
+		return err
+	}
+	var matches []analysis.Activity
+	for _, a := range acts {
+		if analysis.MatchesCourse(a, pts[0], pts[len(pts)-1], routeDist, courseRadius, courseTolerance) {
+			matches = append(matches, a)
+		}
+	}
+	if len(matches) == 0 {
+		return fmt.Errorf("no activities match route %d (try a larger --radius or --tolerance)", id)
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].StartDate.After(matches[j].StartDate) })
+	if courseMax > 0 && len(matches) > courseMax {
+		matches = matches[:courseMax]
+	}
+
+	printer := newPrinter()
+	unit := 1000.0
+	if printer.Units == output.Imperial {
+		unit = 1609.344
+	}
+	limit := int(routeDist / unit)
+	var efforts []analysis.CourseEffort
+	for _, a := range matches {
+		fmt.Fprintf(os.Stderr, "Fetching streams for %d (%s)\n", a.ID, a.Name)
+		s, err := fetchStreams(cmd, api, a.ID, "distance", "time")
+		if err != nil {
+			return err
+		}
+		splits := analysis.Splits(s.Distance, s.Time, unit, limit)
+		if len(splits) == 0 {
+			continue
+		}
+		efforts = append(efforts, analysis.CourseEffort{
+			ActivityID: a.ID,
+			Name:       a.Name,
+			Date:       a.StartDateLocal,
+			Splits:     splits,
+		})
+	}
+	if len(efforts) == 0 {
+		return fmt.Errorf("matching activities have no distance/time streams")
+	}
+	return printer.Course(derefStr(route.JSON200.Name), analysis.CompareCourse(efforts, unit))
+}
+
+// weatherArchiveLag is how far behind real time the weather archive runs.
+const weatherArchiveLag = 5 * 24 * time.Hour
+
+func runAnalyzeWeather(cmd *cobra.Command, args []string) error {
+	period, err := analysis.ParsePeriod(weatherPeriod)
+	if err != nil {
+		return err
+	}
+	after, err := parseDate("after", weatherAfter)
+	if err != nil {
+		return err
+	}
+	before, err := parseDate("before", weatherBefore)
+	if err != nil {
+		return err
+	}
+	if after.IsZero() {
+		after = time.Now().AddDate(-1, 0, 0)
+	}
+
+	api, _, err := apiClient(cmd)
+	if err != nil {
+		return err
+	}
+	acts, err := fetchActivities(cmd, api, after, before)
+	if err != nil {
+		return err
+	}
+
+	cache, err := store.OpenWeatherCache("")
+	if err != nil {
+		return err
+	}
+	// Open-Meteo must not see the Strava token, so it gets a plain client.
+	wc := weather.New(httpClient())
+	conditions := map[int64]analysis.Weather{}
+	fetched := 0
+	for _, a := range acts {
+		if weatherSport != "" && !strings.EqualFold(a.SportType, weatherSport) {
+			continue
+		}
+		if w, ok := cache.Lookup(a.ID); ok {
+			conditions[a.ID] = w
+			continue
+		}
+		start, ok := geo.PointFromSlice(a.StartLatlng)
+		if !ok || a.Trainer || time.Since(a.StartDate) < weatherArchiveLag {
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "Looking up weather for %d (%s)\n", a.ID, a.StartDateLocal.Format("2006-01-02"))
+		w, err := wc.At(cmd.Context(), start, a.StartDate)
+		if err != nil && cmd.Context().Err() != nil {
+			break
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: activity %d: %v\n", a.ID, err)
+			continue
+		}
+		conditions[a.ID] = w
+		cache.Set(a.ID, w)
+		// Save as we go so an interrupted run does not repeat its lookups.
+		if fetched++; fetched%20 == 0 {
+			if err := cache.Save(); err != nil {
+				return err
+			}
+		}
+	}
+	if fetched > 0 {
+		if err := cache.Save(); err != nil {
+			return err
+		}
+	}
+	if err := cmd.Context().Err(); err != nil {
+		return err
+	}
+
+	rows := analysis.WeatherTrends(acts, conditions, period, weatherSport)
+	return newPrinter().WeatherTrends(rows, analysis.IsFootSport(weatherSport))
+}
+
+func runAnalyzeRecords(cmd *cobra.Command, args []string) error {
+	metric, err := analysis.ParseRecordMetric(recordsMetric)
+	if err != nil {
+		return err
+	}
+	days, err := analysis.ParseWindow(recordsWindow)
+	if err != nil {
+		return err
+	}
+	if recordsTop < 1 {
+		return fmt.Errorf("--top must be at least 1")
+	}
+	after, err := parseDate("after", recordsAfter)
+	if err != nil {
+		return err
+	}
+	before, err := parseDate("before", recordsBefore)
+	if err != nil {
+		return err
+	}
+
+	api, _, err := apiClient(cmd)
+	if err != nil {
+		return err
+	}
+	acts, err := historyActivities(cmd, api, after, before)
+	if err != nil {
+		return err
+	}
+	rows := analysis.Records(acts, metric, days, recordsSport, recordsTop)
+	return newPrinter().Records(rows, metric, days)
+}
+
+func runAnalyzeHRR(cmd *cobra.Command, args []string) error {
+	if hrrThreshold < 50 || hrrThreshold > 100 {
+		return fmt.Errorf("--threshold must be between 50 and 100 (percent)")
+	}
+	minEffort := int(hrrMinEffort.Seconds())
+	if minEffort < 1 {
+		return fmt.Errorf("--min-effort must be at least 1s")
+	}
+	period, err := analysis.ParsePeriod(hrrPeriod)
+	if err != nil {
+		return err
+	}
+	after, err := parseDate("after", hrrAfter)
+	if err != nil {
+		return err
+	}
+	before, err := parseDate("before", hrrBefore)
+	if err != nil {
+		return err
+	}
+	if after.IsZero() {
+		after = time.Now().AddDate(-1, 0, 0)
+	}
+
+	api, _, err := apiClient(cmd)
+	if err != nil {
+		return err
+	}
+	if len(args) == 1 {
+		id, err := parseID(args[0])
+		if err != nil {
+			return err
+		}
+		streams, err := fetchStreams(cmd, api, id, "time", "heartrate", "moving")
+		if err != nil {
+			return err
+		}
+		if len(streams.Heartrate) == 0 {
+			return fmt.Errorf("activity %d has no heart rate data", id)
+		}
+		return newPrinter().HeartRateRecovery(analysis.HeartRateRecovery(streams, hrrThreshold, minEffort))
+	}
+
+	acts, err := historyActivities(cmd, api, after, before)
+	if err != nil {
+		return err
+	}
+	cache, err := store.OpenHRRCache("")
+	if err != nil {
+		return err
+	}
+	recovery, err := cachedStreamValues(cmd, api, cache, acts, streamJob[analysis.HRRSummary]{
+		progress: "Measuring recovery in",
+		keys:     []string{"time", "heartrate", "moving"},
+		skip: func(a analysis.Activity) bool {
+			return (hrrSport != "" && !strings.EqualFold(a.SportType, hrrSport)) || a.AverageHeartrate == 0 || a.Manual
+		},
+		fresh: func(r analysis.HRRSummary) bool { return r.Threshold == hrrThreshold && r.MinEffort == minEffort },
+		compute: func(_ analysis.Activity, s *analysis.Streams) analysis.HRRSummary {
+			return analysis.SummarizeRecovery(analysis.HeartRateRecovery(s, hrrThreshold, minEffort), hrrThreshold, minEffort)
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return newPrinter().RecoveryTrends(analysis.RecoveryTrends(acts, recovery, period))
+}
+
+func runAnalyzePolarization(cmd *cobra.Command, args []string) error {
+	method, err := analysis.ParseIntensityMethod(polarMethod)
+	if err != nil {
+		return err
+	}
+	after, err := parseDate("after", polarAfter)
+	if err != nil {
+		return err
+	}
+	before, err := parseDate("before", polarBefore)
+	if err != nil {
+		return err
+	}
+	if after.IsZero() {
+		after = time.Now().AddDate(0, 0, -7*12)
+	}
+
+	api, _, err := apiClient(cmd)
+	if err != nil {
+		return err
+	}
+	acts, err := historyActivities(cmd, api, after, before)
+	if err != nil {
+		return err
+	}
+	if polarSport != "" {
+		var kept []analysis.Activity
+		for _, a := range acts {
+			if strings.EqualFold(a.SportType, polarSport) {
+				kept = append(kept, a)
+			}
+		}
+		acts = kept
+	}
+	classes, err := classifyActivities(cmd, api, acts)
+	if err != nil {
+		return err
+	}
+	return newPrinter().Polarization(analysis.Polarization(acts, classes, method, polarSport))
+}
+
+func runAnalyzeElevation(cmd *cobra.Command, args []string) error {
+	if !datasetName.MatchString(elevDataset) {
+		return fmt.Errorf("invalid --dataset %q", elevDataset)
+	}
+	after, err := parseDate("after", elevAfter)
+	if err != nil {
+		return err
+	}
+	before, err := parseDate("before", elevBefore)
+	if err != nil {
+		return err
+	}
+	if after.IsZero() {
+		after = time.Date(time.Now().Year(), 1, 1, 0, 0, 0, 0, time.Local)
+	}
+
+	api, _, err := apiClient(cmd)
+	if err != nil {
+		return err
+	}
+	acts, err := fetchActivities(cmd, api, after, before)
+	if err != nil {
+		return err
+	}
+
+	cache, err := store.OpenElevationCache(elevDataset, "")
+	if err != nil {
+		return err
+	}
+	// Open Topo Data must not see the Strava token, so it gets a plain client.
+	dc := dem.New(httpClient(), elevDataset)
+	var checks []analysis.ElevationCheck
+	fetched := 0
+	for _, a := range acts {
+		if elevSport != "" && !strings.EqualFold(a.SportType, elevSport) {
+			continue
+		}
+		if a.Trainer || a.Manual {
+			continue
+		}
+		gain, ok := cache.Lookup(a.ID)
+		if !ok {
+			pts := geo.DecodePolyline(a.Map.SummaryPolyline)
+			if len(pts) < 2 {
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "Looking up elevation for %d (%s)\n", a.ID, a.StartDateLocal.Format("2006-01-02"))
+			ele, err := dc.Elevations(cmd.Context(), geo.Resample(pts, demSamples))
+			if err != nil && cmd.Context().Err() != nil {
+				break
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "warning: activity %d: %v\n", a.ID, err)
+				continue
+			}
+			gain = analysis.ElevationGain(ele, analysis.ElevationThreshold)
+			cache.Set(a.ID, gain)
+			// Save as we go so an interrupted run does not repeat its lookups.
+			if fetched++; fetched%20 == 0 {
+				if err := cache.Save(); err != nil {
+					return err
+				}
+			}
+		}
+		checks = append(checks, analysis.CheckElevation(a, gain, elevTolerance, elevMinDiff))
+	}
+	if fetched > 0 {
+		if err := cache.Save(); err != nil {
+			return err
+		}
+	}
+	if err := cmd.Context().Err(); err != nil {
+		return err
+	}
+
+	return newPrinter().ElevationAudit(checks, analysis.ElevationTotals(checks), elevAll)
+}
@@ -2,13 +2,11 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 	"regexp"
 	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/Brainsoft-Raxat/strava-cli/internal/config"
-	"github.com/Brainsoft-Raxat/strava-cli/internal/output"
 )
 
 var athleteCmd = &cobra.Command{
@@ -54,7 +52,7 @@ func runAthleteMe(cmd *cobra.Command, args []string) error {
 	if resp.HTTPResponse.StatusCode != 200 {
 		return apiError(resp.HTTPResponse.StatusCode, resp.Body)
 	}
-	return output.New(os.Stdout, jsonOutput).Athlete(resp)
+	return newPrinter().Athlete(resp)
 }
 
 func runAthleteStats(cmd *cobra.Command, args []string) error {
@@ -90,7 +88,7 @@ func runAthleteStats(cmd *cobra.Command, args []string) error {
 	if resp.HTTPResponse.StatusCode != 200 {
 		return apiError(resp.HTTPResponse.StatusCode, resp.Body)
 	}
-	return output.New(os.Stdout, jsonOutput).Stats(resp)
+	return newPrinter().Stats(resp)
 }
 
 func runAthleteZones(cmd *cobra.Command, args []string) error {
@@ -105,7 +103,7 @@ func runAthleteZones(cmd *cobra.Command, args []string) error {
 	if resp.HTTPResponse.StatusCode != 200 {
 		return apiError(resp.HTTPResponse.StatusCode, resp.Body)
 	}
-	return output.New(os.Stdout, jsonOutput).AthleteZones(resp)
+	return newPrinter().AthleteZones(resp)
 }
 
 // loadAndRefresh loads config and ensures the token is valid.
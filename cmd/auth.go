@@ -2,15 +2,20 @@ package cmd
 
 import (
 	"bufio"
+	"context"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
-	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/Brainsoft-Raxat/strava-cli/internal/auth"
+	genclient "github.com/Brainsoft-Raxat/strava-cli/internal/client"
 	"github.com/Brainsoft-Raxat/strava-cli/internal/config"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/output"
 )
 
 var authCmd = &cobra.Command{
@@ -47,6 +52,22 @@ var authStatusCmd = &cobra.Command{
 	RunE:  runAuthStatus,
 }
 
+var authAppCmd = &cobra.Command{
+	Use:   "app",
+	Short: "Show API application settings, scopes and rate limits, and check for setup problems",
+	Long: `Show the configured Strava API application and diagnose common setup problems:
+
+  - the "Authorization Callback Domain" the app needs for your redirect URI
+  - which OAuth scopes were granted at login
+  - your app's rate limits and current usage (read from API response headers)
+  - misconfigurations such as a non-numeric client ID, an IP-address redirect
+    URI, or scopes left unticked on the consent screen
+
+Strava does not expose app settings over the API; compare the values shown
+here with https://www.strava.com/settings/api.`,
+	RunE: runAuthApp,
+}
+
 var authLogoutCmd = &cobra.Command{
 	Use:   "logout",
 	Short: "Remove stored credentials and tokens",
@@ -63,6 +84,9 @@ func init() {
 	authCmd.AddCommand(authLoginCmd)
 	authCmd.AddCommand(authStatusCmd)
 	authCmd.AddCommand(authLogoutCmd)
+	authCmd.AddCommand(authAppCmd)
+
+	authLogoutCmd.Flags().Bool("yes", false, "Skip interactive confirmation")
 
 	authLoginCmd.Flags().BoolVar(&authRemote, "remote", false,
 		"Two-step remote login: prints auth URL (step 1) or use with --auth-url to complete (step 2)")
@@ -93,6 +117,11 @@ func runAuthLogin(cmd *cobra.Command, args []string) error {
 	}
 
 	// Fall back to interactive prompts for anything still missing.
+	if cfg.ClientID == "" || cfg.ClientSecret == "" {
+		if err := requireInteractive("login", "set STRAVA_CLIENT_ID and STRAVA_CLIENT_SECRET"); err != nil {
+			return err
+		}
+	}
 	reader := bufio.NewReader(os.Stdin)
 	if cfg.ClientID == "" {
 		fmt.Print("Strava Client ID: ")
@@ -113,6 +142,10 @@ func runAuthLogin(cmd *cobra.Command, args []string) error {
 	}
 
 	// Default: local callback server or manual paste (existing behaviour).
+	// Both need a person at a browser, so fail fast rather than wait.
+	if err := requireInteractive("login", "use the two-step flow: stravacli auth login --remote, then --auth-url '<redirect URL>'"); err != nil {
+		return err
+	}
 	tokens, err := auth.Login(cfg.ClientID, cfg.ClientSecret, cfg.RedirectURI)
 	if err != nil {
 		return err
@@ -121,7 +154,10 @@ func runAuthLogin(cmd *cobra.Command, args []string) error {
 	if err := config.Save(cfg); err != nil {
 		return fmt.Errorf("save config: %w", err)
 	}
-	fmt.Println("Successfully authenticated! Tokens stored in ~/.config/strava-cli/config.json")
+	clearHTTPCache() // it may hold another athlete's responses
+	path, _ := config.Path()
+	fmt.Printf("Successfully authenticated! Tokens stored in %s\n", path)
+	detectUnits(cfg)
 	return nil
 }
 
@@ -186,10 +222,39 @@ func completeRemoteLogin(cfg *config.Config) error {
 	if err := config.Save(cfg); err != nil {
 		return fmt.Errorf("save config: %w", err)
 	}
-	fmt.Println("Successfully authenticated! Tokens stored in ~/.config/strava-cli/config.json")
+	clearHTTPCache() // it may hold another athlete's responses
+	path, _ := config.Path()
+	fmt.Printf("Successfully authenticated! Tokens stored in %s\n", path)
+	detectUnits(cfg)
 	return nil
 }
 
+// detectUnits sets cfg.Units from the athlete's Strava measurement preference
+// the first time the user logs in. Failures are non-fatal: output simply stays metric.
+func detectUnits(cfg *config.Config) {
+	if cfg.Units != "" {
+		return
+	}
+	api, err := genclient.NewClientWithResponses("https://www.strava.com/api/v3",
+		genclient.WithHTTPClient(genclient.NewHTTPClient(cfg)))
+	if err != nil {
+		return
+	}
+	resp, err := api.GetLoggedInAthleteWithResponse(context.Background())
+	if err != nil || resp.JSON200 == nil || resp.JSON200.MeasurementPreference == nil {
+		return
+	}
+	units, err := output.ParseUnits(string(*resp.JSON200.MeasurementPreference))
+	if err != nil {
+		return
+	}
+	cfg.Units = string(units)
+	if err := config.Save(cfg); err != nil {
+		return
+	}
+	fmt.Printf("Using %s units from your Strava profile (override with --units or \"units\" in the config file)\n", units)
+}
+
 func runAuthStatus(cmd *cobra.Command, args []string) error {
 	cfg, err := config.Load()
 	if err != nil {
@@ -213,6 +278,10 @@ func runAuthStatus(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	if cfg.Tokens.Scope != "" {
+		fmt.Printf("Scopes:       %s\n", cfg.Tokens.Scope)
+	}
+
 	expiry := time.Unix(cfg.Tokens.ExpiresAt, 0)
 	now := time.Now()
 	if now.Before(expiry) {
@@ -226,27 +295,134 @@ func runAuthStatus(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// appReport is the --json form of `auth app`.
+type appReport struct {
+	ClientID       string       `json:"client_id"`
+	RedirectURI    string       `json:"redirect_uri"`
+	CallbackDomain string       `json:"callback_domain"`
+	Scopes         []string     `json:"scopes,omitempty"`
+	RateLimit      *rateLimit   `json:"rate_limit,omitempty"`
+	ReadRateLimit  *rateLimit   `json:"read_rate_limit,omitempty"`
+	Checks         []auth.Check `json:"checks"`
+}
+
+// rateLimit is a 15-minute and daily request allowance with current usage, as
+// reported in Strava's X-RateLimit-* and X-ReadRateLimit-* response headers.
+type rateLimit struct {
+	ShortLimit int `json:"short_limit"` // per 15 minutes
+	ShortUsage int `json:"short_usage"`
+	DailyLimit int `json:"daily_limit"`
+	DailyUsage int `json:"daily_usage"`
+}
+
+// parseRateLimit reads a "<15min>,<daily>" limit/usage header pair.
+func parseRateLimit(h http.Header, prefix string) *rateLimit {
+	limit := strings.Split(h.Get(prefix+"-Limit"), ",")
+	usage := strings.Split(h.Get(prefix+"-Usage"), ",")
+	if len(limit) != 2 || len(usage) != 2 {
+		return nil
+	}
+	var n [4]int
+	for i, v := range []string{limit[0], usage[0], limit[1], usage[1]} {
+		x, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return nil
+		}
+		n[i] = x
+	}
+	return &rateLimit{ShortLimit: n[0], ShortUsage: n[1], DailyLimit: n[2], DailyUsage: n[3]}
+}
+
+func runAuthApp(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	redirect := auth.RedirectURI(cfg)
+	r := appReport{
+		ClientID:       cfg.ClientID,
+		RedirectURI:    redirect,
+		CallbackDomain: auth.CallbackDomain(redirect),
+		Checks:         auth.Diagnose(cfg),
+	}
+	if cfg.Tokens.Scope != "" {
+		r.Scopes = strings.Split(cfg.Tokens.Scope, ",")
+	}
+
+	// One cheap authenticated request proves the tokens work and returns the
+	// app's rate limit headers.
+	if cfg.ClientID != "" && cfg.Tokens.AccessToken != "" {
+		check := auth.Check{Level: auth.CheckOK, Message: "API request succeeded"}
+		req, _ := http.NewRequestWithContext(cmd.Context(), http.MethodGet, "https://www.strava.com/api/v3/athlete", nil)
+		resp, err := genclient.NewHTTPClient(cfg).Do(req)
+		if err != nil {
+			check = auth.Check{Level: auth.CheckFail, Message: fmt.Sprintf("API request failed: %v", err)}
+		} else {
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			r.RateLimit = parseRateLimit(resp.Header, "X-RateLimit")
+			r.ReadRateLimit = parseRateLimit(resp.Header, "X-ReadRateLimit")
+			if resp.StatusCode != http.StatusOK {
+				check = auth.Check{Level: auth.CheckFail, Message: fmt.Sprintf("API request failed: %v", apiError(resp.StatusCode, body))}
+			} else if rl := r.RateLimit; rl != nil && (rl.ShortUsage >= rl.ShortLimit || rl.DailyUsage >= rl.DailyLimit) {
+				check = auth.Check{Level: auth.CheckWarn, Message: "rate limit exhausted — requests will fail with HTTP 429 until it resets"}
+			}
+		}
+		r.Checks = append(r.Checks, check)
+	}
+
+	if jsonOutput {
+		return output.PrintJSON(os.Stdout, r)
+	}
+
+	fmt.Printf("Client ID:        %s\n", r.ClientID)
+	fmt.Printf("Redirect URI:     %s\n", r.RedirectURI)
+	fmt.Printf("Callback domain:  %s  (\"Authorization Callback Domain\" at https://www.strava.com/settings/api)\n", r.CallbackDomain)
+	if len(r.Scopes) > 0 {
+		fmt.Printf("Granted scopes:   %s\n", strings.Join(r.Scopes, ", "))
+	}
+	for _, l := range []struct {
+		label string
+		rl    *rateLimit
+	}{{"Rate limit:", r.RateLimit}, {"Read rate limit:", r.ReadRateLimit}} {
+		if l.rl != nil {
+			fmt.Printf("%-17s %d/%d per 15 min, %d/%d per day\n", l.label,
+				l.rl.ShortUsage, l.rl.ShortLimit, l.rl.DailyUsage, l.rl.DailyLimit)
+		}
+	}
+	fmt.Println("\nChecks:")
+	for _, c := range r.Checks {
+		fmt.Printf("  %-4s  %s\n", strings.ToUpper(c.Level), c.Message)
+	}
+	return nil
+}
+
 func runAuthLogout(cmd *cobra.Command, args []string) error {
-	dir, err := config.Dir()
+	path, err := config.Path()
 	if err != nil {
 		return err
 	}
-	path := filepath.Join(dir, "config.json")
 	if _, statErr := os.Stat(path); os.IsNotExist(statErr) {
 		fmt.Println("Not authenticated.")
 		return nil
 	}
 
-	fmt.Printf("This will delete %s and revoke local credentials.\nProceed? [y/N] ", path)
-	var ans string
-	fmt.Fscanln(os.Stdin, &ans)
-	if strings.ToLower(strings.TrimSpace(ans)) != "y" {
-		fmt.Println("Aborted.")
-		return nil
+	if yes, _ := cmd.Flags().GetBool("yes"); !yes {
+		if err := requireInteractive("logout confirmation", "pass --yes to log out without prompting"); err != nil {
+			return err
+		}
+		fmt.Printf("This will delete %s and revoke local credentials.\nProceed? [y/N] ", path)
+		var ans string
+		fmt.Fscanln(os.Stdin, &ans)
+		if strings.ToLower(strings.TrimSpace(ans)) != "y" {
+			fmt.Println("Aborted.")
+			return nil
+		}
 	}
 	if err := os.Remove(path); err != nil {
 		return fmt.Errorf("remove config: %w", err)
 	}
+	clearHTTPCache()
 	fmt.Println("Logged out. Run 'stravacli auth login' to re-authenticate.")
 	return nil
 }
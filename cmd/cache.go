@@ -0,0 +1,282 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/client"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/config"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/fsutil"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/maps"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/output"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/store"
+)
+
+// staleSpillAge is how long the streams of an unfinished download are kept
+// for it to resume.
+const staleSpillAge = 7 * 24 * time.Hour
+
+var (
+	cacheGCMinAge    time.Duration
+	cachePurgeOthers bool
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage local caches and leftover files",
+}
+
+var cacheGCCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Remove stale temporary files, expired caches and orphaned entries",
+	Long: `Remove what interrupted or old runs left behind in the config directory
+(~/.config/strava-cli) and the system temporary directory:
+
+  temp files       files being written when a run crashed or was killed
+                   (exports, state files, compressed uploads), older than
+                   --min-age so writes in progress are left alone
+  map tiles        cached tiles past their 30-day expiry
+  API responses    responses kept by the HTTP cache and not used for 30 days
+  stream downloads streams of downloads not resumed for 7 days
+  upload queue     queued uploads never sent whose file no longer exists
+  club feed log    other athletes' activities logged by "clubs leaderboard"
+                   and "clubs digest" longer ago than retention-days (see "strava config set")
+  command history  runs recorded by "strava history" more than 90 days ago
+
+It prints how many items and bytes each removed. --dry-run only lists them.
+
+Examples:
+  strava cache gc --dry-run
+  strava cache gc
+  strava cache gc --min-age 0 --json`,
+	Args: cobra.NoArgs,
+	RunE: runCacheGC,
+}
+
+var cachePurgeCmd = &cobra.Command{
+	Use:   "purge",
+	Short: "Delete cached data about other athletes",
+	Long: `Delete cached data that is not yours. Strava's API agreement limits how
+long apps may keep other athletes' data; --others deletes all of it now:
+
+  club feed log    club members' activities logged by "clubs leaderboard"
+                   and "clubs digest"
+
+To keep it for a limited time instead, set the number of days with
+"strava config set retention-days <days>": older entries are purged
+whenever "clubs leaderboard", "clubs digest", "sync" or "cache gc" runs.
+
+Examples:
+  strava cache purge --others --dry-run
+  strava cache purge --others`,
+	Args: cobra.NoArgs,
+	RunE: runCachePurge,
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheGCCmd)
+	cacheGCCmd.Flags().DurationVar(&cacheGCMinAge, "min-age", time.Hour, "Leave temporary files younger than this")
+	cacheGCCmd.Flags().Bool("dry-run", false, "List what would be removed without removing it")
+	cacheCmd.AddCommand(cachePurgeCmd)
+	cachePurgeCmd.Flags().BoolVar(&cachePurgeOthers, "others", false, "Delete all cached data about other athletes")
+	cachePurgeCmd.Flags().Bool("dry-run", false, "List what would be removed without removing it")
+}
+
+// gcCategory is one kind of leftover that cache gc removes.
+type gcCategory struct {
+	Name  string   `json:"name"`
+	Items int      `json:"items"`
+	Bytes int64    `json:"bytes"`
+	Paths []string `json:"paths"` // files and directories; queue entries are listed by file
+}
+
+func (c *gcCategory) add(entries []fsutil.Entry) {
+	for _, e := range entries {
+		c.Items++
+		c.Bytes += e.Size
+		c.Paths = append(c.Paths, e.Path)
+	}
+}
+
+func runCacheGC(cmd *cobra.Command, args []string) error {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	dir, err := config.Dir()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+
+	temp := &gcCategory{Name: "temp files", Paths: []string{}}
+	found, err := fsutil.FindTemp(dir, true, now.Add(-cacheGCMinAge))
+	if err != nil {
+		return err
+	}
+	temp.add(found)
+	if found, err = fsutil.FindTemp(os.TempDir(), false, now.Add(-cacheGCMinAge)); err != nil {
+		return err
+	}
+	temp.add(found)
+
+	tiles := &gcCategory{Name: "map tiles", Paths: []string{}}
+	if found, err = fsutil.FindOlder(filepath.Join(dir, "tiles"), now.Add(-maps.CacheMaxAge)); err != nil {
+		return err
+	}
+	tiles.add(found)
+
+	responses := &gcCategory{Name: "API responses", Paths: []string{}}
+	if found, err = fsutil.FindOlder(filepath.Join(dir, store.HTTPCacheDir), now.Add(-client.DiskCacheMaxAge)); err != nil {
+		return err
+	}
+	responses.add(found)
+
+	spills := &gcCategory{Name: "stream downloads", Paths: []string{}}
+	if found, err = store.StaleStreamSpills("", now.Add(-staleSpillAge)); err != nil {
+		return err
+	}
+	spills.add(found)
+
+	queue, err := store.OpenUploadQueue("")
+	if err != nil {
+		return err
+	}
+	orphans := queue.Orphans()
+	queued := &gcCategory{Name: "upload queue", Paths: []string{}}
+	for _, u := range orphans {
+		queued.Items++
+		queued.Paths = append(queued.Paths, u.Path)
+	}
+
+	clubFeed := &gcCategory{Name: "club feed log", Paths: []string{}}
+	if clubFeed.Items, err = purgeExpiredOthers(dryRun, now); err != nil {
+		return err
+	}
+
+	commands := &gcCategory{Name: "command history", Paths: []string{}}
+	cmdLog, err := store.OpenCommandLog("")
+	if err != nil {
+		return err
+	}
+	if commands.Items, err = cmdLog.Prune(now.Add(-store.CommandRetention), dryRun); err != nil {
+		return err
+	}
+
+	categories := []*gcCategory{temp, tiles, responses, spills, queued, clubFeed, commands}
+	var errs []error
+	if !dryRun {
+		for _, c := range []*gcCategory{temp, tiles, responses} {
+			for _, p := range c.Paths {
+				if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+					errs = append(errs, err)
+				}
+			}
+		}
+		for _, p := range spills.Paths {
+			if err := os.RemoveAll(p); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		for _, u := range orphans {
+			if err := queue.Remove(u.Hash); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	if err := printCategories(categories, dryRun); err != nil {
+		return err
+	}
+	return errors.Join(errs...)
+}
+
+// printCategories reports what cache gc or purge removed, or would remove
+// with dryRun.
+func printCategories(categories []*gcCategory, dryRun bool) error {
+	if jsonOutput {
+		return output.PrintJSON(stdout(), map[string]any{"dry_run": dryRun, "categories": categories})
+	}
+	var items int
+	var size int64
+	fmt.Fprintf(stdout(), "%-18s  %6s  %10s\n", "CATEGORY", "ITEMS", "SIZE")
+	fmt.Fprintln(stdout(), strings.Repeat("─", 38))
+	for _, c := range categories {
+		fmt.Fprintf(stdout(), "%-18s  %6d  %10s\n", c.Name, c.Items, output.FormatSize(c.Bytes))
+		items += c.Items
+		size += c.Bytes
+	}
+	fmt.Fprintln(stdout(), strings.Repeat("─", 38))
+	fmt.Fprintf(stdout(), "%-18s  %6d  %10s\n", "total", items, output.FormatSize(size))
+	if dryRun {
+		for _, c := range categories {
+			for _, p := range c.Paths {
+				fmt.Fprintf(os.Stderr, "DRY RUN: would remove %s: %s\n", c.Name, p)
+			}
+		}
+	} else {
+		fmt.Fprintf(os.Stderr, "Removed %d items, %s\n", items, output.FormatSize(size))
+	}
+	return nil
+}
+
+func runCachePurge(cmd *cobra.Command, args []string) error {
+	if !cachePurgeOthers {
+		return fmt.Errorf("nothing to purge: pass --others to delete cached data about other athletes")
+	}
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	path, err := store.Path(store.ClubFeedFile)
+	if err != nil {
+		return err
+	}
+	log, err := store.OpenClubFeed(path)
+	if err != nil {
+		return err
+	}
+	clubFeed := &gcCategory{Name: "club feed log", Items: log.Len(), Paths: []string{}}
+	if info, err := os.Stat(path); err == nil {
+		clubFeed.Bytes = info.Size()
+		clubFeed.Paths = append(clubFeed.Paths, path)
+	}
+	if !dryRun {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return printCategories([]*gcCategory{clubFeed}, dryRun)
+}
+
+// othersRetention is how long other athletes' data is kept: retention-days
+// when set, else the club feed log's own limit.
+func othersRetention() time.Duration {
+	if cfg, err := config.Load(); err == nil && cfg.RetentionDays > 0 {
+		return time.Duration(cfg.RetentionDays) * 24 * time.Hour
+	}
+	return store.ClubFeedRetention
+}
+
+// purgeExpiredOthers drops the club feed log entries older than
+// othersRetention, returning how many there were; with dryRun it only
+// counts them.
+func purgeExpiredOthers(dryRun bool, now time.Time) (int, error) {
+	log, err := store.OpenClubFeed("")
+	if err != nil {
+		return 0, err
+	}
+	n := log.Purge(now.Add(-othersRetention()))
+	if n == 0 || dryRun {
+		return n, nil
+	}
+	return n, log.Save()
+}
+
+// clearHTTPCache deletes the API responses the HTTP cache keeps, when the
+// athlete logs in or out.
+func clearHTTPCache() {
+	if dir, err := store.Path(store.HTTPCacheDir); err == nil {
+		_ = os.RemoveAll(dir)
+	}
+}
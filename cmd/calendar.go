@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/analysis"
+)
+
+var (
+	calendarYear   int
+	calendarMetric string
+	calendarSport  string
+)
+
+var calendarCmd = &cobra.Command{
+	Use:   "calendar",
+	Short: "Print a year of training as a heatmap",
+	Long: `Print a GitHub-style heatmap of a year's training days: a column per week,
+Monday to Sunday, with each day shaded by its moving time (--metric time, the
+default) or distance (--metric distance). The shades split the year's
+training days into quarters, lightest for the quarter with the least, so a
+glance shows how consistent the year was. Totals and the longest streak of
+consecutive training days follow.
+
+The activities come from the synced history (see "strava sync") when there
+is one.
+
+Examples:
+  strava calendar
+  strava calendar --year 2024 --metric distance
+  strava calendar --sport Run --json`,
+	Args: cobra.NoArgs,
+	RunE: runCalendar,
+}
+
+func init() {
+	rootCmd.AddCommand(calendarCmd)
+	calendarCmd.Flags().IntVar(&calendarYear, "year", 0, "Year to show (default this year)")
+	calendarCmd.Flags().StringVar(&calendarMetric, "metric", "time", "Shade days by: time or distance")
+	calendarCmd.Flags().StringVar(&calendarSport, "sport", "", "Only count this sport type (e.g. Run, Ride)")
+	calendarCmd.RegisterFlagCompletionFunc("sport", completeSportTypes)
+}
+
+func runCalendar(cmd *cobra.Command, args []string) error {
+	metric, err := analysis.ParseCalendarMetric(calendarMetric)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	year := calendarYear
+	if year == 0 {
+		year = now.Year()
+	}
+	api, _, err := apiClient(cmd)
+	if err != nil {
+		return err
+	}
+	// A day either side, since activities are placed by their local date.
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, -1)
+	acts, err := historyActivities(cmd, api, from, from.AddDate(1, 0, 2))
+	if err != nil {
+		return err
+	}
+	return newPrinter().Calendar(analysis.TrainingCalendar(acts, year, metric, calendarSport, now))
+}
@@ -1,12 +1,21 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/analysis"
 	genclient "github.com/Brainsoft-Raxat/strava-cli/internal/client"
 	"github.com/Brainsoft-Raxat/strava-cli/internal/output"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/store"
 )
 
 var clubsCmd = &cobra.Command{
@@ -15,8 +24,12 @@ var clubsCmd = &cobra.Command{
 }
 
 var (
-	clubsPage    int
-	clubsPerPage int
+	clubsEnrich       bool
+	clubsRole         string
+	leaderboardPeriod string
+	leaderboardSport  string
+	digestPeriod      string
+	digestLast        bool
 )
 
 var clubsListCmd = &cobra.Command{
@@ -35,15 +48,111 @@ var clubsGetCmd = &cobra.Command{
 var clubsMembersCmd = &cobra.Command{
 	Use:   "members <id>",
 	Short: "List members of a club",
+	Long: `List members of a club. --role keeps only admins (owners included), the
+owner, or members who are neither. Roles are filtered after fetching, so add
+--all to search the whole roster rather than one page.
+
+Examples:
+  strava clubs members 12345 --all
+  strava clubs members 12345 --all --role admin
+  strava clubs members 12345 --all --output csv > roster.csv`,
+	Args: cobra.ExactArgs(1),
+	RunE: runClubsMembers,
+}
+
+var clubsAdminsCmd = &cobra.Command{
+	Use:   "admins <id>",
+	Short: "List the admins of a club",
 	Args:  cobra.ExactArgs(1),
-	RunE:  runClubsMembers,
+	RunE:  runClubsAdmins,
 }
 
 var clubsActivitiesCmd = &cobra.Command{
 	Use:   "activities <id>",
 	Short: "List recent activities from a club",
+	Long: `List recent activities from a club feed.
+
+With --enrich, each activity's athlete is cross-referenced against the club
+roster to produce a consistent "Firstname L." display name, and your own
+activities are flagged with "*". Strava only exposes first name and last
+initial in club feeds, so an athlete whose name collides with another
+member is never flagged as you.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runClubsActivities,
+}
+
+var clubsLeaderboardCmd = &cobra.Command{
+	Use:   "leaderboard <id>",
+	Short: "Rank club members by distance this week, month or year",
+	Long: `Rank club members by distance and time, from the club's activity feed.
+
+The website's club leaderboard is not in the API, and the feed it is built
+from has no dates. Each run logs the feed in
+~/.config/strava-cli/club-feed.json with when each activity was first seen,
+and the leaderboard counts the activities first seen in the period. Run it
+regularly (daily, say) so activities land in the right week; on the first
+run, the whole feed counts as seen today. Athletes appear as the feed names
+them ("Firstname L."), so two members with the same name share a row.
+
+Examples:
+  strava clubs leaderboard 12345
+  strava clubs leaderboard 12345 --period month --sport Run`,
+	Args: cobra.ExactArgs(1),
+	RunE: runClubsLeaderboard,
+}
+
+var clubsDigestCmd = &cobra.Command{
+	Use:   "digest <id>",
+	Short: "Write a club's weekly or monthly digest in Markdown, for a newsletter",
+	Long: `Sum up a club's week (or --period month) in Markdown, ready to paste into
+a newsletter or post: activities, athletes, distance and climbing in all,
+the top athletes by distance, the longest ride and run, the biggest climb,
+and a welcome to athletes out with the club for the first time.
+
+Like leaderboard, it reads the club feed and counts the activities first
+seen in the period, logged in ~/.config/strava-cli/club-feed.json; run
+either regularly so activities land in the right week. Newcomers are those
+whose first activity in the log is in the period, so they are only named
+once the log reaches back before it. --last sums up the previous, complete
+period, for a digest sent out on Monday about the week before.
+
+Examples:
+  strava clubs digest 12345
+  strava clubs digest 12345 --last > digest.md
+  strava clubs digest 12345 --period month --last --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runClubsDigest,
+}
+
+var clubsEventsCmd = &cobra.Command{
+	Use:   "events <club-id>",
+	Short: "List a club's upcoming group events",
+	Long: `List a club's upcoming group events, soonest first. Events you joined are
+marked with "*". join and leave RSVP to one event by its ID.
+
+Strava's published API does not document group events; these commands use
+the endpoints the Strava apps call, which may change without notice.
+
+Examples:
+  strava clubs events 12345
+  strava clubs events join 987654
+  strava clubs events leave 987654 --yes`,
+	Args: cobra.ExactArgs(1),
+	RunE: runClubsEvents,
+}
+
+var clubsEventsJoinCmd = &cobra.Command{
+	Use:   "join <event-id>",
+	Short: "Join a club's group event",
+	Args:  cobra.ExactArgs(1),
+	RunE:  func(cmd *cobra.Command, args []string) error { return runClubsEventRSVP(cmd, args, true) },
+}
+
+var clubsEventsLeaveCmd = &cobra.Command{
+	Use:   "leave <event-id>",
+	Short: "Leave a club's group event",
 	Args:  cobra.ExactArgs(1),
-	RunE:  runClubsActivities,
+	RunE:  func(cmd *cobra.Command, args []string) error { return runClubsEventRSVP(cmd, args, false) },
 }
 
 func init() {
@@ -51,11 +160,36 @@ func init() {
 	clubsCmd.AddCommand(clubsListCmd)
 	clubsCmd.AddCommand(clubsGetCmd)
 	clubsCmd.AddCommand(clubsMembersCmd)
+	clubsCmd.AddCommand(clubsAdminsCmd)
 	clubsCmd.AddCommand(clubsActivitiesCmd)
+	clubsCmd.AddCommand(clubsLeaderboardCmd)
+	clubsCmd.AddCommand(clubsDigestCmd)
+	clubsCmd.AddCommand(clubsEventsCmd)
+	clubsEventsCmd.AddCommand(clubsEventsJoinCmd)
+	clubsEventsCmd.AddCommand(clubsEventsLeaveCmd)
 
-	for _, c := range []*cobra.Command{clubsListCmd, clubsMembersCmd, clubsActivitiesCmd} {
-		c.Flags().IntVar(&clubsPage, "page", 1, "Page number")
-		c.Flags().IntVar(&clubsPerPage, "per-page", 30, "Items per page")
+	addPageFlags(clubsListCmd, "clubs", true)
+	addListFlags(clubsListCmd, "clubs")
+	addPageFlags(clubsMembersCmd, "members", true)
+	addListFlags(clubsMembersCmd, "club-members")
+	clubsMembersCmd.Flags().StringVar(&clubsRole, "role", "", "Only members with this role: admin, owner or member")
+	clubsMembersCmd.RegisterFlagCompletionFunc("role", func(_ *cobra.Command, _ []string, _ string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		return []cobra.Completion{"admin", "owner", "member"}, cobra.ShellCompDirectiveNoFileComp
+	})
+	addPageFlags(clubsAdminsCmd, "admins", true)
+	addListFlags(clubsAdminsCmd, "club-members")
+	addPageFlags(clubsActivitiesCmd, "activities", true)
+	addListFlags(clubsActivitiesCmd, "club-activities")
+	clubsActivitiesCmd.Flags().BoolVar(&clubsEnrich, "enrich", false,
+		"Resolve athlete names against the club roster and flag your own activities")
+	clubsLeaderboardCmd.Flags().StringVar(&leaderboardPeriod, "period", "week", "week, month or year (the current one)")
+	clubsLeaderboardCmd.Flags().StringVar(&leaderboardSport, "sport", "", "Only count this sport type (e.g. Run, Ride)")
+	clubsLeaderboardCmd.RegisterFlagCompletionFunc("sport", completeSportTypes)
+	clubsDigestCmd.Flags().StringVar(&digestPeriod, "period", "week", "week, month or year")
+	clubsDigestCmd.Flags().BoolVar(&digestLast, "last", false, "Sum up the previous, complete period instead of the current one")
+	for _, c := range []*cobra.Command{clubsEventsJoinCmd, clubsEventsLeaveCmd} {
+		c.Flags().Bool("yes", false, "Skip interactive confirmation")
+		c.Flags().Bool("dry-run", false, "Print what would change without calling the API")
 	}
 }
 
@@ -64,15 +198,18 @@ func runClubsList(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	resp, err := api.GetLoggedInAthleteClubsWithResponse(cmd.Context(),
-		&genclient.GetLoggedInAthleteClubsParams{Page: intPtr(clubsPage), PerPage: intPtr(clubsPerPage)})
-	if err != nil {
-		return fmt.Errorf("fetch clubs: %w", err)
-	}
-	if resp.HTTPResponse.StatusCode != 200 {
-		return apiError(resp.HTTPResponse.StatusCode, resp.Body)
-	}
-	return output.New(os.Stdout, jsonOutput).Clubs(resp)
+	return runList(cmd, newPrinter(), listSource[genclient.GetLoggedInAthleteClubsResponse]{
+		what: "clubs",
+		fetch: func(ctx context.Context, page, perPage int) (*http.Response, []byte, error) {
+			resp, err := api.GetLoggedInAthleteClubsWithResponse(ctx,
+				&genclient.GetLoggedInAthleteClubsParams{Page: &page, PerPage: &perPage})
+			if err != nil {
+				return nil, nil, err
+			}
+			return resp.HTTPResponse, resp.Body, nil
+		},
+		print: (*output.Printer).Clubs,
+	})
 }
 
 func runClubsGet(cmd *cobra.Command, args []string) error {
@@ -91,7 +228,7 @@ func runClubsGet(cmd *cobra.Command, args []string) error {
 	if resp.HTTPResponse.StatusCode != 200 {
 		return apiError(resp.HTTPResponse.StatusCode, resp.Body)
 	}
-	return output.New(os.Stdout, jsonOutput).Club(resp)
+	return newPrinter().Club(resp)
 }
 
 func runClubsMembers(cmd *cobra.Command, args []string) error {
@@ -99,19 +236,91 @@ func runClubsMembers(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	var filter func([]json.RawMessage) ([]json.RawMessage, error)
+	switch clubsRole {
+	case "":
+	case "admin", "owner", "member":
+		filter = func(items []json.RawMessage) ([]json.RawMessage, error) { return filterClubRole(items, clubsRole) }
+	default:
+		return fmt.Errorf("invalid --role %q: use admin, owner or member", clubsRole)
+	}
 	api, _, err := apiClient(cmd)
 	if err != nil {
 		return err
 	}
-	resp, err := api.GetClubMembersByIdWithResponse(cmd.Context(), id,
-		&genclient.GetClubMembersByIdParams{Page: intPtr(clubsPage), PerPage: intPtr(clubsPerPage)})
+	return runList(cmd, newPrinter(), listSource[genclient.GetClubMembersByIdResponse]{
+		what: "members",
+		fetch: func(ctx context.Context, page, perPage int) (*http.Response, []byte, error) {
+			resp, err := api.GetClubMembersByIdWithResponse(ctx, id,
+				&genclient.GetClubMembersByIdParams{Page: &page, PerPage: &perPage})
+			if err != nil {
+				return nil, nil, err
+			}
+			return resp.HTTPResponse, resp.Body, nil
+		},
+		filter: filter,
+		print:  (*output.Printer).ClubMembers,
+	})
+}
+
+// filterClubRole keeps the roster entries with role: admins (owners are
+// admins too), the owner, or members who are neither.
+func filterClubRole(items []json.RawMessage, role string) ([]json.RawMessage, error) {
+	var out []json.RawMessage
+	for _, raw := range items {
+		var m struct {
+			Admin bool `json:"admin"`
+			Owner bool `json:"owner"`
+		}
+		if err := json.Unmarshal(raw, &m); err != nil {
+			return nil, fmt.Errorf("parse member: %w", err)
+		}
+		if role == "admin" && (m.Admin || m.Owner) || role == "owner" && m.Owner || role == "member" && !m.Admin && !m.Owner {
+			out = append(out, raw)
+		}
+	}
+	return out, nil
+}
+
+// runClubsAdmins lists a club's admins through GET /clubs/{id}/admins, which
+// the generated client lacks. The endpoint returns bare athletes; they are
+// marked as admins so the members table shows their role.
+func runClubsAdmins(cmd *cobra.Command, args []string) error {
+	id, err := parseID(args[0])
 	if err != nil {
-		return fmt.Errorf("fetch members: %w", err)
+		return err
 	}
-	if resp.HTTPResponse.StatusCode != 200 {
-		return apiError(resp.HTTPResponse.StatusCode, resp.Body)
+	httpClient, _, err := rawClient(cmd)
+	if err != nil {
+		return err
 	}
-	return output.New(os.Stdout, jsonOutput).ClubMembers(resp)
+	return runList(cmd, newPrinter(), listSource[genclient.GetClubMembersByIdResponse]{
+		what: "admins",
+		fetch: func(ctx context.Context, page, perPage int) (*http.Response, []byte, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+				fmt.Sprintf("https://www.strava.com/api/v3/clubs/%d/admins?page=%d&per_page=%d", id, page, perPage), nil)
+			if err != nil {
+				return nil, nil, err
+			}
+			resp, err := httpClient.Do(req)
+			if err != nil {
+				return nil, nil, err
+			}
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			return resp, body, err
+		},
+		filter: func(items []json.RawMessage) ([]json.RawMessage, error) {
+			for i, raw := range items {
+				var err error
+				if items[i], err = setJSONField(raw, "admin", true); err != nil {
+					return nil, fmt.Errorf("parse admin: %w", err)
+				}
+			}
+			return items, nil
+		},
+		print: (*output.Printer).ClubMembers,
+	})
 }
 
 func runClubsActivities(cmd *cobra.Command, args []string) error {
@@ -123,13 +332,355 @@ func runClubsActivities(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	resp, err := api.GetClubActivitiesByIdWithResponse(cmd.Context(), id,
-		&genclient.GetClubActivitiesByIdParams{Page: intPtr(clubsPage), PerPage: intPtr(clubsPerPage)})
+	printFeed := (*output.Printer).ClubActivities
+	if clubsEnrich {
+		printFeed = func(p *output.Printer, r *genclient.GetClubActivitiesByIdResponse) error {
+			return printClubFeed(cmd, api, id, p, r.Body)
+		}
+	}
+	return runList(cmd, newPrinter(), listSource[genclient.GetClubActivitiesByIdResponse]{
+		what: "club activities",
+		fetch: func(ctx context.Context, page, perPage int) (*http.Response, []byte, error) {
+			resp, err := api.GetClubActivitiesByIdWithResponse(ctx, id,
+				&genclient.GetClubActivitiesByIdParams{Page: &page, PerPage: &perPage})
+			if err != nil {
+				return nil, nil, err
+			}
+			return resp.HTTPResponse, resp.Body, nil
+		},
+		print: printFeed,
+	})
+}
+
+func runClubsLeaderboard(cmd *cobra.Command, args []string) error {
+	id, err := parseID(args[0])
+	if err != nil {
+		return err
+	}
+	period, err := analysis.ParsePeriod(leaderboardPeriod)
 	if err != nil {
-		return fmt.Errorf("fetch club activities: %w", err)
+		return err
 	}
-	if resp.HTTPResponse.StatusCode != 200 {
-		return apiError(resp.HTTPResponse.StatusCode, resp.Body)
+	api, _, err := apiClient(cmd)
+	if err != nil {
+		return err
+	}
+	log, err := recordClubFeed(cmd, api, id, string(period))
+	if err != nil {
+		return err
+	}
+	start := period.Start(time.Now())
+	label := "in " + period.Label(start)
+	if period == analysis.Week {
+		label = fmt.Sprintf("in %s (since %s)", period.Label(start), start.Format("Mon 2 Jan"))
+	}
+	if leaderboardSport != "" {
+		label += ", " + leaderboardSport
+	}
+	return newPrinter().ClubLeaderboard(label, analysis.ClubLeaderboard(log.Entries(id), start, leaderboardSport))
+}
+
+func runClubsDigest(cmd *cobra.Command, args []string) error {
+	id, err := parseID(args[0])
+	if err != nil {
+		return err
+	}
+	period, err := analysis.ParsePeriod(digestPeriod)
+	if err != nil {
+		return err
+	}
+	api, _, err := apiClient(cmd)
+	if err != nil {
+		return err
+	}
+	club, err := api.GetClubByIdWithResponse(cmd.Context(), id)
+	if err != nil {
+		return fmt.Errorf("fetch club: %w", err)
+	}
+	if club.HTTPResponse.StatusCode != 200 {
+		return apiError(club.HTTPResponse.StatusCode, club.Body)
+	}
+	name := fmt.Sprintf("Club %d", id)
+	if club.JSON200 != nil && club.JSON200.Name != nil {
+		name = *club.JSON200.Name
+	}
+	log, err := recordClubFeed(cmd, api, id, string(period))
+	if err != nil {
+		return err
+	}
+
+	start, until := period.Start(time.Now()), time.Time{}
+	if digestLast {
+		until = start
+		start = period.Start(start.AddDate(0, 0, -1))
+	}
+	label := period.Label(start)
+	if period == analysis.Week {
+		label = "week of " + start.Format("Mon 2 Jan 2006")
+	}
+	d := analysis.DigestClub(log.Entries(id), start, until)
+	if d.Activities > 0 && !d.NewcomersKnown {
+		fmt.Fprintf(os.Stderr, "Newcomers are left out: the club feed log does not reach back before %s yet.\n", start.Format("2 Jan"))
+	}
+	return newPrinter().ClubDigest(name+": "+label, d)
+}
+
+// recordClubFeed fetches a club's whole feed and logs it (see
+// store.ClubFeed.Record), purging entries past the retention, and returns
+// the log. period names what the feed is summed over, for the note on a
+// club's first run.
+func recordClubFeed(cmd *cobra.Command, api *genclient.ClientWithResponses, id int64, period string) (*store.ClubFeed, error) {
+	var feed []analysis.ClubEntry
+	for page := 1; ; page++ {
+		resp, err := api.GetClubActivitiesByIdWithResponse(cmd.Context(), id,
+			&genclient.GetClubActivitiesByIdParams{Page: intPtr(page), PerPage: intPtr(historyPageSize)})
+		if err != nil {
+			return nil, fmt.Errorf("fetch club activities: %w", err)
+		}
+		if resp.HTTPResponse.StatusCode != 200 {
+			return nil, apiError(resp.HTTPResponse.StatusCode, resp.Body)
+		}
+		var batch []clubFeedActivity
+		if err := json.Unmarshal(resp.Body, &batch); err != nil {
+			return nil, fmt.Errorf("parse club activities: %w", err)
+		}
+		for _, a := range batch {
+			feed = append(feed, analysis.ClubEntry{
+				Athlete:       displayName(a.Athlete.Firstname, a.Athlete.Lastname),
+				Name:          a.Name,
+				SportType:     a.SportType,
+				Distance:      float64(a.Distance),
+				MovingTime:    a.MovingTime,
+				ElapsedTime:   a.ElapsedTime,
+				ElevationGain: float64(a.TotalElevationGain),
+			})
+		}
+		if len(batch) < historyPageSize {
+			break
+		}
+	}
+
+	log, err := store.OpenClubFeed("")
+	if err != nil {
+		return nil, err
+	}
+	firstRun := len(log.Entries(id)) == 0
+	now := time.Now()
+	added := log.Record(id, feed, now)
+	log.Purge(now.Add(-othersRetention()))
+	if err := log.Save(); err != nil {
+		return nil, err
+	}
+	if firstRun && added > 0 {
+		fmt.Fprintf(os.Stderr, "First run for club %d: its feed's %d activities count as seen today. "+
+			"Run this regularly so new ones land in the right %s.\n", id, added, period)
+	}
+	return log, nil
+}
+
+// runClubsEvents lists a club's upcoming events through
+// GET /clubs/{id}/group_events, which the generated client lacks.
+func runClubsEvents(cmd *cobra.Command, args []string) error {
+	id, err := parseID(args[0])
+	if err != nil {
+		return err
+	}
+	httpClient, _, err := rawClient(cmd)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(cmd.Context(), http.MethodGet,
+		fmt.Sprintf("https://www.strava.com/api/v3/clubs/%d/group_events?upcoming=true", id), nil)
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetch club events: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return apiError(resp.StatusCode, body)
+	}
+	var events []output.ClubEvent
+	if err := json.Unmarshal(body, &events); err != nil {
+		return fmt.Errorf("parse club events: %w", err)
+	}
+	// Events without a date left to come sort last.
+	next := func(e output.ClubEvent) time.Time {
+		if len(e.UpcomingOccurrences) == 0 {
+			return time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC)
+		}
+		return e.UpcomingOccurrences[0]
+	}
+	slices.SortStableFunc(events, func(a, b output.ClubEvent) int { return next(a).Compare(next(b)) })
+	return newPrinter().ClubEvents(events)
+}
+
+// runClubsEventRSVP joins or leaves the event given as the argument through
+// POST or DELETE /group_events/{id}/rsvps.
+func runClubsEventRSVP(cmd *cobra.Command, args []string, join bool) error {
+	id, err := parseID(args[0])
+	if err != nil {
+		return err
+	}
+	method, verb, done := http.MethodPost, "join", "Joined"
+	if !join {
+		method, verb, done = http.MethodDelete, "leave", "Left"
+	}
+	proceed, err := confirmMutation(cmd, fmt.Sprintf("%s group event %d", verb, id))
+	if err != nil || !proceed {
+		return err
+	}
+	httpClient, _, err := rawClient(cmd)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(cmd.Context(), method,
+		fmt.Sprintf("https://www.strava.com/api/v3/group_events/%d/rsvps", id), nil)
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s group event: %w", verb, err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return apiError(resp.StatusCode, body)
+	}
+	if jsonOutput {
+		fmt.Fprintln(os.Stdout, string(body))
+		return nil
+	}
+	fmt.Fprintf(os.Stdout, "%s group event %d\n", done, id)
+	return nil
+}
+
+// printClubFeed prints a club's feed (body) with athlete names resolved
+// against the club roster and the authenticated athlete's activities flagged.
+func printClubFeed(cmd *cobra.Command, api *genclient.ClientWithResponses, id int64, p *output.Printer, body []byte) error {
+	// The generated model drops the athlete name fields, so decode the raw body.
+	var feed []clubFeedActivity
+	if err := json.Unmarshal(body, &feed); err != nil {
+		return fmt.Errorf("parse club activities: %w", err)
+	}
+	roster, err := fetchClubRoster(cmd, api, id)
+	if err != nil {
+		return err
+	}
+	me, err := api.GetLoggedInAthleteWithResponse(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("fetch athlete: %w", err)
+	}
+	if me.HTTPResponse.StatusCode != 200 {
+		return apiError(me.HTTPResponse.StatusCode, me.Body)
+	}
+	myKey := ""
+	if me.JSON200 != nil {
+		myKey = athleteNameKey(derefStr(me.JSON200.Firstname), derefStr(me.JSON200.Lastname))
+	}
+
+	entries := make([]output.ClubFeedEntry, 0, len(feed))
+	for _, a := range feed {
+		key := athleteNameKey(a.Athlete.Firstname, a.Athlete.Lastname)
+		name := displayName(a.Athlete.Firstname, a.Athlete.Lastname)
+		if m, ok := roster[key]; ok && len(m) == 1 {
+			name = displayName(m[0].first, m[0].last)
+		}
+		entries = append(entries, output.ClubFeedEntry{
+			Athlete: name,
+			// Only flag activities as ours when the name is unambiguous in the roster.
+			Mine:               key != "" && key == myKey && len(roster[key]) <= 1,
+			Name:               a.Name,
+			SportType:          a.SportType,
+			Distance:           a.Distance,
+			MovingTime:         a.MovingTime,
+			ElapsedTime:        a.ElapsedTime,
+			TotalElevationGain: a.TotalElevationGain,
+		})
+	}
+	return p.ClubFeed(entries)
+}
+
+// clubFeedActivity mirrors a club feed entry, including the athlete name fields
+// that the generated client does not model.
+type clubFeedActivity struct {
+	Athlete struct {
+		Firstname string `json:"firstname"`
+		Lastname  string `json:"lastname"`
+	} `json:"athlete"`
+	Name               string  `json:"name"`
+	SportType          string  `json:"sport_type"`
+	Distance           float32 `json:"distance"`
+	MovingTime         int     `json:"moving_time"`
+	ElapsedTime        int     `json:"elapsed_time"`
+	TotalElevationGain float32 `json:"total_elevation_gain"`
+}
+
+type rosterName struct {
+	first, last string
+}
+
+// fetchClubRoster pages through the club's members and indexes them by
+// athleteNameKey. Keys mapping to more than one member are ambiguous.
+func fetchClubRoster(cmd *cobra.Command, api *genclient.ClientWithResponses, clubID int64) (map[string][]rosterName, error) {
+	const perPage = 200
+	roster := map[string][]rosterName{}
+	for page := 1; ; page++ {
+		resp, err := api.GetClubMembersByIdWithResponse(cmd.Context(), clubID,
+			&genclient.GetClubMembersByIdParams{Page: intPtr(page), PerPage: intPtr(perPage)})
+		if err != nil {
+			return nil, fmt.Errorf("fetch members: %w", err)
+		}
+		if resp.HTTPResponse.StatusCode != 200 {
+			return nil, apiError(resp.HTTPResponse.StatusCode, resp.Body)
+		}
+		if resp.JSON200 == nil {
+			break
+		}
+		for _, m := range *resp.JSON200 {
+			n := rosterName{first: derefStr(m.Firstname), last: derefStr(m.Lastname)}
+			if key := athleteNameKey(n.first, n.last); key != "" {
+				roster[key] = append(roster[key], n)
+			}
+		}
+		if len(*resp.JSON200) < perPage {
+			break
+		}
+	}
+	return roster, nil
+}
+
+// athleteNameKey normalizes a first name and last name (or last initial) into
+// a case-insensitive lookup key of the form "first|l".
+func athleteNameKey(first, last string) string {
+	first = strings.ToLower(strings.TrimSpace(first))
+	last = strings.ToLower(strings.TrimSpace(last))
+	if first == "" {
+		return ""
+	}
+	if r := []rune(last); len(r) > 0 {
+		return first + "|" + string(r[0])
+	}
+	return first + "|"
+}
+
+// displayName formats an athlete as "Firstname L.".
+func displayName(first, last string) string {
+	first = strings.TrimSpace(first)
+	last = strings.TrimSpace(last)
+	if r := []rune(last); len(r) > 0 {
+		return first + " " + strings.ToUpper(string(r[0])) + "."
+	}
+	return first
+}
+
+func derefStr(s *string) string {
+	if s == nil {
+		return ""
 	}
-	return output.New(os.Stdout, jsonOutput).ClubActivities(resp)
+	return *s
 }
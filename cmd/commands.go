@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/client"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/redact"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/store"
+)
+
+var (
+	historyDays    int
+	historyCommand string
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show what each day's commands cost against the API rate limits",
+	Long: `Every run of the CLI is recorded in ~/.config/strava-cli/commands.jsonl:
+the command line (with tokens and keys redacted), when it started, how long
+it took, how many API requests it sent (retries included; responses reused
+within the run are not) and how many bytes they carried.
+
+With no subcommand, totals the runs of each of the last --days days, with
+the command that made the most requests, to find the scripts and cron jobs
+that use up the rate limits. "history log" lists the runs themselves; both
+export with --json or --output csv.
+
+"strava cache gc" removes runs older than 90 days.
+
+Examples:
+  strava history
+  strava history --days 0 --output csv > usage.csv
+  strava history log --command sync
+  strava history log --days 1 --json`,
+	Args: cobra.NoArgs,
+	RunE: runHistory,
+}
+
+var historyLogCmd = &cobra.Command{
+	Use:   "log",
+	Short: "List recorded runs of the CLI, oldest first",
+	Args:  cobra.NoArgs,
+	RunE:  runHistoryLog,
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	historyCmd.PersistentFlags().IntVar(&historyDays, "days", 14, "Only the last N days, today included (0 for all)")
+	historyCmd.PersistentFlags().StringVar(&historyCommand, "command", "", "Only runs of this command (e.g. sync, \"activities list\")")
+	historyCmd.AddCommand(historyLogCmd)
+}
+
+func runHistory(cmd *cobra.Command, args []string) error {
+	runs, err := recordedRuns()
+	if err != nil {
+		return err
+	}
+	return newPrinter().CommandDays(store.UsageByDay(runs))
+}
+
+func runHistoryLog(cmd *cobra.Command, args []string) error {
+	runs, err := recordedRuns()
+	if err != nil {
+		return err
+	}
+	return newPrinter().Commands(runs)
+}
+
+// recordedRuns returns the runs in the command history that --days and
+// --command select.
+func recordedRuns() ([]store.Invocation, error) {
+	log, err := store.OpenCommandLog("")
+	if err != nil {
+		return nil, err
+	}
+	all, err := log.List()
+	if err != nil {
+		return nil, err
+	}
+	var since time.Time
+	if historyDays > 0 {
+		y, m, d := time.Now().Date()
+		since = time.Date(y, m, d-historyDays+1, 0, 0, 0, 0, time.Local)
+	}
+	runs := []store.Invocation{}
+	for _, r := range all {
+		if r.StartedAt.Before(since) || (historyCommand != "" && r.Command != historyCommand) {
+			continue
+		}
+		runs = append(runs, r)
+	}
+	return runs, nil
+}
+
+// recordCommand appends the run of ran that began at start, and what it
+// sent to the API, to the command history. Help, completion and failures to
+// parse the command line are not recorded, and neither is a failure to
+// record.
+func recordCommand(ran *cobra.Command, start time.Time, runErr error) {
+	if ran == nil || !ran.Runnable() || ran.Name() == cobra.ShellCompRequestCmd || ran.Name() == cobra.ShellCompNoDescRequestCmd {
+		return
+	}
+	if help, _ := ran.Flags().GetBool("help"); help {
+		return
+	}
+	for c := ran; c != nil; c = c.Parent() {
+		if c.Name() == "completion" {
+			return
+		}
+	}
+	log, err := store.OpenCommandLog("")
+	if err != nil {
+		return
+	}
+	requests, bytes := client.Usage()
+	inv := store.Invocation{
+		Command:    strings.TrimPrefix(ran.CommandPath(), rootCmd.Name()+" "),
+		StartedAt:  start.UTC(),
+		DurationMS: time.Since(start).Milliseconds(),
+		Requests:   requests,
+		Bytes:      bytes,
+	}
+	inv.Args = redact.Args(os.Args[1:])
+	if runErr != nil {
+		inv.Error = redact.String(runErr.Error())
+	}
+	_ = log.Append(inv)
+}
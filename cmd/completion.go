@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Dynamic shell completion. The completion functions query the API, so they
+// are bounded by completionTimeout: a slow network or a rate-limit backoff
+// should cost a missing suggestion, not a hung shell.
+
+// completionTimeout bounds the API calls made while completing.
+const completionTimeout = 5 * time.Second
+
+// completionActivities is how many recent activities are offered as IDs.
+const completionActivities = 30
+
+// sportTypes are the values Strava accepts for an activity's sport_type.
+var sportTypes = []string{
+	"AlpineSki", "BackcountrySki", "Badminton", "Canoeing", "Crossfit", "EBikeRide",
+	"Elliptical", "EMountainBikeRide", "Golf", "GravelRide", "Handcycle",
+	"HighIntensityIntervalTraining", "Hike", "IceSkate", "InlineSkate", "Kayaking",
+	"Kitesurf", "MountainBikeRide", "NordicSki", "Pickleball", "Pilates", "Racquetball",
+	"Ride", "RockClimbing", "RollerSki", "Rowing", "Run", "Sail", "Skateboard",
+	"Snowboard", "Snowshoe", "Soccer", "Squash", "StairStepper", "StandUpPaddling",
+	"Surfing", "Swim", "TableTennis", "Tennis", "TrailRun", "Velomobile", "VirtualRide",
+	"VirtualRow", "VirtualRun", "Walk", "WeightTraining", "Wheelchair", "Windsurf",
+	"Workout", "Yoga",
+}
+
+// completeActivityIDs completes the first argument with recent activity IDs,
+// described by date and name.
+func completeActivityIDs(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	ctx, cancel := context.WithTimeout(cmd.Context(), completionTimeout)
+	defer cancel()
+	acts, err := recentActivities(ctx, cmd, completionActivities)
+	if err != nil {
+		cobra.CompDebugln(err.Error(), true)
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var out []cobra.Completion
+	for _, a := range acts {
+		id := fmt.Sprint(a.ID)
+		if strings.HasPrefix(id, toComplete) {
+			out = append(out, cobra.CompletionWithDesc(id, a.StartDateLocal.Format("2006-01-02")+" "+a.Name))
+		}
+	}
+	// Keep the API's newest-first order rather than the shell's sorting.
+	return out, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+}
+
+// completeGearIDs completes the first argument with the athlete's bike and
+// shoe IDs, described by name.
+func completeGearIDs(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	ctx, cancel := context.WithTimeout(cmd.Context(), completionTimeout)
+	defer cancel()
+	api, _, err := apiClient(cmd)
+	if err != nil {
+		cobra.CompDebugln(err.Error(), true)
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	resp, err := api.GetLoggedInAthleteWithResponse(ctx)
+	if err != nil || resp.JSON200 == nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var out []cobra.Completion
+	add := func(id, name *string) {
+		if id != nil && strings.HasPrefix(*id, toComplete) {
+			out = append(out, cobra.CompletionWithDesc(*id, derefStr(name)))
+		}
+	}
+	if resp.JSON200.Bikes != nil {
+		for _, g := range *resp.JSON200.Bikes {
+			add(g.Id, g.Name)
+		}
+	}
+	if resp.JSON200.Shoes != nil {
+		for _, g := range *resp.JSON200.Shoes {
+			add(g.Id, g.Name)
+		}
+	}
+	return out, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeSportTypes completes a sport type flag value, case-insensitively.
+func completeSportTypes(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	var out []cobra.Completion
+	for _, s := range sportTypes {
+		if strings.HasPrefix(strings.ToLower(s), strings.ToLower(toComplete)) {
+			out = append(out, s)
+		}
+	}
+	return out, cobra.ShellCompDirectiveNoFileComp
+}
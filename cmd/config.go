@@ -0,0 +1,267 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/config"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/output"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Show and change local settings",
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Change a setting",
+	Long: `Change a setting in the config file: ~/.config/strava-cli/config.json, or
+config.yaml or config.toml, whose comments are kept.
+
+Keys:
+  ftp             functional threshold power, watts: IF and TSS (the if and
+                  tss list columns), and training load of rides with power
+  max-hr          maximum heart rate, bpm: training load from heart rate, and
+                  heart rate zones when your Strava profile has none
+  retention-days  days to keep other athletes' data (the club feed log that
+                  "clubs leaderboard" and "digest" keep); older entries are
+                  purged automatically. Unset, they are kept for 366 days
+  hooks.post-sync program to run whenever a sync stores new activities, with
+                  them as a JSON array on stdin (see "strava sync --help")
+  defaults.<flag> value for --<flag> on every command that has it, unless
+                  given on the command line (any flag but --yes, --force,
+                  --overwrite, --gear-id and --hide)
+
+Strava's API does not give the first two, so without them training load
+falls back to the highest heart rate in your history, and analytics that
+need zones use the ones on your Strava profile.
+
+Examples:
+  strava config set ftp 265
+  strava config set max-hr 190
+  strava config set retention-days 7
+  strava config set hooks.post-sync ~/bin/on-new-activities.sh
+  strava config set defaults.per-page 100
+  strava config set defaults.units imperial
+  strava config set defaults.json true`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeConfigKeys,
+	RunE:              runConfigSet,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get [key]",
+	Short: "Show settings",
+	Long: `Show one setting, or all of them with a description.
+
+Examples:
+  strava config get
+  strava config get ftp`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeConfigKeys,
+	RunE:              runConfigGet,
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:               "unset <key>",
+	Short:             "Clear a setting",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeConfigKeys,
+	RunE:              runConfigUnset,
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configSetCmd, configGetCmd, configUnsetCmd)
+}
+
+// configKey is a setting that config set, get and unset handle: a whole
+// number from min to max, 0 when unset, or a text, "" when unset, that check
+// validates and normalizes, or the default of a flag.
+type configKey struct {
+	name, help string
+	min, max   int
+	field      func(*config.Config) *int
+	text       func(*config.Config) *string
+	check      func(string) (string, error)
+	flag       string // for defaults.<flag>: the flag whose default it is
+}
+
+var configKeys = []configKey{
+	{name: "ftp", help: "Functional threshold power (W)", min: 50, max: 600,
+		field: func(c *config.Config) *int { return &c.FTP }},
+	{name: "max-hr", help: "Maximum heart rate (bpm)", min: 100, max: 240,
+		field: func(c *config.Config) *int { return &c.MaxHR }},
+	{name: "retention-days", help: "Days to keep other athletes' data", min: 1, max: 366,
+		field: func(c *config.Config) *int { return &c.RetentionDays }},
+	{name: "hooks.post-sync", help: "Program run with new activities after a sync",
+		text: func(c *config.Config) *string { return &c.Hooks.PostSync }, check: checkHook},
+}
+
+// value returns k's setting in cfg, "" when unset.
+func (k configKey) value(cfg *config.Config) string {
+	if k.flag != "" {
+		return cfg.Defaults[k.flag]
+	}
+	if k.text != nil {
+		return *k.text(cfg)
+	}
+	if v := *k.field(cfg); v != 0 {
+		return strconv.Itoa(v)
+	}
+	return ""
+}
+
+func findConfigKey(name string) (configKey, error) {
+	if k, ok, err := defaultKey(name); ok {
+		return k, err
+	}
+	var names []string
+	for _, k := range configKeys {
+		if k.name == name {
+			return k, nil
+		}
+		names = append(names, k.name)
+	}
+	return configKey{}, fmt.Errorf("unknown key %q: must be one of %s, or %s<flag>", name, strings.Join(names, ", "), defaultsPrefix)
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) error {
+	k, err := findConfigKey(args[0])
+	if err != nil {
+		return err
+	}
+	var (
+		n    int
+		text string
+	)
+	switch {
+	case k.flag != "":
+		if text, err = checkDefault(k.flag, args[1]); err != nil {
+			return err
+		}
+	case k.text != nil:
+		if text, err = k.check(args[1]); err != nil {
+			return err
+		}
+	default:
+		if n, err = strconv.Atoi(strings.TrimSpace(args[1])); err != nil || n < k.min || n > k.max {
+			return fmt.Errorf("invalid %s %q: want a whole number from %d to %d", k.name, args[1], k.min, k.max)
+		}
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	switch {
+	case k.flag != "":
+		if cfg.Defaults == nil {
+			cfg.Defaults = config.FlagDefaults{}
+		}
+		cfg.Defaults[k.flag] = text
+	case k.text != nil:
+		*k.text(cfg) = text
+	default:
+		*k.field(cfg) = n
+	}
+	if err := config.Save(cfg); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Set %s to %s.\n", k.name, k.value(cfg))
+	return nil
+}
+
+func runConfigUnset(cmd *cobra.Command, args []string) error {
+	k, err := findConfigKey(args[0])
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	switch {
+	case k.flag != "":
+		delete(cfg.Defaults, k.flag)
+	case k.text != nil:
+		*k.text(cfg) = ""
+	default:
+		*k.field(cfg) = 0
+	}
+	if err := config.Save(cfg); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Cleared %s.\n", k.name)
+	return nil
+}
+
+func runConfigGet(cmd *cobra.Command, args []string) error {
+	keys := configKeys
+	if len(args) == 1 {
+		k, err := findConfigKey(args[0])
+		if err != nil {
+			return err
+		}
+		keys = []configKey{k}
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		keys = append(slices.Clone(keys), setDefaultKeys(cfg)...)
+	}
+	if jsonOutput {
+		values := map[string]any{}
+		for _, k := range keys {
+			values[k.name] = nil
+			switch v := k.value(cfg); {
+			case v == "":
+			case k.text != nil || k.flag != "":
+				values[k.name] = v
+			default:
+				values[k.name] = *k.field(cfg)
+			}
+		}
+		return output.PrintJSON(stdout(), values)
+	}
+	if len(args) == 1 {
+		if v := keys[0].value(cfg); v != "" {
+			fmt.Fprintln(stdout(), v)
+		}
+		return nil
+	}
+	width := 15
+	for _, k := range keys {
+		width = max(width, len(k.name))
+	}
+	fmt.Fprintf(stdout(), "%-*s  %-9s  %s\n", width, "KEY", "VALUE", "DESCRIPTION")
+	fmt.Fprintln(stdout(), strings.Repeat("─", 45+width))
+	for _, k := range keys {
+		value := k.value(cfg)
+		if value == "" {
+			value = "-"
+		}
+		fmt.Fprintf(stdout(), "%-*s  %-9s  %s\n", width, k.name, value, k.help)
+	}
+	return nil
+}
+
+func completeConfigKeys(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	if strings.HasPrefix(toComplete, defaultsPrefix) {
+		return completeDefaultKeys(), cobra.ShellCompDirectiveNoFileComp
+	}
+	var names []string
+	for _, k := range configKeys {
+		names = append(names, k.name+"\t"+k.help)
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
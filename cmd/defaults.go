@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/config"
+)
+
+// defaultsPrefix starts the config keys that set a flag's default, as in
+// defaults.per-page.
+const defaultsPrefix = "defaults."
+
+// noDefault are the flags the config may not set: --yes would confirm
+// every change to Strava data without asking, --force and --overwrite
+// would replace files and uploads unasked, and --gear-id and --hide would
+// change every activity updated.
+var noDefault = map[string]bool{
+	"yes": true, "help": true, "version": true,
+	"force": true, "overwrite": true, "gear-id": true, "hide": true,
+}
+
+// outputFlags choose the output format together; given any of them on the
+// command line, the config's defaults for the others do not apply, so that
+// defaults.output csv and --json do not clash.
+var outputFlags = []string{"json", "output", "template", "jq"}
+
+// findFlags returns the flags called name, on every command that has one.
+func findFlags(name string) []*pflag.Flag {
+	var found []*pflag.Flag
+	var walk func(c *cobra.Command)
+	walk = func(c *cobra.Command) {
+		for _, f := range []*pflag.Flag{c.PersistentFlags().Lookup(name), c.Flags().Lookup(name)} {
+			if f != nil && !slices.Contains(found, f) {
+				found = append(found, f)
+			}
+		}
+		for _, sub := range c.Commands() {
+			walk(sub)
+		}
+	}
+	walk(rootCmd)
+	return found
+}
+
+// defaultKey returns the config key for the default of the flag named by a
+// defaults.<flag> key.
+func defaultKey(name string) (configKey, bool, error) {
+	flag, ok := strings.CutPrefix(name, defaultsPrefix)
+	if !ok {
+		return configKey{}, false, nil
+	}
+	if len(findFlags(flag)) == 0 {
+		return configKey{}, true, fmt.Errorf("unknown key %q: no command has a --%s flag", name, flag)
+	}
+	if noDefault[flag] {
+		return configKey{}, true, fmt.Errorf("--%s cannot have a default", flag)
+	}
+	return configKey{name: name, help: "Default for --" + flag, flag: flag}, true, nil
+}
+
+// checkDefault validates a flag's default as each flag of that name would
+// parse it.
+func checkDefault(flag, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	for _, f := range findFlags(flag) {
+		if err := scratchValue(f).Set(value); err != nil {
+			return "", fmt.Errorf("invalid default for --%s: %w", flag, err)
+		}
+	}
+	return value, nil
+}
+
+// scratchValue returns a new value of f's type, to parse a default without
+// setting f itself.
+func scratchValue(f *pflag.Flag) pflag.Value {
+	fs := pflag.NewFlagSet(f.Name, pflag.ContinueOnError)
+	switch f.Value.Type() {
+	case "bool":
+		fs.Bool(f.Name, false, "")
+	case "duration":
+		fs.Duration(f.Name, 0, "")
+	case "float64":
+		fs.Float64(f.Name, 0, "")
+	case "int":
+		fs.Int(f.Name, 0, "")
+	case "int64":
+		fs.Int64(f.Name, 0, "")
+	case "stringArray":
+		fs.StringArray(f.Name, nil, "")
+	case "stringSlice":
+		fs.StringSlice(f.Name, nil, "")
+	default:
+		fs.String(f.Name, "", "")
+	}
+	return fs.Lookup(f.Name).Value
+}
+
+// setDefaultKeys returns the config keys of the flag defaults set in cfg,
+// sorted.
+func setDefaultKeys(cfg *config.Config) []configKey {
+	keys := make([]configKey, 0, len(cfg.Defaults))
+	for flag := range cfg.Defaults {
+		keys = append(keys, configKey{name: defaultsPrefix + flag, help: "Default for --" + flag, flag: flag})
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].name < keys[j].name })
+	return keys
+}
+
+// applyDefaults gives the flags of cmd not set on the command line the
+// values the config has for them.
+func applyDefaults(cmd *cobra.Command) error {
+	cfg, err := config.Load()
+	if err != nil || len(cfg.Defaults) == 0 {
+		return nil // commands reading the config report errors with it
+	}
+	flags := cmd.Flags()
+	skip := map[string]bool{}
+	for _, name := range outputFlags {
+		if flags.Changed(name) {
+			for _, other := range outputFlags {
+				skip[other] = true
+			}
+		}
+	}
+	names := make([]string, 0, len(cfg.Defaults))
+	for name := range cfg.Defaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		f := flags.Lookup(name)
+		if f == nil || f.Changed || skip[name] || noDefault[name] {
+			continue
+		}
+		if err := flags.Set(name, cfg.Defaults[name]); err != nil {
+			return fmt.Errorf("config %s%s: %w", defaultsPrefix, name, err)
+		}
+	}
+	return nil
+}
+
+// completeDefaultKeys offers defaults.<flag> for every flag that may have a
+// default.
+func completeDefaultKeys() []string {
+	seen := map[string]bool{}
+	var keys []string
+	var walk func(c *cobra.Command)
+	walk = func(c *cobra.Command) {
+		add := func(f *pflag.Flag) {
+			if !seen[f.Name] && !noDefault[f.Name] && !f.Hidden {
+				seen[f.Name] = true
+				keys = append(keys, defaultsPrefix+f.Name+"\t"+f.Usage)
+			}
+		}
+		c.PersistentFlags().VisitAll(add)
+		c.Flags().VisitAll(add)
+		for _, sub := range c.Commands() {
+			walk(sub)
+		}
+	}
+	walk(rootCmd)
+	sort.Strings(keys)
+	return keys
+}
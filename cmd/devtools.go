@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+
+	"github.com/spf13/cobra"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/fsutil"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/spec"
+)
+
+// The files regen reads and writes, relative to the repository root.
+const (
+	specFile      = "strava.minimal.json"
+	codegenConfig = "oapi-codegen.yaml"
+	overlaysDir   = "overlays"
+)
+
+// codegenModule is the generator the client was last generated with, run
+// with `go run` when oapi-codegen is not installed.
+const codegenModule = "github.com/oapi-codegen/oapi-codegen/v2/cmd/oapi-codegen@v2.5.1"
+
+var (
+	regenURL      string
+	regenDir      string
+	regenAdd      []string
+	regenSpecOnly bool
+)
+
+var devtoolsCmd = &cobra.Command{
+	Use:    "devtools",
+	Short:  "Tools for working on stravacli itself",
+	Hidden: true,
+}
+
+var devtoolsRegenCmd = &cobra.Command{
+	Use:   "regen",
+	Short: "Regenerate the API client from Strava's latest API definition",
+	Long: `Fetch Strava's published Swagger definition, patch known bugs in it with
+the overlays in overlays/, keep the operations the client already has (plus
+any given with --add), inline every reference, convert it to OpenAPI 3 and
+write strava.minimal.json; then run oapi-codegen on it to regenerate
+internal/client/strava_gen.go.
+
+Run it from the repository root, or point --dir at it. go generate runs it
+for internal/client.
+
+Examples:
+  strava devtools regen
+  strava devtools regen --add "PUT /segments/{id}/starred"
+  go generate ./internal/client`,
+	Args: cobra.NoArgs,
+	RunE: runDevtoolsRegen,
+}
+
+func init() {
+	rootCmd.AddCommand(devtoolsCmd)
+	devtoolsCmd.AddCommand(devtoolsRegenCmd)
+	devtoolsRegenCmd.Flags().StringVar(&regenURL, "url", spec.Upstream, "API definition to start from (URL or file)")
+	devtoolsRegenCmd.Flags().StringVar(&regenDir, "dir", ".", "Repository root")
+	devtoolsRegenCmd.Flags().StringArrayVar(&regenAdd, "add", nil,
+		"Operation to add to the client, as METHOD /path (repeatable)")
+	devtoolsRegenCmd.Flags().BoolVar(&regenSpecOnly, "spec-only", false,
+		"Write strava.minimal.json without regenerating the client")
+}
+
+func runDevtoolsRegen(cmd *cobra.Command, args []string) error {
+	specPath := filepath.Join(regenDir, specFile)
+	data, err := os.ReadFile(specPath)
+	if err != nil {
+		return fmt.Errorf("%w (run from the repository root, or use --dir)", err)
+	}
+	var current map[string]any
+	if err := json.Unmarshal(data, &current); err != nil {
+		return fmt.Errorf("parse %s: %w", specFile, err)
+	}
+	before := spec.Operations(current)
+	ops := slices.Clone(before)
+	for _, a := range regenAdd {
+		op, err := spec.ParseOperation(a)
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(ops, op) {
+			ops = append(ops, op)
+		}
+	}
+
+	overlays, err := spec.LoadOverlays(filepath.Join(regenDir, overlaysDir))
+	if err != nil {
+		return err
+	}
+	loader := &spec.Loader{Client: httpClient(), Overlays: overlays}
+	doc, err := loader.Build(cmd.Context(), regenURL, ops)
+	if err != nil {
+		return err
+	}
+	out, err := spec.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	if err := fsutil.WriteFile(specPath, out, 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %s: %d operations (%d added), %d overlays applied\n",
+		specFile, len(ops), len(ops)-len(before), len(overlays))
+	if regenSpecOnly {
+		return nil
+	}
+
+	var gen *exec.Cmd
+	if _, err := exec.LookPath("oapi-codegen"); err == nil {
+		gen = exec.CommandContext(cmd.Context(), "oapi-codegen", "-config", codegenConfig, specFile)
+	} else {
+		gen = exec.CommandContext(cmd.Context(), "go", "run", codegenModule, "-config", codegenConfig, specFile)
+	}
+	gen.Dir = regenDir
+	gen.Stdout = os.Stderr
+	gen.Stderr = os.Stderr
+	if err := gen.Run(); err != nil {
+		return fmt.Errorf("oapi-codegen: %w", err)
+	}
+	fmt.Fprintln(os.Stderr, "Regenerated internal/client/strava_gen.go; review the diff, then run: go build ./... && go test ./...")
+	return nil
+}
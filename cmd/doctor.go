@@ -0,0 +1,333 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/auth"
+	genclient "github.com/Brainsoft-Raxat/strava-cli/internal/client"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/config"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/fsutil"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/output"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/store"
+)
+
+// maxClockSkew is how far the local clock may differ from Strava's before
+// doctor warns: token expiry and date filters are computed locally.
+const maxClockSkew = time.Minute
+
+// rateLimitWarnFraction is the share of a rate limit doctor warns at.
+const rateLimitWarnFraction = 0.8
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check your setup and connection to Strava",
+	Long: `Run a series of read-only checks and report each as ok, warn or fail,
+with a hint on how to fix it:
+
+  - the config file, its permissions, and the API app settings
+  - authentication, by fetching your profile
+  - listing activities (one)
+  - rate limit usage
+  - clock skew against Strava's servers
+  - the integrity of local state: synced history, caches, jobs
+
+Include the output when reporting a bug. Exits non-zero if any check fails.
+
+Examples:
+  strava doctor
+  strava doctor --json`,
+	Args: cobra.NoArgs,
+	RunE: runDoctor,
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
+
+// doctorCheck is the outcome of one doctor check.
+type doctorCheck struct {
+	Name string `json:"name"`
+	auth.Check
+	Hint string `json:"hint,omitempty"`
+}
+
+// doctorReport is the --json form of `doctor`.
+type doctorReport struct {
+	Version  string        `json:"version"`
+	Platform string        `json:"platform"`
+	Checks   []doctorCheck `json:"checks"`
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	r := doctorReport{
+		Version:  rootCmd.Version,
+		Platform: runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	add := func(name, level, hint, format string, a ...any) {
+		r.Checks = append(r.Checks, doctorCheck{
+			Name:  name,
+			Check: auth.Check{Level: level, Message: fmt.Sprintf(format, a...)},
+			Hint:  hint,
+		})
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		add("config", auth.CheckFail, "fix the file, its includes or the environment variables it refers to; or delete it and run: stravacli auth login", "%v", err)
+	} else {
+		add("config", auth.CheckOK, "", "config file readable")
+		r.Checks = append(r.Checks, doctorPermissions()...)
+		for _, c := range auth.Diagnose(cfg) {
+			r.Checks = append(r.Checks, doctorCheck{Name: "auth", Check: c})
+		}
+		if cfg.ClientID != "" && cfg.Tokens.AccessToken != "" {
+			r.Checks = append(r.Checks, doctorAPI(cmd, cfg)...)
+		}
+	}
+	r.Checks = append(r.Checks, doctorState()...)
+
+	failed := 0
+	for _, c := range r.Checks {
+		if c.Level == auth.CheckFail {
+			failed++
+		}
+	}
+	if jsonOutput {
+		if err := output.PrintJSON(stdout(), r); err != nil {
+			return err
+		}
+	} else {
+		fmt.Fprintf(stdout(), "stravacli %s (%s)\n\n", r.Version, r.Platform)
+		for _, c := range r.Checks {
+			fmt.Fprintf(stdout(), "  %-4s  %-12s  %s\n", strings.ToUpper(c.Level), c.Name, c.Message)
+			if c.Hint != "" {
+				fmt.Fprintf(stdout(), "  %-4s  %-12s  → %s\n", "", "", c.Hint)
+			}
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(r.Checks))
+	}
+	return nil
+}
+
+// doctorPermissions checks that the config directory and file, which hold
+// the client secret and tokens, are private to the user (see
+// config.CheckPermissions).
+func doctorPermissions() []doctorCheck {
+	if runtime.GOOS == "windows" {
+		return nil // permission bits do not apply; the profile directory is private
+	}
+	check := func(level, message, hint string) doctorCheck {
+		return doctorCheck{Name: "permissions", Check: auth.Check{Level: level, Message: message}, Hint: hint}
+	}
+	problems, err := config.CheckPermissions()
+	if err != nil {
+		return []doctorCheck{check(auth.CheckFail, err.Error(), "")}
+	}
+	if len(problems) == 0 {
+		return []doctorCheck{check(auth.CheckOK, "config directory and file are private", "")}
+	}
+	var checks []doctorCheck
+	for _, p := range problems {
+		// An open directory only reveals file names; the rest can leak or
+		// mix up the tokens.
+		level := auth.CheckFail
+		if p.Minor {
+			level = auth.CheckWarn
+		}
+		checks = append(checks, check(level, p.Message, p.Hint))
+	}
+	return checks
+}
+
+// doctorAPI fetches the profile and one activity, and checks the rate limit
+// and clock skew from the response headers.
+func doctorAPI(cmd *cobra.Command, cfg *config.Config) []doctorCheck {
+	httpClient := genclient.NewHTTPClient(cfg)
+	get := func(path string) (*http.Response, []byte, time.Time, error) {
+		req, err := http.NewRequestWithContext(cmd.Context(), http.MethodGet, "https://www.strava.com/api/v3"+path, nil)
+		if err != nil {
+			return nil, nil, time.Time{}, err
+		}
+		sent := time.Now()
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return nil, nil, time.Time{}, err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		// The server's Date is stamped somewhere between sending and receiving.
+		return resp, body, sent.Add(time.Since(sent) / 2), err
+	}
+	check := func(name, level, message, hint string) doctorCheck {
+		return doctorCheck{Name: name, Check: auth.Check{Level: level, Message: message}, Hint: hint}
+	}
+
+	var checks []doctorCheck
+	resp, body, mid, err := get("/athlete")
+	switch {
+	case err != nil:
+		return append(checks, check("athlete", auth.CheckFail, fmt.Sprintf("fetch profile: %v", err),
+			"check your network connection; if the token refresh failed, run: stravacli auth login"))
+	case resp.StatusCode != http.StatusOK:
+		checks = append(checks, check("athlete", auth.CheckFail, apiError(resp.StatusCode, body).Error(), ""))
+	default:
+		checks = append(checks, check("athlete", auth.CheckOK, "profile fetched; token works", ""))
+	}
+
+	for _, l := range []struct {
+		name, prefix string
+	}{{"rate limit", "X-RateLimit"}, {"read limit", "X-ReadRateLimit"}} {
+		rl := parseRateLimit(resp.Header, l.prefix)
+		if rl == nil {
+			continue
+		}
+		msg := fmt.Sprintf("%d/%d per 15 min, %d/%d per day", rl.ShortUsage, rl.ShortLimit, rl.DailyUsage, rl.DailyLimit)
+		switch {
+		case rl.ShortUsage >= rl.ShortLimit || rl.DailyUsage >= rl.DailyLimit:
+			checks = append(checks, check(l.name, auth.CheckFail, msg+" — exhausted",
+				"requests fail with HTTP 429 until the limit resets (every 15 minutes, and daily at midnight UTC)"))
+		case float64(rl.ShortUsage) >= rateLimitWarnFraction*float64(rl.ShortLimit) ||
+			float64(rl.DailyUsage) >= rateLimitWarnFraction*float64(rl.DailyLimit):
+			checks = append(checks, check(l.name, auth.CheckWarn, msg+" — nearly exhausted",
+				"avoid large syncs or audits until the limit resets"))
+		default:
+			checks = append(checks, check(l.name, auth.CheckOK, msg, ""))
+		}
+	}
+
+	if server, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+		skew := mid.Sub(server).Round(time.Second)
+		msg := fmt.Sprintf("local clock is %v off Strava's", skew)
+		if skew.Abs() > maxClockSkew {
+			checks = append(checks, check("clock", auth.CheckWarn, msg,
+				"enable network time sync; a wrong clock makes tokens look expired and shifts date filters"))
+		} else {
+			checks = append(checks, check("clock", auth.CheckOK, msg, ""))
+		}
+	}
+
+	if resp.StatusCode == http.StatusOK {
+		resp, body, _, err := get("/athlete/activities?per_page=1")
+		switch {
+		case err != nil:
+			checks = append(checks, check("activities", auth.CheckFail, fmt.Sprintf("list activities: %v", err), ""))
+		case resp.StatusCode != http.StatusOK:
+			checks = append(checks, check("activities", auth.CheckFail, apiError(resp.StatusCode, body).Error(),
+				"log in again granting activity:read_all: stravacli auth login"))
+		default:
+			checks = append(checks, check("activities", auth.CheckOK, "activities listed", ""))
+		}
+	}
+	return checks
+}
+
+// doctorState checks that every local state file parses, since a corrupt
+// one makes the commands using it fail.
+func doctorState() []doctorCheck {
+	var checks []doctorCheck
+	fail := func(err error, hint string) {
+		checks = append(checks, doctorCheck{Name: "state", Check: auth.Check{Level: auth.CheckFail, Message: err.Error()}, Hint: hint})
+	}
+	rebuilt := "delete the file; it is rebuilt as needed"
+
+	if hist, err := store.OpenActivities(""); err != nil {
+		fail(err, "delete the file, then run: stravacli sync")
+	} else if _, err := hist.List(); err != nil {
+		fail(err, "run: stravacli sync --full")
+	}
+	if _, err := store.OpenWeatherCache(""); err != nil {
+		fail(err, rebuilt)
+	}
+	if _, err := store.OpenEffortWeatherCache(""); err != nil {
+		fail(err, rebuilt)
+	}
+	if _, err := store.OpenHRRCache(""); err != nil {
+		fail(err, rebuilt)
+	}
+	if _, err := store.OpenClassCache(""); err != nil {
+		fail(err, rebuilt)
+	}
+	if _, err := store.OpenStressCache(""); err != nil {
+		fail(err, rebuilt)
+	}
+	if _, err := store.OpenPowerZoneCache(""); err != nil {
+		fail(err, rebuilt)
+	}
+	if _, err := store.OpenBestsCache(""); err != nil {
+		fail(err, rebuilt)
+	}
+	if _, err := store.OpenRecords(""); err != nil {
+		fail(err, "delete the file, then run: stravacli prs")
+	}
+	if paths, err := filepath.Glob(mustPath("elevation-*.json")); err == nil {
+		for _, p := range paths {
+			dataset := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(p), "elevation-"), ".json")
+			if _, err := store.OpenElevationCache(dataset, p); err != nil {
+				fail(err, rebuilt)
+			}
+		}
+	}
+	if _, err := store.OpenFeatures(""); err != nil {
+		fail(err, rebuilt)
+	}
+	if _, err := store.OpenDriftReport(""); err != nil {
+		fail(err, "delete the file; --strict-decode starts a new report")
+	}
+	if _, err := store.OpenMeta(""); err != nil {
+		fail(err, "fix the JSON by hand, or restore it from a meta list --json export; it cannot be rebuilt")
+	}
+	if _, err := store.OpenClubFeed(""); err != nil {
+		fail(err, "delete the file; club leaderboards then count the current feed as seen today")
+	}
+	if _, err := store.OpenLedger(""); err != nil {
+		fail(err, "move the file aside; without it, uploads are no longer checked for duplicates")
+	}
+	if _, err := store.OpenPushes(""); err != nil {
+		fail(err, "move the file aside; the next push then needs --after, and may send activities again")
+	}
+	if log, err := store.OpenCommandLog(""); err != nil {
+		fail(err, "")
+	} else if _, err := log.List(); err != nil {
+		fail(err, "delete the file; only the command history is lost")
+	}
+	if jobs, err := store.OpenJobs(""); err != nil {
+		fail(err, "")
+	} else if _, err := jobs.List(); err != nil {
+		fail(err, "run: stravacli jobs clean")
+	}
+	tmps, _ := filepath.Glob(mustPath("*.tmp"))
+	if dir, err := config.Dir(); err == nil {
+		found, _ := fsutil.FindTemp(dir, true, time.Now())
+		for _, e := range found {
+			tmps = append(tmps, e.Path)
+		}
+	}
+	if len(tmps) > 0 {
+		checks = append(checks, doctorCheck{Name: "state",
+			Check: auth.Check{Level: auth.CheckWarn, Message: fmt.Sprintf("interrupted write left %s", strings.Join(tmps, ", "))},
+			Hint:  "run: stravacli cache gc"})
+	}
+	if len(checks) == 0 {
+		checks = append(checks, doctorCheck{Name: "state", Check: auth.Check{Level: auth.CheckOK, Message: "local history and caches readable"}})
+	}
+	return checks
+}
+
+// mustPath returns the location of a state file, or "" (matching nothing)
+// when the config directory cannot be determined.
+func mustPath(name string) string {
+	p, err := store.Path(name)
+	if err != nil {
+		return ""
+	}
+	return p
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/erg"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/fit"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/fsutil"
+)
+
+var (
+	ergFile    string
+	ergMergeHR string
+	ergOffset  time.Duration
+	ergStart   string
+	ergFTP     int
+	ergOut     string
+)
+
+var activitiesUploadErgCmd = &cobra.Command{
+	Use:   "erg",
+	Short: "Upload an indoor trainer workout from an ERG file",
+	Long: `Turn an ERG workout (the power course TrainerRoad, Golden Cheetah, Zwift
+and PerfPRO export) into a FIT activity and upload it as an indoor ride.
+
+The ERG file holds only the target power, so the activity has one record a
+second at that power. --merge-hr adds heart rate from a FIT file recorded at
+the same time, for example by a watch or a chest strap app: each second gets
+the latest heart rate at or before it, and gaps of more than 5 seconds are
+left empty rather than filled in.
+
+The workout starts at --start, or, with --merge-hr, at the first record of
+the heart rate file. If the trainer was started later than the heart rate
+recording, give the difference as --offset.
+
+Courses in percent of FTP (MRC files) need --ftp to be turned into watts.
+
+The merged file is written to --out (default: next to the ERG file, as
+<name>-merged.fit) and then uploaded like any other file, so the upload
+ledger and queue apply to it.
+
+Examples:
+  strava activities upload erg --file results.erg --merge-hr hr.fit --yes --wait
+  strava activities upload erg --file results.erg --merge-hr hr.fit --offset 45s --dry-run
+  strava activities upload erg --file sweetspot.mrc --ftp 250 --start 2025-03-01T07:30:00+01:00 --yes`,
+	Args: cobra.NoArgs,
+	RunE: runActivitiesUploadErg,
+}
+
+func init() {
+	activitiesUploadCmd.AddCommand(activitiesUploadErgCmd)
+
+	f := activitiesUploadErgCmd.Flags()
+	f.StringVar(&ergFile, "file", "", "ERG or MRC workout file")
+	f.StringVar(&ergMergeHR, "merge-hr", "", "FIT file to take heart rate from")
+	f.DurationVar(&ergOffset, "offset", 0, "How long after the start of the heart rate file the workout began")
+	f.StringVar(&ergStart, "start", "", "Start of the workout, as RFC3339 or a Unix timestamp (default: from --merge-hr)")
+	f.IntVar(&ergFTP, "ftp", 0, "FTP in watts, for courses in percent of FTP")
+	f.StringVar(&ergOut, "out", "", "Where to write the merged FIT file (default: <file>-merged.fit)")
+	f.StringVar(&uploadName, "name", "", "Activity name (default: the workout's description)")
+	f.StringVar(&uploadDescription, "description", "", "Activity description")
+	f.BoolVar(&uploadWait, "wait", false, "Poll until Strava finishes processing")
+	f.BoolVar(&uploadForce, "force", false, "Upload even if the file was uploaded before")
+	f.Bool("yes", false, "Skip interactive confirmation")
+	f.Bool("dry-run", false, "Write the merged file and print what would be uploaded without calling the API")
+	activitiesUploadErgCmd.MarkFlagRequired("file")
+}
+
+func runActivitiesUploadErg(cmd *cobra.Command, args []string) error {
+	in, err := os.Open(ergFile)
+	if err != nil {
+		return err
+	}
+	course, err := erg.Parse(in)
+	in.Close()
+	if err != nil {
+		return fmt.Errorf("%s: %w", ergFile, err)
+	}
+
+	var hr []fit.Record
+	if ergMergeHR != "" {
+		b, err := os.ReadFile(ergMergeHR)
+		if err != nil {
+			return err
+		}
+		a, err := fit.Decode(bytes.NewReader(b))
+		if err != nil {
+			return fmt.Errorf("%s: %w", ergMergeHR, err)
+		}
+		hr = a.Records
+		if len(hr) == 0 {
+			return fmt.Errorf("%s has no records to take heart rate from", ergMergeHR)
+		}
+	}
+
+	var start time.Time
+	switch {
+	case ergStart != "":
+		if start, err = parseDate("start", ergStart); err != nil {
+			return err
+		}
+	case hr != nil:
+		start = hr[0].Time
+	default:
+		return fmt.Errorf("--start is required without --merge-hr: an ERG file does not say when it was ridden")
+	}
+	start = start.Add(ergOffset)
+
+	recs, err := course.Records(start, ergFTP)
+	if err != nil {
+		return fmt.Errorf("%w (pass --ftp)", err)
+	}
+	if hr != nil {
+		fit.MergeHeartRate(recs, hr)
+		if !hasHeartRate(recs) {
+			fmt.Fprintf(os.Stderr, "warning: no heart rate in %s overlaps the workout; check --start and --offset\n", ergMergeHR)
+		}
+	}
+
+	out := ergOut
+	if out == "" {
+		out = strings.TrimSuffix(ergFile, filepath.Ext(ergFile)) + "-merged.fit"
+	}
+	var buf bytes.Buffer
+	if err := fit.Encode(&buf, fit.Activity{Sport: fit.SportCycling, SubSport: fit.SubSportIndoorCycling, Records: recs}); err != nil {
+		return err
+	}
+	if err := fsutil.WriteFile(out, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %s (%s, %d records)\n", out, course.Duration(), len(recs))
+
+	if uploadName == "" {
+		uploadName = course.Description
+	}
+	uploadFile, uploadDir = out, ""
+	uploadDataType, uploadTrainer = "fit", true
+	return runActivitiesUpload(cmd, nil)
+}
+
+func hasHeartRate(recs []fit.Record) bool {
+	for _, r := range recs {
+		if r.HeartRate >= 0 {
+			return true
+		}
+	}
+	return false
+}
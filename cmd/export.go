@@ -0,0 +1,612 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/analysis"
+	genclient "github.com/Brainsoft-Raxat/strava-cli/internal/client"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/fsutil"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/influx"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/metrics"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/output"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/parquet"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/redact"
+)
+
+var (
+	promListen   string
+	promInterval time.Duration
+)
+
+var (
+	influxAfter   string
+	influxBefore  string
+	influxSport   string
+	influxStreams bool
+	influxURL     string
+	influxOrg     string
+	influxBucket  string
+	influxDB      string
+	influxToken   string
+)
+
+var (
+	markdownDir       string
+	markdownTemplate  string
+	markdownAfter     string
+	markdownBefore    string
+	markdownSport     string
+	markdownOverwrite bool
+)
+
+var (
+	parquetOut     string
+	parquetStreams bool
+	parquetAfter   string
+	parquetBefore  string
+	parquetSport   string
+)
+
+// influxBatchLines is how many lines are posted to InfluxDB at a time.
+const influxBatchLines = 5000
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export your Strava data to other systems",
+}
+
+var exportPrometheusCmd = &cobra.Command{
+	Use:   "prometheus",
+	Short: "Serve athlete stats and rate limit usage as Prometheus metrics",
+	Long: `Serve your athlete stats as Prometheus metrics on /metrics, for a homelab
+dashboard. Every --interval the stats are fetched again (one API request), so
+the metrics are never older than that however often they are scraped.
+
+Metrics, each labeled by period (recent, the last 4 weeks; ytd; all) and
+sport (ride, run, swim):
+  strava_activities, strava_distance_meters, strava_moving_time_seconds,
+  strava_elapsed_time_seconds, strava_elevation_gain_meters
+and:
+  strava_biggest_ride_distance_meters, strava_biggest_climb_elevation_gain_meters
+  strava_rate_limit, strava_rate_limit_usage (labeled by limit, overall or
+    read, and window, 15m or daily)
+  strava_exporter_up, strava_exporter_last_success_timestamp_seconds,
+    strava_exporter_collect_duration_seconds
+
+When a refresh fails the last metrics stay served, with strava_exporter_up 0.
+Stop with Ctrl-C.
+
+Examples:
+  strava export prometheus
+  strava export prometheus --listen 127.0.0.1:9100 --interval 30m
+
+  # prometheus.yml
+  scrape_configs:
+    - job_name: strava
+      static_configs:
+        - targets: ["localhost:9100"]`,
+	Args: cobra.NoArgs,
+	RunE: runExportPrometheus,
+}
+
+var exportInfluxCmd = &cobra.Command{
+	Use:   "influx",
+	Short: "Export activities, and their streams, as InfluxDB line protocol",
+	Long: `Write activities as InfluxDB line protocol, to stdout or straight to an
+InfluxDB server, to feed Grafana dashboards.
+
+Each activity is a strava_activity point at its start time, tagged by
+sport_type and gear_id, with its distance, times, speeds, elevation gain,
+heart rate, power and Relative Effort as fields. --streams adds a
+strava_stream point per sample (one API request per activity), tagged by
+activity_id and sport_type, with distance, altitude, velocity, grade, heart
+rate, cadence, watts and temperature. Times are in seconds.
+
+With --url the points are posted to the server: InfluxDB 2 or 3 with --org
+and --bucket, or InfluxDB 1 with --db. The token is read from --token or
+the INFLUX_TOKEN environment variable.
+
+The activities come from the synced history (see "strava sync") when there
+is one.
+
+Examples:
+  strava export influx --after 2024-01-01 > activities.lp
+  strava export influx --url http://localhost:8086 --org home --bucket strava
+  strava export influx --after 2024-06-01 --streams --url http://localhost:8086 --db strava`,
+	Args: cobra.NoArgs,
+	RunE: runExportInflux,
+}
+
+var exportParquetCmd = &cobra.Command{
+	Use:   "parquet",
+	Short: "Export activities, and their streams, as Parquet files",
+	Long: `Write activities to an Apache Parquet file, one row per activity, for
+pandas, Polars, DuckDB or Spark.
+
+The columns are the activity's ID, name, sport type, start (UTC) and local
+start, distance, moving and elapsed time, elevation gain, speeds, heart
+rate, power, kilojoules, Relative Effort, gear ID and its commute, trainer,
+manual and private flags, in the API's units (meters, seconds, meters per
+second). Heart rate, power and the like are null when an activity has none.
+
+--streams also writes the samples of each activity's streams (one API
+request per activity) to a second file next to --out, named after it with
+-streams (activities-streams.parquet): a row per sample with activity_id,
+time, elapsed seconds, distance, lat, lng, altitude, velocity, grade, heart
+rate, cadence, watts, temperature and moving.
+
+The activities come from the synced history (see "strava sync") when there
+is one.
+
+Examples:
+  strava export parquet --out activities.parquet
+  strava export parquet --out rides.parquet --sport Ride --after 2024-01-01 --streams
+
+  # Python
+  import pandas as pd
+  df = pd.read_parquet("activities.parquet")`,
+	Args: cobra.NoArgs,
+	RunE: runExportParquet,
+}
+
+var exportMarkdownCmd = &cobra.Command{
+	Use:   "markdown",
+	Short: "Write a Markdown note per activity, for Obsidian, Logseq and the like",
+	Long: `Write one Markdown file per activity into --dir, named by its date and ID
+(2024-06-01-12345678901.md), for training logs kept in Obsidian, Logseq or
+any folder of notes.
+
+A note has YAML front matter (title, date, sport, distance, moving time,
+elevation gain, heart rate, power, tags), then the description, a summary
+table and the splits, in your units. --template replaces that with a Go
+text/template file, executed with the activity's fields (.Name,
+.SportType, .Distance, .StartDateLocal and so on, as in --template) and
+.Description, .Splits (each with .Split, .Distance, .MovingTime,
+.ElapsedTime, .AverageSpeed, .ElevationDifference) and .URL. Besides the
+--template functions it has distance, elevation, pace (pace $.SportType
+.AverageSpeed), stats and yaml; the built-in template is the place to start.
+
+Each new note costs one API request, for the description and splits. Notes
+already in --dir are left alone, edits and all, so running the export again
+only adds new activities; --overwrite writes them all again.
+
+The activities come from the synced history (see "strava sync") when there
+is one.
+
+Examples:
+  strava export markdown --dir ~/notes/training
+  strava export markdown --dir ~/notes/training --after 2024-01-01 --sport Run
+  strava export markdown --dir ~/notes/training --template activity.md.tmpl --overwrite`,
+	Args: cobra.NoArgs,
+	RunE: runExportMarkdown,
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.AddCommand(exportParquetCmd)
+	exportParquetCmd.Flags().StringVar(&parquetOut, "out", "activities.parquet", "Parquet file to write")
+	exportParquetCmd.Flags().BoolVar(&parquetStreams, "streams", false, "Also write each activity's stream samples to <out>-streams.parquet (one request per activity)")
+	exportParquetCmd.Flags().StringVar(&parquetAfter, "after", "", "Only activities after this date (YYYY-MM-DD)")
+	exportParquetCmd.Flags().StringVar(&parquetBefore, "before", "", "Only activities before this date (YYYY-MM-DD)")
+	exportParquetCmd.Flags().StringVar(&parquetSport, "sport", "", "Only this sport type (e.g. Run, Ride)")
+	exportParquetCmd.RegisterFlagCompletionFunc("sport", completeSportTypes)
+	exportCmd.AddCommand(exportMarkdownCmd)
+	exportMarkdownCmd.Flags().StringVar(&markdownDir, "dir", ".", "Directory to write the notes to")
+	exportMarkdownCmd.Flags().StringVar(&markdownTemplate, "template", "", "Go text/template file for each note (default: the built-in one)")
+	exportMarkdownCmd.Flags().StringVar(&markdownAfter, "after", "", "Only activities after this date (YYYY-MM-DD)")
+	exportMarkdownCmd.Flags().StringVar(&markdownBefore, "before", "", "Only activities before this date (YYYY-MM-DD)")
+	exportMarkdownCmd.Flags().StringVar(&markdownSport, "sport", "", "Only this sport type (e.g. Run, Ride)")
+	exportMarkdownCmd.RegisterFlagCompletionFunc("sport", completeSportTypes)
+	exportMarkdownCmd.Flags().BoolVar(&markdownOverwrite, "overwrite", false, "Write notes that already exist again")
+	exportCmd.AddCommand(exportPrometheusCmd)
+	exportPrometheusCmd.Flags().StringVar(&promListen, "listen", ":9100", "Address to serve metrics on")
+	exportPrometheusCmd.Flags().DurationVar(&promInterval, "interval", 15*time.Minute, "How often to fetch the stats again")
+
+	exportCmd.AddCommand(exportInfluxCmd)
+	exportInfluxCmd.Flags().StringVar(&influxAfter, "after", "", "Only activities after this date (YYYY-MM-DD)")
+	exportInfluxCmd.Flags().StringVar(&influxBefore, "before", "", "Only activities before this date (YYYY-MM-DD)")
+	exportInfluxCmd.Flags().StringVar(&influxSport, "sport", "", "Only this sport type (e.g. Run, Ride)")
+	exportInfluxCmd.RegisterFlagCompletionFunc("sport", completeSportTypes)
+	exportInfluxCmd.Flags().BoolVar(&influxStreams, "streams", false, "Also export each activity's streams (one request per activity)")
+	exportInfluxCmd.Flags().StringVar(&influxURL, "url", "", "InfluxDB server to write to, e.g. http://localhost:8086 (default: stdout)")
+	exportInfluxCmd.Flags().StringVar(&influxOrg, "org", "", "InfluxDB 2 organization")
+	exportInfluxCmd.Flags().StringVar(&influxBucket, "bucket", "", "InfluxDB 2 bucket")
+	exportInfluxCmd.Flags().StringVar(&influxDB, "db", "", "InfluxDB 1 database")
+	exportInfluxCmd.Flags().StringVar(&influxToken, "token", "", "InfluxDB token (default $INFLUX_TOKEN)")
+}
+
+func runExportPrometheus(cmd *cobra.Command, args []string) error {
+	if promInterval < time.Minute {
+		return fmt.Errorf("--interval must be at least 1m")
+	}
+	// Every collection must fetch the stats again.
+	genclient.SetMemo(nil)
+	api, _, err := apiClient(cmd)
+	if err != nil {
+		return err
+	}
+	me, err := api.GetLoggedInAthleteWithResponse(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("fetch athlete: %w", err)
+	}
+	if me.HTTPResponse.StatusCode != 200 {
+		return apiError(me.HTTPResponse.StatusCode, me.Body)
+	}
+	if me.JSON200 == nil || me.JSON200.Id == nil {
+		return fmt.Errorf("fetch athlete: unexpected empty response")
+	}
+	athleteID := *me.JSON200.Id
+
+	e := &metrics.Exporter{
+		Collect: func(ctx context.Context) ([]metrics.Metric, error) {
+			return collectStats(ctx, api, athleteID)
+		},
+		Interval: promInterval,
+		OnError: func(err error) {
+			fmt.Fprintf(os.Stderr, "warning: refresh metrics: %v\n", err)
+		},
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", e)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintln(w, "strava-cli Prometheus exporter: metrics are on /metrics")
+	})
+
+	ctx := cmd.Context() // cancelled by Ctrl-C
+	ln, err := net.Listen("tcp", promListen)
+	if err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go e.Run(ctx)
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdown)
+	}()
+	fmt.Fprintf(os.Stderr, "Serving metrics on http://%s/metrics (Ctrl-C to stop)\n", ln.Addr())
+	if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// collectStats fetches an athlete's stats as metrics, with the rate limit
+// usage the response reports.
+func collectStats(ctx context.Context, api *genclient.ClientWithResponses, athleteID int64) ([]metrics.Metric, error) {
+	resp, err := api.GetStatsWithResponse(ctx, athleteID)
+	if err != nil {
+		return nil, fmt.Errorf("fetch stats: %w", err)
+	}
+	if resp.HTTPResponse.StatusCode != 200 {
+		return nil, apiError(resp.HTTPResponse.StatusCode, resp.Body)
+	}
+	ms, err := metrics.AthleteStats(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	limit := metrics.Metric{Name: "strava_rate_limit", Help: "API requests allowed per window.", Type: "gauge"}
+	usage := metrics.Metric{Name: "strava_rate_limit_usage", Help: "API requests made in the current window.", Type: "gauge"}
+	for _, l := range []struct{ name, prefix string }{{"overall", "X-RateLimit"}, {"read", "X-ReadRateLimit"}} {
+		rl := parseRateLimit(resp.HTTPResponse.Header, l.prefix)
+		if rl == nil {
+			continue
+		}
+		short := map[string]string{"limit": l.name, "window": "15m"}
+		daily := map[string]string{"limit": l.name, "window": "daily"}
+		limit.Samples = append(limit.Samples, metrics.Sample{Labels: short, Value: float64(rl.ShortLimit)}, metrics.Sample{Labels: daily, Value: float64(rl.DailyLimit)})
+		usage.Samples = append(usage.Samples, metrics.Sample{Labels: short, Value: float64(rl.ShortUsage)}, metrics.Sample{Labels: daily, Value: float64(rl.DailyUsage)})
+	}
+	if len(limit.Samples) > 0 {
+		ms = append(ms, limit, usage)
+	}
+	return ms, nil
+}
+
+func runExportInflux(cmd *cobra.Command, args []string) error {
+	after, err := parseDate("after", influxAfter)
+	if err != nil {
+		return err
+	}
+	before, err := parseDate("before", influxBefore)
+	if err != nil {
+		return err
+	}
+	var server *influx.Server
+	if influxURL != "" {
+		token := influxToken
+		if token == "" {
+			token = os.Getenv("INFLUX_TOKEN")
+		}
+		if influxBucket != "" && influxOrg == "" {
+			return fmt.Errorf("--bucket needs --org")
+		}
+		if influxBucket == "" && influxDB == "" {
+			return fmt.Errorf("--url needs --org and --bucket (InfluxDB 2) or --db (InfluxDB 1)")
+		}
+		redact.Add(token)
+		server = &influx.Server{URL: influxURL, Org: influxOrg, Bucket: influxBucket, DB: influxDB, Token: token, HTTP: httpClient()}
+	}
+	api, _, err := apiClient(cmd)
+	if err != nil {
+		return err
+	}
+	acts, err := historyActivities(cmd, api, after, before)
+	if err != nil {
+		return err
+	}
+	slices.SortFunc(acts, func(a, b analysis.Activity) int { return a.StartDate.Compare(b.StartDate) })
+
+	var buf []byte
+	lines, points := 0, 0
+	flush := func() error {
+		if len(buf) == 0 {
+			return nil
+		}
+		var err error
+		if server != nil {
+			err = server.Write(cmd.Context(), buf)
+		} else {
+			_, err = os.Stdout.Write(buf)
+		}
+		buf, lines = buf[:0], 0
+		return err
+	}
+	add := func(p influx.Point) error {
+		var err error
+		if buf, err = p.Append(buf); err != nil {
+			return err
+		}
+		lines++
+		points++
+		if lines >= influxBatchLines {
+			return flush()
+		}
+		return nil
+	}
+	for _, a := range acts {
+		if influxSport != "" && !strings.EqualFold(a.SportType, influxSport) {
+			continue
+		}
+		if err := add(influx.ActivityPoint(a)); err != nil {
+			return err
+		}
+		if !influxStreams || a.Manual {
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "Fetching streams of %d (%s)\n", a.ID, a.StartDateLocal.Format("2006-01-02"))
+		streams, err := fetchStreams(cmd, api, a.ID, influx.StreamKeys...)
+		if err != nil && cmd.Context().Err() != nil {
+			break
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: activity %d: %v\n", a.ID, err)
+			continue
+		}
+		for _, p := range influx.StreamPoints(a, streams) {
+			if err := add(p); err != nil {
+				return err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+	if server != nil {
+		fmt.Fprintf(os.Stderr, "Wrote %d points to %s\n", points, influxURL)
+	}
+	return cmd.Context().Err()
+}
+
+func runExportMarkdown(cmd *cobra.Command, args []string) error {
+	after, err := parseDate("after", markdownAfter)
+	if err != nil {
+		return err
+	}
+	before, err := parseDate("before", markdownBefore)
+	if err != nil {
+		return err
+	}
+	p := newPrinter()
+	var text []byte
+	if markdownTemplate != "" {
+		if text, err = os.ReadFile(markdownTemplate); err != nil {
+			return fmt.Errorf("read --template: %w", err)
+		}
+	}
+	tmpl, err := p.NoteTemplate(string(text))
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(markdownDir, 0o755); err != nil {
+		return err
+	}
+	api, _, err := apiClient(cmd)
+	if err != nil {
+		return err
+	}
+	acts, err := historyActivities(cmd, api, after, before)
+	if err != nil {
+		return err
+	}
+	slices.SortFunc(acts, func(a, b analysis.Activity) int { return a.StartDate.Compare(b.StartDate) })
+
+	written, kept := 0, 0
+	for _, a := range acts {
+		if markdownSport != "" && !strings.EqualFold(a.SportType, markdownSport) {
+			continue
+		}
+		path := filepath.Join(markdownDir, fmt.Sprintf("%s-%d.md", a.StartDateLocal.Format("2006-01-02"), a.ID))
+		if _, err := os.Stat(path); err == nil && !markdownOverwrite {
+			kept++
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "Writing %s\n", path)
+		note, err := activityNote(cmd, api, a, p.Units)
+		if err != nil && cmd.Context().Err() != nil {
+			break
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: activity %d: %v\n", a.ID, err)
+			continue
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, note); err != nil {
+			return fmt.Errorf("execute --template: %w", err)
+		}
+		if err := fsutil.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+			return err
+		}
+		written++
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %d notes to %s (%d already there)\n", written, markdownDir, kept)
+	return cmd.Context().Err()
+}
+
+// activityNote fetches what a Markdown note shows of a beyond its summary:
+// its description and its splits, per kilometer or per mile in imperial
+// units.
+func activityNote(cmd *cobra.Command, api *genclient.ClientWithResponses, a analysis.Activity, units output.Units) (output.Note, error) {
+	resp, err := api.GetActivityByIdWithResponse(cmd.Context(), a.ID,
+		&genclient.GetActivityByIdParams{IncludeAllEfforts: boolPtr(false)})
+	if err != nil {
+		return output.Note{}, fmt.Errorf("fetch activity: %w", err)
+	}
+	if resp.HTTPResponse.StatusCode != 200 {
+		return output.Note{}, apiError(resp.HTTPResponse.StatusCode, resp.Body)
+	}
+	var detail struct {
+		Description    string         `json:"description"`
+		SplitsMetric   []output.Split `json:"splits_metric"`
+		SplitsStandard []output.Split `json:"splits_standard"`
+	}
+	if err := json.Unmarshal(resp.Body, &detail); err != nil {
+		return output.Note{}, fmt.Errorf("parse activity: %w", err)
+	}
+	note := output.Note{
+		Activity:    a,
+		Description: detail.Description,
+		Splits:      detail.SplitsMetric,
+		URL:         fmt.Sprintf("https://www.strava.com/activities/%d", a.ID),
+	}
+	if units == output.Imperial {
+		note.Splits = detail.SplitsStandard
+	}
+	return note, nil
+}
+
+func runExportParquet(cmd *cobra.Command, args []string) error {
+	after, err := parseDate("after", parquetAfter)
+	if err != nil {
+		return err
+	}
+	before, err := parseDate("before", parquetBefore)
+	if err != nil {
+		return err
+	}
+	api, _, err := apiClient(cmd)
+	if err != nil {
+		return err
+	}
+	all, err := historyActivities(cmd, api, after, before)
+	if err != nil {
+		return err
+	}
+	var acts []analysis.Activity
+	for _, a := range all {
+		if parquetSport == "" || strings.EqualFold(a.SportType, parquetSport) {
+			acts = append(acts, a)
+		}
+	}
+	slices.SortFunc(acts, func(a, b analysis.Activity) int { return a.StartDate.Compare(b.StartDate) })
+
+	err = writeParquet(parquetOut, parquet.ActivityColumns, func(w *parquet.Writer) error {
+		for _, a := range acts {
+			if err := w.Write(parquet.ActivityRow(a)...); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %d activities to %s\n", len(acts), parquetOut)
+	if !parquetStreams {
+		return nil
+	}
+
+	out := strings.TrimSuffix(parquetOut, filepath.Ext(parquetOut)) + "-streams.parquet"
+	samples := 0
+	err = writeParquet(out, parquet.StreamColumns, func(w *parquet.Writer) error {
+		for _, a := range acts {
+			if a.Manual {
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "Fetching streams of %d (%s)\n", a.ID, a.StartDateLocal.Format("2006-01-02"))
+			streams, err := fetchStreams(cmd, api, a.ID, parquet.StreamKeys...)
+			if err != nil && cmd.Context().Err() != nil {
+				return cmd.Context().Err()
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "warning: activity %d: %v\n", a.ID, err)
+				continue
+			}
+			for _, row := range parquet.StreamRows(a, streams) {
+				if err := w.Write(row...); err != nil {
+					return err
+				}
+				samples++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %d stream samples to %s\n", samples, out)
+	return nil
+}
+
+// writeParquet writes a Parquet file of cols to path, crash-safely, with
+// the rows fill writes; nothing is written if fill fails.
+func writeParquet(path string, cols []parquet.Column, fill func(*parquet.Writer) error) error {
+	f, err := fsutil.Create(path, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Discard()
+	bw := bufio.NewWriter(f)
+	w, err := parquet.NewWriter(bw, cols)
+	if err != nil {
+		return err
+	}
+	if err := fill(w); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return f.Commit()
+}
@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	genclient "github.com/Brainsoft-Raxat/strava-cli/internal/client"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/config"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/store"
+)
+
+// featuresMaxAge is how long a probe result is trusted, by `features` and by
+// the commands that pre-check against it.
+const featuresMaxAge = 24 * time.Hour
+
+// Feature names, as shown by `features` and looked up by precheckFeature.
+const (
+	featureActivityZones  = "activity-zones"
+	featureSegmentEfforts = "segment-efforts"
+)
+
+var featuresRefresh bool
+
+var featuresCmd = &cobra.Command{
+	Use:   "features",
+	Short: "Show which API features your account and token can use",
+	Long: `Probe the API capabilities your account and token can use and print a
+matrix with the commands each one affects. Some endpoints need a Strava
+subscription (HTTP 402) and some an OAuth scope you may not have granted
+(HTTP 403); this explains which, before a command fails with one.
+
+Each probe is a single small read request. The result is cached for a day
+(or until you log in with different scopes); --refresh probes again.
+Commands that need a subscription feature check the cached result and
+fail early with an explanation when it is unavailable.
+
+Examples:
+  strava features
+  strava features --refresh --json`,
+	Args: cobra.NoArgs,
+	RunE: runFeatures,
+}
+
+func init() {
+	rootCmd.AddCommand(featuresCmd)
+	featuresCmd.Flags().BoolVar(&featuresRefresh, "refresh", false, "Probe again instead of using the cached result")
+}
+
+func runFeatures(cmd *cobra.Command, args []string) error {
+	api, cfg, err := apiClient(cmd)
+	if err != nil {
+		return err
+	}
+	cache, err := store.OpenFeatures("")
+	if err != nil {
+		return err
+	}
+	if featuresRefresh || !cache.Fresh(cfg.Tokens.Scope, featuresMaxAge) {
+		if err := probeFeatures(cmd.Context(), api, cfg, cache); err != nil {
+			return err
+		}
+		if err := cache.Save(); err != nil {
+			return err
+		}
+	}
+	return newPrinter().Features(cache)
+}
+
+// probeFeatures probes every capability with one read request each (or the
+// granted scopes alone, for write access) and records the results in f.
+func probeFeatures(ctx context.Context, api *genclient.ClientWithResponses, cfg *config.Config, f *store.Features) error {
+	athlete, err := api.GetLoggedInAthleteWithResponse(ctx)
+	if err != nil {
+		return fmt.Errorf("fetch athlete: %w", err)
+	}
+	if athlete.StatusCode() != 200 || athlete.JSON200 == nil {
+		return apiError(athlete.StatusCode(), athlete.Body)
+	}
+	if athlete.JSON200.Id != nil {
+		f.AthleteID = *athlete.JSON200.Id
+	}
+	f.Summit = athlete.JSON200.Summit != nil && *athlete.JSON200.Summit
+	f.Scope = cfg.Tokens.Scope
+	f.CheckedAt = time.Now().UTC()
+	f.Features = nil
+
+	add := func(name, commands string, status int, err error) {
+		ft := store.Feature{Name: name, Commands: commands, HTTP: status}
+		switch {
+		case err != nil:
+			ft.Status, ft.Detail = store.FeatureError, err.Error()
+		case status == 200:
+			ft.Status = store.FeatureAvailable
+		case status == 402:
+			ft.Status, ft.Detail = store.FeatureNeedsSummit, "requires a Strava subscription"
+		case status == 401 || status == 403:
+			ft.Status, ft.Detail = store.FeatureMissingScope, "not allowed for this token; log in again granting every requested scope"
+		default:
+			ft.Status, ft.Detail = store.FeatureError, fmt.Sprintf("unexpected HTTP %d", status)
+		}
+		f.Features = append(f.Features, ft)
+	}
+	unknown := func(name, commands, detail string) {
+		f.Features = append(f.Features, store.Feature{Name: name, Commands: commands, Status: store.FeatureUnknown, Detail: detail})
+	}
+	scope := func(name, commands, want string) {
+		ft := store.Feature{Name: name, Commands: commands, Status: store.FeatureAvailable}
+		switch {
+		case cfg.Tokens.Scope == "":
+			ft.Status, ft.Detail = store.FeatureUnknown, "granted scopes not recorded; run: stravacli auth login"
+		case !hasScope(cfg.Tokens.Scope, want):
+			ft.Status, ft.Detail = store.FeatureMissingScope, want+" not granted; run: stravacli auth login"
+		}
+		f.Features = append(f.Features, ft)
+	}
+
+	add("profile", "athlete me, gear list", athlete.StatusCode(), nil)
+
+	acts, err := api.GetLoggedInAthleteActivitiesWithResponse(ctx,
+		&genclient.GetLoggedInAthleteActivitiesParams{PerPage: intPtr(1)})
+	status, err := probeStatus(acts, err)
+	add("activities", "activities list, sync, report", status, err)
+	var activityID int64
+	if status == 200 && acts.JSON200 != nil && len(*acts.JSON200) > 0 && (*acts.JSON200)[0].Id != nil {
+		activityID = *(*acts.JSON200)[0].Id
+	}
+	scope("private-activities", "private activities in every list", "activity:read_all")
+	scope("write", "activities update, activities upload", "activity:write")
+	scope("profile-write", "segments star, segments unstar", "profile:write")
+
+	status, err = probeStatus(api.GetLoggedInAthleteZonesWithResponse(ctx))
+	add("athlete-zones", "athlete zones", status, err)
+	if status == 403 {
+		f.Features[len(f.Features)-1].Detail = "needs the profile:read_all scope, which stravacli does not request"
+	}
+
+	if activityID == 0 {
+		unknown(featureActivityZones, "activities zones", "no activity to probe with")
+	} else {
+		status, err = probeStatus(api.GetZonesByActivityIdWithResponse(ctx, activityID))
+		add(featureActivityZones, "activities zones", status, err)
+	}
+
+	starred, err := api.GetLoggedInAthleteStarredSegmentsWithResponse(ctx,
+		&genclient.GetLoggedInAthleteStarredSegmentsParams{PerPage: intPtr(1)})
+	status, err = probeStatus(starred, err)
+	add("starred-segments", "segments starred", status, err)
+	var segments []struct {
+		ID int64 `json:"id"`
+	}
+	if status == 200 {
+		_ = json.Unmarshal(starred.Body, &segments)
+	}
+
+	if len(segments) == 0 {
+		unknown(featureSegmentEfforts, "segments efforts list", "no starred segment to probe with")
+	} else {
+		status, err = probeStatus(api.GetEffortsBySegmentIdWithResponse(ctx,
+			&genclient.GetEffortsBySegmentIdParams{SegmentId: int(segments[0].ID), PerPage: intPtr(1)}))
+		add(featureSegmentEfforts, "segments efforts list", status, err)
+	}
+
+	status, err = probeStatus(api.GetRoutesByAthleteIdWithResponse(ctx, f.AthleteID,
+		&genclient.GetRoutesByAthleteIdParams{PerPage: intPtr(1)}))
+	add("routes", "routes list, routes export", status, err)
+
+	status, err = probeStatus(api.GetLoggedInAthleteClubsWithResponse(ctx,
+		&genclient.GetLoggedInAthleteClubsParams{PerPage: intPtr(1)}))
+	add("clubs", "clubs list, clubs activities", status, err)
+	return nil
+}
+
+// probeStatus returns the status code of a probe's response, or the error
+// that prevented one.
+func probeStatus[R interface{ StatusCode() int }](r R, err error) (int, error) {
+	if err != nil {
+		return 0, err
+	}
+	return r.StatusCode(), nil
+}
+
+// hasScope reports whether the comma-separated granted scopes include want.
+func hasScope(granted, want string) bool {
+	for _, s := range strings.Split(granted, ",") {
+		if strings.TrimSpace(s) == want {
+			return true
+		}
+	}
+	return false
+}
+
+// precheckFeature fails early when the cached `features` probe (if recent
+// and made with the current token's scopes) found a feature unavailable. It
+// never probes itself, and without a usable cache lets the command try.
+func precheckFeature(cfg *config.Config, name string) error {
+	cache, err := store.OpenFeatures("")
+	if err != nil || !cache.Fresh(cfg.Tokens.Scope, featuresMaxAge) {
+		return nil
+	}
+	ft, ok := cache.Lookup(name)
+	if !ok || (ft.Status != store.FeatureNeedsSummit && ft.Status != store.FeatureMissingScope) {
+		return nil
+	}
+	return fmt.Errorf("%s is unavailable for your account (%s: %s); if that has changed, run: stravacli features --refresh",
+		name, ft.Status, ft.Detail)
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	genclient "github.com/Brainsoft-Raxat/strava-cli/internal/client"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/analysis"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/store"
+)
+
+var (
+	fitnessWeeks   int
+	fitnessStreams bool
+)
+
+var fitnessCmd = &cobra.Command{
+	Use:   "fitness",
+	Short: "Chart fitness, fatigue and form from daily training stress",
+	Long: `Work out the Training Stress Score (TSS) of each day over the last --weeks
+weeks, and from it fitness (chronic training load, CTL: the 42-day average),
+fatigue (acute training load, ATL: the 7-day average) and form (training
+stress balance, TSB: fitness minus fatigue).
+
+It prints charts of fitness and form, a table of the values at the end of
+each week, and today's form: below -30 overreaching, -30 to -10 productive
+training, -10 to 5 maintaining, 5 to 25 fresh, above 25 losing fitness.
+
+An activity's TSS comes from its power when it has some and an FTP is set,
+otherwise from its heart rate, otherwise from its moving time at an easy
+intensity. Heart rate TSS is relative to a threshold at 90% of the maximum
+heart rate set with "strava config set max-hr", or of the highest in your
+history. --streams works TSS out from each activity's power and heart rate
+streams instead of its averages, which is more exact for intervals; the
+streams are fetched once per activity and the result cached.
+
+The activities come from the synced history (see "strava sync") when there
+is one, reaching back 180 days further for fitness to settle. --output csv
+writes one row per day.
+
+Examples:
+  strava fitness
+  strava fitness --weeks 26 --streams
+  strava fitness --output csv > fitness.csv`,
+	Args: cobra.NoArgs,
+	RunE: runFitness,
+}
+
+func init() {
+	rootCmd.AddCommand(fitnessCmd)
+	fitnessCmd.Flags().IntVar(&fitnessWeeks, "weeks", 12, "Number of weeks to show, ending today")
+	fitnessCmd.Flags().BoolVar(&fitnessStreams, "streams", false, "Work TSS out from activity streams (fetches streams once per activity)")
+}
+
+func runFitness(cmd *cobra.Command, args []string) error {
+	if fitnessWeeks < 1 {
+		return fmt.Errorf("--weeks must be at least 1")
+	}
+	now := time.Now()
+	from := now.AddDate(0, 0, 1-7*fitnessWeeks)
+
+	api, _, err := apiClient(cmd)
+	if err != nil {
+		return err
+	}
+	acts, err := historyActivities(cmd, api, from.Add(-fatigueHistory), time.Time{})
+	if err != nil {
+		return err
+	}
+
+	set := thresholds()
+	if set.FTP == 0 {
+		fmt.Fprintln(os.Stderr, "No FTP set, so rides are scored from heart rate: set it with: strava config set ftp <watts>")
+	}
+	t := set.OrHistory(acts)
+	if set.MaxHR == 0 && t.MaxHR > 0 {
+		fmt.Fprintf(os.Stderr, "No maximum heart rate set; using the highest in your history, %.0f bpm (strava config set max-hr <bpm>)\n", t.MaxHR)
+	}
+
+	stress := map[int64]analysis.Stress{}
+	for _, a := range acts {
+		stress[a.ID] = analysis.ActivityStress(a, t)
+	}
+	if fitnessStreams {
+		if err := streamStress(cmd, api, acts, t, stress); err != nil {
+			return err
+		}
+	}
+	return newPrinter().Fitness(analysis.FitnessSeries(acts, stress, from, now))
+}
+
+// streamStress replaces the TSS in stress of each activity with power or
+// heart rate by that worked out from its streams. Results are cached per
+// activity (in stress.json) along with the thresholds they were relative
+// to; the rest fetch their streams once.
+func streamStress(cmd *cobra.Command, api *genclient.ClientWithResponses, acts []analysis.Activity, t analysis.Thresholds, stress map[int64]analysis.Stress) error {
+	cache, err := store.OpenStressCache("")
+	if err != nil {
+		return err
+	}
+	scored, err := cachedStreamValues(cmd, api, cache, acts, streamJob[analysis.Stress]{
+		progress: "Scoring",
+		keys:     []string{"time", "watts", "heartrate"},
+		skip:     func(a analysis.Activity) bool { return a.Manual || (a.AverageHeartrate == 0 && a.AverageWatts == 0) },
+		fresh:    func(s analysis.Stress) bool { return s.Thresholds == t },
+		compute: func(a analysis.Activity, streams *analysis.Streams) analysis.Stress {
+			if s, ok := analysis.StreamStress(streams, t); ok {
+				return s
+			}
+			return stress[a.ID]
+		},
+	})
+	maps.Copy(stress, scored)
+	return err
+}
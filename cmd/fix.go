@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/fit"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/fsutil"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/store"
+)
+
+var fixCmd = &cobra.Command{
+	Use:   "fix",
+	Short: "Repair activity files before or after upload",
+}
+
+var (
+	mergeFiles       []string
+	mergeOut         string
+	mergeMaxGap      time.Duration
+	mergeUpload      bool
+	mergeArchive     bool
+	mergeActivityIDs []int64
+)
+
+var fixMergeCmd = &cobra.Command{
+	Use:   "merge",
+	Short: "Join a recording split across FIT files into one",
+	Long: `Join the parts of a recording that was split across FIT files, for example
+when a bike computer rebooted mid-ride, into one FIT file.
+
+The parts may be given in any order; they are put in time order. Each must
+have its timestamps in order, the parts must not overlap, and no two may be
+more than --max-gap apart, so unrelated files are not joined by mistake. The
+merged file has every record of the parts, one lap, with the distance of each
+part continuing from the end of the one before. Errors number the parts in
+--files order.
+
+--upload then uploads the merged file (see "activities upload"), and
+--archive hides the activities the parts were uploaded as from the home feed.
+Those are found in the upload ledger; name others with --activity-ids.
+Strava's API cannot delete activities, so delete them on strava.com once
+the merged activity is in.
+
+Examples:
+  strava fix merge --files part1.fit,part2.fit --out merged.fit
+  strava fix merge --files part1.fit,part2.fit --out merged.fit --upload --archive --dry-run
+  strava fix merge --files a.fit,b.fit --out ride.fit --upload --wait --archive --activity-ids 123,456 --yes`,
+	Args: cobra.NoArgs,
+	RunE: runFixMerge,
+}
+
+func init() {
+	rootCmd.AddCommand(fixCmd)
+	fixCmd.AddCommand(fixMergeCmd)
+
+	f := fixMergeCmd.Flags()
+	f.StringSliceVar(&mergeFiles, "files", nil, "FIT files to join, comma-separated")
+	f.StringVar(&mergeOut, "out", "", "Where to write the merged FIT file")
+	f.DurationVar(&mergeMaxGap, "max-gap", time.Hour, "Longest pause between parts")
+	f.BoolVar(&mergeUpload, "upload", false, "Upload the merged file")
+	f.BoolVar(&mergeArchive, "archive", false, "Hide the activities of the parts from the home feed")
+	f.Int64SliceVar(&mergeActivityIDs, "activity-ids", nil, "Activities to archive besides those in the upload ledger")
+	f.StringVar(&uploadName, "name", "", "Activity name, with --upload")
+	f.StringVar(&uploadDescription, "description", "", "Activity description, with --upload")
+	f.BoolVar(&uploadWait, "wait", false, "With --upload, poll until Strava finishes processing")
+	f.Bool("yes", false, "Skip interactive confirmation")
+	f.Bool("dry-run", false, "Check the parts and print what would be done without writing or calling the API")
+	fixMergeCmd.MarkFlagRequired("files")
+	fixMergeCmd.MarkFlagRequired("out")
+}
+
+func runFixMerge(cmd *cobra.Command, args []string) error {
+	if len(mergeFiles) < 2 {
+		return fmt.Errorf("--files needs at least two files to merge")
+	}
+	parts := make([]fit.Activity, len(mergeFiles))
+	for i, name := range mergeFiles {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		if parts[i], err = fit.Decode(bytes.NewReader(b)); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	merged, err := fit.Join(parts, mergeMaxGap)
+	if err != nil {
+		return fmt.Errorf("cannot merge: %w", err)
+	}
+	first, last := merged.Records[0].Time, merged.Records[len(merged.Records)-1].Time
+	fmt.Fprintf(os.Stderr, "Joined %d parts: %s to %s, %d records\n",
+		len(parts), first.Local().Format("2006-01-02 15:04:05"), last.Local().Format("15:04:05"), len(merged.Records))
+
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	if dryRun {
+		fmt.Fprintf(os.Stderr, "DRY RUN: would write %s\n", mergeOut)
+	} else {
+		var buf bytes.Buffer
+		if err := fit.Encode(&buf, merged); err != nil {
+			return err
+		}
+		if err := fsutil.WriteFile(mergeOut, buf.Bytes(), 0o644); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Wrote %s\n", mergeOut)
+	}
+
+	if mergeUpload {
+		uploadFile, uploadDir = mergeOut, ""
+		uploadDataType = "fit"
+		uploadTrainer = merged.SubSport == fit.SubSportIndoorCycling || merged.SubSport == fit.SubSportTreadmill
+		if err := runActivitiesUpload(cmd, nil); err != nil {
+			return err
+		}
+	}
+	if mergeArchive {
+		return archiveParts(cmd)
+	}
+	return nil
+}
+
+// archiveParts hides the activities the merged parts were uploaded as.
+func archiveParts(cmd *cobra.Command) error {
+	ledger, err := store.OpenLedger("")
+	if err != nil {
+		return err
+	}
+	ids := append([]int64(nil), mergeActivityIDs...)
+	for _, name := range mergeFiles {
+		hash, err := store.HashFile(name)
+		if err != nil {
+			return err
+		}
+		if e, ok := ledger.Lookup(hash); ok && e.ActivityID != 0 {
+			ids = append(ids, e.ActivityID)
+		}
+	}
+	if len(ids) == 0 {
+		fmt.Fprintln(os.Stderr, "None of the parts is in the upload ledger; name their activities with --activity-ids to archive them.")
+		return nil
+	}
+	strIDs := make([]string, len(ids))
+	for i, id := range ids {
+		strIDs[i] = strconv.FormatInt(id, 10)
+	}
+	proceed, err := confirmMutation(cmd, fmt.Sprintf("hide activities %s from the home feed", strings.Join(strIDs, ", ")))
+	if err != nil || !proceed {
+		return err
+	}
+	httpClient, _, err := rawClient(cmd)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if _, err := putActivity(cmd.Context(), httpClient, id, []byte(`{"hide_from_home":true}`)); err != nil {
+			return fmt.Errorf("hide activity %d: %w", id, err)
+		}
+		fmt.Fprintf(os.Stdout, "Hid activity %d; delete it at https://www.strava.com/activities/%d once the merged activity is in\n", id, id)
+	}
+	return nil
+}
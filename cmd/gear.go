@@ -2,9 +2,11 @@ package cmd
 
 import (
 	"fmt"
-	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/analysis"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/config"
 	"github.com/Brainsoft-Raxat/strava-cli/internal/output"
 )
 
@@ -18,11 +20,89 @@ var gearGetCmd = &cobra.Command{
 	Short: "Get gear by ID (e.g. b12345 for a bike, g12345 for shoes)",
 	Args:  cobra.ExactArgs(1),
 	RunE:  runGearGet,
+
+	ValidArgsFunction: completeGearIDs,
+}
+
+var gearListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List your bikes and shoes",
+	Long: `List the bikes and shoes in your profile with the distance Strava has
+logged on each, and whether each is primary or retired.`,
+	Args: cobra.NoArgs,
+	RunE: runGearList,
+}
+
+var (
+	gearReportAfter  string
+	gearReportBefore string
+	gearReportSport  string
+)
+
+var gearReportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Activity totals per bike and pair of shoes",
+	Long: `Total your activities per piece of gear: count, distance, moving time,
+climbing, and the first and last time each was used. Activities without
+gear are totalled as "(no gear)".
+
+Reads the history kept by "strava sync" when there is one (bringing it up
+to date first), and pages through the API otherwise.
+
+Examples:
+  strava gear report
+  strava gear report --after 2024-01-01 --sport Ride
+  strava gear report --json`,
+	Args: cobra.NoArgs,
+	RunE: runGearReport,
+}
+
+var (
+	alertSet    []string
+	alertRemove []string
+	alertReset  []string
+)
+
+var gearAlertsCmd = &cobra.Command{
+	Use:   "alerts",
+	Short: "Maintenance alerts on gear distance",
+	Long: `Set maintenance intervals on your gear and see which are due. An alert
+names a gear ID, optionally with a part (b123:chain), and a distance;
+"gear list" flags gear whose alerts are due soon (90% of the interval) or
+overdue. Alerts are stored in the config file.
+
+An alert on the gear itself counts its whole distance (e.g. retiring
+shoes at 800km). An alert on a part counts from when the alert is set;
+after replacing the part, --reset starts counting again.
+
+Distances take a unit (5000km, 3000mi); a bare number is in your display
+units. Each flag can be repeated.
+
+Examples:
+  strava gear alerts
+  strava gear alerts --set g123=800km
+  strava gear alerts --set b123:chain=3000km --set b123:tires=5000km
+  strava gear alerts --reset b123:chain
+  strava gear alerts --remove b123:tires`,
+	Args: cobra.NoArgs,
+	RunE: runGearAlerts,
 }
 
 func init() {
 	rootCmd.AddCommand(gearCmd)
 	gearCmd.AddCommand(gearGetCmd)
+	gearCmd.AddCommand(gearListCmd)
+	gearCmd.AddCommand(gearReportCmd)
+	gearCmd.AddCommand(gearAlertsCmd)
+
+	gearReportCmd.Flags().StringVar(&gearReportAfter, "after", "", "Start date (YYYY-MM-DD)")
+	gearReportCmd.Flags().StringVar(&gearReportBefore, "before", "", "End date (YYYY-MM-DD)")
+	gearReportCmd.Flags().StringVar(&gearReportSport, "sport", "", "Only count this sport type (e.g. Run, Ride)")
+	gearReportCmd.RegisterFlagCompletionFunc("sport", completeSportTypes)
+
+	gearAlertsCmd.Flags().StringArrayVar(&alertSet, "set", nil, "Add or change an alert: <gear-id>[:<part>]=<distance>")
+	gearAlertsCmd.Flags().StringArrayVar(&alertRemove, "remove", nil, "Remove an alert: <gear-id>[:<part>]")
+	gearAlertsCmd.Flags().StringArrayVar(&alertReset, "reset", nil, "Record that a part was just replaced: <gear-id>:<part>")
 }
 
 func runGearGet(cmd *cobra.Command, args []string) error {
@@ -38,5 +118,155 @@ func runGearGet(cmd *cobra.Command, args []string) error {
 	if resp.HTTPResponse.StatusCode != 200 {
 		return apiError(resp.HTTPResponse.StatusCode, resp.Body)
 	}
-	return output.New(os.Stdout, jsonOutput).Gear(resp)
+	return newPrinter().Gear(resp)
+}
+
+func runGearList(cmd *cobra.Command, args []string) error {
+	gear, err := athleteGear(cmd)
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	return newPrinter().GearList(gear, analysis.CheckGearAlerts(gear, gearAlerts(cfg)))
+}
+
+func runGearReport(cmd *cobra.Command, args []string) error {
+	after, err := parseDate("after", gearReportAfter)
+	if err != nil {
+		return err
+	}
+	before, err := parseDate("before", gearReportBefore)
+	if err != nil {
+		return err
+	}
+	gear, err := athleteGear(cmd)
+	if err != nil {
+		return err
+	}
+	api, _, err := apiClient(cmd)
+	if err != nil {
+		return err
+	}
+	acts, err := historyActivities(cmd, api, after, before)
+	if err != nil {
+		return err
+	}
+	if gearReportSport != "" {
+		kept := acts[:0]
+		for _, a := range acts {
+			if strings.EqualFold(a.SportType, gearReportSport) {
+				kept = append(kept, a)
+			}
+		}
+		acts = kept
+	}
+	return newPrinter().GearReport(analysis.GearUsage(acts, gear))
+}
+
+// athleteGear fetches the athlete's bikes and shoes from their profile.
+func athleteGear(cmd *cobra.Command) ([]analysis.Gear, error) {
+	api, _, err := apiClient(cmd)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := api.GetLoggedInAthleteWithResponse(cmd.Context())
+	if err != nil {
+		return nil, fmt.Errorf("fetch athlete: %w", err)
+	}
+	if resp.HTTPResponse.StatusCode != 200 {
+		return nil, apiError(resp.HTTPResponse.StatusCode, resp.Body)
+	}
+	return analysis.ParseAthleteGear(resp.Body)
+}
+
+func runGearAlerts(cmd *cobra.Command, args []string) error {
+	printer := newPrinter()
+	gear, err := athleteGear(cmd)
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	distances := map[string]float64{}
+	for _, g := range gear {
+		distances[g.ID] = g.Distance
+	}
+	find := func(gearID, part string) int {
+		for i, a := range cfg.GearAlerts {
+			if a.GearID == gearID && strings.EqualFold(a.Part, part) {
+				return i
+			}
+		}
+		return -1
+	}
+
+	for _, s := range alertSet {
+		key, dist, ok := strings.Cut(s, "=")
+		if !ok {
+			return fmt.Errorf("invalid --set %q: use <gear-id>[:<part>]=<distance>", s)
+		}
+		gearID, part := splitAlertKey(key)
+		current, known := distances[gearID]
+		if !known {
+			return fmt.Errorf("invalid --set %q: no gear %s in your profile (see: strava gear list)", s, gearID)
+		}
+		every, err := output.ParseDistance(dist, printer.Units)
+		if err != nil || every <= 0 {
+			return fmt.Errorf("invalid --set %q: distance must be positive, e.g. 5000km", s)
+		}
+		if i := find(gearID, part); i >= 0 {
+			cfg.GearAlerts[i].Every = every
+			continue
+		}
+		a := config.GearAlert{GearID: gearID, Part: part, Every: every}
+		if part != "" {
+			a.Since = current // a part counts from now
+		}
+		cfg.GearAlerts = append(cfg.GearAlerts, a)
+	}
+	for _, key := range alertRemove {
+		i := find(splitAlertKey(key))
+		if i < 0 {
+			return fmt.Errorf("invalid --remove %q: no such alert", key)
+		}
+		cfg.GearAlerts = append(cfg.GearAlerts[:i], cfg.GearAlerts[i+1:]...)
+	}
+	for _, key := range alertReset {
+		gearID, part := splitAlertKey(key)
+		i := find(gearID, part)
+		if i < 0 {
+			return fmt.Errorf("invalid --reset %q: no such alert", key)
+		}
+		current, known := distances[gearID]
+		if !known {
+			return fmt.Errorf("invalid --reset %q: no gear %s in your profile", key, gearID)
+		}
+		cfg.GearAlerts[i].Since = current
+	}
+	if len(alertSet)+len(alertRemove)+len(alertReset) > 0 {
+		if err := config.Save(cfg); err != nil {
+			return err
+		}
+	}
+	return printer.GearAlerts(analysis.CheckGearAlerts(gear, gearAlerts(cfg)))
+}
+
+// gearAlerts returns the maintenance intervals set in cfg.
+func gearAlerts(cfg *config.Config) []analysis.GearAlert {
+	out := make([]analysis.GearAlert, len(cfg.GearAlerts))
+	for i, a := range cfg.GearAlerts {
+		out[i] = analysis.GearAlert{GearID: a.GearID, Part: a.Part, Every: a.Every, Since: a.Since}
+	}
+	return out
+}
+
+// splitAlertKey splits "b123:chain" into the gear ID and part.
+func splitAlertKey(key string) (gearID, part string) {
+	gearID, part, _ = strings.Cut(strings.TrimSpace(key), ":")
+	return gearID, part
 }
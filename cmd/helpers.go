@@ -1,16 +1,67 @@
 package cmd
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/analysis"
 	genclient "github.com/Brainsoft-Raxat/strava-cli/internal/client"
 	"github.com/Brainsoft-Raxat/strava-cli/internal/config"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/output"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/redact"
 )
 
+// newPrinter returns a Printer for stdout honouring --json, --template and the
+// unit system. Units resolve as: --units flag, then "units" in config, then
+// metric.
+func newPrinter() *output.Printer {
+	p := output.New(stdout(), jsonOutput)
+	p.Format = outputFormat
+	if templateFlag != "" {
+		// Already validated in the root command's PersistentPreRunE.
+		p.Template, _ = output.ParseTemplate(templateFlag)
+	}
+	if listColumns != "" {
+		p.Columns = strings.Split(listColumns, ",")
+	}
+	p.Sort = listSort
+	p.Color = colorEnabled(os.Stdout)
+	name := unitsFlag
+	if cfg, err := config.Load(); err == nil {
+		if name == "" {
+			name = cfg.Units
+		}
+		p.Thresholds = configThresholds(cfg)
+	}
+	if u, err := output.ParseUnits(name); err == nil {
+		p.Units = u
+	}
+	return p
+}
+
+// thresholds returns the FTP and maximum heart rate set with "config set",
+// or none if the config cannot be read.
+func thresholds() analysis.Thresholds {
+	cfg, err := config.Load()
+	if err != nil {
+		return analysis.Thresholds{}
+	}
+	return configThresholds(cfg)
+}
+
+// configThresholds returns the athlete's FTP and maximum heart rate as set
+// in cfg.
+func configThresholds(cfg *config.Config) analysis.Thresholds {
+	return analysis.Thresholds{FTP: float64(cfg.FTP), MaxHR: float64(cfg.MaxHR)}
+}
+
 // apiClient loads config, refreshes the token, and returns a ready API client.
 func apiClient(cmd *cobra.Command) (*genclient.ClientWithResponses, *config.Config, error) {
 	cfg, err := loadAndRefresh()
@@ -36,6 +87,35 @@ func rawClient(cmd *cobra.Command) (*http.Client, *config.Config, error) {
 	return genclient.NewHTTPClient(cfg), cfg, nil
 }
 
+// interactive reports whether the CLI may prompt on stdin: --non-interactive
+// was not given and stdin is a terminal (not a pipe, file or /dev/null, as
+// under cron or CI).
+func interactive() bool {
+	if nonInteractive {
+		return false
+	}
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// colorEnabled reports whether output to f may be colored: f is a terminal
+// and neither --no-color, NO_COLOR (https://no-color.org) nor TERM=dumb
+// says otherwise.
+func colorEnabled(f *os.File) bool {
+	if noColor || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// requireInteractive returns an error explaining how to avoid the prompt for
+// what when the CLI may not prompt.
+func requireInteractive(what, hint string) error {
+	if interactive() {
+		return nil
+	}
+	return fmt.Errorf("%s needs interactive input, but running non-interactively\n  %s", what, hint)
+}
+
 // confirmMutation handles the --dry-run / --yes / interactive-prompt safety gate for
 // write commands. It returns (proceed, err). When proceed is false and err is nil
 // the caller should return nil (dry-run preview or user declined).
@@ -48,9 +128,12 @@ func confirmMutation(cmd *cobra.Command, description string) (bool, error) {
 		return false, nil
 	}
 	if yes {
-		fmt.Fprintf(os.Stderr, "AUDIT: %s\n", description)
+		fmt.Fprintf(os.Stderr, "AUDIT: %s\n", redact.String(description))
 		return true, nil
 	}
+	if err := requireInteractive("confirmation", "pass --yes to proceed or --dry-run to preview"); err != nil {
+		return false, err
+	}
 	fmt.Fprintf(os.Stderr, "About to %s\nProceed? [y/N] ", description)
 	var ans string
 	fmt.Fscanln(os.Stdin, &ans)
@@ -58,6 +141,89 @@ func confirmMutation(cmd *cobra.Command, description string) (bool, error) {
 		fmt.Fprintln(os.Stderr, "Aborted.")
 		return false, nil
 	}
-	fmt.Fprintf(os.Stderr, "AUDIT: %s\n", description)
+	fmt.Fprintf(os.Stderr, "AUDIT: %s\n", redact.String(description))
 	return true, nil
 }
+
+// change is an update a batch command is about to make to one activity:
+// the fields it changes, each from old to new.
+type change struct {
+	ID     int64
+	Name   string
+	Date   time.Time
+	Fields []fieldChange
+}
+
+type fieldChange struct {
+	Field, Old, New string
+}
+
+// reviewMutation is confirmMutation for a batch of changes. Run
+// interactively without --yes or --dry-run, it shows each change in turn
+// and asks whether to make it; otherwise it confirms the whole batch the
+// way confirmMutation does. It returns the indexes of the changes to make,
+// none to make no API calls at all.
+func reviewMutation(cmd *cobra.Command, description string, changes []change) ([]int, error) {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	yes, _ := cmd.Flags().GetBool("yes")
+	if dryRun || yes || !interactive() {
+		proceed, err := confirmMutation(cmd, description)
+		if err != nil || !proceed {
+			return nil, err
+		}
+		all := make([]int, len(changes))
+		for i := range all {
+			all[i] = i
+		}
+		return all, nil
+	}
+	fmt.Fprintf(os.Stderr, "About to %s.\n", description)
+	accepted := reviewChanges(bufio.NewReader(os.Stdin), os.Stderr, changes)
+	if len(accepted) == 0 {
+		fmt.Fprintln(os.Stderr, "Nothing accepted; no changes made.")
+		return nil, nil
+	}
+	fmt.Fprintf(os.Stderr, "AUDIT: %s (%d of %d accepted)\n", redact.String(description), len(accepted), len(changes))
+	return accepted, nil
+}
+
+// reviewChanges shows each change on w and reads from in whether to make
+// it, returning the indexes of those accepted. Quitting, or the end of in,
+// accepts none.
+func reviewChanges(in *bufio.Reader, w io.Writer, changes []change) []int {
+	fmt.Fprintln(w, "For each: y make it, n skip it, a make it and all the rest, s skip all the rest, q quit without changes")
+	var accepted []int
+	for i, c := range changes {
+		fmt.Fprintf(w, "\n[%d/%d] %s  %d  %s\n", i+1, len(changes), c.Date.Format("2006-01-02"), c.ID, c.Name)
+		for _, f := range c.Fields {
+			fmt.Fprintf(w, "  %s: %s → %s\n", f.Field, f.Old, f.New)
+		}
+		for {
+			fmt.Fprint(w, "Make this change? [y/n/a/s/q] ")
+			line, err := in.ReadString('\n')
+			if err != nil && line == "" {
+				fmt.Fprintln(w, "\nAborted.")
+				return nil
+			}
+			switch strings.ToLower(strings.TrimSpace(line)) {
+			case "y", "yes":
+				accepted = append(accepted, i)
+			case "n", "no":
+			case "a", "all":
+				for j := i; j < len(changes); j++ {
+					accepted = append(accepted, j)
+				}
+				return accepted
+			case "s", "skip":
+				return accepted
+			case "q", "quit":
+				fmt.Fprintln(w, "Aborted.")
+				return nil
+			default:
+				continue
+			}
+			break
+		}
+	}
+	return accepted
+}
@@ -0,0 +1,316 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/analysis"
+	genclient "github.com/Brainsoft-Raxat/strava-cli/internal/client"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/store"
+)
+
+// historyPageSize is the largest page Strava allows for /athlete/activities.
+const historyPageSize = 200
+
+// fetchActivities pages through GET /athlete/activities and returns every
+// summary activity between after and before (zero values mean unbounded).
+// The raw body is decoded into analysis.Activity so fields the generated
+// model omits (heart rate, etc.) are kept.
+func fetchActivities(cmd *cobra.Command, api *genclient.ClientWithResponses, after, before time.Time) ([]analysis.Activity, error) {
+	var all []analysis.Activity
+	_, err := fetchPages(cmd.Context(), 1, historyPageSize, func(ctx context.Context, page int) ([]analysis.Activity, error) {
+		params := &genclient.GetLoggedInAthleteActivitiesParams{
+			Page:    intPtr(page),
+			PerPage: intPtr(historyPageSize),
+		}
+		if !after.IsZero() {
+			params.After = intPtr(int(after.Unix()))
+		}
+		if !before.IsZero() {
+			params.Before = intPtr(int(before.Unix()))
+		}
+		resp, err := api.GetLoggedInAthleteActivitiesWithResponse(ctx, params)
+		if err != nil {
+			return nil, fmt.Errorf("fetch activities (page %d): %w", page, err)
+		}
+		if resp.HTTPResponse.StatusCode != 200 {
+			return nil, apiError(resp.HTTPResponse.StatusCode, resp.Body)
+		}
+		var batch []analysis.Activity
+		if err := json.Unmarshal(resp.Body, &batch); err != nil {
+			return nil, fmt.Errorf("parse activities (page %d): %w", page, err)
+		}
+		return batch, nil
+	}, func(_ int, batch []analysis.Activity) error {
+		all = append(all, batch...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+// historyActivities returns the summary activities between after and before
+// (zero values mean unbounded). When a history has been synced it is brought
+// up to date and read locally, saving the full paging through the API;
+// otherwise this is fetchActivities.
+func historyActivities(cmd *cobra.Command, api *genclient.ClientWithResponses, after, before time.Time) ([]analysis.Activity, error) {
+	hist, err := store.OpenActivities("")
+	if err != nil {
+		return nil, err
+	}
+	if hist.Len() == 0 {
+		return fetchActivities(cmd, api, after, before)
+	}
+	if _, _, err := syncSummaries(cmd, api, hist, false); err != nil {
+		return nil, err
+	}
+	return storedActivities(hist, after, before)
+}
+
+// storedActivities returns the synced activities between after and before
+// (zero values mean unbounded).
+func storedActivities(hist *store.Activities, after, before time.Time) ([]analysis.Activity, error) {
+	items, err := hist.List()
+	if err != nil {
+		return nil, err
+	}
+	var acts []analysis.Activity
+	for _, raw := range items {
+		var a analysis.Activity
+		if err := json.Unmarshal(raw, &a); err != nil {
+			return nil, fmt.Errorf("parse synced activity: %w", err)
+		}
+		if (!after.IsZero() && !a.StartDate.After(after)) || (!before.IsZero() && !a.StartDate.Before(before)) {
+			continue
+		}
+		acts = append(acts, a)
+	}
+	return acts, nil
+}
+
+// fetchStreams fetches the given stream keys for an activity.
+func fetchStreams(cmd *cobra.Command, api *genclient.ClientWithResponses, id int64, keys ...string) (*analysis.Streams, error) {
+	body, err := streamsBody(cmd, api, id, keys, false)
+	if err != nil {
+		return nil, err
+	}
+	return analysis.ParseStreams(body)
+}
+
+// streamJob is a value worked out from each activity's streams and cached
+// per activity; see cachedStreamValues.
+type streamJob[V any] struct {
+	progress string                                           // shown before each fetch, e.g. "Classifying"
+	keys     []string                                         // the streams to fetch
+	skip     func(a analysis.Activity) bool                   // activities without the data to work from
+	fresh    func(v V) bool                                   // whether a cached value still holds; nil if always
+	compute  func(a analysis.Activity, s *analysis.Streams) V // the value, from the streams
+}
+
+// cachedStreamValues works out job's value for each activity in acts that
+// job does not skip: from cache when it is there and fresh, otherwise from
+// the activity's streams, which are fetched once and the result cached. The
+// cache is saved as it goes, so an interrupted run does not repeat its
+// requests; then what was worked out so far is returned with the context's
+// error. An activity whose streams cannot be fetched is left out with a
+// warning.
+func cachedStreamValues[V any](cmd *cobra.Command, api *genclient.ClientWithResponses, cache *store.Cache[V],
+	acts []analysis.Activity, job streamJob[V]) (map[int64]V, error) {
+	values := map[int64]V{}
+	fetched := 0
+	for _, a := range acts {
+		if job.skip(a) {
+			continue
+		}
+		if v, ok := cache.Lookup(a.ID); ok && (job.fresh == nil || job.fresh(v)) {
+			values[a.ID] = v
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "%s %d (%s)\n", job.progress, a.ID, a.StartDateLocal.Format("2006-01-02"))
+		streams, err := fetchStreams(cmd, api, a.ID, job.keys...)
+		if err != nil && cmd.Context().Err() != nil {
+			break
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: activity %d: %v\n", a.ID, err)
+			continue
+		}
+		v := job.compute(a, streams)
+		values[a.ID] = v
+		cache.Set(a.ID, v)
+		if fetched++; fetched%20 == 0 {
+			if err := cache.Save(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if fetched > 0 {
+		if err := cache.Save(); err != nil {
+			return nil, err
+		}
+	}
+	return values, cmd.Context().Err()
+}
+
+// streamChunkAttempts is how many times each stream is requested when a
+// download is chunked, before giving up (and leaving the rest for a rerun).
+const streamChunkAttempts = 3
+
+// streamsBody fetches an activity's streams, keyed by type, as one JSON
+// object. If the request fails in transit — typically a timeout on the huge
+// response of a 20-hour activity — or chunked is set, the streams are
+// fetched one key at a time instead (see fetchStreamChunks).
+func streamsBody(cmd *cobra.Command, api *genclient.ClientWithResponses, id int64, keys []string, chunked bool) ([]byte, error) {
+	if !chunked {
+		params := &genclient.GetActivityStreamsParams{KeyByType: true}
+		for _, k := range keys {
+			params.Keys = append(params.Keys, genclient.GetActivityStreamsParamsKeys(k))
+		}
+		resp, err := api.GetActivityStreamsWithResponse(cmd.Context(), id, params)
+		if err == nil {
+			if resp.HTTPResponse.StatusCode != 200 {
+				return nil, apiError(resp.HTTPResponse.StatusCode, resp.Body)
+			}
+			return resp.Body, nil
+		}
+		if cmd.Context().Err() != nil || len(keys) < 2 {
+			return nil, fmt.Errorf("fetch streams for %d: %w", id, err)
+		}
+		fmt.Fprintf(os.Stderr, "Fetching streams for %d failed (%v); fetching one stream at a time\n", id, err)
+	}
+	return fetchStreamChunks(cmd, api, id, keys)
+}
+
+// fetchStreamChunks fetches an activity's streams one key at a time, each
+// request a fraction of the whole (the API cannot split a stream by time
+// range), retrying each a few times. Every stream is spilled to disk as it
+// arrives (see store.StreamSpill), so a download that still fails resumes
+// from the streams already saved when run again.
+func fetchStreamChunks(cmd *cobra.Command, api *genclient.ClientWithResponses, id int64, keys []string) ([]byte, error) {
+	spill, err := store.OpenStreamSpill(id, "")
+	if err != nil {
+		return nil, err
+	}
+	streams := map[string]json.RawMessage{}
+	for n, key := range keys {
+		if !slices.Contains(analysis.StreamKeys, key) {
+			return nil, fmt.Errorf("unknown stream %q (available: %s)", key, strings.Join(analysis.StreamKeys, ", "))
+		}
+		raw, ok, err := spill.Get(key)
+		if err != nil {
+			return nil, err
+		}
+		for attempt := 1; !ok; attempt++ {
+			fmt.Fprintf(os.Stderr, "Fetching %s stream for %d (%d/%d)\n", key, id, n+1, len(keys))
+			var retry bool
+			raw, retry, err = fetchStreamChunk(cmd, api, id, key)
+			if err == nil {
+				if err := spill.Put(key, raw); err != nil {
+					return nil, err
+				}
+				break
+			}
+			if !retry || attempt == streamChunkAttempts || cmd.Context().Err() != nil {
+				return nil, fmt.Errorf("%w\n  %d of %d streams for %d are saved; run the command again to fetch the rest",
+					err, n, len(keys), id)
+			}
+			select {
+			case <-cmd.Context().Done():
+				return nil, fmt.Errorf("%w\n  %d of %d streams for %d are saved; run the command again to fetch the rest",
+					cmd.Context().Err(), n, len(keys), id)
+			case <-time.After(time.Duration(attempt) * 2 * time.Second):
+			}
+		}
+		if raw != nil {
+			streams[key] = raw
+		}
+	}
+	body, err := json.Marshal(streams)
+	if err != nil {
+		return nil, err
+	}
+	if err := spill.Remove(); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+	return body, nil
+}
+
+// fetchStreamChunk fetches one stream of an activity: its raw JSON object, or
+// nil when the activity does not have it. retry reports whether an error
+// happened in transit and may pass; an error status from the API will not.
+func fetchStreamChunk(cmd *cobra.Command, api *genclient.ClientWithResponses, id int64, key string) (raw json.RawMessage, retry bool, err error) {
+	resp, err := api.GetActivityStreamsWithResponse(cmd.Context(), id, &genclient.GetActivityStreamsParams{
+		Keys:      []genclient.GetActivityStreamsParamsKeys{genclient.GetActivityStreamsParamsKeys(key)},
+		KeyByType: true,
+	})
+	if err != nil {
+		return nil, true, fmt.Errorf("fetch %s stream for %d: %w", key, id, err)
+	}
+	if resp.HTTPResponse.StatusCode != 200 {
+		return nil, false, apiError(resp.HTTPResponse.StatusCode, resp.Body)
+	}
+	// The response carries the series the stream is indexed by as well.
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(resp.Body, &all); err != nil {
+		return nil, false, fmt.Errorf("parse streams: %w", err)
+	}
+	return all[key], false, nil
+}
+
+// streamSampling returns a request editor that asks the streams endpoint to
+// downsample: resolution is low (~100 points), medium (~1000) or high
+// (~10000), and seriesType spaces the samples by time or by distance. The
+// generated client does not know these parameters, so they are added to the
+// query directly. Empty values leave the request untouched (every sample).
+func streamSampling(resolution, seriesType string) (genclient.RequestEditorFn, error) {
+	switch resolution {
+	case "", "low", "medium", "high":
+	default:
+		return nil, fmt.Errorf("invalid --resolution %q: must be low, medium or high", resolution)
+	}
+	switch seriesType {
+	case "", "time", "distance":
+	default:
+		return nil, fmt.Errorf("invalid --series-type %q: must be time or distance", seriesType)
+	}
+	return func(_ context.Context, req *http.Request) error {
+		q := req.URL.Query()
+		if resolution != "" {
+			q.Set("resolution", resolution)
+		}
+		if seriesType != "" {
+			q.Set("series_type", seriesType)
+		}
+		req.URL.RawQuery = q.Encode()
+		return nil
+	}, nil
+}
+
+// parseDate accepts YYYY-MM-DD, RFC3339, or a Unix timestamp. An empty string
+// yields the zero time.
+func parseDate(flag, s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(n, 0), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --%s %q: use YYYY-MM-DD, RFC3339, or a Unix timestamp", flag, s)
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/config"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/store"
+)
+
+// hookEnv names the hook a program is run as, so one script can serve
+// several.
+const hookEnv = "STRAVA_HOOK"
+
+// checkHook validates a hook program for config set: an executable file,
+// returned as an absolute path so the hook runs from any directory.
+func checkHook(path string) (string, error) {
+	path = strings.TrimSpace(path)
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(home, rest)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return "", fmt.Errorf("invalid hook: %w", err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("invalid hook: %s is a directory", abs)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0o111 == 0 {
+		return "", fmt.Errorf("invalid hook: %s is not executable (chmod +x %s)", abs, abs)
+	}
+	return abs, nil
+}
+
+// runPostSyncHook runs the hooks.post-sync program, if one is set, with the
+// activities a sync stored as new (their summaries, as the API returned
+// them) as a JSON array on stdin. Its output goes to stderr, leaving stdout
+// to the command. A hook that fails is reported, not returned: the sync it
+// follows has succeeded.
+//
+// The activities are held in post-sync-pending.json until the hook exits
+// successfully, so those of a hook that fails or is interrupted are handed
+// to it again, before the new ones, by the next sync: delivery is at least
+// once.
+func runPostSyncHook(cmd *cobra.Command, fresh []json.RawMessage) {
+	cfg, err := config.Load()
+	if err != nil || cfg.Hooks.PostSync == "" {
+		return
+	}
+	pending, err := store.OpenHookQueue("")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: post-sync hook: %v\n", err)
+		return
+	}
+	retries := len(pending.Activities)
+	if retries+len(fresh) == 0 {
+		return
+	}
+	if err := pending.Add(fresh); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: post-sync hook: %v\n", err)
+		return
+	}
+	payload, err := json.Marshal(pending.Activities)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: post-sync hook: %v\n", err)
+		return
+	}
+	n := len(pending.Activities)
+	if retries > 0 {
+		fmt.Fprintf(os.Stderr, "Running post-sync hook %s (%d new activities, %d held from a failed run)\n",
+			cfg.Hooks.PostSync, n-retries, retries)
+	} else {
+		fmt.Fprintf(os.Stderr, "Running post-sync hook %s (%d new activities)\n", cfg.Hooks.PostSync, n)
+	}
+	syncLog.Info("post-sync hook started", "hook", cfg.Hooks.PostSync, "activities", n, "retried", retries)
+	c := exec.CommandContext(cmd.Context(), cfg.Hooks.PostSync)
+	c.Stdin = bytes.NewReader(payload)
+	c.Stdout, c.Stderr = os.Stderr, os.Stderr
+	c.Env = append(os.Environ(), hookEnv+"=post-sync", fmt.Sprintf("STRAVA_NEW_ACTIVITIES=%d", n))
+	if err := c.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: post-sync hook %s: %v; its %d activities are handed to it again at the next sync\n",
+			cfg.Hooks.PostSync, err, n)
+		syncLog.Warn("post-sync hook failed", "hook", cfg.Hooks.PostSync, "error", err)
+		return
+	}
+	if err := pending.Clear(); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: post-sync hook: %v\n", err)
+	}
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/inspect"
+)
+
+var (
+	inspectFile string
+	inspectGap  time.Duration
+)
+
+var inspectCmd = &cobra.Command{
+	Use:   "inspect",
+	Short: "Summarise an activity file without uploading it",
+	Long: `Read a FIT, GPX or TCX file (optionally gzipped) locally and summarise it:
+sport, recording device and firmware, start, elapsed time, distance, the
+sensors it has data from, and the gaps of at least --gap between records.
+
+It then lists problems that make Strava reject or mangle an upload: points
+without timestamps (a route exported instead of a recording), timestamps
+that go backwards, GPS jumps, positions at 0°, 0°, and an unset device clock.
+Nothing is sent to Strava, so it works offline and without logging in.
+
+The distance is the device's own when the file records one, otherwise it is
+measured along the GPS track, leaving out jumps.
+
+Examples:
+  strava inspect --file ride.fit
+  strava inspect --file export.gpx --gap 10s
+  strava inspect --file run.tcx.gz --json`,
+	Args: cobra.NoArgs,
+	RunE: runInspect,
+}
+
+func init() {
+	rootCmd.AddCommand(inspectCmd)
+	inspectCmd.Flags().StringVar(&inspectFile, "file", "", "Activity file: .fit, .gpx or .tcx, optionally .gz")
+	inspectCmd.Flags().DurationVar(&inspectGap, "gap", 30*time.Second, "Report pauses between records of at least this long")
+	inspectCmd.MarkFlagRequired("file")
+}
+
+func runInspect(cmd *cobra.Command, args []string) error {
+	in, err := os.Open(inspectFile)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	f, err := inspect.Read(inspectFile, in)
+	if err != nil {
+		return err
+	}
+	return newPrinter().Inspection(filepath.Base(inspectFile), inspect.Summarize(f, inspectGap))
+}
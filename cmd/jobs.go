@@ -0,0 +1,358 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/redact"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/store"
+)
+
+// jobEnv carries the job ID to the process running a detached command, so
+// it can record how the command ended.
+const jobEnv = "STRAVA_JOB_ID"
+
+// jobPollInterval is how often `jobs logs --follow` and `jobs cancel` check
+// on a job.
+const jobPollInterval = 500 * time.Millisecond
+
+// jobCancelWait bounds how long `jobs cancel` waits for the process to exit.
+const jobCancelWait = 5 * time.Second
+
+var jobsCmd = &cobra.Command{
+	Use:   "jobs",
+	Short: "Manage background jobs started with --detach",
+	Long: `Long-running commands (such as sync) accept --detach, which starts the
+command in the background as a job and returns at once. Each job has a
+state file and a log under ~/.config/strava-cli/jobs/, so jobs can be
+listed, followed and cancelled from another terminal (or another program).
+
+With no subcommand, lists the jobs.
+
+Examples:
+  strava sync --descriptions=500 --detach
+  strava jobs
+  strava jobs logs 3fa9c1 --follow
+  strava jobs cancel 3fa9c1`,
+	Args: cobra.NoArgs,
+	RunE: runJobsList,
+}
+
+var jobsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List jobs, most recent first",
+	Args:  cobra.NoArgs,
+	RunE:  runJobsList,
+}
+
+var jobsShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Show a job's state",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runJobsShow,
+
+	ValidArgsFunction: completeJobIDs,
+}
+
+var jobsFollow bool
+
+var jobsLogsCmd = &cobra.Command{
+	Use:   "logs <id>",
+	Short: "Print a job's output",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runJobsLogs,
+
+	ValidArgsFunction: completeJobIDs,
+}
+
+var jobsCancelCmd = &cobra.Command{
+	Use:   "cancel <id>",
+	Short: "Stop a running job",
+	Long: `Stop a running job. Commands save their progress as they go (sync
+keeps every page it fetched), so running the same command again continues
+where the job stopped.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runJobsCancel,
+
+	ValidArgsFunction: completeJobIDs,
+}
+
+var jobsCleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "Delete the records and logs of finished jobs",
+	Args:  cobra.NoArgs,
+	RunE:  runJobsClean,
+}
+
+func init() {
+	rootCmd.AddCommand(jobsCmd)
+	jobsCmd.AddCommand(jobsListCmd)
+	jobsCmd.AddCommand(jobsShowCmd)
+	jobsCmd.AddCommand(jobsLogsCmd)
+	jobsCmd.AddCommand(jobsCancelCmd)
+	jobsCmd.AddCommand(jobsCleanCmd)
+
+	jobsLogsCmd.Flags().BoolVarP(&jobsFollow, "follow", "f", false, "Keep printing output until the job ends")
+}
+
+// detachable adds --detach to a long-running command: with it, the command
+// runs again in the background as a job and this process returns at once.
+// Call it after RunE is set.
+func detachable(c *cobra.Command) {
+	c.Flags().Bool("detach", false, "Run in the background as a job (see: strava jobs)")
+	run := c.RunE
+	c.RunE = func(cmd *cobra.Command, args []string) error {
+		if detach, _ := cmd.Flags().GetBool("detach"); detach {
+			return startJob()
+		}
+		return run(cmd, args)
+	}
+}
+
+// startJob re-runs the current command line, minus --detach, as a
+// background process writing to the job's log.
+func startJob() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("start job: %w", err)
+	}
+	var args []string
+	for _, a := range os.Args[1:] {
+		if a != "--detach" && !strings.HasPrefix(a, "--detach=") {
+			args = append(args, a)
+		}
+	}
+	shown := redact.Args(args)
+	jobs, err := store.OpenJobs("")
+	if err != nil {
+		return err
+	}
+	job, err := jobs.New(shown)
+	if err != nil {
+		return err
+	}
+	logFile, err := os.OpenFile(jobs.LogPath(job.ID), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("start job: %w", err)
+	}
+	defer logFile.Close()
+
+	c := exec.Command(exe, args...)
+	c.Stdout, c.Stderr = logFile, logFile
+	c.Env = append(os.Environ(), jobEnv+"="+job.ID)
+	c.SysProcAttr = detachAttr()
+	if err := c.Start(); err != nil {
+		job.State, job.Error, job.FinishedAt = store.JobFailed, err.Error(), time.Now().UTC()
+		_ = jobs.Save(job)
+		return fmt.Errorf("start job: %w", err)
+	}
+	job.PID = c.Process.Pid
+	if err := jobs.Update(job.ID, func(j *store.Job) { j.PID = job.PID }); err != nil {
+		return err
+	}
+	_ = c.Process.Release()
+
+	if jsonOutput || templateFlag != "" {
+		return newPrinter().Job(*job, jobs.LogPath(job.ID))
+	}
+	fmt.Fprintf(os.Stdout, "Started job %s (PID %d)\n", job.ID, job.PID)
+	fmt.Fprintf(os.Stdout, "  follow: strava jobs logs %s --follow\n  cancel: strava jobs cancel %s\n", job.ID, job.ID)
+	return nil
+}
+
+// takeJobID returns the ID of the job this process runs, if it was started
+// with --detach, and removes it from the environment so that no process the
+// command starts (a post-sync hook calling stravacli, say) finishes the job
+// in its place.
+func takeJobID() string {
+	id := os.Getenv(jobEnv)
+	os.Unsetenv(jobEnv)
+	return id
+}
+
+// finishJob records how a detached command ended. It runs in the job's own
+// process, after the command returns.
+func finishJob(id string, runErr error) {
+	jobs, err := store.OpenJobs("")
+	if err != nil {
+		return
+	}
+	_ = jobs.Update(id, func(j *store.Job) {
+		j.FinishedAt = time.Now().UTC()
+		switch {
+		case runErr == nil:
+			j.State = store.JobDone
+		case j.CancelRequested:
+			j.State = store.JobCancelled
+		default:
+			j.State, j.Error = store.JobFailed, redact.String(runErr.Error())
+		}
+	})
+}
+
+// jobState returns the job's state, noticing jobs whose process has gone
+// without recording how it ended (killed, crashed, or cancelled).
+func jobState(j store.Job) string {
+	if j.State != store.JobRunning || j.PID == 0 || processAlive(j.PID) {
+		return j.State
+	}
+	if j.CancelRequested {
+		return store.JobCancelled
+	}
+	return store.JobDied
+}
+
+func runJobsList(cmd *cobra.Command, args []string) error {
+	jobs, err := store.OpenJobs("")
+	if err != nil {
+		return err
+	}
+	list, err := jobs.List()
+	if err != nil {
+		return err
+	}
+	for i := range list {
+		list[i].State = jobState(list[i])
+	}
+	return newPrinter().Jobs(list)
+}
+
+func runJobsShow(cmd *cobra.Command, args []string) error {
+	jobs, err := store.OpenJobs("")
+	if err != nil {
+		return err
+	}
+	job, err := jobs.Get(args[0])
+	if err != nil {
+		return err
+	}
+	job.State = jobState(*job)
+	return newPrinter().Job(*job, jobs.LogPath(job.ID))
+}
+
+func runJobsLogs(cmd *cobra.Command, args []string) error {
+	jobs, err := store.OpenJobs("")
+	if err != nil {
+		return err
+	}
+	job, err := jobs.Get(args[0])
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(jobs.LogPath(job.ID))
+	if err != nil {
+		return fmt.Errorf("open job log: %w", err)
+	}
+	defer f.Close()
+	for {
+		// Check before copying, so output written as the job ends is not lost.
+		running := jobsFollow && jobState(*job) == store.JobRunning
+		if _, err := io.Copy(os.Stdout, f); err != nil {
+			return err
+		}
+		if !running {
+			return nil
+		}
+		select {
+		case <-cmd.Context().Done():
+			return cmd.Context().Err()
+		case <-time.After(jobPollInterval):
+		}
+		if job, err = jobs.Get(job.ID); err != nil {
+			return err
+		}
+	}
+}
+
+func runJobsCancel(cmd *cobra.Command, args []string) error {
+	jobs, err := store.OpenJobs("")
+	if err != nil {
+		return err
+	}
+	job, err := jobs.Get(args[0])
+	if err != nil {
+		return err
+	}
+	if state := jobState(*job); state != store.JobRunning {
+		return fmt.Errorf("job %s is not running (%s)", job.ID, state)
+	}
+	if job.PID == 0 {
+		return fmt.Errorf("job %s has not started yet; try again in a moment", job.ID)
+	}
+	if err := jobs.Update(job.ID, func(j *store.Job) { j.CancelRequested = true }); err != nil {
+		return err
+	}
+	if err := terminate(job.PID); err != nil {
+		return fmt.Errorf("cancel job %s: %w", job.ID, err)
+	}
+	for deadline := time.Now().Add(jobCancelWait); processAlive(job.PID); {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("job %s (PID %d) did not stop within %v", job.ID, job.PID, jobCancelWait)
+		}
+		time.Sleep(jobPollInterval)
+	}
+	err = jobs.Update(job.ID, func(j *store.Job) {
+		if j.State == store.JobRunning {
+			j.State, j.FinishedAt = store.JobCancelled, time.Now().UTC()
+		}
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stdout, "Cancelled job %s\n", job.ID)
+	return nil
+}
+
+func runJobsClean(cmd *cobra.Command, args []string) error {
+	jobs, err := store.OpenJobs("")
+	if err != nil {
+		return err
+	}
+	list, err := jobs.List()
+	if err != nil {
+		return err
+	}
+	var errs []error
+	removed := 0
+	for _, j := range list {
+		if jobState(j) == store.JobRunning {
+			continue
+		}
+		if err := jobs.Remove(j.ID); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		removed++
+	}
+	fmt.Fprintf(os.Stdout, "Removed %d finished jobs\n", removed)
+	return errors.Join(errs...)
+}
+
+// completeJobIDs completes the first argument with job IDs, described by
+// state and command.
+func completeJobIDs(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	jobs, err := store.OpenJobs("")
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	list, err := jobs.List()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var out []cobra.Completion
+	for _, j := range list {
+		if strings.HasPrefix(j.ID, toComplete) {
+			out = append(out, cobra.CompletionWithDesc(j.ID, jobState(j)+" "+j.Command()))
+		}
+	}
+	return out, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+}
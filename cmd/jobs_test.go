@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestTakeJobID_HidesItFromChildren(t *testing.T) {
+	t.Setenv(jobEnv, "20260101-120000-abcd")
+	if id := takeJobID(); id != "20260101-120000-abcd" {
+		t.Fatalf("takeJobID = %q", id)
+	}
+	if _, ok := os.LookupEnv(jobEnv); ok {
+		t.Errorf("%s is still set", jobEnv)
+	}
+	out, err := exec.Command("env").Output()
+	if err != nil {
+		t.Skip("no env command:", err)
+	}
+	if strings.Contains(string(out), jobEnv+"=") {
+		t.Errorf("a child process inherits %s", jobEnv)
+	}
+	if id := takeJobID(); id != "" {
+		t.Errorf("takeJobID again = %q, want none", id)
+	}
+}
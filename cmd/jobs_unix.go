@@ -0,0 +1,22 @@
+//go:build !windows
+
+package cmd
+
+import "syscall"
+
+// detachAttr starts a job in its own session, so it outlives the terminal
+// that started it.
+func detachAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
+
+// processAlive reports whether a process with the given PID exists.
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
+
+// terminate asks a job's process to stop.
+func terminate(pid int) error {
+	return syscall.Kill(pid, syscall.SIGTERM)
+}
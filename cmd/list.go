@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/output"
+)
+
+// This file is the pipeline the list commands share: fetch pages, filter,
+// then print, where the printer sorts (--sort) and selects columns
+// (--columns). A flag added here applies to every list.
+
+var (
+	listPage    int
+	listPerPage int
+	listAll     bool
+	listColumns string
+	listSort    string
+)
+
+// addListFlags registers --columns and --sort on a list command whose table
+// is the named entry of output.ListColumns.
+func addListFlags(cmd *cobra.Command, list string) {
+	cols := output.ListColumns[list]
+	cmd.Flags().StringVar(&listColumns, "columns", "",
+		"Comma-separated table columns: "+strings.Join(cols, ", "))
+	cmd.Flags().StringVar(&listSort, "sort", "", "Sort by a column, ascending or with :desc (e.g. distance:desc)")
+	cmd.RegisterFlagCompletionFunc("columns", func(_ *cobra.Command, _ []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		// Complete the last entry of the comma-separated list.
+		done := toComplete[:strings.LastIndex(toComplete, ",")+1]
+		var out []cobra.Completion
+		for _, c := range cols {
+			out = append(out, done+c)
+		}
+		return out, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+	})
+	cmd.RegisterFlagCompletionFunc("sort", func(_ *cobra.Command, _ []string, _ string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		var out []cobra.Completion
+		for _, c := range cols {
+			out = append(out, c, c+":desc")
+		}
+		return out, cobra.ShellCompDirectiveNoFileComp
+	})
+}
+
+// addPageFlags registers --per-page on a list command of the named items and,
+// when the endpoint takes a page number, --page and --all.
+func addPageFlags(cmd *cobra.Command, what string, paged bool) {
+	cmd.Flags().IntVar(&listPerPage, "per-page", 30, fmt.Sprintf("%s per page (max 200)", strings.ToUpper(what[:1])+what[1:]))
+	if paged {
+		cmd.Flags().IntVar(&listPage, "page", 1, "Page number")
+		cmd.Flags().BoolVar(&listAll, "all", false,
+			"Fetch every page from --page on (200 per page unless --per-page is set); printed once all are fetched, except with --output ndjson")
+	}
+}
+
+// listSource is where runList gets a list from. fetch requests one page
+// (endpoints without pages ignore page); filter, when set, drops or rewrites
+// the raw items before printing; print renders them as the generated list
+// response type R its printer takes.
+type listSource[R any] struct {
+	what   string // plural noun for messages, e.g. "routes"
+	fetch  func(ctx context.Context, page, perPage int) (*http.Response, []byte, error)
+	filter func(items []json.RawMessage) ([]json.RawMessage, error)
+	print  func(p *output.Printer, r *R) error
+}
+
+// runList fetches the pages asked for with --page, --per-page and --all,
+// filters and prints them. When the output format streams (ndjson) and the
+// list is not sorted, each page is printed as it arrives; otherwise all of
+// them at the end: table columns are as wide as the widest value in the
+// whole list, CSV and Markdown columns are those of all its items, and
+// Parquet is written in one go. When a later page fails, what was fetched
+// is printed and the error says how to resume. Once the pager is quit, no
+// more pages are fetched.
+func runList[R any](cmd *cobra.Command, printer *output.Printer, src listSource[R]) error {
+	paged := cmd.Flags().Lookup("page") != nil
+	page, perPage := 1, listPerPage
+	if paged {
+		page = listPage
+	}
+	if listAll && !cmd.Flags().Changed("per-page") {
+		perPage = historyPageSize
+	}
+	stream := printer.Streaming() && listSort == ""
+
+	var items []json.RawMessage
+	var fetchErr, printErr error
+	fetched := 0
+	deliver := func(_ int, batch []json.RawMessage) error {
+		if pagerQuit() {
+			return errPagerQuit
+		}
+		fetched += len(batch)
+		if stream {
+			printErr = printList(printer, src, batch)
+			return printErr
+		}
+		items = append(items, batch...)
+		return nil
+	}
+	fetch := func(ctx context.Context, page int) ([]json.RawMessage, error) {
+		return fetchListPage(ctx, src, page, perPage)
+	}
+	if paged && listAll {
+		page, fetchErr = fetchPages(cmd.Context(), page, perPage, fetch, deliver)
+	} else if batch, err := fetch(cmd.Context(), page); err != nil {
+		fetchErr = err
+	} else {
+		fetchErr = deliver(page, batch)
+	}
+	if printErr != nil {
+		return printErr
+	}
+	if pagerQuit() {
+		return nil // the rest is not wanted, nor an error about it
+	}
+	if fetchErr != nil && fetched == 0 {
+		return fetchErr
+	}
+
+	if !stream {
+		if err := printList(printer, src, items); err != nil {
+			return err
+		}
+	}
+	if fetchErr != nil {
+		// Keep what was fetched (printed above) and say how to get the rest.
+		return fmt.Errorf("%w\n  pages before %d are shown above; fetch the rest with:\n  %s",
+			fetchErr, page, resumeCommand(cmd, page, perPage))
+	}
+	return nil
+}
+
+// printList filters raw list items and prints them with src's printer.
+func printList[R any](printer *output.Printer, src listSource[R], items []json.RawMessage) error {
+	if src.filter != nil {
+		var err error
+		if items, err = src.filter(items); err != nil {
+			return err
+		}
+	}
+	resp, err := listResponse[R](items)
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", src.what, err)
+	}
+	return src.print(printer, resp)
+}
+
+func fetchListPage[R any](ctx context.Context, src listSource[R], page, perPage int) ([]json.RawMessage, error) {
+	resp, body, err := src.fetch(ctx, page, perPage)
+	if err != nil {
+		return nil, fmt.Errorf("fetch %s (page %d): %w", src.what, page, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch %s (page %d): %w", src.what, page, apiError(resp.StatusCode, body))
+	}
+	var batch []json.RawMessage
+	if err := json.Unmarshal(body, &batch); err != nil {
+		return nil, fmt.Errorf("parse %s (page %d): %w", src.what, page, err)
+	}
+	return batch, nil
+}
+
+// listResponse builds a generated list response (its Body and JSON200) from
+// raw items, as if a single page had returned them all. R must be one of the
+// generated XResponse types.
+func listResponse[R any](items []json.RawMessage) (*R, error) {
+	if items == nil {
+		items = []json.RawMessage{} // "[]", not "null"
+	}
+	body, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+	r := new(R)
+	v := reflect.ValueOf(r).Elem()
+	v.FieldByName("Body").SetBytes(body)
+	if err := json.Unmarshal(body, v.FieldByName("JSON200").Addr().Interface()); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// resumeCommand rebuilds the command line that was run with --page and
+// --per-page set to continue from page.
+func resumeCommand(cmd *cobra.Command, page, perPage int) string {
+	parts := []string{cmd.CommandPath()}
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		if f.Name == "page" || f.Name == "per-page" {
+			return
+		}
+		v := f.Value.String()
+		if strings.ContainsAny(v, " \t'\"$") {
+			v = "'" + strings.ReplaceAll(v, "'", `'\''`) + "'"
+		}
+		parts = append(parts, "--"+f.Name+"="+v)
+	})
+	return strings.Join(append(parts, fmt.Sprintf("--page=%d", page), fmt.Sprintf("--per-page=%d", perPage)), " ")
+}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	imgpng "image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+
+	"github.com/spf13/cobra"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/config"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/fsutil"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/geo"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/maps"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/output"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/plot"
+)
+
+// This file holds what routes map and activities map share: both decode a
+// polyline and draw it in the terminal, as a web page or as a PNG on map
+// tiles.
+
+var (
+	mapHTML  string
+	mapOpen  bool
+	mapOut   string
+	mapTiles string
+)
+
+// addMapFlags registers --html, --open, --map-out and --tiles on a map
+// command.
+func addMapFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&mapHTML, "html", "", "Write an interactive map page (Leaflet, OpenStreetMap tiles) to this file")
+	cmd.Flags().BoolVar(&mapOpen, "open", false, "Open the map page in the browser (in a temporary file unless --html is set)")
+	cmd.Flags().StringVar(&mapOut, "map-out", "", "Write the track on OpenStreetMap tiles to this PNG file")
+	cmd.Flags().StringVar(&mapTiles, "tiles", maps.DefaultTileURL, "Tile server URL template for --map-out, with {z}, {x} and {y}")
+	_ = cmd.MarkFlagFilename("html", "html")
+	_ = cmd.MarkFlagFilename("map-out", "png")
+}
+
+// showMap draws a track from its encoded polyline: as a braille minimap, or
+// as a PNG with --map-out and a web page with --html or --open. name is the
+// page's file name stem.
+func showMap(ctx context.Context, name, polyline string, m output.TrackMap) error {
+	m.Points = geo.DecodePolyline(polyline)
+	if mapHTML == "" && !mapOpen && mapOut == "" {
+		return newPrinter().Map(m)
+	}
+	if len(m.Points) < 2 {
+		return fmt.Errorf("%s has no GPS track to map", name)
+	}
+	if mapOut != "" {
+		if err := writeTileMap(ctx, mapOut, m.Points); err != nil {
+			return err
+		}
+		if mapHTML == "" && !mapOpen {
+			return nil
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := plot.LeafletHTML(&buf, m.Name, m.Points); err != nil {
+		return fmt.Errorf("write map: %w", err)
+	}
+	path := mapHTML
+	if path == "" {
+		// A page to open in the browser, which must outlive this run.
+		f, err := os.CreateTemp("", name+"-*.html")
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(buf.Bytes())
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return fmt.Errorf("write map: %w", err)
+		}
+		path = f.Name()
+	} else if err := fsutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("write map: %w", err)
+	}
+	path, _ = filepath.Abs(path)
+	fmt.Fprintf(os.Stderr, "Wrote map → %s\n", path)
+	if !mapOpen {
+		return nil
+	}
+	if err := openBrowser("file://" + filepath.ToSlash(path)); err != nil {
+		return fmt.Errorf("open browser: %w (open %s yourself)", err, path)
+	}
+	return nil
+}
+
+// writeTileMap renders pts on map tiles from --tiles into a PNG at path.
+// Tiles are cached under the config directory.
+func writeTileMap(ctx context.Context, path string, pts []geo.Point) error {
+	mc := maps.New(httpClient(), "strava-cli/"+rootCmd.Version+" (+https://github.com/Brainsoft-Raxat/strava-cli)")
+	if mapTiles != maps.DefaultTileURL {
+		mc.TileURL, mc.Attribution = mapTiles, ""
+	}
+	if dir, err := config.Dir(); err == nil {
+		mc.CacheDir = filepath.Join(dir, "tiles")
+	}
+	img, err := mc.Render(ctx, pts, shareMapWidth, shareMapHeight)
+	if err != nil {
+		return fmt.Errorf("render map: %w", err)
+	}
+	f, err := fsutil.Create(path, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Discard()
+	err = imgpng.Encode(f, img)
+	if err == nil {
+		err = f.Commit()
+	}
+	if err != nil {
+		return fmt.Errorf("write map: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Wrote map → %s\n", path)
+	return nil
+}
+
+// openBrowser opens url in the desktop's default browser.
+func openBrowser(url string) error {
+	var c *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		c = exec.Command("open", url)
+	case "windows":
+		c = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		c = exec.Command("xdg-open", url)
+	}
+	return c.Start()
+}
@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/store"
+)
+
+var metaCmd = &cobra.Command{
+	Use:   "meta",
+	Short: "Attach your own fields to activities, kept locally",
+	Long: `Attach key/value fields to activities for data Strava's model has no
+place for: which shoe rotation, a race or not, how the legs felt. Fields are
+kept in ~/.config/strava-cli/meta.json and never sent to Strava.
+
+activities list and activities search filter on them with --meta and show
+them in the meta column (--columns id,name,meta); their --json output is the
+API's and leaves them out. meta list --json exports them all, for a backup
+next to the synced history.
+
+Examples:
+  strava meta set 12345 shoes_rotation=B race=yes
+  strava meta get 12345
+  strava meta unset 12345 race
+  strava activities list --meta shoes_rotation=B --all
+  strava meta list --json > meta-backup.json`,
+}
+
+var metaSetCmd = &cobra.Command{
+	Use:   "set <id> <key=value>...",
+	Short: "Set fields on an activity",
+	Args:  cobra.MinimumNArgs(2),
+	RunE:  runMetaSet,
+}
+
+var metaUnsetCmd = &cobra.Command{
+	Use:   "unset <id> <key>...",
+	Short: "Remove fields from an activity",
+	Args:  cobra.MinimumNArgs(2),
+	RunE:  runMetaUnset,
+
+	ValidArgsFunction: completeMetaKeys,
+}
+
+var metaGetCmd = &cobra.Command{
+	Use:   "get <id>",
+	Short: "Show an activity's fields",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runMetaGet,
+}
+
+var metaListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Show the fields of every activity (--json to export them)",
+	Args:  cobra.NoArgs,
+	RunE:  runMetaList,
+}
+
+func init() {
+	rootCmd.AddCommand(metaCmd)
+	metaCmd.AddCommand(metaSetCmd)
+	metaCmd.AddCommand(metaUnsetCmd)
+	metaCmd.AddCommand(metaGetCmd)
+	metaCmd.AddCommand(metaListCmd)
+}
+
+// metaKeyRe is what a field name may be: it has to survive the command line
+// and the key=value syntax of --meta.
+var metaKeyRe = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+func validMetaKey(k string) error {
+	if !metaKeyRe.MatchString(k) {
+		return fmt.Errorf("invalid field name %q: use letters, digits, _, . and -", k)
+	}
+	return nil
+}
+
+func runMetaSet(cmd *cobra.Command, args []string) error {
+	id, err := parseID(args[0])
+	if err != nil {
+		return err
+	}
+	m, err := store.OpenMeta("")
+	if err != nil {
+		return err
+	}
+	for _, kv := range args[1:] {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			return fmt.Errorf("invalid field %q: want key=value", kv)
+		}
+		if err := validMetaKey(k); err != nil {
+			return err
+		}
+		if v == "" {
+			return fmt.Errorf("empty value for %s: to remove it, run: strava meta unset %d %s", k, id, k)
+		}
+		m.Set(id, k, v)
+	}
+	if err := m.Save(); err != nil {
+		return err
+	}
+	return newPrinter().Meta(map[string]map[string]string{strconv.FormatInt(id, 10): m.Get(id)})
+}
+
+func runMetaUnset(cmd *cobra.Command, args []string) error {
+	id, err := parseID(args[0])
+	if err != nil {
+		return err
+	}
+	m, err := store.OpenMeta("")
+	if err != nil {
+		return err
+	}
+	for _, k := range args[1:] {
+		if !m.Unset(id, k) {
+			fmt.Fprintf(os.Stderr, "Activity %d has no field %s\n", id, k)
+		}
+	}
+	return m.Save()
+}
+
+func runMetaGet(cmd *cobra.Command, args []string) error {
+	id, err := parseID(args[0])
+	if err != nil {
+		return err
+	}
+	m, err := store.OpenMeta("")
+	if err != nil {
+		return err
+	}
+	fields := m.Get(id)
+	if fields == nil {
+		fields = map[string]string{}
+	}
+	return newPrinter().Meta(map[string]map[string]string{strconv.FormatInt(id, 10): fields})
+}
+
+func runMetaList(cmd *cobra.Command, args []string) error {
+	m, err := store.OpenMeta("")
+	if err != nil {
+		return err
+	}
+	return newPrinter().Meta(m.Activities)
+}
+
+// completeMetaKeys completes the field names of the activity named by the
+// first argument.
+func completeMetaKeys(_ *cobra.Command, args []string, _ string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	m, err := store.OpenMeta("")
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var out []cobra.Completion
+	for k := range m.Get(id) {
+		out = append(out, k)
+	}
+	return out, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeMetaFilter completes --meta with the field names in use.
+func completeMetaFilter(_ *cobra.Command, _ []string, _ string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	m, err := store.OpenMeta("")
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var out []cobra.Completion
+	for _, k := range m.Keys() {
+		out = append(out, k, k+"=")
+	}
+	return out, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+}
+
+// parseMetaFilter parses --meta values, key=value or key alone (any value).
+func parseMetaFilter(args []string) (map[string]string, error) {
+	if len(args) == 0 {
+		return nil, nil
+	}
+	f := map[string]string{}
+	for _, a := range args {
+		k, v, _ := strings.Cut(a, "=")
+		if err := validMetaKey(k); err != nil {
+			return nil, fmt.Errorf("--meta: %w", err)
+		}
+		f[k] = v
+	}
+	return f, nil
+}
+
+// withMeta sets "meta" on raw summary activities that have local fields, for
+// filters and the meta column.
+func withMeta(items []json.RawMessage) ([]json.RawMessage, error) {
+	m, err := store.OpenMeta("")
+	if err != nil || len(m.Activities) == 0 {
+		return items, err
+	}
+	for i, raw := range items {
+		var a struct {
+			ID int64 `json:"id"`
+		}
+		if err := json.Unmarshal(raw, &a); err != nil {
+			return nil, fmt.Errorf("parse activity: %w", err)
+		}
+		if fields := m.Get(a.ID); fields != nil {
+			if items[i], err = setJSONField(raw, "meta", fields); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return items, nil
+}
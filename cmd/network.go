@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/auth"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/client"
+)
+
+// network is how requests leave the machine (--proxy, --ca-bundle,
+// --insecure-skip-verify).
+var network client.Network
+
+// setNetwork makes the API client, token requests and the other services'
+// clients go through the --proxy and trust the --ca-bundle.
+func setNetwork() error {
+	if network.IsZero() {
+		client.SetTransport(nil)
+		auth.SetTransport(nil)
+		return nil
+	}
+	t, err := client.NewTransport(network)
+	if err != nil {
+		return err
+	}
+	if network.InsecureSkipVerify {
+		fmt.Fprintln(os.Stderr, "warning: --insecure-skip-verify: TLS certificates are not checked, so anyone on the network path can read and change requests, tokens included; prefer --ca-bundle")
+	}
+	client.SetTransport(t)
+	auth.SetTransport(t)
+	return nil
+}
+
+// httpClient returns a client for the services other than Strava's API
+// (weather, maps, elevation, intervals.icu, InfluxDB), over the same
+// network as the API client.
+func httpClient() *http.Client {
+	return &http.Client{Transport: client.Transport(), Timeout: 30 * time.Second}
+}
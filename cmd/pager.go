@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// usePager is set before a command runs when its tables may go through the
+// pager (see pageable).
+var usePager bool
+
+// stopPager waits for the pager, if one was started, to exit once the
+// command has written everything.
+var stopPager = func() {}
+
+// pageable reports whether the tables cmd prints may be shown in a pager:
+// they are written to a terminal, --no-pager was not given, and cmd does not
+// prompt for confirmation, which the pager would hide.
+func pageable(cmd *cobra.Command) bool {
+	if noPager || jsonOutput || templateFlag != "" || (outputFormat != "" && outputFormat != "table") {
+		return false
+	}
+	if cmd.Flags().Lookup("yes") != nil {
+		return false
+	}
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// pager is stdout for the printers when usePager is set. The pager is
+// started on the first write, so commands that print nothing, or ask which
+// activity to show first, do not start one.
+var pager = &pagerWriter{} //nolint:gochecknoglobals
+
+type pagerWriter struct {
+	once   sync.Once
+	w      io.Writer
+	paging bool
+	quit   atomic.Bool // the pager was quit before the end
+}
+
+func (pw *pagerWriter) Write(b []byte) (int, error) {
+	pw.once.Do(func() { pw.w, pw.paging = startPager() })
+	if pw.quit.Load() {
+		return len(b), nil
+	}
+	n, err := pw.w.Write(b)
+	if err != nil && pw.paging {
+		// The pager was quit before the end: the rest is not wanted.
+		pw.quit.Store(true)
+		return len(b), nil
+	}
+	return n, err
+}
+
+// errPagerQuit stops a command from fetching more of what it prints once
+// the pager it prints to has been quit.
+var errPagerQuit = errors.New("pager quit")
+
+// pagerQuit reports whether the pager was quit before the command finished
+// writing to it: what it still has to print is not wanted.
+func pagerQuit() bool { return usePager && pager.quit.Load() }
+
+// stdout is where commands write what they print themselves, rather than
+// through a Printer: the pager when usePager is set, so that it stays in
+// order with the tables, else stdout.
+func stdout() io.Writer {
+	if usePager {
+		return pager
+	}
+	return os.Stdout
+}
+
+// startPager runs $PAGER (less by default, as git does) and returns the
+// pipe to it. With PAGER empty or "cat", or when the pager cannot be run,
+// it returns stdout.
+func startPager() (io.Writer, bool) {
+	prog, ok := os.LookupEnv("PAGER")
+	if !ok {
+		prog = "less"
+	}
+	prog = strings.TrimSpace(prog)
+	if prog == "" || prog == "cat" {
+		return os.Stdout, false
+	}
+	var c *exec.Cmd
+	if runtime.GOOS == "windows" {
+		fields := strings.Fields(prog)
+		c = exec.Command(fields[0], fields[1:]...)
+	} else {
+		c = exec.Command("sh", "-c", prog)
+	}
+	c.Stdout, c.Stderr = os.Stdout, os.Stderr
+	c.Env = os.Environ()
+	if _, ok := os.LookupEnv("LESS"); !ok {
+		// Quit when the output fits on one screen, keep colors, and leave
+		// the output on the screen afterwards.
+		c.Env = append(c.Env, "LESS=FRX")
+	}
+	if _, ok := os.LookupEnv("LV"); !ok {
+		c.Env = append(c.Env, "LV=-c")
+	}
+	in, err := c.StdinPipe()
+	if err != nil {
+		return os.Stdout, false
+	}
+	if err := c.Start(); err != nil {
+		return os.Stdout, false
+	}
+	stopPager = func() {
+		in.Close()
+		// Ctrl-C in the pager is for the pager, as with git.
+		signal.Ignore(os.Interrupt)
+		c.Wait()
+	}
+	return in, true
+}
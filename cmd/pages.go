@@ -0,0 +1,52 @@
+package cmd
+
+import "context"
+
+// pageConcurrency is how many pages fetchPages requests at once at most
+// (--page-concurrency).
+var pageConcurrency int
+
+// fetchPages fetches the pages of a list from first on, several at a time,
+// and hands each to deliver in order, stopping after the first page with
+// fewer than perPage items. It returns the page that failed, with its
+// error, or that deliver rejected. The fetches still in flight when it
+// returns are canceled through the context fetch is given.
+//
+// How many pages there are is only known once the last one comes back, so
+// the pages after it are fetched for nothing. To keep that from costing a
+// short list several requests, fetchPages starts with one page at a time
+// and doubles that with each full page, up to pageConcurrency. Requests
+// still go out at the pace of --max-rps: the transport paces them.
+func fetchPages[T any](ctx context.Context, first, perPage int, fetch func(ctx context.Context, page int) ([]T, error),
+	deliver func(page int, batch []T) error) (int, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	type result struct {
+		batch []T
+		err   error
+	}
+	pending := map[int]chan result{}
+	next, window := first, 1
+	for page := first; ; page++ {
+		for ; next < page+window; next++ {
+			ch := make(chan result, 1) // never blocks, so a fetch not waited for ends
+			pending[next] = ch
+			go func(p int) {
+				batch, err := fetch(ctx, p)
+				ch <- result{batch, err}
+			}(next)
+		}
+		r := <-pending[page]
+		delete(pending, page)
+		if r.err != nil {
+			return page, r.err
+		}
+		if err := deliver(page, r.batch); err != nil {
+			return page, err
+		}
+		if len(r.batch) < perPage {
+			return page, nil
+		}
+		window = max(1, min(window*2, pageConcurrency))
+	}
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestFetchPages_DeliversInOrderUntilShortPage(t *testing.T) {
+	defer func(n int) { pageConcurrency = n }(pageConcurrency)
+	pageConcurrency = 4
+
+	var got []int
+	last, err := fetchPages(context.Background(), 1, 2, func(_ context.Context, page int) ([]int, error) {
+		// Later pages come back first.
+		time.Sleep(time.Duration(10-page) * time.Millisecond)
+		if page == 5 {
+			return []int{9}, nil
+		}
+		return []int{2*page - 1, 2 * page}, nil
+	}, func(_ int, batch []int) error {
+		got = append(got, batch...)
+		return nil
+	})
+	if err != nil || last != 5 {
+		t.Fatalf("fetchPages = %d, %v; want page 5, no error", last, err)
+	}
+	if want := []int{1, 2, 3, 4, 5, 6, 7, 8, 9}; !slices.Equal(got, want) {
+		t.Errorf("delivered %v, want %v", got, want)
+	}
+}
+
+func TestFetchPages_StopsAtErrorAndCancelsTheRest(t *testing.T) {
+	defer func(n int) { pageConcurrency = n }(pageConcurrency)
+	pageConcurrency = 4
+
+	boom := errors.New("boom")
+	started, canceled := make(chan struct{}), make(chan error, 1)
+	var delivered []int
+	last, err := fetchPages(context.Background(), 1, 1, func(ctx context.Context, page int) ([]int, error) {
+		switch {
+		case page == 3:
+			<-started // fail with page 4 in flight
+			return nil, boom
+		case page == 4:
+			close(started)
+			<-ctx.Done()
+			canceled <- ctx.Err()
+			return nil, ctx.Err()
+		case page > 4:
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return []int{page}, nil
+	}, func(page int, _ []int) error {
+		delivered = append(delivered, page)
+		return nil
+	})
+	if !errors.Is(err, boom) || last != 3 {
+		t.Fatalf("fetchPages = %d, %v; want page 3, boom", last, err)
+	}
+	if !slices.Equal(delivered, []int{1, 2}) {
+		t.Errorf("delivered pages %v, want 1 and 2", delivered)
+	}
+	select {
+	case err := <-canceled:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("page 4 ended with %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("page 4 was not canceled")
+	}
+}
+
+func TestFetchPages_DeliverErrorStops(t *testing.T) {
+	stop := errors.New("stop")
+	last, err := fetchPages(context.Background(), 7, 10, func(_ context.Context, page int) ([]int, error) {
+		return make([]int, 10), nil
+	}, func(page int, _ []int) error {
+		if page == 8 {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) || last != 8 {
+		t.Errorf("fetchPages = %d, %v; want page 8, stop", last, err)
+	}
+}
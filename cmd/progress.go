@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/term"
+)
+
+// progressBarWidth is the number of cells in a drawn progress bar.
+const progressBarWidth = 30
+
+// progressBar reports progress through a known number of items on stderr.
+// On a terminal it redraws one bar in place; otherwise (a log file, CI) it
+// prints a line per item. It is safe for concurrent use.
+type progressBar struct {
+	mu    sync.Mutex
+	w     io.Writer
+	tty   bool
+	what  string // plural noun, e.g. "routes"
+	total int
+	done  int
+}
+
+func newProgressBar(what string, total int) *progressBar {
+	return &progressBar{
+		w:     os.Stderr,
+		tty:   term.IsTerminal(int(os.Stderr.Fd())),
+		what:  what,
+		total: total,
+	}
+}
+
+// Step counts one more item done; note says what happened to it.
+func (b *progressBar) Step(note string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.done++
+	if !b.tty {
+		fmt.Fprintf(b.w, "  [%d/%d] %s\n", b.done, b.total, note)
+		return
+	}
+	b.draw()
+}
+
+// Warn prints a message on its own line, redrawing the bar below it.
+func (b *progressBar) Warn(format string, args ...any) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tty && b.done > 0 {
+		fmt.Fprint(b.w, "\r\033[K")
+	}
+	fmt.Fprintf(b.w, format+"\n", args...)
+	if b.tty && b.done > 0 {
+		b.draw()
+	}
+}
+
+// Finish ends the bar's line.
+func (b *progressBar) Finish() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tty && b.done > 0 {
+		fmt.Fprintln(b.w)
+	}
+}
+
+func (b *progressBar) draw() {
+	filled := progressBarWidth
+	if b.total > 0 {
+		filled = b.done * progressBarWidth / b.total
+	}
+	fmt.Fprintf(b.w, "\r[%s%s] %d/%d %s", strings.Repeat("█", filled),
+		strings.Repeat("░", progressBarWidth-filled), b.done, b.total, b.what)
+}
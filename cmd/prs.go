@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+	genclient "github.com/Brainsoft-Raxat/strava-cli/internal/client"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/analysis"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/store"
+)
+
+var (
+	prsSport  string
+	prsAfter  string
+	prsBefore string
+)
+
+var prsCmd = &cobra.Command{
+	Use:   "prs",
+	Short: "Personal records: best run times and best power",
+	Long: `Scan your history for personal records: the fastest 1k, 5k, 10k, half
+marathon and marathon within any run, and the highest average power over
+5 seconds, 1, 5 and 20 minutes within any activity, per sport type.
+
+Best efforts are found in each activity's streams, which are fetched once
+per run or activity with power and the efforts cached; the first run over a
+long history takes a while (Strava allows 100 requests per 15 minutes), and
+can be interrupted and run again to continue.
+
+The records are kept, and those set since they were last updated are
+marked new; not with --sport, --after or --before, which see only part of
+the history. Once kept, "strava sync" looks for efforts in the activities it
+fetches and prints any new records.
+
+Examples:
+  strava prs
+  strava prs --sport Run
+  strava prs --sport Ride --after 2024-01-01 --json`,
+	Args: cobra.NoArgs,
+	RunE: runPRs,
+}
+
+func init() {
+	rootCmd.AddCommand(prsCmd)
+	prsCmd.Flags().StringVar(&prsSport, "sport", "", "Only this sport type (e.g. Run, Ride)")
+	prsCmd.RegisterFlagCompletionFunc("sport", completeSportTypes)
+	prsCmd.Flags().StringVar(&prsAfter, "after", "", "Only activities after this date (YYYY-MM-DD)")
+	prsCmd.Flags().StringVar(&prsBefore, "before", "", "Only activities before this date (YYYY-MM-DD)")
+}
+
+func runPRs(cmd *cobra.Command, args []string) error {
+	after, err := parseDate("after", prsAfter)
+	if err != nil {
+		return err
+	}
+	before, err := parseDate("before", prsBefore)
+	if err != nil {
+		return err
+	}
+	api, _, err := apiClient(cmd)
+	if err != nil {
+		return err
+	}
+	acts, err := historyActivities(cmd, api, after, before)
+	if err != nil {
+		return err
+	}
+	if prsSport != "" {
+		var kept []analysis.Activity
+		for _, a := range acts {
+			if strings.EqualFold(a.SportType, prsSport) {
+				kept = append(kept, a)
+			}
+		}
+		acts = kept
+	}
+	bests, err := bestEfforts(cmd, api, acts)
+	if err != nil {
+		return err
+	}
+	prs := analysis.PersonalRecords(acts, bests)
+	// Records within a date range are not all-time records, and those of one
+	// sport would leave the others untracked, to show up as new at the next
+	// sync.
+	if after.IsZero() && before.IsZero() && prsSport == "" {
+		if prs, err = updateRecords(prs); err != nil {
+			return err
+		}
+	}
+	return newPrinter().PersonalRecords(prs)
+}
+
+// bestEfforts returns the best efforts of each run or activity with power in
+// acts. They are cached per activity (in best-efforts.json); the rest fetch
+// their streams once.
+func bestEfforts(cmd *cobra.Command, api *genclient.ClientWithResponses, acts []analysis.Activity) (map[int64]analysis.Bests, error) {
+	cache, err := store.OpenBestsCache("")
+	if err != nil {
+		return nil, err
+	}
+	return cachedStreamValues(cmd, api, cache, acts, streamJob[analysis.Bests]{
+		progress: "Finding best efforts in",
+		keys:     []string{"time", "distance", "watts"},
+		skip:     func(a analysis.Activity) bool { return !analysis.HasBests(a) },
+		compute:  analysis.BestEfforts,
+	})
+}
+
+// updateRecords stores prs as the personal records (see store.Records.Update)
+// and returns them with the new ones marked.
+func updateRecords(prs []analysis.PR) ([]analysis.PR, error) {
+	records, err := store.OpenRecords("")
+	if err != nil {
+		return nil, err
+	}
+	prs = records.Update(prs)
+	return prs, records.Save()
+}
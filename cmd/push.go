@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/analysis"
+	genclient "github.com/Brainsoft-Raxat/strava-cli/internal/client"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/fit"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/intervals"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/redact"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/store"
+)
+
+var (
+	intervalsAPIKey  string
+	intervalsAthlete string
+	intervalsAfter   string
+	intervalsSport   string
+)
+
+// intervalsTarget is intervals.icu's name in the push ledger.
+const intervalsTarget = "intervals"
+
+var pushCmd = &cobra.Command{
+	Use:   "push",
+	Short: "Forward synced activities to other training platforms",
+	Long: `Forward activities from your synced history to other training platforms,
+each one once. Every platform is a subcommand.`,
+}
+
+var pushIntervalsCmd = &cobra.Command{
+	Use:   "intervals",
+	Short: "Upload new activities to intervals.icu",
+	Long: `Upload the activities not yet pushed to intervals.icu, oldest first, with
+their names, descriptions and recordings.
+
+Each activity's recording is rebuilt from its streams (one API request per
+activity) as a FIT file with position, altitude, distance, heart rate,
+cadence and power, and uploaded with the Strava activity ID as its external
+ID. Manual activities have no recording and are skipped.
+
+The first push needs --after, the first day to push; later pushes continue
+from there. What was pushed is kept in ~/.config/strava-cli/pushes.json, so
+running the command again (after every "strava sync", say) only uploads new
+activities.
+
+The API key (intervals.icu settings, Developer Settings) is read from
+--api-key or the INTERVALS_API_KEY environment variable.
+
+Examples:
+  strava push intervals --after 2024-06-01 --dry-run
+  INTERVALS_API_KEY=... strava push intervals --yes
+  strava push intervals --sport Ride --yes`,
+	Args: cobra.NoArgs,
+	RunE: runPushIntervals,
+}
+
+func init() {
+	rootCmd.AddCommand(pushCmd)
+	pushCmd.AddCommand(pushIntervalsCmd)
+	pushIntervalsCmd.Flags().StringVar(&intervalsAPIKey, "api-key", "", "intervals.icu API key (default $INTERVALS_API_KEY)")
+	pushIntervalsCmd.Flags().StringVar(&intervalsAthlete, "athlete", "0", "intervals.icu athlete ID (0 for the API key's own)")
+	pushIntervalsCmd.Flags().StringVar(&intervalsAfter, "after", "", "Only activities after this date (YYYY-MM-DD); needed for the first push")
+	pushIntervalsCmd.Flags().StringVar(&intervalsSport, "sport", "", "Only this sport type (e.g. Run, Ride)")
+	pushIntervalsCmd.RegisterFlagCompletionFunc("sport", completeSportTypes)
+	pushIntervalsCmd.Flags().Bool("yes", false, "Skip interactive confirmation")
+	pushIntervalsCmd.Flags().Bool("dry-run", false, "List the activities that would be pushed without uploading them")
+}
+
+func runPushIntervals(cmd *cobra.Command, args []string) error {
+	key := intervalsAPIKey
+	if key == "" {
+		key = os.Getenv("INTERVALS_API_KEY")
+	}
+	if key == "" {
+		return fmt.Errorf("intervals.icu needs an API key: pass --api-key or set INTERVALS_API_KEY (intervals.icu settings, Developer Settings)")
+	}
+	redact.Add(key)
+	after, err := parseDate("after", intervalsAfter)
+	if err != nil {
+		return err
+	}
+	pushes, err := store.OpenPushes("")
+	if err != nil {
+		return err
+	}
+	target := pushes.Target(intervalsTarget)
+	if after.IsZero() {
+		if target == nil {
+			return fmt.Errorf("nothing has been pushed to intervals.icu yet: pass --after with the first day to push, e.g. --after 2024-06-01")
+		}
+		after = target.Since
+	}
+
+	api, _, err := apiClient(cmd)
+	if err != nil {
+		return err
+	}
+	acts, err := historyActivities(cmd, api, after, time.Time{})
+	if err != nil {
+		return err
+	}
+	slices.SortFunc(acts, func(a, b analysis.Activity) int { return a.StartDate.Compare(b.StartDate) })
+	var todo []analysis.Activity
+	manual := 0
+	for _, a := range acts {
+		if pushes.Pushed(intervalsTarget, a.ID) || (intervalsSport != "" && !strings.EqualFold(a.SportType, intervalsSport)) {
+			continue
+		}
+		if a.Manual {
+			manual++
+			continue
+		}
+		todo = append(todo, a)
+	}
+	if manual > 0 {
+		fmt.Fprintf(os.Stderr, "Skipping %d manual activities, which have no recording\n", manual)
+	}
+	if len(todo) == 0 {
+		fmt.Fprintln(os.Stderr, "Nothing new to push to intervals.icu.")
+		return nil
+	}
+	for _, a := range todo {
+		fmt.Fprintf(os.Stderr, "  %s  %s (%d)\n", a.StartDateLocal.Format("2006-01-02"), a.Name, a.ID)
+	}
+	ok, err := confirmMutation(cmd, fmt.Sprintf("push %d activities to intervals.icu", len(todo)))
+	if err != nil || !ok {
+		return err
+	}
+	if target == nil || intervalsAfter != "" && after.Before(target.Since) {
+		pushes.Start(intervalsTarget, after)
+		if err := pushes.Save(); err != nil {
+			return err
+		}
+	}
+
+	hist, err := store.OpenActivities("")
+	if err != nil {
+		return err
+	}
+	server := &intervals.Server{APIKey: key, Athlete: intervalsAthlete, HTTP: httpClient()}
+	pushed, failed := 0, 0
+	for _, a := range todo {
+		remote, err := pushIntervals(cmd, api, server, hist, a)
+		if err == nil {
+			err = pushes.Record(intervalsTarget, a.ID, remote)
+		}
+		if err != nil && cmd.Context().Err() != nil {
+			break
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: activity %d: %v\n", a.ID, err)
+			failed++
+			continue
+		}
+		pushed++
+	}
+	fmt.Fprintf(os.Stderr, "Pushed %d activities to intervals.icu\n", pushed)
+	if err := cmd.Context().Err(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d activities could not be pushed; run again to retry", failed)
+	}
+	return nil
+}
+
+// pushIntervals uploads an activity's recording, rebuilt from its streams,
+// to intervals.icu and returns its ID there.
+func pushIntervals(cmd *cobra.Command, api *genclient.ClientWithResponses, server *intervals.Server, hist *store.Activities, a analysis.Activity) (string, error) {
+	fmt.Fprintf(os.Stderr, "Fetching streams of %d (%s)\n", a.ID, a.StartDateLocal.Format("2006-01-02"))
+	streams, err := fetchStreams(cmd, api, a.ID, intervals.StreamKeys...)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := fit.Encode(&buf, intervals.Activity(a, streams)); err != nil {
+		return "", err
+	}
+	desc, _ := hist.Description(a.ID)
+	return server.Upload(cmd.Context(), intervals.Upload{
+		Name:        a.Name,
+		Description: desc,
+		ExternalID:  strconv.FormatInt(a.ID, 10),
+		File:        buf.Bytes(),
+	})
+}
@@ -0,0 +1,225 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/ble"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/fit"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/fsutil"
+)
+
+var (
+	recordSensors  string
+	recordSport    string
+	recordScan     time.Duration
+	recordOut      string
+	recordNoUpload bool
+)
+
+// How long a sensor's last value is recorded for when it sends no new one;
+// older, the second is left empty.
+const (
+	heartRateHold = 5 * time.Second
+	powerHold     = 3 * time.Second
+)
+
+// recordSports are the --sport values, with the FIT sport each is written as.
+var recordSports = map[string]fit.Activity{
+	"ride": {Sport: fit.SportCycling, SubSport: fit.SubSportIndoorCycling},
+	"run":  {Sport: fit.SportRunning, SubSport: fit.SubSportTreadmill},
+}
+
+var recordCmd = &cobra.Command{
+	Use:   "record",
+	Short: "Record an indoor session from BLE sensors (experimental)",
+	Long: `Record heart rate, power and cadence from Bluetooth Low Energy sensors into
+a FIT file, one record a second, then offer to upload it as an indoor
+activity: a minimal head unit for the trainer or the treadmill.
+
+The command scans for --scan for heart rate monitors (chest straps, watches
+broadcasting heart rate) and power meters (pedals, smart trainers), connects
+to those it finds, and records until Ctrl-C. Wake the sensors (put the strap
+on, turn the pedals) before starting. Cadence comes from the power meter's
+crank revolutions. There is no GPS indoors, so the activity has no route.
+
+Only BLE (--sensors ble) is supported, on Linux with BlueZ; ANT+ sensors need
+a USB stick that this command does not drive. The recording is written to
+--out (default record-<date>-<time>.fit) before it is uploaded, so it is kept
+if the upload fails or is declined; upload it later with
+"strava activities upload --file <out> --trainer".
+
+Examples:
+  strava record --sensors ble
+  strava record --sensors ble --sport run --name "Treadmill intervals"
+  strava record --sensors ble --out session.fit --no-upload`,
+	Args: cobra.NoArgs,
+	RunE: runRecord,
+}
+
+func init() {
+	rootCmd.AddCommand(recordCmd)
+
+	f := recordCmd.Flags()
+	f.StringVar(&recordSensors, "sensors", "", "Sensors to record from: ble")
+	f.StringVar(&recordSport, "sport", "ride", "Sport: ride (indoor cycling) or run (treadmill)")
+	f.DurationVar(&recordScan, "scan", 10*time.Second, "How long to look for sensors")
+	f.StringVar(&recordOut, "out", "", "FIT file to write (default: record-<date>-<time>.fit)")
+	f.BoolVar(&recordNoUpload, "no-upload", false, "Only write the FIT file")
+	f.StringVar(&uploadName, "name", "", "Activity name")
+	f.StringVar(&uploadDescription, "description", "", "Activity description")
+	f.BoolVar(&uploadWait, "wait", false, "Poll until Strava finishes processing the upload")
+	f.Bool("yes", false, "Upload without asking")
+	f.Bool("dry-run", false, "Record and write the file, and print what would be uploaded without calling the API")
+	recordCmd.MarkFlagRequired("sensors")
+	_ = recordCmd.RegisterFlagCompletionFunc("sensors", cobra.FixedCompletions([]string{"ble"}, cobra.ShellCompDirectiveNoFileComp))
+	_ = recordCmd.RegisterFlagCompletionFunc("sport", cobra.FixedCompletions([]string{"ride", "run"}, cobra.ShellCompDirectiveNoFileComp))
+}
+
+func runRecord(cmd *cobra.Command, args []string) error {
+	switch strings.ToLower(recordSensors) {
+	case "ble":
+	case "ant", "ant+":
+		return fmt.Errorf("ANT+ sensors are not supported: most can also be paired over BLE (--sensors ble)")
+	default:
+		return fmt.Errorf("invalid --sensors %q: must be ble", recordSensors)
+	}
+	activity, ok := recordSports[strings.ToLower(recordSport)]
+	if !ok {
+		return fmt.Errorf("invalid --sport %q: must be ride or run", recordSport)
+	}
+
+	fmt.Fprintf(os.Stderr, "Looking for sensors for %s...\n", recordScan)
+	session, err := ble.Connect(cmd.Context(), recordScan)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+	for _, s := range session.Sensors() {
+		fmt.Fprintf(os.Stderr, "Connected to %s (%s, %s)\n", s.Name, s.Kind, s.Address)
+	}
+	fmt.Fprintln(os.Stderr, "Recording; press Ctrl-C to stop.")
+
+	activity.Records = recordSession(cmd.Context(), session)
+	if len(activity.Records) == 0 {
+		return fmt.Errorf("nothing was recorded")
+	}
+	out := recordOut
+	if out == "" {
+		out = activity.Records[0].Time.Format("record-20060102-150405.fit")
+	}
+	var buf bytes.Buffer
+	if err := fit.Encode(&buf, activity); err != nil {
+		return err
+	}
+	if err := fsutil.WriteFile(out, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %s (%s, %d records)\n", out,
+		activity.Records[len(activity.Records)-1].Time.Sub(activity.Records[0].Time).Round(time.Second), len(activity.Records))
+
+	if recordNoUpload {
+		fmt.Fprintf(os.Stderr, "To upload it: strava activities upload --file %s --trainer\n", out)
+		return nil
+	}
+	// Ctrl-C ended the recording; the upload gets a context of its own.
+	interrupted.Store(false)
+	ctx, stop := interruptContext()
+	defer stop()
+	cmd.SetContext(ctx)
+	uploadFile, uploadDir = out, ""
+	uploadDataType, uploadTrainer = "fit", true
+	return runActivitiesUpload(cmd, nil)
+}
+
+// recordSession records a sample a second from session until ctx is done,
+// showing the latest values on a terminal.
+func recordSession(ctx context.Context, session *ble.Session) []fit.Record {
+	var (
+		mu                       sync.Mutex
+		hr, power, cadence       int
+		hrAt, powerAt, cadenceAt time.Time
+	)
+	done := make(chan error, 1)
+	go func() {
+		done <- session.Run(ctx, func(r ble.Reading) {
+			mu.Lock()
+			defer mu.Unlock()
+			now := time.Now()
+			if r.HeartRate >= 0 {
+				hr, hrAt = r.HeartRate, now
+			}
+			if r.Power >= 0 {
+				power, powerAt = r.Power, now
+			}
+			if r.Cadence >= 0 {
+				cadence, cadenceAt = r.Cadence, now
+			}
+		})
+	}()
+
+	tty := term.IsTerminal(int(os.Stderr.Fd()))
+	tick := time.NewTicker(time.Second)
+	defer tick.Stop()
+	var recs []fit.Record
+	for {
+		select {
+		case <-ctx.Done():
+			<-done
+			if tty && len(recs) > 0 {
+				fmt.Fprintln(os.Stderr)
+			}
+			return recs
+		case err := <-done:
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "\nwarning: %v; recording stopped\n", err)
+			}
+			return recs
+		case now := <-tick.C:
+			mu.Lock()
+			rec := fit.Record{
+				Time:      now.Truncate(time.Second),
+				HeartRate: held(hr, hrAt, now, heartRateHold),
+				Power:     held(power, powerAt, now, powerHold),
+				Cadence:   held(cadence, cadenceAt, now, powerHold),
+				Distance:  -1,
+			}
+			mu.Unlock()
+			recs = append(recs, rec)
+			if tty {
+				elapsed := rec.Time.Sub(recs[0].Time)
+				fmt.Fprintf(os.Stderr, "\r\033[K%s  HR %s  Power %s  Cadence %s",
+					formatClock(elapsed), orDash(rec.HeartRate, ""), orDash(rec.Power, " W"), orDash(rec.Cadence, " rpm"))
+			}
+		}
+	}
+}
+
+// held returns v if it was measured within hold of now, or -1.
+func held(v int, at, now time.Time, hold time.Duration) int {
+	if at.IsZero() || now.Sub(at) > hold {
+		return -1
+	}
+	return v
+}
+
+// formatClock formats d as h:mm:ss.
+func formatClock(d time.Duration) string {
+	s := int(d.Seconds())
+	return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+}
+
+// orDash formats v with unit, or "-" if it was not measured.
+func orDash(v int, unit string) string {
+	if v < 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d%s", v, unit)
+}
@@ -0,0 +1,299 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	genclient "github.com/Brainsoft-Raxat/strava-cli/internal/client"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/analysis"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/store"
+)
+
+var (
+	reportPeriod   string
+	reportSport    string
+	reportAfter    string
+	reportBefore   string
+	reportClassify bool
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Summarize training by week, month or year",
+	Long: `Total distance, moving time, elevation gain and activity count per week,
+month or year — a quick training log.
+
+Activities are bucketed by their local start date; weeks start on Monday.
+Without --after the report covers the last 12 weeks, the last 12 months, or
+your whole history for --period year.
+
+--classify labels each session with heart rate by its time in your Strava
+heart rate zones — easy, tempo, threshold or VO2 — and adds the sessions per
+class and the share of time at low intensity (zones 1–2) to each period: the
+80/20 split of polarized training. See "strava activities list --classify".
+"strava report zones" sums the time in each zone instead.
+
+Examples:
+  strava report
+  strava report --period month --sport Run
+  strava report --period year --json
+  strava report --classify --period month --sport Run`,
+	Args: cobra.NoArgs,
+	RunE: runReport,
+}
+
+var (
+	zonesMetric string
+	zonesSport  string
+	zonesAfter  string
+	zonesBefore string
+)
+
+var reportZonesCmd = &cobra.Command{
+	Use:   "zones",
+	Short: "Sum time in heart rate or power zones across activities",
+	Long: `Add up the time each activity spent in each heart rate zone (--metric
+heartrate, the default) or power zone (--metric power) and print the
+distribution, with the split into low (zones 1–2), moderate (zone 3) and
+high intensity (the rest) that polarized training aims at 80/20 or so.
+
+Heart rate zones are those on your Strava profile, or five zones from the
+maximum heart rate set with "strava config set max-hr". Power zones are the
+seven Coggan zones of the FTP set with "strava config set ftp", or those on
+your Strava profile. Each activity's streams are fetched once; the time in
+zones is cached (classes.json for heart rate, power-zones.json for power) and
+measured again when the zones change.
+
+Without --after the report covers the last 12 weeks.
+
+Examples:
+  strava report zones --after 2024-01-01
+  strava report zones --metric power --sport Ride
+  strava report zones --json`,
+	Args: cobra.NoArgs,
+	RunE: runReportZones,
+}
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+	reportCmd.AddCommand(reportZonesCmd)
+
+	reportZonesCmd.Flags().StringVar(&zonesMetric, "metric", "heartrate", "Zones to sum: heartrate or power")
+	reportZonesCmd.Flags().StringVar(&zonesSport, "sport", "", "Only count this sport type (e.g. Run, Ride)")
+	reportZonesCmd.RegisterFlagCompletionFunc("sport", completeSportTypes)
+	reportZonesCmd.Flags().StringVar(&zonesAfter, "after", "", "Start date (YYYY-MM-DD)")
+	reportZonesCmd.Flags().StringVar(&zonesBefore, "before", "", "End date (YYYY-MM-DD)")
+
+	reportCmd.Flags().StringVar(&reportPeriod, "period", "week", "Bucket size: week, month or year")
+	reportCmd.Flags().StringVar(&reportSport, "sport", "", "Only count this sport type (e.g. Run, Ride)")
+	reportCmd.RegisterFlagCompletionFunc("sport", completeSportTypes)
+	reportCmd.Flags().StringVar(&reportAfter, "after", "", "Start date (YYYY-MM-DD)")
+	reportCmd.Flags().StringVar(&reportBefore, "before", "", "End date (YYYY-MM-DD)")
+	reportCmd.Flags().BoolVar(&reportClassify, "classify", false, "Add sessions per class and the low-intensity share (fetches streams once per activity)")
+}
+
+func runReport(cmd *cobra.Command, args []string) error {
+	period, err := analysis.ParsePeriod(reportPeriod)
+	if err != nil {
+		return err
+	}
+	after, err := parseDate("after", reportAfter)
+	if err != nil {
+		return err
+	}
+	before, err := parseDate("before", reportBefore)
+	if err != nil {
+		return err
+	}
+	if after.IsZero() {
+		switch period {
+		case analysis.Week:
+			after = period.Start(time.Now()).AddDate(0, 0, -7*11)
+		case analysis.Month:
+			after = period.Start(time.Now()).AddDate(0, -11, 0)
+		}
+	}
+
+	api, _, err := apiClient(cmd)
+	if err != nil {
+		return err
+	}
+	acts, err := fetchActivities(cmd, api, after, before)
+	if err != nil {
+		return err
+	}
+	if !reportClassify {
+		return newPrinter().Report(analysis.Summarize(acts, period, reportSport))
+	}
+	classes, err := classifyActivities(cmd, api, acts)
+	if err != nil {
+		return err
+	}
+	return newPrinter().IntensityReport(analysis.SummarizeIntensity(acts, classes, period, reportSport))
+}
+
+func runReportZones(cmd *cobra.Command, args []string) error {
+	if zonesMetric != "heartrate" && zonesMetric != "power" {
+		return fmt.Errorf("invalid --metric %q: must be heartrate or power", zonesMetric)
+	}
+	after, err := parseDate("after", zonesAfter)
+	if err != nil {
+		return err
+	}
+	before, err := parseDate("before", zonesBefore)
+	if err != nil {
+		return err
+	}
+	if after.IsZero() {
+		after = time.Now().AddDate(0, 0, -7*12)
+	}
+
+	api, _, err := apiClient(cmd)
+	if err != nil {
+		return err
+	}
+	acts, err := historyActivities(cmd, api, after, before)
+	if err != nil {
+		return err
+	}
+	if zonesSport != "" {
+		var kept []analysis.Activity
+		for _, a := range acts {
+			if strings.EqualFold(a.SportType, zonesSport) {
+				kept = append(kept, a)
+			}
+		}
+		acts = kept
+	}
+
+	if zonesMetric == "power" {
+		bounds, err := powerZones(cmd, api)
+		if err != nil {
+			return err
+		}
+		times, err := powerZoneTimes(cmd, api, acts, bounds)
+		if err != nil {
+			return err
+		}
+		return newPrinter().ZoneReport(analysis.SumZones(zonesMetric, acts, times, bounds, zonesSport))
+	}
+	bounds, err := heartRateZones(cmd, api)
+	if err != nil {
+		return err
+	}
+	classes, err := classifyActivities(cmd, api, acts)
+	if err != nil {
+		return err
+	}
+	times := map[int64]analysis.ZoneTime{}
+	for id, c := range classes {
+		times[id] = analysis.ZoneTime{Zones: c.Zones, Bounds: c.Bounds}
+	}
+	return newPrinter().ZoneReport(analysis.SumZones(zonesMetric, acts, times, bounds, zonesSport))
+}
+
+// heartRateZones returns the lower bound of each of the athlete's heart rate
+// zones, as set on Strava, or derived from the maximum heart rate set with
+// "config set max-hr" when the profile has none.
+func heartRateZones(cmd *cobra.Command, api *genclient.ClientWithResponses) ([]int, error) {
+	resp, err := api.GetLoggedInAthleteZonesWithResponse(cmd.Context())
+	if err != nil {
+		return nil, fmt.Errorf("fetch zones: %w", err)
+	}
+	if resp.HTTPResponse.StatusCode != 200 {
+		return nil, apiError(resp.HTTPResponse.StatusCode, resp.Body)
+	}
+	var bounds []int
+	if d := resp.JSON200; d != nil && d.HeartRate != nil && d.HeartRate.Zones != nil {
+		for _, z := range *d.HeartRate.Zones {
+			lo := 0
+			if z.Min != nil {
+				lo = *z.Min
+			}
+			bounds = append(bounds, lo)
+		}
+	}
+	if len(bounds) < 3 {
+		if t := thresholds(); t.MaxHR > 0 {
+			return analysis.HeartRateZones(t.MaxHR), nil
+		}
+		return nil, fmt.Errorf("no heart rate zones on your Strava profile: set them under Settings → My Performance, or set your maximum heart rate with: strava config set max-hr <bpm>")
+	}
+	return bounds, nil
+}
+
+// classifyActivities labels each activity with heart rate data by its time in
+// the athlete's heart rate zones. Labels are cached per activity (in
+// classes.json) along with the zones they were measured against; the rest
+// fetch their streams once.
+func classifyActivities(cmd *cobra.Command, api *genclient.ClientWithResponses, acts []analysis.Activity) (map[int64]analysis.Classification, error) {
+	bounds, err := heartRateZones(cmd, api)
+	if err != nil {
+		return nil, err
+	}
+	cache, err := store.OpenClassCache("")
+	if err != nil {
+		return nil, err
+	}
+	return cachedStreamValues(cmd, api, cache, acts, streamJob[analysis.Classification]{
+		progress: "Classifying",
+		keys:     []string{"time", "heartrate"},
+		skip:     func(a analysis.Activity) bool { return a.AverageHeartrate == 0 || a.Manual },
+		fresh:    func(c analysis.Classification) bool { return slices.Equal(c.Bounds, bounds) },
+		compute: func(_ analysis.Activity, s *analysis.Streams) analysis.Classification {
+			zones := analysis.TimeInZones(s, bounds)
+			return analysis.Classification{Class: analysis.Classify(zones), Zones: zones, Bounds: bounds}
+		},
+	})
+}
+
+// powerZones returns the lower bound of each power zone: the seven zones of
+// the FTP set with "config set ftp", or those on the athlete's Strava profile.
+func powerZones(cmd *cobra.Command, api *genclient.ClientWithResponses) ([]int, error) {
+	if t := thresholds(); t.FTP > 0 {
+		return analysis.PowerZones(t.FTP), nil
+	}
+	resp, err := api.GetLoggedInAthleteZonesWithResponse(cmd.Context())
+	if err != nil {
+		return nil, fmt.Errorf("fetch zones: %w", err)
+	}
+	if resp.HTTPResponse.StatusCode != 200 {
+		return nil, apiError(resp.HTTPResponse.StatusCode, resp.Body)
+	}
+	var bounds []int
+	if d := resp.JSON200; d != nil && d.Power != nil && d.Power.Zones != nil {
+		for _, z := range *d.Power.Zones {
+			lo := 0
+			if z.Min != nil {
+				lo = *z.Min
+			}
+			bounds = append(bounds, lo)
+		}
+	}
+	if len(bounds) < 3 {
+		return nil, fmt.Errorf("no power zones: set your FTP with: strava config set ftp <watts>")
+	}
+	return bounds, nil
+}
+
+// powerZoneTimes measures the time in power zones of each activity with
+// power. Results are cached per activity (in power-zones.json) along with
+// the zones they were measured against; the rest fetch their streams once.
+func powerZoneTimes(cmd *cobra.Command, api *genclient.ClientWithResponses, acts []analysis.Activity, bounds []int) (map[int64]analysis.ZoneTime, error) {
+	cache, err := store.OpenPowerZoneCache("")
+	if err != nil {
+		return nil, err
+	}
+	return cachedStreamValues(cmd, api, cache, acts, streamJob[analysis.ZoneTime]{
+		progress: "Measuring",
+		keys:     []string{"time", "watts"},
+		skip:     func(a analysis.Activity) bool { return a.AverageWatts == 0 || a.Manual },
+		fresh:    func(zt analysis.ZoneTime) bool { return slices.Equal(zt.Bounds, bounds) },
+		compute: func(_ analysis.Activity, s *analysis.Streams) analysis.ZoneTime {
+			return analysis.ZoneTime{Zones: analysis.TimeInPowerZones(s, bounds), Bounds: bounds}
+		},
+	})
+}
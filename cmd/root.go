@@ -1,40 +1,381 @@
 package cmd
 
 import (
+	"cmp"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/client"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/config"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/log"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/output"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/redact"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/store"
 )
 
-var jsonOutput bool
+var (
+	jsonOutput     bool
+	unitsFlag      string
+	templateFlag   string
+	nonInteractive bool
+	strictDecode   bool
+	outputFormat   string
+	outputVersion  string
+	jqFlag         string
+	noColor        bool
+	noPager        bool
+	debugFlag      bool
+	noCache        bool
+	logLevel       string
+	logFile        string
+	strictPerms    bool
+	maxRPS         float64
+)
 
 var rootCmd = &cobra.Command{
 	Use:   "stravacli",
 	Short: "A Strava CLI powered by the official API",
 	Long: `stravacli is a command-line interface for the Strava API.
 
-Configuration is stored in ~/.config/strava-cli/config.json.
+Configuration is stored in ~/.config/strava-cli/config.json, or config.yaml
+or config.toml if you prefer to edit it by hand.
 
 To get started:
   stravacli auth login
 `,
 	SilenceUsage: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := applyDefaults(cmd); err != nil {
+			return err
+		}
+		if err := checkPermissions(cmd); err != nil {
+			return err
+		}
+		if err := openLog(cmd); err != nil {
+			return err
+		}
+		if strictDecode {
+			client.SetDrift(client.NewDrift())
+		}
+		if debugEnabled() {
+			client.SetDebug(os.Stderr)
+		}
+		if maxRPS < 0 {
+			return fmt.Errorf("--max-rps must not be negative")
+		}
+		client.SetRateLimit(maxRPS, client.DefaultBurst)
+		if err := setNetwork(); err != nil {
+			return err
+		}
+		if pageConcurrency < 1 {
+			return fmt.Errorf("--page-concurrency must be at least 1")
+		}
+		client.SetMemo(client.NewMemo())
+		if dir, err := store.Path(store.HTTPCacheDir); err == nil {
+			client.SetDiskCache(&client.DiskCache{Dir: dir, Refresh: noCache})
+		}
+		if templateFlag != "" {
+			if jsonOutput {
+				return fmt.Errorf("--template and --json cannot be used together")
+			}
+			if _, err := output.ParseTemplate(templateFlag); err != nil {
+				return err
+			}
+		}
+		if outputFormat != "" {
+			f, err := output.ParseFormat(outputFormat)
+			if err != nil {
+				return err
+			}
+			switch {
+			case jsonOutput && f != "json":
+				return fmt.Errorf("--output %s and --json cannot be used together", f)
+			case templateFlag != "" && f != "template":
+				return fmt.Errorf("--output %s and --template cannot be used together", f)
+			case templateFlag == "" && f == "template":
+				return fmt.Errorf("--output template needs --template")
+			case f == output.ParquetFormat && term.IsTerminal(int(os.Stdout.Fd())):
+				return fmt.Errorf("--output parquet writes a binary file: redirect it, e.g. > activities.parquet")
+			}
+			outputFormat = f
+			// Commands that print ad-hoc JSON check --json itself.
+			jsonOutput = jsonOutput || f == "json"
+		}
+		if jqFlag != "" {
+			if err := startQuery(cmd); err != nil {
+				return err
+			}
+		}
+		if outputVersion != "" {
+			if _, err := output.ParseVersion(outputVersion); err != nil {
+				return err
+			}
+		}
+		usePager = pageable(cmd)
+		if unitsFlag == "" {
+			return nil
+		}
+		_, err := output.ParseUnits(unitsFlag)
+		return err
+	},
+}
+
+// checkPermissions warns on stderr about each way the config could expose
+// the tokens (see config.CheckPermissions), or with --strict-permissions
+// refuses to run. An open directory, which only lists file names, is left
+// to doctor, which reports every problem itself.
+func checkPermissions(cmd *cobra.Command) error {
+	if cmd.Name() == "doctor" && cmd.HasParent() && !cmd.Parent().HasParent() {
+		return nil
+	}
+	all, err := config.CheckPermissions()
+	if err != nil {
+		return nil // commands reading the config report errors with it
+	}
+	var problems []config.PermissionProblem
+	for _, p := range all {
+		if !p.Minor {
+			problems = append(problems, p)
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	if strictPerms {
+		msgs := make([]string, len(problems))
+		for i, p := range problems {
+			msgs[i] = fmt.Sprintf("  %s (%s)", p.Message, p.Hint)
+		}
+		return fmt.Errorf("refusing to run with an exposed config (--strict-permissions):\n%s", strings.Join(msgs, "\n"))
+	}
+	for _, p := range problems {
+		fmt.Fprintf(os.Stderr, "warning: %s (%s)\n", p.Message, p.Hint)
+	}
+	return nil
+}
+
+// cliLog records each command run while logging is on.
+var cliLog = log.New("cli")
+
+// closeLog closes the --log-file, if one was opened.
+var closeLog = func() error { return nil }
+
+// openLog starts logging as --log-level and --log-file ask: at info and
+// above when only a file is given, to stderr when only a level is.
+func openLog(cmd *cobra.Command) error {
+	if logLevel == "" && logFile == "" {
+		return nil
+	}
+	level, err := log.ParseLevel(cmp.Or(logLevel, "info"))
+	if err != nil {
+		return err
+	}
+	closer, err := log.Open(level, logFile)
+	if err != nil {
+		return err
+	}
+	closeLog = closer
+	cliLog.Info("command started", "command", cmd.CommandPath(), "args", strings.Join(redact.Args(os.Args[1:]), " "),
+		"version", cmd.Root().Version)
+	return nil
+}
+
+// debugEnabled reports whether API requests are traced: with --debug, or
+// STRAVA_DEBUG set to anything but a false value ("0", "false").
+func debugEnabled() bool {
+	if debugFlag {
+		return true
+	}
+	v := os.Getenv("STRAVA_DEBUG")
+	if v == "" {
+		return false
+	}
+	on, err := strconv.ParseBool(v)
+	return on || err != nil
+}
+
+// stopQuery ends the --jq filter started by startQuery, if any, once the
+// command has written everything.
+var stopQuery = func() error { return nil }
+
+// startQuery compiles --jq and sends what the command writes to stdout, as
+// JSON, through it. Commands that print JSON only with --json are switched
+// to it, as are the printers.
+func startQuery(cmd *cobra.Command) error {
+	switch {
+	case templateFlag != "":
+		return fmt.Errorf("--jq and --template cannot be used together")
+	case outputFormat != "" && outputFormat != "json" && outputFormat != output.NDJSONFormat:
+		return fmt.Errorf("--jq and --output %s cannot be used together", outputFormat)
+	}
+	q, err := output.ParseQuery(jqFlag)
+	if err != nil {
+		return err
+	}
+	if outputFormat == "" {
+		jsonOutput = true
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	done := make(chan error, 1)
+	go func() {
+		// What an interrupted command printed is still filtered.
+		err := q.Filter(context.WithoutCancel(cmd.Context()), stdout, r)
+		io.Copy(io.Discard, r) // never leave the command blocked on a full pipe
+		done <- err
+	}()
+	stopQuery = func() error {
+		os.Stdout = stdout
+		w.Close()
+		err := <-done
+		r.Close()
+		return err
+	}
+	return nil
 }
 
 // SetVersion stamps the build version into the root command (called from main).
 func SetVersion(v string) {
 	rootCmd.Version = v
+	rootCmd.SetVersionTemplate("{{.Name}} version {{.Version}} (output version " + output.OutputVersion + ")\n")
 }
 
 // Execute runs the root command.
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
+	rootCmd.SetErr(redact.Writer(os.Stderr))
+	start := time.Now()
+	jobID := takeJobID()
+	ctx, stop := interruptContext()
+	ran, err := rootCmd.ExecuteContextC(ctx)
+	stop()
+	if interrupted.Load() && errors.Is(err, context.Canceled) {
+		err = errors.New("interrupted")
+	}
+	if qerr := stopQuery(); qerr != nil && err == nil {
+		err = qerr
+	}
+	stopPager()
+	if ran != nil {
+		if err != nil {
+			cliLog.Error("command failed", "command", ran.CommandPath(), "duration", time.Since(start), "error", err)
+		} else {
+			cliLog.Info("command finished", "command", ran.CommandPath(), "duration", time.Since(start))
+		}
+	}
+	closeLog()
+	if strictDecode {
+		if derr := reportDrift(); derr != nil && err == nil {
+			err = derr
+		}
+	}
+	recordCommand(ran, start, err)
+	if jobID != "" {
+		finishJob(jobID, err)
+	}
+	if err != nil {
+		msg := redact.String(err.Error())
+		if colorEnabled(os.Stderr) {
+			msg = output.Red(msg)
+		}
+		fmt.Fprintln(os.Stderr, msg)
+		if interrupted.Load() {
+			os.Exit(exitInterrupted)
+		}
 		os.Exit(1)
 	}
 }
 
+// reportDrift adds the response fields --strict-decode found the generated
+// client lacking to the drift report, and fails the run if there were any.
+func reportDrift() error {
+	d := client.SetDrift(nil)
+	if d == nil {
+		return nil
+	}
+	fields := d.Fields()
+	if len(fields) == 0 {
+		return nil
+	}
+	report, err := store.OpenDriftReport("")
+	if err != nil {
+		return err
+	}
+	endpoints := make([]string, 0, len(fields))
+	for e := range fields {
+		endpoints = append(endpoints, e)
+	}
+	sort.Strings(endpoints)
+	now := time.Now()
+	total, added := 0, 0
+	for _, e := range endpoints {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", e, strings.Join(fields[e], ", "))
+		total += len(fields[e])
+		added += report.Add(e, fields[e], now)
+	}
+	if err := report.Save(); err != nil {
+		return err
+	}
+	return fmt.Errorf("strict decode: %d response field(s) unknown to the client (%d new), recorded in %s — the client may need regenerating",
+		total, added, report.Path())
+}
+
 func init() {
 	rootCmd.PersistentFlags().BoolVar(&jsonOutput, "json", false, "Output raw JSON")
+	rootCmd.PersistentFlags().BoolVar(&nonInteractive, "non-interactive", false,
+		"Never prompt; fail instead (implied when stdin is not a terminal)")
+	rootCmd.PersistentFlags().StringVar(&unitsFlag, "units", "",
+		"Unit system for distances, elevation and speed: metric or imperial (default: from profile)")
+	rootCmd.PersistentFlags().StringVar(&templateFlag, "template", "",
+		"Format output with a Go template, one line per list item (e.g. '{{.Id}} {{.Name}}')")
+	rootCmd.PersistentFlags().StringVar(&outputFormat, "output", "",
+		"Output format: "+strings.Join(output.Formats(), ", ")+" (default table; --json is --output json)")
+	_ = rootCmd.RegisterFlagCompletionFunc("output", func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return output.Formats(), cobra.ShellCompDirectiveNoFileComp
+	})
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false,
+		"Never color output (also when NO_COLOR is set; colors are off anyway unless writing to a terminal)")
+	rootCmd.PersistentFlags().BoolVar(&noPager, "no-pager", false,
+		"Do not show long tables in $PAGER (less by default; PAGER=cat also turns it off)")
+	rootCmd.PersistentFlags().StringVar(&jqFlag, "jq", "",
+		"Filter JSON output with a jq expression, no jq needed (e.g. '.[] | {id, name}'); implies --json")
+	rootCmd.PersistentFlags().StringVar(&outputVersion, "output-version", "",
+		"Output contract version the script expects, e.g. 1; fails if this build cannot write it (current: "+output.OutputVersion+")")
+	rootCmd.PersistentFlags().BoolVar(&noCache, "no-cache", false,
+		"Fetch API responses afresh instead of reusing those kept on disk (they are still kept for next time)")
+	rootCmd.PersistentFlags().BoolVar(&debugFlag, "debug", false,
+		"Trace API requests on stderr: URLs, status, timings, retries and rate-limit headers, tokens redacted (also STRAVA_DEBUG=1)")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "",
+		"Log what the CLI does at this level and above: "+strings.Join(log.Levels, ", ")+" (default info with --log-file)")
+	_ = rootCmd.RegisterFlagCompletionFunc("log-level", func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return log.Levels, cobra.ShellCompDirectiveNoFileComp
+	})
+	rootCmd.PersistentFlags().StringVar(&logFile, "log-file", "",
+		"Append the log to this file as JSON lines instead of writing it to stderr")
+	rootCmd.PersistentFlags().BoolVar(&strictDecode, "strict-decode", false,
+		"Fail when API responses contain fields the client does not know, recording them in drift.json")
+	rootCmd.PersistentFlags().BoolVar(&strictPerms, "strict-permissions", false,
+		"Fail, instead of warning, when the config is readable by other users or owned by another")
+	rootCmd.PersistentFlags().Float64Var(&maxRPS, "max-rps", client.DefaultRate,
+		"Most API requests per second, after a burst of "+strconv.Itoa(client.DefaultBurst)+" (0: no limit)")
+	rootCmd.PersistentFlags().IntVar(&pageConcurrency, "page-concurrency", 4,
+		"Pages of a long list fetched at once (--all, sync, full-history reports), still paced by --max-rps")
+	rootCmd.PersistentFlags().StringVar(&network.Proxy, "proxy", "",
+		"Proxy URL to send requests through, e.g. http://proxy.example.com:3128 (default: $HTTPS_PROXY)")
+	rootCmd.PersistentFlags().StringVar(&network.CABundle, "ca-bundle", "",
+		"PEM file of CA certificates to trust besides the system's, e.g. a TLS-inspecting proxy's")
+	rootCmd.PersistentFlags().BoolVar(&network.InsecureSkipVerify, "insecure-skip-verify", false,
+		"Do not verify TLS certificates (unsafe; prefer --ca-bundle)")
 }
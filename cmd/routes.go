@@ -1,15 +1,28 @@
 package cmd
 
 import (
+	"archive/zip"
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
 	"fmt"
+	imgpng "image/png"
 	"io"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/analysis"
 	genclient "github.com/Brainsoft-Raxat/strava-cli/internal/client"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/fsutil"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/geo"
 	"github.com/Brainsoft-Raxat/strava-cli/internal/output"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/plot"
 )
 
 var routesCmd = &cobra.Command{
@@ -17,11 +30,6 @@ var routesCmd = &cobra.Command{
 	Short: "Route commands",
 }
 
-var (
-	routesPage    int
-	routesPerPage int
-)
-
 var routesListCmd = &cobra.Command{
 	Use:   "list [athlete-id]",
 	Short: "List routes (defaults to the authenticated athlete)",
@@ -36,6 +44,25 @@ var routesGetCmd = &cobra.Command{
 	RunE:  runRoutesGet,
 }
 
+var routesMapCmd = &cobra.Command{
+	Use:   "map <id>",
+	Short: "Draw a route's map in the terminal or the browser",
+	Long: `Draw a route as a braille minimap in the terminal, north up, with S at
+the start and E at the end. --html writes an interactive map page instead
+(Leaflet on OpenStreetMap tiles, loaded when the page is viewed), and --open
+opens it in the browser. --map-out draws it on OpenStreetMap tiles into a
+1200×800 PNG for sharing; --tiles picks another tile server, such as a
+static map service that takes an API key in the URL.
+
+Examples:
+  strava routes map 12345
+  strava routes map 12345 --open
+  strava routes map 12345 --html sunday-loop.html
+  strava routes map 12345 --map-out sunday-loop.png`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRoutesMap,
+}
+
 var (
 	exportFormat string
 	exportOut    string
@@ -55,17 +82,131 @@ Examples:
 	RunE: runRoutesExport,
 }
 
+var (
+	exportAllFormat      string
+	exportAllOut         string
+	exportAllConcurrency int
+	exportAllForce       bool
+)
+
+var routesExportAllCmd = &cobra.Command{
+	Use:   "export-all [athlete-id]",
+	Short: "Export every route as GPX or TCX into a directory",
+	Long: `List all of an athlete's routes (defaults to the authenticated athlete)
+and download each one as route-<id>.<format> into --out.
+
+Downloads run --concurrency at a time, with a progress bar on stderr. Files
+already in --out are skipped, so rerunning after a failure fetches only what
+is missing; --force downloads them again. Each file is written under a
+temporary name and renamed when complete, so an interrupted run leaves no
+partial files.
+
+Examples:
+  strava routes export-all --out routes/
+  strava routes export-all --format tcx --out routes/ --concurrency 8`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRoutesExportAll,
+}
+
+var (
+	createFile string
+	createName string
+)
+
+var routesCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Check a GPX file for importing as a route (the API cannot create routes)",
+	Long: `Strava's API can read and export routes but has no endpoint to create
+one, and no way to turn an uploaded activity into a route: routes are made in
+the route builder on strava.com or the mobile app only.
+
+This command does the part that can be scripted: it reads the GPX file,
+checks it has a usable track, prints its distance and climbing, and then
+exits non-zero, so that a script notices the route was not created.
+Uploading the file with "activities upload" instead would make it an
+activity, not a route.
+
+Examples:
+  strava routes create --file ride.gpx --name "Sunday loop"
+  strava routes create --file ride.gpx --name "Sunday loop" --json`,
+	Args: cobra.NoArgs,
+	RunE: runRoutesCreate,
+}
+
+var shareBundle string
+
+var routesShareCmd = &cobra.Command{
+	Use:   "share <id>",
+	Short: "Bundle a route as a ZIP ready to post in a club chat",
+	Long: `Package a route into one ZIP file containing:
+
+  route-<id>.gpx   the route's GPS track (as from: strava routes export)
+  map.png          a preview of the route's shape, start in green, end in red
+  elevation.png    the elevation profile (when the GPX has elevation data)
+  README.md        name, distance, climbing, estimated time and a link to the route
+
+Distances in the README follow --units.
+
+Examples:
+  strava routes share 12345
+  strava routes share 12345 --bundle ~/Desktop/sunday-ride.zip`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRoutesShare,
+}
+
 func init() {
 	rootCmd.AddCommand(routesCmd)
 	routesCmd.AddCommand(routesListCmd)
 	routesCmd.AddCommand(routesGetCmd)
+	routesCmd.AddCommand(routesMapCmd)
 	routesCmd.AddCommand(routesExportCmd)
+	routesCmd.AddCommand(routesExportAllCmd)
+	routesCmd.AddCommand(routesShareCmd)
+	routesCmd.AddCommand(routesCreateCmd)
+
+	addPageFlags(routesListCmd, "routes", true)
+	addListFlags(routesListCmd, "routes")
 
-	routesListCmd.Flags().IntVar(&routesPage, "page", 1, "Page number")
-	routesListCmd.Flags().IntVar(&routesPerPage, "per-page", 30, "Items per page")
+	addMapFlags(routesMapCmd)
 
 	routesExportCmd.Flags().StringVar(&exportFormat, "format", "gpx", "Export format: gpx or tcx")
 	routesExportCmd.Flags().StringVar(&exportOut, "out", "", "Output file path (default: route-<id>.<format>)")
+
+	routesExportAllCmd.Flags().StringVar(&exportAllFormat, "format", "gpx", "Export format: gpx or tcx")
+	routesExportAllCmd.Flags().StringVar(&exportAllOut, "out", ".", "Directory to write the files to (created if missing)")
+	routesExportAllCmd.Flags().IntVar(&exportAllConcurrency, "concurrency", 4, "Number of routes to download at once")
+	routesExportAllCmd.Flags().BoolVar(&exportAllForce, "force", false, "Download routes whose file already exists")
+	_ = routesExportAllCmd.MarkFlagDirname("out")
+
+	routesCreateCmd.Flags().StringVar(&createFile, "file", "", "GPX file with the route's track (required)")
+	routesCreateCmd.Flags().StringVar(&createName, "name", "", "Route name (default: the file name)")
+	_ = routesCreateCmd.MarkFlagRequired("file")
+	_ = routesCreateCmd.MarkFlagFilename("file", "gpx")
+
+	routesShareCmd.Flags().StringVar(&shareBundle, "bundle", "", "ZIP file to write (default: route-<id>.zip)")
+}
+
+// routesAthleteID returns the athlete ID given as the optional argument of a
+// routes command, or the authenticated athlete's.
+func routesAthleteID(cmd *cobra.Command, api *genclient.ClientWithResponses, args []string) (int64, error) {
+	var athleteID int64
+	if len(args) == 1 {
+		if _, err := fmt.Sscan(args[0], &athleteID); err != nil {
+			return 0, fmt.Errorf("invalid athlete ID %q", args[0])
+		}
+		return athleteID, nil
+	}
+	me, err := api.GetLoggedInAthleteWithResponse(cmd.Context())
+	if err != nil {
+		return 0, fmt.Errorf("fetch athlete: %w", err)
+	}
+	if me.HTTPResponse.StatusCode != 200 {
+		return 0, apiError(me.HTTPResponse.StatusCode, me.Body)
+	}
+	if me.JSON200 != nil && me.JSON200.Id != nil {
+		athleteID = *me.JSON200.Id
+	}
+	return athleteID, nil
 }
 
 func runRoutesList(cmd *cobra.Command, args []string) error {
@@ -73,37 +214,45 @@ func runRoutesList(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-
-	var athleteID int64
-	if len(args) == 1 {
-		if _, err := fmt.Sscan(args[0], &athleteID); err != nil {
-			return fmt.Errorf("invalid athlete ID %q", args[0])
-		}
-	} else {
-		me, err := api.GetLoggedInAthleteWithResponse(cmd.Context())
-		if err != nil {
-			return fmt.Errorf("fetch athlete: %w", err)
-		}
-		if me.HTTPResponse.StatusCode != 200 {
-			return apiError(me.HTTPResponse.StatusCode, me.Body)
-		}
-		if me.JSON200 != nil && me.JSON200.Id != nil {
-			athleteID = *me.JSON200.Id
-		}
+	athleteID, err := routesAthleteID(cmd, api, args)
+	if err != nil {
+		return err
 	}
 
-	resp, err := api.GetRoutesByAthleteIdWithResponse(cmd.Context(), athleteID,
-		&genclient.GetRoutesByAthleteIdParams{Page: intPtr(routesPage), PerPage: intPtr(routesPerPage)})
+	return runList(cmd, newPrinter(), listSource[genclient.GetRoutesByAthleteIdResponse]{
+		what: "routes",
+		fetch: func(ctx context.Context, page, perPage int) (*http.Response, []byte, error) {
+			resp, err := api.GetRoutesByAthleteIdWithResponse(ctx, athleteID,
+				&genclient.GetRoutesByAthleteIdParams{Page: &page, PerPage: &perPage})
+			if err != nil {
+				return nil, nil, err
+			}
+			return resp.HTTPResponse, resp.Body, nil
+		},
+		print: (*output.Printer).Routes,
+	})
+}
+
+func runRoutesGet(cmd *cobra.Command, args []string) error {
+	id, err := parseID(args[0])
+	if err != nil {
+		return err
+	}
+	api, _, err := apiClient(cmd)
 	if err != nil {
-		return fmt.Errorf("fetch routes: %w", err)
+		return err
+	}
+	resp, err := api.GetRouteByIdWithResponse(cmd.Context(), id)
+	if err != nil {
+		return fmt.Errorf("fetch route: %w", err)
 	}
 	if resp.HTTPResponse.StatusCode != 200 {
 		return apiError(resp.HTTPResponse.StatusCode, resp.Body)
 	}
-	return output.New(os.Stdout, jsonOutput).Routes(resp)
+	return newPrinter().Route(resp)
 }
 
-func runRoutesGet(cmd *cobra.Command, args []string) error {
+func runRoutesMap(cmd *cobra.Command, args []string) error {
 	id, err := parseID(args[0])
 	if err != nil {
 		return err
@@ -119,7 +268,19 @@ func runRoutesGet(cmd *cobra.Command, args []string) error {
 	if resp.HTTPResponse.StatusCode != 200 {
 		return apiError(resp.HTTPResponse.StatusCode, resp.Body)
 	}
-	return output.New(os.Stdout, jsonOutput).Route(resp)
+	r := resp.JSON200
+	if r == nil {
+		return fmt.Errorf("unexpected empty response")
+	}
+	m := output.TrackMap{Name: derefStr(r.Name)}
+	if r.Distance != nil {
+		m.Distance = float64(*r.Distance)
+	}
+	var polyline string
+	if r.Map != nil {
+		polyline = cmp.Or(derefStr(r.Map.Polyline), derefStr(r.Map.SummaryPolyline))
+	}
+	return showMap(cmd.Context(), fmt.Sprintf("route-%d", id), polyline, m)
 }
 
 func runRoutesExport(cmd *cobra.Command, args []string) error {
@@ -142,35 +303,339 @@ func runRoutesExport(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	body, err := fetchRouteExport(cmd.Context(), httpClient, id, format)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
 
+	f, err := fsutil.Create(outPath, 0644)
+	if err != nil {
+		return fmt.Errorf("create output file: %w", err)
+	}
+	defer f.Discard()
+
+	n, err := io.Copy(f, body)
+	if err == nil {
+		err = f.Commit()
+	}
+	if err != nil {
+		return fmt.Errorf("write file: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Saved %d bytes → %s\n", n, outPath)
+	return nil
+}
+
+func runRoutesExportAll(cmd *cobra.Command, args []string) error {
+	format := strings.ToLower(exportAllFormat)
+	if format != "gpx" && format != "tcx" {
+		return fmt.Errorf("--format must be gpx or tcx, got %q", format)
+	}
+	if exportAllConcurrency < 1 {
+		return fmt.Errorf("--concurrency must be at least 1, got %d", exportAllConcurrency)
+	}
+
+	api, cfg, err := apiClient(cmd)
+	if err != nil {
+		return err
+	}
+	athleteID, err := routesAthleteID(cmd, api, args)
+	if err != nil {
+		return err
+	}
+	var routes []routeRef
+	for page := 1; ; page++ {
+		perPage := historyPageSize
+		resp, err := api.GetRoutesByAthleteIdWithResponse(cmd.Context(), athleteID,
+			&genclient.GetRoutesByAthleteIdParams{Page: &page, PerPage: &perPage})
+		if err != nil {
+			return fmt.Errorf("fetch routes (page %d): %w", page, err)
+		}
+		if resp.HTTPResponse.StatusCode != 200 {
+			return fmt.Errorf("fetch routes (page %d): %w", page, apiError(resp.HTTPResponse.StatusCode, resp.Body))
+		}
+		var batch []routeRef
+		if err := json.Unmarshal(resp.Body, &batch); err != nil {
+			return fmt.Errorf("parse routes (page %d): %w", page, err)
+		}
+		routes = append(routes, batch...)
+		if len(batch) < perPage {
+			break
+		}
+	}
+	if len(routes) == 0 {
+		fmt.Fprintln(os.Stderr, "No routes to export.")
+		return nil
+	}
+	if err := os.MkdirAll(exportAllOut, 0755); err != nil {
+		return err
+	}
+
+	httpClient := genclient.NewHTTPClient(cfg)
+	bar := newProgressBar("routes", len(routes))
+	jobs := make(chan routeRef)
+	var (
+		wg             sync.WaitGroup
+		mu             sync.Mutex
+		saved, skipped int
+		failed         []string
+	)
+	for range min(exportAllConcurrency, len(routes)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range jobs {
+				id := r.id()
+				path := filepath.Join(exportAllOut, fmt.Sprintf("route-%d.%s", id, format))
+				if _, err := os.Stat(path); err == nil && !exportAllForce {
+					mu.Lock()
+					skipped++
+					mu.Unlock()
+					bar.Step(filepath.Base(path) + " exists, skipped")
+					continue
+				}
+				err := saveRouteExport(cmd.Context(), httpClient, id, format, path)
+				mu.Lock()
+				if err != nil {
+					failed = append(failed, fmt.Sprint(id))
+				} else {
+					saved++
+				}
+				mu.Unlock()
+				if err != nil {
+					bar.Warn("route %d: %v", id, err)
+					bar.Step(fmt.Sprintf("route %d failed", id))
+				} else {
+					bar.Step(filepath.Base(path))
+				}
+			}
+		}()
+	}
+	for _, r := range routes {
+		if cmd.Context().Err() != nil {
+			break
+		}
+		jobs <- r
+	}
+	close(jobs)
+	wg.Wait()
+	bar.Finish()
+
+	fmt.Fprintf(os.Stderr, "Exported %d routes to %s (%d already there)\n", saved, exportAllOut, skipped)
+	if err := cmd.Context().Err(); err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d routes failed to export: %s\n  run the command again to retry them",
+			len(failed), len(routes), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// routeRef is the part of a listed route that export-all needs.
+type routeRef struct {
+	ID    int64  `json:"id"`
+	IDStr string `json:"id_str"`
+}
+
+// id returns the route's ID, from the string form when present: route IDs
+// outgrew float precision, which some JSON encoders still round to.
+func (r routeRef) id() int64 {
+	if id, err := parseID(r.IDStr); err == nil {
+		return id
+	}
+	return r.ID
+}
+
+// saveRouteExport downloads a route to path, writing a temporary file first
+// so that path only ever holds a complete export.
+func saveRouteExport(ctx context.Context, httpClient *http.Client, id int64, format, path string) error {
+	body, err := fetchRouteExport(ctx, httpClient, id, format)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	f, err := fsutil.Create(path, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Discard()
+	_, err = io.Copy(f, body)
+	if err == nil {
+		err = f.Commit()
+	}
+	if err != nil {
+		return fmt.Errorf("write file: %w", err)
+	}
+	return nil
+}
+
+// fetchRouteExport starts downloading a route as GPX or TCX. The caller must
+// close the returned body.
+func fetchRouteExport(ctx context.Context, httpClient *http.Client, id int64, format string) (io.ReadCloser, error) {
 	url := fmt.Sprintf("https://www.strava.com/api/v3/routes/%d/export_%s", id, format)
-	req, err := http.NewRequestWithContext(cmd.Context(), http.MethodGet, url, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return fmt.Errorf("build request: %w", err)
+		return nil, fmt.Errorf("build request: %w", err)
 	}
 
 	resp, err := httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("export route: %w", err)
+		return nil, fmt.Errorf("export route: %w", err)
 	}
-	defer resp.Body.Close()
-
 	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
 		body, _ := io.ReadAll(resp.Body)
-		return apiError(resp.StatusCode, body)
+		return nil, apiError(resp.StatusCode, body)
 	}
+	return resp.Body, nil
+}
+
+// Share bundle image sizes in pixels.
+const (
+	shareMapWidth      = 1200
+	shareMapHeight     = 800
+	shareProfileHeight = 300
+)
 
-	f, err := os.Create(outPath)
+func runRoutesShare(cmd *cobra.Command, args []string) error {
+	id, err := parseID(args[0])
 	if err != nil {
-		return fmt.Errorf("create output file: %w", err)
+		return err
+	}
+	outPath := shareBundle
+	if outPath == "" {
+		outPath = fmt.Sprintf("route-%d.zip", id)
 	}
-	defer f.Close()
 
-	n, err := io.Copy(f, resp.Body)
+	api, cfg, err := apiClient(cmd)
 	if err != nil {
-		return fmt.Errorf("write file: %w", err)
+		return err
+	}
+	route, err := api.GetRouteByIdWithResponse(cmd.Context(), id)
+	if err != nil {
+		return fmt.Errorf("fetch route: %w", err)
+	}
+	if route.HTTPResponse.StatusCode != 200 {
+		return apiError(route.HTTPResponse.StatusCode, route.Body)
 	}
 
-	fmt.Fprintf(os.Stderr, "Saved %d bytes → %s\n", n, outPath)
+	body, err := fetchRouteExport(cmd.Context(), genclient.NewHTTPClient(cfg), id, "gpx")
+	if err != nil {
+		return err
+	}
+	gpx, err := io.ReadAll(body)
+	body.Close()
+	if err != nil {
+		return fmt.Errorf("download GPX: %w", err)
+	}
+	track, err := geo.ParseGPX(bytes.NewReader(gpx))
+	if err != nil {
+		return err
+	}
+
+	pts := make([]geo.Point, len(track))
+	for i, tp := range track {
+		pts[i] = tp.Point
+	}
+	if len(pts) < 2 && route.JSON200 != nil && route.JSON200.Map != nil {
+		pts = geo.DecodePolyline(derefStr(route.JSON200.Map.SummaryPolyline))
+	}
+	if len(pts) < 2 {
+		return fmt.Errorf("route %d has no track points", id)
+	}
+
+	files := []bundleFile{{Name: fmt.Sprintf("route-%d.gpx", id), Data: gpx}}
+	var png bytes.Buffer
+	if err := imgpng.Encode(&png, plot.Map(pts, shareMapWidth, shareMapHeight)); err != nil {
+		return fmt.Errorf("render map: %w", err)
+	}
+	files = append(files, bundleFile{Name: "map.png", Data: png.Bytes()})
+	if dist, ele := geo.Profile(track); len(ele) >= 2 {
+		var png bytes.Buffer
+		if err := imgpng.Encode(&png, plot.Profile(dist, ele, shareMapWidth, shareProfileHeight)); err != nil {
+			return fmt.Errorf("render elevation profile: %w", err)
+		}
+		files = append(files, bundleFile{Name: "elevation.png", Data: png.Bytes()})
+	}
+
+	names := make([]string, len(files))
+	for i, f := range files {
+		names[i] = f.Name
+	}
+	var readme bytes.Buffer
+	p := output.New(&readme, false)
+	p.Units = newPrinter().Units
+	if err := p.RouteReadme(route, names); err != nil {
+		return err
+	}
+	files = append([]bundleFile{{Name: "README.md", Data: readme.Bytes()}}, files...)
+
+	if err := writeZip(outPath, files); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Saved %s (%d files) → %s\n", derefStr(route.JSON200.Name), len(files), outPath)
 	return nil
 }
+
+// bundleFile is one entry of a ZIP written by writeZip.
+type bundleFile struct {
+	Name string
+	Data []byte
+}
+
+// writeZip writes files, in order, to a new ZIP archive at path.
+func writeZip(path string, files []bundleFile) error {
+	f, err := fsutil.Create(path, 0644)
+	if err != nil {
+		return fmt.Errorf("create bundle: %w", err)
+	}
+	defer f.Discard()
+	zw := zip.NewWriter(f)
+	for _, file := range files {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: file.Name, Method: zip.Deflate, Modified: time.Now()})
+		if err == nil {
+			_, err = w.Write(file.Data)
+		}
+		if err != nil {
+			return fmt.Errorf("write %s to bundle: %w", file.Name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("write bundle: %w", err)
+	}
+	if err := f.Commit(); err != nil {
+		return fmt.Errorf("write bundle: %w", err)
+	}
+	return nil
+}
+
+func runRoutesCreate(cmd *cobra.Command, args []string) error {
+	f, err := os.Open(createFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	pts, err := geo.ParseGPX(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", createFile, err)
+	}
+	if len(pts) < 2 {
+		return fmt.Errorf("%s: no track to make a route from (%d points)", createFile, len(pts))
+	}
+
+	draft := output.RouteDraft{Name: createName, File: createFile, Points: len(pts)}
+	if draft.Name == "" {
+		draft.Name = strings.TrimSuffix(filepath.Base(createFile), filepath.Ext(createFile))
+	}
+	_, ele := geo.Profile(pts)
+	draft.ElevationGain = analysis.ElevationGain(ele, analysis.ElevationThreshold)
+	for i := 1; i < len(pts); i++ {
+		draft.Distance += geo.Distance(pts[i-1].Point, pts[i].Point)
+	}
+	if err := newPrinter().RouteDraft(draft); err != nil {
+		return err
+	}
+	return fmt.Errorf("route not created: the Strava API cannot create routes; create it in the route builder (https://www.strava.com/routes/new) or the mobile app, then find it with: stravacli routes list")
+}
@@ -1,15 +1,27 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
 	"os"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 	genclient "github.com/Brainsoft-Raxat/strava-cli/internal/client"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/analysis"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/fsutil"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/geo"
 	"github.com/Brainsoft-Raxat/strava-cli/internal/output"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/store"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/weather"
 )
 
 var segmentsCmd = &cobra.Command{
@@ -17,11 +29,6 @@ var segmentsCmd = &cobra.Command{
 	Short: "Segment commands",
 }
 
-var (
-	segPage    int
-	segPerPage int
-)
-
 var segmentsGetCmd = &cobra.Command{
 	Use:   "get <id>",
 	Short: "Get a segment by ID",
@@ -35,6 +42,55 @@ var segmentsStarredCmd = &cobra.Command{
 	RunE:  runSegmentsStarred,
 }
 
+var segmentsStarCmd = &cobra.Command{
+	Use:   "star <id>",
+	Short: "Star a segment",
+	Long: `Star a segment, adding it to your starred segments (see: strava segments
+starred) and to the segments your device shows live.
+
+Needs the profile:write scope, which logins before this command existed did
+not request; run "strava auth login" again if Strava refuses.
+
+Examples:
+  strava segments star 229781
+  strava segments star 229781 --yes`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error { return runSegmentsSetStarred(cmd, args, true) },
+}
+
+var segmentsUnstarCmd = &cobra.Command{
+	Use:   "unstar <id>",
+	Short: "Unstar a segment",
+	Long: `Remove a segment from your starred segments. Needs the profile:write
+scope, like "strava segments star".
+
+Examples:
+  strava segments unstar 229781`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error { return runSegmentsSetStarred(cmd, args, false) },
+}
+
+var (
+	segExportFormat string
+	segExportOut    string
+)
+
+var segmentsExportCmd = &cobra.Command{
+	Use:   "export <id>",
+	Short: "Export a segment as a GPX track",
+	Long: `Write a segment's course as a GPX track, from its streams (position and
+elevation), to load onto a bike computer as a course for a KOM attempt.
+
+The file is written to --out (defaults to segment-<id>.gpx). GPX is the
+only format.
+
+Examples:
+  strava segments export 229781
+  strava segments export 229781 --out box-hill.gpx`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSegmentsExport,
+}
+
 var (
 	exploreBounds       string
 	exploreActivityType string
@@ -58,10 +114,9 @@ var segmentEffortsCmd = &cobra.Command{
 }
 
 var (
-	effortsSegmentID  int64
-	effortsStartDate  string
-	effortsEndDate    string
-	effortsPerPage    int
+	effortsSegmentID int64
+	effortsStartDate string
+	effortsEndDate   string
 )
 
 var segmentEffortsListCmd = &cobra.Command{
@@ -70,6 +125,60 @@ var segmentEffortsListCmd = &cobra.Command{
 	RunE:  runSegmentEffortsList,
 }
 
+var (
+	segHistoryFormat  string
+	segHistoryAfter   string
+	segHistoryBefore  string
+	segHistoryWeather bool
+)
+
+var segmentsHistoryCmd = &cobra.Command{
+	Use:   "history <id>",
+	Short: "List all your efforts on a segment, for tracking it over time",
+	Long: `List every effort you have made on a segment, oldest first, with elapsed
+and moving time, average heart rate and power.
+
+--format csv writes the efforts as CSV for a spreadsheet: one row per
+effort, times in seconds, metric units, empty cells for missing values.
+
+--weather adds the temperature and wind at the start of each effort, from
+the Open-Meteo archive (cached; efforts from the last five days have
+none yet).
+
+Requires a Strava subscription, like "segments efforts list".
+
+Examples:
+  strava segments history 12345678
+  strava segments history 12345678 --after 2024-01-01 --format csv > climb.csv
+  strava segments history 12345678 --weather --format csv > climb.csv`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSegmentsHistory,
+}
+
+var (
+	segLeaderboardWindow string
+	segLeaderboardTop    int
+)
+
+var segmentsLeaderboardCmd = &cobra.Command{
+	Use:   "leaderboard <id>",
+	Short: "Rank your own efforts on a segment and show your PR progression",
+	Long: `Rank all your efforts on a segment fastest first, with how far each is
+behind your best, and list the efforts that set a new personal record, with
+the time each took off the one before.
+
+--window year counts efforts from this calendar year only; all (the
+default) counts every effort.
+
+Requires a Strava subscription, like "segments efforts list".
+
+Examples:
+  strava segments leaderboard 12345678
+  strava segments leaderboard 12345678 --window year --top 5`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSegmentsLeaderboard,
+}
+
 var segmentEffortsGetCmd = &cobra.Command{
 	Use:   "get <id>",
 	Short: "Get a segment effort by ID",
@@ -77,17 +186,50 @@ var segmentEffortsGetCmd = &cobra.Command{
 	RunE:  runSegmentEffortsGet,
 }
 
+var effortStreamsKeys string
+
+var segmentEffortsStreamsCmd = &cobra.Command{
+	Use:   "streams <effort-id>",
+	Short: "Get data streams for a segment effort",
+	Long: `Fetch the time-series data streams of one segment effort — just the part
+of the activity on the segment — for power or heart rate analysis tools.
+
+Stream keys are as for "strava activities streams": time, distance, latlng,
+altitude, velocity_smooth, heartrate, cadence, watts, temp, moving,
+grade_smooth, or "all".
+
+Examples:
+  strava segments efforts streams 98765432
+  strava segments efforts streams 98765432 --keys watts,heartrate --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSegmentEffortsStreams,
+}
+
 func init() {
 	rootCmd.AddCommand(segmentsCmd)
 	segmentsCmd.AddCommand(segmentsGetCmd)
 	segmentsCmd.AddCommand(segmentsStarredCmd)
+	segmentsCmd.AddCommand(segmentsStarCmd)
+	segmentsCmd.AddCommand(segmentsUnstarCmd)
+	segmentsCmd.AddCommand(segmentsExportCmd)
 	segmentsCmd.AddCommand(segmentsExploreCmd)
+	segmentsCmd.AddCommand(segmentsHistoryCmd)
+	segmentsCmd.AddCommand(segmentsLeaderboardCmd)
 	segmentsCmd.AddCommand(segmentEffortsCmd)
 	segmentEffortsCmd.AddCommand(segmentEffortsListCmd)
 	segmentEffortsCmd.AddCommand(segmentEffortsGetCmd)
+	segmentEffortsCmd.AddCommand(segmentEffortsStreamsCmd)
+
+	addPageFlags(segmentsStarredCmd, "segments", true)
+	addListFlags(segmentsStarredCmd, "segments")
 
-	segmentsStarredCmd.Flags().IntVar(&segPage, "page", 1, "Page number")
-	segmentsStarredCmd.Flags().IntVar(&segPerPage, "per-page", 30, "Items per page")
+	for _, c := range []*cobra.Command{segmentsStarCmd, segmentsUnstarCmd} {
+		c.Flags().Bool("yes", false, "Skip the confirmation prompt")
+		c.Flags().Bool("dry-run", false, "Print what would change without calling the API")
+	}
+
+	segmentsExportCmd.Flags().StringVar(&segExportFormat, "format", "gpx", "Output format: gpx")
+	segmentsExportCmd.Flags().StringVarP(&segExportOut, "out", "o", "", "Output file path (default: segment-<id>.gpx)")
 
 	segmentsExploreCmd.Flags().StringVar(&exploreBounds, "bounds", "",
 		"Bounding box: sw_lat,sw_lng,ne_lat,ne_lng (required)")
@@ -102,8 +244,20 @@ func init() {
 		"ISO 8601 start date, e.g. 2024-01-01T00:00:00Z")
 	segmentEffortsListCmd.Flags().StringVar(&effortsEndDate, "end-date", "",
 		"ISO 8601 end date")
-	segmentEffortsListCmd.Flags().IntVar(&effortsPerPage, "per-page", 30, "Items per page")
+	addPageFlags(segmentEffortsListCmd, "efforts", false)
+	addListFlags(segmentEffortsListCmd, "efforts")
 	_ = segmentEffortsListCmd.MarkFlagRequired("segment-id")
+
+	segmentEffortsStreamsCmd.Flags().StringVar(&effortStreamsKeys, "keys", "time,distance,altitude,heartrate,cadence,watts,velocity_smooth",
+		"Comma-separated stream keys to fetch, or \"all\"")
+
+	segmentsHistoryCmd.Flags().StringVar(&segHistoryFormat, "format", "table", "Output format: table or csv")
+	segmentsHistoryCmd.Flags().StringVar(&segHistoryAfter, "after", "", "Only efforts on or after this date (YYYY-MM-DD)")
+	segmentsHistoryCmd.Flags().StringVar(&segHistoryBefore, "before", "", "Only efforts before this date (YYYY-MM-DD)")
+	segmentsHistoryCmd.Flags().BoolVar(&segHistoryWeather, "weather", false, "Add temperature and wind columns (Open-Meteo)")
+
+	segmentsLeaderboardCmd.Flags().StringVar(&segLeaderboardWindow, "window", "all", "Efforts to rank: year (this calendar year) or all")
+	segmentsLeaderboardCmd.Flags().IntVar(&segLeaderboardTop, "top", 10, "Number of ranked efforts to list")
 }
 
 func runSegmentsGet(cmd *cobra.Command, args []string) error {
@@ -122,23 +276,176 @@ func runSegmentsGet(cmd *cobra.Command, args []string) error {
 	if resp.HTTPResponse.StatusCode != 200 {
 		return apiError(resp.HTTPResponse.StatusCode, resp.Body)
 	}
-	return output.New(os.Stdout, jsonOutput).Segment(resp)
+	return newPrinter().Segment(resp)
 }
 
-func runSegmentsStarred(cmd *cobra.Command, args []string) error {
+// runSegmentsSetStarred stars or unstars the segment given as the argument
+// through PUT /segments/{id}/starred, which the generated client lacks.
+func runSegmentsSetStarred(cmd *cobra.Command, args []string, starred bool) error {
+	id, err := parseID(args[0])
+	if err != nil {
+		return err
+	}
+	httpClient, cfg, err := rawClient(cmd)
+	if err != nil {
+		return err
+	}
+	if cfg.Tokens.Scope != "" && !hasScope(cfg.Tokens.Scope, "profile:write") {
+		return fmt.Errorf("starring segments needs the profile:write scope, not granted at login; run: stravacli auth login")
+	}
+	verb, done := "star", "Starred"
+	if !starred {
+		verb, done = "unstar", "Unstarred"
+	}
+	proceed, err := confirmMutation(cmd, fmt.Sprintf("%s segment %d", verb, id))
+	if err != nil || !proceed {
+		return err
+	}
+
+	form := url.Values{"starred": {strconv.FormatBool(starred)}}
+	req, err := http.NewRequestWithContext(cmd.Context(), http.MethodPut,
+		fmt.Sprintf("https://www.strava.com/api/v3/segments/%d/starred", id), strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s segment: %w", verb, err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return apiError(resp.StatusCode, body)
+	}
+
+	if jsonOutput {
+		fmt.Fprintln(os.Stdout, string(body))
+		return nil
+	}
+	var seg struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(body, &seg); err == nil && seg.Name != "" {
+		fmt.Fprintf(os.Stdout, "%s segment %d: %q\n", done, id, seg.Name)
+	} else {
+		fmt.Fprintf(os.Stdout, "%s segment %d\n", done, id)
+	}
+	return nil
+}
+
+func runSegmentsExport(cmd *cobra.Command, args []string) error {
+	id, err := parseID(args[0])
+	if err != nil {
+		return err
+	}
+	if format := strings.ToLower(segExportFormat); format != "gpx" {
+		return fmt.Errorf("--format must be gpx, got %q", segExportFormat)
+	}
+	outPath := segExportOut
+	if outPath == "" {
+		outPath = fmt.Sprintf("segment-%d.gpx", id)
+	}
+
 	api, _, err := apiClient(cmd)
 	if err != nil {
 		return err
 	}
-	resp, err := api.GetLoggedInAthleteStarredSegmentsWithResponse(cmd.Context(),
-		&genclient.GetLoggedInAthleteStarredSegmentsParams{Page: intPtr(segPage), PerPage: intPtr(segPerPage)})
+	resp, err := api.GetSegmentByIdWithResponse(cmd.Context(), id)
 	if err != nil {
-		return fmt.Errorf("fetch starred segments: %w", err)
+		return fmt.Errorf("fetch segment: %w", err)
 	}
 	if resp.HTTPResponse.StatusCode != 200 {
 		return apiError(resp.HTTPResponse.StatusCode, resp.Body)
 	}
-	return output.New(os.Stdout, jsonOutput).StarredSegments(resp)
+	name := fmt.Sprintf("Segment %d", id)
+	if resp.JSON200 != nil && resp.JSON200.Name != nil {
+		name = *resp.JSON200.Name
+	}
+
+	httpClient, _, err := rawClient(cmd)
+	if err != nil {
+		return err
+	}
+	streams, err := fetchSegmentStreams(cmd.Context(), httpClient, id)
+	if err != nil {
+		return err
+	}
+	if len(streams.Latlng) < 2 {
+		return fmt.Errorf("segment %d has no position stream", id)
+	}
+	pts := make([]geo.TrackPoint, len(streams.Latlng))
+	for i, ll := range streams.Latlng {
+		pts[i].Point = geo.Point{Lat: ll[0], Lng: ll[1]}
+		if len(streams.Altitude) == len(streams.Latlng) {
+			pts[i].Ele, pts[i].HasEle = streams.Altitude[i], true
+		}
+	}
+
+	f, err := fsutil.Create(outPath, 0644)
+	if err != nil {
+		return fmt.Errorf("create output file: %w", err)
+	}
+	defer f.Discard()
+	if err := geo.WriteGPX(f, name, pts); err != nil {
+		return err
+	}
+	if err := f.Commit(); err != nil {
+		return fmt.Errorf("write file: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Saved %d points of %q → %s\n", len(pts), name, outPath)
+	return nil
+}
+
+// fetchSegmentStreams fetches a segment's position, elevation and distance
+// streams.
+func fetchSegmentStreams(ctx context.Context, httpClient *http.Client, id int64) (*analysis.Streams, error) {
+	body, err := fetchStreamsAt(ctx, httpClient, fmt.Sprintf("/segments/%d/streams", id), []string{"latlng", "altitude", "distance"})
+	if err != nil {
+		return nil, fmt.Errorf("fetch segment streams: %w", err)
+	}
+	return analysis.ParseStreams(body)
+}
+
+// fetchStreamsAt fetches the given streams, keyed by type, from a streams
+// endpoint the generated client lacks (segments and segment efforts), and
+// returns the raw body.
+func fetchStreamsAt(ctx context.Context, httpClient *http.Client, path string, keys []string) ([]byte, error) {
+	// Commas left unescaped, as the generated client sends them.
+	endpoint := "https://www.strava.com/api/v3" + path + "?key_by_type=true&keys=" + strings.Join(keys, ",")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, apiError(resp.StatusCode, body)
+	}
+	return body, nil
+}
+
+func runSegmentsStarred(cmd *cobra.Command, args []string) error {
+	api, _, err := apiClient(cmd)
+	if err != nil {
+		return err
+	}
+	return runList(cmd, newPrinter(), listSource[genclient.GetLoggedInAthleteStarredSegmentsResponse]{
+		what: "starred segments",
+		fetch: func(ctx context.Context, page, perPage int) (*http.Response, []byte, error) {
+			resp, err := api.GetLoggedInAthleteStarredSegmentsWithResponse(ctx,
+				&genclient.GetLoggedInAthleteStarredSegmentsParams{Page: &page, PerPage: &perPage})
+			if err != nil {
+				return nil, nil, err
+			}
+			return resp.HTTPResponse, resp.Body, nil
+		},
+		print: (*output.Printer).StarredSegments,
+	})
 }
 
 func runSegmentsExplore(cmd *cobra.Command, args []string) error {
@@ -170,13 +477,12 @@ func runSegmentsExplore(cmd *cobra.Command, args []string) error {
 	if resp.HTTPResponse.StatusCode != 200 {
 		return apiError(resp.HTTPResponse.StatusCode, resp.Body)
 	}
-	return output.New(os.Stdout, jsonOutput).ExploreSegments(resp)
+	return newPrinter().ExploreSegments(resp)
 }
 
 func runSegmentEffortsList(cmd *cobra.Command, args []string) error {
 	params := &genclient.GetEffortsBySegmentIdParams{
 		SegmentId: int(effortsSegmentID),
-		PerPage:   intPtr(effortsPerPage),
 	}
 	if effortsStartDate != "" {
 		t, err := time.Parse(time.RFC3339, effortsStartDate)
@@ -193,18 +499,232 @@ func runSegmentEffortsList(cmd *cobra.Command, args []string) error {
 		params.EndDateLocal = &t
 	}
 
-	api, _, err := apiClient(cmd)
+	api, cfg, err := apiClient(cmd)
 	if err != nil {
 		return err
 	}
-	resp, err := api.GetEffortsBySegmentIdWithResponse(cmd.Context(), params)
+	if err := precheckFeature(cfg, featureSegmentEfforts); err != nil {
+		return err
+	}
+	return runList(cmd, newPrinter(), listSource[genclient.GetEffortsBySegmentIdResponse]{
+		what: "efforts",
+		fetch: func(ctx context.Context, _, perPage int) (*http.Response, []byte, error) {
+			params.PerPage = &perPage
+			resp, err := api.GetEffortsBySegmentIdWithResponse(ctx, params)
+			if err != nil {
+				return nil, nil, err
+			}
+			return resp.HTTPResponse, resp.Body, nil
+		},
+		print: (*output.Printer).SegmentEfforts,
+	})
+}
+
+func runSegmentsHistory(cmd *cobra.Command, args []string) error {
+	id, err := parseID(args[0])
 	if err != nil {
-		return fmt.Errorf("fetch efforts: %w", err)
+		return err
+	}
+	if segHistoryFormat != "table" && segHistoryFormat != "csv" {
+		return fmt.Errorf("invalid --format %q: use table or csv", segHistoryFormat)
+	}
+	after, err := parseDate("after", segHistoryAfter)
+	if err != nil {
+		return err
+	}
+	before, err := parseDate("before", segHistoryBefore)
+	if err != nil {
+		return err
+	}
+
+	api, cfg, err := apiClient(cmd)
+	if err != nil {
+		return err
+	}
+	if err := precheckFeature(cfg, featureSegmentEfforts); err != nil {
+		return err
+	}
+	efforts, err := fetchSegmentEfforts(cmd, api, id, after, before)
+	if err != nil {
+		return err
+	}
+	if segHistoryWeather {
+		if err := effortWeather(cmd, api, id, efforts); err != nil {
+			return err
+		}
+	}
+
+	printer := newPrinter()
+	if segHistoryFormat == "csv" {
+		return printer.SegmentHistoryCSV(efforts, segHistoryWeather)
+	}
+	return printer.SegmentHistory(efforts, segHistoryWeather)
+}
+
+func runSegmentsLeaderboard(cmd *cobra.Command, args []string) error {
+	id, err := parseID(args[0])
+	if err != nil {
+		return err
+	}
+	var after time.Time
+	switch segLeaderboardWindow {
+	case "all":
+	case "year":
+		after = time.Date(time.Now().Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return fmt.Errorf("invalid --window %q: use year or all", segLeaderboardWindow)
+	}
+	if segLeaderboardTop < 1 {
+		return fmt.Errorf("--top must be at least 1")
+	}
+
+	api, cfg, err := apiClient(cmd)
+	if err != nil {
+		return err
+	}
+	if err := precheckFeature(cfg, featureSegmentEfforts); err != nil {
+		return err
+	}
+	seg, err := api.GetSegmentByIdWithResponse(cmd.Context(), id)
+	if err != nil {
+		return fmt.Errorf("fetch segment: %w", err)
+	}
+	if seg.HTTPResponse.StatusCode != 200 {
+		return apiError(seg.HTTPResponse.StatusCode, seg.Body)
+	}
+	name := fmt.Sprintf("Segment %d", id)
+	if seg.JSON200 != nil && seg.JSON200.Name != nil {
+		name = *seg.JSON200.Name
+	}
+	efforts, err := fetchSegmentEfforts(cmd, api, id, after, time.Time{})
+	if err != nil {
+		return err
+	}
+	return newPrinter().SegmentLeaderboard(name, analysis.RankEfforts(efforts), analysis.PRProgression(efforts), segLeaderboardTop)
+}
+
+// fetchSegmentEfforts returns every effort on a segment started between
+// after and before (zero for no bound), oldest first. The endpoint has no
+// page parameter, so a full page is followed by requests for the time
+// before and after the efforts it returned.
+func fetchSegmentEfforts(cmd *cobra.Command, api *genclient.ClientWithResponses, segmentID int64, after, before time.Time) ([]analysis.SegmentEffort, error) {
+	type window struct{ from, to time.Time }
+	var efforts []analysis.SegmentEffort
+	seen := map[int64]bool{}
+	queue := []window{{after, before}}
+	for len(queue) > 0 {
+		w := queue[0]
+		queue = queue[1:]
+		params := &genclient.GetEffortsBySegmentIdParams{SegmentId: int(segmentID), PerPage: intPtr(historyPageSize)}
+		if !w.from.IsZero() {
+			params.StartDateLocal = &w.from
+		}
+		if !w.to.IsZero() {
+			params.EndDateLocal = &w.to
+		}
+		resp, err := api.GetEffortsBySegmentIdWithResponse(cmd.Context(), params)
+		if err != nil {
+			return nil, fmt.Errorf("fetch efforts: %w", err)
+		}
+		if resp.HTTPResponse.StatusCode != 200 {
+			return nil, apiError(resp.HTTPResponse.StatusCode, resp.Body)
+		}
+		var batch []struct {
+			analysis.SegmentEffort
+			Activity struct {
+				ID int64 `json:"id"`
+			} `json:"activity"`
+		}
+		if err := json.Unmarshal(resp.Body, &batch); err != nil {
+			return nil, fmt.Errorf("parse efforts: %w", err)
+		}
+		var first, last time.Time
+		added := 0
+		for _, e := range batch {
+			if first.IsZero() || e.StartDateLocal.Before(first) {
+				first = e.StartDateLocal
+			}
+			if e.StartDateLocal.After(last) {
+				last = e.StartDateLocal
+			}
+			if seen[e.ID] {
+				continue
+			}
+			seen[e.ID] = true
+			added++
+			e.SegmentEffort.ActivityID = e.Activity.ID
+			efforts = append(efforts, e.SegmentEffort)
+		}
+		// Stop when the filters are ignored and the same page comes back.
+		if len(batch) == historyPageSize && added > 0 {
+			queue = append(queue, window{w.from, first.Add(-time.Second)}, window{last.Add(time.Second), w.to})
+		}
+		fmt.Fprintf(os.Stderr, "Fetched %d efforts\n", len(efforts))
+	}
+	sort.Slice(efforts, func(i, j int) bool { return efforts[i].StartDate.Before(efforts[j].StartDate) })
+	return efforts, nil
+}
+
+// effortWeather sets the weather at the start of each effort, looked up at
+// the segment's start point and cached per effort.
+func effortWeather(cmd *cobra.Command, api *genclient.ClientWithResponses, segmentID int64, efforts []analysis.SegmentEffort) error {
+	resp, err := api.GetSegmentByIdWithResponse(cmd.Context(), segmentID)
+	if err != nil {
+		return fmt.Errorf("fetch segment: %w", err)
 	}
 	if resp.HTTPResponse.StatusCode != 200 {
 		return apiError(resp.HTTPResponse.StatusCode, resp.Body)
 	}
-	return output.New(os.Stdout, jsonOutput).SegmentEfforts(resp)
+	var start geo.Point
+	ok := false
+	if resp.JSON200 != nil && resp.JSON200.StartLatlng != nil {
+		start, ok = geo.PointFromSlice(*resp.JSON200.StartLatlng)
+	}
+	if !ok {
+		fmt.Fprintln(os.Stderr, "warning: the segment has no start location; weather columns are empty")
+		return nil
+	}
+
+	cache, err := store.OpenEffortWeatherCache("")
+	if err != nil {
+		return err
+	}
+	// Open-Meteo must not see the Strava token, so it gets a plain client.
+	wc := weather.New(httpClient())
+	fetched := 0
+	for i := range efforts {
+		e := &efforts[i]
+		if w, ok := cache.Lookup(e.ID); ok {
+			e.Weather = &w
+			continue
+		}
+		if time.Since(e.StartDate) < weatherArchiveLag {
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "Looking up weather for effort %d (%s)\n", e.ID, e.StartDateLocal.Format("2006-01-02"))
+		w, err := wc.At(cmd.Context(), start, e.StartDate)
+		if err != nil && cmd.Context().Err() != nil {
+			break
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: effort %d: %v\n", e.ID, err)
+			continue
+		}
+		e.Weather = &w
+		cache.Set(e.ID, w)
+		// Save as we go so an interrupted run does not repeat its lookups.
+		if fetched++; fetched%20 == 0 {
+			if err := cache.Save(); err != nil {
+				return err
+			}
+		}
+	}
+	if fetched > 0 {
+		if err := cache.Save(); err != nil {
+			return err
+		}
+	}
+	return cmd.Context().Err()
 }
 
 func runSegmentEffortsGet(cmd *cobra.Command, args []string) error {
@@ -223,7 +743,46 @@ func runSegmentEffortsGet(cmd *cobra.Command, args []string) error {
 	if resp.HTTPResponse.StatusCode != 200 {
 		return apiError(resp.HTTPResponse.StatusCode, resp.Body)
 	}
-	return output.New(os.Stdout, jsonOutput).SegmentEffort(resp)
+	return newPrinter().SegmentEffort(resp)
+}
+
+func runSegmentEffortsStreams(cmd *cobra.Command, args []string) error {
+	id, err := parseID(args[0])
+	if err != nil {
+		return err
+	}
+	keys := analysis.StreamKeys
+	if k := strings.TrimSpace(effortStreamsKeys); k != "all" {
+		keys = nil
+		for _, key := range strings.Split(k, ",") {
+			key = strings.TrimSpace(key)
+			if key == "" {
+				continue
+			}
+			if !slices.Contains(analysis.StreamKeys, key) {
+				return fmt.Errorf("unknown stream %q (available: %s)", key, strings.Join(analysis.StreamKeys, ", "))
+			}
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("--keys is empty; pass stream keys or all")
+	}
+
+	httpClient, _, err := rawClient(cmd)
+	if err != nil {
+		return err
+	}
+	body, err := fetchStreamsAt(cmd.Context(), httpClient, fmt.Sprintf("/segment_efforts/%d/streams", id), keys)
+	if err != nil {
+		return fmt.Errorf("fetch effort streams: %w", err)
+	}
+	// Effort streams have the same shape as an activity's.
+	resp := &genclient.GetActivityStreamsResponse{Body: body}
+	if err := json.Unmarshal(body, &resp.JSON200); err != nil {
+		return fmt.Errorf("parse streams: %w", err)
+	}
+	return newPrinter().Streams(resp)
 }
 
 // parseBounds parses "sw_lat,sw_lng,ne_lat,ne_lng" into []float32.
@@ -0,0 +1,408 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	genclient "github.com/Brainsoft-Raxat/strava-cli/internal/client"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/analysis"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/fsutil"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/geo"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/output"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/plot"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/store"
+)
+
+var shareCmd = &cobra.Command{
+	Use:   "share",
+	Short: "Make shareable summaries of activities",
+}
+
+var (
+	shareImageOut  string
+	shareImageSize string
+)
+
+var shareImageCmd = &cobra.Command{
+	Use:   "image [id]",
+	Short: "Render an activity as a summary card image",
+	Long: `Render a PNG card for an activity, for posting on social media: name and
+date, the route, distance, time, pace or speed, climbing, and the elevation
+profile. The image is drawn locally.
+
+The activity comes from the history kept by "strava sync" when it is
+there, and is fetched otherwise; the route and profile come from its
+streams (one request), falling back to the summary map.
+
+Without an ID, choose from your latest activities.
+
+Examples:
+  strava share image 12345 --out post.png
+  strava share image 12345 --size 1080x1350`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runShareImage,
+
+	ValidArgsFunction: completeActivityIDs,
+}
+
+var (
+	coachWeek   string
+	coachOut    string
+	coachRedact []string
+)
+
+// coachRedactions are the --redact choices of share coach, with what each
+// leaves out as the bundle's README puts it.
+var coachRedactions = map[string]string{
+	"gps":   "GPS tracks, maps and start/end locations",
+	"names": "activity names (shown as sport and weekday)",
+	"ids":   "Strava activity IDs",
+	"gear":  "gear IDs",
+}
+
+var shareCoachCmd = &cobra.Command{
+	Use:   "coach",
+	Short: "Bundle a week of training for your coach",
+	Long: `Write a ZIP with a week of training, for handing to a coach:
+
+  README.md        totals per sport and the week's heart rate zone distribution
+  summary.csv      one row per activity (metric units, times in seconds)
+  zones.csv        seconds in each heart rate zone, per activity and in total
+  charts/          heart rate, power and elevation charts per activity (PNG),
+                   and a map of each when GPS is not redacted
+
+--redact leaves out personal data, as a comma-separated list: gps (maps,
+tracks and locations; the default), names, ids, gear; or none. Zones are your
+Strava heart rate zones; without them zones.csv is left out.
+
+Activities come from the history kept by "strava sync" when there is one.
+Charts take one streams request per activity.
+
+Examples:
+  strava share coach                                  # last week
+  strava share coach --week 2025-W14 --out week.zip
+  strava share coach --redact gps,names,ids,gear`,
+	Args: cobra.NoArgs,
+	RunE: runShareCoach,
+}
+
+func init() {
+	rootCmd.AddCommand(shareCmd)
+	shareCmd.AddCommand(shareImageCmd)
+	shareCmd.AddCommand(shareCoachCmd)
+
+	shareImageCmd.Flags().StringVarP(&shareImageOut, "out", "o", "", "PNG file to write (default: activity-<id>.png)")
+	shareImageCmd.Flags().StringVar(&shareImageSize, "size", "1080x1080", "Image size in pixels, WIDTHxHEIGHT")
+
+	shareCoachCmd.Flags().StringVar(&coachWeek, "week", "", "ISO week, e.g. 2025-W14 (default: last week)")
+	shareCoachCmd.Flags().StringVarP(&coachOut, "out", "o", "", "ZIP file to write (default: coach-<week>.zip)")
+	shareCoachCmd.Flags().StringSliceVar(&coachRedact, "redact", []string{"gps"}, "What to leave out: gps, names, ids, gear, or none")
+	shareCoachCmd.RegisterFlagCompletionFunc("redact", func(_ *cobra.Command, _ []string, _ string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		return []cobra.Completion{"gps", "names", "ids", "gear", "none"}, cobra.ShellCompDirectiveNoFileComp
+	})
+}
+
+func runShareImage(cmd *cobra.Command, args []string) error {
+	width, height, err := parseSize(shareImageSize)
+	if err != nil {
+		return err
+	}
+	id, err := activityIDArg(cmd, args)
+	if err != nil {
+		return err
+	}
+	outPath := shareImageOut
+	if outPath == "" {
+		outPath = fmt.Sprintf("activity-%d.png", id)
+	}
+
+	api, _, err := apiClient(cmd)
+	if err != nil {
+		return err
+	}
+	a, err := shareActivity(cmd, api, id)
+	if err != nil {
+		return err
+	}
+	card := plot.Card{
+		Title:    a.Name,
+		Subtitle: a.StartDateLocal.Format("Mon 2 Jan 2006") + " · " + a.SportType,
+		Stats:    newPrinter().CardStats(a),
+		Route:    geo.DecodePolyline(a.Map.SummaryPolyline),
+	}
+	if streams, err := fetchStreams(cmd, api, id, "latlng", "distance", "altitude"); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: no streams (%v); drawing the summary map without a profile\n", err)
+	} else {
+		var route []geo.Point
+		for _, ll := range streams.Latlng {
+			route = append(route, geo.Point{Lat: ll[0], Lng: ll[1]})
+		}
+		if len(route) >= 2 {
+			card.Route = route
+		}
+		card.Dist, card.Ele = streams.Distance, streams.Altitude
+	}
+
+	img, err := card.Render(width, height)
+	if err != nil {
+		return err
+	}
+	f, err := fsutil.Create(outPath, 0644)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", outPath, err)
+	}
+	defer f.Discard()
+	if err := png.Encode(f, img); err != nil {
+		return fmt.Errorf("write %s: %w", outPath, err)
+	}
+	if err := f.Commit(); err != nil {
+		return fmt.Errorf("write %s: %w", outPath, err)
+	}
+	fmt.Fprintf(os.Stdout, "Wrote %s (%dx%d)\n", outPath, width, height)
+	return nil
+}
+
+// shareActivity returns an activity's summary from the synced history, or
+// fetches it when it is not there.
+func shareActivity(cmd *cobra.Command, api *genclient.ClientWithResponses, id int64) (analysis.Activity, error) {
+	var a analysis.Activity
+	var body json.RawMessage
+	var ok bool
+	if hist, err := store.OpenActivities(""); err == nil {
+		body, ok = hist.Get(id)
+	}
+	if !ok {
+		resp, err := api.GetActivityByIdWithResponse(cmd.Context(), id, nil)
+		if err != nil {
+			return a, fmt.Errorf("fetch activity: %w", err)
+		}
+		if resp.HTTPResponse.StatusCode != 200 {
+			return a, apiError(resp.HTTPResponse.StatusCode, resp.Body)
+		}
+		body = resp.Body
+	}
+	if err := json.Unmarshal(body, &a); err != nil {
+		return a, fmt.Errorf("parse activity: %w", err)
+	}
+	return a, nil
+}
+
+// parseSize parses "WIDTHxHEIGHT" in pixels.
+func parseSize(s string) (width, height int, err error) {
+	w, h, ok := strings.Cut(strings.ToLower(s), "x")
+	if ok {
+		width, err = strconv.Atoi(w)
+		if err == nil {
+			height, err = strconv.Atoi(h)
+		}
+	}
+	if !ok || err != nil || width < 200 || height < 200 || width > 4000 || height > 4000 {
+		return 0, 0, fmt.Errorf("invalid --size %q: use WIDTHxHEIGHT, each 200 to 4000 pixels", s)
+	}
+	return width, height, nil
+}
+
+func runShareCoach(cmd *cobra.Command, args []string) error {
+	start := analysis.Week.Start(time.Now()).AddDate(0, 0, -7)
+	if coachWeek != "" {
+		var err error
+		if start, err = analysis.ParseISOWeek(coachWeek, time.Local); err != nil {
+			return err
+		}
+	}
+	week := output.CoachWeek{Week: analysis.Week.Label(start), Start: start}
+	redact := map[string]bool{}
+	for _, r := range coachRedact {
+		r = strings.ToLower(strings.TrimSpace(r))
+		if r == "none" {
+			continue
+		}
+		what, ok := coachRedactions[r]
+		if !ok {
+			return fmt.Errorf("invalid --redact %q: use gps, names, ids, gear or none", r)
+		}
+		if !redact[r] {
+			week.Redacted = append(week.Redacted, what)
+		}
+		redact[r] = true
+	}
+	outPath := coachOut
+	if outPath == "" {
+		outPath = "coach-" + week.Week + ".zip"
+	}
+
+	api, _, err := apiClient(cmd)
+	if err != nil {
+		return err
+	}
+	// The API filters by UTC start time; weeks go by the local start date,
+	// as in report, so fetch a day either side.
+	end := start.AddDate(0, 0, 7)
+	fetched, err := historyActivities(cmd, api, start.AddDate(0, 0, -1), end.AddDate(0, 0, 1))
+	if err != nil {
+		return err
+	}
+	var acts []analysis.Activity
+	for _, a := range fetched {
+		l := a.StartDateLocal
+		if d := time.Date(l.Year(), l.Month(), l.Day(), 0, 0, 0, 0, start.Location()); !d.Before(start) && d.Before(end) {
+			acts = append(acts, a)
+		}
+	}
+	slices.SortFunc(acts, func(a, b analysis.Activity) int { return a.StartDate.Compare(b.StartDate) })
+	if week.Bounds, err = heartRateZones(cmd, api); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: no zone distribution: %v\n", err)
+	}
+
+	keys := []string{"time", "distance", "heartrate", "watts", "altitude"}
+	if !redact["gps"] {
+		keys = append(keys, "latlng")
+	}
+	var charts []bundleFile
+	week.Zones = make([][]int, len(acts))
+	for i, a := range acts {
+		prefix := fmt.Sprintf("charts/%02d-%s-%s", i+1, a.StartDateLocal.Format("2006-01-02"), strings.ToLower(a.SportType))
+		var streams *analysis.Streams
+		if !a.Manual {
+			fmt.Fprintf(os.Stderr, "Charting %s %s\n", a.StartDateLocal.Format("Mon 2 Jan"), a.SportType)
+			if streams, err = fetchStreams(cmd, api, a.ID, keys...); err != nil {
+				if cmd.Context().Err() != nil {
+					return cmd.Context().Err()
+				}
+				fmt.Fprintf(os.Stderr, "Warning: activity %d: no charts: %v\n", a.ID, err)
+			}
+		}
+		if streams != nil && len(week.Bounds) > 0 && len(streams.Heartrate) > 0 {
+			week.Zones[i] = analysis.TimeInZones(streams, week.Bounds)
+		}
+		files, err := coachCharts(prefix, a, streams, !redact["gps"])
+		if err != nil {
+			return err
+		}
+		charts = append(charts, files...)
+	}
+
+	for i := range acts {
+		a := &acts[i]
+		if redact["gps"] {
+			a.StartLatlng, a.EndLatlng = nil, nil
+			a.Map.SummaryPolyline = ""
+		}
+		if redact["names"] {
+			a.Name = a.SportType + " " + a.StartDateLocal.Format("Monday")
+		}
+		if redact["ids"] {
+			a.ID, a.ExternalID = 0, ""
+		}
+		if redact["gear"] {
+			a.GearID = ""
+		}
+	}
+	week.Activities = acts
+
+	printer := newPrinter()
+	render := func(name string, print func(*output.Printer, output.CoachWeek) error) (bundleFile, error) {
+		var b bytes.Buffer
+		p := output.New(&b, false)
+		p.Units = printer.Units
+		err := print(p, week)
+		return bundleFile{Name: name, Data: b.Bytes()}, err
+	}
+	summary, err := render("summary.csv", (*output.Printer).CoachSummaryCSV)
+	if err != nil {
+		return err
+	}
+	files := []bundleFile{summary}
+	if len(week.Bounds) > 0 {
+		f, err := render("zones.csv", (*output.Printer).CoachZonesCSV)
+		if err != nil {
+			return err
+		}
+		files = append(files, f)
+	}
+	files = append(files, charts...)
+	for _, f := range files {
+		week.Files = append(week.Files, f.Name)
+	}
+	readme, err := render("README.md", (*output.Printer).CoachReadme)
+	if err != nil {
+		return err
+	}
+	if err := writeZip(outPath, append([]bundleFile{readme}, files...)); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stdout, "Wrote %s: %s, %d activities, %d charts\n", outPath, week.Week, len(acts), len(charts))
+	return nil
+}
+
+// coachCharts draws an activity's heart rate, power and elevation charts, and
+// its map when withMap is set, as PNG files named prefix-<chart>.png.
+func coachCharts(prefix string, a analysis.Activity, s *analysis.Streams, withMap bool) ([]bundleFile, error) {
+	var files []bundleFile
+	add := func(name string, img image.Image) error {
+		var b bytes.Buffer
+		if err := png.Encode(&b, img); err != nil {
+			return fmt.Errorf("render %s: %w", name, err)
+		}
+		files = append(files, bundleFile{Name: prefix + "-" + name + ".png", Data: b.Bytes()})
+		return nil
+	}
+	// overTime returns a stream against elapsed seconds, with zero (no
+	// reading) as a gap.
+	overTime := func(v []int) (x, y []float64, ok bool) {
+		if len(v) == 0 || len(v) != len(s.Time) {
+			return nil, nil, false
+		}
+		for i, t := range s.Time {
+			x = append(x, float64(t))
+			y = append(y, math.NaN())
+			if v[i] > 0 {
+				y[i], ok = float64(v[i]), true
+			}
+		}
+		return x, y, ok
+	}
+	if s == nil {
+		s = &analysis.Streams{}
+	}
+	if x, y, ok := overTime(s.Heartrate); ok {
+		if err := add("heartrate", plot.Line(x, y, shareMapWidth, shareProfileHeight)); err != nil {
+			return nil, err
+		}
+	}
+	if x, y, ok := overTime(s.Watts); ok {
+		if err := add("power", plot.Line(x, y, shareMapWidth, shareProfileHeight)); err != nil {
+			return nil, err
+		}
+	}
+	if len(s.Altitude) >= 2 && len(s.Distance) == len(s.Altitude) {
+		if err := add("elevation", plot.Profile(s.Distance, s.Altitude, shareMapWidth, shareProfileHeight)); err != nil {
+			return nil, err
+		}
+	}
+	if withMap {
+		var route []geo.Point
+		for _, ll := range s.Latlng {
+			route = append(route, geo.Point{Lat: ll[0], Lng: ll[1]})
+		}
+		if len(route) < 2 {
+			route = geo.DecodePolyline(a.Map.SummaryPolyline)
+		}
+		if len(route) >= 2 {
+			if err := add("map", plot.Map(route, shareMapWidth, shareMapHeight)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+)
+
+// exitInterrupted is the exit status of a command stopped by Ctrl-C, as a
+// shell reports for one killed by SIGINT.
+const exitInterrupted = 130
+
+// interrupted is set once Ctrl-C (or SIGTERM) has cancelled the command.
+var interrupted atomic.Bool
+
+// interruptContext returns a context that Ctrl-C or SIGTERM cancels, so the
+// command stops its requests, saves what it has done, and says how to
+// resume, instead of dying mid-write. A second Ctrl-C quits at once. The
+// returned func stops listening.
+func interruptContext() (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-sigs:
+			signal.Stop(sigs) // the next one gets the default handling
+			interrupted.Store(true)
+			fmt.Fprintln(os.Stderr, "\nInterrupted: stopping and saving progress (Ctrl-C again to quit now)")
+			cancel()
+		case <-done:
+		}
+	}()
+	return ctx, func() {
+		close(done)
+		signal.Stop(sigs)
+		cancel()
+	}
+}
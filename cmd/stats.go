@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/analysis"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/output"
+)
+
+var (
+	advancedSport  string
+	advancedBucket float64
+	advancedAfter  string
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Statistics computed from your full activity history",
+	Long: `Statistics computed from your full activity history.
+
+For Strava's own recent/year-to-date/all-time totals use: strava athlete stats`,
+}
+
+var statsAdvancedCmd = &cobra.Command{
+	Use:   "advanced",
+	Short: "Eddington number, activity streaks and distance distribution",
+	Long: `Compute statistics Strava does not show:
+
+  Eddington number  the largest E such that you have covered at least E km
+                    (or miles with --units imperial) on E different days
+  Streaks           current and longest runs of consecutive days with an activity
+  Distribution      histogram of activity distances in --bucket sized bins
+
+Activities on the same local day are added together for the Eddington number.
+The Eddington number is usually tracked per sport, e.g. --sport Ride.
+
+Examples:
+  strava stats advanced
+  strava stats advanced --sport Ride --units imperial
+  strava stats advanced --sport Run --bucket 2 --json`,
+	Args: cobra.NoArgs,
+	RunE: runStatsAdvanced,
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+	statsCmd.AddCommand(statsAdvancedCmd)
+
+	statsAdvancedCmd.Flags().StringVar(&advancedSport, "sport", "", "Only count this sport type (e.g. Run, Ride)")
+	statsAdvancedCmd.RegisterFlagCompletionFunc("sport", completeSportTypes)
+	statsAdvancedCmd.Flags().Float64Var(&advancedBucket, "bucket", 5, "Histogram bin width in km (or miles)")
+	statsAdvancedCmd.Flags().StringVar(&advancedAfter, "after", "", "Only consider activities after this date (YYYY-MM-DD)")
+}
+
+func runStatsAdvanced(cmd *cobra.Command, args []string) error {
+	after, err := parseDate("after", advancedAfter)
+	if err != nil {
+		return err
+	}
+	api, _, err := apiClient(cmd)
+	if err != nil {
+		return err
+	}
+	acts, err := fetchActivities(cmd, api, after, time.Time{})
+	if err != nil {
+		return err
+	}
+
+	printer := newPrinter()
+	unit := 1000.0
+	if printer.Units == output.Imperial {
+		unit = 1609.344
+	}
+	// start_date_local carries local wall-clock time with a Z suffix, so "today"
+	// must be expressed the same way.
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return printer.AdvancedStats(analysis.ComputeAdvanced(acts, advancedSport, unit, advancedBucket, today))
+}
@@ -0,0 +1,276 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/analysis"
+	genclient "github.com/Brainsoft-Raxat/strava-cli/internal/client"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/log"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/store"
+)
+
+// syncLog records syncs and their post-sync hooks.
+var syncLog = log.New("sync")
+
+var (
+	syncFull         bool
+	syncDescriptions int
+)
+
+// defaultDescriptions is how many descriptions a bare --descriptions fetches:
+// one request each, kept under Strava's 100 requests per 15 minutes.
+const defaultDescriptions = 90
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Copy your activity history to the local store",
+	Long: `Copy your activity summaries into a local store, so commands such as
+"activities search" can work over your whole history without paging through
+the API each time.
+
+The first run fetches everything; later runs fetch only activities that
+started after the newest one already stored. Pages are saved as they
+arrive, so an interrupted sync picks up where it stopped when run again.
+Use --full to refetch every summary, picking up renamed, edited and
+deleted activities.
+
+The activity list does not include descriptions. --descriptions fetches
+them for stored activities that have none yet, newest first, at one API
+request per activity (90 per run by default; pass --descriptions=N to
+change that). Run it again later to continue. A description is fetched
+again only when the activity's summary (name, sport, distance, times, gear
+and so on) has changed since, as a --full sync finds out; otherwise runs
+once everything is fetched make no detail requests at all.
+
+Once personal records are kept (see "strava prs"), sync also finds the best
+efforts of the activities it fetched and prints any new records. It also
+purges other athletes' data older than retention-days (see "strava cache
+purge").
+
+With a post-sync hook set ("strava config set hooks.post-sync <program>"),
+every sync that stores new activities, this command or one a report does
+before reading the history, runs the program with their summaries as a JSON
+array on stdin. Its environment has STRAVA_HOOK=post-sync and
+STRAVA_NEW_ACTIVITIES=<count>, and its output goes to stderr. A failing
+hook is reported but does not fail the sync. The activities of a hook that
+fails or is interrupted are handed to it again by the next sync, with that
+sync's new ones, so a hook may see an activity more than once.
+
+Examples:
+  strava sync
+  strava sync --descriptions
+  strava sync --full --descriptions=500`,
+	Args: cobra.NoArgs,
+	RunE: runSync,
+}
+
+func init() {
+	rootCmd.AddCommand(syncCmd)
+	syncCmd.Flags().BoolVar(&syncFull, "full", false, "Refetch every activity summary and drop deleted activities")
+	syncCmd.Flags().IntVar(&syncDescriptions, "descriptions", 0, "Also fetch up to this many missing or out-of-date descriptions (one request each)")
+	syncCmd.Flags().Lookup("descriptions").NoOptDefVal = fmt.Sprint(defaultDescriptions)
+	detachable(syncCmd)
+}
+
+func runSync(cmd *cobra.Command, args []string) error {
+	api, _, err := apiClient(cmd)
+	if err != nil {
+		return err
+	}
+	hist, err := store.OpenActivities("")
+	if err != nil {
+		return err
+	}
+	syncLog.Info("sync started", "full", syncFull, "stored", hist.Len())
+	added, removed, err := syncSummaries(cmd, api, hist, syncFull)
+	if err != nil {
+		syncLog.Error("sync failed", "added", added, "error", err)
+		return err
+	}
+	syncLog.Info("sync finished", "added", added, "removed", removed, "stored", hist.Len())
+	fmt.Fprintf(stdout(), "Synced %d new activities (%d removed, %d stored)\n", added, removed, hist.Len())
+	if err := syncRecords(cmd, api, hist); err != nil {
+		return err
+	}
+	if n, err := purgeExpiredOthers(false, time.Now()); err != nil {
+		return err
+	} else if n > 0 {
+		fmt.Fprintf(os.Stderr, "Purged %d club feed entries older than retention-days\n", n)
+	}
+	if syncDescriptions == 0 {
+		return nil
+	}
+	fetched, err := syncActivityDescriptions(cmd, api, hist, syncDescriptions)
+	// Keep what was fetched even if the run stopped early.
+	if serr := hist.Save(); err == nil {
+		err = serr
+	}
+	if err != nil {
+		syncLog.Error("descriptions failed", "fetched", fetched, "error", err)
+		return fmt.Errorf("%w\n  %d descriptions were saved; run strava sync --descriptions again to continue", err, fetched)
+	}
+	syncLog.Info("descriptions fetched", "fetched", fetched, "missing", hist.MissingDetails())
+	fmt.Fprintf(stdout(), "Fetched %d descriptions (%d still missing or out of date)\n", fetched, hist.MissingDetails())
+	return nil
+}
+
+// syncSummaries stores every activity that started after the newest stored
+// one (or every activity, when full is set), saving after each page. Given
+// an after time, /athlete/activities returns the oldest activities first, so
+// a sync that fails part way leaves the store consistent and the next run
+// resumes from the last page saved. A full sync also removes stored
+// activities the API no longer lists. The activities saved as new, even by
+// a sync that fails part way, are handed to the post-sync hook.
+func syncSummaries(cmd *cobra.Command, api *genclient.ClientWithResponses, hist *store.Activities, full bool) (added, removed int, err error) {
+	var fresh []json.RawMessage
+	saved := 0 // of fresh
+	defer func() { runPostSyncHook(cmd, fresh[:saved]) }()
+	var after time.Time
+	if !full {
+		if after, err = hist.Latest(); err != nil {
+			return 0, 0, err
+		}
+	}
+	params := &genclient.GetLoggedInAthleteActivitiesParams{
+		After:   intPtr(0),
+		PerPage: intPtr(historyPageSize),
+	}
+	if !after.IsZero() {
+		params.After = intPtr(int(after.Unix()))
+	}
+	seen := map[int64]bool{}
+	var storeErr error // from storing a page rather than fetching one
+	_, err = fetchPages(cmd.Context(), 1, historyPageSize, func(ctx context.Context, page int) ([]json.RawMessage, error) {
+		fmt.Fprintf(os.Stderr, "Fetching activities (page %d)\n", page)
+		params := *params
+		params.Page = intPtr(page)
+		return fetchActivityPage(ctx, api, &params)
+	}, func(page int, batch []json.RawMessage) error {
+		syncLog.Debug("page fetched", "page", page, "activities", len(batch))
+		for _, raw := range batch {
+			id, isNew, err := hist.Put(raw)
+			if err != nil {
+				storeErr = err
+				return err
+			}
+			seen[id] = true
+			if isNew {
+				added++
+				fresh = append(fresh, raw)
+			}
+		}
+		if len(batch) < historyPageSize {
+			return nil
+		}
+		if err := hist.Save(); err != nil {
+			storeErr = err
+			return err
+		}
+		saved = len(fresh)
+		return nil
+	})
+	if storeErr != nil {
+		return added, 0, storeErr
+	}
+	if err != nil {
+		if added > 0 {
+			if serr := hist.Save(); serr != nil {
+				return added, 0, serr
+			}
+			saved = len(fresh)
+		}
+		return added, 0, fmt.Errorf("%w\n  %d new activities were saved; run strava sync again to continue", err, added)
+	}
+	if full {
+		for _, id := range hist.IDs() {
+			if !seen[id] {
+				hist.Remove(id)
+				removed++
+			}
+		}
+	}
+	hist.SyncedAt = time.Now().UTC()
+	if err := hist.Save(); err != nil {
+		return added, removed, err
+	}
+	saved = len(fresh)
+	return added, removed, nil
+}
+
+// syncRecords updates the personal records kept by "prs", if any, with the
+// best efforts of the synced activities not yet scanned, and prints those
+// that are new.
+func syncRecords(cmd *cobra.Command, api *genclient.ClientWithResponses, hist *store.Activities) error {
+	records, err := store.OpenRecords("")
+	if err != nil || !records.Tracked() {
+		return err
+	}
+	acts, err := storedActivities(hist, time.Time{}, time.Time{})
+	if err != nil {
+		return err
+	}
+	bests, err := bestEfforts(cmd, api, acts)
+	if err != nil {
+		return err
+	}
+	prs := records.Update(analysis.PersonalRecords(acts, bests))
+	if err := records.Save(); err != nil {
+		return err
+	}
+	newPrinter().NewRecords(prs)
+	return nil
+}
+
+// syncActivityDescriptions fetches the descriptions of up to limit stored
+// activities that have none yet, or whose summary changed since theirs was
+// fetched (see store.Activities.NeedsDetails), newest first, saving as it
+// goes. The caller saves the rest.
+func syncActivityDescriptions(cmd *cobra.Command, api *genclient.ClientWithResponses, hist *store.Activities, limit int) (int, error) {
+	items, err := hist.List()
+	if err != nil {
+		return 0, err
+	}
+	fetched := 0
+	for _, raw := range items {
+		if fetched >= limit {
+			break
+		}
+		var s struct {
+			ID   int64  `json:"id"`
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return fetched, fmt.Errorf("parse synced activity: %w", err)
+		}
+		if !hist.NeedsDetails(s.ID) {
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "Fetching description for %d (%s)\n", s.ID, s.Name)
+		resp, err := api.GetActivityByIdWithResponse(cmd.Context(), s.ID,
+			&genclient.GetActivityByIdParams{IncludeAllEfforts: boolPtr(false)})
+		if err != nil {
+			return fetched, fmt.Errorf("fetch activity %d: %w", s.ID, err)
+		}
+		switch {
+		case resp.HTTPResponse.StatusCode == 404:
+			// Deleted since it was synced.
+			hist.Remove(s.ID)
+			continue
+		case resp.HTTPResponse.StatusCode != 200 || resp.JSON200 == nil:
+			return fetched, fmt.Errorf("fetch activity %d: %w", s.ID, apiError(resp.HTTPResponse.StatusCode, resp.Body))
+		}
+		hist.SetDescription(s.ID, derefStr(resp.JSON200.Description))
+		// Save as we go so a rate-limited run does not repeat its requests.
+		if fetched++; fetched%20 == 0 {
+			if err := hist.Save(); err != nil {
+				return fetched, err
+			}
+		}
+	}
+	return fetched, nil
+}
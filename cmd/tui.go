@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	genclient "github.com/Brainsoft-Raxat/strava-cli/internal/client"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/analysis"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/output"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/tui"
+)
+
+var tuiCmd = &cobra.Command{
+	Use:     "tui",
+	Aliases: []string{"browse"},
+	Short:   "Browse your activities interactively",
+	Long: `Open a full-screen browser of your activities.
+
+Move through the list with the arrow keys (or j/k); more activities are
+loaded as you scroll. For the selected activity:
+
+  enter, d   details (as in: strava activities get)
+  l          laps
+  c          stream chart; press c again to cycle altitude, heart rate,
+             power and pace
+  esc        back to the list
+  q          quit
+
+Distances follow --units.`,
+	Args: cobra.NoArgs,
+	RunE: runTUI,
+}
+
+func init() {
+	rootCmd.AddCommand(tuiCmd)
+}
+
+func runTUI(cmd *cobra.Command, args []string) error {
+	if jsonOutput || templateFlag != "" {
+		return fmt.Errorf("tui is interactive and does not support --json or --template; use strava activities list instead")
+	}
+	if err := requireInteractive("tui", "use strava activities list / get instead"); err != nil {
+		return err
+	}
+	api, _, err := apiClient(cmd)
+	if err != nil {
+		return err
+	}
+	src := &tuiSource{api: api, units: newPrinter().Units}
+	return tui.Run(cmd.Context(), src, newPrinter().ActivityRow)
+}
+
+// tuiSource serves the browser from the API, rendering detail views with the
+// same printers the CLI commands use.
+type tuiSource struct {
+	api   *genclient.ClientWithResponses
+	units output.Units
+}
+
+// render runs fn against a text printer and returns what it wrote.
+func (s *tuiSource) render(fn func(p *output.Printer) error) (string, error) {
+	var buf bytes.Buffer
+	p := output.New(&buf, false)
+	p.Units = s.units
+	if err := fn(p); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func (s *tuiSource) Activities(ctx context.Context, page int) ([]analysis.Activity, error) {
+	resp, err := s.api.GetLoggedInAthleteActivitiesWithResponse(ctx, &genclient.GetLoggedInAthleteActivitiesParams{
+		Page:    intPtr(page),
+		PerPage: intPtr(tui.PageSize),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fetch activities: %w", err)
+	}
+	if resp.HTTPResponse.StatusCode != 200 {
+		return nil, apiError(resp.HTTPResponse.StatusCode, resp.Body)
+	}
+	var acts []analysis.Activity
+	if err := json.Unmarshal(resp.Body, &acts); err != nil {
+		return nil, fmt.Errorf("parse activities: %w", err)
+	}
+	return acts, nil
+}
+
+func (s *tuiSource) Detail(ctx context.Context, id int64) (string, error) {
+	resp, err := s.api.GetActivityByIdWithResponse(ctx, id,
+		&genclient.GetActivityByIdParams{IncludeAllEfforts: boolPtr(false)})
+	if err != nil {
+		return "", fmt.Errorf("fetch activity: %w", err)
+	}
+	if resp.HTTPResponse.StatusCode != 200 {
+		return "", apiError(resp.HTTPResponse.StatusCode, resp.Body)
+	}
+	return s.render(func(p *output.Printer) error { return p.Activity(resp) })
+}
+
+func (s *tuiSource) Laps(ctx context.Context, id int64) (string, error) {
+	resp, err := s.api.GetLapsByActivityIdWithResponse(ctx, id)
+	if err != nil {
+		return "", fmt.Errorf("fetch laps: %w", err)
+	}
+	if resp.HTTPResponse.StatusCode != 200 {
+		return "", apiError(resp.HTTPResponse.StatusCode, resp.Body)
+	}
+	sport, _ := activitySport(ctx, s.api, id)
+	return s.render(func(p *output.Printer) error { return p.Laps(resp, sport) })
+}
+
+func (s *tuiSource) Chart(ctx context.Context, id int64, metric string) (string, error) {
+	key := chartStreamKeys[metric]
+	params := &genclient.GetActivityStreamsParams{KeyByType: true}
+	for _, k := range []string{key, "distance", "time"} {
+		params.Keys = append(params.Keys, genclient.GetActivityStreamsParamsKeys(k))
+	}
+	resp, err := s.api.GetActivityStreamsWithResponse(ctx, id, params)
+	if err != nil {
+		return "", fmt.Errorf("fetch streams: %w", err)
+	}
+	if resp.HTTPResponse.StatusCode != 200 {
+		return "", apiError(resp.HTTPResponse.StatusCode, resp.Body)
+	}
+	streams, err := analysis.ParseStreams(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	series := chartSeries(metric, streams)
+	if len(series.Values) == 0 {
+		return fmt.Sprintf("No %s stream recorded for this activity.", metric), nil
+	}
+	return s.render(func(p *output.Printer) error { return p.Chart("", series) })
+}
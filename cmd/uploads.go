@@ -1,15 +1,25 @@
 package cmd
 
 import (
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/fs"
+	"mime/multipart"
 	"net/http"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/fsutil"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/output"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/store"
 )
 
 // uploadStatus mirrors the Strava Upload object returned by POST /uploads and
@@ -23,6 +33,19 @@ type uploadStatus struct {
 	ActivityID *int64  `json:"activity_id"`
 }
 
+// uploadRequest describes one file to send to POST /uploads.
+type uploadRequest struct {
+	Path        string
+	DataType    string // fit, fit.gz, tcx, tcx.gz, gpx or gpx.gz
+	Name        string
+	Description string
+	Trainer     bool
+	Commute     bool
+	ExternalID  string
+	Compress    bool                    // gzip the file before sending; see gzipWorthwhile
+	Progress    func(sent, total int64) // optional; called as the file is read
+}
+
 var uploadsCmd = &cobra.Command{
 	Use:   "uploads",
 	Short: "Upload status commands",
@@ -35,9 +58,36 @@ var uploadsGetCmd = &cobra.Command{
 	RunE:  runUploadsGet,
 }
 
+var uploadsResumeClear bool
+
+var uploadsResumeCmd = &cobra.Command{
+	Use:   "resume",
+	Short: "Retry uploads cut short by a lost connection or Ctrl-C",
+	Long: `Finish the uploads that "activities upload" (with --file or --dir) did not
+complete: files that could not be sent, and uploads sent but not yet
+processed by Strava when the command stopped.
+
+Each upload is queued in ~/.config/strava-cli/upload-queue.json before it
+is sent and leaves the queue once Strava has processed it. A file whose
+earlier attempt may have reached Strava is checked for before it is sent
+again. Files deleted or changed since they were queued are dropped.
+
+Examples:
+  strava uploads resume --dry-run    # list the queue
+  strava uploads resume --yes
+  strava uploads resume --clear      # forget the queued uploads`,
+	Args: cobra.NoArgs,
+	RunE: runUploadsResume,
+}
+
 func init() {
 	rootCmd.AddCommand(uploadsCmd)
 	uploadsCmd.AddCommand(uploadsGetCmd)
+	uploadsCmd.AddCommand(uploadsResumeCmd)
+
+	uploadsResumeCmd.Flags().BoolVar(&uploadsResumeClear, "clear", false, "Empty the queue instead of uploading")
+	uploadsResumeCmd.Flags().Bool("yes", false, "Skip interactive confirmation")
+	uploadsResumeCmd.Flags().Bool("dry-run", false, "List the queued uploads without calling the API")
 }
 
 func runUploadsGet(cmd *cobra.Command, args []string) error {
@@ -54,13 +104,351 @@ func runUploadsGet(cmd *cobra.Command, args []string) error {
 		return err
 	}
 	if jsonOutput {
-		fmt.Fprintln(os.Stdout, string(raw))
+		fmt.Fprintln(stdout(), string(raw))
 		return nil
 	}
-	printUploadStatus(os.Stdout, u)
+	printUploadStatus(stdout(), u)
 	return nil
 }
 
+// inferDataType maps a file name to the Strava upload data_type.
+func inferDataType(path string) (string, error) {
+	base := strings.ToLower(path)
+	for _, dt := range []string{"fit.gz", "tcx.gz", "gpx.gz", "fit", "tcx", "gpx"} {
+		if strings.HasSuffix(base, "."+dt) {
+			return dt, nil
+		}
+	}
+	return "", fmt.Errorf("cannot infer data type from %q", path)
+}
+
+// postUpload streams the file described by r to POST /uploads and returns the
+// initial upload status plus the raw response body.
+func postUpload(ctx context.Context, httpClient *http.Client, r uploadRequest) (uploadStatus, []byte, error) {
+	src, name := r.Path, filepath.Base(r.Path)
+	if r.Compress && !strings.HasSuffix(r.DataType, ".gz") {
+		gz, err := gzipToTemp(r.Path)
+		if err != nil {
+			return uploadStatus{}, nil, err
+		}
+		defer os.Remove(gz)
+		src, name, r.DataType = gz, name+".gz", r.DataType+".gz"
+	}
+	info, err := os.Stat(src)
+	if err != nil {
+		return uploadStatus{}, nil, fmt.Errorf("open file: %w", err)
+	}
+	body := &uploadBody{req: r, src: src, name: name, size: info.Size(), boundary: multipart.NewWriter(io.Discard).Boundary()}
+	length, err := body.contentLength()
+	if err != nil {
+		return uploadStatus{}, nil, err
+	}
+	rc, err := body.open()
+	if err != nil {
+		return uploadStatus{}, nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		"https://www.strava.com/api/v3/uploads", rc)
+	if err != nil {
+		rc.Close()
+		return uploadStatus{}, nil, fmt.Errorf("build request: %w", err)
+	}
+	// The body is streamed from disk; GetBody re-opens the file so the retrying
+	// transport can resend it after a 429/5xx.
+	req.GetBody = body.open
+	req.ContentLength = length
+	req.Header.Set("Content-Type", "multipart/form-data; boundary="+body.boundary)
+
+	// A large file can take longer than the client's overall timeout to send;
+	// the context still bounds the request.
+	uploadClient := *httpClient
+	uploadClient.Timeout = 0
+
+	resp, err := uploadClient.Do(req)
+	if err != nil {
+		return uploadStatus{}, nil, fmt.Errorf("upload: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return uploadStatus{}, nil, apiError(resp.StatusCode, respBody)
+	}
+
+	var u uploadStatus
+	if err := json.Unmarshal(respBody, &u); err != nil {
+		return uploadStatus{}, nil, fmt.Errorf("parse response: %w", err)
+	}
+	return u, respBody, nil
+}
+
+// uploadBody produces the multipart/form-data body for an upload without
+// holding the file in memory. Every call to open streams a fresh copy from disk.
+type uploadBody struct {
+	req      uploadRequest
+	src      string // file to send: req.Path, or its compressed copy
+	name     string // file name in the form
+	size     int64
+	boundary string
+}
+
+// open starts writing the multipart body into a pipe and returns its read end.
+func (b *uploadBody) open() (io.ReadCloser, error) {
+	f, err := os.Open(b.src)
+	if err != nil {
+		return nil, fmt.Errorf("open file: %w", err)
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		defer f.Close()
+		var src io.Reader = f
+		if b.req.Progress != nil {
+			src = &progressReader{r: f, total: b.size, fn: b.req.Progress}
+		}
+		pw.CloseWithError(b.write(pw, src))
+	}()
+	return pr, nil
+}
+
+// contentLength computes the exact body size by writing the form with an empty
+// file part, so the request is not sent with chunked encoding.
+func (b *uploadBody) contentLength() (int64, error) {
+	var cw countingWriter
+	if err := b.write(&cw, strings.NewReader("")); err != nil {
+		return 0, err
+	}
+	return int64(cw) + b.size, nil
+}
+
+// write encodes the form fields and the file part read from file into w.
+func (b *uploadBody) write(w io.Writer, file io.Reader) error {
+	mw := multipart.NewWriter(w)
+	if err := mw.SetBoundary(b.boundary); err != nil {
+		return fmt.Errorf("set boundary: %w", err)
+	}
+	part, err := mw.CreateFormFile("file", b.name)
+	if err != nil {
+		return fmt.Errorf("create form file: %w", err)
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return fmt.Errorf("read file: %w", err)
+	}
+	fields := [][2]string{{"data_type", b.req.DataType}}
+	if b.req.Name != "" {
+		fields = append(fields, [2]string{"name", b.req.Name})
+	}
+	if b.req.Description != "" {
+		fields = append(fields, [2]string{"description", b.req.Description})
+	}
+	if b.req.Trainer {
+		fields = append(fields, [2]string{"trainer", "1"})
+	}
+	if b.req.Commute {
+		fields = append(fields, [2]string{"commute", "1"})
+	}
+	if b.req.ExternalID != "" {
+		fields = append(fields, [2]string{"external_id", b.req.ExternalID})
+	}
+	for _, f := range fields {
+		if err := mw.WriteField(f[0], f[1]); err != nil {
+			return fmt.Errorf("write form field %s: %w", f[0], err)
+		}
+	}
+	return mw.Close()
+}
+
+// gzipMinSize is the size from which GPX and TCX files are compressed before
+// upload. XML shrinks to a tenth or less; smaller files are quick to send as
+// they are.
+const gzipMinSize = 1 << 20
+
+// gzipWorthwhile reports whether a file of data type dt is worth compressing
+// before upload: an uncompressed GPX or TCX file of gzipMinSize or more. FIT
+// files are binary and compress little.
+func gzipWorthwhile(path, dt string) bool {
+	if dt != "gpx" && dt != "tcx" {
+		return false
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.Size() >= gzipMinSize
+}
+
+// gzipToTemp compresses the file at path into a temporary file and returns
+// its path; the caller removes it.
+func gzipToTemp(path string) (string, error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("open file: %w", err)
+	}
+	defer in.Close()
+	out, err := fsutil.CreateTemp("upload-*.gz")
+	if err != nil {
+		return "", fmt.Errorf("compress %s: %w", filepath.Base(path), err)
+	}
+	zw := gzip.NewWriter(out)
+	_, err = io.Copy(zw, in)
+	if cerr := zw.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(out.Name())
+		return "", fmt.Errorf("compress %s: %w", filepath.Base(path), err)
+	}
+	return out.Name(), nil
+}
+
+// countingWriter discards writes and counts the bytes.
+type countingWriter int64
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	*c += countingWriter(len(p))
+	return len(p), nil
+}
+
+// progressReader reports cumulative bytes read to fn.
+type progressReader struct {
+	r     io.Reader
+	n     int64
+	total int64
+	fn    func(sent, total int64)
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.n += int64(n)
+	if n > 0 {
+		p.fn(p.n, p.total)
+	}
+	return n, err
+}
+
+// uploadProgress returns a Progress callback that prints the percentage sent
+// to stderr in 10% steps. Small files are not worth reporting.
+func uploadProgress(name string) func(sent, total int64) {
+	const minSize = 5 << 20
+	last := -1
+	return func(sent, total int64) {
+		if total < minSize {
+			return
+		}
+		pct := int(sent * 100 / total)
+		if pct/10 == last/10 && pct != 100 {
+			return
+		}
+		if pct < last {
+			fmt.Fprintln(os.Stderr, "  retrying upload")
+		}
+		last = pct
+		fmt.Fprintf(os.Stderr, "  %s: %3d%% of %.1f MB sent\n", name, pct, float64(total)/(1<<20))
+	}
+}
+
+// uploadExternalID derives a stable external_id from a file's content hash, so
+// a re-submitted file can be recognised both by Strava and by findExistingUpload.
+func uploadExternalID(hash string) string {
+	return "stravacli-" + hash[:16]
+}
+
+// duplicateRe matches Strava's duplicate-upload error, which links the original
+// activity: "x.fit duplicate of <a href='/activities/123'>Morning Run</a>".
+var duplicateRe = regexp.MustCompile(`(?i)duplicate of\b.*?(?:/activities/|activity )(\d+)`)
+
+// duplicateActivityID extracts the original activity ID from a duplicate error.
+func duplicateActivityID(msg string) (int64, bool) {
+	m := duplicateRe.FindStringSubmatch(msg)
+	if m == nil {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(m[1], 10, 64)
+	return id, err == nil
+}
+
+// ledgerEntryFor converts an upload status into a ledger entry for path. A
+// duplicate error is resolved to the activity the file was first uploaded as.
+func ledgerEntryFor(path string, u uploadStatus) store.LedgerEntry {
+	e := store.LedgerEntry{Path: path, UploadID: u.ID}
+	if u.ActivityID != nil {
+		e.ActivityID = *u.ActivityID
+	}
+	if u.Error != nil {
+		e.Error = stripHTML(*u.Error)
+		if id, ok := duplicateActivityID(*u.Error); ok {
+			e.ActivityID = id
+		}
+	}
+	return e
+}
+
+// recentActivityWindow is how many of the latest activities are checked for a
+// matching external_id before uploading.
+const recentActivityWindow = 50
+
+// findExistingUpload reports whether the file with the given content hash has
+// already been sent to Strava. The upload ledger is consulted first (following
+// up on uploads that were still processing); otherwise the most recent
+// activities are searched for externalID, and a match is added to the ledger.
+// The returned entry has ActivityID set, or only UploadID if Strava is still
+// processing the earlier upload.
+func findExistingUpload(ctx context.Context, httpClient *http.Client, ledger *store.Ledger, path, hash, externalID string) (store.LedgerEntry, bool, error) {
+	if e, ok := ledger.Lookup(hash); ok {
+		switch {
+		case e.ActivityID != 0:
+			return e, true, nil
+		case e.Error == "" && e.UploadID != 0:
+			u, _, err := fetchUploadStatus(ctx, httpClient, e.UploadID)
+			if err != nil {
+				return e, false, err
+			}
+			if u.ActivityID != nil {
+				e.ActivityID = *u.ActivityID
+				return e, true, ledger.Record(hash, e)
+			}
+			if u.Error == nil {
+				return e, true, nil // still processing
+			}
+			if id, ok := duplicateActivityID(*u.Error); ok {
+				e.ActivityID = id
+				return e, true, ledger.Record(hash, e)
+			}
+			// The earlier attempt failed; a new upload is safe.
+		}
+	}
+
+	url := fmt.Sprintf("https://www.strava.com/api/v3/athlete/activities?per_page=%d", recentActivityWindow)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return store.LedgerEntry{}, false, fmt.Errorf("build request: %w", err)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return store.LedgerEntry{}, false, fmt.Errorf("fetch recent activities: %w", err)
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return store.LedgerEntry{}, false, apiError(resp.StatusCode, raw)
+	}
+	var recent []struct {
+		ID         int64  `json:"id"`
+		ExternalID string `json:"external_id"`
+	}
+	if err := json.Unmarshal(raw, &recent); err != nil {
+		return store.LedgerEntry{}, false, fmt.Errorf("parse recent activities: %w", err)
+	}
+	for _, a := range recent {
+		// Strava may append the file extension to the external_id it stores.
+		if a.ExternalID != "" && strings.HasPrefix(a.ExternalID, externalID) {
+			e := store.LedgerEntry{Path: path, ActivityID: a.ID}
+			return e, true, ledger.Record(hash, e)
+		}
+	}
+	return store.LedgerEntry{}, false, nil
+}
+
 // fetchUploadStatus calls GET /uploads/{id} and returns the parsed status plus the
 // raw response body (so callers can pass it through in --json mode).
 func fetchUploadStatus(ctx context.Context, httpClient *http.Client, id int64) (uploadStatus, []byte, error) {
@@ -98,8 +486,38 @@ func printUploadStatus(w io.Writer, u uploadStatus) {
 }
 
 // pollUpload polls GET /uploads/{id} every 3 seconds until processing completes,
-// an error is reported by Strava, or a 5-minute timeout is reached.
-func pollUpload(cmd *cobra.Command, httpClient *http.Client, id int64) error {
+// an error is reported by Strava, or a 5-minute timeout is reached. The final
+// status is printed and returned.
+func pollUpload(cmd *cobra.Command, httpClient *http.Client, id int64) (uploadStatus, error) {
+	fmt.Fprintf(os.Stderr, "Polling upload %d (Ctrl-C to cancel, check later with: strava uploads get %d)\n", id, id)
+
+	u, raw, err := awaitUpload(cmd.Context(), httpClient, id, func(u uploadStatus) {
+		fmt.Fprintf(os.Stderr, "  still processing: %s\n", u.Status)
+	})
+	if err != nil && cmd.Context().Err() != nil {
+		return u, fmt.Errorf("interrupted; Strava keeps processing the upload, check it with: strava uploads get %d", id)
+	}
+	if err != nil {
+		return u, err
+	}
+	if jsonOutput {
+		fmt.Fprintln(os.Stdout, string(raw))
+	} else {
+		printUploadStatus(os.Stdout, u)
+	}
+	if u.Error != nil {
+		return u, fmt.Errorf("upload failed: %s", stripHTML(*u.Error))
+	}
+	if !jsonOutput {
+		fmt.Fprintln(os.Stderr, "Done.")
+	}
+	return u, nil
+}
+
+// awaitUpload polls GET /uploads/{id} every 3 seconds until Strava reports an
+// activity ID or an error, calling progress after each still-processing poll.
+// It gives up after 5 minutes.
+func awaitUpload(ctx context.Context, httpClient *http.Client, id int64, progress func(uploadStatus)) (uploadStatus, []byte, error) {
 	const (
 		pollInterval = 3 * time.Second
 		timeout      = 5 * time.Minute
@@ -108,38 +526,413 @@ func pollUpload(cmd *cobra.Command, httpClient *http.Client, id int64) error {
 	ticker := time.NewTicker(pollInterval)
 	defer ticker.Stop()
 
-	fmt.Fprintf(os.Stderr, "Polling upload %d (Ctrl-C to cancel, check later with: strava uploads get %d)\n", id, id)
-
 	for {
 		select {
-		case <-cmd.Context().Done():
-			return cmd.Context().Err()
+		case <-ctx.Done():
+			return uploadStatus{}, nil, ctx.Err()
 		case <-ticker.C:
-			u, raw, err := fetchUploadStatus(cmd.Context(), httpClient, id)
+			u, raw, err := fetchUploadStatus(ctx, httpClient, id)
 			if err != nil {
-				return err
+				return uploadStatus{}, nil, err
 			}
-			if u.Error != nil {
-				if jsonOutput {
-					fmt.Fprintln(os.Stdout, string(raw))
-				} else {
-					printUploadStatus(os.Stdout, u)
-				}
-				return fmt.Errorf("upload failed: %s", stripHTML(*u.Error))
+			if u.Error != nil || u.ActivityID != nil {
+				return u, raw, nil
 			}
-			if u.ActivityID != nil {
-				if jsonOutput {
-					fmt.Fprintln(os.Stdout, string(raw))
-				} else {
-					printUploadStatus(os.Stdout, u)
-					fmt.Fprintln(os.Stderr, "Done.")
+			if time.Now().After(deadline) {
+				return uploadStatus{}, nil, fmt.Errorf("upload timed out after %v; check status with: strava uploads get %d", timeout, id)
+			}
+			if progress != nil {
+				progress(u)
+			}
+		}
+	}
+}
+
+// uploadDirFiles lists the activity files in dir, and in its subdirectories
+// when recursive is set, in name order.
+func uploadDirFiles(dir string, recursive bool) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && !recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if _, err := inferDataType(path); err == nil {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", dir, err)
+	}
+	return files, nil
+}
+
+// runUploadDir uploads every activity file in --dir not yet in the ledger.
+// All files are sent first and polled afterwards, so Strava processes them
+// while the rest upload.
+func runUploadDir(cmd *cobra.Command) error {
+	if uploadName != "" || uploadDescription != "" || uploadExternalIDF != "" || uploadDataType != "" {
+		return fmt.Errorf("--name, --description, --external-id and --data-type apply to a single --file, not --dir")
+	}
+	files, err := uploadDirFiles(uploadDir, uploadRecursive)
+	if err != nil {
+		return err
+	}
+	ledger, err := store.OpenLedger("")
+	if err != nil {
+		return err
+	}
+
+	// One result per file, in file order; send and poll refer into it.
+	results := make([]uploadResult, len(files))
+	hashes := make([]string, len(files))
+	var send, poll []int
+	for i, path := range files {
+		results[i].File = path
+		hash, err := store.HashFile(path)
+		if err != nil {
+			results[i].Status, results[i].Error = "failed", err.Error()
+			continue
+		}
+		hashes[i] = hash
+		e, ok := ledger.Lookup(hash)
+		switch {
+		case !ok || uploadForce:
+			results[i].Status = "new"
+			send = append(send, i)
+		case e.ActivityID != 0:
+			results[i].Status, results[i].UploadID, results[i].ActivityID = "duplicate", e.UploadID, e.ActivityID
+		case e.Error == "" && e.UploadID != 0:
+			results[i].Status, results[i].UploadID = "processing", e.UploadID
+			poll = append(poll, i)
+		default:
+			results[i].Status, results[i].Error = "skipped", "failed before: "+e.Error
+		}
+	}
+	if len(send)+len(poll) == 0 {
+		fmt.Fprintf(os.Stderr, "Nothing to upload: %d files in %s, all uploaded before\n", len(files), uploadDir)
+		return printUploadResults(uploadDir, results)
+	}
+
+	if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+		if err := printUploadResults(uploadDir, results); err != nil {
+			return err
+		}
+	}
+	proceed, err := confirmMutation(cmd, fmt.Sprintf("upload %d files from %s", len(send), uploadDir))
+	if err != nil || !proceed {
+		return err
+	}
+	httpClient, _, err := rawClient(cmd)
+	if err != nil {
+		return err
+	}
+	queue, err := store.OpenUploadQueue("")
+	if err != nil {
+		return err
+	}
+
+	// Queue the batch before sending anything, so that an interrupted run
+	// can be picked up by "uploads resume".
+	var items []store.QueuedUpload
+	var queued []*uploadResult
+	for _, i := range append(send, poll...) {
+		abs, err := filepath.Abs(files[i])
+		if err != nil {
+			return err
+		}
+		dt, _ := inferDataType(files[i])
+		item := store.QueuedUpload{
+			Path:       abs,
+			Hash:       hashes[i],
+			DataType:   dt,
+			Trainer:    uploadTrainer,
+			Commute:    uploadCommute,
+			ExternalID: uploadExternalID(hashes[i]),
+			UploadID:   results[i].UploadID,
+			NoGzip:     uploadNoGzip,
+		}
+		if err := queue.Put(item); err != nil {
+			return err
+		}
+		items = append(items, item)
+		queued = append(queued, &results[i])
+	}
+	ctx := cmd.Context()
+	if err := processUploadQueue(ctx, httpClient, ledger, queue, items, queued); err != nil {
+		return err
+	}
+
+	if err := printUploadResults(uploadDir, results); err != nil {
+		return err
+	}
+	if ctx.Err() != nil {
+		return fmt.Errorf("interrupted; run: strava uploads resume")
+	}
+	failed := 0
+	for _, r := range results {
+		if r.Status == "failed" {
+			failed++
+		}
+	}
+	if failed > 0 && len(queue.Items) > 0 {
+		return fmt.Errorf("%d of %d files failed to upload; retry with: strava uploads resume", failed, len(files))
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files failed to upload", failed, len(files))
+	}
+	return nil
+}
+
+// processUploadQueue sends the queued files not sent yet, then waits for
+// Strava to process every sent one; results[i] receives the outcome of
+// items[i]. Each outcome is recorded in the ledger and the file taken off
+// the queue; files that could not be sent, and uploads still processing
+// when ctx ends, stay queued for "uploads resume".
+func processUploadQueue(ctx context.Context, httpClient *http.Client, ledger *store.Ledger, queue *store.UploadQueue,
+	items []store.QueuedUpload, results []*uploadResult) error {
+	var poll []int
+	for n := range items {
+		if items[n].UploadID != 0 {
+			poll = append(poll, n)
+		}
+	}
+	pending, sent := len(items)-len(poll), 0
+	for n := range items {
+		it, r := &items[n], results[n]
+		if it.UploadID != 0 {
+			continue
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		sent++
+		name := filepath.Base(it.Path)
+		fmt.Fprintf(os.Stderr, "[%d/%d] uploading %s\n", sent, pending, name)
+		// An earlier attempt may have reached Strava before the connection
+		// dropped.
+		if it.Attempts > 0 {
+			if e, found, err := findExistingUpload(ctx, httpClient, ledger, it.Path, it.Hash, it.ExternalID); err == nil && found {
+				r.UploadID = e.UploadID
+				if e.ActivityID != 0 {
+					r.Status, r.ActivityID = "duplicate", e.ActivityID
+					if err := queue.Remove(it.Hash); err != nil {
+						return err
+					}
+					continue
 				}
-				return nil
+				it.UploadID = e.UploadID
+				if err := queue.Put(*it); err != nil {
+					return err
+				}
+				r.Status = "processing"
+				poll = append(poll, n)
+				continue
 			}
-			if time.Now().After(deadline) {
-				return fmt.Errorf("upload timed out after %v; check status with: strava uploads get %d", timeout, id)
+		}
+		// Count the attempt first: if the process dies while sending, resume
+		// checks whether the file arrived before sending it again.
+		it.Attempts++
+		if err := queue.Put(*it); err != nil {
+			return err
+		}
+		u, _, err := postUpload(ctx, httpClient, uploadRequest{
+			Path:        it.Path,
+			DataType:    it.DataType,
+			Name:        it.Name,
+			Description: it.Description,
+			Trainer:     it.Trainer,
+			Commute:     it.Commute,
+			ExternalID:  it.ExternalID,
+			Compress:    !it.NoGzip && gzipWorthwhile(it.Path, it.DataType),
+			Progress:    uploadProgress(name),
+		})
+		if err != nil {
+			r.Status, r.Error = "failed", err.Error()
+			it.LastError = err.Error()
+			if err := queue.Put(*it); err != nil {
+				return err
+			}
+			continue
+		}
+		r.UploadID = u.ID
+		if err := ledger.Record(it.Hash, ledgerEntryFor(it.Path, u)); err != nil {
+			return err
+		}
+		if u.Error == nil && u.ActivityID == nil {
+			r.Status = "processing"
+			it.UploadID, it.LastError = u.ID, ""
+			if err := queue.Put(*it); err != nil {
+				return err
 			}
-			fmt.Fprintf(os.Stderr, "  still processing: %s\n", u.Status)
+			poll = append(poll, n)
+			continue
+		}
+		setUploadResult(r, u)
+		if err := queue.Remove(it.Hash); err != nil {
+			return err
+		}
+	}
+
+	for n, i := range poll {
+		if ctx.Err() != nil {
+			break
+		}
+		it, r := &items[i], results[i]
+		r.Status, r.UploadID = "processing", it.UploadID
+		fmt.Fprintf(os.Stderr, "[%d/%d] waiting for %s (upload %d)\n", n+1, len(poll), filepath.Base(it.Path), it.UploadID)
+		u, _, err := awaitUpload(ctx, httpClient, it.UploadID, nil)
+		if err != nil {
+			if ctx.Err() != nil {
+				break // interrupted: still processing, for resume to poll
+			}
+			// The upload stays queued with its ID, so resume polls it again.
+			r.Status, r.Error = "failed", err.Error()
+			it.LastError = err.Error()
+			if err := queue.Put(*it); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := ledger.Record(it.Hash, ledgerEntryFor(it.Path, u)); err != nil {
+			return err
 		}
+		setUploadResult(r, u)
+		if err := queue.Remove(it.Hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runUploadsResume(cmd *cobra.Command, args []string) error {
+	queue, err := store.OpenUploadQueue("")
+	if err != nil {
+		return err
+	}
+	if len(queue.Items) == 0 {
+		fmt.Fprintln(os.Stderr, "Nothing to resume: the upload queue is empty.")
+		return nil
+	}
+	if uploadsResumeClear {
+		proceed, err := confirmMutation(cmd, fmt.Sprintf("forget %d queued uploads", len(queue.Items)))
+		if err != nil || !proceed {
+			return err
+		}
+		return queue.Clear()
+	}
+
+	var items []store.QueuedUpload
+	var queued []*uploadResult
+	var results []uploadResult
+	for _, it := range queue.Items {
+		r := uploadResult{File: it.Path, Status: "new", UploadID: it.UploadID, Error: it.LastError}
+		if it.UploadID != 0 {
+			r.Status = "processing"
+		} else if hash, err := store.HashFile(it.Path); err != nil || hash != it.Hash {
+			r.Status, r.Error = "failed", "deleted or changed since it was queued; upload it again"
+		}
+		results = append(results, r)
+	}
+	if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+		return printUploadResults("", results)
+	}
+	proceed, err := confirmMutation(cmd, fmt.Sprintf("resume %d queued uploads", len(queue.Items)))
+	if err != nil || !proceed {
+		return err
 	}
+	httpClient, _, err := rawClient(cmd)
+	if err != nil {
+		return err
+	}
+	ledger, err := store.OpenLedger("")
+	if err != nil {
+		return err
+	}
+
+	snapshot := append([]store.QueuedUpload(nil), queue.Items...)
+	for i, it := range snapshot {
+		if results[i].Status == "failed" {
+			if err := queue.Remove(it.Hash); err != nil {
+				return err
+			}
+			continue
+		}
+		results[i].Error = ""
+		items = append(items, it)
+		queued = append(queued, &results[i])
+	}
+	ctx := cmd.Context()
+	if err := processUploadQueue(ctx, httpClient, ledger, queue, items, queued); err != nil {
+		return err
+	}
+	if err := printUploadResults("", results); err != nil {
+		return err
+	}
+	if ctx.Err() != nil {
+		return fmt.Errorf("interrupted; run: strava uploads resume")
+	}
+	if n := len(queue.Items); n > 0 {
+		return fmt.Errorf("%d of %d uploads still queued; run: strava uploads resume", n, len(results))
+	}
+	return nil
+}
+
+// setUploadResult records a processed upload's outcome in r.
+func setUploadResult(r *uploadResult, u uploadStatus) {
+	e := ledgerEntryFor(r.File, u)
+	r.ActivityID, r.Error = e.ActivityID, e.Error
+	switch {
+	case u.Error != nil && e.ActivityID != 0:
+		r.Status = "duplicate"
+	case u.Error != nil:
+		r.Status = "failed"
+	default:
+		r.Status = "uploaded"
+	}
+}
+
+// printUploadResults prints one row per file, named relative to dir, and a
+// count per status.
+func printUploadResults(dir string, results []uploadResult) error {
+	if jsonOutput {
+		if results == nil {
+			results = []uploadResult{}
+		}
+		return output.PrintJSON(os.Stdout, results)
+	}
+	counts := map[string]int{}
+	fmt.Fprintf(os.Stdout, "%-36s  %-10s  %12s  %s\n", "FILE", "STATUS", "ACTIVITY", "DETAIL")
+	fmt.Fprintln(os.Stdout, strings.Repeat("─", 80))
+	for _, r := range results {
+		counts[r.Status]++
+		activity := ""
+		if r.ActivityID != 0 {
+			activity = strconv.FormatInt(r.ActivityID, 10)
+		}
+		detail := r.Error
+		if r.Status == "processing" && detail == "" {
+			detail = fmt.Sprintf("check later with: strava uploads get %d", r.UploadID)
+		}
+		name := r.File
+		if rel, err := filepath.Rel(dir, r.File); err == nil {
+			name = rel
+		}
+		row := fmt.Sprintf("%-36s  %-10s  %12s  %s", name, r.Status, activity, detail)
+		fmt.Fprintln(os.Stdout, strings.TrimRight(row, " "))
+	}
+	var parts []string
+	for _, s := range []string{"new", "uploaded", "duplicate", "processing", "skipped", "failed"} {
+		if counts[s] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[s], s))
+		}
+	}
+	fmt.Fprintf(os.Stdout, "\n%d files: %s\n", len(results), strings.Join(parts, ", "))
+	return nil
 }
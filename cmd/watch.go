@@ -0,0 +1,460 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/cobra"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/analysis"
+	genclient "github.com/Brainsoft-Raxat/strava-cli/internal/client"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/log"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/store"
+)
+
+// watchLog records what a folder watch found and uploaded.
+var watchLog = log.New("watch")
+
+var (
+	watchUpload      bool
+	watchOnce        bool
+	watchInterval    time.Duration
+	watchSettle      time.Duration
+	watchGearID      string
+	watchHide        bool
+	watchCommute     bool
+	watchTrainer     bool
+	watchName        string
+	watchDescription string
+	watchFormWarn    float64
+	watchOnFatigue   string
+)
+
+// fatigueHistory is how far back the history behind form warnings reaches,
+// long enough for the 42-day fitness average to settle.
+const fatigueHistory = 180 * 24 * time.Hour
+
+var uploadsWatchCmd = &cobra.Command{
+	Use:   "watch <dir>",
+	Short: "Watch a directory and upload new activity files",
+	Long: `Watch a directory (for example a mounted bike computer, or a folder a head
+unit syncs to) for new FIT, TCX or GPX files and upload them to Strava. It
+runs until interrupted, so it can be left running as a service.
+
+Changes are picked up as they happen; the directory is also rescanned every
+--interval, for file systems that do not report changes (network mounts).
+Files modified within --settle are skipped until the device has finished
+writing them.
+
+Files are identified by the SHA-256 of their contents and recorded in
+~/.config/strava-cli/uploads.json, so a file is never uploaded twice even if it
+is renamed or copied again.
+
+Without --upload the command only reports which files it would upload.
+--name and --description set the new activity's title and description;
+"{file}" in them is replaced by the file name without extension. Once Strava
+has processed an upload, --gear-id and --hide are applied to the new
+activity (the upload endpoint does not accept them).
+
+--form-warn turns on fatigue warnings: after each upload, training form
+(fitness minus fatigue, from the last six months of activities) is
+recomputed, and when the new activity takes it below the threshold a
+warning is printed and the --on-fatigue command, if any, is run with
+STRAVA_ACTIVITY_ID, STRAVA_FORM, STRAVA_FITNESS and STRAVA_FATIGUE in its
+environment. Form is worked out as "strava fitness" does, from each
+activity's power, else heart rate, else moving time; around -30 and
+below, a recovery day is due.
+
+Examples:
+  strava uploads watch ~/Garmin/Activities
+  strava uploads watch /media/EDGE/Garmin/Activities --upload --gear-id b12345
+  strava uploads watch ~/Dropbox/Wahoo --upload --name "Ride ({file})"
+  strava uploads watch ~/Garmin/Activities --upload --once
+  strava uploads watch ~/Garmin/Activities --upload --form-warn -30 --on-fatigue 'notify-send "Rest day"'`,
+	Args: cobra.ExactArgs(1),
+	RunE: runWatchFolder,
+}
+
+// watchFolderCmd is uploads watch under its first name, kept out of the
+// command list.
+var watchFolderCmd = &cobra.Command{
+	Use:    "watch-folder <dir>",
+	Short:  "Watch a directory and upload new activity files (same as uploads watch)",
+	Long:   uploadsWatchCmd.Long,
+	Hidden: true,
+	Args:   cobra.ExactArgs(1),
+	RunE:   runWatchFolder,
+}
+
+func init() {
+	uploadsCmd.AddCommand(uploadsWatchCmd)
+	rootCmd.AddCommand(watchFolderCmd)
+	addWatchFlags(uploadsWatchCmd)
+	addWatchFlags(watchFolderCmd)
+}
+
+func addWatchFlags(c *cobra.Command) {
+	c.Flags().BoolVar(&watchUpload, "upload", false, "Upload new files (default: only report them)")
+	c.Flags().BoolVar(&watchOnce, "once", false, "Scan the directory once and exit")
+	c.Flags().DurationVar(&watchInterval, "interval", 30*time.Second, "How often to rescan the directory")
+	c.Flags().DurationVar(&watchSettle, "settle", 10*time.Second,
+		"Ignore files modified more recently than this")
+	c.Flags().StringVar(&watchGearID, "gear-id", "", "Gear ID to set on each uploaded activity")
+	c.Flags().BoolVar(&watchHide, "hide", false, "Hide uploaded activities from the home feed")
+	c.Flags().BoolVar(&watchCommute, "commute", false, "Mark uploaded activities as commutes")
+	c.Flags().BoolVar(&watchTrainer, "trainer", false, "Mark uploaded activities as indoor trainer")
+	c.Flags().StringVar(&watchName, "name", "", `Activity name; "{file}" is replaced by the file name`)
+	c.Flags().StringVar(&watchDescription, "description", "", `Activity description; "{file}" is replaced by the file name`)
+	c.Flags().Float64Var(&watchFormWarn, "form-warn", 0, "Warn when an upload takes training form below this (negative, e.g. -30)")
+	c.Flags().StringVar(&watchOnFatigue, "on-fatigue", "", "Shell command to run on a form warning")
+	c.RegisterFlagCompletionFunc("gear-id", completeGearIDs)
+}
+
+// uploadResult is the outcome for one file of watch-folder (one JSON object
+// per line in --json mode) or upload --dir.
+type uploadResult struct {
+	File       string `json:"file"`
+	Status     string `json:"status"` // new, uploaded, duplicate, processing, skipped, failed
+	UploadID   int64  `json:"upload_id,omitempty"`
+	ActivityID int64  `json:"activity_id,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// folderWatcher holds the state shared across scans of one directory.
+type folderWatcher struct {
+	dir        string
+	httpClient *http.Client
+	api        *genclient.ClientWithResponses // for form warnings; nil when off
+	cmd        *cobra.Command
+	ledger     *store.Ledger
+	seen       map[string]time.Time // path → mod time already handled this session
+}
+
+func runWatchFolder(cmd *cobra.Command, args []string) error {
+	dir := args[0]
+	if info, err := os.Stat(dir); err != nil {
+		return fmt.Errorf("watch folder: %w", err)
+	} else if !info.IsDir() {
+		return fmt.Errorf("watch folder: %s is not a directory", dir)
+	}
+
+	ledger, err := store.OpenLedger("")
+	if err != nil {
+		return err
+	}
+	if watchFormWarn > 0 {
+		return fmt.Errorf("--form-warn must be negative, e.g. -30")
+	}
+	if watchOnFatigue != "" && watchFormWarn == 0 {
+		return fmt.Errorf("--on-fatigue needs --form-warn")
+	}
+	// Watching runs for hours and must see each change, so responses are
+	// not reused.
+	genclient.SetMemo(nil)
+	w := &folderWatcher{dir: dir, ledger: ledger, cmd: cmd, seen: map[string]time.Time{}}
+	if watchUpload {
+		if w.httpClient, _, err = rawClient(cmd); err != nil {
+			return err
+		}
+		if watchFormWarn < 0 {
+			if w.api, _, err = apiClient(cmd); err != nil {
+				return err
+			}
+		}
+	}
+
+	ctx := cmd.Context() // cancelled by Ctrl-C
+
+	if watchOnce {
+		if err := w.scan(ctx); err != nil && ctx.Err() == nil {
+			return err
+		}
+		return nil
+	}
+
+	// A change in the directory schedules a scan once the file has had time
+	// to settle; the periodic rescan catches what notifications miss.
+	var events <-chan fsnotify.Event
+	var watchErrs <-chan error
+	fsw, err := fsnotify.NewWatcher()
+	if err == nil {
+		if err = fsw.Add(dir); err != nil {
+			fsw.Close()
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: cannot watch %s for changes (%v); rescanning every %v only\n", dir, err, watchInterval)
+		watchLog.Warn("cannot watch for changes", "dir", dir, "error", err)
+	} else {
+		defer fsw.Close()
+		events, watchErrs = fsw.Events, fsw.Errors
+	}
+	rescan := time.NewTicker(watchInterval)
+	defer rescan.Stop()
+	settled := time.NewTimer(watchSettle)
+	settled.Stop()
+
+	fmt.Fprintf(os.Stderr, "Watching %s (Ctrl-C to stop)\n", dir)
+	watchLog.Info("watch started", "dir", dir, "upload", watchUpload)
+	for {
+		if err := w.scan(ctx); err != nil {
+			if ctx.Err() != nil {
+				watchLog.Info("watch stopped", "dir", dir)
+				return nil
+			}
+			watchLog.Error("watch failed", "dir", dir, "error", err)
+			return err
+		}
+	wait:
+		for {
+			select {
+			case <-ctx.Done():
+				watchLog.Info("watch stopped", "dir", dir)
+				return nil
+			case <-rescan.C:
+				break wait
+			case <-settled.C:
+				break wait
+			case ev := <-events:
+				if ev.Op&(fsnotify.Create|fsnotify.Write|fsnotify.Rename) != 0 {
+					settled.Reset(watchSettle + time.Second)
+				}
+			case err := <-watchErrs:
+				fmt.Fprintf(os.Stderr, "warning: watch %s: %v\n", dir, err)
+				watchLog.Warn("watch error", "dir", dir, "error", err)
+			}
+		}
+	}
+}
+
+// scan handles every settled, not-yet-uploaded activity file in the directory.
+func (w *folderWatcher) scan(ctx context.Context) error {
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", w.dir, err)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		path := filepath.Join(w.dir, e.Name())
+		dt, err := inferDataType(path)
+		if err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		if handled, ok := w.seen[path]; ok && handled.Equal(info.ModTime()) {
+			continue
+		}
+		if time.Since(info.ModTime()) < watchSettle {
+			continue // still being written
+		}
+
+		hash, err := store.HashFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+			continue
+		}
+		if _, ok := w.ledger.Lookup(hash); ok {
+			w.seen[path] = info.ModTime()
+			continue
+		}
+
+		if !watchUpload {
+			w.seen[path] = info.ModTime()
+			printWatchResult(uploadResult{File: path, Status: "new"})
+			continue
+		}
+
+		res, err := w.upload(ctx, path, dt, hash)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			// Network and API errors are retried on the next scan.
+			fmt.Fprintf(os.Stderr, "warning: %s: %v\n", filepath.Base(path), err)
+			watchLog.Warn("upload failed, retrying on the next scan", "file", path, "error", err)
+			continue
+		}
+		w.seen[path] = info.ModTime()
+		watchLog.Info("file handled", "file", path, "status", res.Status,
+			"upload_id", res.UploadID, "activity_id", res.ActivityID, "upload_error", res.Error)
+		printWatchResult(res)
+		if res.Status == "uploaded" && w.api != nil {
+			if err := w.checkForm(ctx, res.ActivityID); err != nil && ctx.Err() == nil {
+				fmt.Fprintf(os.Stderr, "warning: form check after activity %d: %v\n", res.ActivityID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// upload sends one file, waits for Strava to process it, records the outcome
+// in the ledger and applies post-upload enrichment.
+func (w *folderWatcher) upload(ctx context.Context, path, dt, hash string) (uploadResult, error) {
+	res := uploadResult{File: path}
+
+	externalID := uploadExternalID(hash)
+	if e, found, err := findExistingUpload(ctx, w.httpClient, w.ledger, path, hash, externalID); err != nil {
+		return res, err
+	} else if found && e.ActivityID != 0 {
+		res.Status, res.UploadID, res.ActivityID = "duplicate", e.UploadID, e.ActivityID
+		return res, nil
+	} else if found {
+		return res, fmt.Errorf("upload %d is still processing", e.UploadID)
+	}
+
+	fmt.Fprintf(os.Stderr, "AUDIT: upload %s (data_type=%s)\n", filepath.Base(path), dt)
+	watchLog.Info("uploading", "file", path, "data_type", dt)
+	u, _, err := postUpload(ctx, w.httpClient, uploadRequest{
+		Path:        path,
+		DataType:    dt,
+		Name:        expandFileName(watchName, path),
+		Description: expandFileName(watchDescription, path),
+		Trainer:     watchTrainer,
+		Commute:     watchCommute,
+		ExternalID:  externalID,
+		Compress:    gzipWorthwhile(path, dt),
+	})
+	if err != nil {
+		return res, err
+	}
+	res.UploadID = u.ID
+	if u.Error == nil && u.ActivityID == nil {
+		if u, _, err = awaitUpload(ctx, w.httpClient, u.ID, nil); err != nil {
+			return res, err
+		}
+	}
+
+	// Processing errors (corrupt file, duplicate) are permanent: record them so
+	// the same bytes are not sent again.
+	entry := ledgerEntryFor(path, u)
+	if err := w.ledger.Record(hash, entry); err != nil {
+		return res, err
+	}
+	res.ActivityID, res.Error = entry.ActivityID, entry.Error
+	switch {
+	case u.Error != nil && entry.ActivityID != 0:
+		res.Status = "duplicate"
+		return res, nil // the original activity was enriched when it was uploaded
+	case u.Error != nil:
+		res.Status = "failed"
+	default:
+		res.Status = "uploaded"
+	}
+
+	if res.ActivityID != 0 {
+		if err := w.enrich(ctx, res.ActivityID); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: activity %d uploaded but not updated: %v\n", res.ActivityID, err)
+			watchLog.Warn("activity not updated", "activity_id", res.ActivityID, "error", err)
+		}
+	}
+	return res, nil
+}
+
+// enrich applies the fields that POST /uploads cannot set.
+func (w *folderWatcher) enrich(ctx context.Context, activityID int64) error {
+	body := map[string]any{}
+	if watchGearID != "" {
+		body["gear_id"] = watchGearID
+	}
+	if watchHide {
+		body["hide_from_home"] = true
+	}
+	if len(body) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("marshal body: %w", err)
+	}
+	_, err = putActivity(ctx, w.httpClient, activityID, data)
+	return err
+}
+
+// checkForm recomputes training form with the new activity and warns, and
+// runs the --on-fatigue hook, when the activity takes form below --form-warn.
+// Form that was already below the threshold does not warn again.
+func (w *folderWatcher) checkForm(ctx context.Context, activityID int64) error {
+	acts, err := historyActivities(w.cmd, w.api, time.Now().Add(-fatigueHistory), time.Time{})
+	if err != nil {
+		return err
+	}
+	var without []analysis.Activity
+	for _, a := range acts {
+		if a.ID != activityID {
+			without = append(without, a)
+		}
+	}
+	now, t := time.Now(), thresholds().OrHistory(acts)
+	before, after := analysis.TrainingForm(without, now, t), analysis.TrainingForm(acts, now, t)
+	if before.Form < watchFormWarn || after.Form >= watchFormWarn {
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, "WARNING: form is %.0f after activity %d (fitness %.0f, fatigue %.0f), below %.0f: consider a recovery day\n",
+		after.Form, activityID, after.Fitness, after.Fatigue, watchFormWarn)
+	watchLog.Warn("form below threshold", "activity_id", activityID, "form", after.Form, "threshold", watchFormWarn)
+	if watchOnFatigue == "" {
+		return nil
+	}
+	return runHook(ctx, watchOnFatigue,
+		fmt.Sprintf("STRAVA_ACTIVITY_ID=%d", activityID),
+		fmt.Sprintf("STRAVA_FORM=%.1f", after.Form),
+		fmt.Sprintf("STRAVA_FITNESS=%.1f", after.Fitness),
+		fmt.Sprintf("STRAVA_FATIGUE=%.1f", after.Fatigue))
+}
+
+// runHook runs a user's shell command with extra environment variables, its
+// output going to stderr.
+func runHook(ctx context.Context, command string, env ...string) error {
+	c := exec.CommandContext(ctx, "/bin/sh", "-c", command)
+	if runtime.GOOS == "windows" {
+		c = exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	c.Env = append(os.Environ(), env...)
+	c.Stdout, c.Stderr = os.Stderr, os.Stderr
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("hook %q: %w", command, err)
+	}
+	return nil
+}
+
+// expandFileName replaces "{file}" in s with the base name of path up to its
+// first dot, so "2024-05-01-07-30.fit.gz" gives "2024-05-01-07-30".
+func expandFileName(s, path string) string {
+	name, _, _ := strings.Cut(filepath.Base(path), ".")
+	return strings.ReplaceAll(s, "{file}", name)
+}
+
+func printWatchResult(r uploadResult) {
+	if jsonOutput {
+		data, _ := json.Marshal(r)
+		fmt.Fprintln(os.Stdout, string(data))
+		return
+	}
+	name := filepath.Base(r.File)
+	switch r.Status {
+	case "new":
+		fmt.Fprintf(os.Stdout, "new        %s (run with --upload to send it)\n", name)
+	case "uploaded":
+		fmt.Fprintf(os.Stdout, "uploaded   %s → activity %d\n", name, r.ActivityID)
+	case "duplicate":
+		fmt.Fprintf(os.Stdout, "duplicate  %s: already uploaded as activity %d\n", name, r.ActivityID)
+	default:
+		fmt.Fprintf(os.Stdout, "%-10s %s: %s\n", r.Status, name, r.Error)
+	}
+}
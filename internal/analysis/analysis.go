@@ -0,0 +1,135 @@
+// Package analysis computes training metrics from Strava activities and streams.
+//
+// The types here are deliberately flat, value-typed mirrors of the API payloads
+// so that analytics code does not have to chase the generated client's pointers.
+package analysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Activity is a summary activity as returned by GET /athlete/activities.
+// Fields absent from the payload (e.g. heart rate on activities recorded
+// without a strap) are left at their zero value.
+type Activity struct {
+	ID                   int64     `json:"id"`
+	Name                 string    `json:"name"`
+	SportType            string    `json:"sport_type"`
+	StartDate            time.Time `json:"start_date"`
+	StartDateLocal       time.Time `json:"start_date_local"`
+	Distance             float64   `json:"distance"`
+	MovingTime           int       `json:"moving_time"`
+	ElapsedTime          int       `json:"elapsed_time"`
+	TotalElevationGain   float64   `json:"total_elevation_gain"`
+	AverageSpeed         float64   `json:"average_speed"`
+	MaxSpeed             float64   `json:"max_speed"`
+	AverageHeartrate     float64   `json:"average_heartrate"`
+	MaxHeartrate         float64   `json:"max_heartrate"`
+	AverageWatts         float64   `json:"average_watts"`
+	WeightedAverageWatts float64   `json:"weighted_average_watts"`
+	Kilojoules           float64   `json:"kilojoules"`
+	SufferScore          float64   `json:"suffer_score"` // Relative Effort; absent for some activities
+	GearID               string    `json:"gear_id"`
+	Commute              bool      `json:"commute"`
+	Trainer              bool      `json:"trainer"`
+	Manual               bool      `json:"manual"`
+	Private              bool      `json:"private"`
+	ExternalID           string    `json:"external_id"`
+	StartLatlng          []float64 `json:"start_latlng"`
+	EndLatlng            []float64 `json:"end_latlng"`
+	Map                  struct {
+		SummaryPolyline string `json:"summary_polyline"`
+	} `json:"map"`
+	Class Class             `json:"class,omitempty"` // set locally by --classify, never by the API
+	Meta  map[string]string `json:"meta,omitempty"`  // set locally from "strava meta", never by the API
+}
+
+// Streams holds the per-sample data returned by the streams endpoints with
+// key_by_type=true. Missing streams are nil.
+type Streams struct {
+	Time           []int        `json:"time,omitempty"`
+	Distance       []float64    `json:"distance,omitempty"`
+	Latlng         [][2]float64 `json:"latlng,omitempty"`
+	Altitude       []float64    `json:"altitude,omitempty"`
+	VelocitySmooth []float64    `json:"velocity_smooth,omitempty"`
+	Heartrate      []int        `json:"heartrate,omitempty"`
+	Cadence        []int        `json:"cadence,omitempty"`
+	Watts          []int        `json:"watts,omitempty"`
+	Temp           []int        `json:"temp,omitempty"`
+	Moving         []bool       `json:"moving,omitempty"`
+	GradeSmooth    []float64    `json:"grade_smooth,omitempty"`
+}
+
+// StreamKeys lists every stream type the API can return.
+var StreamKeys = []string{
+	"time", "distance", "latlng", "altitude", "velocity_smooth", "heartrate",
+	"cadence", "watts", "temp", "moving", "grade_smooth",
+}
+
+// Default streams per kind of sport: only what the sport's devices record,
+// so that a swim does not ask for power or a yoga session for distance.
+var (
+	sensorStreamKeys = []string{"time", "distance", "altitude", "heartrate", "cadence", "watts", "velocity_smooth"}
+	footStreamKeys   = []string{"time", "distance", "altitude", "heartrate", "cadence", "velocity_smooth"}
+	waterStreamKeys  = []string{"time", "distance", "heartrate", "velocity_smooth"}
+	gymStreamKeys    = []string{"time", "heartrate"}
+)
+
+// sportStreamKeys maps sport types (lowercased) to their default streams.
+// Rides, runs (which may carry a power meter) and unlisted sports get
+// sensorStreamKeys, the broadest set.
+var sportStreamKeys = map[string][]string{
+	"walk": footStreamKeys, "hike": footStreamKeys, "snowshoe": footStreamKeys,
+	"swim": waterStreamKeys, "rowing": waterStreamKeys, "virtualrow": waterStreamKeys,
+	"canoeing": waterStreamKeys, "kayaking": waterStreamKeys, "standuppaddling": waterStreamKeys,
+	"weighttraining": gymStreamKeys, "workout": gymStreamKeys, "yoga": gymStreamKeys,
+	"crossfit": gymStreamKeys, "pilates": gymStreamKeys, "elliptical": gymStreamKeys,
+	"stairstepper": gymStreamKeys, "highintensityintervaltraining": gymStreamKeys,
+}
+
+// DefaultStreamKeys returns the streams worth requesting for an activity of
+// sport (case-insensitive).
+func DefaultStreamKeys(sport string) []string {
+	if keys, ok := sportStreamKeys[strings.ToLower(sport)]; ok {
+		return keys
+	}
+	return sensorStreamKeys
+}
+
+// ParseStreams decodes a key_by_type streams payload such as
+// {"time": {"data": [...]}, "heartrate": {"data": [...]}}.
+func ParseStreams(body []byte) (*Streams, error) {
+	var raw map[string]struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("parse streams: %w", err)
+	}
+	s := &Streams{}
+	targets := map[string]any{
+		"time":            &s.Time,
+		"distance":        &s.Distance,
+		"latlng":          &s.Latlng,
+		"altitude":        &s.Altitude,
+		"velocity_smooth": &s.VelocitySmooth,
+		"heartrate":       &s.Heartrate,
+		"cadence":         &s.Cadence,
+		"watts":           &s.Watts,
+		"temp":            &s.Temp,
+		"moving":          &s.Moving,
+		"grade_smooth":    &s.GradeSmooth,
+	}
+	for key, v := range raw {
+		dst, ok := targets[key]
+		if !ok || len(v.Data) == 0 {
+			continue
+		}
+		if err := json.Unmarshal(v.Data, dst); err != nil {
+			return nil, fmt.Errorf("parse %s stream: %w", key, err)
+		}
+	}
+	return s, nil
+}
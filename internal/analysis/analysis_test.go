@@ -0,0 +1,936 @@
+package analysis_test
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/analysis"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/geo"
+)
+
+func approx(a, b float64) bool { return math.Abs(a-b) < 1e-6 }
+
+func TestParseStreams(t *testing.T) {
+	s, err := analysis.ParseStreams([]byte(`{
+		"time": {"data": [0, 1, 2]},
+		"heartrate": {"data": [120, 125, 130]},
+		"latlng": {"data": [[51.5, -0.1], [51.6, -0.2], [51.7, -0.3]]},
+		"unknown": {"data": [1]}
+	}`))
+	if err != nil {
+		t.Fatalf("ParseStreams: %v", err)
+	}
+	if len(s.Time) != 3 || s.Heartrate[2] != 130 || s.Latlng[1][0] != 51.6 {
+		t.Errorf("unexpected streams: %+v", s)
+	}
+	if s.Watts != nil {
+		t.Errorf("missing stream should be nil, got %v", s.Watts)
+	}
+}
+
+func TestSplits_Interpolates(t *testing.T) {
+	// Constant 4 m/s with samples every 100 s → 250 s per km.
+	dist := []float64{0, 400, 800, 1200, 1600, 2000, 2400}
+	times := []int{0, 100, 200, 300, 400, 500, 600}
+	got := analysis.Splits(dist, times, 1000, 0)
+	if len(got) != 2 || !approx(got[0], 250) || !approx(got[1], 250) {
+		t.Errorf("Splits = %v, want [250 250]", got)
+	}
+	if got := analysis.Splits(dist, times, 1000, 1); len(got) != 1 {
+		t.Errorf("limit not honoured: %v", got)
+	}
+}
+
+func TestCompareCourse(t *testing.T) {
+	efforts := []analysis.CourseEffort{
+		{ActivityID: 1, Splits: []float64{300, 310, 320}},
+		{ActivityID: 2, Splits: []float64{290, 300}},
+		{ActivityID: 3, Splits: []float64{310, 290, 280}},
+	}
+	c := analysis.CompareCourse(efforts, 1000)
+	if len(c.Best) != 2 {
+		t.Fatalf("expected splits truncated to 2, got %d", len(c.Best))
+	}
+	if c.Efforts[0].ActivityID != 2 || c.Efforts[2].ActivityID != 1 {
+		t.Errorf("unexpected ranking: %+v", c.Efforts)
+	}
+	if !approx(c.Best[0], 290) || !approx(c.Worst[1], 310) || !approx(c.Average[0], 300) {
+		t.Errorf("best=%v worst=%v avg=%v", c.Best, c.Worst, c.Average)
+	}
+}
+
+func TestMatchesCourse(t *testing.T) {
+	start := geo.Point{Lat: 51.5, Lng: -0.1}
+	end := geo.Point{Lat: 51.55, Lng: -0.1}
+	a := analysis.Activity{
+		Distance:    10200,
+		StartLatlng: []float64{51.5005, -0.1},
+		EndLatlng:   []float64{51.5501, -0.1},
+	}
+	if !analysis.MatchesCourse(a, start, end, 10000, 300, 0.1) {
+		t.Error("expected activity to match course")
+	}
+	a.Distance = 15000
+	if analysis.MatchesCourse(a, start, end, 10000, 300, 0.1) {
+		t.Error("distance outside tolerance should not match")
+	}
+	a.Distance = 10000
+	a.StartLatlng = nil
+	if analysis.MatchesCourse(a, start, end, 10000, 300, 0.1) {
+		t.Error("activity without GPS should not match")
+	}
+}
+
+func TestSummarize_WeeksWithGaps(t *testing.T) {
+	day := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return d
+	}
+	acts := []analysis.Activity{
+		{SportType: "Run", StartDateLocal: day("2024-01-01"), Distance: 5000, MovingTime: 1500, TotalElevationGain: 20},
+		{SportType: "Run", StartDateLocal: day("2024-01-07"), Distance: 10000, MovingTime: 3000, TotalElevationGain: 50},
+		{SportType: "Ride", StartDateLocal: day("2024-01-03"), Distance: 40000, MovingTime: 4000},
+		{SportType: "run", StartDateLocal: day("2024-01-17"), Distance: 8000, MovingTime: 2400},
+	}
+
+	got := analysis.Summarize(acts, analysis.Week, "Run")
+	if len(got) != 3 {
+		t.Fatalf("got %d periods, want 3 (including the empty week): %+v", len(got), got)
+	}
+	if got[0].Period != "2024-W01" || got[0].Count != 2 || got[0].Distance != 15000 || got[0].ElevationGain != 70 {
+		t.Errorf("week 1 = %+v", got[0])
+	}
+	if got[1].Period != "2024-W02" || got[1].Count != 0 {
+		t.Errorf("week 2 should be an empty gap, got %+v", got[1])
+	}
+	if got[2].Period != "2024-W03" || got[2].MovingTime != 2400 {
+		t.Errorf("week 3 = %+v", got[2])
+	}
+
+	months := analysis.Summarize(acts, analysis.Month, "")
+	if len(months) != 1 || months[0].Period != "2024-01" || months[0].Count != 4 {
+		t.Errorf("monthly summary = %+v", months)
+	}
+}
+
+func TestParsePeriod(t *testing.T) {
+	if p, err := analysis.ParsePeriod("Month"); err != nil || p != analysis.Month {
+		t.Errorf("ParsePeriod(Month) = %q, %v", p, err)
+	}
+	if _, err := analysis.ParsePeriod("fortnight"); err == nil {
+		t.Error("ParsePeriod(fortnight) should fail")
+	}
+}
+
+func TestParseISOWeek(t *testing.T) {
+	cases := map[string]string{
+		"2025-W14": "2025-03-31",
+		"2025-w01": "2024-12-30", // week 1 can start in the year before
+		"2020-W53": "2020-12-28",
+	}
+	for in, want := range cases {
+		got, err := analysis.ParseISOWeek(in, time.UTC)
+		if err != nil || got.Format("2006-01-02") != want {
+			t.Errorf("ParseISOWeek(%q) = %s, %v; want %s", in, got.Format("2006-01-02"), err, want)
+		}
+	}
+	for _, bad := range []string{"2025-14", "2025-W00", "2025-W53", "W14"} {
+		if _, err := analysis.ParseISOWeek(bad, time.UTC); err == nil {
+			t.Errorf("ParseISOWeek(%q) should fail", bad)
+		}
+	}
+}
+
+func TestEddington(t *testing.T) {
+	tests := []struct {
+		daily    []float64
+		want     int
+		wantNext int
+	}{
+		{nil, 0, 1},
+		{[]float64{0.5}, 0, 1},
+		{[]float64{10, 10, 10}, 3, 1},
+		{[]float64{1, 2, 3, 4, 5}, 3, 2},
+		{[]float64{50, 50, 50, 50, 4.9}, 4, 1},
+	}
+	for _, tc := range tests {
+		e, next := analysis.Eddington(tc.daily)
+		if e != tc.want || next != tc.wantNext {
+			t.Errorf("Eddington(%v) = %d, %d; want %d, %d", tc.daily, e, next, tc.want, tc.wantNext)
+		}
+	}
+}
+
+func TestStreaks(t *testing.T) {
+	d := func(s string) time.Time {
+		t, _ := time.Parse("2006-01-02", s)
+		return t
+	}
+	days := []time.Time{d("2024-03-01"), d("2024-03-02"), d("2024-03-02"), d("2024-03-03"),
+		d("2024-03-10"), d("2024-03-11")}
+
+	current, longest := analysis.Streaks(days, d("2024-03-12"))
+	if longest.Days != 3 || !longest.Start.Equal(d("2024-03-01")) {
+		t.Errorf("longest = %+v, want 3 days from 2024-03-01", longest)
+	}
+	if current.Days != 2 {
+		t.Errorf("current = %+v, want 2 days (ending yesterday)", current)
+	}
+
+	current, _ = analysis.Streaks(days, d("2024-03-20"))
+	if current.Days != 0 {
+		t.Errorf("streak should be broken, got %+v", current)
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	h := analysis.Histogram([]float64{1, 4.9, 5, 12}, 5)
+	if len(h) != 3 {
+		t.Fatalf("got %d buckets, want 3: %+v", len(h), h)
+	}
+	if h[0].Count != 2 || h[1].Count != 1 || h[2].Count != 1 || h[2].Min != 10 {
+		t.Errorf("unexpected histogram: %+v", h)
+	}
+}
+
+func TestWeatherPenalty(t *testing.T) {
+	if s, p := analysis.WeatherPenalty(analysis.Weather{TempC: 10}, "Run"); s != 0 || p != 0 {
+		t.Errorf("neutral still weather = %v/%v, want no penalty", s, p)
+	}
+	// 25°C is 10°C above neutral: 4% for both speed and power.
+	if s, p := analysis.WeatherPenalty(analysis.Weather{TempC: 25}, "Ride"); !approx(s, 0.04) || !approx(p, 0.04) {
+		t.Errorf("heat penalty = %v/%v, want 0.04/0.04", s, p)
+	}
+	// Wind costs speed only, and more on a bike.
+	run, runPower := analysis.WeatherPenalty(analysis.Weather{TempC: 10, WindSpeed: 10}, "Run")
+	ride, _ := analysis.WeatherPenalty(analysis.Weather{TempC: 10, WindSpeed: 10}, "Ride")
+	if !approx(run, 0.05) || runPower != 0 || !(ride > run) {
+		t.Errorf("wind penalty run = %v (power %v), ride = %v", run, runPower, ride)
+	}
+}
+
+func TestWeatherPenalty_Extremes(t *testing.T) {
+	tests := []struct {
+		name string
+		w    analysis.Weather
+	}{
+		{"heat", analysis.Weather{TempC: 200}},
+		{"cold", analysis.Weather{TempC: -200}},
+		{"gale", analysis.Weather{TempC: 10, WindSpeed: 40}},
+		{"heat and gale", analysis.Weather{TempC: 60, WindSpeed: 40}},
+	}
+	for _, tc := range tests {
+		for _, sport := range []string{"Run", "Ride"} {
+			s, p := analysis.WeatherPenalty(tc.w, sport)
+			if s < 0 || s >= 0.5 || p < 0 || p >= 0.5 {
+				t.Errorf("%s %s: penalty = %v/%v, want within [0, 0.5)", tc.name, sport, s, p)
+			}
+		}
+	}
+
+	// Adjusted values stay finite and positive however bad the weather.
+	acts := []analysis.Activity{{ID: 1, SportType: "Ride", StartDateLocal: time.Date(2024, 7, 10, 0, 0, 0, 0, time.UTC),
+		Distance: 40000, MovingTime: 3600, AverageWatts: 200}}
+	rows := analysis.WeatherTrends(acts, map[int64]analysis.Weather{1: {TempC: 300, WindSpeed: 60}}, analysis.Month, "")
+	r := rows[0]
+	if math.IsInf(r.AdjustedSpeed, 0) || r.AdjustedSpeed <= 0 || math.IsInf(r.AdjustedPower, 0) || r.AdjustedPower <= 0 {
+		t.Errorf("extreme weather adjusted to speed %v, power %v", r.AdjustedSpeed, r.AdjustedPower)
+	}
+}
+
+func TestWeatherTrends_AdjustsOnlyActivitiesWithWeather(t *testing.T) {
+	jan := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	jul := time.Date(2024, 7, 10, 0, 0, 0, 0, time.UTC)
+	acts := []analysis.Activity{
+		{ID: 1, SportType: "Run", StartDateLocal: jul, Distance: 10000, MovingTime: 3000},
+		{ID: 2, SportType: "Run", StartDateLocal: jan, Distance: 10000, MovingTime: 3000, AverageWatts: 250},
+		{ID: 3, SportType: "Ride", StartDateLocal: jan, Distance: 40000, MovingTime: 3600},
+	}
+	weather := map[int64]analysis.Weather{1: {TempC: 25}}
+
+	rows := analysis.WeatherTrends(acts, weather, analysis.Month, "run")
+	if len(rows) != 2 || rows[0].Period != "2024-01" || rows[1].Period != "2024-07" {
+		t.Fatalf("periods = %+v, want January and July only", rows)
+	}
+	jan0, jul0 := rows[0], rows[1]
+	if jan0.WithWeather != 0 || !approx(jan0.AdjustedSpeed, jan0.Speed) || jan0.Power != 250 || jan0.AdjustedPower != 250 {
+		t.Errorf("January without weather should be unadjusted: %+v", jan0)
+	}
+	// 4% heat penalty: 10 km in 3000 s adjusts to 10 km in 2880 s.
+	if jul0.WithWeather != 1 || !approx(jul0.AdjustedSpeed, 10000.0/2880) || jul0.AvgTempC != 25 {
+		t.Errorf("July = %+v, want speed adjusted for heat", jul0)
+	}
+}
+
+func TestElevationGain_IgnoresJitter(t *testing.T) {
+	// Climbs of 3 m are noise; the 100→140 climb counts once, then 130→150.
+	ele := []float64{100, 103, 100, 103, 120, 140, 130, 150, 148}
+	if got := analysis.ElevationGain(ele, 5); got != 60 {
+		t.Errorf("ElevationGain = %v, want 60", got)
+	}
+}
+
+func TestElevationAudit_FlagsAndCorrects(t *testing.T) {
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	acts := []analysis.Activity{
+		{ID: 1, StartDateLocal: day, TotalElevationGain: 1000}, // barometer gone wild
+		{ID: 2, StartDateLocal: day, TotalElevationGain: 60},   // 50% off but only 20 m
+		{ID: 3, StartDateLocal: day.AddDate(1, 0, 0), TotalElevationGain: 520},
+	}
+	dems := []float64{400, 40, 500}
+	var checks []analysis.ElevationCheck
+	for i, a := range acts {
+		checks = append(checks, analysis.CheckElevation(a, dems[i], 0.3, 50))
+	}
+	if !checks[0].Outlier || checks[1].Outlier || checks[2].Outlier {
+		t.Fatalf("outliers = %v %v %v, want only the first", checks[0].Outlier, checks[1].Outlier, checks[2].Outlier)
+	}
+	years := analysis.ElevationTotals(checks)
+	if len(years) != 2 || years[0].Year != 2024 || years[1].Year != 2025 {
+		t.Fatalf("years = %+v", years)
+	}
+	if y := years[0]; y.Count != 2 || y.Outliers != 1 || y.Reported != 1060 || y.Corrected != 460 {
+		t.Errorf("2024 = %+v, want reported 1060 corrected 460", y)
+	}
+}
+
+func TestDefaultStreamKeys_BySport(t *testing.T) {
+	has := func(keys []string, k string) bool {
+		for _, v := range keys {
+			if v == k {
+				return true
+			}
+		}
+		return false
+	}
+	if swim := analysis.DefaultStreamKeys("Swim"); has(swim, "watts") || has(swim, "altitude") || !has(swim, "heartrate") {
+		t.Errorf("Swim keys = %v, want heart rate but no power or altitude", swim)
+	}
+	if ride := analysis.DefaultStreamKeys("gravelride"); !has(ride, "watts") || !has(ride, "cadence") {
+		t.Errorf("GravelRide keys = %v, want power and cadence", ride)
+	}
+	if yoga := analysis.DefaultStreamKeys("Yoga"); has(yoga, "distance") {
+		t.Errorf("Yoga keys = %v, want no distance", yoga)
+	}
+}
+
+func TestFilter_Match(t *testing.T) {
+	commute := false
+	f := analysis.Filter{Sports: []string{"run", " TrailRun"}, MinDistance: 10000, Commute: &commute, NameContains: "TEMPO"}
+	cases := []struct {
+		a    analysis.Activity
+		want bool
+	}{
+		{analysis.Activity{SportType: "TrailRun", Distance: 12000, Name: "Hill tempo"}, true},
+		{analysis.Activity{SportType: "Ride", Distance: 12000, Name: "Hill tempo"}, false},
+		{analysis.Activity{SportType: "Run", Distance: 8000, Name: "Short tempo"}, false},
+		{analysis.Activity{SportType: "Run", Distance: 12000, Name: "Tempo", Commute: true}, false},
+		{analysis.Activity{SportType: "Run", Distance: 12000, Name: "Easy"}, false},
+	}
+	for i, c := range cases {
+		if got := f.Match(c.a); got != c.want {
+			t.Errorf("case %d: Match = %v, want %v", i, got, c.want)
+		}
+	}
+	if !(analysis.Filter{}).IsZero() || f.IsZero() {
+		t.Error("IsZero wrong")
+	}
+
+	m := analysis.Filter{Meta: map[string]string{"shoes": "b", "race": ""}}
+	if m.IsZero() {
+		t.Error("IsZero true with Meta set")
+	}
+	for i, c := range []struct {
+		meta map[string]string
+		want bool
+	}{
+		{map[string]string{"shoes": "B", "race": "yes"}, true},
+		{map[string]string{"shoes": "A", "race": "yes"}, false},
+		{map[string]string{"shoes": "B"}, false}, // "race" must be set
+		{nil, false},
+	} {
+		if got := m.Match(analysis.Activity{Meta: c.meta}); got != c.want {
+			t.Errorf("meta case %d: Match = %v, want %v", i, got, c.want)
+		}
+	}
+}
+
+func TestMatchText_EveryWordInSomeText(t *testing.T) {
+	cases := []struct {
+		query string
+		want  bool
+	}{
+		{"tempo", true},
+		{"TEMPO hills", true}, // "hills" only in the description
+		{"tempo track", false},
+		{"  ", false},
+	}
+	for _, c := range cases {
+		if got := analysis.MatchText(c.query, "Morning Tempo", "5x hills after"); got != c.want {
+			t.Errorf("MatchText(%q) = %v, want %v", c.query, got, c.want)
+		}
+	}
+}
+
+func TestGearUsage_TotalsPerGear(t *testing.T) {
+	gear, err := analysis.ParseAthleteGear([]byte(`{
+		"bikes": [{"id": "b1", "name": "Road", "distance": 5000, "primary": true},
+		          {"id": "b2", "name": "MTB", "retired": true}],
+		"shoes": [{"id": "g1", "name": "Pegasus"}]}`))
+	if err != nil {
+		t.Fatalf("ParseAthleteGear: %v", err)
+	}
+	if len(gear) != 3 || gear[1].Kind != analysis.Bike || !gear[1].Retired || gear[2].Kind != analysis.Shoes {
+		t.Fatalf("ParseAthleteGear = %+v", gear)
+	}
+
+	day := func(d int) time.Time { return time.Date(2024, 5, d, 7, 0, 0, 0, time.UTC) }
+	acts := []analysis.Activity{
+		{GearID: "g1", Distance: 10000, MovingTime: 3000, StartDateLocal: day(3)},
+		{GearID: "b1", Distance: 40000, MovingTime: 5000, StartDateLocal: day(2)},
+		{GearID: "g1", Distance: 5000, MovingTime: 1500, StartDateLocal: day(1)},
+		{Distance: 2000, StartDateLocal: day(4)},
+		{GearID: "b9", Distance: 1000, StartDateLocal: day(5)},
+	}
+	rows := analysis.GearUsage(acts, gear)
+	if len(rows) != 4 {
+		t.Fatalf("GearUsage returned %d rows, want 4", len(rows))
+	}
+	if rows[0].GearID != "b1" || rows[1].GearID != "g1" {
+		t.Errorf("rows not sorted by distance: %+v", rows)
+	}
+	g1 := rows[1]
+	if g1.Name != "Pegasus" || g1.Count != 2 || g1.Distance != 15000 || g1.MovingTime != 4500 ||
+		!g1.First.Equal(day(1)) || !g1.Last.Equal(day(3)) {
+		t.Errorf("g1 totals = %+v", g1)
+	}
+	if rows[2].Name != "(no gear)" || rows[3].Name != "b9" {
+		t.Errorf("unnamed gear rows = %+v, %+v", rows[2], rows[3])
+	}
+}
+
+func TestCheckGearAlerts_Levels(t *testing.T) {
+	gear := []analysis.Gear{
+		{ID: "b1", Name: "Road", Distance: 10000e3},
+		{ID: "g1", Name: "Pegasus", Distance: 760e3},
+		{ID: "b2", Name: "Old", Distance: 9000e3, Retired: true},
+	}
+	alerts := []analysis.GearAlert{
+		{GearID: "b1", Part: "chain", Every: 3000e3, Since: 8000e3}, // 2000 of 3000 km
+		{GearID: "g1", Every: 800e3},                                // 760 of 800 km
+		{GearID: "b1", Part: "tires", Every: 4000e3, Since: 5000e3}, // 5000 of 4000 km
+		{GearID: "b2", Every: 1000e3},                               // retired
+		{GearID: "sold", Every: 1000e3},
+	}
+	got := analysis.CheckGearAlerts(gear, alerts)
+	want := []struct {
+		label, level string
+	}{
+		{"b1:tires", analysis.AlertOverdue},
+		{"g1", analysis.AlertDueSoon},
+		{"b1:chain", analysis.AlertOK},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("CheckGearAlerts returned %d alerts, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		if got[i].Label() != w.label || got[i].Level != w.level {
+			t.Errorf("alert %d = %s %s, want %s %s", i, got[i].Label(), got[i].Level, w.label, w.level)
+		}
+	}
+	if got[0].Remaining != -1000e3 || got[0].Name != "Road" {
+		t.Errorf("tires = %+v", got[0])
+	}
+}
+
+func TestRecords_TopNonOverlappingBlocks(t *testing.T) {
+	d := func(s string) time.Time {
+		t, _ := time.Parse("2006-01-02", s)
+		return t
+	}
+	acts := []analysis.Activity{
+		{SportType: "Run", StartDateLocal: d("2024-03-01"), Distance: 10000},
+		{SportType: "Run", StartDateLocal: d("2024-03-03"), Distance: 20000},
+		{SportType: "Run", StartDateLocal: d("2024-03-03"), Distance: 5000},
+		{SportType: "Run", StartDateLocal: d("2024-03-09"), Distance: 8000},
+		{SportType: "Ride", StartDateLocal: d("2024-03-09"), Distance: 90000},
+		{SportType: "Run", StartDateLocal: d("2024-04-20"), Distance: 30000},
+	}
+	got := analysis.Records(acts, analysis.RecordDistance, 7, "run", 5)
+	if len(got) != 3 {
+		t.Fatalf("got %d blocks, want 3: %+v", len(got), got)
+	}
+	// 03-01..03-07 holds 35 km; 03-03..03-09 (33 km) overlaps it and is skipped.
+	if got[0].Value != 35000 || got[0].Count != 3 || !got[0].Start.Equal(d("2024-03-01")) || !got[0].End.Equal(d("2024-03-07")) {
+		t.Errorf("best = %+v, want 35 km over 3 runs from 2024-03-01 to 03-07", got[0])
+	}
+	if got[1].Value != 30000 || got[2].Value != 8000 {
+		t.Errorf("runners-up = %v, %v; want 30000, 8000", got[1].Value, got[2].Value)
+	}
+
+	if got := analysis.Records(acts, analysis.RecordDistance, 7, "", 1); len(got) != 1 || got[0].Value != 123000 {
+		t.Errorf("all sports = %+v, want one block of 123 km", got)
+	}
+}
+
+func TestParseWindow(t *testing.T) {
+	for in, want := range map[string]int{"7d": 7, "30": 30, "4w": 28, " 1D ": 1} {
+		if got, err := analysis.ParseWindow(in); err != nil || got != want {
+			t.Errorf("ParseWindow(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "0d", "-3d", "7m", "week"} {
+		if _, err := analysis.ParseWindow(in); err == nil {
+			t.Errorf("ParseWindow(%q) should fail", in)
+		}
+	}
+}
+
+func TestHeartRateRecovery(t *testing.T) {
+	s := &analysis.Streams{}
+	add := func(secs, hr int, moving bool) {
+		for range secs {
+			s.Time = append(s.Time, len(s.Time))
+			s.Heartrate = append(s.Heartrate, hr)
+			s.Moving = append(s.Moving, moving)
+		}
+	}
+	add(300, 120, true)
+	add(170, 172, true) // hard effort, peaking as it ends
+	add(10, 180, true)
+	add(60, 150, false) // standing recovery
+	add(60, 140, false)
+	add(30, 178, true) // too short to count
+	add(200, 130, true)
+
+	got := analysis.HeartRateRecovery(s, 85, 60)
+	if len(got) != 1 {
+		t.Fatalf("got %d recoveries, want 1: %+v", len(got), got)
+	}
+	r := got[0]
+	if r.Start != 300 || r.End != 479 || r.PeakHR != 180 || r.HR60 != 150 || r.Drop != 30 || !r.Stopped {
+		t.Errorf("recovery = %+v, want effort from 300s, 180 → 150 bpm from 479s, stopped", r)
+	}
+
+	sum := analysis.SummarizeRecovery(got, 85, 60)
+	trend := analysis.RecoveryTrends([]analysis.Activity{
+		{ID: 1, StartDateLocal: time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)},
+		{ID: 2, StartDateLocal: time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC)},
+	}, map[int64]analysis.HRRSummary{1: sum, 2: {Efforts: 3, MeanDrop: 34}}, analysis.Month)
+	if len(trend) != 1 || trend[0].Efforts != 4 || trend[0].MeanDrop != 33 {
+		t.Errorf("trend = %+v, want one month of 4 efforts averaging 33 bpm", trend)
+	}
+}
+
+func TestThresholds_OrHistory(t *testing.T) {
+	acts := []analysis.Activity{{MaxHeartrate: 181}, {MaxHeartrate: 187}, {}}
+	if got := (analysis.Thresholds{FTP: 250}).OrHistory(acts); got.MaxHR != 187 || got.FTP != 250 {
+		t.Errorf("without a max HR: %+v, want the highest in the history", got)
+	}
+	if got := (analysis.Thresholds{MaxHR: 192}).OrHistory(acts); got.MaxHR != 192 {
+		t.Errorf("with a max HR: %+v, want it kept", got)
+	}
+}
+
+func TestTrainingForm_MatchesFitnessSeries(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	th := analysis.Thresholds{FTP: 250, MaxHR: 190}
+	var acts []analysis.Activity
+	stress := map[int64]analysis.Stress{}
+	for d := range 30 {
+		a := analysis.Activity{ID: int64(d), StartDateLocal: start.AddDate(0, 0, d), MovingTime: 3600}
+		switch d % 3 {
+		case 0:
+			a.WeightedAverageWatts = 200
+		case 1:
+			a.AverageHeartrate = 150
+		}
+		acts = append(acts, a)
+		stress[a.ID] = analysis.ActivityStress(a, th)
+	}
+	day := start.AddDate(0, 0, 29)
+	f := analysis.TrainingForm(acts, day, th)
+	days := analysis.FitnessSeries(acts, stress, day, day)
+	if len(days) != 1 || !approx(f.Form, days[0].Form) || !approx(f.Fitness, days[0].Fitness) {
+		t.Errorf("TrainingForm = %+v, FitnessSeries = %+v; want the same model", f, days)
+	}
+}
+
+func TestHeartRateZones(t *testing.T) {
+	if got, want := analysis.HeartRateZones(190), []int{0, 114, 133, 152, 171}; !slices.Equal(got, want) {
+		t.Errorf("HeartRateZones(190) = %v, want %v", got, want)
+	}
+}
+
+func TestTrainingForm_BlockDigsFormBelowZero(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var acts []analysis.Activity
+	for d := range 60 { // steady hour a day, then a week of double days
+		a := analysis.Activity{StartDateLocal: start.AddDate(0, 0, d), MovingTime: 3600}
+		if d >= 53 {
+			a.MovingTime = 7200
+		}
+		acts = append(acts, a)
+	}
+	f := analysis.TrainingForm(acts, start.AddDate(0, 0, 59), analysis.Thresholds{})
+	if f.Fatigue <= f.Fitness || f.Form >= -20 {
+		t.Errorf("form after a hard week = %+v, want fatigue above fitness by over 20", f)
+	}
+	rest := analysis.TrainingForm(acts, start.AddDate(0, 0, 73), analysis.Thresholds{})
+	if rest.Form <= 0 {
+		t.Errorf("form after two weeks off = %+v, want positive", rest)
+	}
+}
+
+func TestSegmentLeaderboard_RanksAndPRs(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 8, 0, 0, 0, time.UTC) }
+	efforts := []analysis.SegmentEffort{
+		{ID: 1, StartDate: day(1), ElapsedTime: 300},
+		{ID: 2, StartDate: day(2), ElapsedTime: 320},
+		{ID: 3, StartDate: day(3), ElapsedTime: 290},
+		{ID: 4, StartDate: day(4), ElapsedTime: 290},
+		{ID: 5, StartDate: day(5), ElapsedTime: 280},
+	}
+	ranked := analysis.RankEfforts(efforts)
+	var ids []int64
+	for _, r := range ranked {
+		ids = append(ids, r.ID)
+	}
+	if want := []int64{5, 3, 4, 1, 2}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("ranking = %v, want %v", ids, want)
+	}
+	if ranked[4].Behind != 40 || ranked[4].Rank != 5 {
+		t.Errorf("last = %+v, want rank 5, 40s behind", ranked[4])
+	}
+
+	prs := analysis.PRProgression(efforts)
+	if len(prs) != 3 || prs[0].ID != 1 || prs[1].ID != 3 || prs[1].Improvement != 10 || prs[2].Improvement != 10 {
+		t.Errorf("PRs = %+v, want efforts 1, 3 (-10s), 5 (-10s)", prs)
+	}
+}
+
+func TestClubLeaderboard_TotalsSinceStart(t *testing.T) {
+	mon := time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+	entries := []analysis.ClubEntry{
+		{Athlete: "Jane D.", SportType: "Run", Distance: 10000, MovingTime: 3000, FirstSeen: mon.Add(time.Hour)},
+		{Athlete: "Jane D.", SportType: "Run", Distance: 5000, MovingTime: 1500, FirstSeen: mon.Add(48 * time.Hour)},
+		{Athlete: "Bob S.", SportType: "Ride", Distance: 40000, MovingTime: 5000, FirstSeen: mon.Add(time.Hour)},
+		{Athlete: "Ann K.", SportType: "Run", Distance: 90000, MovingTime: 9000, FirstSeen: mon.Add(-time.Hour)}, // last week
+	}
+	rows := analysis.ClubLeaderboard(entries, mon, "")
+	if len(rows) != 2 || rows[0].Athlete != "Bob S." || rows[1].Athlete != "Jane D." {
+		t.Fatalf("rows = %+v, want Bob then Jane", rows)
+	}
+	if j := rows[1]; j.Rank != 2 || j.Activities != 2 || j.Distance != 15000 || j.Longest != 10000 {
+		t.Errorf("Jane = %+v", j)
+	}
+	if rows := analysis.ClubLeaderboard(entries, mon, "run"); len(rows) != 1 || rows[0].Athlete != "Jane D." {
+		t.Errorf("runs only = %+v, want Jane alone", rows)
+	}
+}
+
+func TestDigestClub(t *testing.T) {
+	mon := time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+	entries := []analysis.ClubEntry{
+		{Athlete: "Ann K.", SportType: "Run", Distance: 9000, FirstSeen: mon.Add(-time.Hour)}, // last week
+		{Athlete: "Ann K.", Name: "Hill reps", SportType: "TrailRun", Distance: 12000, ElevationGain: 800, FirstSeen: mon.Add(time.Hour)},
+		{Athlete: "Bob S.", Name: "Long one", SportType: "Ride", Distance: 90000, ElevationGain: 600, FirstSeen: mon.Add(2 * time.Hour)},
+		{Athlete: "Cy D.", Name: "Spin", SportType: "VirtualRide", Distance: 30000, FirstSeen: mon.Add(3 * time.Hour)},
+		{Athlete: "Cy D.", SportType: "Ride", Distance: 50000, FirstSeen: mon.Add(8 * 24 * time.Hour)}, // next week
+	}
+	d := analysis.DigestClub(entries, mon, mon.AddDate(0, 0, 7))
+	if d.Activities != 3 || d.Athletes != 3 || d.Distance != 132000 || d.ElevationGain != 1400 {
+		t.Errorf("totals = %d activities, %d athletes, %.0f m, %.0f m up", d.Activities, d.Athletes, d.Distance, d.ElevationGain)
+	}
+	if len(d.Top) != 3 || d.Top[0].Athlete != "Bob S." || d.Top[2].Athlete != "Ann K." {
+		t.Errorf("Top = %+v", d.Top)
+	}
+	if d.LongestRide == nil || d.LongestRide.Name != "Long one" || d.LongestRun == nil || d.LongestRun.Name != "Hill reps" {
+		t.Errorf("LongestRide = %+v, LongestRun = %+v", d.LongestRide, d.LongestRun)
+	}
+	if d.BiggestClimb == nil || d.BiggestClimb.Name != "Hill reps" {
+		t.Errorf("BiggestClimb = %+v", d.BiggestClimb)
+	}
+	if !d.NewcomersKnown || strings.Join(d.Newcomers, ",") != "Bob S.,Cy D." {
+		t.Errorf("Newcomers = %v (known %v), want Bob S., Cy D.", d.Newcomers, d.NewcomersKnown)
+	}
+	if d := analysis.DigestClub(entries[1:], mon, time.Time{}); d.NewcomersKnown || len(d.Newcomers) != 0 || d.Activities != 4 {
+		t.Errorf("without earlier entries: newcomers %v (known %v), %d activities", d.Newcomers, d.NewcomersKnown, d.Activities)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	bounds := []int{0, 120, 150, 165, 180}
+	s := &analysis.Streams{}
+	add := func(secs, hr int) {
+		for range secs {
+			s.Time = append(s.Time, len(s.Time))
+			s.Heartrate = append(s.Heartrate, hr)
+		}
+	}
+	add(1800, 140)
+	add(600, 170)
+	add(1, 140)
+	zones := analysis.TimeInZones(s, bounds)
+	if want := []int{0, 1800, 0, 600, 0}; !reflect.DeepEqual(zones, want) {
+		t.Fatalf("zones = %v, want %v", zones, want)
+	}
+	if got := analysis.Classify(zones); got != analysis.Threshold {
+		t.Errorf("class = %q, want threshold (a quarter in zones 4–5)", got)
+	}
+
+	for _, tc := range []struct {
+		zones []int
+		want  analysis.Class
+	}{
+		{[]int{600, 3000, 200, 100, 0}, analysis.Easy},
+		{[]int{0, 1800, 1200, 0, 0}, analysis.Tempo},
+		{[]int{600, 1200, 600, 300, 400}, analysis.VO2},
+		{[]int{0, 200, 0, 0, 0}, ""},
+	} {
+		if got := analysis.Classify(tc.zones); got != tc.want {
+			t.Errorf("Classify(%v) = %q, want %q", tc.zones, got, tc.want)
+		}
+	}
+
+	day := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+	rows := analysis.SummarizeIntensity([]analysis.Activity{
+		{ID: 1, SportType: "Run", StartDateLocal: day},
+		{ID: 2, SportType: "Run", StartDateLocal: day.AddDate(0, 0, 2)},
+		{ID: 3, SportType: "Ride", StartDateLocal: day.AddDate(0, 0, 3)},
+	}, map[int64]analysis.Classification{
+		1: {Class: analysis.Easy, Zones: []int{600, 3000, 200, 0, 0}},
+		2: {Class: analysis.Threshold, Zones: []int{0, 1800, 0, 600, 0}},
+		3: {Class: analysis.VO2, Zones: []int{0, 0, 0, 0, 600}},
+	}, analysis.Week, "run")
+	if len(rows) != 1 {
+		t.Fatalf("got %d weeks, want 1", len(rows))
+	}
+	r := rows[0]
+	if r.Count != 2 || r.Easy != 1 || r.Threshold != 1 || r.VO2 != 0 || r.LowTime != 5400 || r.HighTime != 800 {
+		t.Errorf("week = %+v, want 2 runs, one easy and one threshold, 5400 s low and 800 s high", r)
+	}
+}
+
+func TestPolarization(t *testing.T) {
+	acts := []analysis.Activity{
+		{ID: 1, SportType: "Run", MovingTime: 3600},
+		{ID: 2, SportType: "Run", MovingTime: 3600},
+		{ID: 3, SportType: "Run", MovingTime: 1800},
+		{ID: 4, SportType: "Run", MovingTime: 1800}, // no heart rate
+	}
+	classes := map[int64]analysis.Classification{
+		1: {Class: analysis.Easy, Zones: []int{1000, 2600, 0, 0, 0}},
+		2: {Class: analysis.Easy, Zones: []int{800, 2700, 100, 0, 0}},
+		3: {Class: analysis.VO2, Zones: []int{300, 700, 100, 300, 400}},
+		4: {},
+	}
+	d := analysis.Polarization(acts, classes, analysis.ByZones, "")
+	if d.Sessions != 3 || d.Low != 8100 || d.Moderate != 200 || d.High != 700 {
+		t.Fatalf("by zones = %+v, want 3 sessions, 8100/200/700 s", d)
+	}
+	if d.Model != "polarized" || d.Index < 2 {
+		t.Errorf("model = %q (index %v), want polarized", d.Model, d.Index)
+	}
+	d = analysis.Polarization(acts, classes, analysis.BySession, "")
+	if d.Low != 7200 || d.Moderate != 0 || d.High != 1800 {
+		t.Errorf("by session = %+v, want 7200/0/1800 s", d)
+	}
+
+	classes[3] = analysis.Classification{Class: analysis.Tempo, Zones: []int{0, 300, 1500, 0, 0}}
+	classes[2] = classes[3]
+	if d := analysis.Polarization(acts, classes, analysis.ByZones, ""); d.Model != "pyramidal" {
+		t.Errorf("mostly low, then moderate: model = %q, want pyramidal", d.Model)
+	}
+}
+
+func TestActivityStress_Sources(t *testing.T) {
+	hour := analysis.Activity{MovingTime: 3600, AverageHeartrate: 60 + 0.8*(171-60), WeightedAverageWatts: 200}
+	th := analysis.Thresholds{FTP: 250, MaxHR: 190}
+	if s := analysis.ActivityStress(hour, th); s.Source != analysis.StressPower || math.Abs(s.TSS-64) > 1e-9 {
+		t.Errorf("with power: %+v, want 64 from power", s)
+	}
+	if s := analysis.ActivityStress(hour, analysis.Thresholds{MaxHR: 190}); s.Source != analysis.StressHeartRate || math.Abs(s.TSS-64) > 1e-9 {
+		t.Errorf("heart rate at 80%% of threshold reserve: %+v, want 64 from heart rate", s)
+	}
+	if s := analysis.ActivityStress(analysis.Activity{MovingTime: 7200}, th); s.Source != analysis.StressEstimate || math.Abs(s.TSS-84.5) > 1e-9 {
+		t.Errorf("no sensors: %+v, want an 84.5 estimate", s)
+	}
+}
+
+func TestStreamStress_IntervalsScoreAboveAverage(t *testing.T) {
+	s := &analysis.Streams{}
+	for i := range 3600 {
+		w := 100
+		if (i/60)%2 == 0 { // minutes alternating 300 W and 100 W
+			w = 300
+		}
+		s.Time, s.Watts = append(s.Time, i), append(s.Watts, w)
+	}
+	th := analysis.Thresholds{FTP: 200}
+	stress, ok := analysis.StreamStress(s, th)
+	if !ok || stress.Source != analysis.StressPower {
+		t.Fatalf("StreamStress = %+v, %v; want from power", stress, ok)
+	}
+	if steady := analysis.StressScore(analysis.Activity{MovingTime: 3600, AverageWatts: 200}, 200); stress.TSS <= steady {
+		t.Errorf("intervals TSS = %.1f, want above %.1f of the same average held steadily", stress.TSS, steady)
+	}
+	if _, ok := analysis.StreamStress(&analysis.Streams{Time: []int{0, 1}}, th); ok {
+		t.Error("streams without power or heart rate: ok, want not")
+	}
+}
+
+func TestFitnessSeries(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	var acts []analysis.Activity
+	stress := map[int64]analysis.Stress{}
+	for d := range 28 {
+		acts = append(acts, analysis.Activity{ID: int64(d), StartDateLocal: start.AddDate(0, 0, d)})
+		stress[int64(d)] = analysis.Stress{TSS: 100}
+	}
+	days := analysis.FitnessSeries(acts, stress, start.AddDate(0, 0, -2), start.AddDate(0, 0, 34))
+	if len(days) != 37 {
+		t.Fatalf("%d days, want 37", len(days))
+	}
+	if d := days[0]; d.Fitness != 0 || d.Activities != 0 {
+		t.Errorf("before the first activity: %+v, want nothing", d)
+	}
+	if d := days[2]; d.TSS != 100 || d.Activities != 1 || d.Form >= 0 {
+		t.Errorf("first day: %+v, want 100 TSS and negative form", d)
+	}
+	if d := days[len(days)-1]; d.TSS != 0 || d.Form <= 0 || analysis.FormZone(d.Form) != "fresh" {
+		t.Errorf("after a week off: %+v, want fresh", d)
+	}
+}
+
+func TestSumZones_SkipsStaleBoundsAndSplitsIntensity(t *testing.T) {
+	bounds := analysis.PowerZones(250)
+	if want := []int{0, 140, 190, 228, 265, 303, 378}; !slices.Equal(bounds, want) {
+		t.Fatalf("PowerZones(250) = %v, want %v", bounds, want)
+	}
+	acts := []analysis.Activity{{ID: 1, SportType: "Ride"}, {ID: 2, SportType: "Ride"}, {ID: 3, SportType: "Run"}, {ID: 4, SportType: "Ride"}}
+	times := map[int64]analysis.ZoneTime{
+		1: {Zones: []int{600, 1800, 0, 300, 0, 0, 0}, Bounds: bounds},
+		2: {Zones: []int{0, 1200, 600, 0, 0, 0, 300}, Bounds: bounds},
+		3: {Zones: []int{0, 3600, 0, 0, 0, 0, 0}, Bounds: bounds},
+		4: {Zones: []int{3600}, Bounds: []int{0}}, // measured against old zones
+	}
+	d := analysis.SumZones("power", acts, times, bounds, "ride")
+	if d.Activities != 2 || len(d.Zones) != 7 {
+		t.Fatalf("SumZones = %+v, want 2 rides over 7 zones", d)
+	}
+	if z := d.Zones[1]; z.Seconds != 3000 || z.Min != 140 || z.Max != 189 || math.Abs(z.Share-0.625) > 1e-9 {
+		t.Errorf("zone 2 = %+v, want 3000 s from 140 to 189 W, 62.5%%", z)
+	}
+	if d.Zones[6].Max != 0 {
+		t.Errorf("top zone max = %d, want open", d.Zones[6].Max)
+	}
+	if i := d.Intensity; i.Low != 3600 || i.Moderate != 600 || i.High != 600 {
+		t.Errorf("intensity = %+v, want 3600 low, 600 moderate, 600 high", i)
+	}
+}
+
+func TestTimeInPowerZones_CountsCoasting(t *testing.T) {
+	s := &analysis.Streams{Time: []int{0, 1, 2, 3}, Watts: []int{0, 150, 300, 300}}
+	if got := analysis.TimeInPowerZones(s, []int{0, 100, 200}); !slices.Equal(got, []int{1, 1, 1}) {
+		t.Errorf("TimeInPowerZones = %v, want a second in each zone", got)
+	}
+}
+
+func TestBestEfforts_FastestKilometerAndPower(t *testing.T) {
+	// 2 km run: the first kilometer at 5 m/s, the second at 4 m/s.
+	s := &analysis.Streams{}
+	for sec, dist := 0, 0.0; dist <= 2000; sec++ {
+		s.Time = append(s.Time, sec)
+		s.Distance = append(s.Distance, dist)
+		s.Watts = append(s.Watts, 200+100*(sec/100%2))
+		if dist < 1000 {
+			dist += 5
+		} else {
+			dist += 4
+		}
+	}
+	b := analysis.BestEfforts(analysis.Activity{SportType: "Run"}, s)
+	if got := b.Times["1k"]; got != 200 {
+		t.Errorf("1k = %d s, want 200", got)
+	}
+	if _, ok := b.Times["5k"]; ok {
+		t.Errorf("5k found in a 2 km run")
+	}
+	if got := b.Powers["1m"]; got != 300 {
+		t.Errorf("1m power = %.0f W, want 300", got)
+	}
+	if got := b.Powers["5m"]; got <= 200 || got >= 300 {
+		t.Errorf("5m power = %.0f W, want between 200 and 300", got)
+	}
+	if b := analysis.BestEfforts(analysis.Activity{SportType: "Ride"}, s); b.Times != nil {
+		t.Errorf("ride has distance efforts: %v", b.Times)
+	}
+}
+
+func TestPersonalRecords(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 5, d, 8, 0, 0, 0, time.UTC) }
+	acts := []analysis.Activity{
+		{ID: 2, Name: "Later", SportType: "Run", StartDate: day(9), StartDateLocal: day(9)},
+		{ID: 1, Name: "Earlier", SportType: "Run", StartDate: day(2), StartDateLocal: day(2)},
+		{ID: 3, Name: "Ride", SportType: "Ride", StartDate: day(3), StartDateLocal: day(3)},
+	}
+	bests := map[int64]analysis.Bests{
+		1: {Times: map[string]int{"1k": 240, "5k": 1300}},
+		2: {Times: map[string]int{"1k": 240, "5k": 1250}, Powers: map[string]float64{"5s": 400}},
+		3: {Powers: map[string]float64{"20m": 250, "5s": 900}},
+	}
+	prs := analysis.PersonalRecords(acts, bests)
+	var got []string
+	for _, p := range prs {
+		got = append(got, fmt.Sprintf("%s %s %d", p.Sport, p.Effort, p.ActivityID))
+	}
+	want := []string{"Ride 5s 3", "Ride 20m 3", "Run 1k 1", "Run 5k 2", "Run 5s 2"}
+	if !slices.Equal(got, want) {
+		t.Errorf("PersonalRecords = %v, want %v", got, want)
+	}
+	faster := analysis.PR{Meters: 1000, Seconds: 230}
+	if !faster.Beats(prs[2]) || prs[2].Beats(faster) {
+		t.Errorf("a faster 1k does not beat %+v", prs[2])
+	}
+}
+
+func TestTrainingCalendar(t *testing.T) {
+	at := func(s string, minutes int, sport string) analysis.Activity {
+		d, _ := time.Parse("2006-01-02", s)
+		return analysis.Activity{StartDateLocal: d.Add(7 * time.Hour), MovingTime: minutes * 60, Distance: float64(minutes) * 200, SportType: sport}
+	}
+	acts := []analysis.Activity{
+		at("2023-12-31", 60, "Run"), // another year
+		at("2024-01-01", 20, "Run"),
+		at("2024-01-02", 40, "Run"),
+		at("2024-01-02", 20, "Ride"),
+		at("2024-01-03", 60, "Run"),
+		at("2024-01-10", 90, "Run"),
+		at("2024-03-01", 30, "Run"), // after today
+	}
+	c := analysis.TrainingCalendar(acts, 2024, analysis.ByTime, "", time.Date(2024, 2, 15, 12, 0, 0, 0, time.UTC))
+	if len(c.Days) != 46 {
+		t.Fatalf("%d days, want 46 (to February 15th)", len(c.Days))
+	}
+	if c.ActiveDays != 4 || c.Activities != 5 || c.MovingTime != 230*60 {
+		t.Errorf("totals = %d days, %d activities, %ds", c.ActiveDays, c.Activities, c.MovingTime)
+	}
+	levels := []int{c.Days[0].Level, c.Days[1].Level, c.Days[2].Level, c.Days[3].Level, c.Days[9].Level}
+	if want := []int{1, 2, 2, 0, 4}; !slices.Equal(levels, want) {
+		t.Errorf("levels = %v, want %v", levels, want)
+	}
+	if c.LongestStreak.Days != 3 {
+		t.Errorf("longest streak = %+v, want 3 days", c.LongestStreak)
+	}
+
+	runs := analysis.TrainingCalendar(acts, 2024, analysis.ByDistance, "run", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	if len(runs.Days) != 366 || runs.Activities != 5 || runs.Days[1].Distance != 40*200 {
+		t.Errorf("runs calendar: %d days, %d activities, Jan 2nd %.0f m", len(runs.Days), runs.Activities, runs.Days[1].Distance)
+	}
+}
@@ -0,0 +1,104 @@
+package analysis
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// CalendarMetric is what a training calendar shades each day by.
+type CalendarMetric string
+
+const (
+	ByTime     CalendarMetric = "time"
+	ByDistance CalendarMetric = "distance"
+)
+
+// ParseCalendarMetric validates a calendar metric name.
+func ParseCalendarMetric(s string) (CalendarMetric, error) {
+	switch m := CalendarMetric(strings.ToLower(strings.TrimSpace(s))); m {
+	case ByTime, ByDistance:
+		return m, nil
+	}
+	return "", fmt.Errorf("invalid metric %q: must be time or distance", s)
+}
+
+// CalendarLevels is the number of shades a training day can have.
+const CalendarLevels = 4
+
+// CalendarDay is one day of a training calendar.
+type CalendarDay struct {
+	Date       time.Time `json:"date"`
+	Activities int       `json:"activities"`
+	MovingTime int       `json:"moving_time"` // seconds
+	Distance   float64   `json:"distance"`    // meters
+	Level      int       `json:"level"`       // 0 for a rest day, else 1 to CalendarLevels
+}
+
+// Calendar is a year of training days, as a heatmap.
+type Calendar struct {
+	Year          int            `json:"year"`
+	Metric        CalendarMetric `json:"metric"`
+	Days          []CalendarDay  `json:"days"` // every day of the year from January 1st, up to today
+	ActiveDays    int            `json:"active_days"`
+	Activities    int            `json:"activities"`
+	MovingTime    int            `json:"moving_time"`
+	Distance      float64        `json:"distance"`
+	LongestStreak Streak         `json:"longest_streak"`
+}
+
+// TrainingCalendar totals the activities in acts that started (local time)
+// in year, per day. Each day with activities is given a level from 1 to
+// CalendarLevels by where its moving time or distance falls among the
+// year's training days: the quarter of days with the least is level 1, the
+// quarter with the most level 4. The calendar ends at today when that is in
+// year. If sport is non-empty only that sport type counts.
+func TrainingCalendar(acts []Activity, year int, metric CalendarMetric, sport string, today time.Time) Calendar {
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	c := Calendar{Year: year, Metric: metric}
+	for d := start; d.Year() == year && !d.After(end); d = d.AddDate(0, 0, 1) {
+		c.Days = append(c.Days, CalendarDay{Date: d})
+	}
+	for _, a := range acts {
+		i := a.StartDateLocal.YearDay() - 1
+		if a.StartDateLocal.Year() != year || i >= len(c.Days) || (sport != "" && !strings.EqualFold(a.SportType, sport)) {
+			continue
+		}
+		d := &c.Days[i]
+		d.Activities++
+		d.MovingTime += a.MovingTime
+		d.Distance += a.Distance
+		c.Activities++
+		c.MovingTime += a.MovingTime
+		c.Distance += a.Distance
+	}
+
+	value := func(d CalendarDay) float64 {
+		if metric == ByDistance {
+			return d.Distance
+		}
+		return float64(d.MovingTime)
+	}
+	var values []float64
+	var active []time.Time
+	for _, d := range c.Days {
+		if d.Activities > 0 {
+			values = append(values, value(d))
+			active = append(active, d.Date)
+		}
+	}
+	slices.Sort(values)
+	for i, d := range c.Days {
+		if d.Activities == 0 {
+			continue
+		}
+		// The share of training days with less, in quarters.
+		below, _ := slices.BinarySearch(values, value(d))
+		c.Days[i].Level = 1 + below*CalendarLevels/len(values)
+	}
+	c.ActiveDays = len(active)
+	_, c.LongestStreak = Streaks(active, start)
+	return c
+}
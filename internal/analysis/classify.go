@@ -0,0 +1,172 @@
+package analysis
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Class labels a session by how its time is spread over the heart rate
+// zones.
+type Class string
+
+const (
+	Easy      Class = "easy"
+	Tempo     Class = "tempo"
+	Threshold Class = "threshold"
+	VO2       Class = "vo2"
+)
+
+// Rank orders classes by intensity, easy first; an unclassified session is 0.
+func (c Class) Rank() int {
+	return slices.Index([]Class{Easy, Tempo, Threshold, VO2}, c) + 1
+}
+
+// minClassifyTime is the least heart rate data (seconds) a session needs to be
+// classified: a few minutes say nothing about a workout.
+const minClassifyTime = 300
+
+// Shares of heart rate time, counted from the top zone down, that make a
+// session VO2 (top zone), threshold (top two) or tempo (top three).
+const (
+	vo2Share       = 0.10
+	thresholdShare = 0.25
+	tempoShare     = 0.30
+)
+
+// Classification is a session's class and the time behind it. Bounds are the
+// heart rate zones it was measured against, so that a change of zones
+// invalidates it.
+type Classification struct {
+	Class  Class `json:"class,omitempty"`
+	Zones  []int `json:"zones"`  // seconds in each zone
+	Bounds []int `json:"bounds"` // lower bound of each zone, bpm
+}
+
+// TimeInZones sums the seconds spent in each heart rate zone, given the zones'
+// lower bounds in ascending order. Gaps between samples longer than
+// maxSampleGap are pauses and count as maxSampleGap.
+func TimeInZones(s *Streams, bounds []int) []int {
+	if s == nil {
+		return make([]int, len(bounds))
+	}
+	return timeInZones(s.Time, s.Heartrate, bounds, 1)
+}
+
+// timeInZones sums the seconds that values, sampled at times, spent in each
+// zone of bounds. Samples below floor (no reading) are not counted.
+func timeInZones(times, values, bounds []int, floor int) []int {
+	out := make([]int, len(bounds))
+	if len(bounds) == 0 || len(values) != len(times) {
+		return out
+	}
+	for i := 0; i+1 < len(times); i++ {
+		v := values[i]
+		if v < floor {
+			continue
+		}
+		z := 0
+		for z+1 < len(bounds) && v >= bounds[z+1] {
+			z++
+		}
+		out[z] += min(times[i+1]-times[i], maxSampleGap)
+	}
+	return out
+}
+
+// maxHRZoneShares are the lower bounds of the five heart rate zones of
+// HeartRateZones, as shares of the maximum heart rate.
+var maxHRZoneShares = []float64{0, 0.60, 0.70, 0.80, 0.90}
+
+// HeartRateZones returns the lower bounds of five heart rate zones at 60, 70,
+// 80 and 90% of maxHR, for athletes without zones on their Strava profile.
+func HeartRateZones(maxHR float64) []int {
+	bounds := make([]int, len(maxHRZoneShares))
+	for i, s := range maxHRZoneShares {
+		bounds[i] = int(math.Round(s * maxHR))
+	}
+	return bounds
+}
+
+// Classify labels a session from its seconds per zone (at least three zones,
+// lowest first): VO2 with a tenth of the time in the top zone, threshold with
+// a quarter in the top two, tempo with 30% in the top three, easy otherwise.
+// It returns "" with under five minutes of heart rate data.
+func Classify(zones []int) Class {
+	total := 0
+	for _, s := range zones {
+		total += s
+	}
+	if len(zones) < 3 || total < minClassifyTime {
+		return ""
+	}
+	top := func(n int) float64 {
+		sum := 0
+		for _, s := range zones[len(zones)-n:] {
+			sum += s
+		}
+		return float64(sum) / float64(total)
+	}
+	switch {
+	case top(1) >= vo2Share:
+		return VO2
+	case top(2) >= thresholdShare:
+		return Threshold
+	case top(3) >= tempoShare:
+		return Tempo
+	default:
+		return Easy
+	}
+}
+
+// IntensityTotals adds the intensity distribution to a period's totals:
+// sessions per class, and the heart rate time at low intensity (the lowest
+// two zones) and above it — the split the 80/20 rule is about.
+type IntensityTotals struct {
+	PeriodTotals
+	Easy      int `json:"easy"`
+	Tempo     int `json:"tempo"`
+	Threshold int `json:"threshold"`
+	VO2       int `json:"vo2"`
+	LowTime   int `json:"low_time"`  // seconds
+	HighTime  int `json:"high_time"` // seconds
+}
+
+// SummarizeIntensity buckets activities as Summarize does, adding the
+// classification of each one found in classes.
+func SummarizeIntensity(acts []Activity, classes map[int64]Classification, p Period, sport string) []IntensityTotals {
+	totals := Summarize(acts, p, sport)
+	out := make([]IntensityTotals, len(totals))
+	index := map[time.Time]int{}
+	for i, t := range totals {
+		out[i].PeriodTotals = t
+		index[t.Start] = i
+	}
+	for _, a := range acts {
+		c, ok := classes[a.ID]
+		i, in := index[p.Start(a.StartDateLocal)]
+		if !ok || !in || (sport != "" && !strings.EqualFold(a.SportType, sport)) {
+			continue
+		}
+		t := &out[i]
+		switch c.Class {
+		case Easy:
+			t.Easy++
+		case Tempo:
+			t.Tempo++
+		case Threshold:
+			t.Threshold++
+		case VO2:
+			t.VO2++
+		}
+		for z, s := range c.Zones {
+			if z < 2 {
+				t.LowTime += s
+			} else {
+				t.HighTime += s
+			}
+		}
+	}
+	return out
+}
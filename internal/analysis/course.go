@@ -0,0 +1,118 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/geo"
+)
+
+// CourseEffort is one traversal of a course with its per-unit split times.
+type CourseEffort struct {
+	ActivityID int64     `json:"activity_id"`
+	Name       string    `json:"name"`
+	Date       time.Time `json:"date"`
+	Splits     []float64 `json:"splits"` // seconds per unit segment
+	Total      float64   `json:"total"`  // sum of Splits
+}
+
+// Course is a personal leaderboard for a course: efforts ranked fastest first
+// plus the best, worst and average time for every split.
+type Course struct {
+	Unit    float64        `json:"unit"` // split length in meters
+	Efforts []CourseEffort `json:"efforts"`
+	Best    []float64      `json:"best"`
+	Worst   []float64      `json:"worst"`
+	Average []float64      `json:"average"`
+}
+
+// MatchesCourse reports whether a starts and ends within radius meters of the
+// course start and end and covers the course distance within tol (a fraction,
+// e.g. 0.1 for ±10%).
+func MatchesCourse(a Activity, start, end geo.Point, distance, radius, tol float64) bool {
+	if distance <= 0 || math.Abs(a.Distance-distance)/distance > tol {
+		return false
+	}
+	s, ok := geo.PointFromSlice(a.StartLatlng)
+	if !ok || geo.Distance(s, start) > radius {
+		return false
+	}
+	e, ok := geo.PointFromSlice(a.EndLatlng)
+	if !ok || geo.Distance(e, end) > radius {
+		return false
+	}
+	return true
+}
+
+// Splits aligns a time stream by distance and returns the elapsed seconds for
+// each complete unit (e.g. 1000 m) covered, interpolating between samples.
+// At most limit splits are returned when limit > 0.
+func Splits(distance []float64, times []int, unit float64, limit int) []float64 {
+	n := len(distance)
+	if len(times) < n {
+		n = len(times)
+	}
+	if n < 2 || unit <= 0 {
+		return nil
+	}
+	var splits []float64
+	prev := float64(times[0])
+	next := unit
+	for i := 1; i < n; i++ {
+		for distance[i] >= next {
+			if limit > 0 && len(splits) == limit {
+				return splits
+			}
+			d0, d1 := distance[i-1], distance[i]
+			t0, t1 := float64(times[i-1]), float64(times[i])
+			at := t1
+			if d1 > d0 {
+				at = t0 + (next-d0)/(d1-d0)*(t1-t0)
+			}
+			splits = append(splits, at-prev)
+			prev = at
+			next += unit
+		}
+	}
+	return splits
+}
+
+// CompareCourse ranks efforts by total time over the splits they share and
+// computes per-split best, worst and average. Efforts are truncated to the
+// shortest common number of splits so totals are comparable.
+func CompareCourse(efforts []CourseEffort, unit float64) Course {
+	c := Course{Unit: unit}
+	if len(efforts) == 0 {
+		return c
+	}
+	common := len(efforts[0].Splits)
+	for _, e := range efforts[1:] {
+		if len(e.Splits) < common {
+			common = len(e.Splits)
+		}
+	}
+	for _, e := range efforts {
+		e.Splits = e.Splits[:common]
+		e.Total = 0
+		for _, s := range e.Splits {
+			e.Total += s
+		}
+		c.Efforts = append(c.Efforts, e)
+	}
+	sort.SliceStable(c.Efforts, func(i, j int) bool { return c.Efforts[i].Total < c.Efforts[j].Total })
+
+	c.Best = make([]float64, common)
+	c.Worst = make([]float64, common)
+	c.Average = make([]float64, common)
+	for k := 0; k < common; k++ {
+		best, worst, sum := math.Inf(1), 0.0, 0.0
+		for _, e := range c.Efforts {
+			best = math.Min(best, e.Splits[k])
+			worst = math.Max(worst, e.Splits[k])
+			sum += e.Splits[k]
+		}
+		c.Best[k], c.Worst[k], c.Average[k] = best, worst, sum/float64(len(c.Efforts))
+	}
+	return c
+}
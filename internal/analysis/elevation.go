@@ -0,0 +1,104 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// ElevationThreshold is the climb in meters that ElevationGain needs to see
+// before counting it, which filters out the jitter of DEM samples along flat
+// ground.
+const ElevationThreshold = 5.0
+
+// ElevationGain returns the total ascent of an elevation profile, counting a
+// climb only once it exceeds threshold meters above the last low point.
+func ElevationGain(ele []float64, threshold float64) float64 {
+	if len(ele) == 0 {
+		return 0
+	}
+	gain := 0.0
+	ref := ele[0] // last confirmed low (or high) point
+	for _, e := range ele[1:] {
+		switch {
+		case e-ref >= threshold:
+			gain += e - ref
+			ref = e
+		case e < ref:
+			ref = e
+		}
+	}
+	return gain
+}
+
+// ElevationCheck compares the elevation gain an activity reported with the
+// gain recomputed from a digital elevation model along its route.
+type ElevationCheck struct {
+	ActivityID int64     `json:"activity_id"`
+	Name       string    `json:"name"`
+	SportType  string    `json:"sport_type"`
+	Date       time.Time `json:"date"`
+	Reported   float64   `json:"reported"` // meters
+	DEM        float64   `json:"dem"`      // meters
+	Outlier    bool      `json:"outlier"`
+}
+
+// Corrected returns the gain to trust: the DEM value for outliers, the
+// reported value otherwise.
+func (c ElevationCheck) Corrected() float64 {
+	if c.Outlier {
+		return c.DEM
+	}
+	return c.Reported
+}
+
+// CheckElevation flags an activity as an outlier when its reported gain differs
+// from the DEM gain by more than tol (a fraction of the DEM gain, e.g. 0.3) and
+// by more than minDiff meters, so that short flat activities are not flagged
+// over a few meters of noise.
+func CheckElevation(a Activity, dem, tol, minDiff float64) ElevationCheck {
+	diff := math.Abs(a.TotalElevationGain - dem)
+	return ElevationCheck{
+		ActivityID: a.ID,
+		Name:       a.Name,
+		SportType:  a.SportType,
+		Date:       a.StartDateLocal,
+		Reported:   a.TotalElevationGain,
+		DEM:        math.Round(dem),
+		Outlier:    diff > minDiff && diff > tol*math.Max(dem, 1),
+	}
+}
+
+// ElevationYear sums a year of elevation checks.
+type ElevationYear struct {
+	Year      int     `json:"year"`
+	Count     int     `json:"count"`
+	Outliers  int     `json:"outliers"`
+	Reported  float64 `json:"reported"`
+	Corrected float64 `json:"corrected"`
+}
+
+// ElevationTotals sums checks per calendar year, oldest first.
+func ElevationTotals(checks []ElevationCheck) []ElevationYear {
+	byYear := map[int]*ElevationYear{}
+	for _, c := range checks {
+		y := c.Date.Year()
+		row, ok := byYear[y]
+		if !ok {
+			row = &ElevationYear{Year: y}
+			byYear[y] = row
+		}
+		row.Count++
+		if c.Outlier {
+			row.Outliers++
+		}
+		row.Reported += c.Reported
+		row.Corrected += c.Corrected()
+	}
+	out := make([]ElevationYear, 0, len(byYear))
+	for _, row := range byYear {
+		out = append(out, *row)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Year < out[j].Year })
+	return out
+}
@@ -0,0 +1,85 @@
+package analysis
+
+import "strings"
+
+// Filter selects activities by criteria the activities endpoint cannot
+// filter on. The zero Filter matches everything.
+type Filter struct {
+	Sports       []string // sport types, case-insensitive; empty matches all
+	MinDistance  float64  // meters; 0 means no minimum
+	MaxDistance  float64  // meters; 0 means no maximum
+	Commute      *bool    // nil matches commutes and non-commutes
+	NameContains string   // case-insensitive substring of the name
+
+	// Meta requires local metadata fields: each key must be set to its value,
+	// ignoring case, or to anything when the value is "".
+	Meta map[string]string
+}
+
+// IsZero reports whether f matches every activity.
+func (f Filter) IsZero() bool {
+	return len(f.Sports) == 0 && f.MinDistance == 0 && f.MaxDistance == 0 &&
+		f.Commute == nil && f.NameContains == "" && len(f.Meta) == 0
+}
+
+// Match reports whether a meets every criterion of f.
+func (f Filter) Match(a Activity) bool {
+	if len(f.Sports) > 0 {
+		ok := false
+		for _, s := range f.Sports {
+			if strings.EqualFold(strings.TrimSpace(s), a.SportType) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	if f.MinDistance > 0 && a.Distance < f.MinDistance {
+		return false
+	}
+	if f.MaxDistance > 0 && a.Distance > f.MaxDistance {
+		return false
+	}
+	if f.Commute != nil && a.Commute != *f.Commute {
+		return false
+	}
+	if f.NameContains != "" && !strings.Contains(strings.ToLower(a.Name), strings.ToLower(f.NameContains)) {
+		return false
+	}
+	for k, want := range f.Meta {
+		got, ok := a.Meta[k]
+		if !ok || (want != "" && !strings.EqualFold(got, want)) {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchText reports whether every word of query occurs, case-insensitively,
+// in at least one of the texts (e.g. an activity's name and description).
+// An empty query matches nothing.
+func MatchText(query string, texts ...string) bool {
+	words := strings.Fields(strings.ToLower(query))
+	if len(words) == 0 {
+		return false
+	}
+	lower := make([]string, len(texts))
+	for i, t := range texts {
+		lower[i] = strings.ToLower(t)
+	}
+	for _, w := range words {
+		found := false
+		for _, t := range lower {
+			if strings.Contains(t, w) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,225 @@
+package analysis
+
+import (
+	"math"
+	"time"
+)
+
+// thresholdHRShare is the lactate threshold heart rate, as a share of the
+// maximum, that heart rate TSS is relative to.
+const thresholdHRShare = 0.9
+
+// untrackedIntensity is the intensity factor assumed for activities with
+// neither power nor heart rate: easy aerobic work.
+const untrackedIntensity = 0.65
+
+// npWindow is the rolling average, in seconds, that normalized power is
+// computed over.
+const npWindow = 30
+
+// StressSource is what an activity's Training Stress Score was worked out
+// from.
+type StressSource string
+
+const (
+	StressPower     StressSource = "power"
+	StressHeartRate StressSource = "heartrate"
+	StressEstimate  StressSource = "estimate" // moving time at an easy intensity
+)
+
+// Stress is an activity's Training Stress Score, what it was worked out
+// from, and the thresholds it was relative to.
+type Stress struct {
+	TSS        float64      `json:"tss"`
+	Source     StressSource `json:"source"`
+	Thresholds Thresholds   `json:"thresholds"`
+}
+
+// HeartRateStressScore is an activity's heart rate TSS: like StressScore,
+// with the average heart rate reserve as a fraction of that at threshold
+// (90% of maxHR) as the intensity. It is 0 without heart rate or maxHR.
+func HeartRateStressScore(a Activity, maxHR float64) float64 {
+	r := hrIntensity(a.AverageHeartrate, maxHR)
+	return float64(a.MovingTime) / 3600 * r * r * 100
+}
+
+// hrIntensity is hr's heart rate reserve as a fraction of that at threshold,
+// or 0 if either heart rate is unknown.
+func hrIntensity(hr, maxHR float64) float64 {
+	threshold := thresholdHRShare * maxHR
+	if hr <= restingHR || threshold <= restingHR {
+		return 0
+	}
+	return (hr - restingHR) / (threshold - restingHR)
+}
+
+// ActivityStress works out an activity's TSS from its summary: from power
+// when it has some and t an FTP, otherwise from heart rate when it has some
+// and t a maximum heart rate, otherwise from its moving time.
+func ActivityStress(a Activity, t Thresholds) Stress {
+	if s := StressScore(a, t.FTP); s > 0 {
+		return Stress{TSS: s, Source: StressPower, Thresholds: t}
+	}
+	if s := HeartRateStressScore(a, t.MaxHR); s > 0 {
+		return Stress{TSS: s, Source: StressHeartRate, Thresholds: t}
+	}
+	return Stress{TSS: float64(a.MovingTime) / 3600 * untrackedIntensity * untrackedIntensity * 100,
+		Source: StressEstimate, Thresholds: t}
+}
+
+// StreamStress works out an activity's TSS from its streams, which is more
+// exact than from its averages: from the normalized power of the watts
+// stream when t has an FTP, otherwise from the heart rate of each sample.
+// ok is false when the streams have neither.
+func StreamStress(s *Streams, t Thresholds) (stress Stress, ok bool) {
+	if s == nil || len(s.Time) < 2 {
+		return Stress{}, false
+	}
+	if t.FTP > 0 && len(s.Watts) == len(s.Time) {
+		secs := resample(s, s.Watts)
+		if np := normalizedPower(secs); np > 0 {
+			f := np / t.FTP
+			return Stress{TSS: float64(len(secs)) / 3600 * f * f * 100, Source: StressPower, Thresholds: t}, true
+		}
+	}
+	if t.MaxHR > 0 && len(s.Heartrate) == len(s.Time) {
+		sum := 0.0
+		for i := 0; i+1 < len(s.Time); i++ {
+			r := hrIntensity(float64(s.Heartrate[i]), t.MaxHR)
+			sum += float64(min(s.Time[i+1]-s.Time[i], maxSampleGap)) * r * r
+		}
+		if sum > 0 {
+			return Stress{TSS: sum / 3600 * 100, Source: StressHeartRate, Thresholds: t}, true
+		}
+	}
+	return Stress{}, false
+}
+
+// resample spreads values over one entry per second of s's time stream,
+// holding each sample until the next. Pauses count as maxSampleGap.
+func resample(s *Streams, values []int) []float64 {
+	var out []float64
+	for i := 0; i+1 < len(s.Time); i++ {
+		for range min(s.Time[i+1]-s.Time[i], maxSampleGap) {
+			out = append(out, float64(values[i]))
+		}
+	}
+	return out
+}
+
+// normalizedPower is the fourth-power mean of the npWindow-second rolling
+// average of secs (one power value per second), or their plain average when
+// there are too few.
+func normalizedPower(secs []float64) float64 {
+	if len(secs) == 0 {
+		return 0
+	}
+	if len(secs) < npWindow {
+		sum := 0.0
+		for _, w := range secs {
+			sum += w
+		}
+		return sum / float64(len(secs))
+	}
+	window, sum4 := 0.0, 0.0
+	for i, w := range secs {
+		window += w
+		if i >= npWindow {
+			window -= secs[i-npWindow]
+		}
+		if i >= npWindow-1 {
+			sum4 += math.Pow(window/npWindow, 4)
+		}
+	}
+	return math.Pow(sum4/float64(len(secs)-npWindow+1), 0.25)
+}
+
+// FitnessDay is a day of FitnessSeries: the activities that day, their
+// total TSS, and the training form at its end (see Form).
+type FitnessDay struct {
+	Date       time.Time `json:"date"`
+	Activities int       `json:"activities"`
+	TSS        float64   `json:"tss"`
+	Fitness    float64   `json:"fitness"`
+	Fatigue    float64   `json:"fatigue"`
+	Form       float64   `json:"form"`
+}
+
+// FitnessSeries returns a FitnessDay for each date from from to to, with the
+// TSS of each activity in acts (by local start date) looked up in stress by
+// ID. Fitness and fatigue build up from the first activity, so acts should
+// reach back several months before from for them to settle.
+func FitnessSeries(acts []Activity, stress map[int64]Stress, from, to time.Time) []FitnessDay {
+	first, last := calendarDay(from), calendarDay(to)
+	if last.Before(first) {
+		return nil
+	}
+	loads := map[time.Time]float64{}
+	counts := map[time.Time]int{}
+	for _, a := range acts {
+		date := calendarDay(a.StartDateLocal)
+		if date.After(last) {
+			continue
+		}
+		loads[date] += stress[a.ID].TSS
+		counts[date]++
+	}
+	var out []FitnessDay
+	for date := first; !date.After(last); date = date.AddDate(0, 0, 1) {
+		out = append(out, FitnessDay{Date: date})
+	}
+	formOver(loads, last, func(load float64, f Form) {
+		if i := int(f.Date.Sub(first).Hours() / 24); i >= 0 {
+			out[i] = FitnessDay{Date: f.Date, Activities: counts[f.Date], TSS: load,
+				Fitness: f.Fitness, Fatigue: f.Fatigue, Form: f.Form}
+		}
+	})
+	return out
+}
+
+// FormZone describes a form value in the usual bands: below -30
+// overreaching, -30 to -10 productive training, -10 to 5 maintaining, 5 to 25
+// fresh (ready to race), and above 25 losing fitness.
+func FormZone(form float64) string {
+	switch {
+	case form < -30:
+		return "overreaching"
+	case form < -10:
+		return "productive"
+	case form < 5:
+		return "maintaining"
+	case form <= 25:
+		return "fresh"
+	}
+	return "detraining"
+}
+
+// formOver runs the fitness and fatigue averages over the daily loads from
+// the earliest date in loads to last, calling visit with each day's load and
+// the form at its end.
+func formOver(loads map[time.Time]float64, last time.Time, visit func(load float64, f Form)) {
+	var first time.Time
+	for date := range loads {
+		if first.IsZero() || date.Before(first) {
+			first = date
+		}
+	}
+	if first.IsZero() {
+		return
+	}
+	var f Form
+	for date := first; !date.After(last); date = date.AddDate(0, 0, 1) {
+		f.Date = date
+		f.Fatigue += (loads[date] - f.Fatigue) / fatigueDays
+		f.Fitness += (loads[date] - f.Fitness) / fitnessDays
+		f.Form = f.Fitness - f.Fatigue
+		visit(loads[date], f)
+	}
+}
+
+// calendarDay is midnight UTC of t's date, so that local dates compare
+// equal whatever location t is in.
+func calendarDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
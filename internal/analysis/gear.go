@@ -0,0 +1,171 @@
+package analysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Gear kinds.
+const (
+	Bike  = "bike"
+	Shoes = "shoes"
+)
+
+// Gear is a bike or pair of shoes as listed in the athlete's profile
+// (GET /athlete). The generated model drops the retired flag, hence this
+// mirror.
+type Gear struct {
+	ID       string  `json:"id"`
+	Name     string  `json:"name"`
+	Kind     string  `json:"kind"`     // Bike or Shoes
+	Distance float64 `json:"distance"` // meters, as Strava totals it
+	Primary  bool    `json:"primary"`
+	Retired  bool    `json:"retired"`
+}
+
+// ParseAthleteGear extracts the bikes and then the shoes from a GET /athlete
+// body.
+func ParseAthleteGear(body []byte) ([]Gear, error) {
+	var athlete struct {
+		Bikes []Gear `json:"bikes"`
+		Shoes []Gear `json:"shoes"`
+	}
+	if err := json.Unmarshal(body, &athlete); err != nil {
+		return nil, fmt.Errorf("parse athlete gear: %w", err)
+	}
+	gear := make([]Gear, 0, len(athlete.Bikes)+len(athlete.Shoes))
+	for _, g := range athlete.Bikes {
+		g.Kind = Bike
+		gear = append(gear, g)
+	}
+	for _, g := range athlete.Shoes {
+		g.Kind = Shoes
+		gear = append(gear, g)
+	}
+	return gear, nil
+}
+
+// GearTotals aggregates the activities recorded with one piece of gear.
+type GearTotals struct {
+	GearID        string    `json:"gear_id"` // "" for activities without gear
+	Name          string    `json:"name"`
+	Kind          string    `json:"kind,omitempty"`
+	Retired       bool      `json:"retired,omitempty"`
+	Count         int       `json:"count"`
+	Distance      float64   `json:"distance"`    // meters
+	MovingTime    int       `json:"moving_time"` // seconds
+	ElevationGain float64   `json:"elevation_gain"`
+	First         time.Time `json:"first"` // local start of the earliest activity
+	Last          time.Time `json:"last"`  // local start of the latest activity
+}
+
+// GearUsage totals activities per gear ID, most distance first. Names come
+// from gear; IDs missing from it (such as another athlete's or deleted gear)
+// are listed by ID.
+func GearUsage(acts []Activity, gear []Gear) []GearTotals {
+	byID := map[string]*GearTotals{}
+	var order []string
+	for _, a := range acts {
+		t, ok := byID[a.GearID]
+		if !ok {
+			t = &GearTotals{GearID: a.GearID, Name: a.GearID}
+			if a.GearID == "" {
+				t.Name = "(no gear)"
+			}
+			byID[a.GearID] = t
+			order = append(order, a.GearID)
+		}
+		t.Count++
+		t.Distance += a.Distance
+		t.MovingTime += a.MovingTime
+		t.ElevationGain += a.TotalElevationGain
+		if t.First.IsZero() || a.StartDateLocal.Before(t.First) {
+			t.First = a.StartDateLocal
+		}
+		if a.StartDateLocal.After(t.Last) {
+			t.Last = a.StartDateLocal
+		}
+	}
+	for _, g := range gear {
+		if t, ok := byID[g.ID]; ok {
+			t.Name, t.Kind, t.Retired = g.Name, g.Kind, g.Retired
+		}
+	}
+	out := make([]GearTotals, 0, len(order))
+	for _, id := range order {
+		out = append(out, *byID[id])
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Distance > out[j].Distance })
+	return out
+}
+
+// Maintenance alert levels, from least to most urgent.
+const (
+	AlertOK      = "ok"
+	AlertDueSoon = "due soon"
+	AlertOverdue = "overdue"
+)
+
+// DueSoonFraction is how much of its interval a part may use before its
+// alert is due soon.
+const DueSoonFraction = 0.9
+
+// GearAlert is a maintenance interval on a piece of gear, or on a part of it
+// such as a chain, checked against the distance Strava totals for the gear.
+type GearAlert struct {
+	GearID string  `json:"gear_id"`
+	Part   string  `json:"part,omitempty"`  // e.g. "chain"; "" for the gear itself
+	Every  float64 `json:"every"`           // meters between replacements
+	Since  float64 `json:"since,omitempty"` // the gear's distance (m) when the part was last replaced
+}
+
+// Label names the alert, e.g. "b123" or "b123:chain".
+func (a GearAlert) Label() string {
+	if a.Part == "" {
+		return a.GearID
+	}
+	return a.GearID + ":" + a.Part
+}
+
+// GearAlertStatus is a GearAlert evaluated against the gear's distance.
+type GearAlertStatus struct {
+	GearAlert
+	Name      string  `json:"name"`      // the gear's name
+	Used      float64 `json:"used"`      // meters since the last replacement
+	Remaining float64 `json:"remaining"` // meters until due; negative when overdue
+	Level     string  `json:"level"`     // AlertOK, AlertDueSoon or AlertOverdue
+}
+
+// CheckGearAlerts evaluates each alert against the gear it names, most
+// urgent first. Alerts on retired gear, or on gear missing from gear (sold
+// or deleted), are skipped.
+func CheckGearAlerts(gear []Gear, alerts []GearAlert) []GearAlertStatus {
+	byID := map[string]Gear{}
+	for _, g := range gear {
+		byID[g.ID] = g
+	}
+	var out []GearAlertStatus
+	for _, a := range alerts {
+		g, ok := byID[a.GearID]
+		if !ok || g.Retired {
+			continue
+		}
+		s := GearAlertStatus{GearAlert: a, Name: g.Name, Used: g.Distance - a.Since}
+		s.Remaining = a.Every - s.Used
+		switch {
+		case s.Used >= a.Every:
+			s.Level = AlertOverdue
+		case s.Used >= DueSoonFraction*a.Every:
+			s.Level = AlertDueSoon
+		default:
+			s.Level = AlertOK
+		}
+		out = append(out, s)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Used/out[i].Every > out[j].Used/out[j].Every
+	})
+	return out
+}
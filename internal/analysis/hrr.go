@@ -0,0 +1,168 @@
+package analysis
+
+import (
+	"sort"
+	"time"
+)
+
+// hrrWindow is the time after an effort over which heart rate recovery is
+// measured: HRR60, the usual field measure.
+const hrrWindow = 60
+
+// hrrPeakWindow is how far back from the end of an effort its peak heart
+// rate is looked for: heart rate lags the effort, so it peaks as the effort
+// stops rather than when it falls below the threshold.
+const hrrPeakWindow = 30
+
+// maxSampleGap is how far (seconds) a sample may be from the time asked for
+// before the stream counts as having no value there.
+const maxSampleGap = 5
+
+// Recovery is the heart rate drop in the minute after one hard effort.
+type Recovery struct {
+	Start   int  `json:"start"` // seconds from the activity start
+	End     int  `json:"end"`   // when heart rate peaked at the end of the effort
+	PeakHR  int  `json:"peak_hr"`
+	HR60    int  `json:"hr_60"` // a minute after End
+	Drop    int  `json:"drop"`  // PeakHR - HR60, bpm
+	Stopped bool `json:"stopped"`
+}
+
+// HeartRateRecovery finds the hard efforts in an activity — at least
+// minEffort seconds with heart rate at or above threshold percent of the
+// activity's highest — and measures the drop in the minute after each. An
+// effort followed by another within the minute has no recovery to measure
+// and is skipped. Stopped is set when the moving stream shows the athlete
+// stood still for most of the minute (passive recovery drops faster).
+func HeartRateRecovery(s *Streams, threshold, minEffort int) []Recovery {
+	if s == nil || len(s.Time) == 0 || len(s.Heartrate) != len(s.Time) {
+		return nil
+	}
+	peak := 0
+	for _, hr := range s.Heartrate {
+		peak = max(peak, hr)
+	}
+	if peak == 0 {
+		return nil
+	}
+	limit := peak * threshold / 100
+
+	// Efforts as [first, last] sample indexes above the limit.
+	var efforts [][2]int
+	for i := 0; i < len(s.Time); {
+		if s.Heartrate[i] < limit {
+			i++
+			continue
+		}
+		j := i
+		for j+1 < len(s.Time) && s.Heartrate[j+1] >= limit {
+			j++
+		}
+		if s.Time[j]-s.Time[i] >= minEffort {
+			efforts = append(efforts, [2]int{i, j})
+		}
+		i = j + 1
+	}
+
+	var out []Recovery
+	for n, e := range efforts {
+		end := e[1]
+		for k := e[1]; k >= e[0] && s.Time[e[1]]-s.Time[k] <= hrrPeakWindow; k-- {
+			if s.Heartrate[k] > s.Heartrate[end] {
+				end = k
+			}
+		}
+		t := s.Time[end] + hrrWindow
+		after := s.sampleAt(t)
+		if after < 0 || (n+1 < len(efforts) && s.Time[efforts[n+1][0]] <= t) {
+			continue
+		}
+		r := Recovery{
+			Start:  s.Time[e[0]],
+			End:    s.Time[end],
+			PeakHR: s.Heartrate[end],
+			HR60:   s.Heartrate[after],
+		}
+		r.Drop = r.PeakHR - r.HR60
+		if len(s.Moving) == len(s.Time) {
+			still := 0
+			for k := end; k <= after; k++ {
+				if !s.Moving[k] {
+					still++
+				}
+			}
+			r.Stopped = still*2 > after-end+1
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// sampleAt returns the index of the first sample at or after t, or -1 if
+// there is none within maxSampleGap.
+func (s *Streams) sampleAt(t int) int {
+	i := sort.SearchInts(s.Time, t)
+	if i == len(s.Time) || s.Time[i]-t > maxSampleGap {
+		return -1
+	}
+	return i
+}
+
+// HRRSummary is an activity's heart rate recovery, as cached between runs:
+// the number of efforts measured and their mean drop, for the given
+// detection settings.
+type HRRSummary struct {
+	Threshold int     `json:"threshold"`
+	MinEffort int     `json:"min_effort"`
+	Efforts   int     `json:"efforts"`
+	MeanDrop  float64 `json:"mean_drop"` // bpm
+}
+
+// SummarizeRecovery averages an activity's recoveries.
+func SummarizeRecovery(recs []Recovery, threshold, minEffort int) HRRSummary {
+	sum := HRRSummary{Threshold: threshold, MinEffort: minEffort, Efforts: len(recs)}
+	for _, r := range recs {
+		sum.MeanDrop += float64(r.Drop)
+	}
+	if len(recs) > 0 {
+		sum.MeanDrop /= float64(len(recs))
+	}
+	return sum
+}
+
+// HRRTrend is the heart rate recovery over one period.
+type HRRTrend struct {
+	Period     string    `json:"period"`
+	Start      time.Time `json:"start"`
+	Activities int       `json:"activities"` // with at least one effort
+	Efforts    int       `json:"efforts"`
+	MeanDrop   float64   `json:"mean_drop"` // bpm, over all efforts
+}
+
+// RecoveryTrends buckets the per-activity recoveries by period of the local
+// start date, oldest first. Periods without efforts are left out. A
+// rising drop at the same effort is a sign of improving fitness.
+func RecoveryTrends(acts []Activity, recovery map[int64]HRRSummary, p Period) []HRRTrend {
+	byStart := map[time.Time]*HRRTrend{}
+	for _, a := range acts {
+		r, ok := recovery[a.ID]
+		if !ok || r.Efforts == 0 {
+			continue
+		}
+		start := p.Start(a.StartDateLocal)
+		t, ok := byStart[start]
+		if !ok {
+			t = &HRRTrend{Period: p.Label(start), Start: start}
+			byStart[start] = t
+		}
+		t.Activities++
+		t.MeanDrop = (t.MeanDrop*float64(t.Efforts) + r.MeanDrop*float64(r.Efforts)) / float64(t.Efforts+r.Efforts)
+		t.Efforts += r.Efforts
+	}
+	var out []HRRTrend
+	for _, t := range byStart {
+		out = append(out, *t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	return out
+}
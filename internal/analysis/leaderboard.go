@@ -0,0 +1,147 @@
+package analysis
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ClubEntry is one activity of a club feed. The feed carries neither IDs nor
+// dates, so FirstSeen, when the activity first showed up in a fetch of the
+// feed, stands in for its date.
+type ClubEntry struct {
+	Athlete       string    `json:"athlete"` // "Firstname L.", as the feed gives it
+	Name          string    `json:"name"`
+	SportType     string    `json:"sport_type"`
+	Distance      float64   `json:"distance"`    // meters
+	MovingTime    int       `json:"moving_time"` // seconds
+	ElapsedTime   int       `json:"elapsed_time"`
+	ElevationGain float64   `json:"elevation_gain"`
+	FirstSeen     time.Time `json:"first_seen"`
+}
+
+// Key identifies an entry across fetches of the feed.
+func (e ClubEntry) Key() string {
+	return fmt.Sprintf("%s|%s|%s|%.1f|%d|%d", e.Athlete, e.Name, e.SportType, e.Distance, e.MovingTime, e.ElapsedTime)
+}
+
+// ClubLeaderboardRow is one athlete's totals on a club leaderboard.
+type ClubLeaderboardRow struct {
+	Rank          int     `json:"rank"`
+	Athlete       string  `json:"athlete"`
+	Activities    int     `json:"activities"`
+	Distance      float64 `json:"distance"`    // meters
+	MovingTime    int     `json:"moving_time"` // seconds
+	ElevationGain float64 `json:"elevation_gain"`
+	Longest       float64 `json:"longest"` // meters, the longest single activity
+}
+
+// ClubLeaderboard totals, per athlete, the entries first seen at or after
+// since, ranked by distance and then moving time, as on the club page. If
+// sport is non-empty only entries of that sport type (case-insensitive)
+// count. Athletes who share a display name share a row: the feed cannot tell
+// them apart.
+func ClubLeaderboard(entries []ClubEntry, since time.Time, sport string) []ClubLeaderboardRow {
+	byAthlete := map[string]*ClubLeaderboardRow{}
+	for _, e := range entries {
+		if e.FirstSeen.Before(since) || sport != "" && !strings.EqualFold(e.SportType, sport) {
+			continue
+		}
+		r := byAthlete[e.Athlete]
+		if r == nil {
+			r = &ClubLeaderboardRow{Athlete: e.Athlete}
+			byAthlete[e.Athlete] = r
+		}
+		r.Activities++
+		r.Distance += e.Distance
+		r.MovingTime += e.MovingTime
+		r.ElevationGain += e.ElevationGain
+		r.Longest = max(r.Longest, e.Distance)
+	}
+	rows := make([]ClubLeaderboardRow, 0, len(byAthlete))
+	for _, r := range byAthlete {
+		rows = append(rows, *r)
+	}
+	slices.SortFunc(rows, func(a, b ClubLeaderboardRow) int {
+		return cmp.Or(cmp.Compare(b.Distance, a.Distance), cmp.Compare(b.MovingTime, a.MovingTime),
+			cmp.Compare(a.Athlete, b.Athlete))
+	})
+	for i := range rows {
+		rows[i].Rank = i + 1
+	}
+	return rows
+}
+
+// ClubDigestTop is how many athletes a ClubDigest ranks.
+const ClubDigestTop = 5
+
+// ClubDigest sums up a club's feed over a period, for a newsletter.
+type ClubDigest struct {
+	Since         time.Time            `json:"since"`
+	Until         time.Time            `json:"until"`
+	Activities    int                  `json:"activities"`
+	Athletes      int                  `json:"athletes"`
+	Distance      float64              `json:"distance"`    // meters
+	MovingTime    int                  `json:"moving_time"` // seconds
+	ElevationGain float64              `json:"elevation_gain"`
+	Top           []ClubLeaderboardRow `json:"top"` // by distance, up to ClubDigestTop
+	LongestRide   *ClubEntry           `json:"longest_ride,omitempty"`
+	LongestRun    *ClubEntry           `json:"longest_run,omitempty"`
+	BiggestClimb  *ClubEntry           `json:"biggest_climb,omitempty"`
+	// Newcomers are the athletes whose first activity in the feed log falls
+	// in the period. They are only known (NewcomersKnown) when the log
+	// reaches back before it; otherwise everyone would look new.
+	Newcomers      []string `json:"newcomers"`
+	NewcomersKnown bool     `json:"newcomers_known"`
+}
+
+// DigestClub sums up the entries first seen from since until until (no end
+// when zero): totals, the athletes with the most distance, the longest ride
+// and run, the biggest climb, and the newcomers.
+func DigestClub(entries []ClubEntry, since, until time.Time) ClubDigest {
+	d := ClubDigest{Since: since, Until: until, Newcomers: []string{}}
+	var in []ClubEntry
+	before := map[string]bool{}
+	for _, e := range entries {
+		switch {
+		case e.FirstSeen.Before(since):
+			before[e.Athlete] = true
+			d.NewcomersKnown = true
+		case until.IsZero() || e.FirstSeen.Before(until):
+			in = append(in, e)
+		}
+	}
+	longest := func(best **ClubEntry, e ClubEntry, by func(ClubEntry) float64) {
+		if by(e) > 0 && (*best == nil || by(e) > by(**best)) {
+			*best = &e
+		}
+	}
+	distance := func(e ClubEntry) float64 { return e.Distance }
+	for _, e := range in {
+		d.Activities++
+		d.Distance += e.Distance
+		d.MovingTime += e.MovingTime
+		d.ElevationGain += e.ElevationGain
+		switch {
+		case strings.HasSuffix(e.SportType, "Ride"):
+			longest(&d.LongestRide, e, distance)
+		case strings.HasSuffix(e.SportType, "Run"):
+			longest(&d.LongestRun, e, distance)
+		}
+		longest(&d.BiggestClimb, e, func(e ClubEntry) float64 { return e.ElevationGain })
+	}
+	rows := ClubLeaderboard(in, since, "")
+	d.Athletes = len(rows)
+	d.Top = rows[:min(len(rows), ClubDigestTop)]
+	if d.NewcomersKnown {
+		for _, r := range rows {
+			if !before[r.Athlete] {
+				d.Newcomers = append(d.Newcomers, r.Athlete)
+			}
+		}
+		slices.Sort(d.Newcomers)
+	}
+	return d
+}
@@ -0,0 +1,80 @@
+package analysis
+
+import "time"
+
+// Time constants (days) of the fatigue and fitness averages: the usual
+// acute and chronic training load windows.
+const (
+	fatigueDays = 7
+	fitnessDays = 42
+)
+
+// restingHR is the resting heart rate assumed by heart rate TSS.
+const restingHR = 60
+
+// Thresholds are the athlete's markers that load and zone calculations are
+// relative to, as set with "strava config set". Zero values are unknown.
+type Thresholds struct {
+	FTP   float64 `json:"ftp,omitempty"`    // functional threshold power, watts
+	MaxHR float64 `json:"max_hr,omitempty"` // maximum heart rate, bpm
+}
+
+// OrHistory returns t, with the highest heart rate recorded in acts as the
+// maximum when t has none.
+func (t Thresholds) OrHistory(acts []Activity) Thresholds {
+	if t.MaxHR <= 0 {
+		for _, a := range acts {
+			t.MaxHR = max(t.MaxHR, a.MaxHeartrate)
+		}
+	}
+	return t
+}
+
+// IntensityFactor is an activity's normalized power as a fraction of ftp,
+// taking Strava's weighted average power as the normalized power, or 0 if
+// either is unknown.
+func IntensityFactor(a Activity, ftp float64) float64 {
+	np := a.WeightedAverageWatts
+	if np <= 0 {
+		np = a.AverageWatts
+	}
+	if np <= 0 || ftp <= 0 {
+		return 0
+	}
+	return np / ftp
+}
+
+// StressScore is an activity's Training Stress Score: an hour at FTP scores
+// 100. It is 0 when IntensityFactor is.
+func StressScore(a Activity, ftp float64) float64 {
+	f := IntensityFactor(a, ftp)
+	return float64(a.MovingTime) / 3600 * f * f * 100
+}
+
+// Form is the training state on a day: Fitness (chronic training load, the
+// 42-day average), Fatigue (acute load, the 7-day average) and Form, their
+// difference. Form well below zero means fatigue is building faster than
+// fitness; around -30 and lower the risk of overreaching rises.
+type Form struct {
+	Date    time.Time `json:"date"`
+	Fitness float64   `json:"fitness"`
+	Fatigue float64   `json:"fatigue"`
+	Form    float64   `json:"form"`
+}
+
+// TrainingForm computes the form at the end of day from the activities up to
+// it, by local start date, with each activity's TSS from ActivityStress
+// against t: the model FitnessSeries charts. For the averages to settle,
+// acts should reach back several months before day.
+func TrainingForm(acts []Activity, day time.Time, t Thresholds) Form {
+	last := calendarDay(day)
+	loads := map[time.Time]float64{}
+	for _, a := range acts {
+		if date := calendarDay(a.StartDateLocal); !date.After(last) {
+			loads[date] += ActivityStress(a, t).TSS
+		}
+	}
+	f := Form{Date: last}
+	formOver(loads, last, func(_ float64, day Form) { f = day })
+	return f
+}
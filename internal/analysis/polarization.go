@@ -0,0 +1,110 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// IntensityMethod is how training time is split into intensity zones.
+type IntensityMethod string
+
+const (
+	// ByZones counts each second of heart rate data in its own zone.
+	ByZones IntensityMethod = "zones"
+	// BySession counts a whole session's moving time at its class's
+	// intensity, the "session goal" method: easy is low, tempo moderate,
+	// threshold and VO2 high.
+	BySession IntensityMethod = "session"
+)
+
+// ParseIntensityMethod validates a method name.
+func ParseIntensityMethod(s string) (IntensityMethod, error) {
+	switch m := IntensityMethod(strings.ToLower(strings.TrimSpace(s))); m {
+	case ByZones, BySession:
+		return m, nil
+	}
+	return "", fmt.Errorf("invalid method %q: must be zones or session", s)
+}
+
+// Distribution is training time in the three intensity zones of the
+// polarized model: low (below the first ventilatory threshold, heart rate
+// zones 1–2), moderate (between the thresholds, zone 3) and high (zones 4–5).
+type Distribution struct {
+	Method   IntensityMethod `json:"method"`
+	Sessions int             `json:"sessions"`
+	Low      int             `json:"low"`      // seconds
+	Moderate int             `json:"moderate"` // seconds
+	High     int             `json:"high"`     // seconds
+	Index    float64         `json:"polarization_index,omitempty"`
+	Model    string          `json:"model"` // polarized, pyramidal, threshold or high
+}
+
+// Shares returns the fraction of time in each zone: low, moderate, high.
+func (d Distribution) Shares() (low, moderate, high float64) {
+	total := float64(d.Low + d.Moderate + d.High)
+	if total == 0 {
+		return 0, 0, 0
+	}
+	return float64(d.Low) / total, float64(d.Moderate) / total, float64(d.High) / total
+}
+
+// Polarization sums the intensity distribution of the classified activities
+// (those found in classes with a class), optionally of one sport, and names
+// the model it fits.
+func Polarization(acts []Activity, classes map[int64]Classification, method IntensityMethod, sport string) Distribution {
+	d := Distribution{Method: method}
+	for _, a := range acts {
+		c, ok := classes[a.ID]
+		if !ok || c.Class == "" || (sport != "" && !strings.EqualFold(a.SportType, sport)) {
+			continue
+		}
+		d.Sessions++
+		if method == BySession {
+			switch c.Class {
+			case Easy:
+				d.Low += a.MovingTime
+			case Tempo:
+				d.Moderate += a.MovingTime
+			default:
+				d.High += a.MovingTime
+			}
+			continue
+		}
+		for z, s := range c.Zones {
+			switch {
+			case z < 2:
+				d.Low += s
+			case z == 2:
+				d.Moderate += s
+			default:
+				d.High += s
+			}
+		}
+	}
+	d.Index, d.Model = polarizationModel(d.Shares())
+	return d
+}
+
+// polarizationModel names the distribution with shares low, moderate and
+// high. Following Treff et al. (2019), it is polarized when low > high >
+// moderate and the polarization index log10(low / moderate × high × 100)
+// exceeds 2 (the index is 0 otherwise); else pyramidal when low is the
+// largest share, threshold when moderate is, and high when high is.
+func polarizationModel(low, moderate, high float64) (float64, string) {
+	if low > high && high > moderate {
+		if index := math.Log10(low / math.Max(moderate, 0.01) * high * 100); index > 2 {
+			return math.Round(index*100) / 100, "polarized"
+		}
+	}
+	switch {
+	case low+moderate+high == 0:
+		return 0, ""
+	case low >= moderate && low >= high:
+		return 0, "pyramidal"
+	case moderate >= high:
+		return 0, "threshold"
+	default:
+		return 0, "high"
+	}
+}
@@ -0,0 +1,211 @@
+package analysis
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// BestDistance is a standard distance a run's fastest time is tracked over.
+type BestDistance struct {
+	Name   string
+	Meters float64
+}
+
+// BestDuration is a standard duration an activity's highest average power
+// is tracked over.
+type BestDuration struct {
+	Name    string
+	Seconds int
+}
+
+// BestDistances are the run distances personal records are kept for.
+var BestDistances = []BestDistance{
+	{"1k", 1000}, {"5k", 5000}, {"10k", 10000}, {"half marathon", 21097.5}, {"marathon", 42195},
+}
+
+// BestDurations are the durations power records are kept for.
+var BestDurations = []BestDuration{
+	{"5s", 5}, {"1m", 60}, {"5m", 300}, {"20m", 1200},
+}
+
+// runSports are the sport types whose distance efforts are tracked.
+var runSports = map[string]bool{"Run": true, "TrailRun": true, "VirtualRun": true}
+
+// Bests is an activity's best efforts: the fastest time, in elapsed seconds,
+// over each of BestDistances it covered, and the highest average power over
+// each of BestDurations it lasted.
+type Bests struct {
+	Times  map[string]int     `json:"times,omitempty"`
+	Powers map[string]float64 `json:"powers,omitempty"`
+}
+
+// HasBests reports whether an activity can have best efforts: a run with a
+// distance, or an activity with power.
+func HasBests(a Activity) bool {
+	return !a.Manual && ((runSports[a.SportType] && a.Distance > 0) || a.AverageWatts > 0)
+}
+
+// BestEfforts finds an activity's best efforts in its time, distance and
+// watts streams. Distance efforts are only looked for in runs.
+func BestEfforts(a Activity, s *Streams) Bests {
+	var b Bests
+	if s == nil || len(s.Time) < 2 {
+		return b
+	}
+	if runSports[a.SportType] && len(s.Distance) == len(s.Time) {
+		for _, d := range BestDistances {
+			if t, ok := fastestOver(s.Time, s.Distance, d.Meters); ok {
+				if b.Times == nil {
+					b.Times = map[string]int{}
+				}
+				b.Times[d.Name] = t
+			}
+		}
+	}
+	if len(s.Watts) == len(s.Time) {
+		secs := resample(s, s.Watts)
+		for _, d := range BestDurations {
+			if w := bestAverage(secs, d.Seconds); w > 0 {
+				if b.Powers == nil {
+					b.Powers = map[string]float64{}
+				}
+				b.Powers[d.Name] = w
+			}
+		}
+	}
+	return b
+}
+
+// fastestOver returns the shortest time taken to cover meters anywhere in
+// the distance stream dist, sampled at times, interpolating where the effort
+// starts between samples. ok is false when the whole stream is shorter.
+func fastestOver(times []int, dist []float64, meters float64) (best int, ok bool) {
+	i := 0
+	for j := range dist {
+		if dist[j]-dist[0] < meters {
+			continue
+		}
+		for i+1 < j && dist[j]-dist[i+1] >= meters {
+			i++
+		}
+		start := float64(times[i])
+		if gap := dist[i+1] - dist[i]; gap > 0 {
+			start += float64(times[i+1]-times[i]) * (dist[j] - meters - dist[i]) / gap
+		}
+		if t := int(float64(times[j]) - start + 0.5); !ok || t < best {
+			best, ok = t, true
+		}
+	}
+	return best, ok
+}
+
+// bestAverage is the highest average of n consecutive values in secs, or 0
+// when there are fewer.
+func bestAverage(secs []float64, n int) float64 {
+	if n < 1 || len(secs) < n {
+		return 0
+	}
+	sum, best := 0.0, 0.0
+	for i, w := range secs {
+		sum += w
+		if i >= n {
+			sum -= secs[i-n]
+		}
+		if i >= n-1 {
+			best = max(best, sum/float64(n))
+		}
+	}
+	return best
+}
+
+// PR is a personal record: the best time over a distance, or average power
+// over a duration, of one sport, and the activity it was set in.
+type PR struct {
+	Sport        string    `json:"sport"`
+	Effort       string    `json:"effort"` // a BestDistances or BestDurations name
+	Seconds      int       `json:"seconds,omitempty"`
+	Meters       float64   `json:"meters,omitempty"`
+	Watts        float64   `json:"watts,omitempty"`
+	ActivityID   int64     `json:"activity_id"`
+	ActivityName string    `json:"activity_name"`
+	Date         time.Time `json:"date"`
+	New          bool      `json:"new,omitempty"` // set since the records were last updated
+}
+
+// Key identifies the record a PR is for.
+func (p PR) Key() string { return p.Sport + "/" + p.Effort }
+
+// Beats reports whether p is better than q: faster, or more powerful.
+func (p PR) Beats(q PR) bool {
+	if p.Meters > 0 {
+		return p.Seconds < q.Seconds
+	}
+	return p.Watts > q.Watts
+}
+
+// PersonalRecords returns the best effort of each sport over each distance
+// and duration among acts, looking each activity's efforts up in bests by
+// ID. They are ordered by sport, then distance efforts before power ones,
+// shortest first; ties go to the earlier activity.
+func PersonalRecords(acts []Activity, bests map[int64]Bests) []PR {
+	sorted := slices.Clone(acts)
+	slices.SortStableFunc(sorted, func(a, b Activity) int { return a.StartDate.Compare(b.StartDate) })
+	best := map[string]PR{}
+	offer := func(p PR) {
+		if cur, ok := best[p.Key()]; !ok || p.Beats(cur) {
+			best[p.Key()] = p
+		}
+	}
+	for _, a := range sorted {
+		b, ok := bests[a.ID]
+		if !ok {
+			continue
+		}
+		pr := PR{Sport: a.SportType, ActivityID: a.ID, ActivityName: a.Name, Date: a.StartDateLocal}
+		for _, d := range BestDistances {
+			if t, ok := b.Times[d.Name]; ok {
+				pr.Effort, pr.Meters, pr.Seconds = d.Name, d.Meters, t
+				offer(pr)
+			}
+		}
+		pr.Meters, pr.Seconds = 0, 0
+		for _, d := range BestDurations {
+			if w, ok := b.Powers[d.Name]; ok {
+				pr.Effort, pr.Watts = d.Name, w
+				offer(pr)
+			}
+		}
+	}
+	out := make([]PR, 0, len(best))
+	for _, p := range best {
+		out = append(out, p)
+	}
+	sortPRs(out)
+	return out
+}
+
+// sortPRs orders records by sport, then distance efforts before power ones,
+// shortest first.
+func sortPRs(prs []PR) {
+	slices.SortFunc(prs, func(a, b PR) int {
+		if c := strings.Compare(a.Sport, b.Sport); c != 0 {
+			return c
+		}
+		return effortRank(a.Effort) - effortRank(b.Effort)
+	})
+}
+
+func effortRank(name string) int {
+	for i, d := range BestDistances {
+		if d.Name == name {
+			return i
+		}
+	}
+	for i, d := range BestDurations {
+		if d.Name == name {
+			return len(BestDistances) + i
+		}
+	}
+	return len(BestDistances) + len(BestDurations)
+}
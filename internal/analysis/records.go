@@ -0,0 +1,133 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RecordMetric is the quantity a record block is ranked by.
+type RecordMetric string
+
+const (
+	RecordDistance  RecordMetric = "distance"
+	RecordTime      RecordMetric = "time"
+	RecordElevation RecordMetric = "elevation"
+)
+
+// ParseRecordMetric validates a metric name.
+func ParseRecordMetric(s string) (RecordMetric, error) {
+	switch m := RecordMetric(strings.ToLower(strings.TrimSpace(s))); m {
+	case RecordDistance, RecordTime, RecordElevation:
+		return m, nil
+	}
+	return "", fmt.Errorf("invalid metric %q: must be distance, time or elevation", s)
+}
+
+// value returns the activity's contribution to the metric: meters, moving
+// seconds, or meters climbed.
+func (m RecordMetric) value(a Activity) float64 {
+	switch m {
+	case RecordTime:
+		return float64(a.MovingTime)
+	case RecordElevation:
+		return a.TotalElevationGain
+	default:
+		return a.Distance
+	}
+}
+
+// ParseWindow parses a window length such as "7d", "4w" or "30" (days)
+// into a number of days.
+func ParseWindow(window string) (int, error) {
+	s := strings.ToLower(strings.TrimSpace(window))
+	unit := 1
+	switch {
+	case strings.HasSuffix(s, "w"):
+		s, unit = strings.TrimSuffix(s, "w"), 7
+	case strings.HasSuffix(s, "d"):
+		s = strings.TrimSuffix(s, "d")
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 || n*unit > 3660 {
+		return 0, fmt.Errorf("invalid window %q: use days or weeks, e.g. 7d, 30d or 4w", window)
+	}
+	return n * unit, nil
+}
+
+// Record is a block of consecutive days and its total.
+type Record struct {
+	Start time.Time `json:"start"` // first day
+	End   time.Time `json:"end"`   // last day
+	Value float64   `json:"value"` // meters or seconds, by metric
+	Count int       `json:"count"`
+}
+
+// Records slides a window of days over the activities, by local start date,
+// and returns the top blocks with the highest total of the metric, best
+// first. Blocks do not overlap, so the runner-up is a different period and
+// not the best one shifted by a day. If sport is non-empty only activities of
+// that sport type (case-insensitive) are counted.
+func Records(acts []Activity, metric RecordMetric, days int, sport string, top int) []Record {
+	type day struct {
+		date  time.Time
+		value float64
+		count int
+	}
+	byDate := map[time.Time]*day{}
+	for _, a := range acts {
+		if sport != "" && !strings.EqualFold(a.SportType, sport) {
+			continue
+		}
+		y, m, d := a.StartDateLocal.Date()
+		date := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+		if byDate[date] == nil {
+			byDate[date] = &day{date: date}
+		}
+		byDate[date].value += metric.value(a)
+		byDate[date].count++
+	}
+	var active []*day
+	for _, d := range byDate {
+		active = append(active, d)
+	}
+	sort.Slice(active, func(i, j int) bool { return active[i].date.Before(active[j].date) })
+
+	// The best window can always start on a day with activity: moving its
+	// start forward to the first such day only adds days at its end.
+	var blocks []Record
+	end := 0
+	var sum float64
+	var count int
+	for i, d := range active {
+		last := d.date.AddDate(0, 0, days-1)
+		for ; end < len(active) && !active[end].date.After(last); end++ {
+			sum += active[end].value
+			count += active[end].count
+		}
+		blocks = append(blocks, Record{Start: d.date, End: last, Value: sum, Count: count})
+		sum -= active[i].value
+		count -= active[i].count
+	}
+	sort.SliceStable(blocks, func(i, j int) bool { return blocks[i].Value > blocks[j].Value })
+
+	var out []Record
+	for _, b := range blocks {
+		if len(out) == top || b.Value <= 0 {
+			break
+		}
+		overlaps := false
+		for _, o := range out {
+			if !b.Start.After(o.End) && !o.Start.After(b.End) {
+				overlaps = true
+				break
+			}
+		}
+		if !overlaps {
+			out = append(out, b)
+		}
+	}
+	return out
+}
@@ -0,0 +1,134 @@
+package analysis
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Period is a calendar bucket for training summaries.
+type Period string
+
+const (
+	Week  Period = "week"
+	Month Period = "month"
+	Year  Period = "year"
+)
+
+// ParsePeriod validates a period name.
+func ParsePeriod(s string) (Period, error) {
+	switch p := Period(strings.ToLower(strings.TrimSpace(s))); p {
+	case Week, Month, Year:
+		return p, nil
+	}
+	return "", fmt.Errorf("invalid period %q: must be week, month or year", s)
+}
+
+// Start returns the beginning of the period containing t. Weeks start on
+// Monday, following ISO 8601.
+func (p Period) Start(t time.Time) time.Time {
+	y, m, d := t.Date()
+	switch p {
+	case Year:
+		return time.Date(y, 1, 1, 0, 0, 0, 0, t.Location())
+	case Month:
+		return time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+	default:
+		offset := (int(t.Weekday()) + 6) % 7 // days since Monday
+		return time.Date(y, m, d-offset, 0, 0, 0, 0, t.Location())
+	}
+}
+
+// ParseISOWeek returns the Monday, at midnight in loc, that starts an ISO
+// 8601 week written as "2025-W14" (the form Label gives weeks).
+func ParseISOWeek(s string, loc *time.Location) (time.Time, error) {
+	var y, w int
+	if n, err := fmt.Sscanf(strings.ToUpper(strings.TrimSpace(s)), "%d-W%d", &y, &w); err != nil || n != 2 {
+		return time.Time{}, fmt.Errorf("invalid week %q: use YYYY-Www, e.g. 2025-W14", s)
+	}
+	// January 4th is always in week 1.
+	jan4 := time.Date(y, 1, 4, 0, 0, 0, 0, loc)
+	start := Week.Start(jan4).AddDate(0, 0, 7*(w-1))
+	if w < 1 || w > 53 || Week.Label(start) != fmt.Sprintf("%d-W%02d", y, w) {
+		return time.Time{}, fmt.Errorf("invalid week %q: %d has no week %d", s, y, w)
+	}
+	return start, nil
+}
+
+// next returns the start of the period after the one beginning at start.
+func (p Period) next(start time.Time) time.Time {
+	switch p {
+	case Year:
+		return start.AddDate(1, 0, 0)
+	case Month:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 7)
+	}
+}
+
+// Label formats the period beginning at start, e.g. "2024-W05", "2024-03" or "2024".
+func (p Period) Label(start time.Time) string {
+	switch p {
+	case Year:
+		return start.Format("2006")
+	case Month:
+		return start.Format("2006-01")
+	default:
+		y, w := start.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", y, w)
+	}
+}
+
+// PeriodTotals aggregates the activities that started within one period.
+type PeriodTotals struct {
+	Period        string    `json:"period"`
+	Start         time.Time `json:"start"`
+	Count         int       `json:"count"`
+	Distance      float64   `json:"distance"`    // meters
+	MovingTime    int       `json:"moving_time"` // seconds
+	ElevationGain float64   `json:"elevation_gain"`
+}
+
+// Summarize buckets activities by the local start date into consecutive
+// periods, oldest first. Periods without activities between the first and last
+// are included with zero totals so gaps in training stay visible. If sport is
+// non-empty only activities of that sport type (case-insensitive) are counted.
+func Summarize(acts []Activity, p Period, sport string) []PeriodTotals {
+	byStart := map[time.Time]*PeriodTotals{}
+	var first, last time.Time
+	for _, a := range acts {
+		if sport != "" && !strings.EqualFold(a.SportType, sport) {
+			continue
+		}
+		start := p.Start(a.StartDateLocal)
+		t, ok := byStart[start]
+		if !ok {
+			t = &PeriodTotals{Period: p.Label(start), Start: start}
+			byStart[start] = t
+			if first.IsZero() || start.Before(first) {
+				first = start
+			}
+			if start.After(last) {
+				last = start
+			}
+		}
+		t.Count++
+		t.Distance += a.Distance
+		t.MovingTime += a.MovingTime
+		t.ElevationGain += a.TotalElevationGain
+	}
+	if len(byStart) == 0 {
+		return nil
+	}
+
+	var out []PeriodTotals
+	for s := first; !s.After(last); s = p.next(s) {
+		if t, ok := byStart[s]; ok {
+			out = append(out, *t)
+		} else {
+			out = append(out, PeriodTotals{Period: p.Label(s), Start: s})
+		}
+	}
+	return out
+}
@@ -0,0 +1,71 @@
+package analysis
+
+import (
+	"sort"
+	"time"
+)
+
+// SegmentEffort is one attempt at a segment, with the conditions it was
+// ridden or run in when they have been looked up.
+type SegmentEffort struct {
+	ID               int64     `json:"id"`
+	ActivityID       int64     `json:"activity_id"`
+	StartDate        time.Time `json:"start_date"`
+	StartDateLocal   time.Time `json:"start_date_local"`
+	ElapsedTime      int       `json:"elapsed_time"`
+	MovingTime       int       `json:"moving_time"`
+	AverageHeartrate float64   `json:"average_heartrate,omitempty"`
+	AverageWatts     float64   `json:"average_watts,omitempty"`
+	Weather          *Weather  `json:"weather,omitempty"`
+}
+
+// RankedEffort is an effort in a personal segment leaderboard.
+type RankedEffort struct {
+	Rank int `json:"rank"`
+	SegmentEffort
+	Behind int `json:"behind"` // seconds slower than the best effort
+}
+
+// RankEfforts orders efforts fastest first by elapsed time, as Strava's
+// leaderboards do; ties go to the earlier effort.
+func RankEfforts(efforts []SegmentEffort) []RankedEffort {
+	sorted := append([]SegmentEffort(nil), efforts...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].ElapsedTime != sorted[j].ElapsedTime {
+			return sorted[i].ElapsedTime < sorted[j].ElapsedTime
+		}
+		return sorted[i].StartDate.Before(sorted[j].StartDate)
+	})
+	out := make([]RankedEffort, len(sorted))
+	for i, e := range sorted {
+		out[i] = RankedEffort{Rank: i + 1, SegmentEffort: e, Behind: e.ElapsedTime - sorted[0].ElapsedTime}
+	}
+	return out
+}
+
+// PRStep is an effort that was a personal record when it was set.
+type PRStep struct {
+	SegmentEffort
+	Improvement int `json:"improvement"` // seconds faster than the previous record; 0 for the first
+}
+
+// PRProgression returns the efforts that each beat every earlier one, oldest
+// first: how the personal record came down over time.
+func PRProgression(efforts []SegmentEffort) []PRStep {
+	sorted := append([]SegmentEffort(nil), efforts...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].StartDate.Before(sorted[j].StartDate) })
+	var out []PRStep
+	for _, e := range sorted {
+		if e.ElapsedTime <= 0 {
+			continue
+		}
+		if len(out) == 0 {
+			out = append(out, PRStep{SegmentEffort: e})
+			continue
+		}
+		if best := out[len(out)-1].ElapsedTime; e.ElapsedTime < best {
+			out = append(out, PRStep{SegmentEffort: e, Improvement: best - e.ElapsedTime})
+		}
+	}
+	return out
+}
@@ -0,0 +1,149 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Streak is a run of consecutive days with at least one activity.
+type Streak struct {
+	Days  int       `json:"days"`
+	Start time.Time `json:"start,omitzero"`
+	End   time.Time `json:"end,omitzero"`
+}
+
+// Bucket is one bar of a histogram covering [Min, Max).
+type Bucket struct {
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Count int     `json:"count"`
+}
+
+// Advanced holds whole-history statistics for `stats advanced`.
+type Advanced struct {
+	Activities    int      `json:"activities"`
+	Unit          float64  `json:"unit"`           // meters per Eddington/histogram unit
+	Eddington     int      `json:"eddington"`      // E days with at least E units
+	EddingtonNext int      `json:"eddington_next"` // more days of E+1 units needed to reach E+1
+	CurrentStreak Streak   `json:"current_streak"`
+	LongestStreak Streak   `json:"longest_streak"`
+	Histogram     []Bucket `json:"histogram"` // activity distances, bucket bounds in units
+}
+
+// ComputeAdvanced derives the Eddington number, streaks and a distance
+// histogram from acts. Distances are measured in unit meters (1000 for km,
+// 1609.344 for miles) and the histogram uses buckets bucket units wide. today
+// anchors the current streak. If sport is non-empty only that sport type counts.
+func ComputeAdvanced(acts []Activity, sport string, unit, bucket float64, today time.Time) Advanced {
+	var filtered []Activity
+	for _, a := range acts {
+		if sport == "" || strings.EqualFold(a.SportType, sport) {
+			filtered = append(filtered, a)
+		}
+	}
+
+	daily := map[time.Time]float64{}
+	var distances []float64
+	for _, a := range filtered {
+		daily[day(a.StartDateLocal)] += a.Distance / unit
+		distances = append(distances, a.Distance/unit)
+	}
+	totals := make([]float64, 0, len(daily))
+	days := make([]time.Time, 0, len(daily))
+	for d, v := range daily {
+		totals = append(totals, v)
+		days = append(days, d)
+	}
+
+	e, next := Eddington(totals)
+	current, longest := Streaks(days, day(today))
+	return Advanced{
+		Activities:    len(filtered),
+		Unit:          unit,
+		Eddington:     e,
+		EddingtonNext: next,
+		CurrentStreak: current,
+		LongestStreak: longest,
+		Histogram:     Histogram(distances, bucket),
+	}
+}
+
+// day truncates t to midnight of its calendar date, keeping its location.
+func day(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// Eddington returns the largest E such that at least E of the daily totals are
+// ≥ E, and how many more days of at least E+1 are needed to reach E+1.
+func Eddington(daily []float64) (e, next int) {
+	sorted := append([]float64(nil), daily...)
+	sort.Sort(sort.Reverse(sort.Float64Slice(sorted)))
+	for e < len(sorted) && sorted[e] >= float64(e+1) {
+		e++
+	}
+	have := 0
+	for _, v := range sorted {
+		if v >= float64(e+1) {
+			have++
+		}
+	}
+	return e, e + 1 - have
+}
+
+// Streaks finds the current and longest runs of consecutive days in days
+// (which need not be sorted or unique). The current streak is the run ending
+// today or yesterday, so it is not broken before today's activity is logged.
+func Streaks(days []time.Time, today time.Time) (current, longest Streak) {
+	if len(days) == 0 {
+		return
+	}
+	sorted := append([]time.Time(nil), days...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Before(sorted[j]) })
+
+	run := Streak{Days: 1, Start: sorted[0], End: sorted[0]}
+	longest = run
+	for _, d := range sorted[1:] {
+		switch {
+		case d.Equal(run.End):
+			continue
+		case d.Equal(run.End.AddDate(0, 0, 1)):
+			run.Days++
+			run.End = d
+		default:
+			run = Streak{Days: 1, Start: d, End: d}
+		}
+		if run.Days > longest.Days {
+			longest = run
+		}
+	}
+	if !run.End.Before(today.AddDate(0, 0, -1)) {
+		current = run
+	}
+	return current, longest
+}
+
+// Histogram counts values in consecutive buckets of the given width starting
+// at zero, through the bucket containing the largest value.
+func Histogram(values []float64, width float64) []Bucket {
+	if len(values) == 0 || width <= 0 {
+		return nil
+	}
+	maxV := 0.0
+	for _, v := range values {
+		maxV = math.Max(maxV, v)
+	}
+	out := make([]Bucket, int(maxV/width)+1)
+	for i := range out {
+		out[i] = Bucket{Min: float64(i) * width, Max: float64(i+1) * width}
+	}
+	for _, v := range values {
+		if v < 0 {
+			continue
+		}
+		out[int(v/width)].Count++
+	}
+	return out
+}
@@ -0,0 +1,151 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Weather is the air temperature and wind speed at an activity's start.
+type Weather struct {
+	TempC     float64 `json:"temp_c"`
+	WindSpeed float64 `json:"wind_speed"` // m/s at 10 m
+}
+
+// Conditions in which performance is taken to be unaffected by weather.
+const (
+	neutralHighC = 15.0 // warmer than this slows you down
+	neutralLowC  = 5.0  // colder than this slows you down
+)
+
+// Penalty rates. These are deliberately simple rules of thumb, not a
+// physiological model: the aim is to stop seasonal weather from dominating a
+// long-term trend, not to predict a race time.
+const (
+	heatPenalty     = 0.004  // fraction of speed/power lost per °C above neutralHighC
+	coldPenalty     = 0.002  // fraction lost per °C below neutralLowC
+	footWindPenalty = 0.0005 // fraction of speed lost per (m/s)² of wind, on foot
+	rideWindPenalty = 0.002  // the same on a bike, where drag dominates
+
+	// maxPenalty caps each fraction, so that extreme weather (or a bad
+	// reading) cannot adjust a time to nothing or a power to infinity.
+	maxPenalty = 0.3
+)
+
+// footSports are the sport types whose speed is usually read as pace.
+var footSports = map[string]bool{
+	"run": true, "trailrun": true, "virtualrun": true, "walk": true, "hike": true,
+}
+
+// IsFootSport reports whether sport is run- or walk-like, where speed is
+// conventionally shown as pace.
+func IsFootSport(sport string) bool {
+	return footSports[strings.ToLower(sport)]
+}
+
+// WeatherPenalty returns the fractions of speed and of power an athlete is
+// assumed to lose to the given weather. Headwind and tailwind do not cancel
+// on a loop (the headwind costs more time), so wind is penalized regardless of
+// direction. Wind is not applied to power: riding into it costs speed, not
+// watts. Both fractions are at most maxPenalty.
+func WeatherPenalty(w Weather, sport string) (speed, power float64) {
+	switch {
+	case w.TempC > neutralHighC:
+		power = (w.TempC - neutralHighC) * heatPenalty
+	case w.TempC < neutralLowC:
+		power = (neutralLowC - w.TempC) * coldPenalty
+	}
+	windRate := rideWindPenalty
+	if IsFootSport(sport) {
+		windRate = footWindPenalty
+	}
+	speed = power + windRate*w.WindSpeed*w.WindSpeed
+	return min(speed, maxPenalty), min(power, maxPenalty)
+}
+
+// WeatherTrend is one period of a weather-adjusted performance trend. Speeds
+// are in m/s and power in watts; Adjusted values estimate what the same effort
+// would have produced in neutral conditions. Activities without weather data
+// count unadjusted.
+type WeatherTrend struct {
+	Period        string    `json:"period"`
+	Start         time.Time `json:"start"`
+	Count         int       `json:"count"`
+	WithWeather   int       `json:"with_weather"`
+	AvgTempC      float64   `json:"avg_temp_c,omitempty"`
+	AvgWindSpeed  float64   `json:"avg_wind_speed,omitempty"`
+	Speed         float64   `json:"speed"`
+	AdjustedSpeed float64   `json:"adjusted_speed"`
+	Power         float64   `json:"power,omitempty"`
+	AdjustedPower float64   `json:"adjusted_power,omitempty"`
+}
+
+// WeatherTrends buckets activities into periods (oldest first, skipping
+// empty ones) and computes moving-time weighted average speed and power,
+// both as recorded and adjusted for the weather in weather (keyed by activity
+// ID). Only activities of sport (case-insensitive) are counted when it is set.
+func WeatherTrends(acts []Activity, weather map[int64]Weather, p Period, sport string) []WeatherTrend {
+	type sums struct {
+		row                  WeatherTrend
+		dist, time, adjTime  float64 // meters, seconds, weather-adjusted seconds
+		powTime, pow, adjPow float64 // seconds with power, watt-seconds
+		wxTime, temp, wind   float64 // seconds with weather, time-weighted sums
+	}
+	byStart := map[time.Time]*sums{}
+	var starts []time.Time
+	for _, a := range acts {
+		if sport != "" && !strings.EqualFold(a.SportType, sport) {
+			continue
+		}
+		if a.MovingTime <= 0 || a.Distance <= 0 {
+			continue
+		}
+		start := p.Start(a.StartDateLocal)
+		s, ok := byStart[start]
+		if !ok {
+			s = &sums{row: WeatherTrend{Period: p.Label(start), Start: start}}
+			byStart[start] = s
+			starts = append(starts, start)
+		}
+		t := float64(a.MovingTime)
+		speedLoss, powerLoss := 0.0, 0.0
+		if w, ok := weather[a.ID]; ok {
+			speedLoss, powerLoss = WeatherPenalty(w, a.SportType)
+			s.row.WithWeather++
+			s.wxTime += t
+			s.temp += w.TempC * t
+			s.wind += w.WindSpeed * t
+		}
+		s.row.Count++
+		s.dist += a.Distance
+		s.time += t
+		s.adjTime += t * (1 - speedLoss)
+		if a.AverageWatts > 0 {
+			s.powTime += t
+			s.pow += a.AverageWatts * t
+			s.adjPow += a.AverageWatts / (1 - powerLoss) * t
+		}
+	}
+
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+	out := make([]WeatherTrend, 0, len(starts))
+	for _, start := range starts {
+		s := byStart[start]
+		r := s.row
+		r.Speed = s.dist / s.time
+		r.AdjustedSpeed = s.dist / s.adjTime
+		if s.wxTime > 0 {
+			r.AvgTempC = round1(s.temp / s.wxTime)
+			r.AvgWindSpeed = round1(s.wind / s.wxTime)
+		}
+		if s.powTime > 0 {
+			r.Power = s.pow / s.powTime
+			r.AdjustedPower = s.adjPow / s.powTime
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+func round1(v float64) float64 { return math.Round(v*10) / 10 }
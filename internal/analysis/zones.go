@@ -0,0 +1,102 @@
+package analysis
+
+import (
+	"math"
+	"slices"
+	"strings"
+)
+
+// ZoneTime is the seconds an activity spent in each zone, with the lower
+// bounds of the zones it was measured against.
+type ZoneTime struct {
+	Zones  []int `json:"zones"`
+	Bounds []int `json:"bounds"`
+}
+
+// ftpZoneShares are the lower bounds of Coggan's seven power zones, as
+// shares of FTP: active recovery, endurance, tempo, threshold, VO2 max,
+// anaerobic capacity and neuromuscular power.
+var ftpZoneShares = []float64{0, 0.56, 0.76, 0.91, 1.06, 1.21, 1.51}
+
+// PowerZones returns the lower bounds, in watts, of the seven power zones
+// for ftp.
+func PowerZones(ftp float64) []int {
+	bounds := make([]int, len(ftpZoneShares))
+	for i, s := range ftpZoneShares {
+		bounds[i] = int(math.Round(s * ftp))
+	}
+	return bounds
+}
+
+// TimeInPowerZones sums the seconds spent in each power zone, given the
+// zones' lower bounds in ascending order. Coasting at 0 W counts in the
+// first zone; pauses count as for TimeInZones.
+func TimeInPowerZones(s *Streams, bounds []int) []int {
+	if s == nil {
+		return make([]int, len(bounds))
+	}
+	return timeInZones(s.Time, s.Watts, bounds, 0)
+}
+
+// ZoneShare is the time spent in one zone, from Min up to Max (0 for the
+// open top zone).
+type ZoneShare struct {
+	Zone    int     `json:"zone"` // from 1
+	Min     int     `json:"min"`
+	Max     int     `json:"max,omitempty"`
+	Seconds int     `json:"seconds"`
+	Share   float64 `json:"share"`
+}
+
+// ZoneDistribution is the time a set of activities spent in each heart rate
+// or power zone, and the same time split into the three intensities of the
+// polarized model: zones 1–2 low, zone 3 moderate, the rest high.
+type ZoneDistribution struct {
+	Metric     string       `json:"metric"` // heartrate or power
+	Activities int          `json:"activities"`
+	Zones      []ZoneShare  `json:"zones"`
+	Intensity  Distribution `json:"intensity"`
+}
+
+// SumZones adds up the zone times of the activities in acts (optionally of
+// one sport) found in times that were measured against bounds; the others
+// were measured against zones that have since changed.
+func SumZones(metric string, acts []Activity, times map[int64]ZoneTime, bounds []int, sport string) ZoneDistribution {
+	d := ZoneDistribution{Metric: metric, Intensity: Distribution{Method: ByZones}}
+	secs := make([]int, len(bounds))
+	for _, a := range acts {
+		t, ok := times[a.ID]
+		if !ok || !slices.Equal(t.Bounds, bounds) || (sport != "" && !strings.EqualFold(a.SportType, sport)) {
+			continue
+		}
+		d.Activities++
+		for z, s := range t.Zones {
+			secs[z] += s
+		}
+	}
+	total := 0
+	for _, s := range secs {
+		total += s
+	}
+	for z, s := range secs {
+		zs := ZoneShare{Zone: z + 1, Min: bounds[z], Seconds: s}
+		if z+1 < len(bounds) {
+			zs.Max = bounds[z+1] - 1
+		}
+		if total > 0 {
+			zs.Share = float64(s) / float64(total)
+		}
+		d.Zones = append(d.Zones, zs)
+		switch {
+		case z < 2:
+			d.Intensity.Low += s
+		case z == 2:
+			d.Intensity.Moderate += s
+		default:
+			d.Intensity.High += s
+		}
+	}
+	d.Intensity.Sessions = d.Activities
+	d.Intensity.Index, d.Intensity.Model = polarizationModel(d.Intensity.Shares())
+	return d
+}
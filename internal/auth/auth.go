@@ -17,13 +17,21 @@ import (
 	"time"
 
 	"github.com/Brainsoft-Raxat/strava-cli/internal/config"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/log"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/redact"
 )
 
+var logger = log.New("auth")
+
 const (
 	authURL      = "https://www.strava.com/oauth/authorize"
 	redirectHost = "localhost"
 	redirectPort = "8089"
 	scopes       = "activity:read_all,activity:write"
+	// optionalScopes are requested at login as well, but only the commands
+	// that change the athlete's profile (segments star/unstar) need them, so
+	// their absence is not reported as a setup problem.
+	optionalScopes = "profile:write"
 )
 
 // tokenURL is a variable so tests can override it with httptest servers.
@@ -37,6 +45,17 @@ func SetTokenURL(u string) string {
 	return prev
 }
 
+// transport sends the token requests; nil uses http.DefaultTransport.
+var transport http.RoundTripper //nolint:gochecknoglobals
+
+// SetTransport makes token requests go over rt, the transport the API
+// client uses (a proxy, say), and returns the previous one.
+func SetTransport(rt http.RoundTripper) http.RoundTripper {
+	prev := transport
+	transport = rt
+	return prev
+}
+
 // Login initiates the OAuth2 authorization code flow.
 //
 // If redirectURI is empty or a localhost URI, a local callback server is started
@@ -72,7 +91,7 @@ func isLocalhost(u string) bool {
 func loginLocal(clientID, clientSecret, redirectURI string) (*config.Tokens, error) {
 	authLink := buildAuthURL(clientID, redirectURI)
 
-	codeCh := make(chan string, 1)
+	codeCh := make(chan url.Values, 1)
 	errCh := make(chan error, 1)
 
 	mux := http.NewServeMux()
@@ -90,7 +109,7 @@ func loginLocal(clientID, clientSecret, redirectURI string) (*config.Tokens, err
 			fmt.Fprintf(w, "<html><body><h2>Authorization failed: %s</h2><p>You may close this tab.</p></body></html>", e)
 			return
 		}
-		codeCh <- r.URL.Query().Get("code")
+		codeCh <- r.URL.Query()
 		fmt.Fprintf(w, "<html><body><h2>Authorization successful!</h2><p>You may close this tab.</p></body></html>")
 	})
 
@@ -114,9 +133,9 @@ func loginLocal(clientID, clientSecret, redirectURI string) (*config.Tokens, err
 	fmt.Println()
 	fmt.Println("Waiting for callback...")
 
-	var code string
+	var query url.Values
 	select {
-	case code = <-codeCh:
+	case query = <-codeCh:
 	case err = <-errCh:
 		_ = srv.Shutdown(context.Background())
 		return nil, err
@@ -126,7 +145,7 @@ func loginLocal(clientID, clientSecret, redirectURI string) (*config.Tokens, err
 	}
 	_ = srv.Shutdown(context.Background())
 
-	return exchangeCode(clientID, clientSecret, code, redirectURI)
+	return exchangeCode(clientID, clientSecret, query.Get("code"), query.Get("scope"), redirectURI)
 }
 
 // loginManual displays the auth URL and asks the user to paste back the code.
@@ -150,10 +169,10 @@ func loginManual(clientID, clientSecret, redirectURI string) (*config.Tokens, er
 
 	code := extractCode(pasted)
 	if code == "" {
-		return nil, fmt.Errorf("no authorization code found in %q\n  Hint: paste the full redirect URL or just the code value", pasted)
+		return nil, fmt.Errorf("no authorization code found in %q\n  Hint: paste the full redirect URL or just the code value", redact.String(pasted))
 	}
 
-	return exchangeCode(clientID, clientSecret, code, redirectURI)
+	return exchangeCode(clientID, clientSecret, code, extractScope(pasted), redirectURI)
 }
 
 // ExtractCode parses an authorization code from either a full URL or a bare code string.
@@ -174,13 +193,23 @@ func extractCode(input string) string {
 	return input
 }
 
+// extractScope returns the granted scopes from a pasted redirect URL, or "" if
+// the input is a bare code.
+func extractScope(input string) string {
+	parsed, err := url.Parse(strings.ReplaceAll(strings.TrimSpace(input), `\`, ""))
+	if err != nil {
+		return ""
+	}
+	return parsed.Query().Get("scope")
+}
+
 func buildAuthURL(clientID, redirectURI string) string {
 	params := url.Values{
 		"client_id":       {clientID},
 		"redirect_uri":    {redirectURI},
 		"response_type":   {"code"},
 		"approval_prompt": {"auto"},
-		"scope":           {scopes},
+		"scope":           {scopes + "," + optionalScopes},
 	}
 	return authURL + "?" + params.Encode()
 }
@@ -197,8 +226,9 @@ func RefreshIfExpired(cfg *config.Config) error {
 	}
 	tokens, err := refreshTokens(cfg.ClientID, cfg.ClientSecret, cfg.Tokens.RefreshToken)
 	if err != nil {
-		return fmt.Errorf("refresh token: %w\n  Hint: your session may have been revoked; run: stravacli auth login", err)
+		return redact.Error(fmt.Errorf("refresh token: %w\n  Hint: your session may have been revoked; run: stravacli auth login", err))
 	}
+	tokens.Scope = cfg.Tokens.Scope // the refresh response does not repeat scopes
 	cfg.Tokens = *tokens
 	return config.Save(cfg)
 }
@@ -217,7 +247,9 @@ type tokenResponse struct {
 	Message string `json:"message"`
 }
 
-func exchangeCode(clientID, clientSecret, code, redirectURI string) (*config.Tokens, error) {
+// exchangeCode trades an authorization code for tokens. scope is the list of
+// granted scopes from the redirect URL, recorded for diagnostics.
+func exchangeCode(clientID, clientSecret, code, scope, redirectURI string) (*config.Tokens, error) {
 	tokens, err := postToken(url.Values{
 		"client_id":     {clientID},
 		"client_secret": {clientSecret},
@@ -228,6 +260,7 @@ func exchangeCode(clientID, clientSecret, code, redirectURI string) (*config.Tok
 	if err != nil {
 		return nil, fmt.Errorf("token exchange: %w", err)
 	}
+	tokens.Scope = scope
 	return tokens, nil
 }
 
@@ -240,10 +273,26 @@ func refreshTokens(clientID, clientSecret, refreshToken string) (*config.Tokens,
 	})
 }
 
+// postToken posts vals to the token endpoint. The credentials in vals and
+// the tokens returned are registered with redact, so an error echoing them
+// (or any later message) does not show them.
 func postToken(vals url.Values) (*config.Tokens, error) {
-	resp, err := http.PostForm(tokenURL, vals)
+	grant := vals.Get("grant_type")
+	tokens, err := requestToken(vals)
+	if err != nil {
+		logger.Error("token request failed", "grant_type", grant, "error", err)
+		return nil, err
+	}
+	logger.Info("token granted", "grant_type", grant,
+		"expires_at", time.Unix(tokens.ExpiresAt, 0).UTC().Format(time.RFC3339))
+	return tokens, nil
+}
+
+func requestToken(vals url.Values) (*config.Tokens, error) {
+	redact.Add(vals.Get("client_secret"), vals.Get("code"), vals.Get("refresh_token"))
+	resp, err := (&http.Client{Transport: transport}).PostForm(tokenURL, vals)
 	if err != nil {
-		return nil, fmt.Errorf("POST %s: %w", tokenURL, err)
+		return nil, redact.Error(fmt.Errorf("POST %s: %w", tokenURL, err))
 	}
 	defer resp.Body.Close()
 	body, err := io.ReadAll(resp.Body)
@@ -256,13 +305,14 @@ func postToken(vals url.Values) (*config.Tokens, error) {
 	}
 	if resp.StatusCode != http.StatusOK {
 		if tr.Message != "" {
-			return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, tr.Message)
+			return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, redact.String(tr.Message))
 		}
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, redact.String(strings.TrimSpace(string(body))))
 	}
 	if tr.AccessToken == "" {
 		return nil, fmt.Errorf("no access_token in response")
 	}
+	redact.Add(tr.AccessToken, tr.RefreshToken)
 	return &config.Tokens{
 		AccessToken:  tr.AccessToken,
 		RefreshToken: tr.RefreshToken,
@@ -291,7 +341,7 @@ func RemoteAuthURL(clientID, redirectURI, state string) string {
 		"redirect_uri":    {redirectURI},
 		"response_type":   {"code"},
 		"approval_prompt": {"auto"},
-		"scope":           {scopes},
+		"scope":           {scopes + "," + optionalScopes},
 		"state":           {state},
 	}
 	return authURL + "?" + params.Encode()
@@ -311,9 +361,9 @@ func CompleteRemoteLogin(clientID, clientSecret, redirectURI, expectedState, pas
 		}
 	}
 	if code == "" {
-		return nil, fmt.Errorf("no authorization code found in %q\n  Hint: paste the full redirect URL, e.g. http://localhost:8089/callback?code=...&state=...", pastedInput)
+		return nil, fmt.Errorf("no authorization code found in %q\n  Hint: paste the full redirect URL, e.g. http://localhost:8089/callback?code=...&state=...", redact.String(pastedInput))
 	}
-	return exchangeCode(clientID, clientSecret, code, redirectURI)
+	return exchangeCode(clientID, clientSecret, code, extractScope(pastedInput), redirectURI)
 }
 
 // extractCodeAndState parses both the code and state query params from a URL string.
@@ -2,8 +2,10 @@ package auth_test
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -153,6 +155,7 @@ func TestRefreshIfExpired_Expired(t *testing.T) {
 			AccessToken:  "expired-token",
 			RefreshToken: "old-refresh",
 			ExpiresAt:    time.Now().Add(-10 * time.Minute).Unix(), // expired
+			Scope:        "read,activity:read_all",
 		},
 	}
 
@@ -165,4 +168,96 @@ func TestRefreshIfExpired_Expired(t *testing.T) {
 	if cfg.Tokens.RefreshToken != newRefresh {
 		t.Errorf("refresh token = %q, want %q", cfg.Tokens.RefreshToken, newRefresh)
 	}
+	if cfg.Tokens.Scope != "read,activity:read_all" {
+		t.Errorf("scope = %q, want it preserved across refresh", cfg.Tokens.Scope)
+	}
+}
+
+// --- Diagnose ---
+
+func TestMissingScopes(t *testing.T) {
+	if got := auth.MissingScopes("read,activity:read_all,activity:write"); len(got) != 0 {
+		t.Errorf("all granted: got missing %v", got)
+	}
+	got := auth.MissingScopes("read,activity:read")
+	if len(got) != 2 || got[0] != "activity:read_all" || got[1] != "activity:write" {
+		t.Errorf("got missing %v, want [activity:read_all activity:write]", got)
+	}
+}
+
+func TestDiagnose(t *testing.T) {
+	levels := func(checks []auth.Check) []string {
+		var out []string
+		for _, c := range checks {
+			out = append(out, c.Level)
+		}
+		return out
+	}
+
+	good := &config.Config{
+		ClientID:     "12345",
+		ClientSecret: "s",
+		Tokens:       config.Tokens{AccessToken: "a", Scope: "read,activity:read_all,activity:write"},
+	}
+	for i, c := range auth.Diagnose(good) {
+		if c.Level != auth.CheckOK {
+			t.Errorf("check %d on a good config = %+v", i, c)
+		}
+	}
+
+	bad := &config.Config{
+		ClientID:     "My App",
+		ClientSecret: "s",
+		RedirectURI:  "https://203.0.113.7/cb",
+		Tokens:       config.Tokens{AccessToken: "a", Scope: "read"},
+	}
+	got := levels(auth.Diagnose(bad))
+	want := []string{auth.CheckWarn, auth.CheckWarn, auth.CheckWarn}
+	if len(got) != len(want) {
+		t.Fatalf("levels = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("levels = %v, want %v", got, want)
+			break
+		}
+	}
+}
+
+func TestCallbackDomain(t *testing.T) {
+	if got := auth.CallbackDomain("https://auth.example.com:8443/strava/callback"); got != "auth.example.com" {
+		t.Errorf("CallbackDomain = %q, want auth.example.com", got)
+	}
+}
+
+// TestRefreshIfExpired_ErrorRedacted checks that a token endpoint error
+// echoing the request's credentials does not carry them into the error.
+func TestRefreshIfExpired_ErrorRedacted(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, `{"message":"bad refresh_token %s for client_secret %s"}`, r.FormValue("refresh_token"), r.FormValue("client_secret"))
+	}))
+	defer srv.Close()
+	orig := auth.SetTokenURL(srv.URL)
+	defer auth.SetTokenURL(orig)
+
+	cfg := &config.Config{
+		ClientID:     "cid",
+		ClientSecret: "client-secret-value",
+		Tokens: config.Tokens{
+			AccessToken:  "expired-access-value",
+			RefreshToken: "refresh-token-value",
+			ExpiresAt:    time.Now().Add(-10 * time.Minute).Unix(),
+		},
+	}
+	err := auth.RefreshIfExpired(cfg)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, secret := range []string{"client-secret-value", "refresh-token-value"} {
+		if strings.Contains(err.Error(), secret) {
+			t.Errorf("error shows %q: %v", secret, err)
+		}
+	}
 }
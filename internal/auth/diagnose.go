@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/config"
+)
+
+// Check severities reported by Diagnose.
+const (
+	CheckOK   = "ok"
+	CheckWarn = "warn"
+	CheckFail = "fail"
+)
+
+// Check is the outcome of one setup diagnostic.
+type Check struct {
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
+// RedirectURI returns the redirect URI login uses for cfg.
+func RedirectURI(cfg *config.Config) string {
+	if cfg.RedirectURI != "" {
+		return cfg.RedirectURI
+	}
+	return fmt.Sprintf("http://%s:%s/callback", redirectHost, redirectPort)
+}
+
+// CallbackDomain returns the value the Strava app's "Authorization Callback
+// Domain" setting must have for redirectURI to be accepted.
+func CallbackDomain(redirectURI string) string {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// MissingScopes returns the scopes strava-cli requests that are absent from
+// granted (a comma-separated list as returned in the OAuth redirect).
+func MissingScopes(granted string) []string {
+	have := map[string]bool{}
+	for _, s := range strings.Split(granted, ",") {
+		have[strings.TrimSpace(s)] = true
+	}
+	var missing []string
+	for _, s := range strings.Split(scopes, ",") {
+		if !have[s] {
+			missing = append(missing, s)
+		}
+	}
+	return missing
+}
+
+// Diagnose checks cfg for the most common setup mistakes: missing
+// credentials, a redirect URI Strava will reject, and scopes that were not
+// granted on the consent screen. It makes no network calls.
+func Diagnose(cfg *config.Config) []Check {
+	var checks []Check
+	add := func(level, format string, args ...any) {
+		checks = append(checks, Check{Level: level, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch {
+	case cfg.ClientID == "" || cfg.ClientSecret == "":
+		add(CheckFail, "client ID or secret missing — run: stravacli auth login")
+	case strings.Trim(cfg.ClientID, "0123456789") != "":
+		add(CheckWarn, "client ID %q is not numeric — copy the Client ID (not the app name) from https://www.strava.com/settings/api", cfg.ClientID)
+	default:
+		add(CheckOK, "client credentials configured")
+	}
+
+	redirect := RedirectURI(cfg)
+	u, err := url.Parse(redirect)
+	switch {
+	case err != nil || u.Scheme == "" || u.Host == "":
+		add(CheckFail, "redirect URI %q is not an absolute URL", redirect)
+	case u.Scheme != "http" && u.Scheme != "https":
+		add(CheckFail, "redirect URI %q must use http or https", redirect)
+	case isLocalhost(redirect):
+		add(CheckOK, "redirect URI %s uses the local callback server; the app's callback domain must be %q", redirect, u.Hostname())
+	case net.ParseIP(u.Hostname()) != nil:
+		add(CheckWarn, "redirect URI %s uses an IP address; Strava only accepts a callback domain, so use a host name or localhost", redirect)
+	default:
+		add(CheckOK, "redirect URI %s; the app's callback domain must be %q (or a parent domain)", redirect, u.Hostname())
+	}
+
+	switch {
+	case cfg.Tokens.AccessToken == "":
+		add(CheckFail, "not logged in — run: stravacli auth login")
+	case cfg.Tokens.Scope == "":
+		add(CheckWarn, "granted scopes unknown (logged in with an older version) — run: stravacli auth login to record them")
+	default:
+		if missing := MissingScopes(cfg.Tokens.Scope); len(missing) > 0 {
+			add(CheckWarn, "scope(s) %s not granted — some commands will fail with HTTP 403; run: stravacli auth login and leave every box ticked",
+				strings.Join(missing, ", "))
+		} else {
+			add(CheckOK, "all requested scopes granted")
+		}
+	}
+	return checks
+}
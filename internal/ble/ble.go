@@ -0,0 +1,118 @@
+// Package ble reads heart rate monitors and power meters over Bluetooth Low
+// Energy, through their standard GATT services: Heart Rate (0x180D) and
+// Cycling Power (0x1818), which chest straps, power pedals, smart trainers
+// and most watches broadcasting heart rate offer. On Linux it talks to BlueZ
+// over D-Bus; elsewhere Connect returns ErrUnsupported.
+package ble
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// The services and characteristics read, as BlueZ names them.
+const (
+	HeartRateService        = "0000180d-0000-1000-8000-00805f9b34fb"
+	CyclingPowerService     = "00001818-0000-1000-8000-00805f9b34fb"
+	heartRateMeasurement    = "00002a37-0000-1000-8000-00805f9b34fb"
+	cyclingPowerMeasurement = "00002a63-0000-1000-8000-00805f9b34fb"
+)
+
+// ErrUnsupported is returned by Connect where there is no Bluetooth stack
+// this package can use.
+var ErrUnsupported = errors.New("BLE sensors are only supported on Linux, with BlueZ")
+
+// Kind is what a sensor measures.
+type Kind int
+
+const (
+	HeartRateMonitor Kind = iota
+	PowerMeter
+)
+
+// String names the kind, e.g. "heart rate monitor".
+func (k Kind) String() string {
+	if k == PowerMeter {
+		return "power meter"
+	}
+	return "heart rate monitor"
+}
+
+// Sensor is a connected device.
+type Sensor struct {
+	Name    string
+	Address string
+	Kind    Kind
+}
+
+// Reading is a measurement a sensor sent. Values it does not measure, or
+// could not work out from this one measurement, are negative.
+type Reading struct {
+	Sensor    Sensor
+	HeartRate int // bpm
+	Power     int // watts
+	Cadence   int // rpm, from a power meter's crank revolutions
+}
+
+// ParseHeartRate decodes a Heart Rate Measurement value: a flags byte, then
+// the rate as a uint8, or a uint16 when flag bit 0 is set.
+func ParseHeartRate(b []byte) (int, error) {
+	switch {
+	case len(b) >= 2 && b[0]&0x01 == 0:
+		return int(b[1]), nil
+	case len(b) >= 3:
+		return int(binary.LittleEndian.Uint16(b[1:])), nil
+	}
+	return 0, fmt.Errorf("heart rate measurement: %d bytes", len(b))
+}
+
+// CyclingPower is a decoded Cycling Power Measurement.
+type CyclingPower struct {
+	Power int // watts
+	// Crank revolutions so far and the time of the last one, in 1/1024 s,
+	// if HasCrank; both wrap around.
+	CrankRevs uint16
+	CrankTime uint16
+	HasCrank  bool
+}
+
+// ParseCyclingPower decodes a Cycling Power Measurement value: uint16 flags,
+// the instantaneous power as a sint16, then optional fields as the flags
+// say, of which only the crank revolution data is kept.
+func ParseCyclingPower(b []byte) (CyclingPower, error) {
+	if len(b) < 4 {
+		return CyclingPower{}, fmt.Errorf("cycling power measurement: %d bytes", len(b))
+	}
+	flags := binary.LittleEndian.Uint16(b)
+	p := CyclingPower{Power: int(int16(binary.LittleEndian.Uint16(b[2:])))}
+	off := 4
+	if flags&0x01 != 0 { // pedal power balance
+		off++
+	}
+	if flags&0x04 != 0 { // accumulated torque
+		off += 2
+	}
+	if flags&0x10 != 0 { // wheel revolutions and last wheel event time
+		off += 6
+	}
+	if flags&0x20 != 0 && len(b) >= off+4 {
+		p.CrankRevs = binary.LittleEndian.Uint16(b[off:])
+		p.CrankTime = binary.LittleEndian.Uint16(b[off+2:])
+		p.HasCrank = true
+	}
+	return p, nil
+}
+
+// Cadence works out the cadence in rpm between two measurements with crank
+// data, or returns -1 if no crank revolution happened between them.
+func Cadence(prev, cur CyclingPower) int {
+	if !prev.HasCrank || !cur.HasCrank {
+		return -1
+	}
+	revs, ticks := cur.CrankRevs-prev.CrankRevs, cur.CrankTime-prev.CrankTime
+	if revs == 0 || ticks == 0 {
+		return -1
+	}
+	return int(float64(revs) * 60 * 1024 / float64(ticks))
+}
@@ -0,0 +1,54 @@
+package ble_test
+
+import (
+	"testing"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/ble"
+)
+
+func TestParseHeartRate(t *testing.T) {
+	tests := []struct {
+		in   []byte
+		want int
+	}{
+		{[]byte{0x00, 142}, 142},
+		{[]byte{0x16, 75, 0x10, 0x03}, 75},          // uint8, with RR intervals
+		{[]byte{0x01, 0x2c, 0x01}, 300},             // uint16
+		{[]byte{0x11, 0x8c, 0x00, 0x00, 0x00}, 140}, // uint16, with energy expended
+	}
+	for _, tc := range tests {
+		if got, err := ble.ParseHeartRate(tc.in); err != nil || got != tc.want {
+			t.Errorf("ParseHeartRate(% x) = %d, %v; want %d", tc.in, got, err, tc.want)
+		}
+	}
+	for _, in := range [][]byte{nil, {0x00}, {0x01, 0x2c}} {
+		if _, err := ble.ParseHeartRate(in); err == nil {
+			t.Errorf("ParseHeartRate(% x): expected an error", in)
+		}
+	}
+}
+
+func TestParseCyclingPower_Cadence(t *testing.T) {
+	// Power only.
+	p, err := ble.ParseCyclingPower([]byte{0x00, 0x00, 0xfa, 0x00})
+	if err != nil || p.Power != 250 || p.HasCrank {
+		t.Fatalf("ParseCyclingPower = %+v, %v", p, err)
+	}
+	// Pedal balance, then crank data: 10 revolutions at 0x0400 (1 s).
+	first, err := ble.ParseCyclingPower([]byte{0x21, 0x00, 0xc8, 0x00, 50, 10, 0x00, 0x00, 0x04})
+	if err != nil || first.Power != 200 || !first.HasCrank || first.CrankRevs != 10 || first.CrankTime != 0x0400 {
+		t.Fatalf("ParseCyclingPower (crank) = %+v, %v", first, err)
+	}
+	// 3 revolutions in 2 s, both counters wrapping: 90 rpm.
+	prev := ble.CyclingPower{HasCrank: true, CrankRevs: 0xfffe, CrankTime: 0xfe00}
+	cur := ble.CyclingPower{HasCrank: true, CrankRevs: 0x0001, CrankTime: 0x0600}
+	if got := ble.Cadence(prev, cur); got != 90 {
+		t.Errorf("Cadence across the wrap = %d, want 90", got)
+	}
+	if got := ble.Cadence(first, first); got != -1 {
+		t.Errorf("Cadence without a new revolution = %d, want -1", got)
+	}
+	if _, err := ble.ParseCyclingPower([]byte{0x00, 0x00}); err == nil {
+		t.Error("short measurement: expected an error")
+	}
+}
@@ -0,0 +1,287 @@
+//go:build linux
+
+package ble
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// BlueZ's D-Bus names.
+const (
+	bluez             = "org.bluez"
+	adapterIface      = "org.bluez.Adapter1"
+	deviceIface       = "org.bluez.Device1"
+	charIface         = "org.bluez.GattCharacteristic1"
+	propertiesChanged = "org.freedesktop.DBus.Properties.PropertiesChanged"
+)
+
+// resolveTimeout is how long a connected device has to list its services.
+const resolveTimeout = 15 * time.Second
+
+type objects = map[dbus.ObjectPath]map[string]map[string]dbus.Variant
+
+// Session is a connection to the sensors found by Connect.
+type Session struct {
+	conn    *dbus.Conn
+	sensors []Sensor
+	devices []dbus.ObjectPath
+	chars   map[dbus.ObjectPath]*characteristic
+}
+
+// characteristic is a measurement a sensor notifies.
+type characteristic struct {
+	sensor Sensor
+	last   CyclingPower // the previous power measurement, for cadence
+}
+
+// Connect scans for heart rate monitors and power meters for up to scan,
+// connects to those it finds and subscribes to their measurements. Sensors
+// are woken by moving (a strap worn, pedals turned) before they advertise.
+func Connect(ctx context.Context, scan time.Duration) (*Session, error) {
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return nil, fmt.Errorf("connect to D-Bus (is BlueZ running?): %w", err)
+	}
+	s := &Session{conn: conn, chars: map[dbus.ObjectPath]*characteristic{}}
+	if err := s.connect(ctx, scan); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Session) connect(ctx context.Context, scan time.Duration) error {
+	objs, err := s.objects(ctx)
+	if err != nil {
+		return err
+	}
+	var adapter dbus.ObjectPath
+	for path, ifaces := range objs {
+		if _, ok := ifaces[adapterIface]; ok && (adapter == "" || path < adapter) {
+			adapter = path
+		}
+	}
+	if adapter == "" {
+		return errors.New("no Bluetooth adapter found")
+	}
+	a := s.conn.Object(bluez, adapter)
+	filter := map[string]any{"UUIDs": []string{HeartRateService, CyclingPowerService}, "Transport": "le"}
+	if err := a.CallWithContext(ctx, adapterIface+".SetDiscoveryFilter", 0, filter).Err; err != nil {
+		return fmt.Errorf("set discovery filter: %w", err)
+	}
+	if err := a.CallWithContext(ctx, adapterIface+".StartDiscovery", 0).Err; err != nil {
+		return fmt.Errorf("start discovery: %w", err)
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(scan):
+	}
+	_ = a.Call(adapterIface+".StopDiscovery", 0).Err
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if objs, err = s.objects(ctx); err != nil {
+		return err
+	}
+	for path, ifaces := range objs {
+		dev, ok := ifaces[deviceIface]
+		if !ok || dev["Adapter"].Value() != adapter {
+			continue
+		}
+		uuids, _ := dev["UUIDs"].Value().([]string)
+		_, inRange := dev["RSSI"]
+		connected, _ := dev["Connected"].Value().(bool)
+		if !inRange && !connected {
+			continue // known from before, but not around
+		}
+		name, _ := dev["Alias"].Value().(string)
+		addr, _ := dev["Address"].Value().(string)
+		for _, k := range []Kind{HeartRateMonitor, PowerMeter} {
+			if slices.Contains(uuids, serviceOf(k)) {
+				s.sensors = append(s.sensors, Sensor{Name: name, Address: addr, Kind: k})
+				if !slices.Contains(s.devices, path) {
+					s.devices = append(s.devices, path)
+				}
+			}
+		}
+	}
+	if len(s.sensors) == 0 {
+		return errors.New("no heart rate monitor or power meter found: wake them up and try again")
+	}
+	for _, path := range s.devices {
+		if err := s.resolve(ctx, path); err != nil {
+			return err
+		}
+	}
+	return s.subscribe(ctx)
+}
+
+// resolve connects to the device at path and waits for it to list its
+// services.
+func (s *Session) resolve(ctx context.Context, path dbus.ObjectPath) error {
+	dev := s.conn.Object(bluez, path)
+	if err := dev.CallWithContext(ctx, deviceIface+".Connect", 0).Err; err != nil {
+		return fmt.Errorf("connect to %s: %w", path, err)
+	}
+	deadline := time.Now().Add(resolveTimeout)
+	for {
+		v, err := dev.GetProperty(deviceIface + ".ServicesResolved")
+		if err == nil && v.Value() == true {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%s did not list its services", path)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+}
+
+// subscribe starts the notifications of the measurements of the connected
+// sensors.
+func (s *Session) subscribe(ctx context.Context) error {
+	objs, err := s.objects(ctx)
+	if err != nil {
+		return err
+	}
+	for path, ifaces := range objs {
+		char, ok := ifaces[charIface]
+		if !ok {
+			continue
+		}
+		uuid, _ := char["UUID"].Value().(string)
+		var kind Kind
+		switch uuid {
+		case heartRateMeasurement:
+			kind = HeartRateMonitor
+		case cyclingPowerMeasurement:
+			kind = PowerMeter
+		default:
+			continue
+		}
+		i := slices.IndexFunc(s.devices, func(d dbus.ObjectPath) bool {
+			return len(path) > len(d) && path[:len(d)+1] == d+"/"
+		})
+		if i < 0 {
+			continue
+		}
+		sensor, found := s.sensorAt(s.devices[i], objs, kind)
+		if !found {
+			continue
+		}
+		if err := s.conn.AddMatchSignal(dbus.WithMatchObjectPath(path), dbus.WithMatchInterface("org.freedesktop.DBus.Properties"),
+			dbus.WithMatchMember("PropertiesChanged")); err != nil {
+			return err
+		}
+		if err := s.conn.Object(bluez, path).CallWithContext(ctx, charIface+".StartNotify", 0).Err; err != nil {
+			return fmt.Errorf("subscribe to %s (%s): %w", sensor.Name, kind, err)
+		}
+		s.chars[path] = &characteristic{sensor: sensor}
+	}
+	if len(s.chars) == 0 {
+		return errors.New("the sensors found offer no heart rate or power measurements")
+	}
+	return nil
+}
+
+// sensorAt returns the sensor of kind that is the device at path.
+func (s *Session) sensorAt(path dbus.ObjectPath, objs objects, kind Kind) (Sensor, bool) {
+	addr, _ := objs[path][deviceIface]["Address"].Value().(string)
+	for _, sensor := range s.sensors {
+		if sensor.Address == addr && sensor.Kind == kind {
+			return sensor, true
+		}
+	}
+	return Sensor{}, false
+}
+
+func (s *Session) objects(ctx context.Context) (objects, error) {
+	var objs objects
+	err := s.conn.Object(bluez, "/").CallWithContext(ctx, "org.freedesktop.DBus.ObjectManager.GetManagedObjects", 0).Store(&objs)
+	if err != nil {
+		return nil, fmt.Errorf("list Bluetooth devices (is BlueZ running?): %w", err)
+	}
+	return objs, nil
+}
+
+// Sensors returns the sensors connected.
+func (s *Session) Sensors() []Sensor { return s.sensors }
+
+// Run calls fn with each measurement the sensors send until ctx is done.
+// A measurement that cannot be decoded is skipped.
+func (s *Session) Run(ctx context.Context, fn func(Reading)) error {
+	ch := make(chan *dbus.Signal, 64)
+	s.conn.Signal(ch)
+	defer s.conn.RemoveSignal(ch)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case sig, ok := <-ch:
+			if !ok {
+				return errors.New("lost the connection to BlueZ")
+			}
+			if r, ok := s.reading(sig); ok {
+				fn(r)
+			}
+		}
+	}
+}
+
+// reading decodes the measurement a PropertiesChanged signal carries.
+func (s *Session) reading(sig *dbus.Signal) (Reading, bool) {
+	c := s.chars[sig.Path]
+	if c == nil || sig.Name != propertiesChanged || len(sig.Body) < 2 || sig.Body[0] != charIface {
+		return Reading{}, false
+	}
+	changed, _ := sig.Body[1].(map[string]dbus.Variant)
+	value, _ := changed["Value"].Value().([]byte)
+	if value == nil {
+		return Reading{}, false
+	}
+	r := Reading{Sensor: c.sensor, HeartRate: -1, Power: -1, Cadence: -1}
+	switch c.sensor.Kind {
+	case HeartRateMonitor:
+		hr, err := ParseHeartRate(value)
+		if err != nil {
+			return Reading{}, false
+		}
+		r.HeartRate = hr
+	case PowerMeter:
+		p, err := ParseCyclingPower(value)
+		if err != nil {
+			return Reading{}, false
+		}
+		r.Power, r.Cadence = p.Power, Cadence(c.last, p)
+		c.last = p
+	}
+	return r, true
+}
+
+// Close stops the notifications and disconnects from the sensors.
+func (s *Session) Close() error {
+	for path := range s.chars {
+		_ = s.conn.Object(bluez, path).Call(charIface+".StopNotify", 0).Err
+	}
+	for _, path := range s.devices {
+		_ = s.conn.Object(bluez, path).Call(deviceIface+".Disconnect", 0).Err
+	}
+	return s.conn.Close()
+}
+
+func serviceOf(k Kind) string {
+	if k == PowerMeter {
+		return CyclingPowerService
+	}
+	return HeartRateService
+}
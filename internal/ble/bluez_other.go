@@ -0,0 +1,25 @@
+//go:build !linux
+
+package ble
+
+import (
+	"context"
+	"time"
+)
+
+// Session is a connection to the sensors found by Connect.
+type Session struct{}
+
+// Connect returns ErrUnsupported: only BlueZ, on Linux, is supported.
+func Connect(ctx context.Context, scan time.Duration) (*Session, error) {
+	return nil, ErrUnsupported
+}
+
+// Sensors returns the sensors connected.
+func (s *Session) Sensors() []Sensor { return nil }
+
+// Run calls fn with each measurement the sensors send until ctx is done.
+func (s *Session) Run(ctx context.Context, fn func(Reading)) error { return ErrUnsupported }
+
+// Close disconnects from the sensors.
+func (s *Session) Close() error { return nil }
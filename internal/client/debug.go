@@ -0,0 +1,72 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/redact"
+)
+
+// This file traces the transport for --debug: each attempt at a request,
+// how long it waited for its turn and for the response, the status and
+// rate-limit headers it came back with, and why it was retried. Lines are
+// redacted before they are written, so the trace can be shared.
+
+// rateLimitHeaders are the response headers in which Strava reports the
+// 15-minute and daily limits and what has been used of them.
+var rateLimitHeaders = []string{
+	"X-Ratelimit-Limit", "X-Ratelimit-Usage",
+	"X-Readratelimit-Limit", "X-Readratelimit-Usage",
+}
+
+var (
+	debugMu sync.Mutex
+	debugW  io.Writer //nolint:gochecknoglobals
+)
+
+// SetDebug makes every client trace its requests to w (nil stops it) and
+// returns the previous writer.
+func SetDebug(w io.Writer) io.Writer {
+	debugMu.Lock()
+	defer debugMu.Unlock()
+	prev := debugW
+	debugW = w
+	return prev
+}
+
+// debugf writes one line of the trace, if tracing is on.
+func debugf(format string, args ...any) {
+	debugMu.Lock()
+	defer debugMu.Unlock()
+	if debugW == nil {
+		return
+	}
+	fmt.Fprintln(debugW, redact.String("debug: "+fmt.Sprintf(format, args...)))
+}
+
+// debugResponse traces the response to an attempt, which took d.
+func debugResponse(req *http.Request, resp *http.Response, d time.Duration) {
+	var limits []string
+	for _, h := range rateLimitHeaders {
+		if v := resp.Header.Get(h); v != "" {
+			limits = append(limits, h+"="+v)
+		}
+	}
+	line := fmt.Sprintf("%s %s: %s in %s", req.Method, req.URL, resp.Status, round(d))
+	if len(limits) > 0 {
+		line += " (" + strings.Join(limits, ", ") + ")"
+	}
+	debugf("%s", line)
+}
+
+// round shortens d for the trace.
+func round(d time.Duration) time.Duration {
+	if d < time.Millisecond {
+		return d.Round(time.Microsecond)
+	}
+	return d.Round(time.Millisecond)
+}
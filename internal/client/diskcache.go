@@ -0,0 +1,281 @@
+package client
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/fsutil"
+)
+
+// This file keeps GET responses on disk between runs. Most are revalidated:
+// the next request for the same URL sends the ETag or Last-Modified it came
+// with, and a 304 Not Modified is answered from the disk. Resources that do
+// not change once recorded (an old activity, its streams, a segment effort)
+// are reused without asking for a while (see cacheTTL), which is what saves
+// requests against the rate limits.
+
+// DiskCacheMaxAge is how long a cached response that is not used again is
+// kept before cache gc removes it.
+const DiskCacheMaxAge = 30 * 24 * time.Hour
+
+// maxCacheEntry is the largest response body kept on disk.
+const maxCacheEntry = 16 << 20
+
+// ImmutableTTL is how long a recorded resource is reused without asking
+// the API: the streams and laps of an activity, a segment effort, or an
+// activity started more than oldActivity ago. A changed one (a renamed or
+// cropped activity) may show until then; --no-cache fetches it afresh.
+const ImmutableTTL = 7 * 24 * time.Hour
+
+// SegmentTTL is how long a segment is reused without asking the API. Its
+// course does not change, but its effort counts and the athlete's stats on
+// it do.
+const SegmentTTL = 24 * time.Hour
+
+// oldActivity is the age after which an activity is taken to be final.
+const oldActivity = 30 * 24 * time.Hour
+
+// diskCache is the DiskCache the transport uses; nil turns it off.
+var diskCache *DiskCache //nolint:gochecknoglobals
+
+// SetDiskCache makes every client keep GET responses in c (nil stops it)
+// and returns the previous DiskCache.
+func SetDiskCache(c *DiskCache) *DiskCache {
+	prev := diskCache
+	diskCache = c
+	return prev
+}
+
+// DiskCache keeps GET responses as files in Dir, one directory per URL
+// path. Any other request to a path (an update, starring a segment) drops
+// what is kept for it and the paths above it.
+type DiskCache struct {
+	Dir string
+	// Refresh fetches every response afresh, without validators, and keeps
+	// it for next time.
+	Refresh bool
+}
+
+// cacheEntry is a kept response: this header line, then the body.
+type cacheEntry struct {
+	URL     string      `json:"url"`
+	Header  http.Header `json:"header"` // validators and content type only
+	Stored  time.Time   `json:"stored"`
+	Expires time.Time   `json:"expires,omitzero"` // reused without asking until then
+	body    []byte
+}
+
+// keptHeaders are the response headers a cacheEntry keeps. Rate-limit
+// headers and the like describe the request that fetched it, not this one.
+var keptHeaders = []string{"Content-Type", "Etag", "Last-Modified"}
+
+// roundTrip answers req from the cache, when its entry is fresh or the API
+// says it has not changed, or with fetch, keeping the response.
+func (c *DiskCache) roundTrip(req *http.Request, fetch func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	if !diskCacheable(req.URL.Path) {
+		return fetch(req)
+	}
+	file := c.path(req.URL)
+	var e *cacheEntry
+	if !c.Refresh {
+		e = readEntry(file)
+	}
+	now := time.Now()
+	if e != nil && now.Before(e.Expires) {
+		touch(file, now)
+		debugf("%s %s: reused the cached response (fresh until %s)", req.Method, req.URL, e.Expires.Format(time.RFC3339))
+		logger.Debug("cache hit", "url", req.URL.String(), "expires", e.Expires)
+		return e.response(req), nil
+	}
+	out := req
+	if e != nil && (e.Header.Get("Etag") != "" || e.Header.Get("Last-Modified") != "") {
+		out = req.Clone(req.Context())
+		if v := e.Header.Get("Etag"); v != "" {
+			out.Header.Set("If-None-Match", v)
+		}
+		if v := e.Header.Get("Last-Modified"); v != "" {
+			out.Header.Set("If-Modified-Since", v)
+		}
+	}
+	resp, err := fetch(out)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotModified && e != nil:
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+		if ttl := cacheTTL(req.URL.Path, e.body, now); ttl > 0 {
+			e.Expires = now.Add(ttl)
+			_ = writeEntry(file, e)
+		} else {
+			touch(file, now)
+		}
+		logger.Debug("cache revalidated", "url", req.URL.String())
+		return e.revalidated(req, resp.Header), nil
+	case resp.StatusCode == http.StatusOK:
+		body, rerr := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if rerr != nil {
+			return nil, fmt.Errorf("read response: %w", rerr)
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		c.keep(file, req.URL, resp.Header, body, now)
+		return resp, nil
+	}
+	return resp, nil
+}
+
+// keep stores a 200 response in file, if it can be reused or revalidated.
+// A response that cannot be kept is still returned.
+func (c *DiskCache) keep(file string, u *url.URL, h http.Header, body []byte, now time.Time) {
+	e := &cacheEntry{URL: u.String(), Header: http.Header{}, Stored: now, body: body}
+	for _, k := range keptHeaders {
+		if v := h.Get(k); v != "" {
+			e.Header.Set(k, v)
+		}
+	}
+	if ttl := cacheTTL(u.Path, body, now); ttl > 0 {
+		e.Expires = now.Add(ttl)
+	}
+	if len(body) > maxCacheEntry || (e.Expires.IsZero() && e.Header.Get("Etag") == "" && e.Header.Get("Last-Modified") == "") {
+		_ = os.Remove(file)
+		return
+	}
+	_ = writeEntry(file, e)
+}
+
+// diskCacheable reports whether the response to a GET of urlPath may be
+// kept on disk. Clubs list other athletes and their activities, which the
+// CLI keeps only in the club feed log, for retention-days; an activity's
+// kudos and comments list other athletes too, and are not kept at all.
+func diskCacheable(urlPath string) bool {
+	p := strings.TrimPrefix(urlPath, "/api/v3")
+	return !strings.HasPrefix(p, "/clubs") && !othersPath.MatchString(p)
+}
+
+// invalidate drops the responses kept for u's path and the paths above it,
+// which a request other than a GET may have changed.
+func (c *DiskCache) invalidate(u *url.URL) {
+	for p := u.Path; ; p = path.Dir(p) {
+		_ = os.RemoveAll(filepath.Join(c.Dir, hashKey(u.Host+p)))
+		if p == "/" || p == "." || p == "" {
+			return
+		}
+	}
+}
+
+// path is the file u's response is kept in: the directory of its path,
+// then a file for the query.
+func (c *DiskCache) path(u *url.URL) string {
+	return filepath.Join(c.Dir, hashKey(u.Host+u.Path), hashKey(u.RawQuery))
+}
+
+func hashKey(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:16])
+}
+
+var (
+	activityPath = regexp.MustCompile(`^/activities/\d+$`)
+	recordedPath = regexp.MustCompile(`^/(activities/\d+/(streams|laps)|segment_efforts/\d+)$`)
+	segmentPath  = regexp.MustCompile(`^/segments/\d+$`)
+	othersPath   = regexp.MustCompile(`^/activities/\d+/(kudos|comments)$`)
+)
+
+// cacheTTL returns how long the response to a GET of urlPath, with body,
+// may be reused without asking the API; 0 when it must be revalidated.
+func cacheTTL(urlPath string, body []byte, now time.Time) time.Duration {
+	p := strings.TrimPrefix(urlPath, "/api/v3")
+	switch {
+	case recordedPath.MatchString(p):
+		return ImmutableTTL
+	case segmentPath.MatchString(p):
+		return SegmentTTL
+	case activityPath.MatchString(p):
+		var a struct {
+			StartDate time.Time `json:"start_date"`
+		}
+		if json.Unmarshal(body, &a) == nil && !a.StartDate.IsZero() && now.Sub(a.StartDate) > oldActivity {
+			return ImmutableTTL
+		}
+	}
+	return 0
+}
+
+// readEntry loads the entry kept in file, or returns nil if there is none
+// or it cannot be read.
+func readEntry(file string) *cacheEntry {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+	i := bytes.IndexByte(data, '\n')
+	if i < 0 {
+		return nil
+	}
+	var e cacheEntry
+	if json.Unmarshal(data[:i], &e) != nil {
+		return nil
+	}
+	e.body = data[i+1:]
+	return &e
+}
+
+// writeEntry stores e in file, readable only by the user: responses hold
+// private activities.
+func writeEntry(file string, e *cacheEntry) error {
+	head, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+		return err
+	}
+	return fsutil.WriteFile(file, append(append(head, '\n'), e.body...), 0o600)
+}
+
+// touch marks file as used now, so cache gc keeps it.
+func touch(file string, now time.Time) {
+	_ = os.Chtimes(file, now, now)
+}
+
+// response returns the kept response, as the answer to req.
+// revalidated is the response to req that a 304 with header confirmed e to
+// be: e's body and kept headers, with the 304's others (rate limits, Date).
+func (e *cacheEntry) revalidated(req *http.Request, header http.Header) *http.Response {
+	resp := e.response(req)
+	h := header.Clone()
+	h.Del("Content-Length")
+	for k, v := range resp.Header {
+		h[k] = v
+	}
+	resp.Header = h
+	return resp
+}
+
+func (e *cacheEntry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Request:       req,
+	}
+}
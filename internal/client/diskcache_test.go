@@ -0,0 +1,154 @@
+package client_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	genclient "github.com/Brainsoft-Raxat/strava-cli/internal/client"
+)
+
+func TestDiskCache_RevalidatesAndReuses(t *testing.T) {
+	var calls, notModified int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		switch {
+		case r.Method != http.MethodGet:
+			w.WriteHeader(http.StatusOK)
+		case strings.HasSuffix(r.URL.Path, "/streams"):
+			w.Write([]byte(`[{"type":"time"}]`))
+		case r.Header.Get("If-None-Match") == `"v1"`:
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+		default:
+			w.Header().Set("ETag", `"v1"`)
+			w.Write([]byte(`{"id":1}`))
+		}
+	}))
+	defer srv.Close()
+	dir := t.TempDir()
+	defer genclient.SetDiskCache(genclient.SetDiskCache(&genclient.DiskCache{Dir: dir}))
+
+	get := func(path string) string {
+		t.Helper()
+		resp, err := genclient.NewHTTPClient(freshConfig()).Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("GET %s: HTTP %d", path, resp.StatusCode)
+		}
+		return string(body)
+	}
+
+	// Revalidated with the ETag: a 304 is answered from the disk.
+	for range 2 {
+		if body := get("/athlete"); body != `{"id":1}` {
+			t.Errorf("body = %q", body)
+		}
+	}
+	if calls != 2 || notModified != 1 {
+		t.Errorf("calls = %d (%d not modified), want 2 (1)", calls, notModified)
+	}
+
+	// Streams are reused without asking.
+	calls = 0
+	for range 2 {
+		if body := get("/activities/7/streams?keys=time"); body != `[{"type":"time"}]` {
+			t.Errorf("body = %q", body)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("streams fetched %d times, want 1", calls)
+	}
+
+	// A write drops what is kept for its path and the paths above it only.
+	req, _ := http.NewRequest(http.MethodPut, srv.URL+"/activities/7", nil)
+	resp, err := genclient.NewHTTPClient(freshConfig()).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	calls = 0
+	get("/activities/7/streams?keys=time")
+	if calls != 0 {
+		t.Errorf("streams refetched after an update of another path: %d calls", calls)
+	}
+	req, _ = http.NewRequest(http.MethodPut, srv.URL+"/activities/7/streams", nil)
+	resp, err = genclient.NewHTTPClient(freshConfig()).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	calls = 0
+	get("/activities/7/streams?keys=time")
+	if calls != 1 {
+		t.Errorf("streams fetched %d times after an update, want 1", calls)
+	}
+
+	// Other athletes' kudos and comments are not kept.
+	calls, notModified = 0, 0
+	get("/api/v3/activities/7/kudos")
+	get("/api/v3/activities/7/comments?page=1")
+	get("/api/v3/activities/7/kudos")
+	if calls != 3 || notModified != 0 {
+		t.Errorf("kudos and comments: calls = %d (%d not modified), want 3 (0)", calls, notModified)
+	}
+
+	// Refresh fetches afresh, without validators.
+	genclient.SetDiskCache(&genclient.DiskCache{Dir: dir, Refresh: true})
+	calls, notModified = 0, 0
+	get("/athlete")
+	if calls != 1 || notModified != 0 {
+		t.Errorf("refresh: calls = %d (%d not modified), want 1 (0)", calls, notModified)
+	}
+}
+
+func TestDiskCache_RevalidationKeepsLiveHeaders(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		w.Header().Set("X-RateLimit-Usage", fmt.Sprintf("%d,%d", n, n))
+		w.Header().Set("X-ReadRateLimit-Usage", fmt.Sprintf("%d,%d", n, n))
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.Header().Set("Date", "Tue, 01 Sep 2026 10:00:00 GMT")
+			w.Header().Set("Content-Type", "text/plain")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":1}`))
+	}))
+	defer srv.Close()
+	defer genclient.SetDiskCache(genclient.SetDiskCache(&genclient.DiskCache{Dir: t.TempDir()}))
+
+	var resp *http.Response
+	for range 2 {
+		var err error
+		if resp, err = genclient.NewHTTPClient(freshConfig()).Get(srv.URL + "/athlete"); err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if calls != 2 {
+		t.Fatalf("calls = %d, want 2", calls)
+	}
+	for k, want := range map[string]string{
+		"X-RateLimit-Usage":     "2,2",
+		"X-ReadRateLimit-Usage": "2,2",
+		"Date":                  "Tue, 01 Sep 2026 10:00:00 GMT",
+		"Content-Type":          "application/json", // the kept one
+		"Etag":                  `"v1"`,
+	} {
+		if got := resp.Header.Get(k); got != want {
+			t.Errorf("revalidated %s = %q, want %q", k, got, want)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package client
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// This file implements --strict-decode: responses are checked against the
+// generated types, and every field they do not declare is recorded, so that
+// fields Strava adds or renames are noticed and the client regenerated.
+
+// drift is the collector the transport reports to; nil disables the check.
+var drift *Drift //nolint:gochecknoglobals
+
+// SetDrift makes every client check JSON responses into d (nil stops it) and
+// returns the previous collector.
+func SetDrift(d *Drift) *Drift {
+	prev := drift
+	drift = d
+	return prev
+}
+
+// operations maps the API's GET endpoints to the Response type the generated
+// client decodes them into. Path segments in braces match any value, so literal
+// paths come before the parameterised ones they overlap.
+var operations = []struct {
+	path     string
+	response any
+}{
+	{"/activities/{id}", GetActivityByIdResponse{}},
+	{"/activities/{id}/comments", GetCommentsByActivityIdResponse{}},
+	{"/activities/{id}/kudos", GetKudoersByActivityIdResponse{}},
+	{"/activities/{id}/laps", GetLapsByActivityIdResponse{}},
+	{"/activities/{id}/streams", GetActivityStreamsResponse{}},
+	{"/activities/{id}/zones", GetZonesByActivityIdResponse{}},
+	{"/athlete", GetLoggedInAthleteResponse{}},
+	{"/athlete/activities", GetLoggedInAthleteActivitiesResponse{}},
+	{"/athlete/clubs", GetLoggedInAthleteClubsResponse{}},
+	{"/athlete/zones", GetLoggedInAthleteZonesResponse{}},
+	{"/athletes/{id}/routes", GetRoutesByAthleteIdResponse{}},
+	{"/athletes/{id}/stats", GetStatsResponse{}},
+	{"/clubs/{id}", GetClubByIdResponse{}},
+	{"/clubs/{id}/activities", GetClubActivitiesByIdResponse{}},
+	{"/clubs/{id}/members", GetClubMembersByIdResponse{}},
+	{"/gear/{id}", GetGearByIdResponse{}},
+	{"/routes/{id}", GetRouteByIdResponse{}},
+	{"/segment_efforts", GetEffortsBySegmentIdResponse{}},
+	{"/segment_efforts/{id}", GetSegmentEffortByIdResponse{}},
+	{"/segments/explore", ExploreSegmentsResponse{}},
+	{"/segments/starred", GetLoggedInAthleteStarredSegmentsResponse{}},
+	{"/segments/{id}", GetSegmentByIdResponse{}},
+}
+
+// operationType returns the type a GET of path (without the /api/v3 prefix)
+// decodes into, and the endpoint's path pattern.
+func operationType(path string) (reflect.Type, string, bool) {
+	got := strings.Split(strings.Trim(path, "/"), "/")
+	for _, op := range operations {
+		want := strings.Split(strings.Trim(op.path, "/"), "/")
+		if len(want) != len(got) {
+			continue
+		}
+		match := true
+		for i := range want {
+			if !strings.HasPrefix(want[i], "{") && want[i] != got[i] {
+				match = false
+				break
+			}
+		}
+		if match {
+			f, _ := reflect.TypeOf(op.response).FieldByName("JSON200")
+			return f.Type, op.path, true
+		}
+	}
+	return nil, "", false
+}
+
+// Drift collects the unknown response fields seen per endpoint. It is safe
+// for concurrent use.
+type Drift struct {
+	mu     sync.Mutex
+	fields map[string]map[string]bool // endpoint -> field paths
+}
+
+// NewDrift returns an empty collector.
+func NewDrift() *Drift {
+	return &Drift{fields: map[string]map[string]bool{}}
+}
+
+// Check records the fields of a 200 response body to GET path that the
+// generated type does not declare. Endpoints the generated client does not
+// cover are ignored.
+func (d *Drift) Check(path string, body []byte) {
+	t, endpoint, ok := operationType(path)
+	if !ok {
+		return
+	}
+	unknown := UnknownFields(body, t)
+	if len(unknown) == 0 {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.fields[endpoint] == nil {
+		d.fields[endpoint] = map[string]bool{}
+	}
+	for _, f := range unknown {
+		d.fields[endpoint][f] = true
+	}
+}
+
+// Fields returns the unknown field paths recorded per endpoint, sorted.
+func (d *Drift) Fields() map[string][]string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	out := map[string][]string{}
+	for endpoint, set := range d.fields {
+		for f := range set {
+			out[endpoint] = append(out[endpoint], f)
+		}
+		sort.Strings(out[endpoint])
+	}
+	return out
+}
+
+// UnknownFields returns the paths of the fields in a JSON document that t
+// does not declare, such as "segment_efforts[].pr_elapsed_time". Values
+// that do not decode as JSON yield nothing.
+func UnknownFields(body []byte, t reflect.Type) []string {
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return nil
+	}
+	seen := map[string]bool{}
+	var out []string
+	walkFields(v, t, "", func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			out = append(out, path)
+		}
+	})
+	sort.Strings(out)
+	return out
+}
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+func walkFields(v any, t reflect.Type, path string, report func(string)) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(unmarshalerType) {
+		return // decodes itself (time.Time, json.RawMessage…)
+	}
+	switch val := v.(type) {
+	case map[string]any:
+		switch t.Kind() {
+		case reflect.Struct:
+			fields := jsonFields(t)
+			for key, sub := range val {
+				ft, ok := fields[key]
+				if !ok {
+					report(path + key)
+					continue
+				}
+				walkFields(sub, ft, path+key+".", report)
+			}
+		case reflect.Map:
+			for _, sub := range val {
+				walkFields(sub, t.Elem(), path+"*.", report)
+			}
+		}
+	case []any:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return
+		}
+		prefix := strings.TrimSuffix(path, ".") + "[]."
+		if path == "" {
+			prefix = "[]."
+		}
+		for _, sub := range val {
+			walkFields(sub, t.Elem(), prefix, report)
+		}
+	}
+}
+
+// jsonFields maps the JSON names of a struct's fields, including those of
+// embedded structs, to their types.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	out := map[string]reflect.Type{}
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				for k, v := range jsonFields(ft) {
+					out[k] = v
+				}
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		out[name] = f.Type
+	}
+	return out
+}
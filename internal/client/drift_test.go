@@ -0,0 +1,60 @@
+package client_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	genclient "github.com/Brainsoft-Raxat/strava-cli/internal/client"
+)
+
+func TestUnknownFields(t *testing.T) {
+	type effort struct {
+		Name string `json:"name"`
+	}
+	type segment struct {
+		ID      int64             `json:"id"`
+		Efforts []effort          `json:"efforts"`
+		Extra   map[string]effort `json:"extra"`
+	}
+	body := []byte(`{"id": 1, "new_field": true,
+		"efforts": [{"name": "a", "pr_rank": 1}, {"name": "b", "pr_rank": 2}],
+		"extra": {"x": {"name": "c", "kind": "d"}}}`)
+
+	got := genclient.UnknownFields(body, reflect.TypeOf(segment{}))
+	want := []string{"efforts[].pr_rank", "extra.*.kind", "new_field"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("UnknownFields = %v, want %v", got, want)
+	}
+	if got := genclient.UnknownFields([]byte(`{"id": 1}`), reflect.TypeOf(segment{})); len(got) != 0 {
+		t.Errorf("known fields reported: %v", got)
+	}
+}
+
+func TestRetryTransport_Drift(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `[{"id": 1, "name": "Run", "brand_new": 3}]`)
+	}))
+	defer srv.Close()
+
+	d := genclient.NewDrift()
+	prev := genclient.SetDrift(d)
+	defer genclient.SetDrift(prev)
+
+	resp, err := genclient.NewHTTPClient(freshConfig()).Get(srv.URL + "/athlete/activities")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if len(body) == 0 {
+		t.Error("response body not restored after the drift check")
+	}
+
+	want := map[string][]string{"/athlete/activities": {"[].brand_new"}}
+	if got := d.Fields(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Fields = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,5 @@
+package client
+
+// The client is generated from strava.minimal.json; this refreshes that from
+// Strava's latest API definition first (see `stravacli devtools regen`).
+//go:generate go run ../../cmd/stravacli devtools regen --dir ../..
@@ -0,0 +1,150 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// This file keeps a command from asking the API the same thing twice: the
+// responses to GET requests are kept for the rest of the process, and a
+// request made while the same one is in flight waits for its response
+// instead of going out again. Analytics commands that look an activity or
+// the athlete up from several places then cost one request per resource.
+
+// maxMemoBytes caps the response bodies a Memo keeps; once it is reached,
+// further responses are passed on without being kept.
+const maxMemoBytes = 32 << 20
+
+// memo is the Memo the transport uses; nil turns memoization off.
+var memo *Memo //nolint:gochecknoglobals
+
+// SetMemo makes every client reuse responses through m (nil stops it) and
+// returns the previous Memo.
+func SetMemo(m *Memo) *Memo {
+	prev := memo
+	memo = m
+	return prev
+}
+
+// Memo holds the successful GET responses of a process by URL. Any other
+// request (an update, an upload) may change what they would return, so it
+// empties the Memo.
+type Memo struct {
+	mu      sync.Mutex
+	entries map[string]*memoEntry
+	size    int
+	hits    int
+}
+
+// memoEntry is a response being fetched, or fetched: done is closed once
+// resp is set, or left nil because the response is not kept.
+type memoEntry struct {
+	done chan struct{}
+	resp *http.Response // without its body
+	body []byte
+}
+
+// NewMemo returns an empty Memo.
+func NewMemo() *Memo {
+	return &Memo{entries: map[string]*memoEntry{}}
+}
+
+// Hits returns how many requests were answered from the Memo.
+func (m *Memo) Hits() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.hits
+}
+
+// Forget empties the Memo.
+func (m *Memo) Forget() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	clear(m.entries)
+	m.size = 0
+}
+
+// memoizable reports whether the response to a GET of path may be reused.
+// An upload's status changes while Strava processes it, and is polled.
+func memoizable(path string) bool {
+	return !strings.HasPrefix(strings.TrimPrefix(path, "/api/v3"), "/uploads")
+}
+
+// roundTrip answers req from the Memo, or with fetch, keeping the response
+// for later requests for the same URL.
+func (m *Memo) roundTrip(req *http.Request, fetch func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	key := req.URL.String()
+	m.mu.Lock()
+	if e, ok := m.entries[key]; ok {
+		m.mu.Unlock()
+		select {
+		case <-e.done:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+		if e.resp == nil {
+			// The first request failed, or its response was not kept.
+			return fetch(req)
+		}
+		m.mu.Lock()
+		m.hits++
+		m.mu.Unlock()
+		debugf("%s %s: reused the response from earlier in this run", req.Method, req.URL)
+		return e.replay(req), nil
+	}
+	e := &memoEntry{done: make(chan struct{})}
+	m.entries[key] = e
+	m.mu.Unlock()
+	defer close(e.done)
+
+	resp, err := fetch(req)
+	if err == nil && resp.StatusCode == http.StatusOK {
+		body, rerr := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if rerr != nil {
+			err = fmt.Errorf("read response: %w", rerr)
+		} else {
+			resp.Body = io.NopCloser(bytes.NewReader(body))
+			m.keep(key, e, resp, body)
+		}
+	}
+	if e.resp == nil {
+		m.mu.Lock()
+		if m.entries[key] == e {
+			delete(m.entries, key)
+		}
+		m.mu.Unlock()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// keep stores resp and its body in e, if e is still the Memo's entry for
+// key and the body fits.
+func (m *Memo) keep(key string, e *memoEntry, resp *http.Response, body []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.entries[key] != e || m.size+len(body) > maxMemoBytes {
+		return
+	}
+	kept := *resp
+	kept.Body = nil
+	kept.Header = resp.Header.Clone()
+	e.resp, e.body = &kept, body
+	m.size += len(body)
+}
+
+// replay returns a copy of the kept response, as the answer to req.
+func (e *memoEntry) replay(req *http.Request) *http.Response {
+	resp := *e.resp
+	resp.Header = e.resp.Header.Clone()
+	resp.Body = io.NopCloser(bytes.NewReader(e.body))
+	resp.Request = req
+	return &resp
+}
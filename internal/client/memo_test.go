@@ -0,0 +1,81 @@
+package client_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	genclient "github.com/Brainsoft-Raxat/strava-cli/internal/client"
+)
+
+func TestMemo_ReusesAndCoalescesGets(t *testing.T) {
+	var calls sync.Map
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, _ := calls.LoadOrStore(r.Method+" "+r.URL.Path, new(int32))
+		atomic.AddInt32(n.(*int32), 1)
+		time.Sleep(20 * time.Millisecond) // let concurrent requests overlap
+		w.Write([]byte(`{"path":"` + r.URL.Path + `"}`))
+	}))
+	defer srv.Close()
+	count := func(key string) int32 {
+		n, ok := calls.Load(key)
+		if !ok {
+			return 0
+		}
+		return atomic.LoadInt32(n.(*int32))
+	}
+
+	m := genclient.NewMemo()
+	defer genclient.SetMemo(genclient.SetMemo(m))
+	c := genclient.NewHTTPClient(freshConfig())
+	get := func(path string) string {
+		resp, err := c.Get(srv.URL + path)
+		if err != nil {
+			t.Errorf("GET %s: %v", path, err)
+			return ""
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if body := get("/activities/1"); !strings.Contains(body, "/activities/1") {
+				t.Errorf("body = %q", body)
+			}
+		}()
+	}
+	wg.Wait()
+	get("/activities/1")
+	if n := count("GET /activities/1"); n != 1 {
+		t.Errorf("GET /activities/1 went out %d times, want 1", n)
+	}
+	if m.Hits() != 5 {
+		t.Errorf("Hits() = %d, want 5", m.Hits())
+	}
+
+	get("/uploads/7")
+	get("/uploads/7")
+	if n := count("GET /uploads/7"); n != 2 {
+		t.Errorf("upload status went out %d times, want 2 (never reused)", n)
+	}
+
+	resp, err := c.Post(srv.URL+"/activities/1", "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	get("/activities/1")
+	if n := count("GET /activities/1"); n != 2 {
+		t.Errorf("GET /activities/1 after an update went out %d times in all, want 2", n)
+	}
+}
@@ -0,0 +1,129 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/redact"
+)
+
+// baseTransport sends every client's requests; nil uses http.DefaultTransport.
+var baseTransport http.RoundTripper //nolint:gochecknoglobals
+
+// SetTransport makes every client send its requests over rt (nil for
+// http.DefaultTransport) and returns the previous transport.
+func SetTransport(rt http.RoundTripper) http.RoundTripper {
+	prev := baseTransport
+	baseTransport = rt
+	return prev
+}
+
+// Transport returns the transport clients send their requests over, for
+// the other services the CLI calls to go the same way.
+func Transport() http.RoundTripper {
+	if rt := baseTransport; rt != nil {
+		return rt
+	}
+	return http.DefaultTransport
+}
+
+// Network is how requests reach Strava, for networks that do not let them
+// out directly.
+type Network struct {
+	// Proxy is the URL of the proxy to send requests through (http, https
+	// or socks5). Empty uses HTTPS_PROXY, HTTP_PROXY and NO_PROXY.
+	Proxy string
+	// CABundle is a PEM file of certificates to trust besides the system's,
+	// such as a proxy's that inspects TLS.
+	CABundle string
+	// InsecureSkipVerify accepts any certificate. Anyone on the way can then
+	// read and change the requests, tokens included.
+	InsecureSkipVerify bool
+}
+
+// IsZero reports whether n is the default network.
+func (n Network) IsZero() bool {
+	return n == Network{}
+}
+
+// NewTransport returns a transport like http.DefaultTransport that goes
+// through n's proxy and trusts its certificates.
+func NewTransport(n Network) (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if n.Proxy != "" {
+		proxy, err := parseProxy(n.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		t.Proxy = func(req *http.Request) (*url.URL, error) {
+			if isLoopback(req.URL.Hostname()) {
+				return nil, nil // a local InfluxDB, say
+			}
+			return proxy, nil
+		}
+	}
+	if n.CABundle != "" || n.InsecureSkipVerify {
+		t.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: n.InsecureSkipVerify} //nolint:gosec // asked for, with a warning
+	}
+	if n.CABundle != "" {
+		pool, err := loadCABundle(n.CABundle)
+		if err != nil {
+			return nil, err
+		}
+		t.TLSClientConfig.RootCAs = pool
+	}
+	return t, nil
+}
+
+// parseProxy checks a proxy URL; a bare host:port is taken as http. A
+// password in it is registered with redact.
+func parseProxy(s string) (*url.URL, error) {
+	if !strings.Contains(s, "://") {
+		s = "http://" + s
+	}
+	u, err := url.Parse(s)
+	if err == nil {
+		if pw, ok := u.User.Password(); ok {
+			redact.Add(pw) // kept out of traces and logs
+		}
+	}
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy %q: want a URL such as http://proxy.example.com:3128", s)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+		return u, nil
+	}
+	return nil, fmt.Errorf("invalid proxy %q: scheme must be http, https or socks5", s)
+}
+
+// loadCABundle returns the system's certificates with those in the PEM
+// file at path.
+func loadCABundle(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read CA bundle: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("CA bundle %s: no PEM certificates in it", path)
+	}
+	return pool, nil
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,87 @@
+package client_test
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	genclient "github.com/Brainsoft-Raxat/strava-cli/internal/client"
+)
+
+func TestNewTransport_CABundle(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(bundle, cert, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(n genclient.Network) error {
+		t.Helper()
+		tr, err := genclient.NewTransport(n)
+		if err != nil {
+			t.Fatalf("NewTransport(%+v): %v", n, err)
+		}
+		resp, err := (&http.Client{Transport: tr}).Get(srv.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+	if get(genclient.Network{}) == nil {
+		t.Error("untrusted certificate accepted")
+	}
+	if err := get(genclient.Network{CABundle: bundle}); err != nil {
+		t.Errorf("with the CA bundle: %v", err)
+	}
+	if err := get(genclient.Network{InsecureSkipVerify: true}); err != nil {
+		t.Errorf("skipping verification: %v", err)
+	}
+
+	if err := os.WriteFile(bundle, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := genclient.NewTransport(genclient.Network{CABundle: bundle}); err == nil {
+		t.Error("a bundle without certificates: expected an error")
+	}
+}
+
+func TestNewTransport_Proxy(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+	}))
+	defer proxy.Close()
+	local := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer local.Close()
+
+	tr, err := genclient.NewTransport(genclient.Network{Proxy: proxy.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer genclient.SetTransport(genclient.SetTransport(tr))
+	c := genclient.NewHTTPClient(freshConfig())
+	for _, u := range []string{"http://api.example.test/athlete", local.URL + "/influx"} {
+		resp, err := c.Get(u)
+		if err != nil {
+			t.Fatalf("GET %s: %v", u, err)
+		}
+		resp.Body.Close()
+	}
+	if len(proxied) != 1 || proxied[0] != "http://api.example.test/athlete" {
+		t.Errorf("proxied %v, want only the non-local request", proxied)
+	}
+
+	for _, p := range []string{"ftp://proxy.example.com", "http://"} {
+		if _, err := genclient.NewTransport(genclient.Network{Proxy: p}); err == nil {
+			t.Errorf("proxy %q: expected an error", p)
+		}
+	}
+	if _, err := genclient.NewTransport(genclient.Network{Proxy: "proxy.example.com:3128"}); err != nil {
+		t.Errorf("bare host:port: %v", err)
+	}
+}
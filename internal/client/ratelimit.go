@@ -0,0 +1,76 @@
+package client
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// This file paces requests: a token bucket per host spreads bulk runs out
+// instead of letting them burst into Strava's rate limit, and retries wait
+// a random share of their backoff so clients that failed together do not
+// retry together.
+
+// DefaultRate and DefaultBurst are the pacing applied unless SetRateLimit
+// changes it: a command's first few requests go straight out, and a long
+// run settles at DefaultRate requests per second.
+const (
+	DefaultRate  = 2.0
+	DefaultBurst = 10
+)
+
+var (
+	limitMu    sync.Mutex
+	limitRate  = rate.Limit(DefaultRate) //nolint:gochecknoglobals
+	limitBurst = DefaultBurst            //nolint:gochecknoglobals
+	limiters   = map[string]*rate.Limiter{}
+)
+
+// SetRateLimit paces every client's requests to each host to rps per
+// second, in bursts of up to burst; rps 0 turns pacing off.
+func SetRateLimit(rps float64, burst int) {
+	limitMu.Lock()
+	defer limitMu.Unlock()
+	limitRate, limitBurst = rate.Limit(rps), max(burst, 1)
+	if rps <= 0 {
+		limitRate = rate.Inf
+	}
+	clear(limiters)
+}
+
+// waitTurn blocks until a request to host may go out, or ctx is done.
+func waitTurn(ctx context.Context, host string) error {
+	limitMu.Lock()
+	l, ok := limiters[host]
+	if !ok {
+		l = rate.NewLimiter(limitRate, limitBurst)
+		limiters[host] = l
+	}
+	limitMu.Unlock()
+	return l.Wait(ctx)
+}
+
+// backoff returns how long to wait before retry attempt (from 1): a random
+// duration up to baseBackoff doubled for each earlier retry ("full jitter").
+func backoff(attempt int) time.Duration {
+	ceiling := baseBackoff << (attempt - 1)
+	if ceiling <= 0 {
+		return 0
+	}
+	return rand.N(ceiling)
+}
+
+// sleep waits for d, or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
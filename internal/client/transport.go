@@ -2,15 +2,21 @@
 package client
 
 import (
+	"bytes"
 	"fmt"
-	"math"
+	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/Brainsoft-Raxat/strava-cli/internal/auth"
 	"github.com/Brainsoft-Raxat/strava-cli/internal/config"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/log"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/redact"
 )
 
+var logger = log.New("transport")
+
 const maxRetries = 3
 
 // baseBackoff is a variable so tests can override it to avoid slow sleeps.
@@ -24,7 +30,8 @@ func SetBaseBackoff(d time.Duration) time.Duration {
 	return prev
 }
 
-// retryTransport injects the Bearer token and retries on 429/5xx with exponential backoff.
+// retryTransport injects the Bearer token, paces requests per host and
+// retries on 429/5xx with jittered exponential backoff.
 type retryTransport struct {
 	cfg  *config.Config
 	base http.RoundTripper
@@ -33,20 +40,51 @@ type retryTransport struct {
 // NewHTTPClient returns an *http.Client that:
 //   - refreshes the token if expired before each request
 //   - injects Authorization: Bearer <token>
-//   - retries on HTTP 429 and 5xx with exponential backoff
+//   - paces requests to each host (see SetRateLimit)
+//   - retries on HTTP 429 and 5xx with jittered exponential backoff
+//   - with SetDrift, records response fields the generated types lack
+//   - with SetMemo, sends each GET at most once per process
+//   - with SetDiskCache, keeps GET responses between runs and revalidates them
+//   - counts the requests it sends and their bytes (see Usage)
+//   - with SetDebug, traces each attempt, its timings and rate-limit headers
+//   - with SetTransport, goes through a proxy or trusts more certificates
 func NewHTTPClient(cfg *config.Config) *http.Client {
 	return &http.Client{
 		Transport: &retryTransport{
 			cfg:  cfg,
-			base: http.DefaultTransport,
+			base: Transport(),
 		},
 		Timeout: 30 * time.Second,
 	}
 }
 
 func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		if m := memo; m != nil {
+			m.Forget()
+		}
+		if c := diskCache; c != nil {
+			c.invalidate(req.URL)
+		}
+		return t.send(req)
+	}
+	if !memoizable(req.URL.Path) {
+		return t.send(req)
+	}
+	fetch := t.send
+	if c := diskCache; c != nil {
+		fetch = func(r *http.Request) (*http.Response, error) { return c.roundTrip(r, t.send) }
+	}
+	if m := memo; m != nil {
+		return m.roundTrip(req, fetch)
+	}
+	return fetch(req)
+}
+
+// send makes req, retrying as needed.
+func (t *retryTransport) send(req *http.Request) (*http.Response, error) {
 	// Ensure token is fresh before the first attempt.
-	if err := auth.RefreshIfExpired(t.cfg); err != nil {
+	if err := t.refresh(); err != nil {
 		return nil, err
 	}
 
@@ -55,14 +93,25 @@ func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 
 	for attempt := 0; attempt <= maxRetries; attempt++ {
 		if attempt > 0 {
-			wait := time.Duration(math.Pow(2, float64(attempt-1))) * baseBackoff
-			time.Sleep(wait)
+			wait := backoff(attempt)
+			debugf("%s %s: retry %d of %d in %s", req.Method, req.URL, attempt, maxRetries, round(wait))
+			if serr := sleep(req.Context(), wait); serr != nil {
+				return nil, serr
+			}
 			// Re-check token freshness on retry (it may have expired mid-flow).
-			if rerr := auth.RefreshIfExpired(t.cfg); rerr != nil {
+			if rerr := t.refresh(); rerr != nil {
 				return nil, rerr
 			}
 		}
 
+		queued := time.Now()
+		if werr := waitTurn(req.Context(), req.URL.Host); werr != nil {
+			return nil, werr
+		}
+		if d := time.Since(queued); d >= time.Millisecond {
+			debugf("%s %s: waited %s for its turn (--max-rps)", req.Method, req.URL, round(d))
+		}
+
 		// Clone request so we can add headers safely across retries.
 		cloned := req.Clone(req.Context())
 		cloned.Header.Set("Authorization", "Bearer "+t.cfg.Tokens.AccessToken)
@@ -76,23 +125,64 @@ func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 			cloned.Body = newBody
 		}
 
+		usage.requests.Add(1)
+		if cloned.ContentLength > 0 {
+			usage.bytes.Add(cloned.ContentLength)
+		}
+		debugf("%s %s", req.Method, req.URL)
+		sent := time.Now()
 		resp, err = t.base.RoundTrip(cloned)
 		if err != nil {
+			debugf("%s %s: failed after %s: %v", req.Method, req.URL, round(time.Since(sent)), err)
+			logger.Error("request failed", "method", req.Method, "url", req.URL.String(), "attempt", attempt+1, "error", err)
 			// Network errors are not retried.
-			return nil, fmt.Errorf("request failed: %w", err)
+			return nil, redact.Error(fmt.Errorf("request failed: %w", err))
 		}
+		debugResponse(req, resp, time.Since(sent))
+		logger.Debug("request", "method", req.Method, "url", req.URL.String(), "status", resp.StatusCode,
+			"duration", time.Since(sent), "attempt", attempt+1, "ratelimit_usage", resp.Header.Get("X-Ratelimit-Usage"))
+		resp.Body = countingBody{resp.Body}
 
 		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
 			_ = resp.Body.Close()
 			if attempt == maxRetries {
+				logger.Error("giving up", "method", req.Method, "url", req.URL.String(), "status", resp.StatusCode,
+					"retries", maxRetries)
 				return nil, fmt.Errorf("HTTP %d after %d retries — Strava API may be temporarily unavailable", resp.StatusCode, maxRetries)
 			}
+			logger.Warn("retrying", "method", req.Method, "url", req.URL.String(), "status", resp.StatusCode,
+				"attempt", attempt+1, "ratelimit_usage", resp.Header.Get("X-Ratelimit-Usage"))
 			continue
 		}
 
+		if drift != nil && req.Method == http.MethodGet && resp.StatusCode == http.StatusOK {
+			body, rerr := io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+			if rerr != nil {
+				return nil, fmt.Errorf("read response: %w", rerr)
+			}
+			resp.Body = io.NopCloser(bytes.NewReader(body))
+			drift.Check(strings.TrimPrefix(req.URL.Path, "/api/v3"), body)
+		}
+
 		return resp, nil
 	}
 
 	// Unreachable, but satisfies compiler.
 	return resp, err
 }
+
+// refresh refreshes the access token if it has expired.
+func (t *retryTransport) refresh() error {
+	before := t.cfg.Tokens.ExpiresAt
+	start := time.Now()
+	if err := auth.RefreshIfExpired(t.cfg); err != nil {
+		debugf("token refresh failed after %s: %v", round(time.Since(start)), err)
+		return err
+	}
+	if t.cfg.Tokens.ExpiresAt != before {
+		debugf("refreshed the access token in %s; it expires at %s",
+			round(time.Since(start)), time.Unix(t.cfg.Tokens.ExpiresAt, 0).Format(time.RFC3339))
+	}
+	return nil
+}
@@ -1,12 +1,16 @@
 package client_test
 
 import (
+	"context"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/Brainsoft-Raxat/strava-cli/internal/auth"
 	genclient "github.com/Brainsoft-Raxat/strava-cli/internal/client"
 	"github.com/Brainsoft-Raxat/strava-cli/internal/config"
 )
@@ -144,3 +148,142 @@ func TestRetryTransport_BearerTokenInjected(t *testing.T) {
 		t.Errorf("Authorization header = %q, want %q", gotHeader, "Bearer my-secret-token")
 	}
 }
+
+// TestRetryTransport_RefreshErrorRedacted checks that a failed token refresh
+// does not surface the tokens or client secret in the request's error.
+func TestRetryTransport_RefreshErrorRedacted(t *testing.T) {
+	tokenSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(r.Form.Encode()))
+	}))
+	defer tokenSrv.Close()
+	orig := auth.SetTokenURL(tokenSrv.URL)
+	defer auth.SetTokenURL(orig)
+
+	cfg := freshConfig()
+	cfg.ClientSecret = "transport-secret-value"
+	cfg.Tokens.RefreshToken = "transport-refresh-value"
+	cfg.Tokens.ExpiresAt = time.Now().Add(-time.Hour).Unix()
+
+	_, err := genclient.NewHTTPClient(cfg).Get("http://127.0.0.1:1/athlete")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, secret := range []string{"transport-secret-value", "transport-refresh-value"} {
+		if strings.Contains(err.Error(), secret) {
+			t.Errorf("error shows %q: %v", secret, err)
+		}
+	}
+}
+
+func TestRetryTransport_PacesRequests(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+	genclient.SetRateLimit(20, 1)
+	defer genclient.SetRateLimit(genclient.DefaultRate, genclient.DefaultBurst)
+
+	c := genclient.NewHTTPClient(freshConfig())
+	start := time.Now()
+	for range 3 {
+		resp, err := c.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp.Body.Close()
+	}
+	// The first request goes out at once, the next two 50ms apart.
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("3 requests at 20/s took %v, want at least 100ms", elapsed)
+	}
+}
+
+func TestRetryTransport_BackoffStopsOnCancel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	orig := genclient.SetBaseBackoff(time.Hour)
+	defer genclient.SetBaseBackoff(orig)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	start := time.Now()
+	if _, err := genclient.NewHTTPClient(freshConfig()).Do(req); err == nil {
+		t.Fatal("expected an error")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("cancelled request took %v to give up", elapsed)
+	}
+}
+
+func TestRetryTransport_CountsUsage(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte("0123456789"))
+	}))
+	defer srv.Close()
+	orig := genclient.SetBaseBackoff(time.Millisecond)
+	defer genclient.SetBaseBackoff(orig)
+
+	requests, bytes := genclient.Usage()
+	resp, err := genclient.NewHTTPClient(freshConfig()).Post(srv.URL, "text/plain", strings.NewReader("abc"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	// Two attempts, each sending 3 bytes; 10 bytes back from the second.
+	r, b := genclient.Usage()
+	if r-requests != 2 || b-bytes != 16 {
+		t.Errorf("usage grew by %d requests, %d bytes; want 2, 16", r-requests, b-bytes)
+	}
+}
+
+func TestRetryTransport_DebugTrace(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "200,2000")
+		w.Header().Set("X-RateLimit-Usage", "12,340")
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+	orig := genclient.SetBaseBackoff(time.Millisecond)
+	defer genclient.SetBaseBackoff(orig)
+	var trace strings.Builder
+	genclient.SetDebug(&trace)
+	defer genclient.SetDebug(nil)
+
+	resp, err := genclient.NewHTTPClient(freshConfig()).Get(srv.URL + "/athlete?access_token=test-access")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	out := trace.String()
+	for _, want := range []string{
+		"429 Too Many Requests in ",
+		"retry 1 of 3 in ",
+		"200 OK in ",
+		"X-Ratelimit-Limit=200,2000, X-Ratelimit-Usage=12,340",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("trace lacks %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "test-access") {
+		t.Errorf("trace shows the access token:\n%s", out)
+	}
+}
@@ -0,0 +1,30 @@
+package client
+
+import (
+	"io"
+	"sync/atomic"
+)
+
+// usage counts what this process has sent to the API.
+var usage struct { //nolint:gochecknoglobals
+	requests atomic.Int64
+	bytes    atomic.Int64
+}
+
+// Usage returns how many requests this process has sent, retries included
+// and memoized responses not, and how many bytes of request and response
+// bodies they carried. It is what the run cost against the rate limits.
+func Usage() (requests, bytes int64) {
+	return usage.requests.Load(), usage.bytes.Load()
+}
+
+// countingBody adds the bytes read from a response body to the usage.
+type countingBody struct {
+	io.ReadCloser
+}
+
+func (b countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	usage.bytes.Add(int64(n))
+	return n, err
+}
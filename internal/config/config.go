@@ -2,23 +2,32 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
-)
+	"strings"
 
-const (
-	dirName  = "strava-cli"
-	fileName = "config.json"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/fsutil"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/redact"
 )
 
+const dirName = "strava-cli"
+
+// fileNames are the names the config file may have, by format; a new config
+// is written to the first.
+var fileNames = []string{"config.json", "config.yaml", "config.yml", "config.toml"}
+
 // Tokens holds the OAuth2 token pair and metadata.
 type Tokens struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
 	ExpiresAt    int64  `json:"expires_at"` // Unix timestamp
 	TokenType    string `json:"token_type,omitempty"`
+	Scope        string `json:"scope,omitempty"` // scopes the athlete granted, comma-separated
 }
 
 // PendingAuth holds state between step 1 and step 2 of a remote (two-step) login.
@@ -28,6 +37,43 @@ type PendingAuth struct {
 	ExpiresAt   int64  `json:"expires_at"` // Unix timestamp
 }
 
+// FlagDefaults are values for command-line flags by flag name, set with
+// `config set defaults.<flag>`. Written by hand, a value may also be a
+// number, a boolean or a list, as the flag takes it.
+type FlagDefaults map[string]string
+
+// UnmarshalJSON reads the values as text: a list as its items joined by
+// commas, the way list flags take them.
+func (d *FlagDefaults) UnmarshalJSON(data []byte) error {
+	var raw map[string]any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&raw); err != nil {
+		return err
+	}
+	*d = FlagDefaults{}
+	for name, v := range raw {
+		switch v := v.(type) {
+		case nil:
+		case []any:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			(*d)[name] = strings.Join(items, ",")
+		default:
+			(*d)[name] = fmt.Sprint(v)
+		}
+	}
+	return nil
+}
+
+// Hooks are programs the CLI runs when something happens, set with
+// `config set hooks.<name>`.
+type Hooks struct {
+	PostSync string `json:"post_sync,omitempty"` // given the new activities after a sync
+}
+
 // Config is the full persisted configuration.
 type Config struct {
 	ClientID     string       `json:"client_id"`
@@ -35,6 +81,34 @@ type Config struct {
 	RedirectURI  string       `json:"redirect_uri,omitempty"`
 	Tokens       Tokens       `json:"tokens,omitempty"`
 	PendingAuth  *PendingAuth `json:"pending_auth,omitempty"`
+	Units        string       `json:"units,omitempty"` // "metric" or "imperial"; detected at login
+
+	GearAlerts []GearAlert `json:"gear_alerts,omitempty"` // maintenance intervals, see `gear alerts`
+
+	// Set with `config set`, for analytics the API has no figures for.
+	FTP   int `json:"ftp,omitempty"`    // watts
+	MaxHR int `json:"max_hr,omitempty"` // bpm
+
+	// RetentionDays is how long other athletes' data (the club feed log) is
+	// kept; 0 keeps it for the store's default.
+	RetentionDays int `json:"retention_days,omitempty"`
+
+	Hooks Hooks `json:"hooks,omitzero"`
+
+	// Defaults are values for command-line flags, by flag name, that apply
+	// whenever a command has the flag and it is not given.
+	Defaults FlagDefaults `json:"defaults,omitempty"`
+
+	source *source // how the file wrote it, when loaded from one
+}
+
+// GearAlert is a maintenance interval on a piece of gear, or on a part of
+// it, set with `gear alerts --set`.
+type GearAlert struct {
+	GearID string  `json:"gear_id"`
+	Part   string  `json:"part,omitempty"`  // e.g. "chain"; "" for the gear itself
+	Every  float64 `json:"every"`           // meters between replacements
+	Since  float64 `json:"since,omitempty"` // the gear's distance (m) when the part was last replaced
 }
 
 // Dir returns the path to the config directory (~/.config/strava-cli/).
@@ -52,26 +126,44 @@ func Dir() (string, error) {
 }
 
 // Load reads config from disk. Returns an empty Config if the file doesn't exist yet.
+//
+// The file is config.json, config.yaml (or .yml) or config.toml in the
+// config directory, whichever exists; see Path.
+//
+// String values may refer to environment variables as ${VAR}, or
+// ${VAR:-default} for a fallback when VAR is unset or empty; an unset
+// variable without one is an error. An "include" key names a file, or an
+// array of files, of shared settings (relative paths are to the config
+// directory) that the config's own values override, object by object.
 func Load() (*Config, error) {
-	path, err := configPath()
+	path, err := Path()
 	if err != nil {
 		return nil, err
 	}
-	data, err := os.ReadFile(path)
-	if os.IsNotExist(err) {
+	own, resolved, err := loadLayers(path, nil)
+	if errors.Is(err, fs.ErrNotExist) && !fileExists(path) {
 		return &Config{}, nil
 	}
 	if err != nil {
 		return nil, fmt.Errorf("read config: %w", err)
 	}
+	data, err := json.Marshal(resolved)
+	if err != nil {
+		return nil, fmt.Errorf("parse config: %w", err)
+	}
 	var cfg Config
 	if err := json.Unmarshal(data, &cfg); err != nil {
 		return nil, fmt.Errorf("parse config: %w", err)
 	}
+	cfg.source = &source{own: own, resolved: resolved}
+	redact.Add(cfg.ClientSecret, cfg.Tokens.AccessToken, cfg.Tokens.RefreshToken)
 	return &cfg, nil
 }
 
-// Save writes the config to disk, creating the directory if needed.
+// Save writes the config to disk, creating the directory if needed. Values
+// unchanged since Load are written as the file had them: ${VAR} references
+// stay references, and values from included files stay out. A YAML or TOML
+// file keeps its comments and layout.
 func Save(cfg *Config) error {
 	dir, err := Dir()
 	if err != nil {
@@ -80,21 +172,75 @@ func Save(cfg *Config) error {
 	if err := os.MkdirAll(dir, 0700); err != nil {
 		return fmt.Errorf("create config dir: %w", err)
 	}
-	data, err := json.MarshalIndent(cfg, "", "  ")
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	f, err := formatOf(path)
+	if err != nil {
+		return err
+	}
+	values, err := toMap(cfg)
 	if err != nil {
 		return fmt.Errorf("marshal config: %w", err)
 	}
-	path := filepath.Join(dir, fileName)
-	if err := os.WriteFile(path, data, 0600); err != nil {
+	if cfg.source != nil {
+		out := unresolve(values, cfg.source.resolved, cfg.source.own)
+		if inc, ok := cfg.source.own[includeKey]; ok {
+			out[includeKey] = inc
+		}
+		values = out
+	}
+	prev, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("read config: %w", err)
+	}
+	data, err := f.encode(values, prev)
+	if err != nil {
+		return fmt.Errorf("marshal config: %w", err)
+	}
+	if err := fsutil.WriteFile(path, data, 0600); err != nil {
 		return fmt.Errorf("write config: %w", err)
 	}
 	return nil
 }
 
-func configPath() (string, error) {
+// toMap returns cfg as the generic JSON values it encodes to.
+func toMap(cfg *Config) (map[string]any, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	err = json.Unmarshal(data, &m)
+	return m, err
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// Path returns the config file: the one of config.json, config.yaml,
+// config.yml and config.toml in the config directory that exists, or
+// config.json when none does. More than one is an error, rather than
+// guessing which is meant.
+func Path() (string, error) {
 	dir, err := Dir()
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(dir, fileName), nil
+	var found []string
+	for _, name := range fileNames {
+		if fileExists(filepath.Join(dir, name)) {
+			found = append(found, name)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return filepath.Join(dir, fileNames[0]), nil
+	case 1:
+		return filepath.Join(dir, found[0]), nil
+	}
+	return "", fmt.Errorf("both %s and %s are in %s: keep one", found[0], found[1], dir)
 }
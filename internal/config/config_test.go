@@ -3,6 +3,8 @@ package config_test
 import (
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/Brainsoft-Raxat/strava-cli/internal/config"
@@ -94,3 +96,254 @@ func TestSave_FilePermissions(t *testing.T) {
 		t.Errorf("config.json permissions = %o, want 0600", mode)
 	}
 }
+
+func TestLoad_InterpolatesEnvironment(t *testing.T) {
+	restore := withTempConfigDir(t)
+	defer restore()
+	t.Setenv("TEST_STRAVA_SECRET", "from-env")
+	dir, _ := config.Dir()
+	os.MkdirAll(dir, 0700)
+	path := filepath.Join(dir, "config.json")
+	os.WriteFile(path, []byte(`{"client_id": "${TEST_STRAVA_ID:-123}", "client_secret": "${TEST_STRAVA_SECRET}", "redirect_uri": "$${kept}"}`), 0600)
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.ClientID != "123" || cfg.ClientSecret != "from-env" || cfg.RedirectURI != "${kept}" {
+		t.Errorf("loaded %q, %q, %q; want the default, the variable and the escaped literal", cfg.ClientID, cfg.ClientSecret, cfg.RedirectURI)
+	}
+
+	cfg.FTP = 250
+	if err := config.Save(cfg); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), `"${TEST_STRAVA_SECRET}"`) || strings.Contains(string(data), "from-env") {
+		t.Errorf("saved config does not keep the reference:\n%s", data)
+	}
+
+	os.Unsetenv("TEST_STRAVA_SECRET")
+	if _, err := config.Load(); err == nil || !strings.Contains(err.Error(), "TEST_STRAVA_SECRET is not set") {
+		t.Errorf("Load with the variable unset: %v, want an error naming it", err)
+	}
+}
+
+func TestLoad_IncludeLayersDefaults(t *testing.T) {
+	restore := withTempConfigDir(t)
+	defer restore()
+	dir, _ := config.Dir()
+	os.MkdirAll(dir, 0700)
+	os.WriteFile(filepath.Join(dir, "team.json"), []byte(`{"units": "imperial", "ftp": 200, "tokens": {"scope": "read"}}`), 0600)
+	path := filepath.Join(dir, "config.json")
+	os.WriteFile(path, []byte(`{"include": "team.json", "ftp": 280, "tokens": {"access_token": "acc"}}`), 0600)
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Units != "imperial" || cfg.FTP != 280 || cfg.Tokens.AccessToken != "acc" || cfg.Tokens.Scope != "read" {
+		t.Errorf("loaded %+v, want the team units and scope under the own FTP and token", cfg)
+	}
+
+	cfg.MaxHR = 185
+	if err := config.Save(cfg); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	for _, leaked := range []string{"imperial", "scope"} {
+		if strings.Contains(string(data), leaked) {
+			t.Errorf("saved config copies the included %s:\n%s", leaked, data)
+		}
+	}
+	if reloaded, _ := config.Load(); reloaded.MaxHR != 185 || reloaded.Units != "imperial" {
+		t.Errorf("reloaded %+v, want the new max HR and the included units", reloaded)
+	}
+
+	os.WriteFile(filepath.Join(dir, "team.json"), []byte(`{"include": "config.json"}`), 0600)
+	if _, err := config.Load(); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("Load with an include cycle: %v, want a cycle error", err)
+	}
+}
+
+func TestSave_YAMLKeepsComments(t *testing.T) {
+	restore := withTempConfigDir(t)
+	defer restore()
+	dir, _ := config.Dir()
+	os.MkdirAll(dir, 0700)
+	path := filepath.Join(dir, "config.yaml")
+	os.WriteFile(path, []byte(`# Strava API application
+client_id: "123"
+client_secret: ${TEST_STRAVA_SECRET:-none} # kept in the environment
+
+tokens:
+  access_token: old # refreshed by the CLI
+  expires_at: 100
+ftp: 250 # watts, from the ramp test
+defaults:
+  per-page: 2
+`), 0600)
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.ClientID != "123" || cfg.ClientSecret != "none" || cfg.FTP != 250 || cfg.Tokens.ExpiresAt != 100 {
+		t.Fatalf("loaded %+v", cfg)
+	}
+	cfg.Tokens.AccessToken = "new"
+	cfg.Tokens.ExpiresAt = 1792191533
+	cfg.FTP = 265
+	cfg.MaxHR = 190
+	if err := config.Save(cfg); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	for _, want := range []string{"# Strava API application\n", "client_secret: ${TEST_STRAVA_SECRET:-none} # kept in the environment",
+		"access_token: new # refreshed by the CLI", "expires_at: 1792191533\n", "ftp: 265 # watts, from the ramp test", "max_hr: 190",
+		"per-page: 2\n"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("saved config lacks %q:\n%s", want, data)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "config.json")); err == nil {
+		t.Errorf("Save wrote config.json next to config.yaml")
+	}
+	if cfg, err = config.Load(); err != nil || cfg.Tokens.ExpiresAt != 1792191533 || cfg.Defaults["per-page"] != "2" {
+		t.Errorf("reloaded %+v, %v", cfg, err)
+	}
+}
+
+func TestSave_TOMLKeepsComments(t *testing.T) {
+	restore := withTempConfigDir(t)
+	defer restore()
+	dir, _ := config.Dir()
+	os.MkdirAll(dir, 0700)
+	path := filepath.Join(dir, "config.toml")
+	os.WriteFile(path, []byte(`# Strava API application
+client_id = "123"
+client_secret = "s3cret"
+ftp = 250 # watts
+
+# Refreshed by the CLI.
+[tokens]
+access_token = "old"
+expires_at = 100
+
+[pending_auth]
+state = "abc"
+
+# Service intervals.
+[[gear_alerts]]
+gear_id = "b1"
+every = 3000000
+`), 0600)
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.FTP != 250 || cfg.Tokens.AccessToken != "old" || cfg.PendingAuth == nil || len(cfg.GearAlerts) != 1 {
+		t.Fatalf("loaded %+v", cfg)
+	}
+	cfg.FTP = 265
+	cfg.MaxHR = 190
+	cfg.Tokens.AccessToken = "new"
+	cfg.Tokens.Scope = "read,activity:write"
+	cfg.PendingAuth = nil
+	if err := config.Save(cfg); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	want := `# Strava API application
+client_id = "123"
+client_secret = "s3cret"
+ftp = 265 # watts
+max_hr = 190
+
+# Refreshed by the CLI.
+[tokens]
+access_token = "new"
+expires_at = 100
+refresh_token = ""
+scope = "read,activity:write"
+
+# Service intervals.
+[[gear_alerts]]
+gear_id = "b1"
+every = 3000000
+`
+	if string(data) != want {
+		t.Errorf("saved config:\n%s\nwant:\n%s", data, want)
+	}
+	if reloaded, err := config.Load(); err != nil || reloaded.MaxHR != 190 || reloaded.Tokens.Scope != "read,activity:write" {
+		t.Errorf("reloaded %+v, %v", reloaded, err)
+	}
+}
+
+func TestLoad_FlagDefaultsOfAnyType(t *testing.T) {
+	restore := withTempConfigDir(t)
+	defer restore()
+	dir, _ := config.Dir()
+	os.MkdirAll(dir, 0700)
+	os.WriteFile(filepath.Join(dir, "config.toml"), []byte(`client_id = "123"
+
+[defaults]
+per-page = 100
+json = true
+units = "imperial"
+columns = ["id", "name"]
+`), 0600)
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	want := config.FlagDefaults{"per-page": "100", "json": "true", "units": "imperial", "columns": "id,name"}
+	if len(cfg.Defaults) != len(want) {
+		t.Fatalf("Defaults = %v, want %v", cfg.Defaults, want)
+	}
+	for k, v := range want {
+		if cfg.Defaults[k] != v {
+			t.Errorf("Defaults[%s] = %q, want %q", k, cfg.Defaults[k], v)
+		}
+	}
+}
+
+func TestPath_RejectsTwoFormats(t *testing.T) {
+	restore := withTempConfigDir(t)
+	defer restore()
+	dir, _ := config.Dir()
+	os.MkdirAll(dir, 0700)
+	os.WriteFile(filepath.Join(dir, "config.json"), []byte(`{}`), 0600)
+	os.WriteFile(filepath.Join(dir, "config.toml"), nil, 0600)
+	if _, err := config.Load(); err == nil || !strings.Contains(err.Error(), "keep one") {
+		t.Errorf("Load with two config files: %v, want an error", err)
+	}
+}
+
+func TestCheckPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permission bits do not apply on Windows")
+	}
+	restore := withTempConfigDir(t)
+	defer restore()
+	t.Setenv("SUDO_USER", "")
+	if err := config.Save(&config.Config{ClientID: "id"}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if problems, err := config.CheckPermissions(); err != nil || len(problems) != 0 {
+		t.Fatalf("CheckPermissions on a fresh config = %+v, %v; want none", problems, err)
+	}
+
+	dir, _ := config.Dir()
+	os.Chmod(dir, 0755)
+	os.Chmod(filepath.Join(dir, "config.json"), 0644)
+	problems, err := config.CheckPermissions()
+	if err != nil || len(problems) != 2 {
+		t.Fatalf("CheckPermissions = %+v, %v; want the directory and the file", problems, err)
+	}
+	if !problems[0].Minor || problems[1].Minor || !strings.Contains(problems[1].Hint, "chmod 0600") {
+		t.Errorf("problems = %+v, want a minor open directory and an exposed file", problems)
+	}
+}
@@ -0,0 +1,649 @@
+package config
+
+// This file reads and writes the config file syntaxes, chosen by extension:
+// JSON, YAML and TOML. YAML and TOML files are edited rather than rewritten
+// on Save, so the comments and layout of a hand-written file survive.
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// format reads and writes one config file syntax.
+type format struct {
+	decode func(data []byte) (map[string]any, error)
+	// encode renders values, keeping what it can of prev, the file's
+	// current contents (nil for a new file).
+	encode func(values map[string]any, prev []byte) ([]byte, error)
+}
+
+var formats = map[string]format{
+	".json": {decodeJSON, encodeJSON},
+	".yaml": {decodeYAML, encodeYAML},
+	".yml":  {decodeYAML, encodeYAML},
+	".toml": {decodeTOML, encodeTOML},
+}
+
+// formatOf returns the format of the config file at path.
+func formatOf(path string) (format, error) {
+	f, ok := formats[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return format{}, fmt.Errorf("%s: unknown config format: use .json, .yaml, .yml or .toml", filepath.Base(path))
+	}
+	return f, nil
+}
+
+// normalize returns v as the generic values encoding/json decodes it to, so
+// values read from any format compare equal to those of a Config.
+func normalize(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out any
+	err = json.Unmarshal(data, &out)
+	return out, err
+}
+
+func normalizeMap(m map[string]any) (map[string]any, error) {
+	if m == nil {
+		return map[string]any{}, nil
+	}
+	v, err := normalize(m)
+	if err != nil {
+		return nil, err
+	}
+	return v.(map[string]any), nil
+}
+
+func decodeJSON(data []byte) (map[string]any, error) {
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return normalizeMap(m)
+}
+
+func encodeJSON(values map[string]any, _ []byte) ([]byte, error) {
+	return json.MarshalIndent(values, "", "  ")
+}
+
+func decodeYAML(data []byte) (map[string]any, error) {
+	var m map[string]any
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return normalizeMap(m)
+}
+
+// encodeYAML edits the document in prev to hold values: entries whose
+// values are unchanged keep their nodes, and with them their comments and
+// style; changed values replace theirs, keeping the comment on its line.
+func encodeYAML(values map[string]any, prev []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(prev, &doc); err != nil || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	if err := patchYAML(doc.Content[0], values); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// patchYAML makes the mapping node m hold values. Keys it lacks are added
+// at the end, in sorted order.
+func patchYAML(m *yaml.Node, values map[string]any) error {
+	seen := map[string]bool{}
+	var content []*yaml.Node
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		k, v := m.Content[i], m.Content[i+1]
+		nv, ok := values[k.Value]
+		if !ok || seen[k.Value] {
+			continue
+		}
+		seen[k.Value] = true
+		if sub, isObj := nv.(map[string]any); isObj && v.Kind == yaml.MappingNode {
+			if err := patchYAML(v, sub); err != nil {
+				return err
+			}
+		} else if !yamlEqual(v, nv) {
+			n, err := yamlNode(nv)
+			if err != nil {
+				return err
+			}
+			n.LineComment, n.FootComment = v.LineComment, v.FootComment
+			v = n
+		}
+		content = append(content, k, v)
+	}
+	for _, k := range sortedKeys(values) {
+		if seen[k] {
+			continue
+		}
+		n, err := yamlNode(values[k])
+		if err != nil {
+			return err
+		}
+		content = append(content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: k}, n)
+	}
+	m.Content = content
+	return nil
+}
+
+// yamlEqual reports whether node n holds v. A plain scalar whose text is
+// the string v holds it too: flag defaults are strings, written unquoted.
+func yamlEqual(n *yaml.Node, v any) bool {
+	if s, ok := v.(string); ok && n.Kind == yaml.ScalarNode && n.Value == s {
+		return true
+	}
+	var cur any
+	if err := n.Decode(&cur); err != nil {
+		return false
+	}
+	cur, err := normalize(cur)
+	return err == nil && reflect.DeepEqual(cur, v)
+}
+
+func yamlNode(v any) (*yaml.Node, error) {
+	var n yaml.Node
+	if err := n.Encode(yamlValue(v)); err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// yamlValue returns v with its integral numbers as int64, so that they are
+// written as integers rather than in exponent form.
+func yamlValue(v any) any {
+	switch v := v.(type) {
+	case float64:
+		if v == float64(int64(v)) {
+			return int64(v)
+		}
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			out[i] = yamlValue(e)
+		}
+		return out
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, e := range v {
+			out[k] = yamlValue(e)
+		}
+		return out
+	}
+	return v
+}
+
+func decodeTOML(data []byte) (map[string]any, error) {
+	var m map[string]any
+	if err := toml.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return normalizeMap(m)
+}
+
+// encodeTOML edits the lines of prev to hold values: entries whose values
+// are unchanged keep their lines, changed ones are rewritten in place keeping
+// the comment after them, removed ones are dropped with their tables, and
+// new ones are added to the end of their table as inline values. A new file
+// is written with a table per object.
+func encodeTOML(values map[string]any, prev []byte) ([]byte, error) {
+	old, err := decodeTOML(prev)
+	if err != nil || len(bytes.TrimSpace(prev)) == 0 {
+		var buf bytes.Buffer
+		writeTOMLTable(&buf, nil, values)
+		return buf.Bytes(), nil
+	}
+	doc, err := parseTOMLLines(string(prev))
+	if err != nil {
+		return nil, err
+	}
+	return doc.patch(old, values), nil
+}
+
+// tomlEntry is a key/value entry of a TOML file: its lines, from start to
+// end inclusive, and its key path from the root.
+type tomlEntry struct {
+	start, end int
+	key        string // as written
+	path       []string
+	comment    string // after the value, with its "#"
+}
+
+// tomlSection is a [table] or [[array]] header and the lines up to the
+// next one.
+type tomlSection struct {
+	header, end int // end is exclusive
+	path        []string
+	array       string // for an array table or a table in one, the array's path
+	entries     []*tomlEntry
+}
+
+// span returns the lines of s from its header to the comments directly
+// above the next header, which belong to that.
+func (s *tomlSection) span(lines []string) (from, to int) {
+	to = s.end
+	for to > s.header+1 && strings.HasPrefix(strings.TrimSpace(lines[to-1]), "#") {
+		to--
+	}
+	return s.header, to
+}
+
+// tomlDoc is a TOML file split into entries and sections.
+type tomlDoc struct {
+	lines    []string
+	root     *tomlSection // the entries before the first header; header is -1
+	sections []*tomlSection
+}
+
+var tomlHeader = regexp.MustCompile(`^\[\[?`)
+
+// parseTOMLLines splits a valid TOML file into its sections and entries.
+func parseTOMLLines(text string) (*tomlDoc, error) {
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	d := &tomlDoc{lines: lines, root: &tomlSection{header: -1}}
+	cur := d.root
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if open := tomlHeader.FindString(line); open != "" {
+			name := strings.TrimSpace(stripTOMLComment(line))
+			name = strings.TrimSuffix(strings.TrimPrefix(name, open), strings.Repeat("]", len(open)))
+			path, err := tomlKeyPath(name)
+			if err != nil {
+				return nil, err
+			}
+			next := &tomlSection{header: i, path: path}
+			switch {
+			case open == "[[":
+				next.array = strings.Join(path, ".")
+			case cur.array != "" && strings.HasPrefix(strings.Join(path, "."), cur.array+"."):
+				next.array = cur.array // a table inside the array's last item
+			}
+			cur.end = i
+			cur = next
+			d.sections = append(d.sections, cur)
+			continue
+		}
+		// An entry runs until its lines parse on their own.
+		end := i
+		for ; end < len(lines); end++ {
+			var tmp map[string]any
+			if _, err := toml.Decode(strings.Join(lines[i:end+1], "\n"), &tmp); err == nil {
+				break
+			}
+		}
+		if end == len(lines) {
+			return nil, fmt.Errorf("line %d: cannot parse entry", i+1)
+		}
+		key, _, _ := cutUnquoted(lines[i], '=')
+		rel, err := tomlKeyPath(key)
+		if err != nil {
+			return nil, err
+		}
+		e := &tomlEntry{start: i, end: end, key: strings.TrimSpace(key), path: append(slices.Clone(cur.path), rel...)}
+		if _, c, ok := cutUnquoted(lines[end], '#'); ok && i == end {
+			e.comment = "#" + c
+		}
+		cur.entries = append(cur.entries, e)
+		i = end
+	}
+	cur.end = len(lines)
+	return d, nil
+}
+
+// tomlKeyPath splits a (possibly dotted and quoted) key into its parts.
+func tomlKeyPath(key string) ([]string, error) {
+	var m map[string]any
+	if _, err := toml.Decode("["+strings.TrimSpace(key)+"]", &m); err != nil {
+		return nil, fmt.Errorf("invalid key %q: %w", key, err)
+	}
+	var path []string
+	for len(m) == 1 {
+		for k, v := range m {
+			path = append(path, k)
+			m, _ = v.(map[string]any)
+		}
+	}
+	return path, nil
+}
+
+// cutUnquoted cuts s around the first c outside a quoted string.
+func cutUnquoted(s string, c byte) (before, after string, found bool) {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch ch := s[i]; {
+		case quote == '"' && ch == '\\':
+			i++
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == c:
+			return s[:i], s[i+1:], true
+		}
+	}
+	return s, "", false
+}
+
+func stripTOMLComment(s string) string {
+	before, _, _ := cutUnquoted(s, '#')
+	return before
+}
+
+// patch returns the file's lines edited from holding old to holding values.
+func (d *tomlDoc) patch(old, values map[string]any) []byte {
+	replace := map[int]string{} // first line of an entry or section → its new text
+	drop := map[int]bool{}
+	insert := map[int][]string{} // line → lines to add before it
+	covered := map[string]bool{} // key paths written in the file
+
+	for _, s := range append([]*tomlSection{d.root}, d.sections...) {
+		if s.array != "" {
+			covered[s.array] = true
+			continue
+		}
+		if s.header >= 0 {
+			covered[strings.Join(s.path, ".")] = true
+			if _, ok := lookup(values, s.path).(map[string]any); !ok {
+				from, to := s.span(d.lines)
+				for i := from; i < to; i++ {
+					drop[i] = true
+				}
+				continue
+			}
+		}
+		for _, e := range s.entries {
+			covered[strings.Join(e.path, ".")] = true
+			nv, ov := lookup(values, e.path), lookup(old, e.path)
+			if reflect.DeepEqual(nv, ov) {
+				continue
+			}
+			for i := e.start; i <= e.end; i++ {
+				drop[i] = true
+			}
+			if nv != nil {
+				line := e.key + " = " + tomlInline(nv)
+				if e.comment != "" {
+					line += " " + e.comment
+				}
+				replace[e.start] = line
+			}
+		}
+	}
+
+	// Arrays of tables are rewritten whole where the first of them was.
+	arrays := map[string][]*tomlSection{}
+	var arrayKeys []string
+	for _, s := range d.sections {
+		if s.array != "" {
+			if arrays[s.array] == nil {
+				arrayKeys = append(arrayKeys, s.array)
+			}
+			arrays[s.array] = append(arrays[s.array], s)
+		}
+	}
+	for _, k := range arrayKeys {
+		secs := arrays[k]
+		nv := lookup(values, secs[0].path)
+		if reflect.DeepEqual(nv, lookup(old, secs[0].path)) {
+			continue
+		}
+		for _, s := range secs {
+			from, to := s.span(d.lines)
+			for i := from; i < to; i++ {
+				drop[i] = true
+			}
+		}
+		var buf bytes.Buffer
+		if items, ok := nv.([]any); ok {
+			writeTOMLArray(&buf, secs[0].path, items)
+		}
+		if text := strings.TrimSuffix(buf.String(), "\n"); text != "" {
+			replace[secs[0].header] = text
+		}
+	}
+
+	// New values go at the end of the nearest table written in the file;
+	// new objects become tables at the end of the file.
+	var added func(path []string, m map[string]any)
+	added = func(path []string, m map[string]any) {
+		for _, k := range sortedKeys(m) {
+			child := append(slices.Clone(path), k)
+			key := strings.Join(child, ".")
+			isCovered := covered[key]
+			for c := range covered {
+				if strings.HasPrefix(c, key+".") {
+					isCovered = true
+				}
+			}
+			if sub, ok := m[k].(map[string]any); ok && isCovered {
+				added(child, sub)
+				continue
+			}
+			if isCovered || m[k] == nil {
+				continue
+			}
+			var buf bytes.Buffer
+			switch v := m[k].(type) {
+			case map[string]any:
+				fmt.Fprintf(&buf, "\n[%s]\n", tomlKey(child))
+				writeTOMLTable(&buf, child, v)
+			case []any:
+				if len(v) > 0 && isObjectArray(v) {
+					buf.WriteString("\n")
+					writeTOMLArray(&buf, child, v)
+				}
+			}
+			if buf.Len() > 0 {
+				end := len(d.lines)
+				insert[end] = append(insert[end], strings.TrimSuffix(buf.String(), "\n"))
+				continue
+			}
+			s := d.tableFor(path)
+			rel := child[len(s.path):]
+			line := tomlKey(rel) + " = " + tomlInline(m[k])
+			at := d.insertAt(s)
+			insert[at] = append(insert[at], line)
+		}
+	}
+	added(nil, values)
+
+	var out []string
+	for i, line := range d.lines {
+		out = append(out, insert[i]...)
+		if r, ok := replace[i]; ok {
+			out = append(out, r)
+		} else if !drop[i] {
+			out = append(out, line)
+		}
+	}
+	out = append(out, insert[len(d.lines)]...)
+	return []byte(strings.Join(out, "\n") + "\n")
+}
+
+// tableFor returns the deepest standard table written in the file that
+// path is in, or the root.
+func (d *tomlDoc) tableFor(path []string) *tomlSection {
+	best := d.root
+	for _, s := range d.sections {
+		if s.array == "" && len(s.path) > len(best.path) && len(s.path) <= len(path) && slices.Equal(s.path, path[:len(s.path)]) {
+			best = s
+		}
+	}
+	return best
+}
+
+// insertAt returns the line new entries of s go before: after its last
+// entry, or its header. Root entries go before the first header and the
+// comments above it.
+func (d *tomlDoc) insertAt(s *tomlSection) int {
+	if n := len(s.entries); n > 0 {
+		return s.entries[n-1].end + 1
+	}
+	if s.header >= 0 {
+		return s.header + 1
+	}
+	at := s.end
+	for at > 0 && strings.HasPrefix(strings.TrimSpace(d.lines[at-1]), "#") {
+		at--
+	}
+	return at
+}
+
+// lookup returns the value at path in m, or nil.
+func lookup(m map[string]any, path []string) any {
+	var v any = m
+	for _, k := range path {
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = obj[k]
+	}
+	return v
+}
+
+var bareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// tomlKey renders a dotted key, quoting the parts that need it.
+func tomlKey(path []string) string {
+	parts := make([]string, len(path))
+	for i, k := range path {
+		parts[i] = k
+		if !bareKey.MatchString(k) {
+			data, _ := json.Marshal(k)
+			parts[i] = string(data)
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// tomlInline renders a generic JSON value as a TOML value on one line.
+// Whole numbers are written as integers.
+func tomlInline(v any) string {
+	switch v := v.(type) {
+	case string:
+		data, _ := json.Marshal(v)
+		return string(data)
+	case float64:
+		if v == float64(int64(v)) {
+			return strconv.FormatInt(int64(v), 10)
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case []any:
+		parts := make([]string, 0, len(v))
+		for _, e := range v {
+			parts = append(parts, tomlInline(e))
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	case map[string]any:
+		var parts []string
+		for _, k := range sortedKeys(v) {
+			if v[k] != nil {
+				parts = append(parts, tomlKey([]string{k})+" = "+tomlInline(v[k]))
+			}
+		}
+		if len(parts) == 0 {
+			return "{}"
+		}
+		return "{ " + strings.Join(parts, ", ") + " }"
+	}
+	return `""`
+}
+
+// writeTOMLTable writes the table at path: its values, then a [table] for
+// each object and a [[table]] for each array of objects in it.
+func writeTOMLTable(buf *bytes.Buffer, path []string, m map[string]any) {
+	var tables, arrays []string
+	for _, k := range sortedKeys(m) {
+		switch v := m[k].(type) {
+		case nil:
+		case map[string]any:
+			tables = append(tables, k)
+		case []any:
+			if len(v) > 0 && isObjectArray(v) {
+				arrays = append(arrays, k)
+				continue
+			}
+			fmt.Fprintf(buf, "%s = %s\n", tomlKey([]string{k}), tomlInline(v))
+		default:
+			fmt.Fprintf(buf, "%s = %s\n", tomlKey([]string{k}), tomlInline(v))
+		}
+	}
+	for _, k := range tables {
+		child := append(slices.Clone(path), k)
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		fmt.Fprintf(buf, "[%s]\n", tomlKey(child))
+		writeTOMLTable(buf, child, m[k].(map[string]any))
+	}
+	for _, k := range arrays {
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		writeTOMLArray(buf, append(slices.Clone(path), k), m[k].([]any))
+	}
+}
+
+// writeTOMLArray writes an array of objects as [[path]] tables.
+func writeTOMLArray(buf *bytes.Buffer, path []string, items []any) {
+	for i, item := range items {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		fmt.Fprintf(buf, "[[%s]]\n", tomlKey(path))
+		obj, _ := item.(map[string]any)
+		for _, k := range sortedKeys(obj) {
+			if obj[k] != nil {
+				fmt.Fprintf(buf, "%s = %s\n", tomlKey([]string{k}), tomlInline(obj[k]))
+			}
+		}
+	}
+}
+
+func isObjectArray(items []any) bool {
+	for _, e := range items {
+		if !isObject(e) {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
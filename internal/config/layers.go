@@ -0,0 +1,220 @@
+package config
+
+// This file resolves the two indirections a config file may use: ${VAR}
+// references to environment variables in string values, and an "include"
+// key naming files of shared defaults that the file's own values override.
+// Save writes such values back as they were written, so secrets kept in the
+// environment and defaults kept in an include never end up in the config.
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// includeKey is the config key listing the files a config file includes:
+// a path or an array of them, relative to the including file's directory,
+// each in any of the config formats.
+const includeKey = "include"
+
+// maxIncludeDepth caps how deeply includes nest.
+const maxIncludeDepth = 8
+
+// envRef matches ${VAR} and ${VAR:-default}; $${ is a literal "${".
+var envRef = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// source is how a loaded Config was written: the config file's own values,
+// and the values once includes and environment variables were resolved.
+type source struct {
+	own      map[string]any
+	resolved map[string]any
+}
+
+// loadLayers reads the config file at path with its includes, returning
+// the file's own values and the resolved ones. seen holds the files being
+// read, to catch include cycles.
+func loadLayers(path string, seen []string) (own, resolved map[string]any, err error) {
+	if len(seen) >= maxIncludeDepth {
+		return nil, nil, fmt.Errorf("%s: includes nest more than %d deep", path, maxIncludeDepth)
+	}
+	for _, p := range seen {
+		if p == path {
+			return nil, nil, fmt.Errorf("%s: include cycle", path)
+		}
+	}
+	f, err := formatOf(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if own, err = f.decode(data); err != nil {
+		return nil, nil, fmt.Errorf("parse %s: %w", filepath.Base(path), err)
+	}
+
+	resolved = map[string]any{}
+	includes, err := includePaths(own[includeKey], filepath.Dir(path))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	for _, inc := range includes {
+		_, layer, err := loadLayers(inc, append(seen, path))
+		if err != nil {
+			return nil, nil, fmt.Errorf("include %s: %w", inc, err)
+		}
+		merge(resolved, layer)
+	}
+	self, err := interpolate(own, "")
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	merge(resolved, self.(map[string]any))
+	delete(resolved, includeKey)
+	return own, resolved, nil
+}
+
+// includePaths returns the files named by an include value, resolved
+// against dir.
+func includePaths(v any, dir string) ([]string, error) {
+	var names []string
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		names = []string{v}
+	case []any:
+		for _, n := range v {
+			s, ok := n.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s: want a path or an array of paths", includeKey)
+			}
+			names = append(names, s)
+		}
+	default:
+		return nil, fmt.Errorf("%s: want a path or an array of paths", includeKey)
+	}
+	paths := make([]string, len(names))
+	for i, n := range names {
+		n = os.ExpandEnv(n)
+		if rest, ok := strings.CutPrefix(n, "~/"); ok {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return nil, err
+			}
+			n = filepath.Join(home, rest)
+		}
+		if !filepath.IsAbs(n) {
+			n = filepath.Join(dir, n)
+		}
+		paths[i] = filepath.Clean(n)
+	}
+	return paths, nil
+}
+
+// interpolate returns v with the environment variable references in its
+// strings replaced. key is where v is, for errors.
+func interpolate(v any, key string) (any, error) {
+	switch v := v.(type) {
+	case string:
+		var missing string
+		out := envRef.ReplaceAllStringFunc(v, func(ref string) string {
+			if strings.HasPrefix(ref, "$$") {
+				return ref[1:]
+			}
+			m := envRef.FindStringSubmatch(ref)
+			val, ok := os.LookupEnv(m[1])
+			switch {
+			case m[2] != "" && val == "":
+				return m[3]
+			case !ok && missing == "":
+				missing = m[1]
+			}
+			return val
+		})
+		if missing != "" {
+			return nil, fmt.Errorf("%s: environment variable %s is not set", key, missing)
+		}
+		return out, nil
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, e := range v {
+			r, err := interpolate(e, joinKey(key, k))
+			if err != nil {
+				return nil, err
+			}
+			out[k] = r
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			r, err := interpolate(e, fmt.Sprintf("%s[%d]", key, i))
+			if err != nil {
+				return nil, err
+			}
+			out[i] = r
+		}
+		return out, nil
+	}
+	return v, nil
+}
+
+func joinKey(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+// merge copies over into base, merging objects key by key; other values in
+// over replace those in base.
+func merge(base, over map[string]any) {
+	for k, v := range over {
+		if sub, ok := v.(map[string]any); ok {
+			if b, ok := base[k].(map[string]any); ok {
+				merge(b, sub)
+				continue
+			}
+			cp := map[string]any{}
+			merge(cp, sub)
+			v = cp
+		}
+		base[k] = v
+	}
+}
+
+// unresolve turns the values of a config about to be saved back into how
+// the file writes them: a value unchanged since it was loaded is written
+// as the file had it (with its ${VAR} references), or not at all when it
+// came from an include; changed values are written as they are.
+func unresolve(values, resolved, own map[string]any) map[string]any {
+	out := map[string]any{}
+	for k, v := range values {
+		r, wasResolved := resolved[k]
+		o, inOwn := own[k]
+		switch {
+		case wasResolved && reflect.DeepEqual(v, r):
+			if inOwn {
+				out[k] = o
+			}
+		case isObject(v) && isObject(r):
+			ownObj, _ := o.(map[string]any)
+			if sub := unresolve(v.(map[string]any), r.(map[string]any), ownObj); len(sub) > 0 {
+				out[k] = sub
+			}
+		default:
+			out[k] = v
+		}
+	}
+	return out
+}
+
+func isObject(v any) bool {
+	_, ok := v.(map[string]any)
+	return ok
+}
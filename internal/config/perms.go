@@ -0,0 +1,68 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// PermissionProblem is a way the config directory or file could expose the
+// credentials and tokens in it.
+type PermissionProblem struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+	Hint    string `json:"hint,omitempty"` // how to fix it
+	Minor   bool   `json:"minor"`          // only the directory's listing of file names is exposed
+}
+
+// CheckPermissions checks that the config directory and file are private to
+// the user running the CLI and owned by them, and that a run under sudo uses
+// the invoking user's config rather than root's. The CLI creates them
+// private, but a copy, an editor or a umask can loosen them later.
+// Permission bits do not apply on Windows, where the profile directory is
+// private.
+func CheckPermissions() ([]PermissionProblem, error) {
+	if runtime.GOOS == "windows" {
+		return nil, nil
+	}
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	var problems []PermissionProblem
+	if p := sudoProblem(dir); p != nil {
+		problems = append(problems, *p)
+	}
+	paths := []string{dir}
+	for _, name := range fileNames {
+		paths = append(paths, filepath.Join(dir, name))
+	}
+	for i, path := range paths {
+		info, err := os.Stat(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		secret := i > 0
+		if uid, ok := fileOwner(info); ok && uid != os.Geteuid() {
+			problems = append(problems, PermissionProblem{Path: path,
+				Message: fmt.Sprintf("%s is owned by another user (uid %d)", path, uid),
+				Hint:    fmt.Sprintf("run: sudo chown %d %s", os.Geteuid(), path)})
+		}
+		want := os.FileMode(0700)
+		if secret {
+			want = 0600
+		}
+		if mode := info.Mode().Perm(); mode&0077 != 0 {
+			problems = append(problems, PermissionProblem{Path: path, Minor: !secret,
+				Message: fmt.Sprintf("%s is accessible to other users (mode %04o)", path, mode),
+				Hint:    fmt.Sprintf("run: chmod %04o %s", want, path)})
+		}
+	}
+	return problems, nil
+}
@@ -0,0 +1,41 @@
+//go:build !windows
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// fileOwner returns the user ID owning a file.
+func fileOwner(info os.FileInfo) (int, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return int(st.Uid), true
+}
+
+// sudoProblem reports a run as root under sudo whose config directory, dir,
+// is not under the invoking user's home: sudo reset HOME, so the run reads
+// and writes root's config instead of theirs.
+func sudoProblem(dir string) *PermissionProblem {
+	name := os.Getenv("SUDO_USER")
+	if os.Geteuid() != 0 || name == "" || name == "root" {
+		return nil
+	}
+	u, err := user.Lookup(name)
+	if err != nil || u.HomeDir == "" {
+		return nil
+	}
+	if rel, err := filepath.Rel(u.HomeDir, dir); err == nil && !strings.HasPrefix(rel, "..") {
+		return nil
+	}
+	return &PermissionProblem{Path: dir,
+		Message: fmt.Sprintf("running under sudo with HOME %s: using root's config in %s, not %s's", os.Getenv("HOME"), dir, name),
+		Hint:    "run without sudo, or set STRAVA_CONFIG_DIR to the config to use"}
+}
@@ -0,0 +1,11 @@
+//go:build windows
+
+package config
+
+import "os"
+
+// fileOwner is not checked on Windows, where files have no owner ID.
+func fileOwner(info os.FileInfo) (int, bool) { return 0, false }
+
+// sudoProblem does not apply on Windows.
+func sudoProblem(dir string) *PermissionProblem { return nil }
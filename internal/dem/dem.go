@@ -0,0 +1,123 @@
+// Package dem looks up terrain heights from a digital elevation model via the
+// Open Topo Data API (https://www.opentopodata.org), which needs no API key.
+package dem
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/geo"
+)
+
+// DefaultBaseURL is the public Open Topo Data endpoint.
+const DefaultBaseURL = "https://api.opentopodata.org/v1"
+
+// DefaultDataset is a global 90 m resolution model. Finer regional datasets
+// (e.g. eudem25m, ned10m) give better results where they have coverage.
+const DefaultDataset = "srtm90m"
+
+// BatchSize is the most locations the public API accepts per request.
+const BatchSize = 100
+
+// minInterval is the public API's rate limit: one request per second.
+const minInterval = time.Second
+
+// Client queries one elevation dataset.
+type Client struct {
+	HTTP     *http.Client
+	BaseURL  string
+	Dataset  string
+	Interval time.Duration // minimum time between requests
+
+	last time.Time
+}
+
+// New returns a Client for dataset using hc (http.DefaultClient if nil).
+func New(hc *http.Client, dataset string) *Client {
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	if dataset == "" {
+		dataset = DefaultDataset
+	}
+	return &Client{HTTP: hc, BaseURL: DefaultBaseURL, Dataset: dataset, Interval: minInterval}
+}
+
+type lookupResponse struct {
+	Status  string `json:"status"`
+	Error   string `json:"error"`
+	Results []struct {
+		Elevation *float64 `json:"elevation"`
+	} `json:"results"`
+}
+
+// Elevations returns the terrain height in meters at each point, querying in
+// batches of BatchSize and pacing requests to the rate limit. Points outside
+// the dataset's coverage are an error.
+func (c *Client) Elevations(ctx context.Context, pts []geo.Point) ([]float64, error) {
+	out := make([]float64, 0, len(pts))
+	for from := 0; from < len(pts); from += BatchSize {
+		batch, err := c.lookup(ctx, pts[from:min(len(pts), from+BatchSize)])
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, batch...)
+	}
+	return out, nil
+}
+
+func (c *Client) lookup(ctx context.Context, pts []geo.Point) ([]float64, error) {
+	if wait := c.Interval - time.Since(c.last); wait > 0 {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+	defer func() { c.last = time.Now() }()
+
+	locs := make([]string, len(pts))
+	for i, p := range pts {
+		locs[i] = fmt.Sprintf("%.5f,%.5f", p.Lat, p.Lng)
+	}
+	u := fmt.Sprintf("%s/%s?locations=%s", c.BaseURL, url.PathEscape(c.Dataset),
+		url.QueryEscape(strings.Join(locs, "|")))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch elevations: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read elevations: %w", err)
+	}
+
+	var lr lookupResponse
+	if err := json.Unmarshal(body, &lr); err != nil {
+		return nil, fmt.Errorf("parse elevations (HTTP %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK || lr.Status != "OK" {
+		return nil, fmt.Errorf("elevation API error (HTTP %d): %s", resp.StatusCode, lr.Error)
+	}
+	if len(lr.Results) != len(pts) {
+		return nil, fmt.Errorf("elevation API returned %d results for %d locations", len(lr.Results), len(pts))
+	}
+	out := make([]float64, len(pts))
+	for i, r := range lr.Results {
+		if r.Elevation == nil {
+			return nil, fmt.Errorf("no %s coverage at %s", c.Dataset, locs[i])
+		}
+		out[i] = *r.Elevation
+	}
+	return out, nil
+}
@@ -0,0 +1,57 @@
+package dem_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/dem"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/geo"
+)
+
+func TestElevations_Batches(t *testing.T) {
+	var batches []int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/srtm30m" {
+			t.Errorf("path = %s, want /srtm30m", r.URL.Path)
+		}
+		n := len(strings.Split(r.URL.Query().Get("locations"), "|"))
+		batches = append(batches, n)
+		results := make([]string, n)
+		for i := range results {
+			results[i] = fmt.Sprintf(`{"elevation":%d}`, i)
+		}
+		fmt.Fprintf(w, `{"status":"OK","results":[%s]}`, strings.Join(results, ","))
+	}))
+	defer srv.Close()
+
+	c := dem.New(srv.Client(), "srtm30m")
+	c.BaseURL = srv.URL
+	c.Interval = 0
+	ele, err := c.Elevations(context.Background(), make([]geo.Point, 150))
+	if err != nil {
+		t.Fatalf("Elevations: %v", err)
+	}
+	if len(batches) != 2 || batches[0] != dem.BatchSize || batches[1] != 50 {
+		t.Errorf("batches = %v, want [100 50]", batches)
+	}
+	if len(ele) != 150 || ele[100] != 0 || ele[99] != 99 {
+		t.Errorf("got %d elevations, want 150 in order", len(ele))
+	}
+}
+
+func TestElevations_NoCoverage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"OK","results":[{"elevation":null}]}`))
+	}))
+	defer srv.Close()
+
+	c := dem.New(srv.Client(), "")
+	c.BaseURL = srv.URL
+	if _, err := c.Elevations(context.Background(), []geo.Point{{Lat: 89, Lng: 0}}); err == nil {
+		t.Error("expected an error for a point outside the dataset")
+	}
+}
@@ -0,0 +1,130 @@
+// Package erg reads ERG files, the plain-text power courses that indoor
+// trainer software (TrainerRoad, Golden Cheetah, Zwift, PerfPRO) exports:
+// power against elapsed minutes, in watts or in percent of FTP.
+package erg
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/fit"
+)
+
+// Point is one line of the course data. Consecutive points are joined by a
+// straight line, so two points at the same minute make a step.
+type Point struct {
+	Minutes float64
+	Value   float64 // watts, or percent of FTP
+}
+
+// Course is a parsed ERG file.
+type Course struct {
+	Description string
+	Percent     bool // values are percent of FTP (MRC files), not watts
+	Points      []Point
+}
+
+// Parse reads an ERG file. Both the watts form (MINUTES WATTS) and the
+// percent form (MINUTES PERCENT, as in MRC files) are accepted; columns past
+// the second are ignored.
+func Parse(r io.Reader) (*Course, error) {
+	c := &Course{}
+	section := ""
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			section = strings.ToUpper(strings.Trim(line, "[]"))
+			continue
+		}
+		switch section {
+		case "COURSE HEADER":
+			if k, v, ok := strings.Cut(line, "="); ok {
+				if strings.EqualFold(strings.TrimSpace(k), "DESCRIPTION") {
+					c.Description = strings.TrimSpace(v)
+				}
+				continue
+			}
+			// The units line, e.g. "MINUTES WATTS".
+			fields := strings.Fields(strings.ToUpper(line))
+			if len(fields) >= 2 && fields[0] == "MINUTES" {
+				c.Percent = fields[1] == "PERCENT"
+			}
+		case "COURSE DATA":
+			fields := strings.Fields(line)
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("line %d: want minutes and a value, got %q", n, line)
+			}
+			m, err1 := strconv.ParseFloat(fields[0], 64)
+			v, err2 := strconv.ParseFloat(fields[1], 64)
+			if err1 != nil || err2 != nil || m < 0 || v < 0 {
+				return nil, fmt.Errorf("line %d: invalid course point %q", n, line)
+			}
+			if len(c.Points) > 0 && m < c.Points[len(c.Points)-1].Minutes {
+				return nil, fmt.Errorf("line %d: minutes go backwards", n)
+			}
+			c.Points = append(c.Points, Point{Minutes: m, Value: v})
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(c.Points) < 2 {
+		return nil, errors.New("no course data: want at least two points under [COURSE DATA]")
+	}
+	return c, nil
+}
+
+// Duration is the length of the course.
+func (c *Course) Duration() time.Duration {
+	return time.Duration(c.Points[len(c.Points)-1].Minutes * float64(time.Minute))
+}
+
+// PowerAt returns the power, in watts, at elapsed time t. ftp converts
+// percent courses and is ignored for watts.
+func (c *Course) PowerAt(t time.Duration, ftp int) float64 {
+	m := t.Minutes()
+	v := c.Points[len(c.Points)-1].Value
+	for i := 1; i < len(c.Points); i++ {
+		a, b := c.Points[i-1], c.Points[i]
+		if m < b.Minutes {
+			v = a.Value
+			if b.Minutes > a.Minutes {
+				v += (b.Value - a.Value) * (m - a.Minutes) / (b.Minutes - a.Minutes)
+			}
+			break
+		}
+	}
+	if c.Percent {
+		v = v * float64(ftp) / 100
+	}
+	return v
+}
+
+// Records returns one record a second from start with the course's power,
+// for writing as a FIT file. ftp is needed for percent courses.
+func (c *Course) Records(start time.Time, ftp int) ([]fit.Record, error) {
+	if c.Percent && ftp <= 0 {
+		return nil, errors.New("the course is in percent of FTP: an FTP is needed to turn it into watts")
+	}
+	var recs []fit.Record
+	for t := time.Duration(0); t <= c.Duration(); t += time.Second {
+		recs = append(recs, fit.Record{
+			Time:      start.Add(t),
+			HeartRate: -1,
+			Cadence:   -1,
+			Power:     int(math.Round(c.PowerAt(t, ftp))),
+			Distance:  -1,
+		})
+	}
+	return recs, nil
+}
@@ -0,0 +1,63 @@
+package erg_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/erg"
+)
+
+const sweetSpot = `[COURSE HEADER]
+VERSION = 2
+UNITS = ENGLISH
+DESCRIPTION = Sweet spot 2x10
+MINUTES WATTS
+[END COURSE HEADER]
+[COURSE DATA]
+0.00	100
+5.00	200
+5.00	250
+15.00	250
+[END COURSE DATA]
+`
+
+func TestParse_RampsAndSteps(t *testing.T) {
+	c, err := erg.Parse(strings.NewReader(sweetSpot))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if c.Description != "Sweet spot 2x10" || c.Percent || len(c.Points) != 4 || c.Duration() != 15*time.Minute {
+		t.Fatalf("course = %+v", c)
+	}
+	for _, tc := range []struct {
+		at   time.Duration
+		want float64
+	}{
+		{0, 100},
+		{150 * time.Second, 150}, // halfway up the ramp
+		{5 * time.Minute, 250},   // the step
+		{15 * time.Minute, 250},
+	} {
+		if got := c.PowerAt(tc.at, 0); got != tc.want {
+			t.Errorf("PowerAt(%v) = %v, want %v", tc.at, got, tc.want)
+		}
+	}
+	recs, err := c.Records(time.Unix(0, 0), 0)
+	if err != nil || len(recs) != 15*60+1 || recs[150].Power != 150 {
+		t.Errorf("Records: %d records, err %v", len(recs), err)
+	}
+}
+
+func TestParse_PercentNeedsFTP(t *testing.T) {
+	c, err := erg.Parse(strings.NewReader(strings.Replace(sweetSpot, "MINUTES WATTS", "MINUTES PERCENT", 1)))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if _, err := c.Records(time.Unix(0, 0), 0); err == nil {
+		t.Error("Records without an FTP succeeded for a percent course")
+	}
+	if got := c.PowerAt(0, 280); got != 280 {
+		t.Errorf("100%% of 280 W = %v", got)
+	}
+}
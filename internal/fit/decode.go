@@ -0,0 +1,184 @@
+package fit
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/geo"
+)
+
+// Decode reads the records of a FIT activity file, its sport from the
+// session message and the device that recorded it. Fields other than those
+// of Record and Device are skipped, and so is anything after the first file
+// of a chained file.
+func Decode(r io.Reader) (Activity, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return Activity{}, err
+	}
+	if len(b) < 12 || string(b[8:12]) != ".FIT" {
+		return Activity{}, errors.New("fit: not a FIT file")
+	}
+	hdr := int(b[0])
+	end := hdr + int(binary.LittleEndian.Uint32(b[4:]))
+	if hdr < 12 || end+2 > len(b) {
+		return Activity{}, errors.New("fit: file is truncated")
+	}
+	if crc(0, b[:end+2]) != 0 {
+		return Activity{}, errors.New("fit: checksum mismatch, the file is corrupt")
+	}
+
+	type definition struct {
+		global    uint16
+		bigEndian bool
+		fields    []field
+		extra     int // bytes of developer fields, skipped
+	}
+	var (
+		a        Activity
+		defs     [16]*definition
+		last     uint32 // latest timestamp, for compressed timestamp headers
+		data     = b[hdr:end]
+		errTrunc = errors.New("fit: message runs past the end of the data")
+	)
+	for len(data) > 0 {
+		h := data[0]
+		data = data[1:]
+		local := h & 0x0f
+		if h&0x80 != 0 { // compressed timestamp header
+			local = (h >> 5) & 0x03
+			offset := uint32(h & 0x1f)
+			ts := last&^0x1f | offset
+			if offset < last&0x1f {
+				ts += 0x20
+			}
+			last = ts
+		} else if h&0x40 != 0 {
+			if len(data) < 5 {
+				return a, errTrunc
+			}
+			d := &definition{bigEndian: data[1] == 1}
+			if d.bigEndian {
+				d.global = binary.BigEndian.Uint16(data[2:])
+			} else {
+				d.global = binary.LittleEndian.Uint16(data[2:])
+			}
+			n := int(data[4])
+			data = data[5:]
+			if len(data) < 3*n {
+				return a, errTrunc
+			}
+			for i := range n {
+				d.fields = append(d.fields, field{data[3*i], data[3*i+1], data[3*i+2]})
+			}
+			data = data[3*n:]
+			if h&0x20 != 0 { // developer fields
+				if len(data) < 1 || len(data) < 1+3*int(data[0]) {
+					return a, errTrunc
+				}
+				nd := int(data[0])
+				for i := range nd {
+					d.extra += int(data[1+3*i+1])
+				}
+				data = data[1+3*nd:]
+			}
+			defs[local] = d
+			continue
+		}
+
+		d := defs[local]
+		if d == nil {
+			return a, fmt.Errorf("fit: data message for undefined local type %d", local)
+		}
+		rec := Record{HeartRate: -1, Cadence: -1, Power: -1, Distance: -1}
+		var lat, lng *int32
+		creator, software := true, 0 // device_info describes the creator unless device_index says otherwise
+		for _, f := range d.fields {
+			if len(data) < int(f.size) {
+				return a, errTrunc
+			}
+			v, ok := fieldValue(data[:f.size], f, d.bigEndian)
+			data = data[f.size:]
+			if !ok {
+				continue
+			}
+			switch {
+			case f.num == 253:
+				last = uint32(v)
+			case d.global == 20 && f.num == 3:
+				rec.HeartRate = int(v)
+			case d.global == 20 && f.num == 4:
+				rec.Cadence = int(v)
+			case d.global == 20 && f.num == 7:
+				rec.Power = int(v)
+			case d.global == 20 && f.num == 5:
+				rec.Distance = float64(v) / 100
+			case d.global == 20 && (f.num == 2 || f.num == 78): // altitude, enhanced_altitude
+				alt := float64(v)/altitudeScale - altitudeOffset
+				rec.Altitude = &alt
+			case d.global == 20 && f.num == 0:
+				l := int32(uint32(v))
+				lat = &l
+			case d.global == 20 && f.num == 1:
+				l := int32(uint32(v))
+				lng = &l
+			case d.global == 18 && f.num == 5:
+				a.Sport = Sport(v)
+			case d.global == 18 && f.num == 6:
+				a.SubSport = SubSport(v)
+			case d.global == 0 && f.num == 1:
+				a.Device.Manufacturer = int(v)
+			case d.global == 0 && f.num == 2:
+				a.Device.Product = int(v)
+			case d.global == 23 && f.num == 0:
+				creator = v == 0
+			case (d.global == 23 && f.num == 5) || (d.global == 49 && f.num == 0):
+				software = int(v)
+			}
+		}
+		if software != 0 && creator && a.Device.Software == 0 {
+			a.Device.Software = software
+		}
+		if len(data) < d.extra {
+			return a, errTrunc
+		}
+		data = data[d.extra:]
+		if d.global != 20 {
+			continue
+		}
+		rec.Time = epoch.Add(time.Duration(last) * time.Second)
+		if lat != nil && lng != nil {
+			rec.Position = &geo.Point{Lat: degrees(*lat), Lng: degrees(*lng)}
+		}
+		a.Records = append(a.Records, rec)
+	}
+	return a, nil
+}
+
+// fieldValue decodes an integer field, reporting false for the base type's
+// invalid value and for types Record has no use for.
+func fieldValue(b []byte, f field, bigEndian bool) (uint64, bool) {
+	var order binary.ByteOrder = binary.LittleEndian
+	if bigEndian {
+		order = binary.BigEndian
+	}
+	switch {
+	case len(b) == 1 && (f.typ == typeEnum || f.typ == typeUint8):
+		return uint64(b[0]), b[0] != invalidUint8
+	case len(b) == 2 && f.typ == typeUint16:
+		v := order.Uint16(b)
+		return uint64(v), v != invalidUint16
+	case len(b) == 4 && f.typ == typeUint32:
+		v := order.Uint32(b)
+		return uint64(v), v != invalidUint32
+	case len(b) == 4 && f.typ == typeSint32:
+		v := order.Uint32(b)
+		return uint64(v), v != invalidSint32
+	}
+	return 0, false
+}
+
+func degrees(semicircles int32) float64 { return float64(semicircles) * 180 / (1 << 31) }
@@ -0,0 +1,454 @@
+// Package fit writes and reads activity files in Garmin's Flexible and
+// Interoperable Data Transfer (FIT) format, the format Strava prefers for
+// uploads. It covers what a recorded session needs: a record per sample with
+// heart rate, power, cadence and position, plus the lap, session and
+// activity summaries that uploaders require. Decode reads the records back
+// from any device's file, skipping the rest.
+package fit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/geo"
+)
+
+// profileVersion is the FIT SDK profile the files claim to follow (21.32).
+const profileVersion = 2132
+
+// epoch is the zero of FIT timestamps.
+var epoch = time.Date(1989, 12, 31, 0, 0, 0, 0, time.UTC)
+
+// Sport and SubSport values from the FIT profile, for the ones this CLI
+// writes.
+type (
+	Sport    uint8
+	SubSport uint8
+)
+
+const (
+	SportGeneric      Sport = 0
+	SportRunning      Sport = 1
+	SportCycling      Sport = 2
+	SportSwimming     Sport = 5
+	SportWalking      Sport = 11
+	SportXCSkiing     Sport = 12
+	SportAlpineSkiing Sport = 13
+	SportRowing       Sport = 15
+	SportHiking       Sport = 17
+	SportPaddling     Sport = 19
+	SportEBiking      Sport = 21
+)
+
+const (
+	SubSportGeneric       SubSport = 0
+	SubSportTreadmill     SubSport = 1
+	SubSportIndoorCycling SubSport = 6
+)
+
+// Record is one sample. Negative HeartRate, Cadence or Power, and a nil
+// Position or Altitude, mean the value was not recorded; Distance is meters
+// from the start, or negative if unknown.
+type Record struct {
+	Time      time.Time
+	HeartRate int // bpm
+	Cadence   int // rpm
+	Power     int // watts
+	Distance  float64
+	Position  *geo.Point
+	Altitude  *float64 // meters
+}
+
+// Activity is a recorded session: one lap holding every record.
+type Activity struct {
+	Sport    Sport
+	SubSport SubSport
+	Device   Device   // filled by Decode; Encode writes its own
+	Records  []Record // in time order
+}
+
+// Device is the device that recorded a file, from its file_id message and
+// the creator's device_info or file_creator message. Zero values are
+// unknown.
+type Device struct {
+	Manufacturer int
+	Product      int
+	Software     int // firmware version ×100, e.g. 975 for 9.75
+}
+
+// String names the device, e.g. "Garmin (product 3121), firmware 9.75".
+func (d Device) String() string {
+	s := manufacturers[d.Manufacturer]
+	if s == "" {
+		s = fmt.Sprintf("manufacturer %d", d.Manufacturer)
+	}
+	if d.Product != 0 {
+		s += fmt.Sprintf(" (product %d)", d.Product)
+	}
+	if d.Software != 0 {
+		s += fmt.Sprintf(", firmware %d.%02d", d.Software/100, d.Software%100)
+	}
+	return s
+}
+
+// manufacturers names the FIT manufacturer IDs most files come from.
+var manufacturers = map[int]string{
+	1:   "Garmin",
+	23:  "Suunto",
+	32:  "Wahoo",
+	89:  "Tacx",
+	123: "Polar",
+	255: "development",
+	260: "Zwift",
+	265: "Strava",
+	289: "Hammerhead",
+	294: "Coros",
+}
+
+// String names the sport as the FIT profile does, e.g. "cycling".
+func (s Sport) String() string {
+	if n, ok := sports[s]; ok {
+		return n
+	}
+	return fmt.Sprintf("sport %d", s)
+}
+
+var sports = map[Sport]string{
+	0: "generic", 1: "running", 2: "cycling", 3: "transition", 4: "fitness equipment",
+	5: "swimming", 10: "training", 11: "walking", 12: "cross country skiing",
+	13: "alpine skiing", 15: "rowing", 17: "hiking", 19: "paddling", 21: "e-biking",
+}
+
+// Encode writes a as a FIT activity file.
+func Encode(w io.Writer, a Activity) error {
+	if len(a.Records) == 0 {
+		return errors.New("fit: no records to write")
+	}
+	start, end := a.Records[0].Time, a.Records[len(a.Records)-1].Time
+	elapsed := uint64(end.Sub(start).Milliseconds())
+	s := summarize(a.Records)
+
+	var e encoder
+	e.write(fileIDMsg, 4, 255, 0, 1, timestamp(start)) // activity file, "development" manufacturer
+	e.write(eventMsg, timestamp(start), 0, 0)          // timer start
+	for _, r := range a.Records {
+		lat, lng := uint64(invalidSint32), uint64(invalidSint32)
+		if r.Position != nil {
+			lat, lng = semicircles(r.Position.Lat), semicircles(r.Position.Lng)
+		}
+		alt := uint64(invalidUint16)
+		if r.Altitude != nil {
+			// Outside the field's -500 to 12606 m, the altitude is left unset.
+			if v := math.Round((*r.Altitude + altitudeOffset) * altitudeScale); v >= 0 && v < invalidUint16 {
+				alt = uint64(v)
+			}
+		}
+		e.write(recordMsg, timestamp(r.Time), lat, lng,
+			optional(r.HeartRate, invalidUint8), optional(r.Cadence, invalidUint8),
+			optional(r.Power, invalidUint16), scaled(r.Distance, 100), alt)
+	}
+	e.write(eventMsg, timestamp(end), 0, 4) // timer stop all
+	e.write(lapMsg, timestamp(end), timestamp(start), elapsed, elapsed, scaled(s.distance, 100), 9, 1)
+	e.write(sessionMsg, timestamp(end), timestamp(start), elapsed, elapsed, scaled(s.distance, 100),
+		uint64(a.Sport), uint64(a.SubSport), 0, 1, 8, 1,
+		optional(s.avgHR, invalidUint8), optional(s.maxHR, invalidUint8),
+		optional(s.avgPower, invalidUint16), optional(s.maxPower, invalidUint16))
+	e.write(activityMsg, timestamp(end), elapsed, 1, 0, 26, 1)
+
+	header := make([]byte, 14)
+	header[0] = 14
+	header[1] = 0x20 // protocol 2.0
+	binary.LittleEndian.PutUint16(header[2:], profileVersion)
+	binary.LittleEndian.PutUint32(header[4:], uint32(e.buf.Len()))
+	copy(header[8:], ".FIT")
+	binary.LittleEndian.PutUint16(header[12:], crc(0, header[:12]))
+
+	out := append(header, e.buf.Bytes()...)
+	out = binary.LittleEndian.AppendUint16(out, crc(0, out))
+	_, err := w.Write(out)
+	return err
+}
+
+// MaxHeartRateGap is how long a heart rate sample holds in MergeHeartRate:
+// a record further than this after the last sample gets no heart rate.
+const MaxHeartRateGap = 5 * time.Second
+
+// MergeHeartRate sets the heart rate of each record to that of the latest
+// sample of hr at or before it, for merging a separate heart rate recording
+// into records from another device. Both must be in time order.
+func MergeHeartRate(records, hr []Record) {
+	next, latest := 0, -1
+	for i := range records {
+		t := records[i].Time
+		for ; next < len(hr) && !hr[next].Time.After(t); next++ {
+			if hr[next].HeartRate > 0 {
+				latest = next
+			}
+		}
+		records[i].HeartRate = -1
+		if latest >= 0 && t.Sub(hr[latest].Time) <= MaxHeartRateGap {
+			records[i].HeartRate = hr[latest].HeartRate
+		}
+	}
+}
+
+// Join concatenates the parts of one recording that was split across files,
+// for example by a device reboot, into a single activity. Parts may come in
+// any order; in errors they are numbered as given, from 1. Each part must
+// have records in time order, the parts must not overlap (a record at the
+// very moment the previous part ended is dropped) and no two may be more
+// than maxGap apart. Distances of later parts continue from the end of the
+// earlier ones.
+func Join(parts []Activity, maxGap time.Duration) (Activity, error) {
+	if len(parts) < 2 {
+		return Activity{}, errors.New("fit: want at least two parts to join")
+	}
+	order := make([]int, len(parts))
+	for i, p := range parts {
+		if len(p.Records) == 0 {
+			return Activity{}, fmt.Errorf("part %d has no records", i+1)
+		}
+		for j := 1; j < len(p.Records); j++ {
+			if p.Records[j].Time.Before(p.Records[j-1].Time) {
+				return Activity{}, fmt.Errorf("part %d: timestamps go backwards at %s", i+1, p.Records[j].Time.Format(time.RFC3339))
+			}
+		}
+		if p.Sport != parts[0].Sport {
+			return Activity{}, fmt.Errorf("part %d is %s but part 1 is %s", i+1, p.Sport, parts[0].Sport)
+		}
+		order[i] = i
+	}
+	slices.SortFunc(order, func(a, b int) int {
+		return parts[a].Records[0].Time.Compare(parts[b].Records[0].Time)
+	})
+
+	first := parts[order[0]]
+	out := Activity{Sport: first.Sport, SubSport: first.SubSport, Device: first.Device}
+	offset := 0.0 // distance at the end of the parts so far
+	for k, i := range order {
+		recs := parts[i].Records
+		if k > 0 {
+			prev := order[k-1]
+			end := out.Records[len(out.Records)-1].Time
+			switch gap := recs[0].Time.Sub(end); {
+			case gap < 0:
+				return Activity{}, fmt.Errorf("part %d starts at %s, before part %d ends at %s: they overlap",
+					i+1, recs[0].Time.Format(time.TimeOnly), prev+1, end.Format(time.TimeOnly))
+			case gap > maxGap:
+				return Activity{}, fmt.Errorf("part %d starts %s after part %d ends, more than %s: they look like separate activities",
+					i+1, gap.Round(time.Second), prev+1, maxGap)
+			}
+			for len(recs) > 0 && recs[0].Time.Equal(end) {
+				recs = recs[1:]
+			}
+		}
+		last := offset
+		for _, r := range recs {
+			if r.Distance >= 0 {
+				r.Distance += offset
+				last = r.Distance
+			}
+			out.Records = append(out.Records, r)
+		}
+		offset = last
+	}
+	return out, nil
+}
+
+type summary struct {
+	distance                         float64
+	avgHR, maxHR, avgPower, maxPower int
+}
+
+// summarize totals the records for the lap and session messages.
+func summarize(records []Record) summary {
+	s := summary{distance: -1, avgHR: -1, maxHR: -1, avgPower: -1, maxPower: -1}
+	var hrSum, hrN, powerSum, powerN int
+	for _, r := range records {
+		if r.Distance >= 0 {
+			s.distance = r.Distance
+		}
+		if r.HeartRate > 0 {
+			hrSum += r.HeartRate
+			hrN++
+			s.maxHR = max(s.maxHR, r.HeartRate)
+		}
+		if r.Power >= 0 {
+			powerSum += r.Power
+			powerN++
+			s.maxPower = max(s.maxPower, r.Power)
+		}
+	}
+	if hrN > 0 {
+		s.avgHR = int(math.Round(float64(hrSum) / float64(hrN)))
+	}
+	if powerN > 0 {
+		s.avgPower = int(math.Round(float64(powerSum) / float64(powerN)))
+	}
+	return s
+}
+
+// Altitudes are stored as (meters + altitudeOffset) × altitudeScale.
+const (
+	altitudeOffset = 500
+	altitudeScale  = 5
+)
+
+func timestamp(t time.Time) uint64 { return uint64(t.Sub(epoch) / time.Second) }
+
+func semicircles(deg float64) uint64 {
+	return uint64(uint32(int32(math.Round(deg * (1 << 31) / 180))))
+}
+
+// optional returns v, or the field's invalid value when v < 0.
+func optional(v int, invalid uint64) uint64 {
+	if v < 0 {
+		return invalid
+	}
+	return uint64(v)
+}
+
+// scaled returns v×scale for a uint32 field, invalid when v < 0.
+func scaled(v, scale float64) uint64 {
+	if v < 0 {
+		return invalidUint32
+	}
+	return uint64(math.Round(v * scale))
+}
+
+// Base types, by their number in the FIT profile, and the value that marks
+// a field as not set.
+const (
+	typeEnum    = 0x00
+	typeUint8   = 0x02
+	typeUint16  = 0x84
+	typeSint32  = 0x85
+	typeUint32  = 0x86
+	typeUint32z = 0x8c
+
+	invalidUint8  = 0xff
+	invalidUint16 = 0xffff
+	invalidSint32 = 0x7fffffff
+	invalidUint32 = 0xffffffff
+)
+
+type field struct {
+	num, size, typ uint8
+}
+
+type message struct {
+	local  uint8 // local message type, 0-15
+	global uint16
+	fields []field
+}
+
+// The messages written, with their fields in the order Encode passes
+// values.
+var (
+	fileIDMsg = message{0, 0, []field{
+		{0, 1, typeEnum},    // type
+		{1, 2, typeUint16},  // manufacturer
+		{2, 2, typeUint16},  // product
+		{3, 4, typeUint32z}, // serial_number
+		{4, 4, typeUint32},  // time_created
+	}}
+	eventMsg = message{1, 21, []field{
+		{253, 4, typeUint32}, // timestamp
+		{0, 1, typeEnum},     // event
+		{1, 1, typeEnum},     // event_type
+	}}
+	recordMsg = message{2, 20, []field{
+		{253, 4, typeUint32}, // timestamp
+		{0, 4, typeSint32},   // position_lat
+		{1, 4, typeSint32},   // position_long
+		{3, 1, typeUint8},    // heart_rate
+		{4, 1, typeUint8},    // cadence
+		{7, 2, typeUint16},   // power
+		{5, 4, typeUint32},   // distance, cm
+		{2, 2, typeUint16},   // altitude, (m + 500) × 5
+	}}
+	lapMsg = message{3, 19, []field{
+		{253, 4, typeUint32}, // timestamp
+		{2, 4, typeUint32},   // start_time
+		{7, 4, typeUint32},   // total_elapsed_time, ms
+		{8, 4, typeUint32},   // total_timer_time, ms
+		{9, 4, typeUint32},   // total_distance, cm
+		{0, 1, typeEnum},     // event
+		{1, 1, typeEnum},     // event_type
+	}}
+	sessionMsg = message{4, 18, []field{
+		{253, 4, typeUint32}, // timestamp
+		{2, 4, typeUint32},   // start_time
+		{7, 4, typeUint32},   // total_elapsed_time, ms
+		{8, 4, typeUint32},   // total_timer_time, ms
+		{9, 4, typeUint32},   // total_distance, cm
+		{5, 1, typeEnum},     // sport
+		{6, 1, typeEnum},     // sub_sport
+		{25, 2, typeUint16},  // first_lap_index
+		{26, 2, typeUint16},  // num_laps
+		{0, 1, typeEnum},     // event
+		{1, 1, typeEnum},     // event_type
+		{16, 1, typeUint8},   // avg_heart_rate
+		{17, 1, typeUint8},   // max_heart_rate
+		{20, 2, typeUint16},  // avg_power
+		{21, 2, typeUint16},  // max_power
+	}}
+	activityMsg = message{5, 34, []field{
+		{253, 4, typeUint32}, // timestamp
+		{0, 4, typeUint32},   // total_timer_time, ms
+		{1, 2, typeUint16},   // num_sessions
+		{2, 1, typeEnum},     // type
+		{3, 1, typeEnum},     // event
+		{4, 1, typeEnum},     // event_type
+	}}
+)
+
+// encoder builds the data records of a file, defining each message before
+// its first use.
+type encoder struct {
+	buf     bytes.Buffer
+	defined [16]bool
+}
+
+func (e *encoder) write(m message, values ...uint64) {
+	if !e.defined[m.local] {
+		e.buf.WriteByte(0x40 | m.local)
+		e.buf.Write([]byte{0, 0}) // reserved, little-endian
+		e.buf.Write(binary.LittleEndian.AppendUint16(nil, m.global))
+		e.buf.WriteByte(uint8(len(m.fields)))
+		for _, f := range m.fields {
+			e.buf.Write([]byte{f.num, f.size, f.typ})
+		}
+		e.defined[m.local] = true
+	}
+	e.buf.WriteByte(m.local)
+	for i, f := range m.fields {
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], values[i])
+		e.buf.Write(b[:f.size])
+	}
+}
+
+var crcTable = [16]uint16{
+	0x0000, 0xcc01, 0xd801, 0x1400, 0xf001, 0x3c00, 0x2800, 0xe401,
+	0xa001, 0x6c00, 0x7800, 0xb401, 0x5000, 0x9c01, 0x8801, 0x4400,
+}
+
+// crc continues the FIT checksum c over b.
+func crc(c uint16, b []byte) uint16 {
+	for _, v := range b {
+		t := crcTable[c&0xf]
+		c = (c >> 4) & 0x0fff
+		c ^= t ^ crcTable[v&0xf]
+		t = crcTable[c&0xf]
+		c = (c >> 4) & 0x0fff
+		c ^= t ^ crcTable[(v>>4)&0xf]
+	}
+	return c
+}
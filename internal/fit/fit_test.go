@@ -0,0 +1,208 @@
+package fit_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/fit"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/geo"
+)
+
+func TestEncode_Structure(t *testing.T) {
+	start := time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)
+	var recs []fit.Record
+	for i := range 3 {
+		recs = append(recs, fit.Record{
+			Time: start.Add(time.Duration(i) * time.Second), HeartRate: 120 + i, Cadence: -1, Power: 200,
+			Distance: float64(i) * 8, Position: &geo.Point{Lat: 51.5, Lng: -0.1},
+		})
+	}
+	recs[1].HeartRate = -1 // a dropout
+
+	var buf bytes.Buffer
+	if err := fit.Encode(&buf, fit.Activity{Sport: fit.SportCycling, SubSport: fit.SubSportIndoorCycling, Records: recs}); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	b := buf.Bytes()
+	if string(b[8:12]) != ".FIT" || b[0] != 14 {
+		t.Fatalf("bad header % x", b[:14])
+	}
+	if size := binary.LittleEndian.Uint32(b[4:]); int(size) != len(b)-16 {
+		t.Fatalf("data size %d, file has %d data bytes", size, len(b)-16)
+	}
+
+	// Walk the data records, collecting heart rates from record messages
+	// (global 20, field 3).
+	type def struct {
+		global uint16
+		fields [][2]uint8 // number, size
+	}
+	defs := map[uint8]def{}
+	var hr []uint8
+	globals := map[uint16]int{}
+	for data := b[14 : len(b)-2]; len(data) > 0; {
+		h := data[0]
+		local := h & 0x0f
+		if h&0x40 != 0 {
+			d := def{global: binary.LittleEndian.Uint16(data[3:])}
+			n := int(data[5])
+			for i := range n {
+				d.fields = append(d.fields, [2]uint8{data[6+3*i], data[7+3*i]})
+			}
+			defs[local] = d
+			data = data[6+3*n:]
+			continue
+		}
+		d, ok := defs[local]
+		if !ok {
+			t.Fatalf("data message for undefined local type %d", local)
+		}
+		globals[d.global]++
+		data = data[1:]
+		for _, f := range d.fields {
+			if d.global == 20 && f[0] == 3 {
+				hr = append(hr, data[0])
+			}
+			data = data[f[1]:]
+		}
+	}
+	if globals[20] != 3 || globals[18] != 1 || globals[34] != 1 || globals[0] != 1 {
+		t.Errorf("message counts by global number = %v", globals)
+	}
+	if !bytes.Equal(hr, []byte{120, 0xff, 122}) {
+		t.Errorf("heart rates = %v, want [120 255 122] (255 = not recorded)", hr)
+	}
+}
+
+func TestEncode_NoRecords(t *testing.T) {
+	if err := fit.Encode(&bytes.Buffer{}, fit.Activity{}); err == nil {
+		t.Error("Encode of an empty activity succeeded")
+	}
+}
+
+func TestDecode_RoundTrip(t *testing.T) {
+	start := time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)
+	alt := -12.4
+	in := fit.Activity{Sport: fit.SportCycling, SubSport: fit.SubSportIndoorCycling, Records: []fit.Record{
+		{Time: start, HeartRate: 110, Cadence: 85, Power: 180, Distance: 0, Position: &geo.Point{Lat: 51.5, Lng: -0.1}, Altitude: &alt},
+		{Time: start.Add(time.Second), HeartRate: -1, Cadence: -1, Power: 0, Distance: -1},
+	}}
+	var buf bytes.Buffer
+	if err := fit.Encode(&buf, in); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	out, err := fit.Decode(&buf)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if out.Sport != fit.SportCycling || out.SubSport != fit.SubSportIndoorCycling || len(out.Records) != 2 {
+		t.Fatalf("decoded %+v", out)
+	}
+	r0, r1 := out.Records[0], out.Records[1]
+	if !r0.Time.Equal(start) || r0.HeartRate != 110 || r0.Cadence != 85 || r0.Power != 180 || r0.Position == nil ||
+		math.Abs(r0.Position.Lat-51.5) > 1e-6 || math.Abs(r0.Position.Lng+0.1) > 1e-6 || r0.Altitude == nil || math.Abs(*r0.Altitude-alt) > 0.1 {
+		t.Errorf("record 0 = %+v", r0)
+	}
+	if r1.HeartRate != -1 || r1.Cadence != -1 || r1.Power != 0 || r1.Distance != -1 || r1.Position != nil || r1.Altitude != nil {
+		t.Errorf("record 1 = %+v, want only power (0 W) set", r1)
+	}
+
+	var corrupt bytes.Buffer
+	if err := fit.Encode(&corrupt, in); err != nil {
+		t.Fatal(err)
+	}
+	b := corrupt.Bytes()
+	b[20] ^= 0xff
+	if _, err := fit.Decode(bytes.NewReader(b)); err == nil {
+		t.Error("Decode accepted a file with a bad checksum")
+	}
+}
+
+func TestEncode_AltitudeOutOfRange(t *testing.T) {
+	start := time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)
+	alts := []float64{-600, 20000, 12000}
+	var in fit.Activity
+	for i := range alts {
+		in.Records = append(in.Records, fit.Record{Time: start.Add(time.Duration(i) * time.Second),
+			HeartRate: -1, Cadence: -1, Power: -1, Distance: -1, Altitude: &alts[i]})
+	}
+	var buf bytes.Buffer
+	if err := fit.Encode(&buf, in); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	out, err := fit.Decode(&buf)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	r := out.Records
+	if r[0].Altitude != nil || r[1].Altitude != nil {
+		t.Errorf("altitudes -600 and 20000 m decoded as set, want unset")
+	}
+	if r[2].Altitude == nil || math.Abs(*r[2].Altitude-12000) > 0.1 {
+		t.Errorf("altitude 12000 m decoded as %v", r[2].Altitude)
+	}
+}
+
+func TestMergeHeartRate(t *testing.T) {
+	start := time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)
+	at := func(s int) time.Time { return start.Add(time.Duration(s) * time.Second) }
+	recs := []fit.Record{{Time: at(0)}, {Time: at(1)}, {Time: at(3)}, {Time: at(20)}}
+	hr := []fit.Record{{Time: at(1), HeartRate: 100}, {Time: at(2), HeartRate: -1}, {Time: at(3), HeartRate: 105}}
+	fit.MergeHeartRate(recs, hr)
+	var got []int
+	for _, r := range recs {
+		got = append(got, r.HeartRate)
+	}
+	if want := []int{-1, 100, 105, -1}; !slices.Equal(got, want) {
+		t.Errorf("heart rates = %v, want %v (none before the first sample or after a long gap)", got, want)
+	}
+}
+
+func TestJoin(t *testing.T) {
+	start := time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)
+	part := func(from, n int, dist float64) fit.Activity {
+		a := fit.Activity{Sport: fit.SportCycling}
+		for i := range n {
+			a.Records = append(a.Records, fit.Record{Time: start.Add(time.Duration(from+i) * time.Second),
+				HeartRate: -1, Cadence: -1, Power: -1, Distance: dist * float64(i)})
+		}
+		return a
+	}
+
+	// Given out of order; the second starts where the first ended.
+	got, err := fit.Join([]fit.Activity{part(62, 3, 10), part(0, 3, 5)}, time.Minute)
+	if err != nil {
+		t.Fatalf("Join: %v", err)
+	}
+	var secs []int
+	var dists []float64
+	for _, r := range got.Records {
+		secs = append(secs, int(r.Time.Sub(start).Seconds()))
+		dists = append(dists, r.Distance)
+	}
+	if want := []int{0, 1, 2, 62, 63, 64}; !slices.Equal(secs, want) {
+		t.Errorf("times = %v, want %v", secs, want)
+	}
+	if want := []float64{0, 5, 10, 10, 20, 30}; !slices.Equal(dists, want) {
+		t.Errorf("distances = %v, want %v (continuing from the first part)", dists, want)
+	}
+
+	if _, err := fit.Join([]fit.Activity{part(0, 3, 5), part(2, 3, 5)}, time.Minute); err != nil {
+		t.Errorf("a shared boundary record was rejected: %v", err)
+	}
+	for name, parts := range map[string][]fit.Activity{
+		"overlap":  {part(0, 10, 1), part(5, 10, 1)},
+		"far gap":  {part(0, 3, 1), part(3600, 3, 1)},
+		"one part": {part(0, 3, 1)},
+		"empty":    {part(0, 3, 1), {Sport: fit.SportCycling}},
+		"sports":   {part(0, 3, 1), {Sport: fit.SportRunning, Records: part(10, 3, 1).Records}},
+	} {
+		if _, err := fit.Join(parts, time.Minute); err == nil {
+			t.Errorf("%s: Join succeeded", name)
+		}
+	}
+}
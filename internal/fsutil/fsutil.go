@@ -0,0 +1,150 @@
+// Package fsutil writes files crash-safely. Data goes to a temporary file
+// next to the destination, which replaces the destination only once it is
+// complete, so an interrupted write leaves the old file (or none) behind,
+// never a truncated one. Temporary files carry TempSuffix, so those left by
+// a crash can be found and removed later (see FindTemp).
+package fsutil
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// TempSuffix ends the name of every temporary file this package creates.
+const TempSuffix = ".strava-tmp"
+
+// File is a file being written in place of another. Write to it, then
+// Commit to put it in place; Discard, which is safe to defer, removes it if
+// it was not committed.
+type File struct {
+	*os.File
+	path      string
+	committed bool
+}
+
+// Create starts writing a file that will replace path, with permissions
+// perm, when committed.
+func Create(path string, perm os.FileMode) (*File, error) {
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	f, err := os.CreateTemp(dir, "."+base+".*"+TempSuffix)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Chmod(perm); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, err
+	}
+	return &File{File: f, path: path}, nil
+}
+
+// Commit flushes the file to disk and moves it into place.
+func (f *File) Commit() error {
+	err := f.Sync()
+	if cerr := f.File.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), f.path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	f.committed = true
+	return nil
+}
+
+// Discard abandons the file unless it was committed.
+func (f *File) Discard() {
+	if f.committed {
+		return
+	}
+	f.File.Close()
+	os.Remove(f.Name())
+}
+
+// WriteFile replaces path with data, crash-safely.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	f, err := Create(path, perm)
+	if err != nil {
+		return err
+	}
+	defer f.Discard()
+	if _, err := f.Write(data); err != nil {
+		return err
+	}
+	return f.Commit()
+}
+
+// CreateTemp creates a scratch file in the system temporary directory, named
+// after pattern as os.CreateTemp does. The caller removes it; FindTemp finds
+// it if the caller never gets to.
+func CreateTemp(pattern string) (*os.File, error) {
+	return os.CreateTemp("", "strava-"+pattern+TempSuffix)
+}
+
+// IsTemp reports whether name is that of a temporary file of this package.
+func IsTemp(name string) bool {
+	return strings.HasSuffix(name, TempSuffix)
+}
+
+// Entry is a file found by FindTemp, FindOlder or List.
+type Entry struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// FindTemp returns the temporary files under dir, and its subdirectories
+// if recursive, last modified before cutoff. A missing dir has none.
+func FindTemp(dir string, recursive bool, cutoff time.Time) ([]Entry, error) {
+	return find(dir, recursive, func(name string, mod time.Time) bool {
+		return IsTemp(name) && mod.Before(cutoff)
+	})
+}
+
+// FindOlder returns every file under dir, recursively, last modified before
+// cutoff.
+func FindOlder(dir string, cutoff time.Time) ([]Entry, error) {
+	return find(dir, true, func(_ string, mod time.Time) bool { return mod.Before(cutoff) })
+}
+
+func find(dir string, recursive bool, match func(name string, mod time.Time) bool) ([]Entry, error) {
+	var out []Entry
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && !recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil // removed while walking
+		}
+		if info.Mode().IsRegular() && match(d.Name(), info.ModTime()) {
+			out = append(out, Entry{Path: path, Size: info.Size(), ModTime: info.ModTime()})
+		}
+		return nil
+	})
+	return out, err
+}
+
+// List returns every file under dir, recursively.
+func List(dir string) ([]Entry, error) {
+	return find(dir, true, func(string, time.Time) bool { return true })
+}
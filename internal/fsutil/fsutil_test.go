@@ -0,0 +1,90 @@
+package fsutil_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/fsutil"
+)
+
+func TestCreate_CommitReplaces(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.gpx")
+	os.WriteFile(path, []byte("old"), 0600)
+
+	f, err := fsutil.Create(path, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("new")
+	if b, _ := os.ReadFile(path); string(b) != "old" {
+		t.Errorf("before Commit: %q, want the old contents untouched", b)
+	}
+	if !fsutil.IsTemp(f.Name()) || filepath.Dir(f.Name()) != dir {
+		t.Errorf("temporary file %s: want one with the temp suffix next to the destination", f.Name())
+	}
+	if err := f.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	f.Discard() // no-op once committed
+
+	if b, _ := os.ReadFile(path); string(b) != "new" {
+		t.Errorf("after Commit: %q, want new", b)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0644 {
+		t.Errorf("mode = %v, want 0644", info.Mode().Perm())
+	}
+	if left, _ := fsutil.FindTemp(dir, true, time.Now().Add(time.Hour)); len(left) != 0 {
+		t.Errorf("temporary files left: %+v", left)
+	}
+}
+
+func TestCreate_DiscardKeepsOld(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+	os.WriteFile(path, []byte("old"), 0600)
+
+	f, err := fsutil.Create(path, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("half")
+	f.Discard()
+
+	if b, _ := os.ReadFile(path); string(b) != "old" {
+		t.Errorf("after Discard: %q, want old", b)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("%d files in dir, want only the destination", len(entries))
+	}
+}
+
+func TestFindTemp(t *testing.T) {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "sub")
+	os.Mkdir(sub, 0700)
+	old := time.Now().Add(-2 * time.Hour)
+	for _, p := range []string{
+		filepath.Join(dir, ".a.json.1"+fsutil.TempSuffix),
+		filepath.Join(sub, ".b.json.2"+fsutil.TempSuffix),
+		filepath.Join(dir, "c.json"),
+	} {
+		os.WriteFile(p, []byte("x"), 0600)
+		os.Chtimes(p, old, old)
+	}
+	os.WriteFile(filepath.Join(dir, ".fresh"+fsutil.TempSuffix), []byte("x"), 0600)
+
+	cutoff := time.Now().Add(-time.Hour)
+	if got, _ := fsutil.FindTemp(dir, false, cutoff); len(got) != 1 || !strings.HasPrefix(filepath.Base(got[0].Path), ".a.json") {
+		t.Errorf("not recursive: %+v, want only .a.json's", got)
+	}
+	if got, _ := fsutil.FindTemp(dir, true, cutoff); len(got) != 2 {
+		t.Errorf("recursive: %+v, want .a.json's and .b.json's", got)
+	}
+	if got, err := fsutil.FindTemp(filepath.Join(dir, "missing"), true, cutoff); got != nil || err != nil {
+		t.Errorf("missing dir: %v, %v; want none", got, err)
+	}
+}
@@ -0,0 +1,145 @@
+// Package geo provides coordinate helpers: polyline decoding and distances.
+package geo
+
+import "math"
+
+const earthRadius = 6371000.0 // meters
+
+// Point is a latitude/longitude pair in decimal degrees.
+type Point struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// PointFromSlice converts a Strava [lat, lng] pair into a Point.
+// It returns false if the slice does not hold exactly two values.
+func PointFromSlice[T float32 | float64](v []T) (Point, bool) {
+	if len(v) != 2 {
+		return Point{}, false
+	}
+	return Point{Lat: float64(v[0]), Lng: float64(v[1])}, true
+}
+
+// Distance returns the great-circle distance between a and b in meters.
+func Distance(a, b Point) float64 {
+	lat1 := a.Lat * math.Pi / 180
+	lat2 := b.Lat * math.Pi / 180
+	dLat := lat2 - lat1
+	dLng := (b.Lng - a.Lng) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// DecodePolyline decodes a Google encoded polyline (precision 5), the format
+// Strava uses for map.polyline and map.summary_polyline.
+// Malformed trailing input is ignored.
+func DecodePolyline(s string) []Point {
+	var pts []Point
+	var lat, lng int
+	for i := 0; i < len(s); {
+		dLat, n := decodeValue(s[i:])
+		if n == 0 {
+			break
+		}
+		i += n
+		dLng, n := decodeValue(s[i:])
+		if n == 0 {
+			break
+		}
+		i += n
+		lat += dLat
+		lng += dLng
+		pts = append(pts, Point{Lat: float64(lat) / 1e5, Lng: float64(lng) / 1e5})
+	}
+	return pts
+}
+
+// decodeValue reads one zig-zag encoded varint and returns it with the number
+// of bytes consumed (0 if the input ended mid-value).
+func decodeValue(s string) (int, int) {
+	var result, shift int
+	for i := 0; i < len(s); i++ {
+		b := int(s[i]) - 63
+		result |= (b & 0x1f) << shift
+		shift += 5
+		if b < 0x20 {
+			if result&1 != 0 {
+				return ^(result >> 1), i + 1
+			}
+			return result >> 1, i + 1
+		}
+	}
+	return 0, 0
+}
+
+// EncodePolyline is the inverse of DecodePolyline.
+func EncodePolyline(pts []Point) string {
+	var buf []byte
+	var prevLat, prevLng int
+	for _, p := range pts {
+		lat := int(math.Round(p.Lat * 1e5))
+		lng := int(math.Round(p.Lng * 1e5))
+		buf = encodeValue(buf, lat-prevLat)
+		buf = encodeValue(buf, lng-prevLng)
+		prevLat, prevLng = lat, lng
+	}
+	return string(buf)
+}
+
+func encodeValue(buf []byte, v int) []byte {
+	u := v << 1
+	if v < 0 {
+		u = ^u
+	}
+	for u >= 0x20 {
+		buf = append(buf, byte((0x20|(u&0x1f))+63))
+		u >>= 5
+	}
+	return append(buf, byte(u+63))
+}
+
+// Bounds returns the south-west and north-east corners enclosing pts.
+func Bounds(pts []Point) (sw, ne Point) {
+	if len(pts) == 0 {
+		return Point{}, Point{}
+	}
+	sw, ne = pts[0], pts[0]
+	for _, p := range pts[1:] {
+		sw.Lat = math.Min(sw.Lat, p.Lat)
+		sw.Lng = math.Min(sw.Lng, p.Lng)
+		ne.Lat = math.Max(ne.Lat, p.Lat)
+		ne.Lng = math.Max(ne.Lng, p.Lng)
+	}
+	return sw, ne
+}
+
+// Resample returns n points spaced evenly by distance along the line through
+// pts, including both ends. Lines shorter than two points are returned as is.
+func Resample(pts []Point, n int) []Point {
+	if len(pts) < 2 || n < 2 {
+		return pts
+	}
+	cum := make([]float64, len(pts))
+	for i := 1; i < len(pts); i++ {
+		cum[i] = cum[i-1] + Distance(pts[i-1], pts[i])
+	}
+	total := cum[len(cum)-1]
+	out := make([]Point, 0, n)
+	j := 0
+	for k := 0; k < n; k++ {
+		d := total * float64(k) / float64(n-1)
+		for j < len(pts)-2 && cum[j+1] < d {
+			j++
+		}
+		t := 0.0
+		if seg := cum[j+1] - cum[j]; seg > 0 {
+			t = math.Max(0, math.Min(1, (d-cum[j])/seg))
+		}
+		out = append(out, Point{
+			Lat: pts[j].Lat + (pts[j+1].Lat-pts[j].Lat)*t,
+			Lng: pts[j].Lng + (pts[j+1].Lng-pts[j].Lng)*t,
+		})
+	}
+	return out
+}
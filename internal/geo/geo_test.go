@@ -0,0 +1,117 @@
+package geo_test
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/geo"
+)
+
+func TestDecodePolyline_GoogleExample(t *testing.T) {
+	// Reference example from Google's polyline algorithm documentation.
+	pts := geo.DecodePolyline("_p~iF~ps|U_ulLnnqC_mqNvxq`@")
+	want := []geo.Point{{38.5, -120.2}, {40.7, -120.95}, {43.252, -126.453}}
+	if len(pts) != len(want) {
+		t.Fatalf("got %d points, want %d", len(pts), len(want))
+	}
+	for i := range want {
+		if math.Abs(pts[i].Lat-want[i].Lat) > 1e-6 || math.Abs(pts[i].Lng-want[i].Lng) > 1e-6 {
+			t.Errorf("point %d = %+v, want %+v", i, pts[i], want[i])
+		}
+	}
+}
+
+func TestEncodePolyline_RoundTrip(t *testing.T) {
+	in := []geo.Point{{51.50722, -0.1275}, {51.50901, -0.12512}, {51.5, -0.13}}
+	out := geo.DecodePolyline(geo.EncodePolyline(in))
+	if len(out) != len(in) {
+		t.Fatalf("round trip lost points: %v", out)
+	}
+	for i := range in {
+		if math.Abs(out[i].Lat-in[i].Lat) > 1e-5 || math.Abs(out[i].Lng-in[i].Lng) > 1e-5 {
+			t.Errorf("point %d = %+v, want %+v", i, out[i], in[i])
+		}
+	}
+}
+
+func TestDecodePolyline_Truncated(t *testing.T) {
+	if pts := geo.DecodePolyline("_p~iF~ps|U_ul"); len(pts) != 1 {
+		t.Errorf("expected 1 complete point from truncated input, got %d", len(pts))
+	}
+}
+
+func TestDistance(t *testing.T) {
+	// One degree of latitude is ~111.2 km.
+	d := geo.Distance(geo.Point{Lat: 0, Lng: 0}, geo.Point{Lat: 1, Lng: 0})
+	if math.Abs(d-111195) > 100 {
+		t.Errorf("Distance = %.0f m, want ~111195 m", d)
+	}
+	if d := geo.Distance(geo.Point{Lat: 10, Lng: 10}, geo.Point{Lat: 10, Lng: 10}); d != 0 {
+		t.Errorf("Distance to self = %v, want 0", d)
+	}
+}
+
+func TestParseGPX_TrackAndProfile(t *testing.T) {
+	doc := `<?xml version="1.0"?>
+<gpx version="1.1"><trk><trkseg>
+  <trkpt lat="51.5" lon="-0.1"><ele>10</ele></trkpt>
+  <trkpt lat="51.501" lon="-0.1"></trkpt>
+  <trkpt lat="51.502" lon="-0.1"><ele>14.5</ele></trkpt>
+</trkseg></trk></gpx>`
+	pts, err := geo.ParseGPX(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pts) != 3 || pts[1].HasEle || !pts[2].HasEle {
+		t.Fatalf("unexpected points: %+v", pts)
+	}
+
+	dist, ele := geo.Profile(pts)
+	if len(dist) != 2 || ele[1] != 14.5 {
+		t.Fatalf("profile = %v / %v, want the two points with elevation", dist, ele)
+	}
+	// 0.002° of latitude ≈ 222 m, including the point without elevation.
+	if math.Abs(dist[1]-222.4) > 1 {
+		t.Errorf("distance = %.1f m, want ≈222.4", dist[1])
+	}
+}
+
+func TestParseGPX_Invalid(t *testing.T) {
+	if _, err := geo.ParseGPX(strings.NewReader("not xml")); err == nil {
+		t.Error("expected an error for malformed GPX")
+	}
+}
+
+func TestResample_EvenSpacing(t *testing.T) {
+	// An L-shaped line: the middle sample lands on the corner.
+	pts := []geo.Point{{Lat: 0, Lng: 0}, {Lat: 0, Lng: 0.01}, {Lat: 0, Lng: 0.02}, {Lat: 0.02, Lng: 0.02}}
+	got := geo.Resample(pts, 5)
+	if len(got) != 5 || got[0] != pts[0] || got[4] != pts[3] {
+		t.Fatalf("Resample = %v, want 5 points from start to end", got)
+	}
+	if math.Abs(got[2].Lat) > 1e-9 || math.Abs(got[2].Lng-0.02) > 1e-9 {
+		t.Errorf("middle point = %v, want the corner", got[2])
+	}
+}
+
+func TestWriteGPX_RoundTrip(t *testing.T) {
+	pts := []geo.TrackPoint{
+		{Point: geo.Point{Lat: 51.5, Lng: -0.1}, Ele: 10, HasEle: true},
+		{Point: geo.Point{Lat: 51.501, Lng: -0.1}},
+	}
+	var buf strings.Builder
+	if err := geo.WriteGPX(&buf, "Box Hill & back", pts); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "<name>Box Hill &amp; back</name>") {
+		t.Errorf("track name missing or unescaped:\n%s", buf.String())
+	}
+	got, err := geo.ParseGPX(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != pts[0] || got[1] != pts[1] {
+		t.Errorf("round trip = %+v, want %+v", got, pts)
+	}
+}
@@ -0,0 +1,116 @@
+package geo
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// TrackPoint is one point of a GPX track or route. Ele (meters) is only
+// meaningful when HasEle is set; many route exports omit <ele>.
+type TrackPoint struct {
+	Point
+	Ele    float64
+	HasEle bool
+}
+
+// gpxPoint matches both <trkpt> and <rtept>.
+type gpxPoint struct {
+	Lat float64  `xml:"lat,attr"`
+	Lon float64  `xml:"lon,attr"`
+	Ele *float64 `xml:"ele"`
+}
+
+type gpxFile struct {
+	Tracks []struct {
+		Segments []struct {
+			Points []gpxPoint `xml:"trkpt"`
+		} `xml:"trkseg"`
+	} `xml:"trk"`
+	Routes []struct {
+		Points []gpxPoint `xml:"rtept"`
+	} `xml:"rte"`
+}
+
+// ParseGPX reads every track point (and route point) of a GPX document in
+// document order.
+func ParseGPX(r io.Reader) ([]TrackPoint, error) {
+	var f gpxFile
+	if err := xml.NewDecoder(r).Decode(&f); err != nil {
+		return nil, fmt.Errorf("parse GPX: %w", err)
+	}
+	var pts []TrackPoint
+	add := func(p gpxPoint) {
+		tp := TrackPoint{Point: Point{Lat: p.Lat, Lng: p.Lon}}
+		if p.Ele != nil {
+			tp.Ele, tp.HasEle = *p.Ele, true
+		}
+		pts = append(pts, tp)
+	}
+	for _, t := range f.Tracks {
+		for _, s := range t.Segments {
+			for _, p := range s.Points {
+				add(p)
+			}
+		}
+	}
+	for _, rt := range f.Routes {
+		for _, p := range rt.Points {
+			add(p)
+		}
+	}
+	return pts, nil
+}
+
+// Profile returns the cumulative distance (meters) and elevation of the
+// points that carry an elevation, for plotting an elevation profile.
+func Profile(pts []TrackPoint) (dist, ele []float64) {
+	total := 0.0
+	for i, p := range pts {
+		if i > 0 {
+			total += Distance(pts[i-1].Point, p.Point)
+		}
+		if p.HasEle {
+			dist = append(dist, total)
+			ele = append(ele, p.Ele)
+		}
+	}
+	return dist, ele
+}
+
+// WriteGPX writes pts as a GPX 1.1 document with a single named track, the
+// form bike computers load as a course.
+func WriteGPX(w io.Writer, name string, pts []TrackPoint) error {
+	type trkpt struct {
+		Lat float64  `xml:"lat,attr"`
+		Lon float64  `xml:"lon,attr"`
+		Ele *float64 `xml:"ele,omitempty"`
+	}
+	type doc struct {
+		XMLName xml.Name `xml:"gpx"`
+		Version string   `xml:"version,attr"`
+		Creator string   `xml:"creator,attr"`
+		NS      string   `xml:"xmlns,attr"`
+		Name    string   `xml:"trk>name"`
+		Points  []trkpt  `xml:"trk>trkseg>trkpt"`
+	}
+	d := doc{Version: "1.1", Creator: "strava-cli", NS: "http://www.topografix.com/GPX/1/1", Name: name}
+	for _, p := range pts {
+		tp := trkpt{Lat: p.Lat, Lon: p.Lng}
+		if p.HasEle {
+			ele := p.Ele
+			tp.Ele = &ele
+		}
+		d.Points = append(d.Points, tp)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(d); err != nil {
+		return fmt.Errorf("write GPX: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,251 @@
+// Package influx writes activities and their streams as InfluxDB line
+// protocol, to any writer or to an InfluxDB server's write endpoint.
+package influx
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/analysis"
+)
+
+// Measurement names.
+const (
+	ActivityMeasurement = "strava_activity"
+	StreamMeasurement   = "strava_stream"
+)
+
+// Point is one line of line protocol. Tags are written sorted by key, as
+// InfluxDB prefers; fields in order.
+type Point struct {
+	Measurement string
+	Tags        map[string]string
+	Fields      []Field
+	Time        time.Time // written in seconds
+}
+
+// Field is a field of a point. Value is a float64, int, int64, bool or
+// string.
+type Field struct {
+	Key   string
+	Value any
+}
+
+// Append appends p's line, with its trailing newline, to b. A point without
+// fields has no line. A field of an unsupported type is an error, and b is
+// returned as it was.
+func (p Point) Append(b []byte) ([]byte, error) {
+	if len(p.Fields) == 0 {
+		return b, nil
+	}
+	start := len(b)
+	b = append(b, escape(p.Measurement, ", ")...)
+	keys := make([]string, 0, len(p.Tags))
+	for k, v := range p.Tags {
+		if v != "" {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		b = append(b, ',')
+		b = append(b, escape(k, ",= ")...)
+		b = append(b, '=')
+		b = append(b, escape(p.Tags[k], ",= ")...)
+	}
+	for i, f := range p.Fields {
+		if i == 0 {
+			b = append(b, ' ')
+		} else {
+			b = append(b, ',')
+		}
+		b = append(b, escape(f.Key, ",= ")...)
+		b = append(b, '=')
+		switch v := f.Value.(type) {
+		case float64:
+			b = strconv.AppendFloat(b, v, 'f', -1, 64)
+		case int:
+			b = strconv.AppendInt(b, int64(v), 10)
+			b = append(b, 'i')
+		case int64:
+			b = strconv.AppendInt(b, v, 10)
+			b = append(b, 'i')
+		case bool:
+			b = strconv.AppendBool(b, v)
+		case string:
+			b = append(b, '"')
+			b = append(b, strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(v)...)
+			b = append(b, '"')
+		default:
+			return b[:start], fmt.Errorf("influx: field %s has unsupported type %T", f.Key, v)
+		}
+	}
+	b = append(b, ' ')
+	b = strconv.AppendInt(b, p.Time.Unix(), 10)
+	return append(b, '\n'), nil
+}
+
+// escape backslash-escapes each of chars in s. Newlines, which line
+// protocol cannot hold, become spaces.
+func escape(s, chars string) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	if !strings.ContainsAny(s, chars) {
+		return s
+	}
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(chars, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// ActivityPoint is an activity's summary as a point at its start, tagged
+// by sport type and gear. Heart rate, power and Relative Effort fields are
+// left out when the activity has none.
+func ActivityPoint(a analysis.Activity) Point {
+	p := Point{
+		Measurement: ActivityMeasurement,
+		Tags:        map[string]string{"sport_type": a.SportType, "gear_id": a.GearID},
+		Fields: []Field{
+			{"id", a.ID},
+			{"name", a.Name},
+			{"distance", a.Distance},
+			{"moving_time", a.MovingTime},
+			{"elapsed_time", a.ElapsedTime},
+			{"elevation_gain", a.TotalElevationGain},
+			{"average_speed", a.AverageSpeed},
+			{"max_speed", a.MaxSpeed},
+			{"commute", a.Commute},
+			{"trainer", a.Trainer},
+		},
+		Time: a.StartDate,
+	}
+	for _, f := range []Field{
+		{"average_heartrate", a.AverageHeartrate},
+		{"max_heartrate", a.MaxHeartrate},
+		{"average_watts", a.AverageWatts},
+		{"weighted_average_watts", a.WeightedAverageWatts},
+		{"kilojoules", a.Kilojoules},
+		{"suffer_score", a.SufferScore},
+	} {
+		if f.Value.(float64) > 0 {
+			p.Fields = append(p.Fields, f)
+		}
+	}
+	return p
+}
+
+// StreamPoints are an activity's streams as a point per sample, at the
+// activity's start plus the sample's time, tagged by activity ID and sport
+// type. Streams whose length differs from the time stream's are left out.
+func StreamPoints(a analysis.Activity, s *analysis.Streams) []Point {
+	if s == nil {
+		return nil
+	}
+	n := len(s.Time)
+	tags := map[string]string{"activity_id": strconv.FormatInt(a.ID, 10), "sport_type": a.SportType}
+	points := make([]Point, 0, n)
+	for i, t := range s.Time {
+		var fields []Field
+		addFloat := func(key string, v []float64) {
+			if len(v) == n {
+				fields = append(fields, Field{key, v[i]})
+			}
+		}
+		addInt := func(key string, v []int) {
+			if len(v) == n {
+				fields = append(fields, Field{key, v[i]})
+			}
+		}
+		addFloat("distance", s.Distance)
+		addFloat("altitude", s.Altitude)
+		addFloat("velocity", s.VelocitySmooth)
+		addFloat("grade", s.GradeSmooth)
+		addInt("heartrate", s.Heartrate)
+		addInt("cadence", s.Cadence)
+		addInt("watts", s.Watts)
+		addInt("temp", s.Temp)
+		if len(fields) > 0 {
+			points = append(points, Point{Measurement: StreamMeasurement, Tags: tags, Fields: fields, Time: a.StartDate.Add(time.Duration(t) * time.Second)})
+		}
+	}
+	return points
+}
+
+// StreamKeys are the streams StreamPoints writes, with time.
+var StreamKeys = []string{"time", "distance", "altitude", "velocity_smooth", "grade_smooth", "heartrate", "cadence", "watts", "temp"}
+
+// Server is an InfluxDB server to write points to: InfluxDB 2 (or 3) with
+// Org and Bucket, or InfluxDB 1 with DB. Token, when set, is sent as the
+// Authorization token ("user:password" for InfluxDB 1).
+type Server struct {
+	URL    string
+	Org    string
+	Bucket string
+	DB     string
+	Token  string
+	HTTP   *http.Client
+}
+
+// Write posts lines of line protocol, with times in seconds.
+func (s *Server) Write(ctx context.Context, lines []byte) error {
+	endpoint, err := s.endpoint()
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(lines))
+	if err != nil {
+		return fmt.Errorf("influx write: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if s.Token != "" {
+		req.Header.Set("Authorization", "Token "+s.Token)
+	}
+	c := s.HTTP
+	if c == nil {
+		c = http.DefaultClient
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return fmt.Errorf("influx write: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("influx write: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// endpoint returns the write URL for the server's version.
+func (s *Server) endpoint() (string, error) {
+	u, err := url.Parse(strings.TrimRight(s.URL, "/"))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("invalid InfluxDB URL %q: want e.g. http://localhost:8086", s.URL)
+	}
+	q := url.Values{"precision": {"s"}}
+	switch {
+	case s.DB != "":
+		u.Path += "/write"
+		q.Set("db", s.DB)
+	case s.Bucket != "":
+		u.Path += "/api/v2/write"
+		q.Set("bucket", s.Bucket)
+		q.Set("org", s.Org)
+	default:
+		return "", fmt.Errorf("InfluxDB needs a bucket (InfluxDB 2) or a database (InfluxDB 1)")
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
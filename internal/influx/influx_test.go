@@ -0,0 +1,107 @@
+package influx_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/analysis"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/influx"
+)
+
+func TestPointAppend_Escapes(t *testing.T) {
+	p := influx.Point{
+		Measurement: "strava activity",
+		Tags:        map[string]string{"sport_type": "Run", "gear_id": "", "a b": "x=y,z"},
+		Fields:      []influx.Field{{"name", `Say "hi" \ bye`}, {"distance", 5000.5}, {"moving_time", 1500}, {"commute", true}},
+		Time:        time.Unix(1704092400, 0),
+	}
+	b, err := p.Append(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(b)
+	want := `strava\ activity,a\ b=x\=y\,z,sport_type=Run name="Say \"hi\" \\ bye",distance=5000.5,moving_time=1500i,commute=true 1704092400` + "\n"
+	if got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+	if b, err := (influx.Point{Measurement: "m"}).Append(nil); err != nil || len(b) != 0 {
+		t.Errorf("point without fields wrote %q, %v", b, err)
+	}
+}
+
+func TestPointAppend_UnsupportedField(t *testing.T) {
+	p := influx.Point{Measurement: "m", Fields: []influx.Field{{"ok", 1.5}, {"bad", float32(2)}}}
+	b, err := p.Append([]byte("before\n"))
+	if err == nil || !strings.Contains(err.Error(), "bad") {
+		t.Errorf("Append = %v, want an error naming the field", err)
+	}
+	if string(b) != "before\n" {
+		t.Errorf("Append left %q, want the buffer as it was", b)
+	}
+}
+
+func TestActivityAndStreamPoints(t *testing.T) {
+	start := time.Date(2024, 1, 1, 7, 0, 0, 0, time.UTC)
+	a := analysis.Activity{ID: 7, Name: "Run", SportType: "Run", StartDate: start, Distance: 1000, AverageHeartrate: 150}
+	b, err := influx.ActivityPoint(a).Append(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := string(b)
+	if !strings.Contains(line, "average_heartrate=150") || strings.Contains(line, "average_watts") {
+		t.Errorf("activity line %q", line)
+	}
+	s := &analysis.Streams{Time: []int{0, 10}, Heartrate: []int{120, 130}, Watts: []int{200}}
+	points := influx.StreamPoints(a, s)
+	if len(points) != 2 {
+		t.Fatalf("%d points, want 2", len(points))
+	}
+	want := "strava_stream,activity_id=7,sport_type=Run heartrate=130i " + "1704092410\n"
+	if b, err = points[1].Append(nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(b); got != want {
+		t.Errorf("got  %q\nwant %q (watts has the wrong length)", got, want)
+	}
+}
+
+func TestServerWrite(t *testing.T) {
+	var gotPath, gotQuery, gotAuth, gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotQuery, gotAuth = r.URL.Path, r.URL.RawQuery, r.Header.Get("Authorization")
+		b, _ := io.ReadAll(r.Body)
+		gotBody = string(b)
+		if r.URL.Query().Get("bucket") == "full" {
+			http.Error(w, `{"message":"bucket is full"}`, http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	s := &influx.Server{URL: srv.URL + "/", Org: "home", Bucket: "strava", Token: "tok"}
+	if err := s.Write(context.Background(), []byte("m f=1 1\n")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if gotPath != "/api/v2/write" || gotQuery != "bucket=strava&org=home&precision=s" || gotAuth != "Token tok" || gotBody != "m f=1 1\n" {
+		t.Errorf("request = %s?%s auth %q body %q", gotPath, gotQuery, gotAuth, gotBody)
+	}
+
+	v1 := &influx.Server{URL: srv.URL, DB: "strava"}
+	if err := v1.Write(context.Background(), []byte("m f=1 1\n")); err != nil || gotPath != "/write" || gotQuery != "db=strava&precision=s" {
+		t.Errorf("v1 write: %v, request %s?%s", err, gotPath, gotQuery)
+	}
+
+	full := &influx.Server{URL: srv.URL, Org: "home", Bucket: "full"}
+	if err := full.Write(context.Background(), nil); err == nil || !strings.Contains(err.Error(), "HTTP 403") {
+		t.Errorf("error = %v, want HTTP 403", err)
+	}
+	if err := (&influx.Server{URL: "localhost:8086", DB: "x"}).Write(context.Background(), nil); err == nil {
+		t.Error("expected an error for a URL without a scheme")
+	}
+}
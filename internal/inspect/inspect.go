@@ -0,0 +1,323 @@
+// Package inspect reads activity files (FIT, GPX and TCX, optionally
+// gzipped) locally and summarises what an upload would give Strava: how long
+// and far the recording is, which sensors it has, where it has gaps, and
+// the defects that make Strava reject or mangle a file.
+package inspect
+
+import (
+	"compress/gzip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/fit"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/geo"
+)
+
+// File is an activity file read into FIT records, whatever its format.
+// Records without a time have a zero Time.
+type File struct {
+	Format  string // fit, gpx or tcx
+	Sport   string
+	Device  string // the recording device or app, with its firmware if known
+	Records []fit.Record
+}
+
+// Read reads an activity file, choosing the format by name's extension
+// (.fit, .gpx, .tcx, each optionally followed by .gz).
+func Read(name string, r io.Reader) (*File, error) {
+	ext := strings.ToLower(filepath.Ext(name))
+	if ext == ".gz" {
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		r = zr
+		ext = strings.ToLower(filepath.Ext(strings.TrimSuffix(name, filepath.Ext(name))))
+	}
+	switch ext {
+	case ".fit":
+		a, err := fit.Decode(r)
+		if err != nil {
+			return nil, err
+		}
+		f := &File{Format: "fit", Sport: a.Sport.String(), Records: a.Records}
+		if a.Device != (fit.Device{}) {
+			f.Device = a.Device.String()
+		}
+		return f, nil
+	case ".gpx":
+		return readGPX(r)
+	case ".tcx":
+		return readTCX(r)
+	}
+	return nil, fmt.Errorf("unrecognised file type %q: want .fit, .gpx or .tcx (optionally .gz)", ext)
+}
+
+// Gap is a stretch with no records.
+type Gap struct {
+	Start  time.Time `json:"start"`
+	Length int       `json:"length"` // seconds
+}
+
+// Summary describes a file.
+type Summary struct {
+	Format         string    `json:"format"`
+	Sport          string    `json:"sport,omitempty"`
+	Device         string    `json:"device,omitempty"`
+	Start          time.Time `json:"start,omitzero"`
+	ElapsedTime    int       `json:"elapsed_time"` // seconds, first to last timed record
+	Distance       float64   `json:"distance"`     // meters
+	DistanceSource string    `json:"distance_source,omitempty"`
+	Records        int       `json:"records"`
+	Sensors        []string  `json:"sensors"`
+	Gaps           []Gap     `json:"gaps"`
+	Problems       []string  `json:"problems"`
+}
+
+// MaxSpeed is the speed, in m/s, above which a move between two records is
+// counted as a GPS jump rather than travel.
+const MaxSpeed = 100
+
+// Summarize describes f, listing gaps between records of at least minGap.
+func Summarize(f *File, minGap time.Duration) Summary {
+	s := Summary{Format: f.Format, Sport: f.Sport, Device: f.Device, Records: len(f.Records),
+		Sensors: []string{}, Gaps: []Gap{}, Problems: []string{}}
+	if len(f.Records) == 0 {
+		s.Problems = append(s.Problems, "no track points: Strava rejects a file without any")
+		return s
+	}
+
+	var (
+		has                         [6]bool // gps, heart rate, cadence, power, distance, altitude
+		untimed, backwards, jumps   int
+		nullIsland                  int
+		firstBackwards              time.Time
+		prevTime                    time.Time
+		prevPos                     *geo.Point
+		prevPosTime                 time.Time
+		gpsDistance, deviceDistance = 0.0, -1.0
+	)
+	for _, r := range f.Records {
+		if r.Position != nil {
+			if r.Position.Lat == 0 && r.Position.Lng == 0 {
+				nullIsland++
+			} else {
+				has[0] = true
+				if prevPos != nil {
+					d := geo.Distance(*prevPos, *r.Position)
+					dt := r.Time.Sub(prevPosTime).Seconds()
+					if !r.Time.IsZero() && !prevPosTime.IsZero() && dt > 0 && d/dt > MaxSpeed {
+						jumps++
+					} else {
+						gpsDistance += d
+					}
+				}
+				prevPos, prevPosTime = r.Position, r.Time
+			}
+		}
+		has[1] = has[1] || r.HeartRate > 0
+		has[2] = has[2] || r.Cadence >= 0
+		has[3] = has[3] || r.Power >= 0
+		if r.Distance >= 0 {
+			has[4] = true
+			deviceDistance = r.Distance
+		}
+		has[5] = has[5] || r.Altitude != nil
+
+		if r.Time.IsZero() {
+			untimed++
+			continue
+		}
+		if s.Start.IsZero() {
+			s.Start = r.Time
+		}
+		if !prevTime.IsZero() {
+			switch dt := r.Time.Sub(prevTime); {
+			case dt < 0:
+				if backwards == 0 {
+					firstBackwards = r.Time
+				}
+				backwards++
+				continue // measure from the latest time
+			case dt >= minGap:
+				s.Gaps = append(s.Gaps, Gap{Start: prevTime, Length: int(dt.Seconds())})
+			}
+		}
+		prevTime = r.Time
+	}
+	if !prevTime.IsZero() {
+		s.ElapsedTime = int(prevTime.Sub(s.Start).Seconds())
+	}
+	for i, name := range []string{"gps", "heart rate", "cadence", "power", "distance", "altitude"} {
+		if has[i] {
+			s.Sensors = append(s.Sensors, name)
+		}
+	}
+	switch {
+	case deviceDistance >= 0:
+		s.Distance, s.DistanceSource = deviceDistance, "device"
+	case has[0]:
+		s.Distance, s.DistanceSource = gpsDistance, "gps"
+	}
+
+	if untimed == len(f.Records) {
+		s.Problems = append(s.Problems, "no timestamps: Strava needs a time on each point and rejects the file (is it a route rather than a recording?)")
+	} else if untimed > 0 {
+		s.Problems = append(s.Problems, fmt.Sprintf("%d of %d points have no timestamp; Strava drops them", untimed, len(f.Records)))
+	}
+	if backwards > 0 {
+		s.Problems = append(s.Problems, fmt.Sprintf("timestamps go backwards %d times (first at %s); Strava sorts or drops those points, which can scramble the track",
+			backwards, firstBackwards.Format(time.RFC3339)))
+	}
+	if jumps > 0 {
+		s.Problems = append(s.Problems, fmt.Sprintf("%d GPS jumps faster than %d m/s; Strava may add them to the distance or crop the track", jumps, MaxSpeed))
+	}
+	if nullIsland > 0 {
+		s.Problems = append(s.Problems, fmt.Sprintf("%d points at 0°, 0° (a device writing \"no fix\" as a position); they show as a line to the Gulf of Guinea", nullIsland))
+	}
+	if !s.Start.IsZero() && s.Start.Before(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		s.Problems = append(s.Problems, fmt.Sprintf("start time %s is before 2000: the device clock was not set", s.Start.Format("2006-01-02")))
+	}
+	return s
+}
+
+// ── GPX ───────────────────────────────────────────────────────────────────────
+
+type gpxPoint struct {
+	Lat   float64  `xml:"lat,attr"`
+	Lon   float64  `xml:"lon,attr"`
+	Ele   *float64 `xml:"ele"`
+	Time  string   `xml:"time"`
+	HR    *int     `xml:"extensions>TrackPointExtension>hr"`
+	Cad   *int     `xml:"extensions>TrackPointExtension>cad"`
+	Power *int     `xml:"extensions>power"`
+}
+
+func readGPX(r io.Reader) (*File, error) {
+	var doc struct {
+		Creator string `xml:"creator,attr"`
+		Tracks  []struct {
+			Type     string `xml:"type"`
+			Segments []struct {
+				Points []gpxPoint `xml:"trkpt"`
+			} `xml:"trkseg"`
+		} `xml:"trk"`
+		Routes []struct {
+			Points []gpxPoint `xml:"rtept"`
+		} `xml:"rte"`
+	}
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("parse GPX: %w", err)
+	}
+	f := &File{Format: "gpx", Device: doc.Creator}
+	add := func(p gpxPoint) {
+		f.Records = append(f.Records, fit.Record{
+			Time: parseTime(p.Time), Position: &geo.Point{Lat: p.Lat, Lng: p.Lon},
+			HeartRate: deref(p.HR), Cadence: deref(p.Cad), Power: deref(p.Power), Distance: -1, Altitude: p.Ele,
+		})
+	}
+	for _, t := range doc.Tracks {
+		if f.Sport == "" {
+			f.Sport = t.Type
+		}
+		for _, s := range t.Segments {
+			for _, p := range s.Points {
+				add(p)
+			}
+		}
+	}
+	for _, rt := range doc.Routes {
+		for _, p := range rt.Points {
+			add(p)
+		}
+	}
+	return f, nil
+}
+
+// ── TCX ───────────────────────────────────────────────────────────────────────
+
+type tcxPoint struct {
+	Time     string   `xml:"Time"`
+	Lat      *float64 `xml:"Position>LatitudeDegrees"`
+	Lng      *float64 `xml:"Position>LongitudeDegrees"`
+	Distance *float64 `xml:"DistanceMeters"`
+	Altitude *float64 `xml:"AltitudeMeters"`
+	HR       *int     `xml:"HeartRateBpm>Value"`
+	Cadence  *int     `xml:"Cadence"`
+	Run      *int     `xml:"Extensions>TPX>RunCadence"`
+	Watts    *int     `xml:"Extensions>TPX>Watts"`
+}
+
+func readTCX(r io.Reader) (*File, error) {
+	var doc struct {
+		Activities []struct {
+			Sport string `xml:"Sport,attr"`
+			Laps  []struct {
+				Points []tcxPoint `xml:"Track>Trackpoint"`
+			} `xml:"Lap"`
+			Creator struct {
+				Name    string `xml:"Name"`
+				Version struct {
+					Major *int `xml:"VersionMajor"`
+					Minor *int `xml:"VersionMinor"`
+				} `xml:"Version"`
+			} `xml:"Creator"`
+		} `xml:"Activities>Activity"`
+	}
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("parse TCX: %w", err)
+	}
+	f := &File{Format: "tcx"}
+	for _, a := range doc.Activities {
+		if f.Sport == "" {
+			f.Sport = a.Sport
+		}
+		if f.Device == "" && a.Creator.Name != "" {
+			f.Device = a.Creator.Name
+			if v := a.Creator.Version; v.Major != nil {
+				f.Device += fmt.Sprintf(", firmware %d.%d", *v.Major, max(0, deref(v.Minor)))
+			}
+		}
+		for _, l := range a.Laps {
+			for _, p := range l.Points {
+				rec := fit.Record{
+					Time: parseTime(p.Time), HeartRate: deref(p.HR), Cadence: deref(p.Cadence),
+					Power: deref(p.Watts), Distance: -1, Altitude: p.Altitude,
+				}
+				if rec.Cadence < 0 {
+					rec.Cadence = deref(p.Run)
+				}
+				if p.Distance != nil {
+					rec.Distance = *p.Distance
+				}
+				if p.Lat != nil && p.Lng != nil {
+					rec.Position = &geo.Point{Lat: *p.Lat, Lng: *p.Lng}
+				}
+				f.Records = append(f.Records, rec)
+			}
+		}
+	}
+	return f, nil
+}
+
+// parseTime parses an XML timestamp, or returns the zero time.
+func parseTime(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, strings.TrimSpace(s))
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// deref returns *p, or -1 (not recorded) for nil.
+func deref(p *int) int {
+	if p == nil {
+		return -1
+	}
+	return *p
+}
@@ -0,0 +1,122 @@
+package inspect_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/fit"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/geo"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/inspect"
+)
+
+const gpx = `<?xml version="1.0"?>
+<gpx creator="Wahoo ELEMNT" xmlns="http://www.topografix.com/GPX/1/1"
+     xmlns:gpxtpx="http://www.garmin.com/xmlschemas/TrackPointExtension/v1">
+  <trk><type>cycling</type><trkseg>
+    <trkpt lat="51.5000" lon="-0.1000"><time>2025-03-01T08:00:00Z</time>
+      <extensions><gpxtpx:TrackPointExtension><gpxtpx:hr>120</gpxtpx:hr></gpxtpx:TrackPointExtension></extensions></trkpt>
+    <trkpt lat="51.5001" lon="-0.1000"><time>2025-03-01T08:00:01Z</time></trkpt>
+    <trkpt lat="0" lon="0"><time>2025-03-01T08:00:02Z</time></trkpt>
+    <trkpt lat="51.5002" lon="-0.1000"><time>2025-03-01T08:02:02Z</time></trkpt>
+    <trkpt lat="52.5002" lon="-0.1000"><time>2025-03-01T08:02:03Z</time></trkpt>
+    <trkpt lat="52.5002" lon="-0.1000"><time>2025-03-01T08:02:01Z</time></trkpt>
+  </trkseg></trk>
+</gpx>`
+
+func TestSummarize_GPXProblems(t *testing.T) {
+	f, err := inspect.Read("ride.gpx", strings.NewReader(gpx))
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	s := inspect.Summarize(f, 30*time.Second)
+	if s.Device != "Wahoo ELEMNT" || s.Sport != "cycling" || s.Records != 6 || s.ElapsedTime != 123 {
+		t.Errorf("summary = %+v", s)
+	}
+	if !slices.Equal(s.Sensors, []string{"gps", "heart rate"}) {
+		t.Errorf("sensors = %v", s.Sensors)
+	}
+	if len(s.Gaps) != 1 || s.Gaps[0].Length != 120 {
+		t.Errorf("gaps = %+v, want one of 120 s", s.Gaps)
+	}
+	if s.DistanceSource != "gps" || s.Distance < 20 || s.Distance > 25 {
+		t.Errorf("distance = %.1f m from %q, want ~22 m from gps without the jump", s.Distance, s.DistanceSource)
+	}
+	want := []string{"backwards 1 times", "1 GPS jumps", "1 points at 0°, 0°"}
+	if len(s.Problems) != len(want) {
+		t.Fatalf("problems = %q", s.Problems)
+	}
+	for i, w := range want {
+		if !strings.Contains(s.Problems[i], w) {
+			t.Errorf("problem %d = %q, want it to mention %q", i, s.Problems[i], w)
+		}
+	}
+}
+
+func TestSummarize_RouteHasNoTimestamps(t *testing.T) {
+	f, err := inspect.Read("route.gpx", strings.NewReader(`<gpx><rte><rtept lat="1" lon="1"/><rtept lat="1.001" lon="1"/></rte></gpx>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := inspect.Summarize(f, time.Minute)
+	if len(s.Problems) != 1 || !strings.HasPrefix(s.Problems[0], "no timestamps") {
+		t.Errorf("problems = %q", s.Problems)
+	}
+}
+
+func TestRead_TCX(t *testing.T) {
+	const tcx = `<TrainingCenterDatabase xmlns="http://www.garmin.com/xmlschemas/TrainingCenterDatabase/v2">
+<Activities><Activity Sport="Running"><Lap><Track>
+  <Trackpoint><Time>2025-03-01T08:00:00Z</Time><DistanceMeters>0</DistanceMeters>
+    <Extensions><ns3:TPX xmlns:ns3="http://www.garmin.com/xmlschemas/ActivityExtension/v2"><ns3:RunCadence>88</ns3:RunCadence></ns3:TPX></Extensions></Trackpoint>
+  <Trackpoint><Time>2025-03-01T08:10:00Z</Time><DistanceMeters>2000</DistanceMeters></Trackpoint>
+</Track></Lap>
+<Creator><Name>Forerunner 265</Name><Version><VersionMajor>19</VersionMajor><VersionMinor>22</VersionMinor></Version></Creator>
+</Activity></Activities></TrainingCenterDatabase>`
+	f, err := inspect.Read("run.tcx", strings.NewReader(tcx))
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	s := inspect.Summarize(f, time.Minute)
+	if s.Sport != "Running" || s.Device != "Forerunner 265, firmware 19.22" || s.Distance != 2000 || s.DistanceSource != "device" {
+		t.Errorf("summary = %+v", s)
+	}
+	if !slices.Equal(s.Sensors, []string{"cadence", "distance"}) || len(s.Gaps) != 1 || len(s.Problems) != 0 {
+		t.Errorf("sensors %v, gaps %v, problems %q", s.Sensors, s.Gaps, s.Problems)
+	}
+}
+
+func TestRead_GzippedFIT(t *testing.T) {
+	start := time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)
+	var recs []fit.Record
+	for i := range 3 {
+		recs = append(recs, fit.Record{Time: start.Add(time.Duration(i) * time.Second), HeartRate: 130, Cadence: -1, Power: 210,
+			Distance: float64(i) * 9, Position: &geo.Point{Lat: 51.5 + float64(i)*1e-4, Lng: -0.1}})
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := fit.Encode(zw, fit.Activity{Sport: fit.SportCycling, Records: recs}); err != nil {
+		t.Fatal(err)
+	}
+	zw.Close()
+	f, err := inspect.Read("ride.fit.gz", &buf)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	s := inspect.Summarize(f, time.Minute)
+	if s.Format != "fit" || s.Sport != "cycling" || s.Device != "development" || s.Distance != 18 || s.ElapsedTime != 2 {
+		t.Errorf("summary = %+v", s)
+	}
+	if !slices.Equal(s.Sensors, []string{"gps", "heart rate", "power", "distance"}) {
+		t.Errorf("sensors = %v", s.Sensors)
+	}
+}
+
+func TestRead_UnknownExtension(t *testing.T) {
+	if _, err := inspect.Read("notes.txt", strings.NewReader("")); err == nil {
+		t.Error("Read accepted a .txt file")
+	}
+}
@@ -0,0 +1,199 @@
+// Package intervals forwards activities to intervals.icu: it rebuilds a
+// recording from an activity's streams as a FIT file and uploads it with an
+// intervals.icu API key.
+package intervals
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/analysis"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/fit"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/geo"
+)
+
+// DefaultURL is the intervals.icu API.
+const DefaultURL = "https://intervals.icu"
+
+// StreamKeys are the streams Activity puts in a recording, with time.
+var StreamKeys = []string{"time", "distance", "latlng", "altitude", "heartrate", "cadence", "watts"}
+
+// Server is an intervals.icu account to upload to. Athlete is the athlete
+// ID, "0" (or empty) for the API key's own athlete.
+type Server struct {
+	URL     string
+	APIKey  string
+	Athlete string
+	HTTP    *http.Client
+}
+
+// Upload is an activity to upload: its FIT file, and the name, description
+// and external ID (the Strava activity ID) to give it.
+type Upload struct {
+	Name        string
+	Description string
+	ExternalID  string
+	File        []byte
+}
+
+// Upload sends u as a new activity and returns the intervals.icu ID of the
+// activity it became.
+func (s *Server) Upload(ctx context.Context, u Upload) (string, error) {
+	endpoint, err := s.endpoint(u)
+	if err != nil {
+		return "", err
+	}
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("file", "activity.fit")
+	if err == nil {
+		_, err = fw.Write(u.File)
+	}
+	if err == nil {
+		err = mw.Close()
+	}
+	if err != nil {
+		return "", fmt.Errorf("intervals.icu upload: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, &body)
+	if err != nil {
+		return "", fmt.Errorf("intervals.icu upload: %w", err)
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Accept", "application/json")
+	// intervals.icu takes the API key as the password of the user "API_KEY".
+	req.SetBasicAuth("API_KEY", s.APIKey)
+	c := s.HTTP
+	if c == nil {
+		c = http.DefaultClient
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("intervals.icu upload: %w", err)
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return "", fmt.Errorf("intervals.icu upload: HTTP %d: check the API key (intervals.icu settings, Developer Settings)", resp.StatusCode)
+	case resp.StatusCode/100 != 2:
+		return "", fmt.Errorf("intervals.icu upload: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(b[:min(len(b), 1024)])))
+	}
+	var out struct {
+		ID         string `json:"id"`
+		Activities []struct {
+			ID string `json:"id"`
+		} `json:"activities"`
+	}
+	if err := json.Unmarshal(b, &out); err != nil {
+		return "", fmt.Errorf("intervals.icu upload: decode response: %w", err)
+	}
+	if out.ID == "" && len(out.Activities) > 0 {
+		out.ID = out.Activities[0].ID
+	}
+	return out.ID, nil
+}
+
+// endpoint returns the upload URL for u.
+func (s *Server) endpoint(u Upload) (string, error) {
+	base := s.URL
+	if base == "" {
+		base = DefaultURL
+	}
+	p, err := url.Parse(strings.TrimRight(base, "/"))
+	if err != nil || p.Scheme == "" || p.Host == "" {
+		return "", fmt.Errorf("invalid intervals.icu URL %q: want e.g. %s", base, DefaultURL)
+	}
+	athlete := s.Athlete
+	if athlete == "" {
+		athlete = "0"
+	}
+	p.Path += "/api/v1/athlete/" + url.PathEscape(athlete) + "/activities"
+	q := url.Values{}
+	for k, v := range map[string]string{"name": u.Name, "description": u.Description, "external_id": u.ExternalID} {
+		if v != "" {
+			q.Set(k, v)
+		}
+	}
+	p.RawQuery = q.Encode()
+	return p.String(), nil
+}
+
+// Activity rebuilds a's recording from its streams: a record per sample,
+// with the position, altitude, distance, heart rate, cadence and power the
+// streams have. It is empty when s has no time stream.
+func Activity(a analysis.Activity, s *analysis.Streams) fit.Activity {
+	sport, sub := Sport(a)
+	out := fit.Activity{Sport: sport, SubSport: sub}
+	if s == nil {
+		return out
+	}
+	n := len(s.Time)
+	out.Records = make([]fit.Record, n)
+	for i, t := range s.Time {
+		r := fit.Record{
+			Time:      a.StartDate.Add(time.Duration(t) * time.Second),
+			HeartRate: sample(s.Heartrate, i, n),
+			Cadence:   sample(s.Cadence, i, n),
+			Power:     sample(s.Watts, i, n),
+			Distance:  -1,
+		}
+		if len(s.Distance) == n {
+			r.Distance = s.Distance[i]
+		}
+		if len(s.Latlng) == n {
+			r.Position = &geo.Point{Lat: s.Latlng[i][0], Lng: s.Latlng[i][1]}
+		}
+		if len(s.Altitude) == n {
+			r.Altitude = &s.Altitude[i]
+		}
+		out.Records[i] = r
+	}
+	return out
+}
+
+// sample returns stream v's i'th value, or -1 (not recorded) when v does
+// not have all n.
+func sample(v []int, i, n int) int {
+	if len(v) != n {
+		return -1
+	}
+	return v[i]
+}
+
+// Sport returns the FIT sport of a's sport type, with indoor runs and rides
+// (virtual, or on a trainer) as treadmill and indoor cycling.
+func Sport(a analysis.Activity) (fit.Sport, fit.SubSport) {
+	switch a.SportType {
+	case "Run", "TrailRun", "VirtualRun":
+		if a.Trainer || a.SportType == "VirtualRun" {
+			return fit.SportRunning, fit.SubSportTreadmill
+		}
+		return fit.SportRunning, fit.SubSportGeneric
+	case "Ride", "MountainBikeRide", "GravelRide", "VirtualRide":
+		if a.Trainer || a.SportType == "VirtualRide" {
+			return fit.SportCycling, fit.SubSportIndoorCycling
+		}
+		return fit.SportCycling, fit.SubSportGeneric
+	}
+	if s, ok := sports[a.SportType]; ok {
+		return s, fit.SubSportGeneric
+	}
+	return fit.SportGeneric, fit.SubSportGeneric
+}
+
+var sports = map[string]fit.Sport{
+	"EBikeRide": fit.SportEBiking, "EMountainBikeRide": fit.SportEBiking,
+	"Swim": fit.SportSwimming, "Walk": fit.SportWalking, "Hike": fit.SportHiking,
+	"NordicSki": fit.SportXCSkiing, "BackcountrySki": fit.SportXCSkiing,
+	"AlpineSki": fit.SportAlpineSkiing, "Rowing": fit.SportRowing, "VirtualRow": fit.SportRowing,
+	"Canoeing": fit.SportPaddling, "Kayaking": fit.SportPaddling, "StandUpPaddling": fit.SportPaddling,
+}
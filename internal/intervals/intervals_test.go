@@ -0,0 +1,90 @@
+package intervals_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/analysis"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/fit"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/intervals"
+)
+
+func TestActivity_FromStreams(t *testing.T) {
+	start := time.Date(2024, 6, 1, 7, 0, 0, 0, time.UTC)
+	a := analysis.Activity{ID: 7, SportType: "VirtualRide", StartDate: start}
+	s := &analysis.Streams{
+		Time:      []int{0, 5},
+		Heartrate: []int{120, 130},
+		Watts:     []int{200},
+		Latlng:    [][2]float64{{51.5, -0.1}, {51.6, -0.2}},
+		Altitude:  []float64{10, 12},
+	}
+	got := intervals.Activity(a, s)
+	if got.Sport != fit.SportCycling || got.SubSport != fit.SubSportIndoorCycling {
+		t.Errorf("sport = %v/%d, want cycling/indoor", got.Sport, got.SubSport)
+	}
+	if len(got.Records) != 2 {
+		t.Fatalf("%d records, want 2", len(got.Records))
+	}
+	r := got.Records[1]
+	if !r.Time.Equal(start.Add(5*time.Second)) || r.HeartRate != 130 || r.Power != -1 || r.Cadence != -1 || r.Distance != -1 {
+		t.Errorf("record = %+v (watts has the wrong length)", r)
+	}
+	if r.Position == nil || r.Position.Lat != 51.6 || r.Altitude == nil || *r.Altitude != 12 {
+		t.Errorf("record position %v, altitude %v", r.Position, r.Altitude)
+	}
+
+	if sport, sub := intervals.Sport(analysis.Activity{SportType: "Run", Trainer: true}); sport != fit.SportRunning || sub != fit.SubSportTreadmill {
+		t.Errorf("treadmill run = %v/%d", sport, sub)
+	}
+	if sport, _ := intervals.Sport(analysis.Activity{SportType: "Yoga"}); sport != fit.SportGeneric {
+		t.Errorf("yoga = %v", sport)
+	}
+}
+
+func TestServerUpload(t *testing.T) {
+	var gotPath, gotName, gotExternal, gotFile string
+	var gotUser, gotKey string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotName, gotExternal = r.URL.Query().Get("name"), r.URL.Query().Get("external_id")
+		gotUser, gotKey, _ = r.BasicAuth()
+		if gotKey != "key" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		f, _, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		b, _ := io.ReadAll(f)
+		gotFile = string(b)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"icu_athlete_id":"i1","id":"i99","activities":[{"id":"i99"}]}`)
+	}))
+	defer srv.Close()
+
+	s := &intervals.Server{URL: srv.URL + "/", APIKey: "key"}
+	u := intervals.Upload{Name: "Morning Ride", ExternalID: "7", File: []byte("FIT")}
+	id, err := s.Upload(context.Background(), u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "i99" {
+		t.Errorf("id = %q, want i99", id)
+	}
+	if gotPath != "/api/v1/athlete/0/activities" || gotName != "Morning Ride" || gotExternal != "7" || gotFile != "FIT" || gotUser != "API_KEY" {
+		t.Errorf("request: path %s, name %q, external_id %q, file %q, user %q", gotPath, gotName, gotExternal, gotFile, gotUser)
+	}
+
+	s.APIKey = "wrong"
+	if _, err := s.Upload(context.Background(), u); err == nil || !strings.Contains(err.Error(), "API key") {
+		t.Errorf("upload with a bad key: %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+// Package log records what the CLI does for later audit: token refreshes,
+// API requests and retries, syncs and uploads. It is built on log/slog. By
+// default nothing is recorded; Open starts writing records at a level to
+// stderr, as text, or to a file, as JSON lines. Every record is redacted
+// (see package redact) before it is written.
+package log
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/redact"
+)
+
+// Levels are the names ParseLevel accepts, from the most verbose.
+var Levels = []string{"debug", "info", "warn", "error"}
+
+// handler receives every Logger's records; a discarding one until Open.
+var handler atomic.Pointer[slog.Handler] //nolint:gochecknoglobals
+
+func init() {
+	var h slog.Handler = slog.DiscardHandler
+	handler.Store(&h)
+}
+
+// ParseLevel parses a level name: debug, info, warn or error.
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("invalid log level %q: want %s", s, strings.Join(Levels, ", "))
+}
+
+// Open starts recording at level and above: to the file at path, appending
+// JSON lines, or to stderr as text when path is empty. The returned func
+// closes the file.
+func Open(level slog.Level, path string) (func() error, error) {
+	opts := &slog.HandlerOptions{Level: level, ReplaceAttr: redactAttr}
+	var h slog.Handler
+	closer := func() error { return nil }
+	if path == "" {
+		h = slog.NewTextHandler(os.Stderr, opts)
+	} else {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("open log file: %w", err)
+		}
+		h = slog.NewJSONHandler(f, opts)
+		closer = f.Close
+	}
+	handler.Store(&h)
+	return closer, nil
+}
+
+// SetOutput records at level to w as JSON lines, or stops recording when w
+// is nil. It is meant for tests.
+func SetOutput(w io.Writer, level slog.Level) {
+	var h slog.Handler = slog.DiscardHandler
+	if w != nil {
+		h = slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level, ReplaceAttr: redactAttr})
+	}
+	handler.Store(&h)
+}
+
+// redactAttr scrubs secrets from the message and string and error values.
+func redactAttr(_ []string, a slog.Attr) slog.Attr {
+	switch a.Value.Kind() {
+	case slog.KindString:
+		a.Value = slog.StringValue(redact.String(a.Value.String()))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			a.Value = slog.StringValue(redact.String(err.Error()))
+		}
+	}
+	return a
+}
+
+// Logger writes records for one part of the CLI, tagged with its name as
+// "component". Loggers may be created before Open: they look up where to
+// write on each record.
+type Logger struct {
+	component string
+}
+
+// New returns the Logger of a component, such as "transport".
+func New(component string) *Logger {
+	return &Logger{component: component}
+}
+
+// Enabled reports whether records at level are written, for callers that
+// would otherwise do work to build them.
+func (l *Logger) Enabled(level slog.Level) bool {
+	return (*handler.Load()).Enabled(context.Background(), level)
+}
+
+// Debug records detail useful when diagnosing a problem, such as each API
+// request. args are key-value pairs, as for slog.
+func (l *Logger) Debug(msg string, args ...any) { l.log(slog.LevelDebug, msg, args) }
+
+// Info records something the CLI did, such as a sync or an upload.
+func (l *Logger) Info(msg string, args ...any) { l.log(slog.LevelInfo, msg, args) }
+
+// Warn records something that went wrong but was recovered from, such as
+// a retried request.
+func (l *Logger) Warn(msg string, args ...any) { l.log(slog.LevelWarn, msg, args) }
+
+// Error records a failure.
+func (l *Logger) Error(msg string, args ...any) { l.log(slog.LevelError, msg, args) }
+
+func (l *Logger) log(level slog.Level, msg string, args []any) {
+	h := *handler.Load()
+	if !h.Enabled(context.Background(), level) {
+		return
+	}
+	slog.New(h).With("component", l.component).Log(context.Background(), level, msg, args...)
+}
@@ -0,0 +1,84 @@
+package log_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/log"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/redact"
+)
+
+func TestParseLevel(t *testing.T) {
+	for in, want := range map[string]slog.Level{
+		"debug": slog.LevelDebug, "INFO": slog.LevelInfo, "warn": slog.LevelWarn,
+		"warning": slog.LevelWarn, " error ": slog.LevelError,
+	} {
+		if got, err := log.ParseLevel(in); err != nil || got != want {
+			t.Errorf("ParseLevel(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if _, err := log.ParseLevel("verbose"); err == nil {
+		t.Error("ParseLevel(verbose): expected an error")
+	}
+}
+
+func TestLogger_LevelComponentAndRedaction(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf, slog.LevelInfo)
+	defer log.SetOutput(nil, 0)
+	redact.Add("log-test-secret-value")
+
+	l := log.New("sync")
+	l.Debug("hidden")
+	l.Info("token granted", "url", "https://example.com/?access_token=abcdefgh")
+	l.Error("failed", "error", errors.New("bad secret log-test-secret-value"))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d records, want 2 (debug filtered):\n%s", len(lines), buf.String())
+	}
+	var rec map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec["component"] != "sync" || rec["msg"] != "token granted" || rec["level"] != "INFO" {
+		t.Errorf("record = %v", rec)
+	}
+	if strings.Contains(buf.String(), "abcdefgh") || strings.Contains(buf.String(), "log-test-secret-value") {
+		t.Errorf("log shows a secret:\n%s", buf.String())
+	}
+	if !l.Enabled(slog.LevelWarn) || l.Enabled(slog.LevelDebug) {
+		t.Error("Enabled does not follow the level")
+	}
+}
+
+func TestOpen_AppendsToFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "strava.log")
+	l := log.New("cli")
+	for _, msg := range []string{"first", "second"} {
+		closer, err := log.Open(slog.LevelInfo, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		l.Info(msg)
+		closer()
+	}
+	defer log.SetOutput(nil, 0)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "\n"); n != 2 {
+		t.Errorf("log has %d lines, want 2:\n%s", n, data)
+	}
+	if info, err := os.Stat(path); err == nil && info.Mode().Perm()&0o077 != 0 {
+		t.Errorf("log file mode %v is readable by others", info.Mode().Perm())
+	}
+}
@@ -0,0 +1,237 @@
+// Package maps renders GPS tracks onto slippy-map tiles (OpenStreetMap by
+// default) as a static image for sharing. Tiles are fetched over HTTP and
+// kept in a disk cache, as the tile servers' usage policies ask.
+package maps
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg" // tile servers send PNG or JPEG
+	_ "image/png"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/fsutil"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/geo"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/plot"
+)
+
+// DefaultTileURL is the OpenStreetMap standard tile layer. Its usage policy
+// (https://operations.osmfoundation.org/policies/tiles/) allows light use
+// with an identifying User-Agent, caching, and the attribution below.
+const DefaultTileURL = "https://tile.openstreetmap.org/{z}/{x}/{y}.png"
+
+// DefaultAttribution is the credit drawn on maps made from DefaultTileURL.
+const DefaultAttribution = "© OpenStreetMap contributors"
+
+// TileSize is the side, in pixels, of a map tile.
+const TileSize = 256
+
+// MaxZoom is the deepest zoom level used, however short the track.
+const MaxZoom = 17
+
+// maxLat is the latitude where the Web Mercator projection is cut off.
+const maxLat = 85.05112878
+
+// margin is the border, in pixels, kept clear between the track and the
+// image edge.
+const margin = 32
+
+// CacheMaxAge is how long a cached tile is used before it is fetched again.
+const CacheMaxAge = 30 * 24 * time.Hour
+
+var missingTile = color.RGBA{0xe0, 0xe0, 0xe0, 0xff}
+
+// Client fetches tiles from one tile server.
+type Client struct {
+	HTTP        *http.Client
+	TileURL     string // template with {z}, {x} and {y}
+	UserAgent   string
+	Attribution string
+	CacheDir    string // tiles are not cached if empty
+}
+
+// New returns a Client for the OpenStreetMap tiles using hc
+// (http.DefaultClient if nil).
+func New(hc *http.Client, userAgent string) *Client {
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	return &Client{HTTP: hc, TileURL: DefaultTileURL, UserAgent: userAgent, Attribution: DefaultAttribution}
+}
+
+// Render draws pts on a width×height map, north up, at the closest zoom
+// level the whole track fits.
+func (c *Client) Render(ctx context.Context, pts []geo.Point, width, height int) (*image.RGBA, error) {
+	if len(pts) < 2 {
+		return nil, errors.New("no track to draw")
+	}
+	z := FitZoom(pts, width-2*margin, height-2*margin)
+	minX, minY, maxX, maxY := pixelBounds(pts, z)
+	// World pixel coordinates of the image's top left corner.
+	ox := int(math.Round((minX+maxX)/2)) - width/2
+	oy := int(math.Round((minY+maxY)/2)) - height/2
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(missingTile), image.Point{}, draw.Src)
+	n := 1 << z
+	for ty := floorDiv(oy, TileSize); ty*TileSize < oy+height; ty++ {
+		if ty < 0 || ty >= n {
+			continue
+		}
+		for tx := floorDiv(ox, TileSize); tx*TileSize < ox+width; tx++ {
+			tile, err := c.tile(ctx, z, ((tx%n)+n)%n, ty)
+			if err != nil {
+				return nil, err
+			}
+			x, y := tx*TileSize-ox, ty*TileSize-oy
+			draw.Draw(img, image.Rect(x, y, x+TileSize, y+TileSize), tile, tile.Bounds().Min, draw.Src)
+		}
+	}
+
+	path := make([]image.Point, len(pts))
+	for i, p := range pts {
+		x, y := project(p, z)
+		path[i] = image.Pt(int(math.Round(x))-ox, int(math.Round(y))-oy)
+	}
+	plot.Track(img, path)
+	if c.Attribution != "" {
+		attribution(img, c.Attribution)
+	}
+	return img, nil
+}
+
+// FitZoom returns the deepest zoom level, up to MaxZoom, at which pts fit in
+// a width×height box.
+func FitZoom(pts []geo.Point, width, height int) int {
+	for z := MaxZoom; z > 0; z-- {
+		minX, minY, maxX, maxY := pixelBounds(pts, z)
+		if maxX-minX <= float64(width) && maxY-minY <= float64(height) {
+			return z
+		}
+	}
+	return 0
+}
+
+// project returns p's Web Mercator position in world pixels at zoom z.
+func project(p geo.Point, z int) (x, y float64) {
+	world := float64(TileSize) * math.Exp2(float64(z))
+	lat := math.Max(-maxLat, math.Min(maxLat, p.Lat)) * math.Pi / 180
+	x = (p.Lng + 180) / 360 * world
+	y = (1 - math.Log(math.Tan(lat)+1/math.Cos(lat))/math.Pi) / 2 * world
+	return x, y
+}
+
+func pixelBounds(pts []geo.Point, z int) (minX, minY, maxX, maxY float64) {
+	minX, minY = math.Inf(1), math.Inf(1)
+	maxX, maxY = math.Inf(-1), math.Inf(-1)
+	for _, p := range pts {
+		x, y := project(p, z)
+		minX, maxX = math.Min(minX, x), math.Max(maxX, x)
+		minY, maxY = math.Min(minY, y), math.Max(maxY, y)
+	}
+	return minX, minY, maxX, maxY
+}
+
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && a < 0 {
+		q--
+	}
+	return q
+}
+
+// tile returns one tile, from the cache when it is there and fresh.
+func (c *Client) tile(ctx context.Context, z, x, y int) (image.Image, error) {
+	path := c.cachePath(z, x, y)
+	if path != "" {
+		if fi, err := os.Stat(path); err == nil && time.Since(fi.ModTime()) < CacheMaxAge {
+			if img, err := decodeFile(path); err == nil {
+				return img, nil
+			}
+		}
+	}
+
+	u := strings.NewReplacer("{z}", strconv.Itoa(z), "{x}", strconv.Itoa(x), "{y}", strconv.Itoa(y)).Replace(c.TileURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch tile: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read tile: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("tile server error (HTTP %d) for %d/%d/%d", resp.StatusCode, z, x, y)
+	}
+	img, _, err := image.Decode(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("decode tile %d/%d/%d: %w", z, x, y, err)
+	}
+	if path != "" {
+		// A tile that cannot be cached is fetched again next time; the map
+		// is still drawn.
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err == nil {
+			_ = fsutil.WriteFile(path, body, 0o600)
+		}
+	}
+	return img, nil
+}
+
+// cachePath is where tile z/x/y is cached, under a directory per tile
+// server so that switching servers does not mix their tiles.
+func (c *Client) cachePath(z, x, y int) string {
+	if c.CacheDir == "" {
+		return ""
+	}
+	server := "tiles"
+	if u, err := url.Parse(c.TileURL); err == nil && u.Host != "" {
+		server = u.Host
+	}
+	return filepath.Join(c.CacheDir, server, strconv.Itoa(z), strconv.Itoa(x), strconv.Itoa(y))
+}
+
+func decodeFile(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	return img, err
+}
+
+// attribution writes s in the bottom right corner on a light box.
+func attribution(img *image.RGBA, s string) {
+	face := basicfont.Face7x13
+	d := &font.Drawer{Dst: img, Src: image.NewUniform(color.RGBA{0x33, 0x33, 0x33, 0xff}), Face: face}
+	w := d.MeasureString(s).Ceil()
+	b := img.Bounds()
+	box := image.Rect(b.Max.X-w-8, b.Max.Y-18, b.Max.X, b.Max.Y)
+	draw.Draw(img, box, image.NewUniform(color.RGBA{0xff, 0xff, 0xff, 0xc0}), image.Point{}, draw.Over)
+	d.Dot = fixed.P(box.Min.X+4, b.Max.Y-5)
+	d.DrawString(s)
+}
@@ -0,0 +1,69 @@
+package maps_test
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/geo"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/maps"
+)
+
+var tileBlue = color.RGBA{0x40, 0x60, 0xc0, 0xff}
+
+func TestFitZoom(t *testing.T) {
+	// 360/4096 degrees of longitude is one tile, 256 px, at zoom 12.
+	pts := []geo.Point{{Lat: 0, Lng: 0}, {Lat: 0, Lng: 360.0 / 4096}}
+	if z := maps.FitZoom(pts, 256, 256); z != 12 {
+		t.Errorf("FitZoom = %d, want 12", z)
+	}
+	if z := maps.FitZoom(pts, 255, 256); z != 11 {
+		t.Errorf("FitZoom one pixel narrower = %d, want 11", z)
+	}
+}
+
+func TestRender_DrawsTilesAndCachesThem(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if ua := r.Header.Get("User-Agent"); ua != "strava-cli/test" {
+			t.Errorf("User-Agent = %q", ua)
+		}
+		tile := image.NewRGBA(image.Rect(0, 0, maps.TileSize, maps.TileSize))
+		draw.Draw(tile, tile.Bounds(), image.NewUniform(tileBlue), image.Point{}, draw.Src)
+		png.Encode(w, tile)
+	}))
+	defer srv.Close()
+
+	c := maps.New(srv.Client(), "strava-cli/test")
+	c.TileURL = srv.URL + "/{z}/{x}/{y}.png"
+	c.CacheDir = t.TempDir()
+	pts := []geo.Point{{Lat: 51.5, Lng: -0.2}, {Lat: 51.5, Lng: -0.1}}
+
+	img, err := c.Render(context.Background(), pts, 400, 300)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if got := img.RGBAAt(5, 5); got != tileBlue {
+		t.Errorf("corner pixel = %v, want the tile's %v", got, tileBlue)
+	}
+	if got := img.RGBAAt(200, 150); got == tileBlue {
+		t.Error("no track drawn through the middle")
+	}
+	if requests == 0 {
+		t.Fatal("no tiles fetched")
+	}
+
+	fetched := requests
+	if _, err := c.Render(context.Background(), pts, 400, 300); err != nil {
+		t.Fatalf("second Render: %v", err)
+	}
+	if requests != fetched {
+		t.Errorf("second Render fetched %d tiles, want 0 (cached)", requests-fetched)
+	}
+}
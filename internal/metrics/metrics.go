@@ -0,0 +1,203 @@
+// Package metrics serves Strava data as Prometheus metrics: a Metric type
+// written in the Prometheus text exposition format, the mapping of an
+// athlete's stats onto metrics, and an Exporter that refreshes its metrics
+// on an interval and serves the latest over HTTP.
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metric is one metric family: a name with help text, a type (gauge or
+// counter) and its samples.
+type Metric struct {
+	Name    string
+	Help    string
+	Type    string
+	Samples []Sample
+}
+
+// Sample is one value of a metric, told apart from its others by labels.
+type Sample struct {
+	Labels map[string]string
+	Value  float64
+}
+
+// Gauge returns a gauge metric with a single unlabeled sample.
+func Gauge(name, help string, v float64) Metric {
+	return Metric{Name: name, Help: help, Type: "gauge", Samples: []Sample{{Value: v}}}
+}
+
+// Write writes ms in the Prometheus text exposition format.
+func Write(w io.Writer, ms []Metric) error {
+	var b strings.Builder
+	for _, m := range ms {
+		fmt.Fprintf(&b, "# HELP %s %s\n", m.Name, escape(m.Help, false))
+		fmt.Fprintf(&b, "# TYPE %s %s\n", m.Name, m.Type)
+		for _, s := range m.Samples {
+			b.WriteString(m.Name)
+			if len(s.Labels) > 0 {
+				keys := make([]string, 0, len(s.Labels))
+				for k := range s.Labels {
+					keys = append(keys, k)
+				}
+				slices.Sort(keys)
+				b.WriteByte('{')
+				for i, k := range keys {
+					if i > 0 {
+						b.WriteByte(',')
+					}
+					fmt.Fprintf(&b, `%s="%s"`, k, escape(s.Labels[k], true))
+				}
+				b.WriteByte('}')
+			}
+			fmt.Fprintf(&b, " %s\n", strconv.FormatFloat(s.Value, 'f', -1, 64))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// escape escapes a help text, or a label value when quoted is set.
+func escape(s string, quoted bool) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	if quoted {
+		s = strings.ReplaceAll(s, `"`, `\"`)
+	}
+	return strings.ReplaceAll(s, "\n", `\n`)
+}
+
+// totalsFields are the fields of a Strava activity totals object, with the
+// metric each becomes.
+var totalsFields = []struct {
+	field, name, help string
+}{
+	{"count", "strava_activities", "Number of activities."},
+	{"distance", "strava_distance_meters", "Total distance in meters."},
+	{"moving_time", "strava_moving_time_seconds", "Total moving time in seconds."},
+	{"elapsed_time", "strava_elapsed_time_seconds", "Total elapsed time in seconds."},
+	{"elevation_gain", "strava_elevation_gain_meters", "Total elevation gain in meters."},
+}
+
+// AthleteStats maps the body of a GET /athletes/{id}/stats response onto
+// metrics: the totals of each field (see totalsFields) labeled by period
+// (recent, the last 4 weeks; ytd; all) and sport (ride, run, swim), and the
+// biggest ride and climb.
+func AthleteStats(body []byte) ([]Metric, error) {
+	var stats map[string]json.RawMessage
+	if err := json.Unmarshal(body, &stats); err != nil {
+		return nil, fmt.Errorf("parse stats: %w", err)
+	}
+	ms := make([]Metric, len(totalsFields))
+	for i, f := range totalsFields {
+		ms[i] = Metric{Name: f.name, Help: f.help + ` Period "recent" is the last 4 weeks.`, Type: "gauge"}
+	}
+	for _, period := range []string{"recent", "ytd", "all"} {
+		for _, sport := range []string{"ride", "run", "swim"} {
+			raw, ok := stats[period+"_"+sport+"_totals"]
+			if !ok {
+				continue
+			}
+			var totals map[string]float64
+			if err := json.Unmarshal(raw, &totals); err != nil {
+				return nil, fmt.Errorf("parse %s_%s_totals: %w", period, sport, err)
+			}
+			for i, f := range totalsFields {
+				ms[i].Samples = append(ms[i].Samples, Sample{
+					Labels: map[string]string{"period": period, "sport": sport},
+					Value:  totals[f.field],
+				})
+			}
+		}
+	}
+	var biggest struct {
+		Ride  float64 `json:"biggest_ride_distance"`
+		Climb float64 `json:"biggest_climb_elevation_gain"`
+	}
+	if err := json.Unmarshal(body, &biggest); err != nil {
+		return nil, fmt.Errorf("parse stats: %w", err)
+	}
+	return append(ms,
+		Gauge("strava_biggest_ride_distance_meters", "Longest ride distance in meters.", biggest.Ride),
+		Gauge("strava_biggest_climb_elevation_gain_meters", "Biggest climb elevation gain in meters.", biggest.Climb),
+	), nil
+}
+
+// Exporter keeps a set of metrics fresh, collecting them every Interval,
+// and serves the latest over HTTP.
+type Exporter struct {
+	Collect  func(ctx context.Context) ([]Metric, error)
+	Interval time.Duration
+	OnError  func(error) // called with each failed collection; may be nil
+
+	mu       sync.Mutex
+	metrics  []Metric
+	ok       bool
+	last     time.Time // of the last successful collection
+	duration time.Duration
+}
+
+// Run collects the metrics now and then every Interval until ctx is done.
+func (e *Exporter) Run(ctx context.Context) {
+	t := time.NewTicker(e.Interval)
+	defer t.Stop()
+	for {
+		e.refresh(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func (e *Exporter) refresh(ctx context.Context) {
+	start := time.Now()
+	ms, err := e.Collect(ctx)
+	if err != nil && ctx.Err() != nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.duration = time.Since(start)
+	e.ok = err == nil
+	if err != nil {
+		if e.OnError != nil {
+			e.OnError(err)
+		}
+		return
+	}
+	e.metrics, e.last = ms, time.Now()
+}
+
+// ServeHTTP writes the metrics of the last successful collection, which
+// stay served when a later one fails, followed by the exporter's own.
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	ms := slices.Clone(e.metrics)
+	up := 0.0
+	if e.ok {
+		up = 1
+	}
+	var last float64
+	if !e.last.IsZero() {
+		last = float64(e.last.Unix())
+	}
+	ms = append(ms,
+		Gauge("strava_exporter_up", "Whether the last collection from the Strava API succeeded.", up),
+		Gauge("strava_exporter_last_success_timestamp_seconds", "Unix time of the last successful collection.", last),
+		Gauge("strava_exporter_collect_duration_seconds", "How long the last collection took.", e.duration.Seconds()),
+	)
+	e.mu.Unlock()
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	Write(w, ms)
+}
@@ -0,0 +1,88 @@
+package metrics_test
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/metrics"
+)
+
+func TestWrite(t *testing.T) {
+	ms := []metrics.Metric{{
+		Name: "strava_distance_meters",
+		Help: "Total distance.\nIn meters.",
+		Type: "gauge",
+		Samples: []metrics.Sample{
+			{Labels: map[string]string{"sport": "run", "period": `a"b`}, Value: 1500.5},
+			{Value: 1792163892},
+		},
+	}}
+	var buf bytes.Buffer
+	if err := metrics.Write(&buf, ms); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP strava_distance_meters Total distance.\nIn meters.
+# TYPE strava_distance_meters gauge
+strava_distance_meters{period="a\"b",sport="run"} 1500.5
+strava_distance_meters 1792163892
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestAthleteStats(t *testing.T) {
+	body := []byte(`{
+		"biggest_ride_distance": 160934.4,
+		"recent_run_totals": {"count": 8, "distance": 64000, "moving_time": 21000, "elapsed_time": 22000, "elevation_gain": 420},
+		"all_ride_totals": {"count": 120, "distance": 4.2e6}
+	}`)
+	ms, err := metrics.AthleteStats(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	metrics.Write(&buf, ms)
+	for _, want := range []string{
+		`strava_activities{period="recent",sport="run"} 8`,
+		`strava_distance_meters{period="all",sport="ride"} 4200000`,
+		`strava_elevation_gain_meters{period="recent",sport="run"} 420`,
+		`strava_moving_time_seconds{period="all",sport="ride"} 0`,
+		"strava_biggest_ride_distance_meters 160934.4",
+	} {
+		if !strings.Contains(buf.String(), want+"\n") {
+			t.Errorf("metrics lack %q:\n%s", want, buf.String())
+		}
+	}
+	if _, err := metrics.AthleteStats([]byte("not json")); err == nil {
+		t.Error("expected an error for a bad body")
+	}
+}
+
+func TestExporter_ServesCollectedMetrics(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	e := &metrics.Exporter{
+		Collect: func(context.Context) ([]metrics.Metric, error) {
+			cancel() // stop Run after this collection
+			return []metrics.Metric{metrics.Gauge("strava_test", "A test metric.", 42)}, nil
+		},
+		Interval: time.Hour,
+	}
+	e.Run(ctx)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{"strava_test 42\n", "strava_exporter_up 1\n"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("response lacks %q:\n%s", want, body)
+		}
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+}
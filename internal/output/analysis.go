@@ -0,0 +1,727 @@
+package output
+
+// This file contains formatters for results computed by internal/analysis.
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/analysis"
+)
+
+// maxCourseColumns caps how many individual efforts appear in the split matrix.
+const maxCourseColumns = 5
+
+// Course prints a personal course leaderboard followed by a split matrix.
+func (p *Printer) Course(name string, c analysis.Course) error {
+	if p.structured() {
+		return p.emit(c)
+	}
+	if len(c.Efforts) == 0 {
+		fmt.Fprintln(p.w, "No efforts to compare.")
+		return nil
+	}
+	fmt.Fprintf(p.w, "%s — %d effort(s) over %d × %s\n\n",
+		name, len(c.Efforts), len(c.Best), p.distance(float32(c.Unit)))
+
+	p.header(80, "%-4s  %-12s  %-16s  %-30s  %s\n", "Rank", "Activity", "Date", "Name", "Time")
+	for i, e := range c.Efforts {
+		fmt.Fprintf(p.w, "%-4d  %-12d  %-16s  %-30s  %s\n",
+			i+1, e.ActivityID, formatTime(&e.Date), truncate(e.Name, 30), formatDuration(int(e.Total+0.5)))
+	}
+
+	cols := c.Efforts
+	if len(cols) > maxCourseColumns {
+		cols = cols[:maxCourseColumns]
+	}
+	heading := fmt.Sprintf("\n%-6s  %-9s  %-9s  %-9s", "Split", "Best", "Worst", "Average")
+	for i := range cols {
+		heading += fmt.Sprintf("  %-9s", fmt.Sprintf("#%d", i+1))
+	}
+	p.header(40+11*len(cols), "%s", heading)
+	for k := range c.Best {
+		fmt.Fprintf(p.w, "%-6d  %-9s  %-9s  %-9s", k+1,
+			formatDuration(int(c.Best[k]+0.5)),
+			formatDuration(int(c.Worst[k]+0.5)),
+			formatDuration(int(c.Average[k]+0.5)))
+		for _, e := range cols {
+			fmt.Fprintf(p.w, "  %-9s", formatDuration(int(e.Splits[k]+0.5)))
+		}
+		fmt.Fprintln(p.w)
+	}
+	return nil
+}
+
+// Report prints per-period training totals followed by a grand total.
+func (p *Printer) Report(rows []analysis.PeriodTotals) error {
+	if p.structured() {
+		if rows == nil {
+			rows = []analysis.PeriodTotals{}
+		}
+		return p.emit(rows)
+	}
+	if len(rows) == 0 {
+		fmt.Fprintln(p.w, "No activities in this range.")
+		return nil
+	}
+	p.header(56, "%-10s  %6s  %-10s  %-10s  %s\n", "Period", "Count", "Distance", "Time", "Elevation")
+	var total analysis.PeriodTotals
+	for _, r := range rows {
+		fmt.Fprintf(p.w, "%-10s  %6d  %-10s  %-10s  %s\n", r.Period, r.Count,
+			p.distance(float32(r.Distance)), formatDuration(r.MovingTime), p.elevation(float32(r.ElevationGain)))
+		total.Count += r.Count
+		total.Distance += r.Distance
+		total.MovingTime += r.MovingTime
+		total.ElevationGain += r.ElevationGain
+	}
+	fmt.Fprintln(p.w, strings.Repeat("─", 56))
+	fmt.Fprintf(p.w, "%-10s  %6d  %-10s  %-10s  %s\n", "Total", total.Count,
+		p.distance(float32(total.Distance)), formatDuration(total.MovingTime), p.elevation(float32(total.ElevationGain)))
+	return nil
+}
+
+// ClubLeaderboard prints a club leaderboard for the period named by label.
+func (p *Printer) ClubLeaderboard(label string, rows []analysis.ClubLeaderboardRow) error {
+	if p.structured() {
+		if rows == nil {
+			rows = []analysis.ClubLeaderboardRow{}
+		}
+		return p.emit(rows)
+	}
+	if len(rows) == 0 {
+		fmt.Fprintf(p.w, "No activities %s.\n", label)
+		return nil
+	}
+	fmt.Fprintf(p.w, "Leaderboard %s\n\n", label)
+	p.header(92, "%3s  %-24s  %10s  %-10s  %-10s  %-10s  %s\n", "#", "Athlete", "Activities", "Distance", "Time", "Longest", "Elevation")
+	for _, r := range rows {
+		fmt.Fprintf(p.w, "%3d  %-24s  %10d  %-10s  %-10s  %-10s  %s\n", r.Rank, truncate(r.Athlete, 24), r.Activities,
+			p.distance(float32(r.Distance)), formatDuration(r.MovingTime), p.distance(float32(r.Longest)),
+			p.elevation(float32(r.ElevationGain)))
+	}
+	return nil
+}
+
+// ClubDigest prints a club's digest as Markdown, ready to paste into a
+// newsletter or post: title, then totals, the top athletes by distance,
+// the longest ride and run, the biggest climb and a welcome to newcomers.
+// --output markdown prints the same.
+func (p *Printer) ClubDigest(title string, d analysis.ClubDigest) error {
+	if p.structured() && p.format() != "markdown" {
+		return p.emit(d)
+	}
+	fmt.Fprintf(p.w, "## %s\n\n", title)
+	if d.Activities == 0 {
+		fmt.Fprintln(p.w, "No activities this time.")
+		return nil
+	}
+	athletes := "athletes"
+	if d.Athletes == 1 {
+		athletes = "athlete"
+	}
+	fmt.Fprintf(p.w, "**%d activities** by **%d %s**: %s, %s moving and %s of climbing.\n",
+		d.Activities, d.Athletes, athletes, p.distance(float32(d.Distance)), formatDuration(d.MovingTime),
+		p.elevation(float32(d.ElevationGain)))
+
+	fmt.Fprintf(p.w, "\n### Top distances\n\n")
+	for _, r := range d.Top {
+		fmt.Fprintf(p.w, "%d. **%s**: %s in %d %s\n", r.Rank, r.Athlete, p.distance(float32(r.Distance)),
+			r.Activities, plural(r.Activities, "activity", "activities"))
+	}
+	highlight := func(heading string, e *analysis.ClubEntry, value string) {
+		if e != nil {
+			fmt.Fprintf(p.w, "\n### %s\n\n**%s**: %s, %s\n", heading, e.Athlete, e.Name, value)
+		}
+	}
+	if e := d.LongestRide; e != nil {
+		highlight("Longest ride", e, p.distance(float32(e.Distance)))
+	}
+	if e := d.LongestRun; e != nil {
+		highlight("Longest run", e, p.distance(float32(e.Distance)))
+	}
+	if e := d.BiggestClimb; e != nil {
+		highlight("Biggest climb", e, p.elevation(float32(e.ElevationGain))+" up")
+	}
+	if len(d.Newcomers) > 0 {
+		fmt.Fprintf(p.w, "\n### Welcome\n\nA warm welcome to %s, out with us for the first time!\n", joinAnd(d.Newcomers))
+	}
+	return nil
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+// joinAnd joins names as "A", "A and B" or "A, B and C".
+func joinAnd(names []string) string {
+	if len(names) < 2 {
+		return strings.Join(names, "")
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " and " + names[len(names)-1]
+}
+
+// IntensityReport prints per-period totals with sessions per class and the
+// share of heart rate time spent at low intensity, followed by a grand total.
+func (p *Printer) IntensityReport(rows []analysis.IntensityTotals) error {
+	if p.structured() {
+		if rows == nil {
+			rows = []analysis.IntensityTotals{}
+		}
+		return p.emit(rows)
+	}
+	if len(rows) == 0 {
+		fmt.Fprintln(p.w, "No activities in this range.")
+		return nil
+	}
+	low := func(t analysis.IntensityTotals) string {
+		if t.LowTime+t.HighTime == 0 {
+			return "-"
+		}
+		return fmt.Sprintf("%.0f%%", 100*float64(t.LowTime)/float64(t.LowTime+t.HighTime))
+	}
+	line := func(t analysis.IntensityTotals, label string) {
+		fmt.Fprintf(p.w, "%-10s  %6d  %-10s  %-10s  %5d  %5d  %9d  %4d  %s\n", label, t.Count,
+			p.distance(float32(t.Distance)), formatDuration(t.MovingTime), t.Easy, t.Tempo, t.Threshold, t.VO2, low(t))
+	}
+	p.header(80, "%-10s  %6s  %-10s  %-10s  %5s  %5s  %9s  %4s  %s\n",
+		"Period", "Count", "Distance", "Time", "Easy", "Tempo", "Threshold", "VO2", "Low")
+	var total analysis.IntensityTotals
+	for _, r := range rows {
+		line(r, r.Period)
+		total.Count += r.Count
+		total.Distance += r.Distance
+		total.MovingTime += r.MovingTime
+		total.Easy += r.Easy
+		total.Tempo += r.Tempo
+		total.Threshold += r.Threshold
+		total.VO2 += r.VO2
+		total.LowTime += r.LowTime
+		total.HighTime += r.HighTime
+	}
+	fmt.Fprintln(p.w, strings.Repeat("─", 80))
+	line(total, "Total")
+	fmt.Fprintln(p.w, "\nLow: share of heart rate time in zones 1–2 (80/20 training aims for about 80%).")
+	return nil
+}
+
+// WeatherTrends prints recorded and weather-adjusted speed (as pace when foot
+// is set) and power per period.
+func (p *Printer) WeatherTrends(rows []analysis.WeatherTrend, foot bool) error {
+	if p.structured() {
+		if rows == nil {
+			rows = []analysis.WeatherTrend{}
+		}
+		return p.emit(rows)
+	}
+	if len(rows) == 0 {
+		fmt.Fprintln(p.w, "No activities in this range.")
+		return nil
+	}
+	speedName, speed := "Speed", p.speed
+	if foot {
+		speedName, speed = "Pace", p.pace
+	}
+	p.header(84, "%-10s  %5s  %6s  %-9s  %-11s  %-11s  %6s  %s\n",
+		"Period", "Count", "Temp", "Wind", speedName, "Adjusted", "Power", "Adjusted")
+	missing := 0
+	for _, r := range rows {
+		temp, wind := "-", "-"
+		if r.WithWeather > 0 {
+			temp, wind = p.temperature(r.AvgTempC), p.speed(float32(r.AvgWindSpeed))
+		}
+		power, adjPower := "-", "-"
+		if r.Power > 0 {
+			power, adjPower = fmt.Sprintf("%.0f W", r.Power), fmt.Sprintf("%.0f W", r.AdjustedPower)
+		}
+		fmt.Fprintf(p.w, "%-10s  %5d  %6s  %-9s  %-11s  %-11s  %6s  %s\n", r.Period, r.Count, temp, wind,
+			speed(float32(r.Speed)), speed(float32(r.AdjustedSpeed)), power, adjPower)
+		missing += r.Count - r.WithWeather
+	}
+	if missing > 0 {
+		fmt.Fprintf(p.w, "\n%d activit(ies) without weather data (indoor, no GPS, or too recent) are counted unadjusted.\n", missing)
+	}
+	return nil
+}
+
+// histogramWidth is the length of the longest bar in a histogram.
+const histogramWidth = 40
+
+// AdvancedStats prints the Eddington number, activity streaks and a histogram
+// of activity distances.
+func (p *Printer) AdvancedStats(a analysis.Advanced) error {
+	if p.structured() {
+		return p.emit(a)
+	}
+	unit := "km"
+	if p.Units == Imperial {
+		unit = "mi"
+	}
+	fmt.Fprintf(p.w, "Activities:      %d\n", a.Activities)
+	fmt.Fprintf(p.w, "Eddington:       %d %s (%d more day(s) of %d+ %s for %d)\n",
+		a.Eddington, unit, a.EddingtonNext, a.Eddington+1, unit, a.Eddington+1)
+	fmt.Fprintf(p.w, "Current streak:  %s\n", formatStreak(a.CurrentStreak))
+	fmt.Fprintf(p.w, "Longest streak:  %s\n", formatStreak(a.LongestStreak))
+
+	if len(a.Histogram) == 0 {
+		return nil
+	}
+	maxCount := 0
+	for _, b := range a.Histogram {
+		maxCount = max(maxCount, b.Count)
+	}
+	fmt.Fprintf(p.w, "\nDistance distribution (%s):\n", unit)
+	for _, b := range a.Histogram {
+		bar := 0
+		if maxCount > 0 {
+			bar = (b.Count*histogramWidth + maxCount - 1) / maxCount
+		}
+		fmt.Fprintf(p.w, "  %4.0f–%-4.0f  %-*s  %d\n", b.Min, b.Max, histogramWidth, strings.Repeat("█", bar), b.Count)
+	}
+	return nil
+}
+
+func formatStreak(s analysis.Streak) string {
+	if s.Days == 0 {
+		return "0 days"
+	}
+	return fmt.Sprintf("%d day(s) (%s – %s)", s.Days, s.Start.Format("2006-01-02"), s.End.Format("2006-01-02"))
+}
+
+// ActivityRow formats a summary activity as one line of a list: date, name,
+// sport, distance and moving time.
+func (p *Printer) ActivityRow(a analysis.Activity) string {
+	return fmt.Sprintf("%-16s  %-30s  %-14s  %9s  %8s",
+		formatTime(&a.StartDateLocal),
+		truncate(a.Name, 30),
+		truncate(a.SportType, 14),
+		p.distance(float32(a.Distance)),
+		formatDuration(a.MovingTime))
+}
+
+// ElevationAudit prints the activities whose reported elevation gain
+// disagrees with the DEM (or every checked activity when all is set),
+// followed by reported and corrected climbing per year.
+func (p *Printer) ElevationAudit(checks []analysis.ElevationCheck, years []analysis.ElevationYear, all bool) error {
+	var shown []analysis.ElevationCheck
+	for _, c := range checks {
+		if all || c.Outlier {
+			shown = append(shown, c)
+		}
+	}
+	if p.structured() {
+		if shown == nil {
+			shown = []analysis.ElevationCheck{}
+		}
+		if years == nil {
+			years = []analysis.ElevationYear{}
+		}
+		return p.emit(struct {
+			Activities []analysis.ElevationCheck `json:"activities"`
+			Years      []analysis.ElevationYear  `json:"years"`
+		}{shown, years})
+	}
+	if len(checks) == 0 {
+		fmt.Fprintln(p.w, "No activities with GPS in this range.")
+		return nil
+	}
+	if len(shown) == 0 {
+		fmt.Fprintf(p.w, "No outliers among %d activities.\n", len(checks))
+	} else {
+		p.header(80, "%-12s  %-10s  %-12s  %9s  %9s  %s\n", "ID", "Date", "Sport", "Reported", "DEM", "Name")
+		for _, c := range shown {
+			flag := ""
+			if c.Outlier {
+				flag = "  !"
+			}
+			fmt.Fprintf(p.w, "%-12d  %-10s  %-12s  %9s  %9s  %s%s\n", c.ActivityID, c.Date.Format("2006-01-02"),
+				c.SportType, p.elevation(float32(c.Reported)), p.elevation(float32(c.DEM)), c.Name, flag)
+		}
+	}
+	fmt.Fprintln(p.w)
+	p.header(56, "%-6s  %10s  %8s  %12s  %12s\n", "Year", "Activities", "Outliers", "Reported", "Corrected")
+	for _, y := range years {
+		fmt.Fprintf(p.w, "%-6d  %10d  %8d  %12s  %12s\n", y.Year, y.Count, y.Outliers,
+			p.elevation(float32(y.Reported)), p.elevation(float32(y.Corrected)))
+	}
+	return nil
+}
+
+// GearList prints the athlete's bikes and shoes with Strava's distance
+// totals, flagging gear with a maintenance alert that is due.
+func (p *Printer) GearList(gear []analysis.Gear, alerts []analysis.GearAlertStatus) error {
+	if p.structured() {
+		if gear == nil {
+			gear = []analysis.Gear{}
+		}
+		return p.emit(gear)
+	}
+	if len(gear) == 0 {
+		fmt.Fprintln(p.w, "No gear.")
+		return nil
+	}
+	// alerts are most urgent first, so the first one per gear is its worst.
+	flags := map[string]string{}
+	for _, a := range alerts {
+		if _, seen := flags[a.GearID]; seen || a.Level == analysis.AlertOK {
+			continue
+		}
+		flags[a.GearID] = a.Level
+		if a.Part != "" {
+			flags[a.GearID] += ": " + a.Part
+		}
+	}
+	p.header(96, "%-12s  %-6s  %-30s  %11s  %-7s  %-7s  %s\n", "ID", "Kind", "Name", "Distance", "Primary", "Retired", "Alert")
+	for _, g := range gear {
+		fmt.Fprintf(p.w, "%-12s  %-6s  %-30s  %11s  %-7s  %-7s  %s\n", g.ID, g.Kind, truncate(g.Name, 30),
+			p.distance(float32(g.Distance)), yesNo(g.Primary), yesNo(g.Retired), flags[g.ID])
+	}
+	if len(flags) > 0 {
+		fmt.Fprintln(p.w, "\nSee details with: strava gear alerts")
+	}
+	return nil
+}
+
+// GearAlerts prints maintenance alerts with how far each part has gone since
+// it was last replaced.
+func (p *Printer) GearAlerts(alerts []analysis.GearAlertStatus) error {
+	if p.structured() {
+		if alerts == nil {
+			alerts = []analysis.GearAlertStatus{}
+		}
+		return p.emit(alerts)
+	}
+	if len(alerts) == 0 {
+		fmt.Fprintln(p.w, "No gear alerts. Add one with: strava gear alerts --set <gear-id>[:<part>]=<distance>")
+		return nil
+	}
+	p.header(94, "%-20s  %-24s  %11s  %11s  %11s  %s\n", "Alert", "Gear", "Used", "Every", "Remaining", "Status")
+	for _, a := range alerts {
+		fmt.Fprintf(p.w, "%-20s  %-24s  %11s  %11s  %11s  %s\n", truncate(a.Label(), 20), truncate(a.Name, 24),
+			p.distance(float32(a.Used)), p.distance(float32(a.Every)), p.distance(float32(max(a.Remaining, 0))), a.Level)
+	}
+	return nil
+}
+
+// GearReport prints activity totals per piece of gear.
+func (p *Printer) GearReport(rows []analysis.GearTotals) error {
+	if p.structured() {
+		if rows == nil {
+			rows = []analysis.GearTotals{}
+		}
+		return p.emit(rows)
+	}
+	if len(rows) == 0 {
+		fmt.Fprintln(p.w, "No activities in this range.")
+		return nil
+	}
+	p.header(104, "%-12s  %-24s  %6s  %11s  %-10s  %-9s  %-10s  %s\n",
+		"ID", "Name", "Count", "Distance", "Time", "Elevation", "First", "Last")
+	for _, r := range rows {
+		name := r.Name
+		if r.Retired {
+			name += " (retired)"
+		}
+		fmt.Fprintf(p.w, "%-12s  %-24s  %6d  %11s  %-10s  %-9s  %-10s  %s\n", r.GearID, truncate(name, 24), r.Count,
+			p.distance(float32(r.Distance)), formatDuration(r.MovingTime), p.elevation(float32(r.ElevationGain)),
+			r.First.Format("2006-01-02"), r.Last.Format("2006-01-02"))
+	}
+	return nil
+}
+
+// Records prints the best blocks of days for a metric, best first.
+func (p *Printer) Records(rows []analysis.Record, metric analysis.RecordMetric, days int) error {
+	if p.structured() {
+		if rows == nil {
+			rows = []analysis.Record{}
+		}
+		return p.emit(rows)
+	}
+	if len(rows) == 0 {
+		fmt.Fprintln(p.w, "No activities in this range.")
+		return nil
+	}
+	var name string
+	var format func(float64) string
+	switch metric {
+	case analysis.RecordTime:
+		name, format = "Time", func(v float64) string { return formatDuration(int(v)) }
+	case analysis.RecordElevation:
+		name, format = "Elevation", func(v float64) string { return p.elevation(float32(v)) }
+	default:
+		name, format = "Distance", func(v float64) string { return p.distance(float32(v)) }
+	}
+	fmt.Fprintf(p.w, "Best %d-day blocks by %s\n\n", days, metric)
+	p.header(54, "%2s  %-10s  %-10s  %12s  %s\n", "#", "From", "To", name, "Activities")
+	for i, r := range rows {
+		fmt.Fprintf(p.w, "%2d  %-10s  %-10s  %12s  %d\n", i+1,
+			r.Start.Format("2006-01-02"), r.End.Format("2006-01-02"), format(r.Value), r.Count)
+	}
+	return nil
+}
+
+// Polarization prints time and share per intensity zone and the model the
+// distribution fits.
+func (p *Printer) Polarization(d analysis.Distribution) error {
+	if p.structured() {
+		return p.emit(d)
+	}
+	if d.Sessions == 0 {
+		fmt.Fprintln(p.w, "No classified sessions in this range (they need heart rate data).")
+		return nil
+	}
+	low, moderate, high := d.Shares()
+	method := "time in heart rate zones"
+	if d.Method == analysis.BySession {
+		method = "session goal"
+	}
+	fmt.Fprintf(p.w, "Intensity distribution of %d session(s), by %s\n\n", d.Sessions, method)
+	p.header(30, "%-10s  %-10s  %6s\n", "Zone", "Time", "Share")
+	for _, z := range []struct {
+		name  string
+		secs  int
+		share float64
+	}{{"Low", d.Low, low}, {"Moderate", d.Moderate, moderate}, {"High", d.High, high}} {
+		fmt.Fprintf(p.w, "%-10s  %-10s  %5.0f%%\n", z.name, formatDuration(z.secs), 100*z.share)
+	}
+	fmt.Fprintln(p.w)
+	if d.Model == "polarized" {
+		fmt.Fprintf(p.w, "Model: polarized (index %.2f)\n", d.Index)
+	} else {
+		fmt.Fprintf(p.w, "Model: %s\n", d.Model)
+	}
+	switch {
+	case low >= 0.75 && low <= 0.85:
+		fmt.Fprintln(p.w, "About 80/20: in line with the 80/20 rule.")
+	case low < 0.75:
+		fmt.Fprintf(p.w, "%.0f/%.0f: less low-intensity time than the 80/20 rule suggests.\n", 100*low, 100*(1-low))
+	default:
+		fmt.Fprintf(p.w, "%.0f/%.0f: more low-intensity time than the 80/20 rule suggests.\n", 100*low, 100*(1-low))
+	}
+	return nil
+}
+
+// zoneBarWidth is the length of a bar for all the time in one zone.
+const zoneBarWidth = 30
+
+// ZoneReport prints the time in each zone with a bar of its share, then
+// the split into low, moderate and high intensity.
+func (p *Printer) ZoneReport(d analysis.ZoneDistribution) error {
+	if p.structured() {
+		return p.emit(d)
+	}
+	name, unit := "heart rate", "bpm"
+	if d.Metric == "power" {
+		name, unit = "power", "W"
+	}
+	if d.Activities == 0 {
+		fmt.Fprintf(p.w, "No activities with %s data in this range.\n", name)
+		return nil
+	}
+	fmt.Fprintf(p.w, "Time in %s zones over %d activities\n\n", name, d.Activities)
+	p.header(38+zoneBarWidth, "%-4s  %-13s  %10s  %6s\n", "Zone", "Range", "Time", "Share")
+	for _, z := range d.Zones {
+		rng := fmt.Sprintf("%d+ %s", z.Min, unit)
+		if z.Max > 0 {
+			rng = fmt.Sprintf("%d–%d %s", z.Min, z.Max, unit)
+		}
+		bar := strings.Repeat("█", int(math.Round(z.Share*zoneBarWidth)))
+		line := fmt.Sprintf("Z%-3d  %-13s  %10s  %5.1f%%  %s", z.Zone, rng, formatDuration(z.Seconds), 100*z.Share, bar)
+		fmt.Fprintln(p.w, strings.TrimRight(line, " "))
+	}
+	low, moderate, high := d.Intensity.Shares()
+	fmt.Fprintf(p.w, "\nLow (Z1–2) %.0f%%, moderate (Z3) %.0f%%, high (Z4+) %.0f%%: %s\n",
+		100*low, 100*moderate, 100*high, d.Intensity.Model)
+	return nil
+}
+
+// PersonalRecords prints the best effort of each sport over each standard
+// distance and duration, marking those set since the records were last
+// updated.
+func (p *Printer) PersonalRecords(prs []analysis.PR) error {
+	if p.structured() {
+		if prs == nil {
+			prs = []analysis.PR{}
+		}
+		return p.emit(prs)
+	}
+	if len(prs) == 0 {
+		fmt.Fprintln(p.w, "No runs or activities with power in this range.")
+		return nil
+	}
+	p.header(90, "%-10s  %-13s  %10s  %-9s  %-10s  %s\n", "Sport", "Effort", "Best", "Pace", "Date", "Activity")
+	for _, r := range prs {
+		best, pace := fmt.Sprintf("%.0f W", r.Watts), ""
+		if r.Meters > 0 {
+			best, pace = formatDuration(r.Seconds), p.pace(float32(r.Meters/float64(r.Seconds)))
+		}
+		line := fmt.Sprintf("%-10s  %-13s  %10s  %-9s  %-10s  %s", r.Sport, r.Effort, best, pace,
+			r.Date.Format("2006-01-02"), truncate(r.ActivityName, 30))
+		if r.New {
+			line = p.highlight(line + "  ★ new")
+		}
+		fmt.Fprintln(p.w, line)
+	}
+	return nil
+}
+
+// NewRecords prints the records in prs that are new, one line each, for
+// commands that update the records in passing.
+func (p *Printer) NewRecords(prs []analysis.PR) {
+	for _, r := range prs {
+		if !r.New {
+			continue
+		}
+		best := fmt.Sprintf("%.0f W", r.Watts)
+		if r.Meters > 0 {
+			best = formatDuration(r.Seconds)
+		}
+		fmt.Fprintln(p.w, p.highlight(fmt.Sprintf("New PR: %s %s %s in %s (%s)", r.Sport, r.Effort, best,
+			r.ActivityName, r.Date.Format("2006-01-02"))))
+	}
+}
+
+// HeartRateRecovery prints the recovery after each hard effort in an
+// activity.
+func (p *Printer) HeartRateRecovery(recs []analysis.Recovery) error {
+	if p.structured() {
+		if recs == nil {
+			recs = []analysis.Recovery{}
+		}
+		return p.emit(recs)
+	}
+	if len(recs) == 0 {
+		fmt.Fprintln(p.w, "No hard efforts with a minute of recovery after them.")
+		return nil
+	}
+	p.header(54, "%-9s  %-9s  %5s  %6s  %5s  %s\n", "Effort", "Ended", "Peak", "+60s", "Drop", "Recovery")
+	total := 0
+	for _, r := range recs {
+		kind := "active"
+		if r.Stopped {
+			kind = "stopped"
+		}
+		fmt.Fprintf(p.w, "%-9s  %-9s  %5d  %6d  %5d  %s\n", formatDuration(r.End-r.Start), formatDuration(r.End),
+			r.PeakHR, r.HR60, r.Drop, kind)
+		total += r.Drop
+	}
+	fmt.Fprintf(p.w, "\nMean HRR60: %.0f bpm over %d effort(s)\n", float64(total)/float64(len(recs)), len(recs))
+	return nil
+}
+
+// RecoveryTrends prints heart rate recovery per period.
+func (p *Printer) RecoveryTrends(rows []analysis.HRRTrend) error {
+	if p.structured() {
+		if rows == nil {
+			rows = []analysis.HRRTrend{}
+		}
+		return p.emit(rows)
+	}
+	if len(rows) == 0 {
+		fmt.Fprintln(p.w, "No hard efforts with a minute of recovery after them in this range.")
+		return nil
+	}
+	p.header(42, "%-10s  %10s  %7s  %s\n", "Period", "Activities", "Efforts", "HRR60")
+	for _, r := range rows {
+		fmt.Fprintf(p.w, "%-10s  %10d  %7d  %.0f bpm\n", r.Period, r.Activities, r.Efforts, r.MeanDrop)
+	}
+	return nil
+}
+
+// Fitness prints charts of fitness and form over days, a table of their
+// values at the end of each week (the last one ending on the last day), and
+// the form on the last day.
+func (p *Printer) Fitness(days []analysis.FitnessDay) error {
+	if p.structured() {
+		if days == nil {
+			days = []analysis.FitnessDay{}
+		}
+		return p.emit(days)
+	}
+	if len(days) == 0 {
+		fmt.Fprintln(p.w, "No days in this range.")
+		return nil
+	}
+	fitness, form := make([]float64, len(days)), make([]float64, len(days))
+	for i, d := range days {
+		fitness[i], form[i] = d.Fitness, d.Form
+	}
+	first, last := days[0].Date, days[len(days)-1].Date
+	p.dailyChart("Fitness (CTL)", fitness, first, last)
+	fmt.Fprintln(p.w)
+	p.dailyChart("Form (TSB)", form, first, last)
+	fmt.Fprintln(p.w)
+
+	p.header(43, "%-10s  %6s  %7s  %7s  %5s\n", "Week to", "TSS", "Fitness", "Fatigue", "Form")
+	for i := len(days) % 7; i+7 <= len(days); i += 7 {
+		tss := 0.0
+		for _, d := range days[i : i+7] {
+			tss += d.TSS
+		}
+		d := days[i+6]
+		// +0 turns a form that rounds to -0 into 0.
+		fmt.Fprintf(p.w, "%-10s  %6.0f  %7.0f  %7.0f  %5.0f\n", d.Date.Format("2006-01-02"), tss, d.Fitness, d.Fatigue, math.Round(d.Form)+0)
+	}
+	today := days[len(days)-1]
+	fmt.Fprintf(p.w, "\nForm on %s: %.0f, %s (fitness %.0f, fatigue %.0f)\n",
+		today.Date.Format("2006-01-02"), math.Round(today.Form)+0, analysis.FormZone(today.Form), today.Fitness, today.Fatigue)
+	return nil
+}
+
+// calendarShades are the cells of a training calendar, by level: a rest day,
+// then lightest to darkest.
+var calendarShades = []string{"·", "░", "▒", "▓", "█"}
+
+// Calendar prints a year of training as a heatmap, one column per week
+// (Monday to Sunday) and a cell per day shaded by its level, then totals.
+func (p *Printer) Calendar(c analysis.Calendar) error {
+	if p.structured() {
+		return p.emit(c)
+	}
+	by := "moving time"
+	if c.Metric == analysis.ByDistance {
+		by = "distance"
+	}
+	fmt.Fprintf(p.w, "%d — shaded by %s\n\n", c.Year, by)
+
+	// Jan 1st's column offset: how many days of its week come before it.
+	offset := (int(time.Date(c.Year, time.January, 1, 0, 0, 0, 0, time.UTC).Weekday()) + 6) % 7
+	weeks := (offset + len(c.Days) + 6) / 7
+	months := []byte(strings.Repeat(" ", weeks+3))
+	for m := time.January; m <= time.December; m++ {
+		i := time.Date(c.Year, m, 1, 0, 0, 0, 0, time.UTC).YearDay() - 1
+		if i >= len(c.Days) {
+			break
+		}
+		col := (offset + i) / 7
+		if col == 0 || months[col-1] == ' ' {
+			copy(months[col:], m.String()[:3])
+		}
+	}
+	fmt.Fprintf(p.w, "     %s\n", strings.TrimRight(string(months), " "))
+	for wd := range 7 {
+		var row strings.Builder
+		for w := range weeks {
+			i := w*7 + wd - offset
+			if i < 0 || i >= len(c.Days) {
+				row.WriteString(" ")
+				continue
+			}
+			row.WriteString(calendarShades[c.Days[i].Level])
+		}
+		label := time.Weekday((wd + 1) % 7).String()[:3]
+		fmt.Fprintf(p.w, "%s  %s\n", label, strings.TrimRight(row.String(), " "))
+	}
+	fmt.Fprintf(p.w, "     less %s more\n\n", strings.Join(calendarShades, " "))
+
+	fmt.Fprintf(p.w, "Active days:     %d\n", c.ActiveDays)
+	fmt.Fprintf(p.w, "Activities:      %d\n", c.Activities)
+	fmt.Fprintf(p.w, "Moving time:     %s\n", formatDuration(c.MovingTime))
+	fmt.Fprintf(p.w, "Distance:        %s\n", p.distance(float32(c.Distance)))
+	fmt.Fprintf(p.w, "Longest streak:  %s\n", formatStreak(c.LongestStreak))
+	return nil
+}
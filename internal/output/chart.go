@@ -0,0 +1,241 @@
+package output
+
+// This file renders activity streams as terminal line charts.
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Chart dimensions in terminal cells. Each braille cell holds 2×4 dots.
+const (
+	chartWidth  = 72
+	chartHeight = 12
+)
+
+// minPaceSpeed is the speed (m/s) below which pace is treated as stopped;
+// otherwise a pause would dwarf the rest of a pace chart.
+const minPaceSpeed = 0.5
+
+// Series is one activity stream plotted against distance, or against elapsed
+// time when the activity has no distance stream. Values are in SI units as
+// returned by the API (meters, bpm, watts, m/s for pace).
+type Series struct {
+	Metric   string    `json:"metric"` // altitude, heartrate, watts or pace
+	Distance []float64 `json:"distance,omitempty"`
+	Time     []int     `json:"time,omitempty"`
+	Values   []float64 `json:"values"`
+}
+
+// Chart draws s as a braille line chart with min/max labels.
+func (p *Printer) Chart(title string, s Series) error {
+	if p.structured() {
+		return p.emit(s)
+	}
+	values := make([]float64, len(s.Values))
+	for i, v := range s.Values {
+		values[i] = p.chartValue(s.Metric, v)
+	}
+	points := downsample(values, chartWidth*2)
+	lo, hi, ok := valueRange(points)
+	if !ok {
+		fmt.Fprintf(p.w, "No %s data to chart.\n", s.Metric)
+		return nil
+	}
+
+	if title != "" {
+		fmt.Fprintln(p.w, title)
+	}
+	hiLabel, loLabel := p.chartLabel(s.Metric, hi), p.chartLabel(s.Metric, lo)
+	if s.Metric == "pace" {
+		// Faster (lower) pace is drawn at the top.
+		hiLabel, loLabel = loLabel, hiLabel
+		for i, v := range points {
+			points[i] = -v
+		}
+		lo, hi = -hi, -lo
+	}
+	labelWidth := max(len(hiLabel), len(loLabel))
+	rows := brailleChart(points, lo, hi, chartWidth, chartHeight)
+	for i, row := range rows {
+		label := ""
+		switch i {
+		case 0:
+			label = hiLabel
+		case len(rows) - 1:
+			label = loLabel
+		}
+		fmt.Fprintf(p.w, "%*s ┤%s\n", labelWidth, label, row)
+	}
+
+	end := ""
+	switch {
+	case len(s.Distance) > 0:
+		end = p.distance(float32(s.Distance[len(s.Distance)-1]))
+	case len(s.Time) > 0:
+		end = formatDuration(s.Time[len(s.Time)-1])
+	}
+	fmt.Fprintf(p.w, "%*s └%s\n", labelWidth, "", strings.Repeat("─", chartWidth))
+	fmt.Fprintf(p.w, "%*s  0%*s\n", labelWidth, "", chartWidth-1, end)
+	return nil
+}
+
+// chartValue converts an SI stream value into the unit shown on the chart.
+// Unplottable samples are returned as NaN.
+func (p *Printer) chartValue(metric string, v float64) float64 {
+	switch metric {
+	case "altitude":
+		if p.Units == Imperial {
+			return v * feetPerMeter
+		}
+	case "pace":
+		if v < minPaceSpeed {
+			return math.NaN()
+		}
+		if p.Units == Imperial {
+			return metersPerMile / v
+		}
+		return 1000 / v
+	}
+	return v
+}
+
+// chartLabel formats a converted chart value for the y axis.
+func (p *Printer) chartLabel(metric string, v float64) string {
+	switch metric {
+	case "altitude":
+		if p.Units == Imperial {
+			return fmt.Sprintf("%.0f ft", v)
+		}
+		return fmt.Sprintf("%.0f m", v)
+	case "heartrate":
+		return fmt.Sprintf("%.0f bpm", v)
+	case "watts":
+		return fmt.Sprintf("%.0f W", v)
+	case "pace":
+		unit := "/km"
+		if p.Units == Imperial {
+			unit = "/mi"
+		}
+		s := int(v + 0.5)
+		return fmt.Sprintf("%d:%02d%s", s/60, s%60, unit)
+	}
+	return fmt.Sprintf("%.0f", v)
+}
+
+// downsample reduces values to at most n points by averaging equal-width
+// buckets. NaN samples are ignored; a bucket of only NaNs stays NaN.
+func downsample(values []float64, n int) []float64 {
+	if len(values) <= n {
+		return values
+	}
+	out := make([]float64, n)
+	for i := range out {
+		from, to := i*len(values)/n, (i+1)*len(values)/n
+		sum, count := 0.0, 0
+		for _, v := range values[from:to] {
+			if !math.IsNaN(v) {
+				sum += v
+				count++
+			}
+		}
+		out[i] = math.NaN()
+		if count > 0 {
+			out[i] = sum / float64(count)
+		}
+	}
+	return out
+}
+
+// valueRange returns the min and max of the non-NaN values.
+func valueRange(values []float64) (lo, hi float64, ok bool) {
+	lo, hi = math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		if math.IsNaN(v) {
+			continue
+		}
+		lo, hi, ok = math.Min(lo, v), math.Max(hi, v), true
+	}
+	return lo, hi, ok
+}
+
+// brailleDots maps a dot's (column, row) within a cell to its braille bit.
+var brailleDots = [2][4]rune{{0x01, 0x02, 0x04, 0x40}, {0x08, 0x10, 0x20, 0x80}}
+
+// brailleChart plots values (one per dot column, scaled between lo and hi) as
+// a connected line, returning height rows of width braille characters.
+func brailleChart(values []float64, lo, hi float64, width, height int) []string {
+	cells := make([][]rune, height)
+	for i := range cells {
+		cells[i] = make([]rune, width)
+	}
+	dotsY := height * 4
+	toY := func(v float64) int {
+		if hi == lo {
+			return dotsY / 2
+		}
+		return int(math.Round((hi - v) / (hi - lo) * float64(dotsY-1)))
+	}
+	set := func(x, y int) {
+		cells[y/4][x/2] |= brailleDots[x%2][y%4]
+	}
+
+	// Stretch short series across the full width.
+	cols := width * 2
+	prev := -1
+	for x := 0; x < cols; x++ {
+		i := x * len(values) / cols
+		if i >= len(values) || math.IsNaN(values[i]) {
+			prev = -1
+			continue
+		}
+		y := toY(values[i])
+		set(x, y)
+		if prev >= 0 {
+			// Fill the vertical gap so steep changes stay connected.
+			for yy := min(prev, y) + 1; yy < max(prev, y); yy++ {
+				set(x, yy)
+			}
+		}
+		prev = y
+	}
+
+	rows := make([]string, height)
+	for i, row := range cells {
+		var b strings.Builder
+		for _, c := range row {
+			b.WriteRune(0x2800 + c)
+		}
+		rows[i] = b.String()
+	}
+	return rows
+}
+
+// dailyChart draws one value per day, from first to last, as a braille line
+// chart with min/max labels and the dates at each end.
+func (p *Printer) dailyChart(title string, values []float64, first, last time.Time) {
+	points := downsample(values, chartWidth*2)
+	lo, hi, ok := valueRange(points)
+	if !ok {
+		return
+	}
+	fmt.Fprintln(p.w, title)
+	hiLabel, loLabel := fmt.Sprintf("%.0f", hi), fmt.Sprintf("%.0f", lo)
+	labelWidth := max(len(hiLabel), len(loLabel))
+	rows := brailleChart(points, lo, hi, chartWidth, chartHeight)
+	for i, row := range rows {
+		label := ""
+		switch i {
+		case 0:
+			label = hiLabel
+		case len(rows) - 1:
+			label = loLabel
+		}
+		fmt.Fprintf(p.w, "%*s ┤%s\n", labelWidth, label, row)
+	}
+	from, to := first.Format("Jan 2"), last.Format("Jan 2")
+	fmt.Fprintf(p.w, "%*s └%s\n", labelWidth, "", strings.Repeat("─", chartWidth))
+	fmt.Fprintf(p.w, "%*s  %s%*s\n", labelWidth, "", from, chartWidth-len(from), to)
+}
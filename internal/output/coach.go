@@ -0,0 +1,183 @@
+package output
+
+import (
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/analysis"
+)
+
+// CoachWeek is a week of training bundled by `share coach`.
+type CoachWeek struct {
+	Week       string // e.g. 2025-W14
+	Start      time.Time
+	Activities []analysis.Activity // oldest first, already redacted
+	Bounds     []int               // heart rate zone lower bounds; nil without zones
+	Zones      [][]int             // seconds per zone of each activity; nil without heart rate
+	Redacted   []string            // what was left out, described for the README
+	Files      []string            // the bundle's other files
+}
+
+// zoneTotals sums the seconds per zone over the week.
+func (w CoachWeek) zoneTotals() []int {
+	total := make([]int, len(w.Bounds))
+	for _, z := range w.Zones {
+		for i := range min(len(z), len(total)) {
+			total[i] += z[i]
+		}
+	}
+	return total
+}
+
+// CoachSummaryCSV writes one row per activity of the week, as
+// SegmentHistoryCSV does: times in seconds, values in metric units, and empty
+// cells for missing values. It ignores JSON mode.
+func (p *Printer) CoachSummaryCSV(week CoachWeek) error {
+	num := func(v float64, prec int) string {
+		if v <= 0 {
+			return ""
+		}
+		return strconv.FormatFloat(v, 'f', prec, 64)
+	}
+	id := func(v int64) string {
+		if v == 0 {
+			return ""
+		}
+		return strconv.FormatInt(v, 10)
+	}
+	w := csv.NewWriter(p.w)
+	w.Write([]string{"date", "activity_id", "sport", "name", "distance_km", "moving_s", "elapsed_s",
+		"elevation_m", "avg_hr", "max_hr", "avg_watts", "weighted_watts", "kj", "relative_effort", "gear_id"})
+	for _, a := range week.Activities {
+		w.Write([]string{
+			a.StartDateLocal.Format("2006-01-02 15:04:05"),
+			id(a.ID),
+			a.SportType,
+			a.Name,
+			num(a.Distance/1000, 2),
+			strconv.Itoa(a.MovingTime),
+			strconv.Itoa(a.ElapsedTime),
+			num(a.TotalElevationGain, 0),
+			num(a.AverageHeartrate, 1),
+			num(a.MaxHeartrate, 0),
+			num(a.AverageWatts, 1),
+			num(a.WeightedAverageWatts, 0),
+			num(a.Kilojoules, 0),
+			num(a.SufferScore, 0),
+			a.GearID,
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// CoachZonesCSV writes the seconds each activity spent in each heart rate
+// zone, and a total row. It ignores JSON mode.
+func (p *Printer) CoachZonesCSV(week CoachWeek) error {
+	w := csv.NewWriter(p.w)
+	header := []string{"date", "activity_id", "sport"}
+	for i := range week.Bounds {
+		header = append(header, fmt.Sprintf("z%d_s", i+1))
+	}
+	w.Write(header)
+	for i, a := range week.Activities {
+		if i >= len(week.Zones) || week.Zones[i] == nil {
+			continue
+		}
+		z := week.Zones[i]
+		row := []string{a.StartDateLocal.Format("2006-01-02 15:04:05"), "", a.SportType}
+		if a.ID > 0 {
+			row[1] = strconv.FormatInt(a.ID, 10)
+		}
+		for _, s := range z {
+			row = append(row, strconv.Itoa(s))
+		}
+		w.Write(row)
+	}
+	row := []string{"total", "", ""}
+	for _, s := range week.zoneTotals() {
+		row = append(row, strconv.Itoa(s))
+	}
+	w.Write(row)
+	w.Flush()
+	return w.Error()
+}
+
+// CoachReadme writes the Markdown overview of a coach bundle: totals per
+// sport, the week's heart rate zone distribution and the list of files. It
+// ignores JSON mode.
+func (p *Printer) CoachReadme(week CoachWeek) error {
+	end := week.Start.AddDate(0, 0, 6)
+	fmt.Fprintf(p.w, "# Training week %s\n\n%s – %s\n\n", week.Week,
+		week.Start.Format("Mon 2 Jan"), end.Format("Mon 2 Jan 2006"))
+
+	type totals struct {
+		count, moving    int
+		dist, elev, load float64
+	}
+	bySport := map[string]*totals{}
+	for _, a := range week.Activities {
+		t := bySport[a.SportType]
+		if t == nil {
+			t = &totals{}
+			bySport[a.SportType] = t
+		}
+		t.count++
+		t.moving += a.MovingTime
+		t.dist += a.Distance
+		t.elev += a.TotalElevationGain
+		t.load += a.SufferScore
+	}
+	sports := make([]string, 0, len(bySport))
+	for s := range bySport {
+		sports = append(sports, s)
+	}
+	sort.Strings(sports)
+
+	fmt.Fprintf(p.w, "## Summary\n\n")
+	if len(sports) == 0 {
+		fmt.Fprintf(p.w, "No activities this week.\n\n")
+	} else {
+		fmt.Fprintln(p.w, "| Sport | Sessions | Distance | Moving time | Elevation | Relative Effort |")
+		fmt.Fprintln(p.w, "|---|---:|---:|---:|---:|---:|")
+		for _, s := range sports {
+			t := bySport[s]
+			fmt.Fprintf(p.w, "| %s | %d | %s | %s | %s | %.0f |\n", s, t.count,
+				p.distance(float32(t.dist)), formatDuration(t.moving), p.elevation(float32(t.elev)), t.load)
+		}
+		fmt.Fprintln(p.w)
+	}
+
+	if len(week.Bounds) > 0 {
+		total := week.zoneTotals()
+		sum := 0
+		for _, s := range total {
+			sum += s
+		}
+		fmt.Fprintf(p.w, "## Heart rate zones\n\n")
+		if sum == 0 {
+			fmt.Fprintf(p.w, "No heart rate data this week.\n\n")
+		} else {
+			fmt.Fprintln(p.w, "| Zone | From | Time | Share |")
+			fmt.Fprintln(p.w, "|---|---:|---:|---:|")
+			for i, s := range total {
+				fmt.Fprintf(p.w, "| Z%d | %d bpm | %s | %.0f%% |\n", i+1, week.Bounds[i], formatDuration(s), 100*float64(s)/float64(sum))
+			}
+			fmt.Fprintln(p.w)
+		}
+	}
+
+	fmt.Fprintf(p.w, "## Files\n\n")
+	for _, f := range week.Files {
+		fmt.Fprintf(p.w, "- %s\n", f)
+	}
+	fmt.Fprintln(p.w, "\nsummary.csv has one row per activity, in metric units with times in seconds.")
+	if len(week.Redacted) > 0 {
+		fmt.Fprintf(p.w, "\nLeft out for privacy: %s.\n", strings.Join(week.Redacted, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package output
+
+// This file contains formatters for the command history (internal/store).
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/store"
+)
+
+// CommandDays prints what each day's runs of the CLI cost against the API,
+// with the command that made the most requests.
+func (p *Printer) CommandDays(days []store.DayUsage) error {
+	if p.structured() {
+		return p.emit(days)
+	}
+	if len(days) == 0 {
+		fmt.Fprintln(p.w, "No commands recorded yet.")
+		return nil
+	}
+	p.header(80, "%-10s  %5s  %8s  %9s  %8s  %s\n", "Date", "Runs", "Requests", "Data", "Time", "Costliest")
+	var total store.DayUsage
+	for _, d := range days {
+		costliest := ""
+		if len(d.Commands) > 0 && d.Commands[0].Requests > 0 {
+			c := d.Commands[0]
+			costliest = fmt.Sprintf("%s (%d %s in %d %s)", c.Command,
+				c.Requests, plural(int(c.Requests), "request", "requests"), c.Runs, plural(c.Runs, "run", "runs"))
+		}
+		line := fmt.Sprintf("%-10s  %5d  %8d  %9s  %8s  %s", d.Date, d.Runs, d.Requests, FormatSize(d.Bytes),
+			runDuration(time.Duration(d.DurationMS)*time.Millisecond), costliest)
+		fmt.Fprintln(p.w, strings.TrimRight(line, " "))
+		total.Runs += d.Runs
+		total.Requests += d.Requests
+		total.Bytes += d.Bytes
+		total.DurationMS += d.DurationMS
+	}
+	if len(days) > 1 {
+		fmt.Fprintln(p.w, strings.Repeat("─", 80))
+		fmt.Fprintf(p.w, "%-10s  %5d  %8d  %9s  %8s\n", "Total", total.Runs, total.Requests, FormatSize(total.Bytes),
+			runDuration(time.Duration(total.DurationMS)*time.Millisecond))
+	}
+	return nil
+}
+
+// Commands prints runs of the CLI, one per row.
+func (p *Printer) Commands(runs []store.Invocation) error {
+	if p.structured() {
+		return p.emit(runs)
+	}
+	if len(runs) == 0 {
+		fmt.Fprintln(p.w, "No commands recorded yet.")
+		return nil
+	}
+	p.header(80, "%-16s  %8s  %8s  %9s  %s\n", "Started", "Time", "Requests", "Data", "Command")
+	for _, r := range runs {
+		line := r.Command
+		if len(r.Args) > 0 {
+			line = strings.Join(r.Args, " ")
+		}
+		line = truncate(line, 40)
+		if r.Error != "" {
+			line += "  (failed)"
+		}
+		fmt.Fprintf(p.w, "%-16s  %8s  %8d  %9s  %s\n", formatTime(localTime(r.StartedAt)), runDuration(r.Duration()),
+			r.Requests, FormatSize(r.Bytes), line)
+	}
+	return nil
+}
+
+// runDuration formats how long a run took: tenths of a second under a
+// minute, else as an activity's time.
+func runDuration(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	}
+	return formatDuration(int(d.Seconds()))
+}
@@ -0,0 +1,84 @@
+package output
+
+// This file defines the output contract: the shape of the data the
+// structured formats carry, which scripts may rely on.
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// OutputVersion is the version of the output contract, the data that every
+// format but the table writes: JSON and NDJSON objects, CSV and Markdown
+// columns, and the fields templates see. It is a semantic version:
+//
+//   - major: a field or column is removed or renamed, or changes type or
+//     meaning;
+//   - minor: fields, columns, formats or commands are added;
+//   - patch: output is fixed to match what was documented.
+//
+// Tables are written for people and may change in any release.
+const OutputVersion = "1.0.0"
+
+// outputMajors are the major versions of the contract this build can write,
+// oldest first. There is only the one, so a pinned version is checked but
+// not otherwise used: when a major version is added, the previous one stays
+// here for as long as it is supported, and the pinned version has to reach
+// the renderers for them to write it.
+var outputMajors = []int{1} //nolint:gochecknoglobals
+
+// Version is an output contract version that a script pinned with
+// --output-version. The zero Version is the current one.
+type Version struct {
+	Major, Minor int
+}
+
+// ParseVersion parses a pinned output version: "1", "1.0" or "1.0.0", with
+// an optional leading "v". A patch number is accepted and ignored. It fails
+// when this build cannot write the version: a major it no longer (or does
+// not yet) support, or a minor newer than it knows.
+func ParseVersion(s string) (Version, error) {
+	parts := strings.Split(strings.TrimPrefix(strings.TrimSpace(s), "v"), ".")
+	if len(parts) > 3 {
+		return Version{}, fmt.Errorf("invalid output version %q: want MAJOR[.MINOR[.PATCH]], e.g. 1", s)
+	}
+	var n [3]int
+	for i, part := range parts {
+		v, err := strconv.Atoi(part)
+		if err != nil || v < 0 {
+			return Version{}, fmt.Errorf("invalid output version %q: want MAJOR[.MINOR[.PATCH]], e.g. 1", s)
+		}
+		n[i] = v
+	}
+	v := Version{Major: n[0], Minor: n[1]}
+	cur := currentVersion()
+	supported := false
+	for _, m := range outputMajors {
+		supported = supported || m == v.Major
+	}
+	switch {
+	case !supported:
+		return Version{}, fmt.Errorf("output version %d is not supported: this build writes %s (majors %s)",
+			v.Major, OutputVersion, joinInts(outputMajors))
+	case v.Major == cur.Major && v.Minor > cur.Minor:
+		return Version{}, fmt.Errorf("output version %d.%d is newer than this build's %s; upgrade stravacli",
+			v.Major, v.Minor, OutputVersion)
+	}
+	return v, nil
+}
+
+// currentVersion is OutputVersion without its patch number.
+func currentVersion() Version {
+	var v Version
+	fmt.Sscanf(OutputVersion, "%d.%d", &v.Major, &v.Minor)
+	return v
+}
+
+func joinInts(ns []int) string {
+	s := make([]string, len(ns))
+	for i, n := range ns {
+		s[i] = strconv.Itoa(n)
+	}
+	return strings.Join(s, ", ")
+}
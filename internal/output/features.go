@@ -0,0 +1,30 @@
+package output
+
+// This file contains the formatter for the API capability probe.
+
+import (
+	"fmt"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/store"
+)
+
+// Features prints which API capabilities the account and token can use,
+// with the commands each affects and why unavailable ones are.
+func (p *Printer) Features(f *store.Features) error {
+	if p.structured() {
+		return p.emit(f)
+	}
+	summit := "no"
+	if f.Summit {
+		summit = "yes"
+	}
+	fmt.Fprintf(p.w, "Athlete %d, subscription: %s, checked %s\n\n", f.AthleteID, summit, formatTime(localTime(f.CheckedAt)))
+	p.header(80, "%-19s  %-18s  %s\n", "Feature", "Status", "Used by")
+	for _, ft := range f.Features {
+		fmt.Fprintf(p.w, "%-19s  %-18s  %s\n", ft.Name, ft.Status, ft.Commands)
+		if ft.Detail != "" {
+			fmt.Fprintf(p.w, "%-19s  %-18s  ↳ %s\n", "", "", ft.Detail)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/inspect"
+)
+
+// Inspection prints the summary of a local activity file, then its gaps and
+// problems.
+func (p *Printer) Inspection(name string, s inspect.Summary) error {
+	if p.structured() {
+		return p.emit(s)
+	}
+	orNone := func(v string) string {
+		if v == "" {
+			return "(unknown)"
+		}
+		return v
+	}
+	fmt.Fprintf(p.w, "File:         %s (%s)\n", name, s.Format)
+	fmt.Fprintf(p.w, "Sport:        %s\n", orNone(s.Sport))
+	fmt.Fprintf(p.w, "Device:       %s\n", orNone(s.Device))
+	if !s.Start.IsZero() {
+		fmt.Fprintf(p.w, "Start:        %s\n", s.Start.Local().Format("2006-01-02 15:04:05"))
+	}
+	fmt.Fprintf(p.w, "Elapsed time: %s\n", formatDuration(s.ElapsedTime))
+	dist := p.distance(float32(s.Distance))
+	if s.DistanceSource != "" {
+		dist += " (" + s.DistanceSource + ")"
+	}
+	fmt.Fprintf(p.w, "Distance:     %s\n", dist)
+	fmt.Fprintf(p.w, "Records:      %d\n", s.Records)
+	fmt.Fprintf(p.w, "Sensors:      %s\n", orNone(strings.Join(s.Sensors, ", ")))
+
+	if len(s.Gaps) > 0 {
+		fmt.Fprintf(p.w, "\n%d gap(s):\n", len(s.Gaps))
+		for _, g := range s.Gaps {
+			fmt.Fprintf(p.w, "  %s  %s\n", g.Start.Local().Format("15:04:05"), formatDuration(g.Length))
+		}
+	}
+	if len(s.Problems) == 0 {
+		fmt.Fprintln(p.w, "\nNo problems found.")
+		return nil
+	}
+	fmt.Fprintf(p.w, "\n%d problem(s):\n", len(s.Problems))
+	for _, pr := range s.Problems {
+		fmt.Fprintf(p.w, "  - %s\n", pr)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package output
+
+// This file contains formatters for background jobs (internal/store).
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/store"
+)
+
+// Jobs prints background jobs, one per row.
+func (p *Printer) Jobs(jobs []store.Job) error {
+	if p.structured() {
+		return p.emit(jobs)
+	}
+	if len(jobs) == 0 {
+		fmt.Fprintln(p.w, "No jobs.")
+		return nil
+	}
+	p.header(80, "%-6s  %-9s  %-16s  %9s  %s\n", "ID", "State", "Started", "Duration", "Command")
+	for _, j := range jobs {
+		fmt.Fprintf(p.w, "%-6s  %-9s  %-16s  %9s  %s\n",
+			j.ID, j.State, formatTime(localTime(j.StartedAt)), jobDuration(j), truncate(j.Command(), 40))
+	}
+	return nil
+}
+
+// Job prints one background job.
+func (p *Printer) Job(j store.Job, logPath string) error {
+	if p.structured() {
+		return p.emit(j)
+	}
+	fmt.Fprintf(p.w, "ID:       %s\n", j.ID)
+	fmt.Fprintf(p.w, "Command:  %s\n", j.Command())
+	fmt.Fprintf(p.w, "State:    %s\n", j.State)
+	if j.PID != 0 {
+		fmt.Fprintf(p.w, "PID:      %d\n", j.PID)
+	}
+	fmt.Fprintf(p.w, "Started:  %s\n", formatTime(localTime(j.StartedAt)))
+	if !j.FinishedAt.IsZero() {
+		fmt.Fprintf(p.w, "Finished: %s\n", formatTime(localTime(j.FinishedAt)))
+	}
+	if d := jobDuration(j); d != "" {
+		fmt.Fprintf(p.w, "Duration: %s\n", d)
+	}
+	if j.Error != "" {
+		fmt.Fprintf(p.w, "Error:    %s\n", j.Error)
+	}
+	fmt.Fprintf(p.w, "Log:      %s\n", logPath)
+	return nil
+}
+
+// jobDuration is how long a job ran, or has been running; "" when that is
+// unknown (a job that died without recording when).
+func jobDuration(j store.Job) string {
+	end := j.FinishedAt
+	if end.IsZero() {
+		if j.State != store.JobRunning {
+			return ""
+		}
+		end = time.Now()
+	}
+	return formatDuration(int(end.Sub(j.StartedAt).Seconds()))
+}
+
+func localTime(t time.Time) *time.Time {
+	t = t.Local()
+	return &t
+}
@@ -0,0 +1,86 @@
+package output
+
+// This file contains the --jq filter, which runs a jq program over JSON
+// output without jq installed.
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/itchyny/gojq"
+)
+
+// Query is a compiled jq program (see ParseQuery).
+type Query struct {
+	code *gojq.Code
+}
+
+// ParseQuery compiles a jq program, e.g. '.[] | {id, name}'.
+func ParseQuery(src string) (*Query, error) {
+	parsed, err := gojq.Parse(src)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --jq: %w", err)
+	}
+	code, err := gojq.Compile(parsed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --jq: %w", err)
+	}
+	return &Query{code: code}, nil
+}
+
+// Filter runs q over each JSON value read from r, as jq does over its
+// input, and writes the results to w: strings as plain lines (like jq -r),
+// anything else as indented JSON. Numbers keep their precision, so large
+// IDs come out as they went in.
+func (q *Query) Filter(ctx context.Context, w io.Writer, r io.Reader) error {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	for {
+		var v any
+		if err := dec.Decode(&v); errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("--jq: the output is not JSON: %w", err)
+		}
+		iter := q.code.RunWithContext(ctx, v)
+		for {
+			res, ok := iter.Next()
+			if !ok {
+				break
+			}
+			if err, ok := res.(error); ok {
+				var halt *gojq.HaltError
+				if errors.As(err, &halt) && halt.Value() == nil {
+					return nil
+				}
+				return fmt.Errorf("--jq: %w", err)
+			}
+			if err := writeResult(w, res); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// writeResult writes one result of a jq program.
+func writeResult(w io.Writer, v any) error {
+	if s, ok := v.(string); ok {
+		_, err := fmt.Fprintln(w, s)
+		return err
+	}
+	data, err := gojq.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		return err
+	}
+	buf.WriteByte('\n')
+	_, err = w.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,107 @@
+package output
+
+// This file draws routes as terminal minimaps.
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/geo"
+)
+
+// Map dimensions in terminal cells. A braille dot is about as wide as it is
+// tall, so one scale serves both axes.
+const (
+	mapWidth  = 60
+	mapHeight = 20
+)
+
+// TrackMap is a route or activity track to draw.
+type TrackMap struct {
+	Name     string      `json:"name"`
+	Distance float64     `json:"distance"` // meters, as reported by the API
+	Points   []geo.Point `json:"points"`
+}
+
+// Map draws m's track as a braille minimap, north up, with S and E at the
+// start and end.
+func (p *Printer) Map(m TrackMap) error {
+	if p.structured() {
+		return p.emit(m)
+	}
+	if len(m.Points) < 2 {
+		fmt.Fprintln(p.w, "No GPS track to draw.")
+		return nil
+	}
+	if m.Name != "" {
+		fmt.Fprintln(p.w, m.Name)
+	}
+	for _, row := range brailleMap(m.Points, mapWidth, mapHeight) {
+		fmt.Fprintf(p.w, "│%s│\n", row)
+	}
+	fmt.Fprintf(p.w, "S start  E end  %s  north up\n", p.distance(float32(m.Distance)))
+	return nil
+}
+
+// brailleMap projects pts as plot.Map does, scaling longitudes by the cosine
+// of the mean latitude, and draws them as a connected line in height rows of
+// width braille characters.
+func brailleMap(pts []geo.Point, width, height int) []string {
+	cells := make([][]rune, height)
+	for i := range cells {
+		cells[i] = make([]rune, width)
+	}
+	dotsX, dotsY := width*2, height*4
+	sw, ne := geo.Bounds(pts)
+	kx := math.Cos((sw.Lat + ne.Lat) / 2 * math.Pi / 180)
+	spanX := (ne.Lng - sw.Lng) * kx
+	spanY := ne.Lat - sw.Lat
+	scale := math.Min(float64(dotsX-1)/math.Max(spanX, 1e-9), float64(dotsY-1)/math.Max(spanY, 1e-9))
+	offX := (float64(dotsX-1) - spanX*scale) / 2
+	offY := (float64(dotsY-1) - spanY*scale) / 2
+	project := func(pt geo.Point) (int, int) {
+		return int(math.Round(offX + (pt.Lng-sw.Lng)*kx*scale)),
+			int(math.Round(offY + (ne.Lat-pt.Lat)*scale))
+	}
+	set := func(x, y int) {
+		cells[y/4][x/2] |= brailleDots[x%2][y%4]
+	}
+
+	x0, y0 := project(pts[0])
+	set(x0, y0)
+	for _, pt := range pts[1:] {
+		x1, y1 := project(pt)
+		// Step along the longer axis so the line has no gaps.
+		n := max(abs(x1-x0), abs(y1-y0))
+		for i := 1; i <= n; i++ {
+			set(x0+(x1-x0)*i/n, y0+(y1-y0)*i/n)
+		}
+		x0, y0 = x1, y1
+	}
+
+	rows := make([][]rune, height)
+	for i, row := range cells {
+		rows[i] = make([]rune, width)
+		for j, c := range row {
+			rows[i][j] = 0x2800 + c
+		}
+	}
+	// The start is marked last, so a loop shows S.
+	ex, ey := project(pts[len(pts)-1])
+	rows[ey/4][ex/2] = 'E'
+	sx, sy := project(pts[0])
+	rows[sy/4][sx/2] = 'S'
+
+	out := make([]string, height)
+	for i, row := range rows {
+		out[i] = string(row)
+	}
+	return out
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,125 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"text/template"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/analysis"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/plot"
+)
+
+// Note is what an activity's Markdown note is made from: the activity, its
+// description and splits (from the detail endpoint), and its Strava URL.
+type Note struct {
+	analysis.Activity
+	Description string
+	Splits      []Split
+	URL         string
+}
+
+// Split is one kilometer or mile of a Note's activity, as the API reports it.
+type Split struct {
+	Split               int     `json:"split"`
+	Distance            float64 `json:"distance"`
+	ElapsedTime         int     `json:"elapsed_time"`
+	MovingTime          int     `json:"moving_time"`
+	ElevationDifference float64 `json:"elevation_difference"`
+	AverageSpeed        float64 `json:"average_speed"`
+	AverageHeartrate    float64 `json:"average_heartrate"`
+	PaceZone            int     `json:"pace_zone"`
+}
+
+// DefaultNoteTemplate writes YAML front matter for Obsidian, Logseq and
+// the like, then the description, a summary table and the splits.
+const DefaultNoteTemplate = `---
+title: {{yaml .Name}}
+date: {{date .StartDateLocal}}
+sport: {{.SportType}}
+strava_id: {{.ID}}
+distance_km: {{km .Distance}}
+moving_time_s: {{.MovingTime}}
+elevation_gain_m: {{printf "%.0f" .TotalElevationGain}}
+{{- if .AverageHeartrate}}
+average_heartrate: {{printf "%.0f" .AverageHeartrate}}
+{{- end}}
+{{- if .AverageWatts}}
+average_watts: {{printf "%.0f" .AverageWatts}}
+{{- end}}
+{{- if .SufferScore}}
+relative_effort: {{printf "%.0f" .SufferScore}}
+{{- end}}
+tags: [strava, {{lower .SportType}}]
+---
+
+# {{.Name}}
+
+{{if .Description}}{{.Description}}
+
+{{end}}| | |
+|---|---|
+| Date | {{.StartDateLocal.Format "Mon 2 Jan 2006 15:04"}} |
+{{- range stats .Activity}}
+| {{.Label}} | {{.Value}} |
+{{- end}}
+{{- if .AverageHeartrate}}
+| Avg HR | {{printf "%.0f" .AverageHeartrate}} bpm |
+{{- end}}
+{{- if .AverageWatts}}
+| Avg power | {{printf "%.0f" .AverageWatts}} W |
+{{- end}}
+{{- if .Splits}}
+
+## Splits
+
+| Split | Distance | Time | Pace | Elevation |
+|---:|---:|---:|---:|---:|
+{{- range .Splits}}
+| {{.Split}} | {{distance .Distance}} | {{duration .MovingTime}} | {{pace $.SportType .AverageSpeed}} | {{elevation .ElevationDifference}} |
+{{- end}}
+{{- end}}
+
+[View on Strava]({{.URL}})
+`
+
+// NoteTemplate parses the template of an activity's Markdown note (text,
+// or DefaultNoteTemplate when empty), executed with a Note. Besides the
+// --template functions it has these, in the printer's units: distance,
+// elevation, pace (of a sport type and speed: pace or speed, whichever the
+// sport reads better in), stats (CardStats), and yaml, which quotes a
+// string for front matter.
+func (p *Printer) NoteTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		text = DefaultNoteTemplate
+	}
+	funcs := maps.Clone(templateFuncs)
+	maps.Copy(funcs, template.FuncMap{
+		"distance":  func(meters float64) string { return p.distance(float32(meters)) },
+		"elevation": func(meters float64) string { return p.elevation(float32(meters)) },
+		"pace":      p.sportPace,
+		"stats":     func(a analysis.Activity) []plot.Stat { return p.CardStats(a) },
+		"yaml": func(s string) string {
+			// A JSON string is a valid YAML flow scalar.
+			b, _ := json.Marshal(s)
+			return string(b)
+		},
+	})
+	t, err := template.New("note").Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid note template: %w", err)
+	}
+	return t, nil
+}
+
+// sportPace formats a speed as the sport reads it best: time per 100 meters
+// for swims, per kilometer or mile for runs and walks, else as a speed.
+func (p *Printer) sportPace(sport string, ms float64) string {
+	switch {
+	case sport == "Swim":
+		return p.swimPace(ms)
+	case analysis.IsFootSport(sport):
+		return p.pace(float32(ms))
+	}
+	return p.speed(float32(ms))
+}
@@ -0,0 +1,48 @@
+package output
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Meta prints local activity metadata, keyed by activity ID as in the store,
+// one field per row.
+func (p *Printer) Meta(activities map[string]map[string]string) error {
+	if p.structured() {
+		return p.emit(activities)
+	}
+	ids := slices.Collect(maps.Keys(activities))
+	if !slices.ContainsFunc(ids, func(id string) bool { return len(activities[id]) > 0 }) {
+		fmt.Fprintln(p.w, "No fields. Add some with: strava meta set <id> key=value")
+		return nil
+	}
+	slices.SortFunc(ids, func(a, b string) int {
+		// Numeric order: longer IDs are larger.
+		if len(a) != len(b) {
+			return len(a) - len(b)
+		}
+		return strings.Compare(a, b)
+	})
+	p.header(60, "%-14s  %-20s  %s\n", "Activity", "Key", "Value")
+	for _, id := range ids {
+		fields := activities[id]
+		for _, k := range slices.Sorted(maps.Keys(fields)) {
+			fmt.Fprintf(p.w, "%-14s  %-20s  %s\n", id, k, fields[k])
+		}
+	}
+	return nil
+}
+
+// metaFields formats metadata for a table cell as "k=v, k=v".
+func metaFields(m map[string]string) string {
+	if len(m) == 0 {
+		return "-"
+	}
+	parts := make([]string, 0, len(m))
+	for _, k := range slices.Sorted(maps.Keys(m)) {
+		parts = append(parts, k+"="+m[k])
+	}
+	return strings.Join(parts, ", ")
+}
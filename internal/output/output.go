@@ -6,21 +6,81 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"strconv"
 	"strings"
+	"text/template"
 	"time"
 
+	"github.com/Brainsoft-Raxat/strava-cli/internal/analysis"
 	"github.com/Brainsoft-Raxat/strava-cli/internal/client"
 )
 
+// Units selects the measurement system used for human-readable output.
+type Units string
+
+const (
+	Metric   Units = "metric"
+	Imperial Units = "imperial"
+)
+
+// ParseUnits validates a units name. Strava's measurement_preference values
+// ("meters", "feet") are accepted as aliases.
+func ParseUnits(s string) (Units, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "metric", "meters":
+		return Metric, nil
+	case "imperial", "feet":
+		return Imperial, nil
+	}
+	return "", fmt.Errorf("invalid units %q: must be metric or imperial", s)
+}
+
+// ParseDistance parses a distance such as "10km", "6.2 mi", "800m" or "500ft"
+// into meters. A bare number is in units (kilometers or miles).
+func ParseDistance(s string, units Units) (float64, error) {
+	t := strings.ToLower(strings.TrimSpace(s))
+	num := strings.TrimRightFunc(t, func(r rune) bool { return r >= 'a' && r <= 'z' || r == ' ' })
+	unit := strings.TrimSpace(t[len(num):])
+	v, err := strconv.ParseFloat(num, 64)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("invalid distance %q: want e.g. 10km, 5mi or 800m", s)
+	}
+	if unit == "" {
+		unit = "km"
+		if units == Imperial {
+			unit = "mi"
+		}
+	}
+	switch unit {
+	case "m":
+		return v, nil
+	case "km":
+		return v * 1000, nil
+	case "mi":
+		return v * metersPerMile, nil
+	case "ft":
+		return v / feetPerMeter, nil
+	}
+	return 0, fmt.Errorf("invalid distance %q: unit must be m, km, mi or ft", s)
+}
+
 // Printer writes formatted output to a writer.
 type Printer struct {
-	w    io.Writer
-	JSON bool
+	w        io.Writer
+	JSON     bool
+	Units    Units              // defaults to Metric when empty
+	Template *template.Template // when set, replaces tables and JSON (see ParseTemplate)
+	Format   string             // a registered output format; "" is the table, unless JSON or Template is set
+	Columns  []string           // list table columns; nil means the list's defaults
+	Sort     string             // list order as "column[:asc|desc]"; "" keeps the API's
+	Color    bool               // style tables with ANSI colors (headings, records, improvements)
+
+	Thresholds analysis.Thresholds // FTP and max heart rate, for the if and tss columns
 }
 
 // New creates a Printer that writes to w.
 func New(w io.Writer, jsonMode bool) *Printer {
-	return &Printer{w: w, JSON: jsonMode}
+	return &Printer{w: w, JSON: jsonMode, Units: Metric}
 }
 
 // Athlete prints the authenticated athlete.
@@ -28,8 +88,8 @@ func (p *Printer) Athlete(a *client.GetLoggedInAthleteResponse) error {
 	if a.JSON200 == nil {
 		return fmt.Errorf("unexpected empty response")
 	}
-	if p.JSON {
-		return printJSON(p.w, a.JSON200)
+	if p.structured() {
+		return p.emit(a.JSON200)
 	}
 	d := a.JSON200
 	fmt.Fprintf(p.w, "Name:      %s %s\n", strVal(d.Firstname), strVal(d.Lastname))
@@ -48,32 +108,11 @@ func (p *Printer) Activities(acts *client.GetLoggedInAthleteActivitiesResponse)
 	if acts.JSON200 == nil {
 		return fmt.Errorf("unexpected empty response")
 	}
-	if p.JSON {
-		return printJSON(p.w, acts.JSON200)
-	}
-	list := *acts.JSON200
-	if len(list) == 0 {
+	if len(*acts.JSON200) == 0 && !p.structured() {
 		fmt.Fprintln(p.w, "No activities found.")
 		return nil
 	}
-	fmt.Fprintf(p.w, "%-12s  %-30s  %-18s  %-9s  %-10s  %s\n",
-		"ID", "Name", "Sport", "Distance", "Time", "Date")
-	fmt.Fprintln(p.w, strings.Repeat("─", 105))
-	for _, a := range list {
-		sport := ""
-		if a.SportType != nil {
-			sport = string(*a.SportType)
-		}
-		fmt.Fprintf(p.w, "%-12d  %-30s  %-18s  %-9s  %-10s  %s\n",
-			int64Val(a.Id),
-			truncate(strVal(a.Name), 30),
-			truncate(sport, 18),
-			formatDistance(float32Val(a.Distance)),
-			formatDuration(intVal(a.MovingTime)),
-			formatTime(a.StartDateLocal),
-		)
-	}
-	return nil
+	return listTable(p, acts.Body, *acts.JSON200, activityColumns, activityDefaults)
 }
 
 // Activity prints a single detailed activity.
@@ -81,8 +120,8 @@ func (p *Printer) Activity(a *client.GetActivityByIdResponse) error {
 	if a.JSON200 == nil {
 		return fmt.Errorf("unexpected empty response")
 	}
-	if p.JSON {
-		return printJSON(p.w, a.JSON200)
+	if p.structured() {
+		return p.emit(a.JSON200)
 	}
 	d := a.JSON200
 	sport := ""
@@ -93,11 +132,15 @@ func (p *Printer) Activity(a *client.GetActivityByIdResponse) error {
 	fmt.Fprintf(p.w, "Name:         %s\n", strVal(d.Name))
 	fmt.Fprintf(p.w, "Sport:        %s\n", sport)
 	fmt.Fprintf(p.w, "Date:         %s\n", formatTime(d.StartDateLocal))
-	fmt.Fprintf(p.w, "Distance:     %s\n", formatDistance(float32Val(d.Distance)))
+	fmt.Fprintf(p.w, "Distance:     %s\n", p.distance(float32Val(d.Distance)))
 	fmt.Fprintf(p.w, "Moving time:  %s\n", formatDuration(intVal(d.MovingTime)))
 	fmt.Fprintf(p.w, "Elapsed time: %s\n", formatDuration(intVal(d.ElapsedTime)))
-	fmt.Fprintf(p.w, "Elevation:    %.0f m\n", float32Val(d.TotalElevationGain))
-	fmt.Fprintf(p.w, "Avg speed:    %.1f km/h\n", msToKmh(float32Val(d.AverageSpeed)))
+	fmt.Fprintf(p.w, "Elevation:    %s\n", p.elevation(float32Val(d.TotalElevationGain)))
+	if showsPace(sport) {
+		fmt.Fprintf(p.w, "Avg pace:     %s\n", p.sportPace(sport, float64(float32Val(d.AverageSpeed))))
+	} else {
+		fmt.Fprintf(p.w, "Avg speed:    %s\n", p.speed(float32Val(d.AverageSpeed)))
+	}
 	if d.AverageWatts != nil {
 		fmt.Fprintf(p.w, "Avg power:    %.0f W\n", float32Val(d.AverageWatts))
 	}
@@ -110,6 +153,41 @@ func (p *Printer) Activity(a *client.GetActivityByIdResponse) error {
 
 // --- helpers ---
 
+// PrintJSON writes v as indented JSON, the same encoding the printers use in
+// --json mode. Commands with ad-hoc result types use it directly.
+func PrintJSON(w io.Writer, v any) error { return printJSON(w, v) }
+
+// format returns the output format in effect: a template or JSON when set,
+// the table by default.
+func (p *Printer) format() string {
+	switch {
+	case p.Template != nil:
+		return "template"
+	case p.JSON:
+		return "json"
+	case p.Format == "":
+		return TableFormat
+	}
+	return strings.ToLower(p.Format)
+}
+
+// structured reports whether output is data (JSON, --template or another
+// registered format) rather than a human-readable table.
+func (p *Printer) structured() bool { return p.format() != TableFormat }
+
+// Streaming reports whether the output format can be written a piece at a
+// time, so a long list is best printed page by page as it is fetched.
+func (p *Printer) Streaming() bool { return p.format() == NDJSONFormat }
+
+// emit writes v with the renderer of the output format.
+func (p *Printer) emit(v any) error {
+	r, ok := LookupRenderer(p.format())
+	if !ok {
+		return fmt.Errorf("unknown output format %q", p.format())
+	}
+	return r.Render(p.w, v, RenderOptions{Template: p.Template, Units: p.Units})
+}
+
 func printJSON(w io.Writer, v any) error {
 	enc := json.NewEncoder(w)
 	enc.SetIndent("", "  ")
@@ -152,10 +230,11 @@ func boolVal(v *bool) bool {
 }
 
 func truncate(s string, n int) string {
-	if len(s) <= n {
+	r := []rune(s)
+	if len(r) <= n {
 		return s
 	}
-	return s[:n-1] + "…"
+	return string(r[:n-1]) + "…"
 }
 
 // FormatDistance converts meters to a human-readable string (exported for tests).
@@ -168,6 +247,59 @@ func FormatDuration(seconds int) string {
 	return formatDuration(seconds)
 }
 
+const (
+	metersPerMile = 1609.344
+	feetPerMeter  = 3.28084
+)
+
+// distance formats meters in the printer's units.
+func (p *Printer) distance(meters float32) string {
+	if p.Units != Imperial {
+		return formatDistance(meters)
+	}
+	if meters >= metersPerMile/10 {
+		return fmt.Sprintf("%.2f mi", meters/metersPerMile)
+	}
+	return fmt.Sprintf("%.0f ft", meters*feetPerMeter)
+}
+
+// elevation formats a height or climb in the printer's units.
+func (p *Printer) elevation(meters float32) string {
+	if p.Units == Imperial {
+		return fmt.Sprintf("%.0f ft", meters*feetPerMeter)
+	}
+	return fmt.Sprintf("%.0f m", meters)
+}
+
+// speed formats meters per second in the printer's units.
+func (p *Printer) speed(ms float32) string {
+	if p.Units == Imperial {
+		return fmt.Sprintf("%.1f mph", ms*3600/metersPerMile)
+	}
+	return fmt.Sprintf("%.1f km/h", msToKmh(ms))
+}
+
+// pace formats meters per second as time per kilometer or mile.
+func (p *Printer) pace(ms float32) string {
+	if ms <= 0 {
+		return "-"
+	}
+	unit, meters := "/km", float32(1000)
+	if p.Units == Imperial {
+		unit, meters = "/mi", metersPerMile
+	}
+	s := int(meters/ms + 0.5)
+	return fmt.Sprintf("%d:%02d%s", s/60, s%60, unit)
+}
+
+// temperature formats degrees Celsius in the printer's units.
+func (p *Printer) temperature(c float64) string {
+	if p.Units == Imperial {
+		return fmt.Sprintf("%.0f°F", c*9/5+32)
+	}
+	return fmt.Sprintf("%.0f°C", c)
+}
+
 func formatDistance(meters float32) string {
 	if meters >= 1000 {
 		return fmt.Sprintf("%.2f km", meters/1000)
@@ -186,6 +318,21 @@ func formatDuration(seconds int) string {
 	return fmt.Sprintf("%dm%02ds", m, s)
 }
 
+// FormatSize renders a byte count with a binary unit: 512 B, 1.5 KB, 3.2 MB.
+func FormatSize(n int64) string {
+	if n < 1<<10 {
+		return fmt.Sprintf("%d B", n)
+	}
+	v, unit := float64(n)/(1<<10), "KB"
+	for _, u := range []string{"MB", "GB"} {
+		if v < 1<<10 {
+			break
+		}
+		v, unit = v/(1<<10), u
+	}
+	return fmt.Sprintf("%.1f %s", v, unit)
+}
+
 func formatTime(t *time.Time) string {
 	if t == nil {
 		return ""
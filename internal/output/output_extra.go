@@ -15,8 +15,8 @@ func (p *Printer) Stats(r *client.GetStatsResponse) error {
 	if r.JSON200 == nil {
 		return fmt.Errorf("unexpected empty response")
 	}
-	if p.JSON {
-		return printJSON(p.w, r.JSON200)
+	if p.structured() {
+		return p.emit(r.JSON200)
 	}
 	d := r.JSON200
 	type totals struct {
@@ -46,29 +46,28 @@ func (p *Printer) Stats(r *client.GetStatsResponse) error {
 		}},
 	}
 	for _, sec := range sections {
-		fmt.Fprintf(p.w, "\n%s\n", sec.heading)
-		fmt.Fprintln(p.w, strings.Repeat("─", 70))
+		p.header(70, "\n%s\n", sec.heading)
 		fmt.Fprintf(p.w, "  %-10s  %6s  %-10s  %-10s  %-10s\n",
 			"Sport", "Count", "Distance", "Moving", "Elevation")
 		for _, row := range sec.rows {
 			if row.v == nil {
 				continue
 			}
-			fmt.Fprintf(p.w, "  %-10s  %6d  %-10s  %-10s  %.0f m\n",
+			fmt.Fprintf(p.w, "  %-10s  %6d  %-10s  %-10s  %s\n",
 				row.label,
 				intVal(row.v.Count),
-				formatDistance(float32Val(row.v.Distance)),
+				p.distance(float32Val(row.v.Distance)),
 				formatDuration(intVal(row.v.MovingTime)),
-				float32Val(row.v.ElevationGain),
+				p.elevation(float32Val(row.v.ElevationGain)),
 			)
 		}
 	}
 	if d.BiggestRideDistance != nil {
 		fmt.Fprintf(p.w, "\nBiggest ride distance: %s\n",
-			formatDistance(float32(*d.BiggestRideDistance)))
+			p.distance(float32(*d.BiggestRideDistance)))
 	}
 	if d.BiggestClimbElevationGain != nil {
-		fmt.Fprintf(p.w, "Biggest climb:         %.0f m\n", *d.BiggestClimbElevationGain)
+		fmt.Fprintf(p.w, "Biggest climb:         %s\n", p.elevation(float32(*d.BiggestClimbElevationGain)))
 	}
 	return nil
 }
@@ -78,13 +77,12 @@ func (p *Printer) AthleteZones(r *client.GetLoggedInAthleteZonesResponse) error
 	if r.JSON200 == nil {
 		return fmt.Errorf("unexpected empty response")
 	}
-	if p.JSON {
-		return printJSON(p.w, r.JSON200)
+	if p.structured() {
+		return p.emit(r.JSON200)
 	}
 	d := r.JSON200
 	if d.HeartRate != nil && d.HeartRate.Zones != nil {
-		fmt.Fprintln(p.w, "Heart Rate Zones")
-		fmt.Fprintln(p.w, strings.Repeat("─", 35))
+		p.header(35, "Heart Rate Zones")
 		for i, z := range *d.HeartRate.Zones {
 			min := intVal(z.Min)
 			max := intVal(z.Max)
@@ -96,8 +94,7 @@ func (p *Printer) AthleteZones(r *client.GetLoggedInAthleteZonesResponse) error
 		}
 	}
 	if d.Power != nil && d.Power.Zones != nil {
-		fmt.Fprintln(p.w, "\nPower Zones")
-		fmt.Fprintln(p.w, strings.Repeat("─", 35))
+		p.header(35, "\nPower Zones")
 		for i, z := range *d.Power.Zones {
 			min := intVal(z.Min)
 			max := intVal(z.Max)
@@ -111,41 +108,91 @@ func (p *Printer) AthleteZones(r *client.GetLoggedInAthleteZonesResponse) error
 	return nil
 }
 
-// Laps prints laps for an activity.
-func (p *Printer) Laps(r *client.GetLapsByActivityIdResponse) error {
+// Laps prints laps for an activity of the sport type sport, with their
+// speed as a pace when the sport reads it so. An empty sport shows speeds.
+func (p *Printer) Laps(r *client.GetLapsByActivityIdResponse, sport string) error {
 	if r.JSON200 == nil {
 		return fmt.Errorf("unexpected empty response")
 	}
-	if p.JSON {
-		return printJSON(p.w, r.JSON200)
+	if p.structured() {
+		return p.emit(r.JSON200)
 	}
 	laps := *r.JSON200
 	if len(laps) == 0 {
 		fmt.Fprintln(p.w, "No laps recorded.")
 		return nil
 	}
-	fmt.Fprintf(p.w, "%-4s  %-10s  %-10s  %-10s  %s\n",
-		"Lap", "Distance", "Time", "Avg Speed", "Start")
-	fmt.Fprintln(p.w, strings.Repeat("─", 65))
-	for _, lap := range laps {
-		fmt.Fprintf(p.w, "%-4d  %-10s  %-10s  %-10s  %s\n",
+	speedName := "Avg Speed"
+	if showsPace(sport) {
+		speedName = "Avg Pace"
+	}
+	p.header(65, "%-4s  %-10s  %-10s  %-10s  %s\n",
+		"Lap", "Distance", "Time", speedName, "Start")
+	for i, lap := range laps {
+		// A lap faster than the one before is a negative split.
+		speed := fmt.Sprintf("%-10s", p.sportPace(sport, float64(float32Val(lap.AverageSpeed))))
+		if i > 0 && float32Val(lap.AverageSpeed) > float32Val(laps[i-1].AverageSpeed) {
+			speed = p.good(speed)
+		}
+		fmt.Fprintf(p.w, "%-4d  %-10s  %-10s  %s  %s\n",
 			intVal(lap.LapIndex),
-			formatDistance(float32Val(lap.Distance)),
+			p.distance(float32Val(lap.Distance)),
 			formatDuration(intVal(lap.MovingTime)),
-			fmt.Sprintf("%.1f km/h", msToKmh(float32Val(lap.AverageSpeed))),
+			speed,
 			formatTime(lap.StartDateLocal),
 		)
 	}
 	return nil
 }
 
+// ActivitySegments prints the segment efforts contained in a detailed activity.
+func (p *Printer) ActivitySegments(r *client.GetActivityByIdResponse) error {
+	if r.JSON200 == nil {
+		return fmt.Errorf("unexpected empty response")
+	}
+	if p.structured() {
+		return p.emit(r.JSON200.SegmentEfforts)
+	}
+	if r.JSON200.SegmentEfforts == nil || len(*r.JSON200.SegmentEfforts) == 0 {
+		fmt.Fprintln(p.w, "No segment efforts.")
+		return nil
+	}
+	p.header(85, "%-12s  %-35s  %-10s  %-10s  %-4s  %s\n",
+		"Effort ID", "Segment", "Distance", "Time", "PR", "KOM")
+	for _, e := range *r.JSON200.SegmentEfforts {
+		name := strVal(e.Name)
+		if e.Segment != nil && e.Segment.Name != nil {
+			name = *e.Segment.Name
+		}
+		pr, kom := fmt.Sprintf("%-4s", ""), ""
+		if e.PrRank != nil {
+			pr = fmt.Sprintf("%-4s", fmt.Sprintf("#%d", *e.PrRank))
+			if *e.PrRank == 1 {
+				pr = p.highlight(pr)
+			}
+		}
+		if e.KomRank != nil {
+			kom = fmt.Sprintf("#%d", *e.KomRank)
+		}
+		fmt.Fprintf(p.w, "%-12d  %-35s  %-10s  %-10s  %s  %s\n",
+			int64Val(e.Id),
+			truncate(name, 35),
+			p.distance(float32Val(e.Distance)),
+			formatDuration(intVal(e.ElapsedTime)),
+			pr,
+			kom,
+		)
+	}
+	return nil
+}
+
 // ActivityZones prints HR/power zones for an activity.
 func (p *Printer) ActivityZones(r *client.GetZonesByActivityIdResponse) error {
 	if r.JSON200 == nil {
 		return fmt.Errorf("unexpected empty response")
 	}
-	if p.JSON {
-		return printJSON(p.w, r.JSON200)
+	if p.structured() {
+		return p.emit(r.JSON200)
 	}
 	zones := *r.JSON200
 	if len(zones) == 0 {
@@ -161,8 +208,7 @@ func (p *Printer) ActivityZones(r *client.GetZonesByActivityIdResponse) error {
 		if z.Score != nil {
 			score = fmt.Sprintf("  score: %d", *z.Score)
 		}
-		fmt.Fprintf(p.w, "%s%s\n", strings.Title(typ), score)
-		fmt.Fprintln(p.w, strings.Repeat("─", 40))
+		p.header(40, "%s%s\n", strings.Title(typ), score)
 		if z.DistributionBuckets != nil {
 			for _, b := range *z.DistributionBuckets {
 				fmt.Fprintf(p.w, "  %d–%d bpm: %d s\n",
@@ -179,8 +225,8 @@ func (p *Printer) Comments(r *client.GetCommentsByActivityIdResponse) error {
 	if r.JSON200 == nil {
 		return fmt.Errorf("unexpected empty response")
 	}
-	if p.JSON {
-		return printJSON(p.w, r.JSON200)
+	if p.structured() {
+		return p.emit(r.JSON200)
 	}
 	comments := *r.JSON200
 	if len(comments) == 0 {
@@ -207,8 +253,8 @@ func (p *Printer) Kudos(r *client.GetKudoersByActivityIdResponse) error {
 	if r.JSON200 == nil {
 		return fmt.Errorf("unexpected empty response")
 	}
-	if p.JSON {
-		return printJSON(p.w, r.JSON200)
+	if p.structured() {
+		return p.emit(r.JSON200)
 	}
 	kudoers := *r.JSON200
 	if len(kudoers) == 0 {
@@ -228,8 +274,8 @@ func (p *Printer) Streams(r *client.GetActivityStreamsResponse) error {
 	if r.JSON200 == nil {
 		return fmt.Errorf("unexpected empty response")
 	}
-	if p.JSON {
-		return printJSON(p.w, r.JSON200)
+	if p.structured() {
+		return p.emit(r.JSON200)
 	}
 	d := r.JSON200
 	// Show a summary of available streams with their lengths.
@@ -276,12 +322,11 @@ func (p *Printer) Streams(r *client.GetActivityStreamsResponse) error {
 		fmt.Fprintln(p.w, "No stream data available.")
 		return nil
 	}
-	fmt.Fprintln(p.w, "Available streams:")
-	fmt.Fprintln(p.w, strings.Repeat("─", 35))
+	p.header(35, "Available streams:")
 	for _, s := range available {
 		fmt.Fprintf(p.w, "  %-20s  %d data points\n", s.name, s.n)
 	}
-	fmt.Fprintln(p.w, "\nUse --json to get the full data.")
+	fmt.Fprintln(p.w, "\nUse --json to get the full data, or draw one with: strava activities chart <id> --metric <name>")
 	return nil
 }
 
@@ -290,26 +335,11 @@ func (p *Printer) Clubs(r *client.GetLoggedInAthleteClubsResponse) error {
 	if r.JSON200 == nil {
 		return fmt.Errorf("unexpected empty response")
 	}
-	if p.JSON {
-		return printJSON(p.w, r.JSON200)
-	}
-	clubs := *r.JSON200
-	if len(clubs) == 0 {
+	if len(*r.JSON200) == 0 && !p.structured() {
 		fmt.Fprintln(p.w, "No clubs.")
 		return nil
 	}
-	fmt.Fprintf(p.w, "%-12s  %-35s  %7s  %s\n", "ID", "Name", "Members", "Location")
-	fmt.Fprintln(p.w, strings.Repeat("─", 80))
-	for _, c := range clubs {
-		loc := strings.TrimRight(strVal(c.City)+", "+strVal(c.Country), ", ")
-		fmt.Fprintf(p.w, "%-12d  %-35s  %7d  %s\n",
-			int64Val(c.Id),
-			truncate(strVal(c.Name), 35),
-			intVal(c.MemberCount),
-			loc,
-		)
-	}
-	return nil
+	return listTable(p, r.Body, *r.JSON200, clubColumns, clubDefaults)
 }
 
 // Club prints a single club's detail.
@@ -317,8 +347,8 @@ func (p *Printer) Club(r *client.GetClubByIdResponse) error {
 	if r.JSON200 == nil {
 		return fmt.Errorf("unexpected empty response")
 	}
-	if p.JSON {
-		return printJSON(p.w, r.JSON200)
+	if p.structured() {
+		return p.emit(r.JSON200)
 	}
 	d := r.JSON200
 	fmt.Fprintf(p.w, "ID:       %d\n", int64Val(d.Id))
@@ -337,59 +367,109 @@ func (p *Printer) ClubMembers(r *client.GetClubMembersByIdResponse) error {
 	if r.JSON200 == nil {
 		return fmt.Errorf("unexpected empty response")
 	}
-	if p.JSON {
-		return printJSON(p.w, r.JSON200)
-	}
-	members := *r.JSON200
-	if len(members) == 0 {
+	if len(*r.JSON200) == 0 && !p.structured() {
 		fmt.Fprintln(p.w, "No members.")
 		return nil
 	}
-	fmt.Fprintf(p.w, "%-30s  %s\n", "Name", "Role")
-	fmt.Fprintln(p.w, strings.Repeat("─", 45))
-	for _, m := range members {
-		role := strVal(m.Member)
-		if boolVal(m.Admin) {
-			role = "admin"
-		}
-		if boolVal(m.Owner) {
-			role = "owner"
-		}
-		fmt.Fprintf(p.w, "%-30s  %s\n",
-			truncate(strVal(m.Firstname)+" "+strVal(m.Lastname), 30),
-			role,
-		)
-	}
-	return nil
+	return listTable(p, r.Body, *r.JSON200, memberColumns, memberDefaults)
 }
 
-// ClubActivities prints recent activities from a club.
-// Note: the API only returns athlete ID (not name) for privacy reasons.
+// ClubActivities prints recent activities from a club. The athlete column
+// comes from the response body, since the generated type drops the name the
+// feed gives (first name and last initial).
 func (p *Printer) ClubActivities(r *client.GetClubActivitiesByIdResponse) error {
 	if r.JSON200 == nil {
 		return fmt.Errorf("unexpected empty response")
 	}
-	if p.JSON {
-		return printJSON(p.w, r.JSON200)
+	if len(*r.JSON200) == 0 && !p.structured() {
+		fmt.Fprintln(p.w, "No recent activities.")
+		return nil
 	}
-	acts := *r.JSON200
-	if len(acts) == 0 {
+	return listTable(p, r.Body, *r.JSON200, clubActivityColumns, clubActivityDefaults)
+}
+
+// ClubFeedEntry is a club feed activity whose athlete has been resolved
+// against the club roster.
+type ClubFeedEntry struct {
+	Athlete            string  `json:"athlete"`
+	Mine               bool    `json:"mine"`
+	Name               string  `json:"name"`
+	SportType          string  `json:"sport_type"`
+	Distance           float32 `json:"distance"`
+	MovingTime         int     `json:"moving_time"`
+	ElapsedTime        int     `json:"elapsed_time"`
+	TotalElevationGain float32 `json:"total_elevation_gain"`
+}
+
+// ClubFeed prints an enriched club activity feed. Your own activities are
+// marked with "*".
+func (p *Printer) ClubFeed(entries []ClubFeedEntry) error {
+	if p.structured() {
+		return p.emit(entries)
+	}
+	if len(entries) == 0 {
 		fmt.Fprintln(p.w, "No recent activities.")
 		return nil
 	}
-	fmt.Fprintf(p.w, "%-30s  %-16s  %-10s  %s\n",
-		"Name", "Sport", "Distance", "Time")
-	fmt.Fprintln(p.w, strings.Repeat("─", 75))
-	for _, a := range acts {
-		sport := ""
-		if a.SportType != nil {
-			sport = string(*a.SportType)
+	p.header(100, "   %-20s  %-30s  %-16s  %-10s  %s\n",
+		"Athlete", "Name", "Sport", "Distance", "Time")
+	for _, e := range entries {
+		mark := " "
+		if e.Mine {
+			mark = "*"
+		}
+		fmt.Fprintf(p.w, "%s  %-20s  %-30s  %-16s  %-10s  %s\n",
+			mark,
+			truncate(e.Athlete, 20),
+			truncate(e.Name, 30),
+			truncate(e.SportType, 16),
+			p.distance(e.Distance),
+			formatDuration(e.MovingTime),
+		)
+	}
+	return nil
+}
+
+// ClubEvent is a club's group event, as the undocumented group_events
+// endpoints return it.
+type ClubEvent struct {
+	ID                  int64       `json:"id"`
+	Title               string      `json:"title"`
+	Description         string      `json:"description"`
+	ActivityType        string      `json:"activity_type"`
+	Address             string      `json:"address"`
+	RouteID             int64       `json:"route_id,omitempty"`
+	Private             bool        `json:"private"`
+	WomenOnly           bool        `json:"women_only"`
+	Joined              bool        `json:"joined"`
+	UpcomingOccurrences []time.Time `json:"upcoming_occurrences"`
+}
+
+// ClubEvents prints a club's upcoming group events, soonest first, with the
+// ones you joined marked with "*".
+func (p *Printer) ClubEvents(events []ClubEvent) error {
+	if p.structured() {
+		return p.emit(events)
+	}
+	if len(events) == 0 {
+		fmt.Fprintln(p.w, "No upcoming events.")
+		return nil
+	}
+	p.header(100, "   %-12s  %-17s  %-10s  %-32s  %s\n", "ID", "Next", "Type", "Title", "Where")
+	for _, e := range events {
+		mark := " "
+		if e.Joined {
+			mark = "*"
+		}
+		next := "-"
+		if len(e.UpcomingOccurrences) > 0 {
+			next = e.UpcomingOccurrences[0].Local().Format("Mon 2 Jan 15:04")
 		}
-		fmt.Fprintf(p.w, "%-30s  %-16s  %-10s  %s\n",
-			truncate(strVal(a.Name), 30),
-			truncate(sport, 16),
-			formatDistance(float32Val(a.Distance)),
-			formatDuration(intVal(a.MovingTime)),
+		fmt.Fprintf(p.w, "%s  %-12d  %-17s  %-10s  %-32s  %s\n",
+			mark, e.ID, next,
+			truncate(e.ActivityType, 10),
+			truncate(e.Title, 32),
+			truncate(e.Address, 30),
 		)
 	}
 	return nil
@@ -400,15 +480,15 @@ func (p *Printer) Gear(r *client.GetGearByIdResponse) error {
 	if r.JSON200 == nil {
 		return fmt.Errorf("unexpected empty response")
 	}
-	if p.JSON {
-		return printJSON(p.w, r.JSON200)
+	if p.structured() {
+		return p.emit(r.JSON200)
 	}
 	d := r.JSON200
 	fmt.Fprintf(p.w, "ID:        %s\n", strVal(d.Id))
 	fmt.Fprintf(p.w, "Name:      %s\n", strVal(d.Name))
 	fmt.Fprintf(p.w, "Brand:     %s\n", strVal(d.BrandName))
 	fmt.Fprintf(p.w, "Model:     %s\n", strVal(d.ModelName))
-	fmt.Fprintf(p.w, "Distance:  %s\n", formatDistance(float32Val(d.Distance)))
+	fmt.Fprintf(p.w, "Distance:  %s\n", p.distance(float32Val(d.Distance)))
 	fmt.Fprintf(p.w, "Primary:   %v\n", boolVal(d.Primary))
 	if d.Description != nil && *d.Description != "" {
 		fmt.Fprintf(p.w, "Notes:     %s\n", *d.Description)
@@ -421,27 +501,11 @@ func (p *Printer) Routes(r *client.GetRoutesByAthleteIdResponse) error {
 	if r.JSON200 == nil {
 		return fmt.Errorf("unexpected empty response")
 	}
-	if p.JSON {
-		return printJSON(p.w, r.JSON200)
-	}
-	routes := *r.JSON200
-	if len(routes) == 0 {
+	if len(*r.JSON200) == 0 && !p.structured() {
 		fmt.Fprintln(p.w, "No routes found.")
 		return nil
 	}
-	fmt.Fprintf(p.w, "%-12s  %-35s  %-10s  %-8s  %s\n",
-		"ID", "Name", "Distance", "Elev", "Est. Time")
-	fmt.Fprintln(p.w, strings.Repeat("─", 85))
-	for _, r := range routes {
-		fmt.Fprintf(p.w, "%-12d  %-35s  %-10s  %-8s  %s\n",
-			int64Val(r.Id),
-			truncate(strVal(r.Name), 35),
-			formatDistance(float32Val(r.Distance)),
-			fmt.Sprintf("%.0fm", float32Val(r.ElevationGain)),
-			formatDuration(intVal(r.EstimatedMovingTime)),
-		)
-	}
-	return nil
+	return listTable(p, r.Body, *r.JSON200, routeColumns, routeDefaults)
 }
 
 // Route prints a single route's detail.
@@ -449,14 +513,14 @@ func (p *Printer) Route(r *client.GetRouteByIdResponse) error {
 	if r.JSON200 == nil {
 		return fmt.Errorf("unexpected empty response")
 	}
-	if p.JSON {
-		return printJSON(p.w, r.JSON200)
+	if p.structured() {
+		return p.emit(r.JSON200)
 	}
 	d := r.JSON200
 	fmt.Fprintf(p.w, "ID:           %d\n", int64Val(d.Id))
 	fmt.Fprintf(p.w, "Name:         %s\n", strVal(d.Name))
-	fmt.Fprintf(p.w, "Distance:     %s\n", formatDistance(float32Val(d.Distance)))
-	fmt.Fprintf(p.w, "Elevation:    %.0f m\n", float32Val(d.ElevationGain))
+	fmt.Fprintf(p.w, "Distance:     %s\n", p.distance(float32Val(d.Distance)))
+	fmt.Fprintf(p.w, "Elevation:    %s\n", p.elevation(float32Val(d.ElevationGain)))
 	fmt.Fprintf(p.w, "Est. time:    %s\n", formatDuration(intVal(d.EstimatedMovingTime)))
 	if d.Description != nil && *d.Description != "" {
 		fmt.Fprintf(p.w, "Description:  %s\n", *d.Description)
@@ -467,23 +531,69 @@ func (p *Printer) Route(r *client.GetRouteByIdResponse) error {
 	return nil
 }
 
+// RouteReadme writes a Markdown description of a route for a share bundle,
+// listing the bundle's other files. It ignores JSON mode.
+func (p *Printer) RouteReadme(r *client.GetRouteByIdResponse, files []string) error {
+	if r.JSON200 == nil {
+		return fmt.Errorf("unexpected empty response")
+	}
+	d := r.JSON200
+	fmt.Fprintf(p.w, "# %s\n\n", strVal(d.Name))
+	fmt.Fprintf(p.w, "- Distance: %s\n", p.distance(float32Val(d.Distance)))
+	fmt.Fprintf(p.w, "- Elevation gain: %s\n", p.elevation(float32Val(d.ElevationGain)))
+	if t := intVal(d.EstimatedMovingTime); t > 0 {
+		fmt.Fprintf(p.w, "- Estimated moving time: %s\n", formatDuration(t))
+	}
+	fmt.Fprintf(p.w, "- On Strava: https://www.strava.com/routes/%d\n", int64Val(d.Id))
+	if d.Description != nil && *d.Description != "" {
+		fmt.Fprintf(p.w, "\n%s\n", *d.Description)
+	}
+	fmt.Fprintf(p.w, "\n## Files\n\n")
+	for _, f := range files {
+		fmt.Fprintf(p.w, "- %s\n", f)
+	}
+	fmt.Fprintln(p.w, "\nLoad the GPX file onto a bike computer or watch, or import it into any route planner.")
+	return nil
+}
+
+// RouteDraft is a route file checked by `routes create`.
+type RouteDraft struct {
+	Name          string  `json:"name"`
+	File          string  `json:"file"`
+	Points        int     `json:"points"`
+	Distance      float64 `json:"distance"`       // meters
+	ElevationGain float64 `json:"elevation_gain"` // meters; 0 without elevation data
+}
+
+// RouteDraft prints what a route file contains.
+func (p *Printer) RouteDraft(d RouteDraft) error {
+	if p.structured() {
+		return p.emit(d)
+	}
+	fmt.Fprintf(p.w, "Name:       %s\n", d.Name)
+	fmt.Fprintf(p.w, "File:       %s (%d points)\n", d.File, d.Points)
+	fmt.Fprintf(p.w, "Distance:   %s\n", p.distance(float32(d.Distance)))
+	fmt.Fprintf(p.w, "Elev gain:  %s\n", p.elevation(float32(d.ElevationGain)))
+	return nil
+}
+
 // Segment prints a segment's detail.
 func (p *Printer) Segment(r *client.GetSegmentByIdResponse) error {
 	if r.JSON200 == nil {
 		return fmt.Errorf("unexpected empty response")
 	}
-	if p.JSON {
-		return printJSON(p.w, r.JSON200)
+	if p.structured() {
+		return p.emit(r.JSON200)
 	}
 	d := r.JSON200
 	fmt.Fprintf(p.w, "ID:           %d\n", int64Val(d.Id))
 	fmt.Fprintf(p.w, "Name:         %s\n", strVal(d.Name))
 	fmt.Fprintf(p.w, "Location:     %s, %s, %s\n", strVal(d.City), strVal(d.State), strVal(d.Country))
-	fmt.Fprintf(p.w, "Distance:     %s\n", formatDistance(float32Val(d.Distance)))
+	fmt.Fprintf(p.w, "Distance:     %s\n", p.distance(float32Val(d.Distance)))
 	fmt.Fprintf(p.w, "Avg grade:    %.1f%%\n", float32Val(d.AverageGrade))
 	fmt.Fprintf(p.w, "Max grade:    %.1f%%\n", float32Val(d.MaximumGrade))
-	fmt.Fprintf(p.w, "Elev high:    %.0f m\n", float32Val(d.ElevationHigh))
-	fmt.Fprintf(p.w, "Elev low:     %.0f m\n", float32Val(d.ElevationLow))
+	fmt.Fprintf(p.w, "Elev high:    %s\n", p.elevation(float32Val(d.ElevationHigh)))
+	fmt.Fprintf(p.w, "Elev low:     %s\n", p.elevation(float32Val(d.ElevationLow)))
 	fmt.Fprintf(p.w, "Climb cat:    %d\n", intVal(d.ClimbCategory))
 	fmt.Fprintf(p.w, "Efforts:      %d\n", intVal(d.EffortCount))
 	fmt.Fprintf(p.w, "Stars:        %d\n", intVal(d.StarCount))
@@ -503,24 +613,11 @@ func (p *Printer) StarredSegments(r *client.GetLoggedInAthleteStarredSegmentsRes
 	if r.JSON200 == nil {
 		return fmt.Errorf("unexpected empty response")
 	}
-	if p.JSON {
-		return printJSON(p.w, r.JSON200)
-	}
-	segs := *r.JSON200
-	if len(segs) == 0 {
+	if len(*r.JSON200) == 0 && !p.structured() {
 		fmt.Fprintln(p.w, "No starred segments.")
 		return nil
 	}
-	fmt.Fprintf(p.w, "%-12s  %-35s  %-10s  %6s  %s\n",
-		"ID", "Name", "Distance", "Grade", "City")
-	fmt.Fprintln(p.w, strings.Repeat("─", 80))
-	for _, s := range segs {
-		fmt.Fprintf(p.w, "%-12d  %-35s  %-10s  %5.1f%%  %s\n",
-			int64Val(s.Id), truncate(strVal(s.Name), 35),
-			formatDistance(float32Val(s.Distance)),
-			float32Val(s.AverageGrade), strVal(s.City))
-	}
-	return nil
+	return listTable(p, r.Body, *r.JSON200, segmentColumns, segmentDefaults)
 }
 
 // ExploreSegments prints explored segments.
@@ -528,17 +625,16 @@ func (p *Printer) ExploreSegments(r *client.ExploreSegmentsResponse) error {
 	if r.JSON200 == nil {
 		return fmt.Errorf("unexpected empty response")
 	}
-	if p.JSON {
-		return printJSON(p.w, r.JSON200)
+	if p.structured() {
+		return p.emit(r.JSON200)
 	}
 	if r.JSON200.Segments == nil || len(*r.JSON200.Segments) == 0 {
 		fmt.Fprintln(p.w, "No segments found in this area.")
 		return nil
 	}
 	segs := *r.JSON200.Segments
-	fmt.Fprintf(p.w, "%-12s  %-35s  %-10s  %6s  %s\n",
+	p.header(80, "%-12s  %-35s  %-10s  %6s  %s\n",
 		"ID", "Name", "Distance", "Grade", "Cat")
-	fmt.Fprintln(p.w, strings.Repeat("─", 80))
 	for _, s := range segs {
 		cat := ""
 		if s.ClimbCategoryDesc != nil {
@@ -547,7 +643,7 @@ func (p *Printer) ExploreSegments(r *client.ExploreSegmentsResponse) error {
 		fmt.Fprintf(p.w, "%-12d  %-35s  %-10s  %5.1f%%  %s\n",
 			int64Val(s.Id),
 			truncate(strVal(s.Name), 35),
-			formatDistance(float32Val(s.Distance)),
+			p.distance(float32Val(s.Distance)),
 			float32Val(s.AvgGrade),
 			cat,
 		)
@@ -560,24 +656,11 @@ func (p *Printer) SegmentEfforts(r *client.GetEffortsBySegmentIdResponse) error
 	if r.JSON200 == nil {
 		return fmt.Errorf("unexpected empty response")
 	}
-	if p.JSON {
-		return printJSON(p.w, r.JSON200)
-	}
-	efforts := *r.JSON200
-	if len(efforts) == 0 {
+	if len(*r.JSON200) == 0 && !p.structured() {
 		fmt.Fprintln(p.w, "No efforts found.")
 		return nil
 	}
-	fmt.Fprintf(p.w, "%-12s  %-10s  %s\n", "ID", "Time", "Date")
-	fmt.Fprintln(p.w, strings.Repeat("─", 45))
-	for _, e := range efforts {
-		fmt.Fprintf(p.w, "%-12d  %-10s  %s\n",
-			int64Val(e.Id),
-			formatDuration(intVal(e.ElapsedTime)),
-			formatTime(e.StartDateLocal),
-		)
-	}
-	return nil
+	return listTable(p, r.Body, *r.JSON200, effortColumns, effortDefaults)
 }
 
 // SegmentEffort prints a single segment effort.
@@ -585,8 +668,8 @@ func (p *Printer) SegmentEffort(r *client.GetSegmentEffortByIdResponse) error {
 	if r.JSON200 == nil {
 		return fmt.Errorf("unexpected empty response")
 	}
-	if p.JSON {
-		return printJSON(p.w, r.JSON200)
+	if p.structured() {
+		return p.emit(r.JSON200)
 	}
 	d := r.JSON200
 	segName := strVal(d.Name) // Name field holds the segment name on efforts
@@ -598,7 +681,7 @@ func (p *Printer) SegmentEffort(r *client.GetSegmentEffortByIdResponse) error {
 	fmt.Fprintf(p.w, "Date:         %s\n", formatTime(d.StartDateLocal))
 	fmt.Fprintf(p.w, "Elapsed time: %s\n", formatDuration(intVal(d.ElapsedTime)))
 	fmt.Fprintf(p.w, "Moving time:  %s\n", formatDuration(intVal(d.MovingTime)))
-	fmt.Fprintf(p.w, "Distance:     %s\n", formatDistance(float32Val(d.Distance)))
+	fmt.Fprintf(p.w, "Distance:     %s\n", p.distance(float32Val(d.Distance)))
 	if d.AverageHeartrate != nil {
 		fmt.Fprintf(p.w, "Avg HR:       %.0f bpm\n", *d.AverageHeartrate)
 	}
@@ -616,6 +699,5 @@ func (p *Printer) SegmentEffort(r *client.GetSegmentEffortByIdResponse) error {
 
 // --- internal helpers ---
 
-
 // FormatTime exports the time formatter for use in tests.
 func FormatTime(t *time.Time) string { return formatTime(t) }
@@ -2,12 +2,22 @@ package output_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/Brainsoft-Raxat/strava-cli/internal/analysis"
 	"github.com/Brainsoft-Raxat/strava-cli/internal/client"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/geo"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/inspect"
 	"github.com/Brainsoft-Raxat/strava-cli/internal/output"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/store"
 )
 
 // --- FormatDistance ---
@@ -60,6 +70,34 @@ func TestFormatDuration(t *testing.T) {
 	}
 }
 
+// --- ParseDistance ---
+
+func TestParseDistance(t *testing.T) {
+	tests := []struct {
+		in    string
+		units output.Units
+		want  float64
+	}{
+		{"10km", output.Metric, 10000},
+		{"800 m", output.Metric, 800},
+		{"2.5", output.Metric, 2500},
+		{"1mi", output.Metric, 1609.344},
+		{"1", output.Imperial, 1609.344},
+		{"5280ft", output.Metric, 1609.3440},
+	}
+	for _, tc := range tests {
+		got, err := output.ParseDistance(tc.in, tc.units)
+		if err != nil || math.Abs(got-tc.want) > 0.01 {
+			t.Errorf("ParseDistance(%q, %s) = %v, %v; want %v", tc.in, tc.units, got, err, tc.want)
+		}
+	}
+	for _, bad := range []string{"", "km", "ten km", "-5km", "3 furlongs"} {
+		if _, err := output.ParseDistance(bad, output.Metric); err == nil {
+			t.Errorf("ParseDistance(%q) should fail", bad)
+		}
+	}
+}
+
 // unmarshalAthleteResponse unmarshals JSON into a GetLoggedInAthleteResponse.
 func unmarshalAthleteResponse(t *testing.T, raw string) *client.GetLoggedInAthleteResponse {
 	t.Helper()
@@ -229,6 +267,75 @@ func TestPrinterActivity_HumanReadable(t *testing.T) {
 	}
 }
 
+func TestPrinterActivity_Imperial(t *testing.T) {
+	resp := unmarshalActivityResponse(t, `{
+		"id": 1234567,
+		"name": "Lunch Ride",
+		"sport_type": "Ride",
+		"distance": 16093.44,
+		"moving_time": 3600,
+		"total_elevation_gain": 100,
+		"average_speed": 4.4704
+	}`)
+
+	var buf bytes.Buffer
+	p := output.New(&buf, false)
+	p.Units = output.Imperial
+	if err := p.Activity(resp); err != nil {
+		t.Fatalf("Activity() error: %v", err)
+	}
+	got := buf.String()
+	for _, want := range []string{"10.00 mi", "328 ft", "10.0 mph"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q\ngot:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "km") {
+		t.Errorf("imperial output should not mention km\ngot:\n%s", got)
+	}
+}
+
+func TestPrinterActivity_PaceForRunsAndSwims(t *testing.T) {
+	for sport, want := range map[string]string{"Run": "Avg pace:     5:00/km", "Swim": "Avg pace:     0:30/100m", "Ride": "Avg speed:    12.0 km/h"} {
+		resp := unmarshalActivityResponse(t, `{"id": 1, "sport_type": "`+sport+`", "distance": 5000, "average_speed": 3.3333}`)
+		var buf bytes.Buffer
+		if err := output.New(&buf, false).Activity(resp); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("%s: output missing %q\ngot:\n%s", sport, want, buf.String())
+		}
+	}
+}
+
+func TestParseUnits(t *testing.T) {
+	for in, want := range map[string]output.Units{
+		"metric": output.Metric, "meters": output.Metric,
+		"imperial": output.Imperial, "feet": output.Imperial, "Imperial": output.Imperial,
+	} {
+		got, err := output.ParseUnits(in)
+		if err != nil || got != want {
+			t.Errorf("ParseUnits(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := output.ParseUnits("furlongs"); err == nil {
+		t.Error("ParseUnits(furlongs) should fail")
+	}
+}
+
+func TestParseVersion(t *testing.T) {
+	for _, in := range []string{"1", "1.0", "v1.0.7"} {
+		if v, err := output.ParseVersion(in); err != nil || v != (output.Version{Major: 1}) {
+			t.Errorf("ParseVersion(%q) = %+v, %v; want 1.0", in, v, err)
+		}
+	}
+	for _, in := range []string{"", "one", "0", "2", "1.99", "1.0.0.0"} {
+		if _, err := output.ParseVersion(in); err == nil {
+			t.Errorf("ParseVersion(%q) should fail", in)
+		}
+	}
+}
+
 func TestPrinterActivity_NilJSON200(t *testing.T) {
 	resp := &client.GetActivityByIdResponse{}
 	p := output.New(&bytes.Buffer{}, false)
@@ -236,3 +343,611 @@ func TestPrinterActivity_NilJSON200(t *testing.T) {
 		t.Error("expected error for nil JSON200")
 	}
 }
+
+// --- Club feed output ---
+
+func TestPrinterClubFeed_MarksOwnActivities(t *testing.T) {
+	entries := []output.ClubFeedEntry{
+		{Athlete: "Jane D.", Mine: true, Name: "Lunch Run", SportType: "Run", Distance: 5000, MovingTime: 1500},
+		{Athlete: "Bob S.", Name: "Commute", SportType: "Ride", Distance: 12000, MovingTime: 2400},
+	}
+
+	var buf bytes.Buffer
+	if err := output.New(&buf, false).ClubFeed(entries); err != nil {
+		t.Fatalf("ClubFeed() error: %v", err)
+	}
+	lines := strings.Split(buf.String(), "\n")
+	var mine, other string
+	for _, l := range lines {
+		switch {
+		case strings.Contains(l, "Jane D."):
+			mine = l
+		case strings.Contains(l, "Bob S."):
+			other = l
+		}
+	}
+	if !strings.HasPrefix(mine, "*") {
+		t.Errorf("own activity not flagged: %q", mine)
+	}
+	if strings.HasPrefix(other, "*") {
+		t.Errorf("other athlete's activity flagged: %q", other)
+	}
+}
+
+func TestPrinterClubEvents_MarksJoined(t *testing.T) {
+	events := []output.ClubEvent{
+		{ID: 901, Title: "Saturday long ride", ActivityType: "Ride", Joined: true,
+			UpcomingOccurrences: []time.Time{time.Date(2026, 10, 24, 7, 30, 0, 0, time.Local)}},
+		{ID: 899, Title: "Old event", ActivityType: "Run"},
+	}
+
+	var buf bytes.Buffer
+	if err := output.New(&buf, false).ClubEvents(events); err != nil {
+		t.Fatalf("ClubEvents() error: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "*  901           Sat 24 Oct 07:30") {
+		t.Errorf("joined event not flagged with its next date:\n%s", out)
+	}
+	if !strings.Contains(out, "   899           -  ") {
+		t.Errorf("event without dates should show -:\n%s", out)
+	}
+}
+
+func TestPrinterInspection_ListsProblems(t *testing.T) {
+	s := inspect.Summary{Format: "fit", Distance: 12345, DistanceSource: "device", Records: 10,
+		Sensors: []string{}, Problems: []string{"timestamps go backwards 1 times"}}
+
+	var buf bytes.Buffer
+	if err := output.New(&buf, false).Inspection("ride.fit", s); err != nil {
+		t.Fatalf("Inspection() error: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"File:         ride.fit (fit)", "Device:       (unknown)", "12.35 km (device)",
+		"1 problem(s):\n  - timestamps go backwards 1 times"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestPrinterActivitySegments_Ranks(t *testing.T) {
+	resp := unmarshalActivityResponse(t, `{
+		"id": 1,
+		"segment_efforts": [
+			{"id": 111, "name": "Hill Sprint", "distance": 800, "elapsed_time": 125, "pr_rank": 1},
+			{"id": 222, "segment": {"name": "Long Climb"}, "distance": 4200, "elapsed_time": 900, "kom_rank": 7}
+		]
+	}`)
+
+	var buf bytes.Buffer
+	if err := output.New(&buf, false).ActivitySegments(resp); err != nil {
+		t.Fatalf("ActivitySegments() error: %v", err)
+	}
+	got := buf.String()
+	for _, want := range []string{"111", "Hill Sprint", "2m05s", "#1", "Long Climb", "#7"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q\ngot:\n%s", want, got)
+		}
+	}
+}
+
+// --- Analytics output ---
+
+func TestPrinterCourse_Matrix(t *testing.T) {
+	c := analysis.CompareCourse([]analysis.CourseEffort{
+		{ActivityID: 11, Name: "Tempo", Splits: []float64{240, 250}},
+		{ActivityID: 22, Name: "Easy", Splits: []float64{300, 310}},
+	}, 1000)
+
+	var buf bytes.Buffer
+	if err := output.New(&buf, false).Course("River Loop", c); err != nil {
+		t.Fatalf("Course() error: %v", err)
+	}
+	got := buf.String()
+	for _, want := range []string{"River Loop", "Tempo", "8m10s", "4m00s", "5m10s", "4m30s"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q\ngot:\n%s", want, got)
+		}
+	}
+}
+
+func TestPrinterReport_Totals(t *testing.T) {
+	rows := []analysis.PeriodTotals{
+		{Period: "2024-01", Count: 2, Distance: 15000, MovingTime: 4500, ElevationGain: 70},
+		{Period: "2024-02", Count: 1, Distance: 5000, MovingTime: 1500, ElevationGain: 30},
+	}
+	var buf bytes.Buffer
+	if err := output.New(&buf, false).Report(rows); err != nil {
+		t.Fatalf("Report() error: %v", err)
+	}
+	got := buf.String()
+	for _, want := range []string{"2024-01", "15.00 km", "Total", "20.00 km", "1h40m00s", "100 m"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q\ngot:\n%s", want, got)
+		}
+	}
+}
+
+func TestPrinterAdvancedStats(t *testing.T) {
+	a := analysis.Advanced{
+		Activities:    3,
+		Eddington:     12,
+		EddingtonNext: 4,
+		Histogram:     []analysis.Bucket{{Min: 0, Max: 5, Count: 1}, {Min: 5, Max: 10, Count: 2}},
+	}
+	var buf bytes.Buffer
+	p := output.New(&buf, false)
+	p.Units = output.Imperial
+	if err := p.AdvancedStats(a); err != nil {
+		t.Fatalf("AdvancedStats() error: %v", err)
+	}
+	got := buf.String()
+	for _, want := range []string{"12 mi", "4 more day(s) of 13+ mi", "0 days", "Distance distribution (mi)", "█"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q\ngot:\n%s", want, got)
+		}
+	}
+}
+
+func TestPrinterChart_Altitude(t *testing.T) {
+	n := 1000
+	s := output.Series{Metric: "altitude", Distance: make([]float64, n), Values: make([]float64, n)}
+	for i := range n {
+		s.Distance[i] = float64(i) * 10
+		s.Values[i] = 100 + float64(i/100)*10 // 100 m … 190 m in steps
+	}
+	var buf bytes.Buffer
+	if err := output.New(&buf, false).Chart("Hills", s); err != nil {
+		t.Fatalf("Chart() error: %v", err)
+	}
+	got := buf.String()
+	lines := strings.Split(strings.TrimRight(got, "\n"), "\n")
+	if len(lines) != 1+12+2 {
+		t.Fatalf("got %d lines, want title + 12 rows + axis:\n%s", len(lines), got)
+	}
+	for _, want := range []string{"Hills", "190 m ┤", "100 m ┤", "9.99 km"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q\ngot:\n%s", want, got)
+		}
+	}
+}
+
+func TestPrinterChart_PaceFastestOnTop(t *testing.T) {
+	s := output.Series{Metric: "pace", Time: []int{0, 1, 2, 3}, Values: []float64{0, 2.5, 5, 4}}
+	var buf bytes.Buffer
+	if err := output.New(&buf, false).Chart("", s); err != nil {
+		t.Fatalf("Chart() error: %v", err)
+	}
+	lines := strings.Split(buf.String(), "\n")
+	if !strings.HasPrefix(lines[0], "3:20/km") || !strings.HasPrefix(lines[11], "6:40/km") {
+		t.Errorf("want fastest pace on top, slowest at bottom; got:\n%s", buf.String())
+	}
+}
+
+func TestPrinterMap_StartWestEndEast(t *testing.T) {
+	m := output.TrackMap{Name: "Out", Distance: 7000,
+		Points: []geo.Point{{Lat: 51.5, Lng: -0.2}, {Lat: 51.5, Lng: -0.1}}}
+	var buf bytes.Buffer
+	if err := output.New(&buf, false).Map(m); err != nil {
+		t.Fatalf("Map() error: %v", err)
+	}
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 1+20+1 {
+		t.Fatalf("got %d lines, want title + 20 rows + legend:\n%s", len(lines), buf.String())
+	}
+	var row string
+	for _, l := range lines[1:21] {
+		if strings.Contains(l, "S") {
+			row = l
+		}
+	}
+	if s, e := strings.Index(row, "S"), strings.Index(row, "E"); s < 0 || e < s {
+		t.Errorf("want S west of E on one row; got:\n%s", buf.String())
+	}
+	if !strings.Contains(lines[21], "7.00 km") {
+		t.Errorf("legend = %q, want the distance", lines[21])
+	}
+}
+
+// --- Templates ---
+
+func TestPrinterActivities_Template(t *testing.T) {
+	resp := unmarshalActivitiesResponse(t, `[
+		{"id": 1, "name": "Morning Run", "distance": 10250, "moving_time": 3000, "start_date_local": "2024-05-01T07:00:00Z"},
+		{"id": 2, "name": "No distance"}
+	]`)
+	tmpl, err := output.ParseTemplate(`{{.Id}} {{.Name}} {{km .Distance}} {{duration .MovingTime}} {{date .StartDateLocal}}`)
+	if err != nil {
+		t.Fatalf("ParseTemplate: %v", err)
+	}
+	var buf bytes.Buffer
+	p := output.New(&buf, false)
+	p.Template = tmpl
+	if err := p.Activities(resp); err != nil {
+		t.Fatalf("Activities: %v", err)
+	}
+	want := "1 Morning Run 10.25 50m00s 2024-05-01\n2 No distance 0.00 0m00s \n"
+	if buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestPrinterTemplate_UnknownField(t *testing.T) {
+	resp := unmarshalActivityResponse(t, `{"id": 1, "name": "Ride"}`)
+	tmpl, err := output.ParseTemplate(`{{.Nmae}}`)
+	if err != nil {
+		t.Fatalf("ParseTemplate: %v", err)
+	}
+	p := output.New(&bytes.Buffer{}, false)
+	p.Template = tmpl
+	err = p.Activity(resp)
+	if err == nil || !strings.Contains(err.Error(), "no field Nmae") || !strings.Contains(err.Error(), "Name,") {
+		t.Errorf("error = %v, want a short message listing the fields", err)
+	}
+}
+
+func TestParseTemplate_Invalid(t *testing.T) {
+	if _, err := output.ParseTemplate("{{.Name"); err == nil {
+		t.Error("expected a parse error")
+	}
+}
+
+// --- Columns and sorting ---
+
+func TestPrinterActivities_ColumnsAndSort(t *testing.T) {
+	resp := unmarshalActivitiesResponse(t, `[
+		{"id": 1, "name": "Easy", "average_speed": 2.5, "average_heartrate": 135},
+		{"id": 2, "name": "Tempo", "average_speed": 3.5, "average_heartrate": 162},
+		{"id": 3, "name": "Indoor"}
+	]`)
+	// Summary activity types lack heart rate; the table reads the raw body.
+	resp.Body, _ = json.Marshal([]map[string]any{
+		{"id": 1, "name": "Easy", "average_speed": 2.5, "average_heartrate": 135},
+		{"id": 2, "name": "Tempo", "average_speed": 3.5, "average_heartrate": 162},
+		{"id": 3, "name": "Indoor"},
+	})
+
+	var buf bytes.Buffer
+	p := output.New(&buf, false)
+	p.Columns = []string{"name", "pace", "hr"}
+	p.Sort = "pace"
+	if err := p.Activities(resp); err != nil {
+		t.Fatalf("Activities() error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 5 || !strings.HasPrefix(lines[0], "Name") || strings.Contains(lines[0], "Distance") {
+		t.Fatalf("unexpected table:\n%s", buf.String())
+	}
+	// Fastest pace first; no speed sorts last.
+	for i, want := range []string{"Tempo", "Easy", "Indoor"} {
+		if !strings.HasPrefix(lines[i+2], want) {
+			t.Errorf("row %d = %q, want %s first", i, lines[i+2], want)
+		}
+	}
+	if !strings.Contains(lines[2], "4:46/km") || !strings.Contains(lines[2], "162") {
+		t.Errorf("Tempo row = %q, want pace and HR", lines[2])
+	}
+}
+
+func TestPrinterActivities_SortJSON(t *testing.T) {
+	resp := unmarshalActivitiesResponse(t, `[{"id":1,"distance":5000},{"id":2,"distance":20000}]`)
+
+	var buf bytes.Buffer
+	p := output.New(&buf, true)
+	p.Sort = "distance:desc"
+	if err := p.Activities(resp); err != nil {
+		t.Fatalf("Activities() error: %v", err)
+	}
+	var out []struct{ ID int64 }
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil || len(out) != 2 || out[0].ID != 2 {
+		t.Errorf("JSON order = %s, want the longer activity first", buf.String())
+	}
+}
+
+func TestPrinterActivities_UnknownColumn(t *testing.T) {
+	resp := unmarshalActivitiesResponse(t, `[{"id":1}]`)
+	p := output.New(&bytes.Buffer{}, false)
+	p.Columns = []string{"id", "watts"}
+	if err := p.Activities(resp); err == nil || !strings.Contains(err.Error(), "available: id") {
+		t.Errorf("error = %v, want the available columns listed", err)
+	}
+	p.Columns, p.Sort = nil, "distance:sideways"
+	if err := p.Activities(resp); err == nil {
+		t.Error("expected an error for an invalid sort direction")
+	}
+}
+
+func TestPrinterSegmentHistoryCSV(t *testing.T) {
+	start := time.Date(2024, 5, 1, 7, 30, 0, 0, time.UTC)
+	efforts := []analysis.SegmentEffort{
+		{ID: 1, ActivityID: 10, StartDateLocal: start, ElapsedTime: 300, MovingTime: 290,
+			AverageHeartrate: 162.4, AverageWatts: 251, Weather: &analysis.Weather{TempC: 12.34, WindSpeed: 3}},
+		{ID: 2, ActivityID: 11, StartDateLocal: start.AddDate(0, 0, 7), ElapsedTime: 310, MovingTime: 310},
+	}
+	var buf bytes.Buffer
+	if err := output.New(&buf, false).SegmentHistoryCSV(efforts, true); err != nil {
+		t.Fatal(err)
+	}
+	want := "date,effort_id,activity_id,elapsed_s,moving_s,avg_hr,avg_watts,temp_c,wind_ms\n" +
+		"2024-05-01 07:30:00,1,10,300,290,162.4,251.0,12.3,3.0\n" +
+		"2024-05-08 07:30:00,2,11,310,310,,,,\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// --- Renderers ---
+
+func TestPrinterFormats(t *testing.T) {
+	body := []byte(`[{"id": 7, "name": "Morning | Run", "distance": 5000, "athlete": {"id": 1}}, {"id": 8, "name": "Swim"}]`)
+	acts := &client.GetLoggedInAthleteActivitiesResponse{Body: body}
+	if err := json.Unmarshal(body, &acts.JSON200); err != nil {
+		t.Fatal(err)
+	}
+	render := func(format string) string {
+		t.Helper()
+		var buf bytes.Buffer
+		p := output.New(&buf, false)
+		p.Format = format
+		if err := p.Activities(acts); err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		return buf.String()
+	}
+
+	csv := strings.Split(render("csv"), "\n")
+	if csv[0] != "athlete.id,distance,id,name" {
+		t.Errorf("csv header = %q", csv[0])
+	}
+	if !strings.Contains(csv[1], "Morning | Run") || !strings.Contains(csv[2], "Swim") {
+		t.Errorf("csv rows = %q", csv[1:])
+	}
+	md := render("markdown")
+	if !strings.Contains(md, `Morning \| Run`) || !strings.Contains(md, "| --- |") {
+		t.Errorf("markdown = %q", md)
+	}
+	nd := strings.Split(strings.TrimSuffix(render("ndjson"), "\n"), "\n")
+	if len(nd) != 2 || !strings.Contains(nd[0], `"name":"Morning | Run"`) || !strings.Contains(nd[1], `"name":"Swim"`) {
+		t.Errorf("ndjson = %q", nd)
+	}
+	pq := render("parquet")
+	if !strings.HasPrefix(pq, "PAR1") || !strings.HasSuffix(pq, "PAR1") || !strings.Contains(pq, "athlete.id") {
+		t.Errorf("parquet is not a Parquet file with the flattened columns: %q", pq)
+	}
+	if p := (&output.Printer{Format: "ndjson"}); !p.Streaming() {
+		t.Error("ndjson is not streaming")
+	}
+	if got := render("table"); !strings.Contains(got, "Morning | Run") || strings.Contains(got, "athlete.id") {
+		t.Errorf("table = %q", got)
+	}
+
+	output.Register("count", output.RendererFunc(func(w io.Writer, v any, _ output.RenderOptions) error {
+		_, err := fmt.Fprint(w, reflect.ValueOf(v).Len())
+		return err
+	}))
+	if got := render("count"); got != "2" {
+		t.Errorf("registered renderer wrote %q, want 2", got)
+	}
+	if _, err := output.ParseFormat("yaml"); err == nil {
+		t.Error("ParseFormat accepted an unregistered format")
+	}
+}
+
+func TestQueryFilter(t *testing.T) {
+	q, err := output.ParseQuery(`.[] | select(.distance > 1000) | .name, {id}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := `[{"id": 12345678901234567, "name": "Long Run", "distance": 21097.5}, {"id": 2, "name": "Walk", "distance": 800}]`
+	var buf bytes.Buffer
+	if err := q.Filter(context.Background(), &buf, strings.NewReader(in+"\n"+in)); err != nil {
+		t.Fatal(err)
+	}
+	one := "Long Run\n{\n  \"id\": 12345678901234567\n}\n"
+	if got := buf.String(); got != one+one {
+		t.Errorf("Filter wrote %q, want %q twice", got, one)
+	}
+	if err := q.Filter(context.Background(), io.Discard, strings.NewReader("Distance: 5 km")); err == nil {
+		t.Error("Filter accepted output that is not JSON")
+	}
+	if _, err := output.ParseQuery(".[] |"); err == nil {
+		t.Error("ParseQuery accepted an incomplete program")
+	}
+}
+
+func TestPrinterColor(t *testing.T) {
+	laps := &client.GetLapsByActivityIdResponse{}
+	body := `[{"lap_index": 1, "distance": 1000, "moving_time": 300, "average_speed": 3.3},
+		{"lap_index": 2, "distance": 1000, "moving_time": 290, "average_speed": 3.4}]`
+	if err := json.Unmarshal([]byte(body), &laps.JSON200); err != nil {
+		t.Fatal(err)
+	}
+	render := func(color bool) []string {
+		t.Helper()
+		var buf bytes.Buffer
+		p := output.New(&buf, false)
+		p.Color = color
+		if err := p.Laps(laps, "Ride"); err != nil {
+			t.Fatal(err)
+		}
+		return strings.Split(buf.String(), "\n")
+	}
+	plain := render(false)
+	if strings.Contains(strings.Join(plain, "\n"), "\x1b[") {
+		t.Errorf("uncolored output has escape codes: %q", plain)
+	}
+	colored := render(true)
+	if !strings.HasPrefix(colored[0], "\x1b[1mLap") {
+		t.Errorf("heading = %q, want bold", colored[0])
+	}
+	if strings.Contains(colored[2], "\x1b[32m") || !strings.Contains(colored[3], "\x1b[32m") {
+		t.Errorf("only the faster second lap should be green: %q", colored[2:4])
+	}
+}
+
+func TestPrinterFitness_WeeksEndOnLastDay(t *testing.T) {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	var days []analysis.FitnessDay
+	for d := range 10 {
+		days = append(days, analysis.FitnessDay{Date: start.AddDate(0, 0, d), TSS: 50, Fitness: float64(d), Fatigue: 2 * float64(d), Form: -float64(d)})
+	}
+
+	var buf bytes.Buffer
+	if err := output.New(&buf, false).Fitness(days); err != nil {
+		t.Fatalf("Fitness() error: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"Fitness (CTL)", "Form (TSB)", "2024-03-10     350        9       18     -9",
+		"Form on 2024-03-10: -9, maintaining"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "2024-03-03") {
+		t.Errorf("output has a partial first week:\n%s", out)
+	}
+}
+
+func TestPrinterZoneReport(t *testing.T) {
+	d := analysis.ZoneDistribution{Metric: "heartrate", Activities: 3, Zones: []analysis.ZoneShare{
+		{Zone: 1, Min: 0, Max: 129, Seconds: 5400, Share: 0.75},
+		{Zone: 2, Min: 130, Seconds: 1800, Share: 0.25},
+	}, Intensity: analysis.Distribution{Low: 7200, Model: "pyramidal"}}
+
+	var buf bytes.Buffer
+	if err := output.New(&buf, false).ZoneReport(d); err != nil {
+		t.Fatalf("ZoneReport() error: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"over 3 activities", "Z1    0–129 bpm", "75.0%  " + strings.Repeat("█", 23), "Z2    130+ bpm",
+		"Low (Z1–2) 100%, moderate (Z3) 0%, high (Z4+) 0%: pyramidal"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestPrinterPersonalRecords(t *testing.T) {
+	date := time.Date(2024, 3, 1, 7, 0, 0, 0, time.UTC)
+	prs := []analysis.PR{
+		{Sport: "Ride", Effort: "20m", Watts: 251.4, ActivityName: "Hill repeats", Date: date},
+		{Sport: "Run", Effort: "5k", Meters: 5000, Seconds: 1200, ActivityName: "Parkrun", Date: date, New: true},
+	}
+	var buf bytes.Buffer
+	output.New(&buf, false).PersonalRecords(prs)
+	out := buf.String()
+	for _, want := range []string{"Ride        20m                 251 W             2024-03-01  Hill repeats",
+		"20m00s  4:00/km    2024-03-01  Parkrun  ★ new"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestPrinterCalendar(t *testing.T) {
+	c := analysis.Calendar{Year: 2024, Metric: analysis.ByTime, ActiveDays: 2, Activities: 2, MovingTime: 5400}
+	for d := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC); d.Month() == time.January; d = d.AddDate(0, 0, 1) {
+		c.Days = append(c.Days, analysis.CalendarDay{Date: d})
+	}
+	c.Days[0].Level, c.Days[8].Level = 4, 1 // Monday the 1st, Tuesday the 9th
+	var buf bytes.Buffer
+	output.New(&buf, false).Calendar(c)
+	out := buf.String()
+	for _, want := range []string{"     Jan\n", "Mon  █····\n", "Tue  ·░···\n", "Sun  ····\n", "Moving time:     1h30m00s"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestPrinterNoteTemplate(t *testing.T) {
+	n := output.Note{
+		Activity: analysis.Activity{
+			ID: 42, Name: `Tempo "8k"`, SportType: "Run", Distance: 2000, MovingTime: 600, AverageSpeed: 3.33,
+			StartDateLocal: time.Date(2024, 6, 1, 7, 30, 0, 0, time.UTC),
+		},
+		Description: "Felt strong.",
+		Splits: []output.Split{
+			{Split: 1, Distance: 1000, MovingTime: 290, AverageSpeed: 1000.0 / 290, ElevationDifference: 4},
+			{Split: 2, Distance: 1000, MovingTime: 310, AverageSpeed: 1000.0 / 310, ElevationDifference: -4},
+		},
+		URL: "https://www.strava.com/activities/42",
+	}
+	tmpl, err := output.New(io.Discard, false).NoteTemplate("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, n); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"---\ntitle: \"Tempo \\\"8k\\\"\"\ndate: 2024-06-01\n",
+		"tags: [strava, run]\n---\n",
+		"\nFelt strong.\n",
+		"| Pace | 5:00/km |\n",
+		"| 2 | 1.00 km | 5m10s | 5:10/km | -4 m |\n",
+		"[View on Strava](https://www.strava.com/activities/42)\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("note lacks %q:\n%s", want, out)
+		}
+	}
+
+	if _, err := output.New(io.Discard, false).NoteTemplate("{{.Name"); err == nil {
+		t.Error("NoteTemplate accepted a broken template")
+	}
+}
+
+func TestPrinterClubDigest(t *testing.T) {
+	d := analysis.ClubDigest{
+		Activities: 3, Athletes: 2, Distance: 52000, MovingTime: 7200, ElevationGain: 400,
+		Top: []analysis.ClubLeaderboardRow{
+			{Rank: 1, Athlete: "Bob S.", Activities: 1, Distance: 40000},
+			{Rank: 2, Athlete: "Ann K.", Activities: 2, Distance: 12000},
+		},
+		LongestRide: &analysis.ClubEntry{Athlete: "Bob S.", Name: "Long one", Distance: 40000},
+		Newcomers:   []string{"Ann K.", "Bob S.", "Cy D."},
+	}
+	var buf bytes.Buffer
+	if err := output.New(&buf, false).ClubDigest("Morning Crew: week of Mon 3 Mar 2025", d); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"## Morning Crew: week of Mon 3 Mar 2025\n",
+		"**3 activities** by **2 athletes**: 52.00 km, 2h00m00s moving and 400 m of climbing.\n",
+		"1. **Bob S.**: 40.00 km in 1 activity\n2. **Ann K.**: 12.00 km in 2 activities\n",
+		"### Longest ride\n\n**Bob S.**: Long one, 40.00 km\n",
+		"A warm welcome to Ann K., Bob S. and Cy D., out with us for the first time!",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("digest lacks %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Biggest climb") {
+		t.Errorf("digest has a biggest climb it was not given:\n%s", out)
+	}
+}
+
+func TestPrinterCommandDays(t *testing.T) {
+	days := []store.DayUsage{
+		{Date: "2024-06-02", Runs: 3, Requests: 42, Bytes: 3 << 20, DurationMS: 95000,
+			Commands: []store.CommandUsage{{Command: "sync", Runs: 1, Requests: 40}, {Command: "activities list", Runs: 2, Requests: 2}}},
+		{Date: "2024-06-01", Runs: 1, DurationMS: 300, Commands: []store.CommandUsage{{Command: "config get", Runs: 1}}},
+	}
+	var buf bytes.Buffer
+	output.New(&buf, false).CommandDays(days)
+	out := buf.String()
+	for _, want := range []string{
+		"2024-06-02      3        42     3.0 MB     1m35s  sync (40 requests in 1 run)\n",
+		"2024-06-01      1         0        0 B      0.3s\n",
+		"Total           4        42     3.0 MB     1m35s",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package output
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/parquet"
+)
+
+// ParquetFormat writes a Parquet file, laid out as CSV is, for pandas,
+// Polars or DuckDB. It is binary, so it goes to a file or a pipe.
+const ParquetFormat = "parquet"
+
+// renderParquet writes v as a Parquet table: one row per list item, or a
+// single row for an object, with dotted columns for nested fields and arrays
+// as JSON. A column of numbers is int64, or double if any has a fraction; a
+// column of booleans is bool; anything else is a string.
+func renderParquet(w io.Writer, v any, _ RenderOptions) error {
+	header, rows, err := leafRecords(v)
+	if err != nil {
+		return err
+	}
+	if len(header) == 0 {
+		return nil
+	}
+	cols := make([]parquet.Column, len(header))
+	for j, name := range header {
+		cols[j] = parquet.Column{Name: name, Type: columnType(rows, j)}
+	}
+	pw, err := parquet.NewWriter(w, cols)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		vals := make([]any, len(row))
+		for j, val := range row {
+			vals[j] = parquetValue(cols[j].Type, val)
+		}
+		if err := pw.Write(vals...); err != nil {
+			return err
+		}
+	}
+	return pw.Close()
+}
+
+// columnType returns the Parquet type of column j of rows.
+func columnType(rows [][]any, j int) parquet.Type {
+	var ints, floats, bools, others int
+	for _, row := range rows {
+		switch val := row[j].(type) {
+		case nil:
+		case json.Number:
+			if _, err := val.Int64(); err == nil {
+				ints++
+			} else {
+				floats++
+			}
+		case bool:
+			bools++
+		default:
+			others++
+		}
+	}
+	switch {
+	case others > 0 || ints+floats+bools == 0 || (bools > 0 && ints+floats > 0):
+		return parquet.String
+	case bools > 0:
+		return parquet.Bool
+	case floats > 0:
+		return parquet.Double
+	}
+	return parquet.Int64
+}
+
+// parquetValue converts a leafRecords cell to a value of a column of type t.
+func parquetValue(t parquet.Type, val any) any {
+	if val == nil {
+		return nil
+	}
+	switch t {
+	case parquet.Int64:
+		n, _ := val.(json.Number).Int64()
+		return n
+	case parquet.Double:
+		f, _ := val.(json.Number).Float64()
+		return f
+	case parquet.Bool:
+		return val.(bool)
+	}
+	switch val := val.(type) {
+	case string:
+		return val
+	case bool:
+		return strconv.FormatBool(val)
+	}
+	return val.(json.Number).String()
+}
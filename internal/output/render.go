@@ -0,0 +1,337 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// TableFormat is the default, human-readable format. Each Printer method
+// writes it itself; every other format goes through a registered Renderer.
+const TableFormat = "table"
+
+// RenderOptions are the Printer settings a renderer may use.
+type RenderOptions struct {
+	Template *template.Template // set with --template
+	Units    Units
+}
+
+// Renderer writes data in one output format. v is what the printers emit
+// instead of a table: an API response type, a list of them, or a result of
+// the analysis package.
+type Renderer interface {
+	Render(w io.Writer, v any, opts RenderOptions) error
+}
+
+// RendererFunc adapts a function to Renderer.
+type RendererFunc func(w io.Writer, v any, opts RenderOptions) error
+
+// Render calls f.
+func (f RendererFunc) Render(w io.Writer, v any, opts RenderOptions) error { return f(w, v, opts) }
+
+var (
+	renderersMu sync.RWMutex
+	renderers   = map[string]Renderer{} //nolint:gochecknoglobals
+)
+
+// Register makes r available as the output format name, replacing any
+// renderer registered under it. The table format cannot be replaced.
+func Register(name string, r Renderer) {
+	name = strings.ToLower(name)
+	if name == TableFormat {
+		panic("output: the table format is not a Renderer")
+	}
+	renderersMu.Lock()
+	defer renderersMu.Unlock()
+	renderers[name] = r
+}
+
+// LookupRenderer returns the renderer registered for a format.
+func LookupRenderer(name string) (Renderer, bool) {
+	renderersMu.RLock()
+	defer renderersMu.RUnlock()
+	r, ok := renderers[strings.ToLower(name)]
+	return r, ok
+}
+
+// Formats lists the output formats, table first and the rest sorted.
+func Formats() []string {
+	renderersMu.RLock()
+	defer renderersMu.RUnlock()
+	names := make([]string, 0, len(renderers))
+	for name := range renderers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return append([]string{TableFormat}, names...)
+}
+
+// ParseFormat validates an output format name.
+func ParseFormat(s string) (string, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	if name == TableFormat {
+		return name, nil
+	}
+	if _, ok := LookupRenderer(name); !ok {
+		return "", fmt.Errorf("invalid output format %q: must be one of %s", s, strings.Join(Formats(), ", "))
+	}
+	return name, nil
+}
+
+func init() {
+	Register("json", RendererFunc(func(w io.Writer, v any, _ RenderOptions) error {
+		return printJSON(w, v)
+	}))
+	Register("template", RendererFunc(func(w io.Writer, v any, opts RenderOptions) error {
+		if opts.Template == nil {
+			return fmt.Errorf("the template format needs --template")
+		}
+		return executeTemplate(w, opts.Template, v)
+	}))
+	Register("csv", RendererFunc(renderCSV))
+	Register("markdown", RendererFunc(renderMarkdown))
+	Register(NDJSONFormat, RendererFunc(renderNDJSON))
+	Register(ParquetFormat, RendererFunc(renderParquet))
+}
+
+// NDJSONFormat writes one compact JSON value per line. List commands print
+// each page in it as the page arrives rather than when all are fetched.
+const NDJSONFormat = "ndjson"
+
+// renderNDJSON writes v as newline-delimited JSON: one line per list item,
+// or a single line for an object.
+func renderNDJSON(w io.Writer, v any, _ RenderOptions) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		items = []json.RawMessage{data}
+	}
+	var buf bytes.Buffer
+	for _, item := range items {
+		if err := json.Compact(&buf, item); err != nil {
+			return err
+		}
+		buf.WriteByte('\n')
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// renderCSV writes v as CSV with a header row: one row per list item, or a
+// single row for an object. Nested objects are flattened into dotted column
+// names and arrays are written as JSON.
+func renderCSV(w io.Writer, v any, _ RenderOptions) error {
+	header, rows, err := records(v)
+	if err != nil {
+		return err
+	}
+	if len(header) == 0 {
+		return nil
+	}
+	cw := csv.NewWriter(w)
+	cw.Write(header)
+	cw.WriteAll(rows)
+	return cw.Error()
+}
+
+// renderMarkdown writes v as a Markdown table, laid out as renderCSV does.
+func renderMarkdown(w io.Writer, v any, _ RenderOptions) error {
+	header, rows, err := records(v)
+	if err != nil {
+		return err
+	}
+	if len(header) == 0 {
+		return nil
+	}
+	cell := strings.NewReplacer("|", `\|`, "\r\n", " ", "\n", " ")
+	line := func(cells []string) {
+		escaped := make([]string, len(cells))
+		for i, c := range cells {
+			escaped[i] = cell.Replace(c)
+		}
+		fmt.Fprintf(w, "| %s |\n", strings.Join(escaped, " | "))
+	}
+	line(header)
+	rule := make([]string, len(header))
+	for i := range rule {
+		rule[i] = "---"
+	}
+	line(rule)
+	for _, r := range rows {
+		line(r)
+	}
+	return nil
+}
+
+// records flattens v, through its JSON encoding, into a header and rows. Keys
+// keep their JSON order; a key missing from an item is an empty cell.
+func records(v any) ([]string, [][]string, error) {
+	header, cells, err := leafRecords(v)
+	if err != nil {
+		return nil, nil, err
+	}
+	rows := make([][]string, len(cells))
+	for i, row := range cells {
+		rows[i] = make([]string, len(row))
+		for j, val := range row {
+			if val != nil {
+				rows[i][j] = fmt.Sprint(val)
+			}
+		}
+	}
+	return header, rows, nil
+}
+
+// leafRecords flattens v as records does, keeping each cell's JSON value: a
+// string, json.Number, bool, or nil for null and missing keys.
+func leafRecords(v any) ([]string, [][]any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	doc, err := decodeOrdered(dec)
+	if err != nil {
+		return nil, nil, err
+	}
+	items, ok := doc.([]any)
+	if !ok {
+		items = []any{doc}
+	}
+	var header []string
+	index := map[string]int{}
+	var flat []map[string]any
+	for _, item := range items {
+		rec := map[string]any{}
+		flatten("", item, func(key string, val any) {
+			if _, seen := index[key]; !seen {
+				index[key] = len(header)
+				header = append(header, key)
+			}
+			rec[key] = val
+		})
+		flat = append(flat, rec)
+	}
+	rows := make([][]any, len(flat))
+	for i, rec := range flat {
+		rows[i] = make([]any, len(header))
+		for key, val := range rec {
+			rows[i][index[key]] = val
+		}
+	}
+	return header, rows, nil
+}
+
+// member is one key of a JSON object decoded by decodeOrdered.
+type member struct {
+	key string
+	val any
+}
+
+// decodeOrdered decodes the next JSON value, keeping object keys in order:
+// objects become []member, arrays []any.
+func decodeOrdered(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		var obj []member
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			val, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, member{key.(string), val})
+		}
+		_, err := dec.Token()
+		return obj, err
+	case json.Delim('['):
+		arr := []any{}
+		for dec.More() {
+			val, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, val)
+		}
+		_, err := dec.Token()
+		return arr, err
+	}
+	return tok, nil
+}
+
+// flatten reports the leaves of v: nested object keys are joined with dots,
+// and arrays are kept whole as compact JSON.
+func flatten(prefix string, v any, emit func(key string, val any)) {
+	switch val := v.(type) {
+	case []member:
+		for _, m := range val {
+			key := m.key
+			if prefix != "" {
+				key = prefix + "." + m.key
+			}
+			flatten(key, m.val, emit)
+		}
+		return
+	}
+	if prefix == "" {
+		prefix = "value"
+	}
+	if arr, ok := v.([]any); ok {
+		emit(prefix, compactJSON(arr))
+		return
+	}
+	emit(prefix, v)
+}
+
+// compactJSON re-encodes a decodeOrdered array, keeping key order.
+func compactJSON(v any) string {
+	var b strings.Builder
+	var write func(v any)
+	write = func(v any) {
+		switch val := v.(type) {
+		case []member:
+			b.WriteByte('{')
+			for i, m := range val {
+				if i > 0 {
+					b.WriteByte(',')
+				}
+				k, _ := json.Marshal(m.key)
+				b.Write(k)
+				b.WriteByte(':')
+				write(m.val)
+			}
+			b.WriteByte('}')
+		case []any:
+			b.WriteByte('[')
+			for i, e := range val {
+				if i > 0 {
+					b.WriteByte(',')
+				}
+				write(e)
+			}
+			b.WriteByte(']')
+		default:
+			e, _ := json.Marshal(val)
+			b.Write(e)
+		}
+	}
+	write(v)
+	return b.String()
+}
@@ -0,0 +1,158 @@
+package output
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strconv"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/analysis"
+)
+
+// SegmentHistory prints every effort on a segment, oldest first. The
+// weather columns are shown when withWeather is set.
+func (p *Printer) SegmentHistory(efforts []analysis.SegmentEffort, withWeather bool) error {
+	if p.structured() {
+		if efforts == nil {
+			efforts = []analysis.SegmentEffort{}
+		}
+		return p.emit(efforts)
+	}
+	if len(efforts) == 0 {
+		fmt.Fprintln(p.w, "No efforts found.")
+		return nil
+	}
+	best := 0
+	for i, e := range efforts {
+		if e.ElapsedTime < efforts[best].ElapsedTime {
+			best = i
+		}
+	}
+	header := fmt.Sprintf("%-16s  %-9s  %-9s  %6s  %6s", "Date", "Elapsed", "Moving", "HR", "Power")
+	if withWeather {
+		header += fmt.Sprintf("  %6s  %-9s", "Temp", "Wind")
+	}
+	p.header(len([]rune(header))+14, "%s  %s\n", header, "Activity")
+	for i, e := range efforts {
+		hr, power := "-", "-"
+		if e.AverageHeartrate > 0 {
+			hr = fmt.Sprintf("%.0f", e.AverageHeartrate)
+		}
+		if e.AverageWatts > 0 {
+			power = fmt.Sprintf("%.0f W", e.AverageWatts)
+		}
+		line := fmt.Sprintf("%-16s  %-9s  %-9s  %6s  %6s", e.StartDateLocal.Format("2006-01-02 15:04"),
+			formatDuration(e.ElapsedTime), formatDuration(e.MovingTime), hr, power)
+		if withWeather {
+			temp, wind := "-", "-"
+			if e.Weather != nil {
+				temp, wind = p.temperature(e.Weather.TempC), p.speed(float32(e.Weather.WindSpeed))
+			}
+			line += fmt.Sprintf("  %6s  %-9s", temp, wind)
+		}
+		mark := ""
+		if i == best {
+			mark = "  ★"
+		}
+		fmt.Fprintf(p.w, "%s  %d%s\n", line, e.ActivityID, mark)
+	}
+	fmt.Fprintf(p.w, "\n%d effort(s); ★ marks the fastest.\n", len(efforts))
+	return nil
+}
+
+// SegmentHistoryCSV writes efforts as CSV for spreadsheets: one row per
+// effort with a header row, times in seconds and values in metric units
+// whatever the printer's units, and empty cells for missing values.
+func (p *Printer) SegmentHistoryCSV(efforts []analysis.SegmentEffort, withWeather bool) error {
+	num := func(v float64, prec int) string {
+		if v <= 0 {
+			return ""
+		}
+		return strconv.FormatFloat(v, 'f', prec, 64)
+	}
+	w := csv.NewWriter(p.w)
+	header := []string{"date", "effort_id", "activity_id", "elapsed_s", "moving_s", "avg_hr", "avg_watts"}
+	if withWeather {
+		header = append(header, "temp_c", "wind_ms")
+	}
+	if err := w.Write(header); err != nil {
+		return err
+	}
+	for _, e := range efforts {
+		row := []string{
+			e.StartDateLocal.Format("2006-01-02 15:04:05"),
+			strconv.FormatInt(e.ID, 10),
+			strconv.FormatInt(e.ActivityID, 10),
+			strconv.Itoa(e.ElapsedTime),
+			strconv.Itoa(e.MovingTime),
+			num(e.AverageHeartrate, 1),
+			num(e.AverageWatts, 1),
+		}
+		if withWeather {
+			temp, wind := "", ""
+			if e.Weather != nil {
+				temp = strconv.FormatFloat(e.Weather.TempC, 'f', 1, 64)
+				wind = strconv.FormatFloat(e.Weather.WindSpeed, 'f', 1, 64)
+			}
+			row = append(row, temp, wind)
+		}
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// SegmentLeaderboard prints your fastest efforts on a segment, at most top,
+// and how your record came down over time.
+func (p *Printer) SegmentLeaderboard(name string, ranked []analysis.RankedEffort, prs []analysis.PRStep, top int) error {
+	if p.structured() {
+		if ranked == nil {
+			ranked = []analysis.RankedEffort{}
+		}
+		if prs == nil {
+			prs = []analysis.PRStep{}
+		}
+		return p.emit(struct {
+			Segment string                  `json:"segment"`
+			Efforts []analysis.RankedEffort `json:"efforts"`
+			PRs     []analysis.PRStep       `json:"prs"`
+		}{name, ranked, prs})
+	}
+	if len(ranked) == 0 {
+		fmt.Fprintln(p.w, "No efforts found.")
+		return nil
+	}
+	fmt.Fprintf(p.w, "%s — %d effort(s)\n\n", name, len(ranked))
+	p.header(66, "%4s  %-9s  %-8s  %-10s  %6s  %6s  %s\n", "Rank", "Time", "Behind", "Date", "HR", "Power", "Activity")
+	for _, r := range ranked[:min(top, len(ranked))] {
+		behind := "-"
+		if r.Behind > 0 {
+			behind = "+" + formatDuration(r.Behind)
+		}
+		hr, power := "-", "-"
+		if r.AverageHeartrate > 0 {
+			hr = fmt.Sprintf("%.0f", r.AverageHeartrate)
+		}
+		if r.AverageWatts > 0 {
+			power = fmt.Sprintf("%.0f W", r.AverageWatts)
+		}
+		fmt.Fprintf(p.w, "%4d  %-9s  %-8s  %-10s  %6s  %6s  %d\n", r.Rank, formatDuration(r.ElapsedTime), behind,
+			r.StartDateLocal.Format("2006-01-02"), hr, power, r.ActivityID)
+	}
+	if len(ranked) > top {
+		fmt.Fprintf(p.w, "… %d slower effort(s) not shown (--top)\n", len(ranked)-top)
+	}
+
+	fmt.Fprintf(p.w, "\nPR progression\n\n")
+	p.header(44, "%-10s  %-9s  %-9s  %s\n", "Date", "Time", "Change", "Activity")
+	for _, s := range prs {
+		change := "first"
+		if s.Improvement > 0 {
+			change = "-" + formatDuration(s.Improvement)
+		}
+		fmt.Fprintf(p.w, "%-10s  %-9s  %-9s  %d\n", s.StartDateLocal.Format("2006-01-02"),
+			formatDuration(s.ElapsedTime), change, s.ActivityID)
+	}
+	return nil
+}
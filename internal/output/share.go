@@ -0,0 +1,54 @@
+package output
+
+import (
+	"fmt"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/analysis"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/plot"
+)
+
+// showsPace reports whether a sport's speed is shown as a pace (see
+// sportPace): swimming and the foot sports.
+func showsPace(sport string) bool { return sport == "Swim" || analysis.IsFootSport(sport) }
+
+// CardStats returns the figures for an activity's share card, in the
+// printer's units: distance, moving time, pace or speed, and climbing for
+// activities that cover ground; time and heart rate for those that do not.
+func (p *Printer) CardStats(a analysis.Activity) []plot.Stat {
+	if a.Distance <= 0 {
+		stats := []plot.Stat{{Label: "Time", Value: formatDuration(a.MovingTime)}}
+		if a.AverageHeartrate > 0 {
+			stats = append(stats, plot.Stat{Label: "Avg HR", Value: fmt.Sprintf("%.0f bpm", a.AverageHeartrate)})
+		}
+		return stats
+	}
+	stats := []plot.Stat{
+		{Label: "Distance", Value: p.distance(float32(a.Distance))},
+		{Label: "Time", Value: formatDuration(a.MovingTime)},
+	}
+	switch {
+	case a.SportType == "Swim":
+		stats = append(stats, plot.Stat{Label: "Pace", Value: p.swimPace(a.AverageSpeed)})
+	case analysis.IsFootSport(a.SportType):
+		stats = append(stats, plot.Stat{Label: "Pace", Value: p.pace(float32(a.AverageSpeed))})
+	default:
+		stats = append(stats, plot.Stat{Label: "Speed", Value: p.speed(float32(a.AverageSpeed))})
+	}
+	if a.TotalElevationGain > 0 {
+		stats = append(stats, plot.Stat{Label: "Elevation", Value: p.elevation(float32(a.TotalElevationGain))})
+	}
+	return stats
+}
+
+// swimPace formats meters per second as time per 100 meters or yards.
+func (p *Printer) swimPace(ms float64) string {
+	if ms <= 0 {
+		return "-"
+	}
+	unit, meters := "/100m", 100.0
+	if p.Units == Imperial {
+		unit, meters = "/100yd", 91.44
+	}
+	s := int(meters/ms + 0.5)
+	return fmt.Sprintf("%d:%02d%s", s/60, s%60, unit)
+}
@@ -0,0 +1,53 @@
+package output
+
+// This file contains the styling of human-readable output: ANSI colors,
+// used only when Printer.Color is set.
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiFaint  = "\x1b[2m"
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[1;33m"
+)
+
+// Red returns s in red, for error messages on a terminal.
+func Red(s string) string { return paint(ansiRed, s) }
+
+func paint(code, s string) string {
+	if s == "" {
+		return s
+	}
+	return code + s + ansiReset
+}
+
+// style returns s in the ANSI style code when colors are on. Pad cells
+// before styling them: escape codes count towards fmt widths.
+func (p *Printer) style(code, s string) string {
+	if !p.Color {
+		return s
+	}
+	return paint(code, s)
+}
+
+// good marks an improvement, such as a lap faster than the one before.
+func (p *Printer) good(s string) string { return p.style(ansiGreen, s) }
+
+// highlight marks a personal record.
+func (p *Printer) highlight(s string) string { return p.style(ansiYellow, s) }
+
+// header prints a table's column headings, formatted with format and args,
+// and a rule width cells wide under them.
+func (p *Printer) header(width int, format string, args ...any) {
+	line := strings.TrimSuffix(fmt.Sprintf(format, args...), "\n")
+	text := strings.TrimLeft(line, "\n")
+	fmt.Fprint(p.w, line[:len(line)-len(text)])
+	fmt.Fprintln(p.w, p.style(ansiBold, text))
+	fmt.Fprintln(p.w, p.style(ansiFaint, strings.Repeat("─", width)))
+}
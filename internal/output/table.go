@@ -0,0 +1,559 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/analysis"
+)
+
+// This file implements the list tables whose columns and order are chosen
+// with --columns and --sort. The generated client's list element types are
+// anonymous structs of pointers, so rows are decoded into flat types first.
+
+// column is one selectable column of a list table.
+type column[T any] struct {
+	name   string // as given to --columns and --sort
+	header string
+	max    int // longer values are truncated; 0 means no limit
+	value  func(p *Printer, r T) string
+	key    func(r T) float64 // sort key; nil sorts by value, case-insensitively
+}
+
+// table renders rows with the columns selected by p.Columns (or defaults).
+func table[T any](p *Printer, all []column[T], defaults []string, rows []T) error {
+	names := p.Columns
+	if len(names) == 0 {
+		names = defaults
+	}
+	var cols []column[T]
+	for _, name := range names {
+		c, ok := findColumn(all, name)
+		if !ok {
+			return unknownColumn(all, name)
+		}
+		cols = append(cols, c)
+	}
+
+	cells := make([][]string, len(rows))
+	widths := make([]int, len(cols))
+	for i, c := range cols {
+		widths[i] = utf8.RuneCountInString(c.header)
+	}
+	for r, row := range rows {
+		cells[r] = make([]string, len(cols))
+		for i, c := range cols {
+			v := c.value(p, row)
+			if c.max > 0 {
+				v = truncate(v, c.max)
+			}
+			cells[r][i] = v
+			widths[i] = max(widths[i], utf8.RuneCountInString(v))
+		}
+	}
+
+	line := func(vals []string) string {
+		var b strings.Builder
+		for i, v := range vals {
+			if i == len(vals)-1 {
+				b.WriteString(v)
+				break
+			}
+			fmt.Fprintf(&b, "%-*s  ", widths[i], v)
+		}
+		return b.String()
+	}
+	headers := make([]string, len(cols))
+	total := 0
+	for i, c := range cols {
+		headers[i] = c.header
+		total += widths[i] + 2
+	}
+	p.header(max(0, total-2), "%s", line(headers))
+	for _, row := range cells {
+		fmt.Fprintln(p.w, line(row))
+	}
+	return nil
+}
+
+// sortOrder returns the permutation of rows requested by p.Sort
+// ("column[:asc|desc]"), or nil when no sort was requested.
+func sortOrder[T any](p *Printer, all []column[T], rows []T) ([]int, error) {
+	if p.Sort == "" {
+		return nil, nil
+	}
+	name, dir, _ := strings.Cut(p.Sort, ":")
+	desc := false
+	switch strings.ToLower(dir) {
+	case "", "asc":
+	case "desc":
+		desc = true
+	default:
+		return nil, fmt.Errorf("invalid --sort %q: direction must be asc or desc", p.Sort)
+	}
+	c, ok := findColumn(all, name)
+	if !ok {
+		return nil, unknownColumn(all, name)
+	}
+	less := func(a, b T) bool {
+		return strings.ToLower(c.value(p, a)) < strings.ToLower(c.value(p, b))
+	}
+	if c.key != nil {
+		less = func(a, b T) bool { return c.key(a) < c.key(b) }
+	}
+	order := make([]int, len(rows))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		if desc {
+			return less(rows[order[j]], rows[order[i]])
+		}
+		return less(rows[order[i]], rows[order[j]])
+	})
+	return order, nil
+}
+
+// permute returns s reordered by order (s itself when order is nil).
+func permute[E any](s []E, order []int) []E {
+	if order == nil {
+		return s
+	}
+	out := make([]E, len(s))
+	for i, j := range order {
+		out[i] = s[j]
+	}
+	return out
+}
+
+// listTable sorts and prints a list response: raw is the generated list
+// (emitted as-is, reordered, for JSON and templates) and body the response
+// body it was decoded from. Table rows are decoded from body when present,
+// since the generated types omit some fields (e.g. heart rate on summary
+// activities), and from raw otherwise.
+func listTable[E, T any](p *Printer, body []byte, raw []E, all []column[T], defaults []string) error {
+	var rows []T
+	var err error
+	if len(body) > 0 {
+		err = json.Unmarshal(body, &rows)
+	} else {
+		err = convert(raw, &rows)
+	}
+	if err != nil {
+		return fmt.Errorf("decode list: %w", err)
+	}
+	if len(rows) != len(raw) {
+		return fmt.Errorf("decode list: %d rows for %d items", len(rows), len(raw))
+	}
+	order, err := sortOrder(p, all, rows)
+	if err != nil {
+		return err
+	}
+	if p.structured() {
+		return p.emit(permute(raw, order))
+	}
+	return table(p, all, defaults, permute(rows, order))
+}
+
+// convert decodes src into dst through JSON, mapping the generated types onto
+// flat ones with the same JSON names.
+func convert(src, dst any) error {
+	b, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, dst)
+}
+
+func findColumn[T any](all []column[T], name string) (column[T], bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, c := range all {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return column[T]{}, false
+}
+
+func unknownColumn[T any](all []column[T], name string) error {
+	return fmt.Errorf("unknown column %q (available: %s)", name, strings.Join(columnNames(all), ", "))
+}
+
+func columnNames[T any](all []column[T]) []string {
+	names := make([]string, len(all))
+	for i, c := range all {
+		names[i] = c.name
+	}
+	return names
+}
+
+// ListColumns names the columns each list table offers, keyed by list.
+var ListColumns = map[string][]string{
+	"activities":      columnNames(activityColumns),
+	"routes":          columnNames(routeColumns),
+	"segments":        columnNames(segmentColumns),
+	"clubs":           columnNames(clubColumns),
+	"club-members":    columnNames(memberColumns),
+	"club-activities": columnNames(clubActivityColumns),
+	"efforts":         columnNames(effortColumns),
+}
+
+// ListDefaults names the columns each list table shows without --columns.
+var ListDefaults = map[string][]string{
+	"activities":      activityDefaults,
+	"routes":          routeDefaults,
+	"segments":        segmentDefaults,
+	"clubs":           clubDefaults,
+	"club-members":    memberDefaults,
+	"club-activities": clubActivityDefaults,
+	"efforts":         effortDefaults,
+}
+
+// ── activities ───────────────────────────────────────────────────────────────
+
+var activityDefaults = []string{"id", "name", "sport", "distance", "time", "date"}
+
+var activityColumns = []column[analysis.Activity]{
+	{name: "id", header: "ID",
+		value: func(_ *Printer, a analysis.Activity) string { return fmt.Sprint(a.ID) },
+		key:   func(a analysis.Activity) float64 { return float64(a.ID) }},
+	{name: "name", header: "Name", max: 30,
+		value: func(_ *Printer, a analysis.Activity) string { return a.Name }},
+	{name: "sport", header: "Sport", max: 18,
+		value: func(_ *Printer, a analysis.Activity) string { return a.SportType }},
+	{name: "distance", header: "Distance",
+		value: func(p *Printer, a analysis.Activity) string { return p.distance(float32(a.Distance)) },
+		key:   func(a analysis.Activity) float64 { return a.Distance }},
+	{name: "time", header: "Time",
+		value: func(_ *Printer, a analysis.Activity) string { return formatDuration(a.MovingTime) },
+		key:   func(a analysis.Activity) float64 { return float64(a.MovingTime) }},
+	{name: "elapsed", header: "Elapsed",
+		value: func(_ *Printer, a analysis.Activity) string { return formatDuration(a.ElapsedTime) },
+		key:   func(a analysis.Activity) float64 { return float64(a.ElapsedTime) }},
+	{name: "elevation", header: "Elev",
+		value: func(p *Printer, a analysis.Activity) string { return p.elevation(float32(a.TotalElevationGain)) },
+		key:   func(a analysis.Activity) float64 { return a.TotalElevationGain }},
+	{name: "speed", header: "Speed",
+		// As a pace for runs, walks and swims, as the sport reads it.
+		value: func(p *Printer, a analysis.Activity) string {
+			return optional(a.AverageSpeed, func(ms float32) string { return p.sportPace(a.SportType, float64(ms)) })
+		},
+		key: func(a analysis.Activity) float64 { return a.AverageSpeed }},
+	{name: "pace", header: "Pace",
+		value: func(p *Printer, a analysis.Activity) string {
+			if a.SportType == "Swim" {
+				return optional(a.AverageSpeed, func(ms float32) string { return p.swimPace(float64(ms)) })
+			}
+			return optional(a.AverageSpeed, p.pace)
+		},
+		// Faster first when ascending, like a race result; no speed sorts last.
+		key: func(a analysis.Activity) float64 { return paceKey(a.AverageSpeed) }},
+	{name: "hr", header: "HR",
+		value: func(_ *Printer, a analysis.Activity) string { return optionalf(a.AverageHeartrate, "%.0f") },
+		key:   func(a analysis.Activity) float64 { return a.AverageHeartrate }},
+	{name: "maxhr", header: "Max HR",
+		value: func(_ *Printer, a analysis.Activity) string { return optionalf(a.MaxHeartrate, "%.0f") },
+		key:   func(a analysis.Activity) float64 { return a.MaxHeartrate }},
+	{name: "power", header: "Power",
+		value: func(_ *Printer, a analysis.Activity) string { return optionalf(a.AverageWatts, "%.0f W") },
+		key:   func(a analysis.Activity) float64 { return a.AverageWatts }},
+	{name: "wpower", header: "Weighted",
+		value: func(_ *Printer, a analysis.Activity) string { return optionalf(a.WeightedAverageWatts, "%.0f W") },
+		key:   func(a analysis.Activity) float64 { return a.WeightedAverageWatts }},
+	{name: "kj", header: "kJ",
+		value: func(_ *Printer, a analysis.Activity) string { return optionalf(a.Kilojoules, "%.0f") },
+		key:   func(a analysis.Activity) float64 { return a.Kilojoules }},
+	// IF and TSS need the FTP set with "config set ftp"; for a fixed FTP they
+	// sort like normalized power and like time × its square.
+	{name: "if", header: "IF",
+		value: func(p *Printer, a analysis.Activity) string {
+			return optionalf(analysis.IntensityFactor(a, p.Thresholds.FTP), "%.2f")
+		},
+		key: func(a analysis.Activity) float64 { return analysis.IntensityFactor(a, 1) }},
+	{name: "tss", header: "TSS",
+		value: func(p *Printer, a analysis.Activity) string {
+			return optionalf(analysis.StressScore(a, p.Thresholds.FTP), "%.0f")
+		},
+		key: func(a analysis.Activity) float64 { return analysis.StressScore(a, 1) }},
+	{name: "gear", header: "Gear",
+		value: func(_ *Printer, a analysis.Activity) string { return a.GearID }},
+	{name: "class", header: "Class",
+		value: func(_ *Printer, a analysis.Activity) string {
+			if a.Class == "" {
+				return "-"
+			}
+			return string(a.Class)
+		},
+		key: func(a analysis.Activity) float64 { return float64(a.Class.Rank()) }},
+	{name: "meta", header: "Meta", max: 30,
+		value: func(_ *Printer, a analysis.Activity) string { return metaFields(a.Meta) }},
+	{name: "date", header: "Date",
+		value: func(_ *Printer, a analysis.Activity) string { return a.StartDateLocal.Format("2006-01-02 15:04") },
+		key:   func(a analysis.Activity) float64 { return float64(a.StartDateLocal.Unix()) }},
+}
+
+// ── routes ───────────────────────────────────────────────────────────────────
+
+type routeRow struct {
+	ID            int64   `json:"id"`
+	Name          string  `json:"name"`
+	Distance      float64 `json:"distance"`
+	ElevationGain float64 `json:"elevation_gain"`
+	EstimatedTime int     `json:"estimated_moving_time"`
+	Starred       bool    `json:"starred"`
+}
+
+var routeDefaults = []string{"id", "name", "distance", "elevation", "time"}
+
+var routeColumns = []column[routeRow]{
+	{name: "id", header: "ID",
+		value: func(_ *Printer, r routeRow) string { return fmt.Sprint(r.ID) },
+		key:   func(r routeRow) float64 { return float64(r.ID) }},
+	{name: "name", header: "Name", max: 35,
+		value: func(_ *Printer, r routeRow) string { return r.Name }},
+	{name: "distance", header: "Distance",
+		value: func(p *Printer, r routeRow) string { return p.distance(float32(r.Distance)) },
+		key:   func(r routeRow) float64 { return r.Distance }},
+	{name: "elevation", header: "Elev",
+		value: func(p *Printer, r routeRow) string { return p.elevation(float32(r.ElevationGain)) },
+		key:   func(r routeRow) float64 { return r.ElevationGain }},
+	{name: "time", header: "Est. Time",
+		value: func(_ *Printer, r routeRow) string { return formatDuration(r.EstimatedTime) },
+		key:   func(r routeRow) float64 { return float64(r.EstimatedTime) }},
+	{name: "starred", header: "Starred",
+		value: func(_ *Printer, r routeRow) string { return yesNo(r.Starred) }},
+}
+
+// ── segments ─────────────────────────────────────────────────────────────────
+
+type segmentRow struct {
+	ID            int64   `json:"id"`
+	Name          string  `json:"name"`
+	ActivityType  string  `json:"activity_type"`
+	Distance      float64 `json:"distance"`
+	AverageGrade  float64 `json:"average_grade"`
+	MaximumGrade  float64 `json:"maximum_grade"`
+	ElevationHigh float64 `json:"elevation_high"`
+	ElevationLow  float64 `json:"elevation_low"`
+	ClimbCategory int     `json:"climb_category"`
+	City          string  `json:"city"`
+}
+
+var segmentDefaults = []string{"id", "name", "distance", "grade", "city"}
+
+var segmentColumns = []column[segmentRow]{
+	{name: "id", header: "ID",
+		value: func(_ *Printer, s segmentRow) string { return fmt.Sprint(s.ID) },
+		key:   func(s segmentRow) float64 { return float64(s.ID) }},
+	{name: "name", header: "Name", max: 35,
+		value: func(_ *Printer, s segmentRow) string { return s.Name }},
+	{name: "type", header: "Type",
+		value: func(_ *Printer, s segmentRow) string { return s.ActivityType }},
+	{name: "distance", header: "Distance",
+		value: func(p *Printer, s segmentRow) string { return p.distance(float32(s.Distance)) },
+		key:   func(s segmentRow) float64 { return s.Distance }},
+	{name: "grade", header: "Grade",
+		value: func(_ *Printer, s segmentRow) string { return fmt.Sprintf("%.1f%%", s.AverageGrade) },
+		key:   func(s segmentRow) float64 { return s.AverageGrade }},
+	{name: "maxgrade", header: "Max Grade",
+		value: func(_ *Printer, s segmentRow) string { return fmt.Sprintf("%.1f%%", s.MaximumGrade) },
+		key:   func(s segmentRow) float64 { return s.MaximumGrade }},
+	{name: "climb", header: "Climb",
+		value: func(p *Printer, s segmentRow) string { return p.elevation(float32(s.ElevationHigh - s.ElevationLow)) },
+		key:   func(s segmentRow) float64 { return s.ElevationHigh - s.ElevationLow }},
+	{name: "category", header: "Cat",
+		value: func(_ *Printer, s segmentRow) string { return climbCategory(s.ClimbCategory) },
+		key:   func(s segmentRow) float64 { return float64(s.ClimbCategory) }},
+	{name: "city", header: "City",
+		value: func(_ *Printer, s segmentRow) string { return s.City }},
+}
+
+// ── clubs ────────────────────────────────────────────────────────────────────
+
+type clubRow struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	SportType   string `json:"sport_type"`
+	MemberCount int    `json:"member_count"`
+	City        string `json:"city"`
+	Country     string `json:"country"`
+	Private     bool   `json:"private"`
+}
+
+var clubDefaults = []string{"id", "name", "members", "location"}
+
+var clubColumns = []column[clubRow]{
+	{name: "id", header: "ID",
+		value: func(_ *Printer, c clubRow) string { return fmt.Sprint(c.ID) },
+		key:   func(c clubRow) float64 { return float64(c.ID) }},
+	{name: "name", header: "Name", max: 35,
+		value: func(_ *Printer, c clubRow) string { return c.Name }},
+	{name: "sport", header: "Sport",
+		value: func(_ *Printer, c clubRow) string { return c.SportType }},
+	{name: "members", header: "Members",
+		value: func(_ *Printer, c clubRow) string { return fmt.Sprint(c.MemberCount) },
+		key:   func(c clubRow) float64 { return float64(c.MemberCount) }},
+	{name: "location", header: "Location",
+		value: func(_ *Printer, c clubRow) string { return strings.TrimRight(c.City+", "+c.Country, ", ") }},
+	{name: "private", header: "Private",
+		value: func(_ *Printer, c clubRow) string { return yesNo(c.Private) }},
+}
+
+type memberRow struct {
+	Firstname string `json:"firstname"`
+	Lastname  string `json:"lastname"`
+	Member    string `json:"member"`
+	Admin     bool   `json:"admin"`
+	Owner     bool   `json:"owner"`
+}
+
+// role is the member's highest role: owner, admin or the membership state.
+func (m memberRow) role() string {
+	switch {
+	case m.Owner:
+		return "owner"
+	case m.Admin:
+		return "admin"
+	}
+	return m.Member
+}
+
+var memberDefaults = []string{"name", "role"}
+
+var memberColumns = []column[memberRow]{
+	{name: "name", header: "Name", max: 30,
+		value: func(_ *Printer, m memberRow) string { return m.Firstname + " " + m.Lastname }},
+	{name: "role", header: "Role",
+		value: func(_ *Printer, m memberRow) string { return m.role() }},
+}
+
+type clubActivityRow struct {
+	Name      string  `json:"name"`
+	SportType string  `json:"sport_type"`
+	Distance  float64 `json:"distance"`
+	Moving    int     `json:"moving_time"`
+	Elapsed   int     `json:"elapsed_time"`
+	Elevation float64 `json:"total_elevation_gain"`
+	Athlete   struct {
+		Firstname string `json:"firstname"`
+		Lastname  string `json:"lastname"`
+	} `json:"athlete"`
+}
+
+var clubActivityDefaults = []string{"name", "sport", "distance", "time"}
+
+var clubActivityColumns = []column[clubActivityRow]{
+	{name: "athlete", header: "Athlete", max: 24,
+		// The feed gives first name and last initial only.
+		value: func(_ *Printer, a clubActivityRow) string {
+			return strings.TrimSpace(a.Athlete.Firstname + " " + a.Athlete.Lastname)
+		}},
+	{name: "name", header: "Name", max: 30,
+		value: func(_ *Printer, a clubActivityRow) string { return a.Name }},
+	{name: "sport", header: "Sport", max: 16,
+		value: func(_ *Printer, a clubActivityRow) string { return a.SportType }},
+	{name: "distance", header: "Distance",
+		value: func(p *Printer, a clubActivityRow) string { return p.distance(float32(a.Distance)) },
+		key:   func(a clubActivityRow) float64 { return a.Distance }},
+	{name: "time", header: "Time",
+		value: func(_ *Printer, a clubActivityRow) string { return formatDuration(a.Moving) },
+		key:   func(a clubActivityRow) float64 { return float64(a.Moving) }},
+	{name: "elapsed", header: "Elapsed",
+		value: func(_ *Printer, a clubActivityRow) string { return formatDuration(a.Elapsed) },
+		key:   func(a clubActivityRow) float64 { return float64(a.Elapsed) }},
+	{name: "elevation", header: "Elev",
+		value: func(p *Printer, a clubActivityRow) string { return p.elevation(float32(a.Elevation)) },
+		key:   func(a clubActivityRow) float64 { return a.Elevation }},
+}
+
+// ── segment efforts ──────────────────────────────────────────────────────────
+
+type effortRow struct {
+	ID               int64     `json:"id"`
+	ElapsedTime      int       `json:"elapsed_time"`
+	MovingTime       int       `json:"moving_time"`
+	StartDateLocal   time.Time `json:"start_date_local"`
+	AverageWatts     float64   `json:"average_watts"`
+	AverageHeartrate float64   `json:"average_heartrate"`
+	PRRank           int       `json:"pr_rank"`
+}
+
+var effortDefaults = []string{"id", "time", "date"}
+
+var effortColumns = []column[effortRow]{
+	{name: "id", header: "ID",
+		value: func(_ *Printer, e effortRow) string { return fmt.Sprint(e.ID) },
+		key:   func(e effortRow) float64 { return float64(e.ID) }},
+	{name: "time", header: "Time",
+		value: func(_ *Printer, e effortRow) string { return formatDuration(e.ElapsedTime) },
+		key:   func(e effortRow) float64 { return float64(e.ElapsedTime) }},
+	{name: "moving", header: "Moving",
+		value: func(_ *Printer, e effortRow) string { return formatDuration(e.MovingTime) },
+		key:   func(e effortRow) float64 { return float64(e.MovingTime) }},
+	{name: "power", header: "Power",
+		value: func(_ *Printer, e effortRow) string { return optionalf(e.AverageWatts, "%.0f W") },
+		key:   func(e effortRow) float64 { return e.AverageWatts }},
+	{name: "hr", header: "HR",
+		value: func(_ *Printer, e effortRow) string { return optionalf(e.AverageHeartrate, "%.0f") },
+		key:   func(e effortRow) float64 { return e.AverageHeartrate }},
+	{name: "pr", header: "PR",
+		value: func(_ *Printer, e effortRow) string {
+			if e.PRRank == 0 {
+				return "-"
+			}
+			return fmt.Sprint(e.PRRank)
+		},
+		key: func(e effortRow) float64 { return float64(e.PRRank) }},
+	{name: "date", header: "Date",
+		value: func(_ *Printer, e effortRow) string { return e.StartDateLocal.Format("2006-01-02 15:04") },
+		key:   func(e effortRow) float64 { return float64(e.StartDateLocal.Unix()) }},
+}
+
+// ── cell helpers ─────────────────────────────────────────────────────────────
+
+// optional formats v with f, or "-" when v is zero (not recorded).
+func optional(v float64, f func(float32) string) string {
+	if v == 0 {
+		return "-"
+	}
+	return f(float32(v))
+}
+
+// optionalf formats v with format, or "-" when v is zero (not recorded).
+func optionalf(v float64, format string) string {
+	if v == 0 {
+		return "-"
+	}
+	return fmt.Sprintf(format, v)
+}
+
+// paceKey sorts speeds as paces: faster first, stationary last.
+func paceKey(speed float64) float64 {
+	if speed <= 0 {
+		return math.Inf(1)
+	}
+	return 1 / speed
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// climbCategory renders Strava's 0–5 climb category (5 is HC).
+func climbCategory(c int) string {
+	switch {
+	case c <= 0:
+		return "-"
+	case c == 5:
+		return "HC"
+	}
+	return fmt.Sprint(5 - c)
+}
@@ -0,0 +1,129 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// templateFuncs are available to --template in addition to the text/template
+// builtins. They accept the pointer fields of the generated API types as well
+// as plain values.
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"km":       func(meters any) string { return fmt.Sprintf("%.2f", toFloat(meters)/1000) },
+	"mi":       func(meters any) string { return fmt.Sprintf("%.2f", toFloat(meters)/metersPerMile) },
+	"duration": func(seconds any) string { return formatDuration(int(toFloat(seconds))) },
+	"date": func(v any) string {
+		if t, ok := deref(v).(time.Time); ok {
+			return t.Format("2006-01-02")
+		}
+		return ""
+	},
+	"str":   func(v any) string { return fmt.Sprint(deref(v)) },
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"join":  strings.Join,
+}
+
+// ParseTemplate parses a --template string. Field names are those of the Go
+// types (e.g. .Name, .Distance, .SportType), not the JSON keys.
+func ParseTemplate(text string) (*template.Template, error) {
+	t, err := template.New("output").Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --template: %w", err)
+	}
+	return t, nil
+}
+
+// executeTemplate renders v with t, one line per element when v is a list and
+// a single line otherwise.
+func executeTemplate(w io.Writer, t *template.Template, v any) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Slice {
+		return executeLine(w, t, v)
+	}
+	for i := 0; i < rv.Len(); i++ {
+		if err := executeLine(w, t, rv.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unknownField matches text/template's error for a missing field, which
+// spells out the whole (often anonymous, generated) struct type.
+var unknownField = regexp.MustCompile(`can't evaluate field (\w+) in type .*`)
+
+func executeLine(w io.Writer, t *template.Template, v any) error {
+	if err := t.Execute(w, v); err != nil {
+		msg := err.Error()
+		if m := unknownField.FindStringSubmatchIndex(msg); m != nil {
+			msg = msg[:m[0]] + fmt.Sprintf("no field %s (fields: %s)", msg[m[2]:m[3]], strings.Join(fieldNames(v), ", "))
+		}
+		return fmt.Errorf("execute --template: %s", msg)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// fieldNames lists the exported fields of the struct v (or points to).
+func fieldNames(v any) []string {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).IsExported() {
+			names = append(names, t.Field(i).Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// deref returns the value a pointer points to, or "" for a nil pointer.
+func deref(v any) any {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return ""
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return ""
+	}
+	return rv.Interface()
+}
+
+// toFloat converts any numeric value (or pointer to one) to float64; other
+// values convert to 0.
+func toFloat(v any) float64 {
+	rv := reflect.ValueOf(deref(v))
+	switch {
+	case rv.CanFloat():
+		return rv.Float()
+	case rv.CanInt():
+		return float64(rv.Int())
+	case rv.CanUint():
+		return float64(rv.Uint())
+	}
+	return 0
+}
@@ -0,0 +1,119 @@
+package parquet
+
+import (
+	"time"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/analysis"
+)
+
+// ActivityColumns are the columns of an activities table, one row per
+// activity (see ActivityRow). Units are the API's: meters, seconds, meters
+// per second.
+var ActivityColumns = []Column{
+	{"id", Int64},
+	{"name", String},
+	{"sport_type", String},
+	{"start_date", Timestamp},
+	{"start_date_local", LocalTimestamp},
+	{"distance", Double},
+	{"moving_time", Int64},
+	{"elapsed_time", Int64},
+	{"total_elevation_gain", Double},
+	{"average_speed", Double},
+	{"max_speed", Double},
+	{"average_heartrate", Double},
+	{"max_heartrate", Double},
+	{"average_watts", Double},
+	{"weighted_average_watts", Double},
+	{"kilojoules", Double},
+	{"suffer_score", Double},
+	{"gear_id", String},
+	{"commute", Bool},
+	{"trainer", Bool},
+	{"manual", Bool},
+	{"private", Bool},
+}
+
+// ActivityRow returns an activity's row of ActivityColumns. Heart rate,
+// power, energy, Relative Effort and gear are null when the activity has
+// none.
+func ActivityRow(a analysis.Activity) []any {
+	return []any{
+		a.ID, a.Name, a.SportType, a.StartDate, a.StartDateLocal,
+		a.Distance, a.MovingTime, a.ElapsedTime, a.TotalElevationGain,
+		a.AverageSpeed, a.MaxSpeed,
+		orNull(a.AverageHeartrate), orNull(a.MaxHeartrate),
+		orNull(a.AverageWatts), orNull(a.WeightedAverageWatts),
+		orNull(a.Kilojoules), orNull(a.SufferScore),
+		orNull(a.GearID),
+		a.Commute, a.Trainer, a.Manual, a.Private,
+	}
+}
+
+// StreamColumns are the columns of a streams table, one row per sample
+// (see StreamRows).
+var StreamColumns = []Column{
+	{"activity_id", Int64},
+	{"time", Timestamp},
+	{"elapsed", Int64},
+	{"distance", Double},
+	{"lat", Double},
+	{"lng", Double},
+	{"altitude", Double},
+	{"velocity", Double},
+	{"grade", Double},
+	{"heartrate", Int64},
+	{"cadence", Int64},
+	{"watts", Int64},
+	{"temp", Int64},
+	{"moving", Bool},
+}
+
+// StreamKeys are the streams StreamRows writes, with time.
+var StreamKeys = []string{
+	"time", "distance", "latlng", "altitude", "velocity_smooth", "grade_smooth",
+	"heartrate", "cadence", "watts", "temp", "moving",
+}
+
+// StreamRows returns the rows of StreamColumns for an activity's samples:
+// each at its time, with its seconds since the start as elapsed. A stream
+// the activity lacks is null.
+func StreamRows(a analysis.Activity, s *analysis.Streams) [][]any {
+	if s == nil {
+		return nil
+	}
+	n := len(s.Time)
+	rows := make([][]any, n)
+	for i, t := range s.Time {
+		row := []any{a.ID, a.StartDate.Add(time.Duration(t) * time.Second), t}
+		row = append(row, sample(s.Distance, i, n))
+		if len(s.Latlng) == n {
+			row = append(row, s.Latlng[i][0], s.Latlng[i][1])
+		} else {
+			row = append(row, nil, nil)
+		}
+		row = append(row,
+			sample(s.Altitude, i, n), sample(s.VelocitySmooth, i, n), sample(s.GradeSmooth, i, n),
+			sample(s.Heartrate, i, n), sample(s.Cadence, i, n), sample(s.Watts, i, n), sample(s.Temp, i, n),
+			sample(s.Moving, i, n))
+		rows[i] = row
+	}
+	return rows
+}
+
+// sample returns stream v's i'th value, or nil when v does not have all n.
+func sample[T any](v []T, i, n int) any {
+	if len(v) != n {
+		return nil
+	}
+	return v[i]
+}
+
+// orNull returns v, or nil for the zero value.
+func orNull[T comparable](v T) any {
+	var zero T
+	if v == zero {
+		return nil
+	}
+	return v
+}
@@ -0,0 +1,335 @@
+// Package parquet writes tables as Apache Parquet files, for pandas, Polars,
+// DuckDB and the like. It writes what a dump of activities and streams
+// needs, and no more: nullable columns of a few types, plain encoding,
+// no compression, one data page per column per row group.
+package parquet
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// Type is the type of a column's values.
+type Type int
+
+const (
+	Int64          Type = iota // int or int64
+	Double                     // float64
+	String                     // string
+	Bool                       // bool
+	Timestamp                  // time.Time, as UTC milliseconds
+	LocalTimestamp             // time.Time's wall clock, in milliseconds, without a time zone
+)
+
+// Column is a column of a table. Every column is nullable.
+type Column struct {
+	Name string
+	Type Type
+}
+
+// RowGroupRows is how many rows a Writer buffers before writing them out as
+// a row group.
+const RowGroupRows = 1 << 16
+
+const magic = "PAR1"
+
+// Parquet enum values this package writes.
+const (
+	physBoolean   = 0
+	physInt64     = 2
+	physDouble    = 5
+	physByteArray = 6
+
+	convertedUTF8            = 0
+	convertedTimestampMillis = 9
+
+	repetitionOptional = 1
+	encodingPlain      = 0
+	encodingRLE        = 3
+	pageData           = 0
+	codecUncompressed  = 0
+)
+
+// Writer writes rows to a Parquet file. Call Close to finish the file.
+type Writer struct {
+	w      io.Writer
+	cols   []Column
+	values [][]any // buffered rows, by column
+	rows   int     // buffered rows
+	offset int64   // bytes written
+	groups []rowGroup
+	total  int64
+}
+
+type rowGroup struct {
+	chunks []chunk
+	rows   int
+	size   int64
+}
+
+type chunk struct {
+	offset int64
+	size   int64
+	values int
+}
+
+// NewWriter starts a Parquet file of cols on w.
+func NewWriter(w io.Writer, cols []Column) (*Writer, error) {
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("parquet: no columns")
+	}
+	pw := &Writer{w: w, cols: cols, values: make([][]any, len(cols))}
+	if err := pw.write([]byte(magic)); err != nil {
+		return nil, err
+	}
+	return pw, nil
+}
+
+// Write adds a row, one value per column; nil is null.
+func (w *Writer) Write(row ...any) error {
+	if len(row) != len(w.cols) {
+		return fmt.Errorf("parquet: row has %d values, want %d", len(row), len(w.cols))
+	}
+	for i, v := range row {
+		w.values[i] = append(w.values[i], v)
+	}
+	w.rows++
+	if w.rows >= RowGroupRows {
+		return w.Flush()
+	}
+	return nil
+}
+
+// Flush writes the buffered rows out as a row group.
+func (w *Writer) Flush() error {
+	if w.rows == 0 {
+		return nil
+	}
+	g := rowGroup{rows: w.rows}
+	for i, c := range w.cols {
+		page, err := encodePage(c, w.values[i])
+		if err != nil {
+			return err
+		}
+		header := pageHeader(len(w.values[i]), len(page))
+		ch := chunk{offset: w.offset, size: int64(len(header) + len(page)), values: len(w.values[i])}
+		if err := w.write(header); err != nil {
+			return err
+		}
+		if err := w.write(page); err != nil {
+			return err
+		}
+		g.chunks = append(g.chunks, ch)
+		g.size += ch.size
+		w.values[i] = w.values[i][:0]
+	}
+	w.groups = append(w.groups, g)
+	w.total += int64(w.rows)
+	w.rows = 0
+	return nil
+}
+
+// Close writes the buffered rows and the file's metadata. It does not
+// close the underlying writer.
+func (w *Writer) Close() error {
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	meta := w.metadata()
+	footer := binary.LittleEndian.AppendUint32(meta, uint32(len(meta)))
+	return w.write(append(footer, magic...))
+}
+
+func (w *Writer) write(b []byte) error {
+	n, err := w.w.Write(b)
+	w.offset += int64(n)
+	if err != nil {
+		return fmt.Errorf("parquet: %w", err)
+	}
+	return nil
+}
+
+// encodePage encodes a column's values as a data page: their definition
+// levels (1 for a value, 0 for null), then the values that are not null.
+func encodePage(c Column, values []any) ([]byte, error) {
+	levels := make([]bool, len(values))
+	var data []byte
+	var bits []bool
+	for i, v := range values {
+		if v == nil {
+			continue
+		}
+		switch c.Type {
+		case Int64:
+			switch v := v.(type) {
+			case int:
+				data = binary.LittleEndian.AppendUint64(data, uint64(v))
+			case int64:
+				data = binary.LittleEndian.AppendUint64(data, uint64(v))
+			default:
+				return nil, typeError(c, v)
+			}
+		case Double:
+			f, ok := v.(float64)
+			if !ok {
+				return nil, typeError(c, v)
+			}
+			data = binary.LittleEndian.AppendUint64(data, math.Float64bits(f))
+		case String:
+			s, ok := v.(string)
+			if !ok {
+				return nil, typeError(c, v)
+			}
+			data = binary.LittleEndian.AppendUint32(data, uint32(len(s)))
+			data = append(data, s...)
+		case Bool:
+			b, ok := v.(bool)
+			if !ok {
+				return nil, typeError(c, v)
+			}
+			bits = append(bits, b)
+		case Timestamp, LocalTimestamp:
+			t, ok := v.(time.Time)
+			if !ok {
+				return nil, typeError(c, v)
+			}
+			if t.IsZero() {
+				continue
+			}
+			ms := t.UnixMilli()
+			if c.Type == LocalTimestamp {
+				ms = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC).UnixMilli()
+			}
+			data = binary.LittleEndian.AppendUint64(data, uint64(ms))
+		}
+		levels[i] = true
+	}
+	if c.Type == Bool {
+		data = packBits(bits)
+	}
+	rle := bitPacked(levels)
+	page := binary.LittleEndian.AppendUint32(nil, uint32(len(rle)))
+	page = append(page, rle...)
+	return append(page, data...), nil
+}
+
+func typeError(c Column, v any) error {
+	return fmt.Errorf("parquet: column %s: unexpected %T value", c.Name, v)
+}
+
+// packBits packs booleans eight to a byte, the first in the lowest bit.
+func packBits(bits []bool) []byte {
+	b := make([]byte, (len(bits)+7)/8)
+	for i, v := range bits {
+		if v {
+			b[i/8] |= 1 << (i % 8)
+		}
+	}
+	return b
+}
+
+// bitPacked encodes levels of bit width 1 as one bit-packed run of the
+// RLE/bit-packing hybrid encoding.
+func bitPacked(levels []bool) []byte {
+	groups := (len(levels) + 7) / 8
+	b := binary.AppendUvarint(nil, uint64(groups)<<1|1)
+	return append(b, packBits(levels)...)
+}
+
+// pageHeader encodes the header of a data page of n values, size bytes.
+func pageHeader(n, size int) []byte {
+	t := newThrift()
+	t.i32(1, pageData)
+	t.i32(2, int32(size))
+	t.i32(3, int32(size))
+	t.begin(5)
+	t.i32(1, int32(n))
+	t.i32(2, encodingPlain)
+	t.i32(3, encodingRLE)
+	t.i32(4, encodingRLE)
+	t.end()
+	return t.bytes()
+}
+
+// metadata encodes the file's FileMetaData.
+func (w *Writer) metadata() []byte {
+	t := newThrift()
+	t.i32(1, 1)
+	t.list(2, tStruct, len(w.cols)+1)
+	t.begin(0)
+	t.string(4, "schema")
+	t.i32(5, int32(len(w.cols)))
+	t.end()
+	for _, c := range w.cols {
+		t.begin(0)
+		t.i32(1, physical(c.Type))
+		t.i32(3, repetitionOptional)
+		t.string(4, c.Name)
+		switch c.Type {
+		case String:
+			t.i32(6, convertedUTF8)
+			t.begin(10)
+			t.begin(1) // STRING
+			t.end()
+			t.end()
+		case Timestamp, LocalTimestamp:
+			if c.Type == Timestamp {
+				t.i32(6, convertedTimestampMillis)
+			}
+			t.begin(10)
+			t.begin(8) // TIMESTAMP
+			t.bool(1, c.Type == Timestamp)
+			t.begin(2)
+			t.begin(1) // MILLIS
+			t.end()
+			t.end()
+			t.end()
+			t.end()
+		}
+		t.end()
+	}
+	t.i64(3, w.total)
+	t.list(4, tStruct, len(w.groups))
+	for _, g := range w.groups {
+		t.begin(0)
+		t.list(1, tStruct, len(g.chunks))
+		for i, ch := range g.chunks {
+			t.begin(0)
+			t.i64(2, ch.offset)
+			t.begin(3)
+			t.i32(1, physical(w.cols[i].Type))
+			t.list(2, tI32, 2)
+			t.elemI32(encodingPlain)
+			t.elemI32(encodingRLE)
+			t.list(3, tBinary, 1)
+			t.elemString(w.cols[i].Name)
+			t.i32(4, codecUncompressed)
+			t.i64(5, int64(ch.values))
+			t.i64(6, ch.size)
+			t.i64(7, ch.size)
+			t.i64(9, ch.offset)
+			t.end()
+			t.end()
+		}
+		t.i64(2, g.size)
+		t.i64(3, int64(g.rows))
+		t.end()
+	}
+	t.string(6, "strava-cli")
+	return t.bytes()
+}
+
+func physical(t Type) int32 {
+	switch t {
+	case Double:
+		return physDouble
+	case String:
+		return physByteArray
+	case Bool:
+		return physBoolean
+	}
+	return physInt64
+}
@@ -0,0 +1,173 @@
+package parquet_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/analysis"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/parquet"
+)
+
+// decoder reads the Thrift compact protocol into maps of field ID to value:
+// int64, []byte, bool, []any or map[int16]any.
+type decoder struct {
+	b []byte
+	t *testing.T
+}
+
+func (d *decoder) uvarint() uint64 {
+	v, n := binary.Uvarint(d.b)
+	if n <= 0 {
+		d.t.Fatal("bad varint")
+	}
+	d.b = d.b[n:]
+	return v
+}
+
+func (d *decoder) value(typ byte) any {
+	switch typ {
+	case 1, 2:
+		return typ == 1
+	case 3:
+		v := d.b[0]
+		d.b = d.b[1:]
+		return int64(v)
+	case 4, 5, 6:
+		v, n := binary.Varint(d.b)
+		d.b = d.b[n:]
+		return v
+	case 8:
+		n := d.uvarint()
+		v := d.b[:n]
+		d.b = d.b[n:]
+		return v
+	case 9:
+		h := d.b[0]
+		d.b = d.b[1:]
+		n, et := uint64(h>>4), h&0x0f
+		if n == 15 {
+			n = d.uvarint()
+		}
+		list := make([]any, n)
+		for i := range list {
+			if et == 1 || et == 2 {
+				// Booleans in lists take a byte each.
+				list[i] = d.b[0] == 1
+				d.b = d.b[1:]
+				continue
+			}
+			list[i] = d.value(et)
+		}
+		return list
+	case 12:
+		return d.strct()
+	}
+	d.t.Fatalf("unexpected thrift type %d", typ)
+	return nil
+}
+
+func (d *decoder) strct() map[int16]any {
+	fields := map[int16]any{}
+	var id int16
+	for {
+		h := d.b[0]
+		d.b = d.b[1:]
+		if h == 0 {
+			return fields
+		}
+		if delta := int16(h >> 4); delta != 0 {
+			id += delta
+		} else {
+			v, n := binary.Varint(d.b)
+			d.b = d.b[n:]
+			id = int16(v)
+		}
+		fields[id] = d.value(h & 0x0f)
+	}
+}
+
+func TestWriter_RoundTrip(t *testing.T) {
+	start := time.Date(2024, 6, 1, 7, 0, 0, 0, time.UTC)
+	acts := []analysis.Activity{
+		{ID: 1, Name: "Morning Run", SportType: "Run", StartDate: start, StartDateLocal: start.Add(2 * time.Hour), Distance: 5000, AverageHeartrate: 150, GearID: "g1"},
+		{ID: 2, Name: "Yoga", SportType: "Yoga", StartDate: start.AddDate(0, 0, 1), Commute: true},
+	}
+	var buf bytes.Buffer
+	w, err := parquet.NewWriter(&buf, parquet.ActivityColumns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range acts {
+		if err := w.Write(parquet.ActivityRow(a)...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	file := buf.Bytes()
+	if string(file[:4]) != "PAR1" || string(file[len(file)-4:]) != "PAR1" {
+		t.Fatal("file does not start and end with PAR1")
+	}
+	n := binary.LittleEndian.Uint32(file[len(file)-8:])
+	meta := (&decoder{b: file[len(file)-8-int(n) : len(file)-8], t: t}).strct()
+	if meta[3] != int64(2) {
+		t.Errorf("num_rows = %v, want 2", meta[3])
+	}
+	schema := meta[2].([]any)
+	if len(schema) != len(parquet.ActivityColumns)+1 {
+		t.Fatalf("schema has %d elements, want %d", len(schema), len(parquet.ActivityColumns)+1)
+	}
+	for i, c := range parquet.ActivityColumns {
+		if name := string(schema[i+1].(map[int16]any)[4].([]byte)); name != c.Name {
+			t.Errorf("schema[%d] = %s, want %s", i+1, name, c.Name)
+		}
+	}
+
+	// Read columns back from their pages: definition levels, then values.
+	chunks := meta[4].([]any)[0].(map[int16]any)[1].([]any)
+	page := func(col int) (levels byte, values []byte) {
+		cm := chunks[col].(map[int16]any)[3].(map[int16]any)
+		d := &decoder{b: file[cm[9].(int64):], t: t}
+		h := d.strct()
+		body := d.b[:h[3].(int64)]
+		rle := body[4 : 4+binary.LittleEndian.Uint32(body)]
+		return rle[len(rle)-1], body[4+len(rle):]
+	}
+	levels, values := page(0) // id
+	if levels != 0b11 || binary.LittleEndian.Uint64(values) != 1 || binary.LittleEndian.Uint64(values[8:]) != 2 {
+		t.Errorf("id column: levels %b, values %v", levels, values)
+	}
+	levels, values = page(4) // start_date_local: the wall clock, as if UTC; null when unset
+	if want := start.Add(2 * time.Hour).UnixMilli(); levels != 0b01 || int64(binary.LittleEndian.Uint64(values)) != want {
+		t.Errorf("start_date_local column: levels %b, first %d, want %d", levels, binary.LittleEndian.Uint64(values), want)
+	}
+	levels, values = page(11) // average_heartrate: null for the yoga
+	if levels != 0b01 || len(values) != 8 || math.Float64frombits(binary.LittleEndian.Uint64(values)) != 150 {
+		t.Errorf("average_heartrate column: levels %b, values %v", levels, values)
+	}
+	levels, values = page(18) // commute
+	if levels != 0b11 || len(values) != 1 || values[0] != 0b10 {
+		t.Errorf("commute column: levels %b, values %v", levels, values)
+	}
+
+	if err := w.Write(1); err == nil {
+		t.Error("Write accepted a row of the wrong length")
+	}
+}
+
+func TestStreamRows(t *testing.T) {
+	a := analysis.Activity{ID: 7, StartDate: time.Date(2024, 6, 1, 7, 0, 0, 0, time.UTC)}
+	s := &analysis.Streams{Time: []int{0, 1}, Heartrate: []int{120, 121}, Latlng: [][2]float64{{1, 2}, {3, 4}}}
+	rows := parquet.StreamRows(a, s)
+	if len(rows) != 2 || len(rows[1]) != len(parquet.StreamColumns) {
+		t.Fatalf("StreamRows = %v", rows)
+	}
+	if rows[1][1] != a.StartDate.Add(time.Second) || rows[1][4] != 3.0 || rows[1][9] != 121 || rows[1][3] != nil {
+		t.Errorf("row = %v", rows[1])
+	}
+}
@@ -0,0 +1,107 @@
+package parquet
+
+import (
+	"encoding/binary"
+)
+
+// This file encodes the Thrift compact protocol, in which Parquet writes its
+// page headers and file metadata. Only what those need is here: structs of
+// integers, strings, booleans, lists and nested structs.
+
+// Compact protocol type codes.
+const (
+	tTrue   = 1
+	tFalse  = 2
+	tI32    = 5
+	tI64    = 6
+	tBinary = 8
+	tList   = 9
+	tStruct = 12
+)
+
+// thrift writes one struct, field by field in increasing ID order, into b.
+type thrift struct {
+	b    []byte
+	last []int16 // the previous field ID of each open struct
+}
+
+func (t *thrift) field(id int16, typ byte) {
+	prev := t.last[len(t.last)-1]
+	if d := id - prev; d > 0 && d <= 15 {
+		t.b = append(t.b, byte(d)<<4|typ)
+	} else {
+		t.b = append(t.b, typ)
+		t.b = binary.AppendVarint(t.b, int64(id))
+	}
+	t.last[len(t.last)-1] = id
+}
+
+func (t *thrift) i32(id int16, v int32) {
+	t.field(id, tI32)
+	t.b = binary.AppendVarint(t.b, int64(v))
+}
+
+func (t *thrift) i64(id int16, v int64) {
+	t.field(id, tI64)
+	t.b = binary.AppendVarint(t.b, v)
+}
+
+func (t *thrift) bool(id int16, v bool) {
+	if v {
+		t.field(id, tTrue)
+	} else {
+		t.field(id, tFalse)
+	}
+}
+
+func (t *thrift) string(id int16, s string) {
+	t.field(id, tBinary)
+	t.b = binary.AppendUvarint(t.b, uint64(len(s)))
+	t.b = append(t.b, s...)
+}
+
+// begin opens a nested struct: field id of the current struct, or, with id
+// 0, an element of a list.
+func (t *thrift) begin(id int16) {
+	if id != 0 {
+		t.field(id, tStruct)
+	}
+	t.last = append(t.last, 0)
+}
+
+// end closes the struct begin opened.
+func (t *thrift) end() {
+	t.b = append(t.b, 0)
+	t.last = t.last[:len(t.last)-1]
+}
+
+// list starts field id as a list of n elements of type typ, which follow.
+func (t *thrift) list(id int16, typ byte, n int) {
+	t.field(id, tList)
+	if n < 15 {
+		t.b = append(t.b, byte(n)<<4|typ)
+	} else {
+		t.b = append(t.b, 0xf0|typ)
+		t.b = binary.AppendUvarint(t.b, uint64(n))
+	}
+}
+
+// elemI32 and elemString write an element of a list.
+func (t *thrift) elemI32(v int32) {
+	t.b = binary.AppendVarint(t.b, int64(v))
+}
+
+func (t *thrift) elemString(s string) {
+	t.b = binary.AppendUvarint(t.b, uint64(len(s)))
+	t.b = append(t.b, s...)
+}
+
+// newThrift starts encoding a top-level struct.
+func newThrift() *thrift {
+	return &thrift{last: []int16{0}}
+}
+
+// bytes ends the top-level struct and returns its encoding.
+func (t *thrift) bytes() []byte {
+	return append(t.b, 0)
+}
@@ -0,0 +1,126 @@
+package plot
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/geo"
+)
+
+var (
+	textColor  = color.RGBA{0x24, 0x24, 0x24, 0xff}
+	mutedColor = color.RGBA{0x6d, 0x6d, 0x78, 0xff}
+)
+
+// Card text sizes in pixels, for a 1080-pixel-wide card; they scale with
+// the width.
+const (
+	titleSize    = 52
+	subtitleSize = 30
+	labelSize    = 26
+	valueSize    = 46
+)
+
+// Stat is one figure on a card, such as "Distance" and "42.20 km".
+type Stat struct {
+	Label string
+	Value string
+}
+
+// Card is a shareable summary of an activity: a title, the route, a row of
+// stats and the elevation profile. Route and profile are optional.
+type Card struct {
+	Title    string
+	Subtitle string
+	Stats    []Stat
+	Route    []geo.Point
+	Dist     []float64 // profile distances (m)
+	Ele      []float64 // profile elevations (m)
+}
+
+// Render draws the card. The route takes the space the text, stats and
+// profile leave; a square card of 1080 pixels suits most social media.
+func (c Card) Render(width, height int) (*image.RGBA, error) {
+	k := float64(width) / 1080
+	px := func(v float64) int { return int(v * k) }
+	face := func(src []byte, size float64) (font.Face, error) {
+		f, err := opentype.Parse(src)
+		if err != nil {
+			return nil, fmt.Errorf("load font: %w", err)
+		}
+		return opentype.NewFace(f, &opentype.FaceOptions{Size: size * k, DPI: 72, Hinting: font.HintingFull})
+	}
+	title, err := face(gobold.TTF, titleSize)
+	if err != nil {
+		return nil, err
+	}
+	subtitle, err := face(goregular.TTF, subtitleSize)
+	if err != nil {
+		return nil, err
+	}
+	label, err := face(goregular.TTF, labelSize)
+	if err != nil {
+		return nil, err
+	}
+	value, err := face(gobold.TTF, valueSize)
+	if err != nil {
+		return nil, err
+	}
+
+	img := canvas(width, height)
+	pad := px(56)
+	inner := width - 2*pad
+
+	y := pad + px(titleSize)
+	text(img, title, textColor, pad, y, inner, c.Title)
+	if c.Subtitle != "" {
+		y += px(subtitleSize * 1.6)
+		text(img, subtitle, mutedColor, pad, y, inner, c.Subtitle)
+	}
+	top := y + px(32)
+
+	bottom := height - pad
+	if n := min(len(c.Dist), len(c.Ele)); n >= 2 {
+		h := px(180)
+		draw.Draw(img, image.Rect(pad-margin, bottom-h, width-pad+margin, bottom),
+			Profile(c.Dist, c.Ele, inner+2*margin, h), image.Point{}, draw.Src)
+		bottom -= h + px(16)
+	}
+	if len(c.Stats) > 0 {
+		col := inner / len(c.Stats)
+		for i, s := range c.Stats {
+			x := pad + i*col
+			text(img, value, textColor, x, bottom, col-px(12), s.Value)
+			text(img, label, mutedColor, x, bottom-px(valueSize*1.25), col-px(12), s.Label)
+		}
+		bottom -= px(valueSize*1.25+labelSize) + px(40)
+	}
+	if len(c.Route) >= 2 && bottom-top > 4*margin {
+		// Map adds its own margin; let it reach into the padding.
+		r := image.Rect(pad-margin, top, width-pad+margin, bottom)
+		draw.Draw(img, r, Map(c.Route, r.Dx(), r.Dy()), image.Point{}, draw.Src)
+	}
+	return img, nil
+}
+
+// text draws s with its baseline at y, shortened with an ellipsis to fit
+// within maxWidth pixels.
+func text(img *image.RGBA, face font.Face, c color.Color, x, y, maxWidth int, s string) {
+	d := font.Drawer{Dst: img, Src: image.NewUniform(c), Face: face, Dot: fixed.P(x, y)}
+	limit := fixed.I(maxWidth)
+	if d.MeasureString(s) > limit {
+		r := []rune(s)
+		for len(r) > 0 && d.MeasureString(string(r)+"…") > limit {
+			r = r[:len(r)-1]
+		}
+		s = string(r) + "…"
+	}
+	d.DrawString(s)
+}
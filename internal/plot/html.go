@@ -0,0 +1,56 @@
+package plot
+
+import (
+	"encoding/json"
+	"html/template"
+	"io"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/geo"
+)
+
+// leafletPage is a self-contained map page: Leaflet and OpenStreetMap tiles
+// load from their CDNs, the track is inlined.
+var leafletPage = template.Must(template.New("map").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<link rel="stylesheet" href="https://unpkg.com/leaflet@1.9.4/dist/leaflet.css">
+<script src="https://unpkg.com/leaflet@1.9.4/dist/leaflet.js"></script>
+<style>html, body, #map { height: 100%; margin: 0; }</style>
+</head>
+<body>
+<div id="map"></div>
+<script>
+const track = {{.Track}};
+const map = L.map("map");
+L.tileLayer("https://tile.openstreetmap.org/{z}/{x}/{y}.png", {
+  maxZoom: 19,
+  attribution: '&copy; <a href="https://www.openstreetmap.org/copyright">OpenStreetMap</a> contributors'
+}).addTo(map);
+const line = L.polyline(track, {color: "#fc4c02", weight: 4}).addTo(map);
+L.circleMarker(track[0], {radius: 7, color: "#2ea043", fillOpacity: 1}).addTo(map).bindTooltip("Start");
+L.circleMarker(track[track.length - 1], {radius: 7, color: "#d02121", fillOpacity: 1}).addTo(map).bindTooltip("End");
+map.fitBounds(line.getBounds(), {padding: [24, 24]});
+</script>
+</body>
+</html>
+`))
+
+// LeafletHTML writes an HTML page showing pts on an OpenStreetMap map, with
+// start and end markers as Map draws them.
+func LeafletHTML(w io.Writer, title string, pts []geo.Point) error {
+	track := make([][2]float64, len(pts))
+	for i, p := range pts {
+		track[i] = [2]float64{p.Lat, p.Lng}
+	}
+	data, err := json.Marshal(track)
+	if err != nil {
+		return err
+	}
+	return leafletPage.Execute(w, struct {
+		Title string
+		Track template.JS
+	}{title, template.JS(data)})
+}
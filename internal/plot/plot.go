@@ -0,0 +1,218 @@
+// Package plot renders routes and elevation profiles as raster images for
+// sharing. Maps and profiles carry no text; captions belong in the
+// accompanying README or post. Cards combine them with a title and stats,
+// set in the Go fonts. LeafletHTML writes a route as an interactive web map
+// instead.
+package plot
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/geo"
+)
+
+// margin is the blank border, in pixels, around the plotted data.
+const margin = 16
+
+// markerSize is the side, in pixels, of the start and end markers.
+const markerSize = 14
+
+var (
+	background = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	gridColor  = color.RGBA{0xe6, 0xe6, 0xe6, 0xff}
+	lineColor  = color.RGBA{0xfc, 0x4c, 0x02, 0xff} // Strava orange
+	fillColor  = color.RGBA{0xfe, 0xd2, 0xbf, 0xff}
+	startColor = color.RGBA{0x2e, 0xa0, 0x43, 0xff}
+	endColor   = color.RGBA{0xd0, 0x21, 0x21, 0xff}
+)
+
+// Map draws pts as a line on a plain background, north up, with green and
+// red markers at the start and end. Longitudes are scaled by the cosine of
+// the mean latitude so the shape is not stretched.
+func Map(pts []geo.Point, width, height int) *image.RGBA {
+	img := canvas(width, height)
+	if len(pts) == 0 {
+		return img
+	}
+	sw, ne := geo.Bounds(pts)
+	kx := math.Cos((sw.Lat + ne.Lat) / 2 * math.Pi / 180)
+	spanX := (ne.Lng - sw.Lng) * kx
+	spanY := ne.Lat - sw.Lat
+	innerW, innerH := float64(width-2*margin), float64(height-2*margin)
+	scale := math.Min(innerW/math.Max(spanX, 1e-9), innerH/math.Max(spanY, 1e-9))
+	// Center the route in the frame.
+	offX := margin + (innerW-spanX*scale)/2
+	offY := margin + (innerH-spanY*scale)/2
+	project := func(p geo.Point) (int, int) {
+		x := offX + (p.Lng-sw.Lng)*kx*scale
+		y := offY + (ne.Lat-p.Lat)*scale
+		return int(math.Round(x)), int(math.Round(y))
+	}
+
+	path := make([]image.Point, len(pts))
+	for i, p := range pts {
+		path[i].X, path[i].Y = project(p)
+	}
+	Track(img, path)
+	return img
+}
+
+// Track draws path, in pixel coordinates, onto img the way Map does: an
+// orange line with green and red markers at the start and end.
+func Track(img *image.RGBA, path []image.Point) {
+	if len(path) == 0 {
+		return
+	}
+	for i := 1; i < len(path); i++ {
+		line(img, path[i-1].X, path[i-1].Y, path[i].X, path[i].Y, 3, lineColor)
+	}
+	end := path[len(path)-1]
+	dot(img, end.X, end.Y, markerSize, endColor)
+	dot(img, path[0].X, path[0].Y, markerSize, startColor)
+}
+
+// Profile draws elevation against distance as a filled area chart. The
+// vertical axis covers the elevation range plus 10% headroom, and at least
+// 50 m so that flat routes do not look hilly.
+func Profile(dist, ele []float64, width, height int) *image.RGBA {
+	img := canvas(width, height)
+	n := min(len(dist), len(ele))
+	if n < 2 || dist[n-1] <= dist[0] {
+		return img
+	}
+	lo, hi := ele[0], ele[0]
+	for _, v := range ele[:n] {
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+	}
+	if span := hi - lo; span < 50 {
+		lo -= (50 - span) / 2
+		hi += (50 - span) / 2
+	}
+	pad := (hi - lo) * 0.1
+	lo, hi = lo-pad, hi+pad
+
+	left, right := margin, width-margin
+	top, bottom := margin, height-margin
+	for i := 1; i < 4; i++ {
+		y := top + (bottom-top)*i/4
+		line(img, left, y, right, y, 1, gridColor)
+	}
+
+	toY := func(v float64) int {
+		return bottom - int(math.Round((v-lo)/(hi-lo)*float64(bottom-top)))
+	}
+	// Interpolate the elevation at every pixel column.
+	ys := make([]int, right-left+1)
+	j := 0
+	for x := range ys {
+		d := dist[0] + (dist[n-1]-dist[0])*float64(x)/float64(len(ys)-1)
+		for j < n-2 && dist[j+1] < d {
+			j++
+		}
+		t := 0.0
+		if dist[j+1] > dist[j] {
+			t = math.Max(0, math.Min(1, (d-dist[j])/(dist[j+1]-dist[j])))
+		}
+		ys[x] = toY(ele[j] + (ele[j+1]-ele[j])*t)
+	}
+	for x, y := range ys {
+		line(img, left+x, y, left+x, bottom, 1, fillColor)
+	}
+	for x := 1; x < len(ys); x++ {
+		line(img, left+x-1, ys[x-1], left+x, ys[x], 2, lineColor)
+	}
+	return img
+}
+
+// Line draws y against x as a line chart, for streams such as heart rate or
+// power over time. NaN values leave gaps. The vertical axis covers the range
+// of y plus 10% headroom.
+func Line(x, y []float64, width, height int) *image.RGBA {
+	img := canvas(width, height)
+	n := min(len(x), len(y))
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, v := range y[:n] {
+		if !math.IsNaN(v) {
+			lo, hi = math.Min(lo, v), math.Max(hi, v)
+		}
+	}
+	if n < 2 || x[n-1] <= x[0] || math.IsInf(lo, 1) {
+		return img
+	}
+	if hi == lo {
+		lo, hi = lo-1, hi+1
+	}
+	pad := (hi - lo) * 0.1
+	lo, hi = lo-pad, hi+pad
+
+	left, right := margin, width-margin
+	top, bottom := margin, height-margin
+	for i := 1; i < 4; i++ {
+		gy := top + (bottom-top)*i/4
+		line(img, left, gy, right, gy, 1, gridColor)
+	}
+	project := func(i int) (int, int) {
+		px := left + int(math.Round((x[i]-x[0])/(x[n-1]-x[0])*float64(right-left)))
+		py := bottom - int(math.Round((y[i]-lo)/(hi-lo)*float64(bottom-top)))
+		return px, py
+	}
+	for i := 1; i < n; i++ {
+		if math.IsNaN(y[i-1]) || math.IsNaN(y[i]) {
+			continue
+		}
+		x0, y0 := project(i - 1)
+		x1, y1 := project(i)
+		line(img, x0, y0, x1, y1, 2, lineColor)
+	}
+	return img
+}
+
+func canvas(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+	return img
+}
+
+// line draws a segment of the given thickness using Bresenham's algorithm.
+func line(img *image.RGBA, x0, y0, x1, y1, thickness int, c color.RGBA) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	e := dx + dy
+	for {
+		dot(img, x0, y0, thickness, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+// dot fills a square of side size centered on (x, y).
+func dot(img *image.RGBA, x, y, size int, c color.RGBA) {
+	r := image.Rect(x-size/2, y-size/2, x-size/2+size, y-size/2+size)
+	draw.Draw(img, r.Intersect(img.Bounds()), image.NewUniform(c), image.Point{}, draw.Src)
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,117 @@
+package plot_test
+
+import (
+	"image/color"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/geo"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/plot"
+)
+
+var white = color.RGBA{0xff, 0xff, 0xff, 0xff}
+
+func TestMap_MarksStartAndEnd(t *testing.T) {
+	// A west-to-east line: start on the left, end on the right.
+	pts := []geo.Point{{Lat: 51.5, Lng: -0.2}, {Lat: 51.5, Lng: -0.1}}
+	img := plot.Map(pts, 200, 100)
+
+	start, end := img.RGBAAt(16, 50), img.RGBAAt(183, 50)
+	if start.G <= start.R {
+		t.Errorf("start pixel %v should be green", start)
+	}
+	if end.R <= end.G || end == white {
+		t.Errorf("end pixel %v should be red", end)
+	}
+	if c := img.RGBAAt(100, 10); c != white {
+		t.Errorf("pixel away from the line = %v, want background", c)
+	}
+}
+
+func TestLeafletHTML_InlinesTrack(t *testing.T) {
+	var b strings.Builder
+	pts := []geo.Point{{Lat: 51.5, Lng: -0.2}, {Lat: 51.6, Lng: -0.1}}
+	if err := plot.LeafletHTML(&b, "Loop <b>", pts); err != nil {
+		t.Fatalf("LeafletHTML: %v", err)
+	}
+	page := b.String()
+	if !strings.Contains(page, "[[51.5,-0.2],[51.6,-0.1]]") {
+		t.Error("track not inlined as [lat,lng] pairs")
+	}
+	if !strings.Contains(page, "<title>Loop &lt;b&gt;</title>") {
+		t.Error("title not escaped")
+	}
+}
+
+func TestProfile_FillsBelowLine(t *testing.T) {
+	dist := []float64{0, 1000, 2000}
+	ele := []float64{100, 300, 100}
+	img := plot.Profile(dist, ele, 200, 100)
+
+	// The peak is in the middle: filled near the bottom, empty above it at the edges.
+	if c := img.RGBAAt(100, 80); c == white {
+		t.Error("area under the peak should be filled")
+	}
+	if c := img.RGBAAt(20, 30); c != white {
+		t.Errorf("pixel above the low start = %v, want background", c)
+	}
+}
+
+func TestLine_GapsAtNaN(t *testing.T) {
+	x := []float64{0, 1, 2, 3}
+	y := []float64{100, 100, math.NaN(), 100}
+	img := plot.Line(x, y, 200, 100)
+
+	// A flat line sits mid-height: drawn over the first third, not the second.
+	if c := img.RGBAAt(40, 50); c == white {
+		t.Error("line missing before the gap")
+	}
+	if c := img.RGBAAt(100, 50); int(c.R)-int(c.B) > 0x40 {
+		t.Errorf("pixel in the gap = %v, want background or grid, not the line", c)
+	}
+}
+
+func TestProfile_TooFewPoints(t *testing.T) {
+	img := plot.Profile([]float64{0}, []float64{100}, 50, 20)
+	if c := img.RGBAAt(25, 10); c != white {
+		t.Errorf("single point should render an empty image, got %v", c)
+	}
+}
+
+func TestCard_DrawsTextRouteAndProfile(t *testing.T) {
+	card := plot.Card{
+		Title:    "Morning Ride",
+		Subtitle: "Sun 2 Jun 2024 · Ride",
+		Stats:    []plot.Stat{{Label: "Distance", Value: "42.20 km"}, {Label: "Time", Value: "1h30m00s"}},
+		Route:    []geo.Point{{Lat: 51.5, Lng: -0.2}, {Lat: 51.6, Lng: -0.1}},
+		Dist:     []float64{0, 1000, 2000},
+		Ele:      []float64{100, 300, 100},
+	}
+	img, err := card.Render(540, 540)
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := func(y0, y1 int, match func(color.RGBA) bool) int {
+		n := 0
+		for y := y0; y < y1; y++ {
+			for x := 0; x < 540; x++ {
+				if match(img.RGBAAt(x, y)) {
+					n++
+				}
+			}
+		}
+		return n
+	}
+	dark := func(c color.RGBA) bool { return c.R < 0x80 && c.G < 0x80 && c.B < 0x80 }
+	orange := func(c color.RGBA) bool { return c == color.RGBA{0xfc, 0x4c, 0x02, 0xff} }
+	if count(0, 60, dark) == 0 {
+		t.Error("title should be drawn at the top")
+	}
+	if count(100, 300, orange) == 0 {
+		t.Error("route should be drawn below the title")
+	}
+	if count(450, 540, orange) == 0 {
+		t.Error("profile should be drawn at the bottom")
+	}
+}
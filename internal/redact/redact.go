@@ -0,0 +1,155 @@
+// Package redact keeps credentials out of text the CLI writes anywhere but
+// the config file: error messages, logs, audit lines and job records. It
+// scrubs the secrets it has been told about, plus anything shaped like one:
+// a Bearer token, or the value of a token, secret or authorization code
+// field in a URL query, form body, JSON document or header.
+package redact
+
+import (
+	"io"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Mask replaces each secret.
+const Mask = "[redacted]"
+
+// minSecretLen is the shortest value Add scrubs verbatim; shorter ones would
+// match ordinary text.
+const minSecretLen = 8
+
+// sensitiveKeys are the field names whose values are secrets, lower-case.
+// An authorization code only travels in URLs and form bodies; in JSON, as in
+// Strava's API errors, "code" is an error code.
+var sensitiveKeys = []string{
+	"access_token", "refresh_token", "client_secret", "id_token", "password", "token",
+}
+
+// sensitiveFlags are the command-line flags whose values are secrets, such
+// as the keys of the services the CLI pushes to. Args masks them before
+// anything registers them with Add.
+var sensitiveFlags = []string{
+	"access-token", "api-key", "client-secret", "password", "refresh-token", "secret", "token",
+}
+
+// sensitiveHeaders are the request and response headers whose values are
+// secrets, canonical.
+var sensitiveHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization", "Set-Cookie"}
+
+var (
+	bearer  = regexp.MustCompile(`(?i)\b(bearer\s+)[A-Za-z0-9._~+/=-]+`)
+	keys    = `(?:` + strings.Join(sensitiveKeys, "|") + `)`
+	query   = regexp.MustCompile(`(?i)([?&\s](?:code|` + keys + `)=)[^&\s"'#]+`)
+	jsonVal = regexp.MustCompile(`(?i)("` + keys + `"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+	// userinfo is the password in a URL, as in a proxy's.
+	userinfo = regexp.MustCompile(`(?i)\b([a-z][a-z0-9+.-]*://[^/\s:@]*:)[^/\s@]+@`)
+)
+
+var (
+	mu      sync.RWMutex
+	secrets = map[string]bool{}
+)
+
+// Add registers values to scrub wherever they appear, such as the tokens and
+// client secret once the config is loaded. Empty and short values are
+// ignored.
+func Add(values ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, v := range values {
+		if len(v) >= minSecretLen {
+			secrets[v] = true
+		}
+	}
+}
+
+// String returns s with every secret replaced by Mask.
+func String(s string) string {
+	mu.RLock()
+	for v := range secrets {
+		s = strings.ReplaceAll(s, v, Mask)
+	}
+	mu.RUnlock()
+	s = bearer.ReplaceAllString(s, "${1}"+Mask)
+	s = userinfo.ReplaceAllString(s, "${1}"+Mask+"@")
+	// A query at the start of s has no separator before it.
+	s = query.ReplaceAllString(" "+s, "${1}"+Mask)[1:]
+	return jsonVal.ReplaceAllString(s, `${1}"`+Mask+`"`)
+}
+
+// Args returns a redacted copy of a command line: the values of secret
+// flags, given as --flag value or --flag=value, are masked whether or not
+// they were registered, and so are passwords in URLs.
+func Args(args []string) []string {
+	out := make([]string, len(args))
+	maskNext := false
+	for i, a := range args {
+		if maskNext {
+			out[i] = Mask
+			maskNext = false
+			continue
+		}
+		out[i] = String(a)
+		name, _, hasValue := strings.Cut(strings.TrimPrefix(a, "--"), "=")
+		if !strings.HasPrefix(a, "--") || !slices.Contains(sensitiveFlags, strings.ToLower(name)) {
+			continue
+		}
+		if hasValue {
+			out[i] = "--" + name + "=" + Mask
+		} else {
+			maskNext = true
+		}
+	}
+	return out
+}
+
+// Error returns err with its message redacted, keeping it for errors.Is and
+// errors.As. A nil err stays nil.
+func Error(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &redacted{err}
+}
+
+type redacted struct{ err error }
+
+func (r *redacted) Error() string { return String(r.err.Error()) }
+func (r *redacted) Unwrap() error { return r.err }
+
+// Header returns a copy of h with the values of credential headers masked
+// and the rest redacted.
+func Header(h http.Header) http.Header {
+	out := make(http.Header, len(h))
+	for k, vs := range h {
+		masked := make([]string, len(vs))
+		for i, v := range vs {
+			masked[i] = String(v)
+			for _, s := range sensitiveHeaders {
+				if http.CanonicalHeaderKey(k) == s {
+					masked[i] = Mask
+				}
+			}
+		}
+		out[k] = masked
+	}
+	return out
+}
+
+// Writer returns a writer that redacts each write to w. Secrets split
+// across writes are not caught, so write whole lines.
+func Writer(w io.Writer) io.Writer {
+	return writer{w}
+}
+
+type writer struct{ w io.Writer }
+
+func (w writer) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.w, String(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
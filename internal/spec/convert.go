@@ -0,0 +1,165 @@
+package spec
+
+import "strings"
+
+// convert turns a resolved Swagger 2.0 document into OpenAPI 3.0, covering
+// what Strava's definition uses: parameters with inline types, body and form
+// parameters, and responses with a schema.
+func convert(doc map[string]any) map[string]any {
+	out := map[string]any{
+		"openapi":    "3.0.0",
+		"info":       doc["info"],
+		"paths":      map[string]any{},
+		"components": map[string]any{"schemas": map[string]any{}},
+	}
+	if host, ok := doc["host"].(string); ok {
+		scheme := "https"
+		if schemes, ok := doc["schemes"].([]any); ok && len(schemes) > 0 {
+			scheme, _ = schemes[0].(string)
+		}
+		basePath, _ := doc["basePath"].(string)
+		out["servers"] = []any{map[string]any{"url": scheme + "://" + host + basePath}}
+	}
+	consumes, _ := doc["consumes"].([]any)
+	paths, _ := doc["paths"].(map[string]any)
+	for p, item := range paths {
+		methods, _ := item.(map[string]any)
+		shared, _ := methods["parameters"].([]any)
+		converted := map[string]any{}
+		for m, op := range methods {
+			if isMethod(m) {
+				converted[m] = convertOperation(op.(map[string]any), shared, consumes)
+			}
+		}
+		out["paths"].(map[string]any)[p] = converted
+	}
+	return out
+}
+
+func convertOperation(op map[string]any, shared, consumes []any) map[string]any {
+	out := map[string]any{}
+	for _, k := range []string{"operationId", "summary", "description", "tags", "deprecated"} {
+		if v, ok := op[k]; ok {
+			out[k] = v
+		}
+	}
+	if c, ok := op["consumes"].([]any); ok {
+		consumes = c
+	}
+
+	own, _ := op["parameters"].([]any)
+	var params []any
+	form := map[string]any{}
+	var formRequired []any
+	multipart := false
+	for _, p := range append(shared, own...) {
+		param, _ := p.(map[string]any)
+		switch param["in"] {
+		case "body":
+			out["requestBody"] = map[string]any{
+				"required": param["required"] == true,
+				"content":  map[string]any{"application/json": map[string]any{"schema": param["schema"]}},
+			}
+		case "formData":
+			name, _ := param["name"].(string)
+			schema := paramSchema(param)
+			if schema["type"] == "file" {
+				schema = map[string]any{"type": "string", "format": "binary"}
+				multipart = true
+			}
+			if d, ok := param["description"]; ok {
+				schema["description"] = d
+			}
+			form[name] = schema
+			if param["required"] == true {
+				formRequired = append(formRequired, name)
+			}
+		default:
+			params = append(params, convertParameter(param))
+		}
+	}
+	if len(params) > 0 {
+		out["parameters"] = params
+	}
+	if len(form) > 0 {
+		mediaType := "application/x-www-form-urlencoded"
+		for _, c := range consumes {
+			if c == "multipart/form-data" {
+				multipart = true
+			}
+		}
+		if multipart {
+			mediaType = "multipart/form-data"
+		}
+		schema := map[string]any{"type": "object", "properties": form}
+		if len(formRequired) > 0 {
+			schema["required"] = formRequired
+		}
+		out["requestBody"] = map[string]any{
+			"content": map[string]any{mediaType: map[string]any{"schema": schema}},
+		}
+	}
+
+	responses := map[string]any{}
+	given, _ := op["responses"].(map[string]any)
+	for code, r := range given {
+		resp, _ := r.(map[string]any)
+		converted := map[string]any{"description": resp["description"]}
+		if schema, ok := resp["schema"]; ok {
+			converted["content"] = map[string]any{"application/json": map[string]any{"schema": schema}}
+		}
+		responses[code] = converted
+	}
+	out["responses"] = responses
+	return out
+}
+
+// convertParameter moves a path, query or header parameter's type into a
+// schema, turning collectionFormat into style and explode.
+func convertParameter(param map[string]any) map[string]any {
+	out := map[string]any{}
+	for _, k := range []string{"name", "in", "description", "required"} {
+		if v, ok := param[k]; ok {
+			out[k] = v
+		}
+	}
+	switch param["collectionFormat"] {
+	case "csv", nil:
+		if param["type"] == "array" {
+			out["style"] = "form"
+			out["explode"] = false
+		}
+	case "ssv":
+		out["style"] = "spaceDelimited"
+	case "pipes":
+		out["style"] = "pipeDelimited"
+	case "multi":
+		out["style"] = "form"
+		out["explode"] = true
+	}
+	if param["in"] != "query" {
+		delete(out, "style")
+		delete(out, "explode")
+	}
+	out["schema"] = paramSchema(param)
+	return out
+}
+
+// paramSchema collects the JSON Schema keywords of a Swagger parameter.
+func paramSchema(param map[string]any) map[string]any {
+	schema := map[string]any{}
+	for k, v := range param {
+		switch k {
+		case "name", "in", "description", "required", "collectionFormat", "allowEmptyValue":
+		default:
+			if !strings.HasPrefix(k, "x-") {
+				schema[k] = v
+			}
+		}
+	}
+	if items, ok := schema["items"].(map[string]any); ok {
+		// Items of a parameter array may carry their own collectionFormat.
+		delete(items, "collectionFormat")
+	}
+	return schema
+}
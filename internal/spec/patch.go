@@ -0,0 +1,214 @@
+package spec
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Operation is one JSON Patch (RFC 6902) operation. add, remove, replace
+// and test are supported.
+type Operation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Value any    `json:"value,omitempty"`
+}
+
+// Overlay patches one upstream document to fix a known bug in Strava's
+// spec before the client is generated from it.
+type Overlay struct {
+	Name        string      `json:"-"` // file name, for errors
+	Description string      `json:"description"`
+	Document    string      `json:"document"` // upstream file name, e.g. activity.json
+	Patch       []Operation `json:"patch"`
+}
+
+// LoadOverlays reads the overlays (*.json) in dir, in name order. A missing
+// directory has none.
+func LoadOverlays(dir string) ([]Overlay, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	var out []Overlay
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		var o Overlay
+		if err := unmarshal(data, &o); err != nil {
+			return nil, fmt.Errorf("parse overlay %s: %w", filepath.Base(p), err)
+		}
+		if o.Document == "" {
+			return nil, fmt.Errorf("overlay %s: document is required", filepath.Base(p))
+		}
+		o.Name = filepath.Base(p)
+		out = append(out, o)
+	}
+	return out, nil
+}
+
+// Apply patches doc, returning the result. An operation that no longer
+// applies fails the whole overlay: the bug it works around may be fixed.
+func (o Overlay) Apply(doc any) (any, error) {
+	for i, op := range o.Patch {
+		var err error
+		if doc, err = applyOp(doc, op); err != nil {
+			return nil, fmt.Errorf("overlay %s, operation %d (%s %s): %w", o.Name, i+1, op.Op, op.Path, err)
+		}
+	}
+	return doc, nil
+}
+
+func applyOp(doc any, op Operation) (any, error) {
+	tokens, err := splitPointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+	if op.Op == "test" {
+		got, err := lookup(doc, tokens)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(normalize(got), normalize(op.Value)) {
+			return nil, fmt.Errorf("test failed")
+		}
+		return doc, nil
+	}
+	if len(tokens) == 0 {
+		switch op.Op {
+		case "add", "replace":
+			return op.Value, nil
+		}
+		return nil, fmt.Errorf("cannot %s the whole document", op.Op)
+	}
+	parent, err := lookup(doc, tokens[:len(tokens)-1])
+	if err != nil {
+		return nil, err
+	}
+	key := tokens[len(tokens)-1]
+	switch p := parent.(type) {
+	case map[string]any:
+		_, exists := p[key]
+		switch op.Op {
+		case "add":
+			p[key] = op.Value
+		case "replace":
+			if !exists {
+				return nil, fmt.Errorf("no member %q", key)
+			}
+			p[key] = op.Value
+		case "remove":
+			if !exists {
+				return nil, fmt.Errorf("no member %q", key)
+			}
+			delete(p, key)
+		default:
+			return nil, fmt.Errorf("unsupported operation %q", op.Op)
+		}
+		return doc, nil
+	case []any:
+		// Arrays are values, so the patched one replaces the original in
+		// its parent.
+		i := len(p)
+		if key != "-" || op.Op != "add" {
+			if i, err = strconv.Atoi(key); err != nil || i < 0 || i > len(p) || (i == len(p) && op.Op != "add") {
+				return nil, fmt.Errorf("index %q out of range", key)
+			}
+		}
+		switch op.Op {
+		case "add":
+			p = append(p[:i], append([]any{op.Value}, p[i:]...)...)
+		case "replace":
+			p[i] = op.Value
+		case "remove":
+			p = append(p[:i], p[i+1:]...)
+		default:
+			return nil, fmt.Errorf("unsupported operation %q", op.Op)
+		}
+		return set(doc, tokens[:len(tokens)-1], p)
+	}
+	return nil, fmt.Errorf("%s is not an object or array", "/"+strings.Join(tokens[:len(tokens)-1], "/"))
+}
+
+// set replaces the value at tokens in doc.
+func set(doc any, tokens []string, v any) (any, error) {
+	if len(tokens) == 0 {
+		return v, nil
+	}
+	parent, err := lookup(doc, tokens[:len(tokens)-1])
+	if err != nil {
+		return nil, err
+	}
+	key := tokens[len(tokens)-1]
+	switch p := parent.(type) {
+	case map[string]any:
+		p[key] = v
+	case []any:
+		i, _ := strconv.Atoi(key)
+		p[i] = v
+	}
+	return doc, nil
+}
+
+// Pointer resolves a JSON Pointer (RFC 6901) in doc.
+func Pointer(doc any, ptr string) (any, error) {
+	tokens, err := splitPointer(ptr)
+	if err != nil {
+		return nil, err
+	}
+	return lookup(doc, tokens)
+}
+
+func lookup(doc any, tokens []string) (any, error) {
+	cur := doc
+	for _, t := range tokens {
+		switch c := cur.(type) {
+		case map[string]any:
+			v, ok := c[t]
+			if !ok {
+				return nil, fmt.Errorf("no member %q", t)
+			}
+			cur = v
+		case []any:
+			i, err := strconv.Atoi(t)
+			if err != nil || i < 0 || i >= len(c) {
+				return nil, fmt.Errorf("index %q out of range", t)
+			}
+			cur = c[i]
+		default:
+			return nil, fmt.Errorf("%q: not an object or array", t)
+		}
+	}
+	return cur, nil
+}
+
+func splitPointer(ptr string) ([]string, error) {
+	if ptr == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(ptr, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q", ptr)
+	}
+	tokens := strings.Split(ptr[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(t)
+	}
+	return tokens, nil
+}
+
+// normalize round-trips v through JSON so that values decoded differently
+// (json.Number or float64) compare equal.
+func normalize(v any) any {
+	data, _ := json.Marshal(v)
+	var out any
+	_ = json.Unmarshal(data, &out)
+	return out
+}
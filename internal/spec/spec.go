@@ -0,0 +1,268 @@
+// Package spec builds the trimmed OpenAPI document the API client is
+// generated from: it fetches Strava's published Swagger definition, patches
+// known bugs with local overlays, keeps the operations the CLI uses, inlines
+// every reference and converts the result to OpenAPI 3.
+package spec
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Upstream is where Strava publishes its API definition.
+const Upstream = "https://developers.strava.com/swagger/swagger.json"
+
+// Loader fetches the documents of a spec, applying overlays as each is
+// loaded. Each document is fetched once.
+type Loader struct {
+	Client   *http.Client
+	Overlays []Overlay
+	docs     map[string]any
+}
+
+// Load returns the document at rawURL (http(s) or a local path), patched by
+// the overlays naming it.
+func (l *Loader) Load(ctx context.Context, rawURL string) (any, error) {
+	if doc, ok := l.docs[rawURL]; ok {
+		return doc, nil
+	}
+	data, err := l.fetch(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	var doc any
+	if err := unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", rawURL, err)
+	}
+	for _, o := range l.Overlays {
+		if o.Document == path.Base(rawURL) {
+			if doc, err = o.Apply(doc); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if l.docs == nil {
+		l.docs = map[string]any{}
+	}
+	l.docs[rawURL] = doc
+	return doc, nil
+}
+
+func (l *Loader) fetch(ctx context.Context, rawURL string) ([]byte, error) {
+	if !isRemote(rawURL) {
+		return os.ReadFile(rawURL)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	c := l.Client
+	if c == nil {
+		c = http.DefaultClient
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("fetch %s: %w", rawURL, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch %s: HTTP %d", rawURL, resp.StatusCode)
+	}
+	return data, nil
+}
+
+func isRemote(rawURL string) bool {
+	return strings.HasPrefix(rawURL, "http://") || strings.HasPrefix(rawURL, "https://")
+}
+
+// Build loads the Swagger 2.0 definition at rawURL, keeps the operations in
+// ops (as "get /athlete"), inlines the references they make and returns the
+// equivalent OpenAPI 3 document.
+func (l *Loader) Build(ctx context.Context, rawURL string, ops []string) (map[string]any, error) {
+	doc, err := l.Load(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	root, ok := doc.(map[string]any)
+	if !ok || root["swagger"] != "2.0" {
+		return nil, fmt.Errorf("%s is not a Swagger 2.0 document", rawURL)
+	}
+	selected, err := selectOperations(root, ops)
+	if err != nil {
+		return nil, err
+	}
+	resolved, err := l.resolve(ctx, rawURL, selected, nil)
+	if err != nil {
+		return nil, err
+	}
+	return convert(resolved.(map[string]any)), nil
+}
+
+// Operations lists the operations of an OpenAPI or Swagger document, as
+// "get /athlete", sorted.
+func Operations(doc map[string]any) []string {
+	var out []string
+	paths, _ := doc["paths"].(map[string]any)
+	for p, item := range paths {
+		methods, _ := item.(map[string]any)
+		for m := range methods {
+			if isMethod(m) {
+				out = append(out, m+" "+p)
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// ParseOperation normalizes "GET /segments/{id}" to "get /segments/{id}".
+func ParseOperation(s string) (string, error) {
+	method, p, ok := strings.Cut(strings.TrimSpace(s), " ")
+	method = strings.ToLower(method)
+	p = strings.TrimSpace(p)
+	if !ok || !isMethod(method) || !strings.HasPrefix(p, "/") {
+		return "", fmt.Errorf("invalid operation %q: want a method and a path, e.g. \"GET /segments/{id}\"", s)
+	}
+	return method + " " + p, nil
+}
+
+func isMethod(m string) bool {
+	switch m {
+	case "get", "put", "post", "delete", "patch":
+		return true
+	}
+	return false
+}
+
+// selectOperations returns a copy of doc with only the operations in ops.
+func selectOperations(doc map[string]any, ops []string) (map[string]any, error) {
+	out := map[string]any{}
+	for k, v := range doc {
+		out[k] = v
+	}
+	paths, _ := doc["paths"].(map[string]any)
+	kept := map[string]any{}
+	var missing []string
+	for _, op := range ops {
+		method, p, _ := strings.Cut(op, " ")
+		item, _ := paths[p].(map[string]any)
+		operation, ok := item[method]
+		if !ok {
+			missing = append(missing, op)
+			continue
+		}
+		k, _ := kept[p].(map[string]any)
+		if k == nil {
+			k = map[string]any{}
+			if params, ok := item["parameters"]; ok {
+				k["parameters"] = params
+			}
+			kept[p] = k
+		}
+		k[method] = operation
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("not in the upstream spec: %s", strings.Join(missing, ", "))
+	}
+	out["paths"] = kept
+	return out, nil
+}
+
+// resolve returns v with every $ref replaced by what it points to, loading
+// other documents as needed; base is the URL of the document v is from.
+// stack holds the references being resolved, to detect cycles.
+func (l *Loader) resolve(ctx context.Context, base string, v any, stack []string) (any, error) {
+	switch val := v.(type) {
+	case map[string]any:
+		if ref, ok := val["$ref"].(string); ok {
+			return l.resolveRef(ctx, base, ref, stack)
+		}
+		out := make(map[string]any, len(val))
+		for k, sub := range val {
+			r, err := l.resolve(ctx, base, sub, stack)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = r
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(val))
+		for i, sub := range val {
+			r, err := l.resolve(ctx, base, sub, stack)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = r
+		}
+		return out, nil
+	}
+	return v, nil
+}
+
+func (l *Loader) resolveRef(ctx context.Context, base, ref string, stack []string) (any, error) {
+	docURL, ptr, _ := strings.Cut(ref, "#")
+	target := base
+	if docURL != "" && !isRemote(base) && !isRemote(docURL) {
+		target = filepath.Join(filepath.Dir(base), filepath.FromSlash(docURL))
+	} else if docURL != "" {
+		b, err := url.Parse(base)
+		if err != nil {
+			return nil, err
+		}
+		r, err := url.Parse(docURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid $ref %q: %w", ref, err)
+		}
+		target = b.ResolveReference(r).String()
+	}
+	key := target + "#" + ptr
+	for _, s := range stack {
+		if s == key {
+			return nil, fmt.Errorf("$ref cycle through %s: the client needs a type for it, which an inlined spec cannot give", key)
+		}
+	}
+	doc, err := l.Load(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	v, err := Pointer(doc, ptr)
+	if err != nil {
+		return nil, fmt.Errorf("$ref %q: %w", ref, err)
+	}
+	return l.resolve(ctx, target, v, append(stack, key))
+}
+
+// Marshal encodes a document the way it is committed: indented by two
+// spaces, with a trailing newline.
+func Marshal(doc any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// unmarshal decodes JSON keeping numbers as written.
+func unmarshal(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
@@ -0,0 +1,165 @@
+package spec_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/spec"
+)
+
+const swagger = `{
+  "swagger": "2.0",
+  "info": {"title": "Strava API v3", "version": "3.0"},
+  "host": "www.strava.com",
+  "basePath": "/api/v3",
+  "schemes": ["https"],
+  "paths": {
+    "/activities/{id}/streams": {
+      "get": {
+        "operationId": "getActivityStreams",
+        "parameters": [
+          {"name": "id", "in": "path", "required": true, "type": "integer", "format": "int64"},
+          {"name": "keys", "in": "query", "required": true, "type": "array",
+           "items": {"type": "string"}, "collectionFormat": "csv", "minItems": 1}
+        ],
+        "responses": {
+          "200": {"description": "ok", "schema": {"$ref": "activity.json#/Activity"}},
+          "default": {"description": "error", "schema": {"$ref": "#/definitions/Fault"}}
+        }
+      }
+    },
+    "/segments/{id}/starred": {
+      "put": {
+        "operationId": "starSegment",
+        "parameters": [
+          {"name": "id", "in": "path", "required": true, "type": "integer"},
+          {"name": "starred", "in": "formData", "required": true, "type": "boolean"}
+        ],
+        "responses": {"200": {"description": "ok"}}
+      }
+    }
+  },
+  "definitions": {
+    "Fault": {"type": "object", "properties": {"message": {"type": "string"}}}
+  }
+}`
+
+const activity = `{
+  "Activity": {"type": "object", "properties": {
+    "id": {"type": "integer", "format": "int64"},
+    "athlete": {"$ref": "#/Athlete"}
+  }},
+  "Athlete": {"type": "object", "properties": {"id": {"type": "integer"}}}
+}`
+
+func TestBuild(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/swagger/swagger.json":
+			w.Write([]byte(swagger))
+		case "/swagger/activity.json":
+			w.Write([]byte(activity))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	var overlay spec.Overlay
+	if err := json.Unmarshal([]byte(`{"document": "activity.json", "patch": [
+		{"op": "add", "path": "/Activity/properties/average_heartrate", "value": {"type": "number"}}
+	]}`), &overlay); err != nil {
+		t.Fatal(err)
+	}
+	l := &spec.Loader{Client: srv.Client(), Overlays: []spec.Overlay{overlay}}
+	doc, err := l.Build(context.Background(), srv.URL+"/swagger/swagger.json", []string{"get /activities/{id}/streams"})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	if got := spec.Operations(doc); !reflect.DeepEqual(got, []string{"get /activities/{id}/streams"}) {
+		t.Errorf("operations = %v", got)
+	}
+	data, err := spec.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out any
+	json.Unmarshal(data, &out)
+	check := func(ptr string, want any) {
+		t.Helper()
+		got, err := spec.Pointer(out, ptr)
+		if err != nil {
+			t.Errorf("%s: %v", ptr, err)
+			return
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s = %v, want %v", ptr, got, want)
+		}
+	}
+	op := "/paths/~1activities~1{id}~1streams/get"
+	schema := op + "/responses/200/content/application~1json/schema"
+	check("/openapi", "3.0.0")
+	check("/servers/0/url", "https://www.strava.com/api/v3")
+	check(op+"/parameters/1/style", "form")
+	check(op+"/parameters/1/explode", false)
+	check(op+"/parameters/1/schema/minItems", float64(1))
+	check(schema+"/properties/athlete/properties/id/type", "integer")
+	check(schema+"/properties/average_heartrate/type", "number")
+	check(op+"/responses/default/content/application~1json/schema/properties/message/type", "string")
+	if strings.Contains(string(data), "$ref") || strings.Contains(string(data), "collectionFormat") {
+		t.Errorf("output is not fully converted:\n%s", data)
+	}
+
+	if _, err := l.Build(context.Background(), srv.URL+"/swagger/swagger.json", []string{"get /nope"}); err == nil {
+		t.Error("Build succeeded with an operation missing upstream")
+	}
+}
+
+func TestBuildForm(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "swagger.json")
+	os.WriteFile(path, []byte(swagger), 0600)
+
+	l := &spec.Loader{}
+	doc, err := l.Build(context.Background(), path, []string{"put /segments/{id}/starred"})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	got, err := spec.Pointer(doc, "/paths/~1segments~1{id}~1starred/put/requestBody/content/application~1x-www-form-urlencoded/schema/required")
+	if err != nil || !reflect.DeepEqual(got, []any{"starred"}) {
+		t.Errorf("form body required = %v (%v)", got, err)
+	}
+}
+
+func TestOverlayApply(t *testing.T) {
+	doc := map[string]any{"a": map[string]any{"list": []any{"x", "y"}, "old": 1.0}}
+	o := spec.Overlay{Name: "fix.json", Patch: []spec.Operation{
+		{Op: "test", Path: "/a/old", Value: 1},
+		{Op: "replace", Path: "/a/old", Value: 2},
+		{Op: "add", Path: "/a/list/1", Value: "new"},
+		{Op: "add", Path: "/a/list/-", Value: "end"},
+		{Op: "remove", Path: "/a/list/0"},
+		{Op: "add", Path: "/a/sl~1ash", Value: true},
+	}}
+	got, err := o.Apply(doc)
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	want := map[string]any{"a": map[string]any{"list": []any{"new", "y", "end"}, "old": 2, "sl/ash": true}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Apply = %v, want %v", got, want)
+	}
+
+	stale := spec.Overlay{Name: "stale.json", Patch: []spec.Operation{{Op: "replace", Path: "/a/gone", Value: 1}}}
+	if _, err := stale.Apply(got); err == nil || !strings.Contains(err.Error(), "stale.json") {
+		t.Errorf("stale overlay: err = %v", err)
+	}
+}
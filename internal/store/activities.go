@@ -0,0 +1,228 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ActivitiesFile is the name of the synced activity history inside the
+// config directory.
+const ActivitiesFile = "activities.json"
+
+// Activities is the local copy of the athlete's activity history kept by
+// `sync`. Summaries are stored as the API returned them, so commands reading
+// the history see every field, including those the generated client omits.
+type Activities struct {
+	path         string
+	SyncedAt     time.Time                  `json:"synced_at"`
+	Summaries    map[string]json.RawMessage `json:"summaries"`              // keyed by activity ID
+	Descriptions map[string]string          `json:"descriptions,omitempty"` // from the detail endpoint, keyed by activity ID
+
+	// DetailHashes holds, per activity ID, the SummaryHash of the summary
+	// its description was fetched with, to tell when it may be out of date.
+	DetailHashes map[string]string `json:"detail_hashes,omitempty"`
+}
+
+// syncedSummary holds the summary fields the store itself needs.
+type syncedSummary struct {
+	ID        int64     `json:"id"`
+	StartDate time.Time `json:"start_date"`
+}
+
+// OpenActivities loads the synced history at path, or returns an empty one if
+// nothing has been synced yet. Pass "" to use the default location.
+func OpenActivities(path string) (*Activities, error) {
+	if path == "" {
+		p, err := Path(ActivitiesFile)
+		if err != nil {
+			return nil, err
+		}
+		path = p
+	}
+	a := &Activities{path: path}
+	if err := readJSON(path, a); err != nil {
+		return nil, err
+	}
+	if a.Summaries == nil {
+		a.Summaries = map[string]json.RawMessage{}
+	}
+	if a.Descriptions == nil {
+		a.Descriptions = map[string]string{}
+	}
+	if a.DetailHashes == nil {
+		a.DetailHashes = map[string]string{}
+	}
+	return a, nil
+}
+
+// SummaryHash fingerprints the fields of a summary that an edit to the
+// activity changes: its name, sport, start, distance, times, elevation
+// gain, gear and flags. Kudos, comments and the like are left out, so a
+// summary with the same hash has the same details as when last fetched.
+func SummaryHash(raw json.RawMessage) (string, error) {
+	var s struct {
+		Name          string  `json:"name"`
+		SportType     string  `json:"sport_type"`
+		WorkoutType   *int    `json:"workout_type"`
+		StartDate     string  `json:"start_date"`
+		Distance      float64 `json:"distance"`
+		MovingTime    int     `json:"moving_time"`
+		ElapsedTime   int     `json:"elapsed_time"`
+		ElevationGain float64 `json:"total_elevation_gain"`
+		GearID        string  `json:"gear_id"`
+		Commute       bool    `json:"commute"`
+		Trainer       bool    `json:"trainer"`
+		Private       bool    `json:"private"`
+		Visibility    string  `json:"visibility"`
+		HideFromHome  bool    `json:"hide_from_home"`
+	}
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return "", fmt.Errorf("parse activity: %w", err)
+	}
+	b, _ := json.Marshal(s)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:8]), nil
+}
+
+// Len returns the number of synced activities.
+func (a *Activities) Len() int { return len(a.Summaries) }
+
+// Put stores a summary activity, replacing any earlier copy, and returns its
+// ID and whether it is new to the store. Call Save to persist it.
+func (a *Activities) Put(raw json.RawMessage) (id int64, added bool, err error) {
+	var s syncedSummary
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return 0, false, fmt.Errorf("parse activity: %w", err)
+	}
+	if s.ID == 0 {
+		return 0, false, fmt.Errorf("parse activity: missing id")
+	}
+	key := strconv.FormatInt(s.ID, 10)
+	_, exists := a.Summaries[key]
+	a.Summaries[key] = raw
+	return s.ID, !exists, nil
+}
+
+// Get returns an activity's stored summary.
+func (a *Activities) Get(id int64) (json.RawMessage, bool) {
+	raw, ok := a.Summaries[strconv.FormatInt(id, 10)]
+	return raw, ok
+}
+
+// Has reports whether an activity is stored.
+func (a *Activities) Has(id int64) bool {
+	_, ok := a.Summaries[strconv.FormatInt(id, 10)]
+	return ok
+}
+
+// Remove drops an activity and its description.
+func (a *Activities) Remove(id int64) {
+	key := strconv.FormatInt(id, 10)
+	delete(a.Summaries, key)
+	delete(a.Descriptions, key)
+	delete(a.DetailHashes, key)
+}
+
+// IDs returns the IDs of every synced activity.
+func (a *Activities) IDs() []int64 {
+	ids := make([]int64, 0, len(a.Summaries))
+	for k := range a.Summaries {
+		if id, err := strconv.ParseInt(k, 10, 64); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// Latest returns the start time of the most recent synced activity, or the
+// zero time if none are synced.
+func (a *Activities) Latest() (time.Time, error) {
+	var latest time.Time
+	for _, raw := range a.Summaries {
+		var s syncedSummary
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return time.Time{}, fmt.Errorf("parse synced activity: %w", err)
+		}
+		if s.StartDate.After(latest) {
+			latest = s.StartDate
+		}
+	}
+	return latest, nil
+}
+
+// List returns the synced summaries newest first, as /athlete/activities
+// orders them.
+func (a *Activities) List() ([]json.RawMessage, error) {
+	type entry struct {
+		start time.Time
+		raw   json.RawMessage
+	}
+	entries := make([]entry, 0, len(a.Summaries))
+	for _, raw := range a.Summaries {
+		var s syncedSummary
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, fmt.Errorf("parse synced activity: %w", err)
+		}
+		entries = append(entries, entry{s.StartDate, raw})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].start.After(entries[j].start) })
+	out := make([]json.RawMessage, len(entries))
+	for i, e := range entries {
+		out[i] = e.raw
+	}
+	return out, nil
+}
+
+// Description returns the description fetched for an activity; ok is false
+// if it has not been fetched (as opposed to being empty).
+func (a *Activities) Description(id int64) (desc string, ok bool) {
+	desc, ok = a.Descriptions[strconv.FormatInt(id, 10)]
+	return desc, ok
+}
+
+// SetDescription records an activity's description, as of its stored
+// summary; call Save to persist it.
+func (a *Activities) SetDescription(id int64, desc string) {
+	key := strconv.FormatInt(id, 10)
+	a.Descriptions[key] = desc
+	if h, err := SummaryHash(a.Summaries[key]); err == nil {
+		a.DetailHashes[key] = h
+	}
+}
+
+// NeedsDetails reports whether an activity's description should be
+// fetched: it has none, or its summary has changed since it was fetched.
+// A description fetched before hashes were kept counts as current.
+func (a *Activities) NeedsDetails(id int64) bool {
+	key := strconv.FormatInt(id, 10)
+	if _, ok := a.Descriptions[key]; !ok {
+		return true
+	}
+	was, ok := a.DetailHashes[key]
+	if !ok {
+		return false
+	}
+	h, err := SummaryHash(a.Summaries[key])
+	return err == nil && h != was
+}
+
+// MissingDetails returns how many activities NeedsDetails.
+func (a *Activities) MissingDetails() int {
+	n := 0
+	for _, id := range a.IDs() {
+		if a.NeedsDetails(id) {
+			n++
+		}
+	}
+	return n
+}
+
+// Save writes the history to disk.
+func (a *Activities) Save() error {
+	return writeJSON(a.path, a)
+}
@@ -0,0 +1,113 @@
+package store
+
+import (
+	"strconv"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/analysis"
+)
+
+// Cache file names inside the config directory.
+const (
+	WeatherFile       = "weather.json"
+	EffortWeatherFile = "weather-efforts.json"
+	HRRFile           = "hrr.json"
+	ClassFile         = "classes.json"
+	StressFile        = "stress.json"
+	PowerZoneFile     = "power-zones.json"
+	BestsFile         = "best-efforts.json"
+	HTTPCacheDir      = "http-cache" // API responses, see client.DiskCache
+)
+
+// Cache remembers a value derived for each activity from an external service,
+// so reports over years of history only query that service once per activity.
+type Cache[V any] struct {
+	path    string
+	Entries map[string]V `json:"entries"` // keyed by activity ID
+}
+
+// OpenWeatherCache loads the weather looked up per activity. Pass "" to use
+// the default location.
+func OpenWeatherCache(path string) (*Cache[analysis.Weather], error) {
+	return openCache[analysis.Weather](WeatherFile, path)
+}
+
+// OpenEffortWeatherCache loads the weather looked up per segment effort,
+// keyed by effort ID: an effort can start hours into its activity. Pass ""
+// to use the default location.
+func OpenEffortWeatherCache(path string) (*Cache[analysis.Weather], error) {
+	return openCache[analysis.Weather](EffortWeatherFile, path)
+}
+
+// OpenHRRCache loads the heart rate recovery measured per activity. Pass ""
+// to use the default location.
+func OpenHRRCache(path string) (*Cache[analysis.HRRSummary], error) {
+	return openCache[analysis.HRRSummary](HRRFile, path)
+}
+
+// OpenClassCache loads the session classes (easy, tempo, threshold, VO2)
+// worked out per activity by --classify. Pass "" to use the default location.
+func OpenClassCache(path string) (*Cache[analysis.Classification], error) {
+	return openCache[analysis.Classification](ClassFile, path)
+}
+
+// OpenStressCache loads the Training Stress Scores worked out per activity
+// from its streams by "fitness --streams". Pass "" to use the default
+// location.
+func OpenStressCache(path string) (*Cache[analysis.Stress], error) {
+	return openCache[analysis.Stress](StressFile, path)
+}
+
+// OpenBestsCache loads the best efforts found per activity by "prs". Pass
+// "" to use the default location.
+func OpenBestsCache(path string) (*Cache[analysis.Bests], error) {
+	return openCache[analysis.Bests](BestsFile, path)
+}
+
+// OpenPowerZoneCache loads the time in power zones measured per activity by
+// "report zones --metric power". Pass "" to use the default location.
+func OpenPowerZoneCache(path string) (*Cache[analysis.ZoneTime], error) {
+	return openCache[analysis.ZoneTime](PowerZoneFile, path)
+}
+
+// OpenElevationCache loads the elevation gain (meters) computed per activity
+// from the named DEM dataset. Each dataset has its own file, since their
+// results differ. Pass "" to use the default location.
+func OpenElevationCache(dataset, path string) (*Cache[float64], error) {
+	return openCache[float64]("elevation-"+dataset+".json", path)
+}
+
+// openCache loads the cache at path (or the named file in the config
+// directory when path is ""), or returns an empty one if it does not exist.
+func openCache[V any](name, path string) (*Cache[V], error) {
+	if path == "" {
+		p, err := Path(name)
+		if err != nil {
+			return nil, err
+		}
+		path = p
+	}
+	c := &Cache[V]{path: path}
+	if err := readJSON(path, c); err != nil {
+		return nil, err
+	}
+	if c.Entries == nil {
+		c.Entries = map[string]V{}
+	}
+	return c, nil
+}
+
+// Lookup returns the value cached for an activity.
+func (c *Cache[V]) Lookup(activityID int64) (V, bool) {
+	v, ok := c.Entries[strconv.FormatInt(activityID, 10)]
+	return v, ok
+}
+
+// Set caches the value for an activity in memory; call Save to persist it.
+func (c *Cache[V]) Set(activityID int64, v V) {
+	c.Entries[strconv.FormatInt(activityID, 10)] = v
+}
+
+// Save writes the cache to disk.
+func (c *Cache[V]) Save() error {
+	return writeJSON(c.path, c)
+}
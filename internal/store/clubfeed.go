@@ -0,0 +1,106 @@
+package store
+
+import (
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/analysis"
+)
+
+// ClubFeedFile is the name of the club feed log inside the config directory.
+const ClubFeedFile = "club-feed.json"
+
+// ClubFeedRetention is how long entries stay in the log: long enough for a
+// yearly leaderboard.
+const ClubFeedRetention = 366 * 24 * time.Hour
+
+// ClubFeed logs the activities seen in club feeds, with when each was first
+// seen. The feed has no dates, so this log is what places activities in a
+// week or month.
+type ClubFeed struct {
+	path  string
+	Clubs map[string][]analysis.ClubEntry `json:"clubs"` // keyed by club ID
+}
+
+// OpenClubFeed loads the log at path, or returns an empty one if there is
+// none. Pass "" to use the default location.
+func OpenClubFeed(path string) (*ClubFeed, error) {
+	if path == "" {
+		p, err := Path(ClubFeedFile)
+		if err != nil {
+			return nil, err
+		}
+		path = p
+	}
+	f := &ClubFeed{path: path}
+	if err := readJSON(path, f); err != nil {
+		return nil, err
+	}
+	if f.Clubs == nil {
+		f.Clubs = map[string][]analysis.ClubEntry{}
+	}
+	return f, nil
+}
+
+// Record adds the entries of a club's feed that are not in the log yet,
+// stamped as first seen at now, drops entries older than ClubFeedRetention,
+// and returns how many were added. Identical entries (same athlete, name and
+// figures) are told apart by how many times each appears.
+func (f *ClubFeed) Record(clubID int64, entries []analysis.ClubEntry, now time.Time) int {
+	k := strconv.FormatInt(clubID, 10)
+	logged := slices.DeleteFunc(f.Clubs[k], func(e analysis.ClubEntry) bool {
+		return now.Sub(e.FirstSeen) > ClubFeedRetention
+	})
+	have := map[string]int{}
+	for _, e := range logged {
+		have[e.Key()]++
+	}
+	added := 0
+	for _, e := range entries {
+		if key := e.Key(); have[key] > 0 {
+			have[key]--
+			continue
+		}
+		e.FirstSeen = now
+		logged = append(logged, e)
+		added++
+	}
+	f.Clubs[k] = logged
+	return added
+}
+
+// Purge drops the entries first seen before before, and clubs left with
+// none, returning how many entries it dropped.
+func (f *ClubFeed) Purge(before time.Time) int {
+	n := 0
+	for k, entries := range f.Clubs {
+		kept := slices.DeleteFunc(entries, func(e analysis.ClubEntry) bool { return e.FirstSeen.Before(before) })
+		n += len(entries) - len(kept)
+		if len(kept) == 0 {
+			delete(f.Clubs, k)
+			continue
+		}
+		f.Clubs[k] = kept
+	}
+	return n
+}
+
+// Len returns the number of entries logged across all clubs.
+func (f *ClubFeed) Len() int {
+	n := 0
+	for _, entries := range f.Clubs {
+		n += len(entries)
+	}
+	return n
+}
+
+// Entries returns the logged entries of a club.
+func (f *ClubFeed) Entries(clubID int64) []analysis.ClubEntry {
+	return f.Clubs[strconv.FormatInt(clubID, 10)]
+}
+
+// Save writes the log to disk.
+func (f *ClubFeed) Save() error {
+	return writeJSON(f.path, f)
+}
@@ -0,0 +1,255 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/fsutil"
+)
+
+// CommandsFile is the name of the command history inside the config
+// directory: one JSON line per run of the CLI, appended as each ends.
+const CommandsFile = "commands.jsonl"
+
+// CommandRetention is how long "cache gc" keeps runs in the command history.
+const CommandRetention = 90 * 24 * time.Hour
+
+// Invocation is one run of the CLI and what it cost against the API rate
+// limits.
+type Invocation struct {
+	Command    string    `json:"command"`        // e.g. "activities list"
+	Args       []string  `json:"args,omitempty"` // command line, without the program name, redacted
+	StartedAt  time.Time `json:"started_at"`
+	DurationMS int64     `json:"duration_ms"`
+	Requests   int64     `json:"requests"` // API requests sent, retries included
+	Bytes      int64     `json:"bytes"`    // request and response bodies
+	Error      string    `json:"error,omitempty"`
+}
+
+// Duration is how long the run took.
+func (i Invocation) Duration() time.Duration {
+	return time.Duration(i.DurationMS) * time.Millisecond
+}
+
+// CommandLog is the command history file.
+type CommandLog struct {
+	path string
+}
+
+// OpenCommandLog returns the command history at path. Pass "" to use the
+// default location.
+func OpenCommandLog(path string) (*CommandLog, error) {
+	if path == "" {
+		p, err := Path(CommandsFile)
+		if err != nil {
+			return nil, err
+		}
+		path = p
+	}
+	return &CommandLog{path: path}, nil
+}
+
+// Append adds a run to the end of the history. Runs ending at the same time
+// in other processes each append a whole line.
+func (l *CommandLog) Append(inv Invocation) error {
+	line, err := json.Marshal(inv)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0700); err != nil {
+		return fmt.Errorf("create state dir: %w", err)
+	}
+	unlock, err := l.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("write %s: %w", filepath.Base(l.path), err)
+	}
+	return f.Close()
+}
+
+// List returns every run in the history, oldest first. A missing file is
+// an empty history.
+func (l *CommandLog) List() ([]Invocation, error) {
+	data, err := os.ReadFile(l.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", filepath.Base(l.path), err)
+	}
+	var out []Invocation
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(nil, 1<<20)
+	for n := 1; sc.Scan(); n++ {
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+		var inv Invocation
+		if err := json.Unmarshal(sc.Bytes(), &inv); err != nil {
+			return nil, fmt.Errorf("parse %s line %d: %w", filepath.Base(l.path), n, err)
+		}
+		out = append(out, inv)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", filepath.Base(l.path), err)
+	}
+	sort.SliceStable(out, func(a, b int) bool { return out[a].StartedAt.Before(out[b].StartedAt) })
+	return out, nil
+}
+
+// Prune removes the runs that started before t and returns how many it
+// removed (or, with dryRun, would remove).
+func (l *CommandLog) Prune(before time.Time, dryRun bool) (int, error) {
+	if !dryRun {
+		unlock, err := l.lock()
+		if errors.Is(err, fs.ErrNotExist) {
+			return 0, nil // no history yet
+		}
+		if err != nil {
+			return 0, err
+		}
+		defer unlock()
+	}
+	all, err := l.List()
+	if err != nil {
+		return 0, err
+	}
+	var buf bytes.Buffer
+	n := 0
+	for _, inv := range all {
+		if inv.StartedAt.Before(before) {
+			n++
+			continue
+		}
+		line, err := json.Marshal(inv)
+		if err != nil {
+			return 0, err
+		}
+		buf.Write(append(line, '\n'))
+	}
+	if n == 0 || dryRun {
+		return n, nil
+	}
+	if err := fsutil.WriteFile(l.path, buf.Bytes(), 0600); err != nil {
+		return 0, fmt.Errorf("write %s: %w", filepath.Base(l.path), err)
+	}
+	return n, nil
+}
+
+// lockWait is how long Append and Prune wait for each other's lock, and
+// staleLock the age at which a lock left by a crashed process is broken.
+const (
+	lockWait  = 2 * time.Second
+	staleLock = 30 * time.Second
+)
+
+// lock takes the history's lock file and returns the func that releases it.
+// Prune rewrites the file, so a run appended to it meanwhile would be lost
+// with the old file; holding the lock, it sees every run.
+func (l *CommandLog) lock() (func(), error) {
+	name := l.path + ".lock"
+	deadline := time.Now().Add(lockWait)
+	for {
+		f, err := os.OpenFile(name, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(name) }, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, fmt.Errorf("lock %s: %w", filepath.Base(l.path), err)
+		}
+		if info, err := os.Stat(name); err == nil && time.Since(info.ModTime()) > staleLock {
+			os.Remove(name)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("lock %s: held by another process (remove %s if none is running)", filepath.Base(l.path), name)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// CommandUsage is what one command cost over a day.
+type CommandUsage struct {
+	Command  string `json:"command"`
+	Runs     int    `json:"runs"`
+	Requests int64  `json:"requests"`
+	Bytes    int64  `json:"bytes"`
+}
+
+// DayUsage is what the runs of one day cost against the API: in total, and
+// per command, costliest first.
+type DayUsage struct {
+	Date       string         `json:"date"` // YYYY-MM-DD, local time
+	Runs       int            `json:"runs"`
+	Requests   int64          `json:"requests"`
+	Bytes      int64          `json:"bytes"`
+	DurationMS int64          `json:"duration_ms"`
+	Commands   []CommandUsage `json:"commands"`
+}
+
+// UsageByDay totals runs by the local day they started, newest day first.
+// Commands are ordered by requests, then runs, then name.
+func UsageByDay(invs []Invocation) []DayUsage {
+	byDate := map[string]*DayUsage{}
+	cmds := map[string]map[string]*CommandUsage{}
+	var dates []string
+	for _, inv := range invs {
+		date := inv.StartedAt.Local().Format("2006-01-02")
+		d := byDate[date]
+		if d == nil {
+			d = &DayUsage{Date: date}
+			byDate[date] = d
+			cmds[date] = map[string]*CommandUsage{}
+			dates = append(dates, date)
+		}
+		d.Runs++
+		d.Requests += inv.Requests
+		d.Bytes += inv.Bytes
+		d.DurationMS += inv.DurationMS
+		c := cmds[date][inv.Command]
+		if c == nil {
+			c = &CommandUsage{Command: inv.Command}
+			cmds[date][inv.Command] = c
+		}
+		c.Runs++
+		c.Requests += inv.Requests
+		c.Bytes += inv.Bytes
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(dates)))
+	out := make([]DayUsage, len(dates))
+	for i, date := range dates {
+		d := byDate[date]
+		for _, c := range cmds[date] {
+			d.Commands = append(d.Commands, *c)
+		}
+		sort.Slice(d.Commands, func(a, b int) bool {
+			x, y := d.Commands[a], d.Commands[b]
+			if x.Requests != y.Requests {
+				return x.Requests > y.Requests
+			}
+			if x.Runs != y.Runs {
+				return x.Runs > y.Runs
+			}
+			return x.Command < y.Command
+		})
+		out[i] = *d
+	}
+	return out
+}
@@ -0,0 +1,73 @@
+package store
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// DriftFile is the name of the --strict-decode drift report inside the config
+// directory.
+const DriftFile = "drift.json"
+
+// DriftField is a response field the generated client does not declare.
+type DriftField struct {
+	Endpoint  string    `json:"endpoint"` // e.g. /activities/{id}
+	Field     string    `json:"field"`    // e.g. segment_efforts[].new_field
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// DriftReport accumulates, across runs with --strict-decode, the fields
+// Strava returns that the generated client would drop: a sign it needs
+// regenerating.
+type DriftReport struct {
+	path   string
+	Fields []DriftField `json:"fields"`
+}
+
+// OpenDriftReport loads the report at path, or returns an empty one if there
+// is none. Pass "" to use the default location.
+func OpenDriftReport(path string) (*DriftReport, error) {
+	if path == "" {
+		p, err := Path(DriftFile)
+		if err != nil {
+			return nil, err
+		}
+		path = p
+	}
+	r := &DriftReport{path: path}
+	if err := readJSON(path, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Path returns where the report is stored.
+func (r *DriftReport) Path() string { return r.path }
+
+// Add records fields of endpoint seen at t, and returns how many were not in
+// the report yet.
+func (r *DriftReport) Add(endpoint string, fields []string, t time.Time) int {
+	added := 0
+	for _, f := range fields {
+		i := slices.IndexFunc(r.Fields, func(d DriftField) bool {
+			return d.Endpoint == endpoint && d.Field == f
+		})
+		if i >= 0 {
+			r.Fields[i].LastSeen = t
+			continue
+		}
+		r.Fields = append(r.Fields, DriftField{Endpoint: endpoint, Field: f, FirstSeen: t, LastSeen: t})
+		added++
+	}
+	slices.SortFunc(r.Fields, func(a, b DriftField) int {
+		return cmp.Or(cmp.Compare(a.Endpoint, b.Endpoint), cmp.Compare(a.Field, b.Field))
+	})
+	return added
+}
+
+// Save writes the report to disk.
+func (r *DriftReport) Save() error {
+	return writeJSON(r.path, r)
+}
@@ -0,0 +1,73 @@
+package store
+
+import "time"
+
+// FeaturesFile is the name of the cached API capability probe inside the
+// config directory.
+const FeaturesFile = "features.json"
+
+// Feature availability, as found by probing the API.
+const (
+	FeatureAvailable    = "available"
+	FeatureNeedsSummit  = "needs subscription" // HTTP 402
+	FeatureMissingScope = "missing scope"      // HTTP 403, or a scope not granted
+	FeatureUnknown      = "unknown"            // nothing to probe with (e.g. no activities yet)
+	FeatureError        = "error"
+)
+
+// Feature is the probed availability of one API capability.
+type Feature struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	HTTP     int    `json:"http,omitempty"` // status code of the probe request
+	Detail   string `json:"detail,omitempty"`
+	Commands string `json:"commands,omitempty"` // commands that need the capability
+}
+
+// Features is the result of the last `features` probe. It records the
+// athlete and scopes it was made with, since either changing invalidates it.
+type Features struct {
+	path      string
+	AthleteID int64     `json:"athlete_id"`
+	Summit    bool      `json:"summit"`
+	Scope     string    `json:"scope"`
+	CheckedAt time.Time `json:"checked_at"`
+	Features  []Feature `json:"features"`
+}
+
+// OpenFeatures loads the cached probe at path, or returns an empty one if
+// there is none. Pass "" to use the default location.
+func OpenFeatures(path string) (*Features, error) {
+	if path == "" {
+		p, err := Path(FeaturesFile)
+		if err != nil {
+			return nil, err
+		}
+		path = p
+	}
+	f := &Features{path: path}
+	if err := readJSON(path, f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Fresh reports whether the probe was made with scope within maxAge.
+func (f *Features) Fresh(scope string, maxAge time.Duration) bool {
+	return !f.CheckedAt.IsZero() && f.Scope == scope && time.Since(f.CheckedAt) < maxAge
+}
+
+// Lookup returns the probed availability of the named feature.
+func (f *Features) Lookup(name string) (Feature, bool) {
+	for _, ft := range f.Features {
+		if ft.Name == name {
+			return ft, true
+		}
+	}
+	return Feature{}, false
+}
+
+// Save writes the probe result to disk.
+func (f *Features) Save() error {
+	return writeJSON(f.path, f)
+}
@@ -0,0 +1,65 @@
+package store
+
+import "encoding/json"
+
+// HookQueueFile is the name of the post-sync hook's backlog inside the
+// config directory.
+const HookQueueFile = "post-sync-pending.json"
+
+// HookQueue holds the activities the post-sync hook has not taken yet,
+// because it failed or was interrupted, so the next sync that runs the hook
+// hands them over again along with its own new activities. Each activity
+// (a summary as the API returned it) is held once, by ID.
+type HookQueue struct {
+	path       string
+	Activities []json.RawMessage `json:"activities"`
+}
+
+// OpenHookQueue loads the backlog at path, or returns an empty one if the
+// file does not exist yet. Pass "" to use the default location.
+func OpenHookQueue(path string) (*HookQueue, error) {
+	if path == "" {
+		p, err := Path(HookQueueFile)
+		if err != nil {
+			return nil, err
+		}
+		path = p
+	}
+	q := &HookQueue{path: path}
+	if err := readJSON(path, q); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// Add appends the activities not held yet, keeping the latest summary of
+// those that are, and saves the backlog.
+func (q *HookQueue) Add(acts []json.RawMessage) error {
+	at := map[int64]int{}
+	for i, raw := range q.Activities {
+		at[summaryID(raw)] = i
+	}
+	for _, raw := range acts {
+		id := summaryID(raw)
+		if i, ok := at[id]; ok {
+			q.Activities[i] = raw
+			continue
+		}
+		at[id] = len(q.Activities)
+		q.Activities = append(q.Activities, raw)
+	}
+	return writeJSON(q.path, q)
+}
+
+// Clear empties the backlog and saves it.
+func (q *HookQueue) Clear() error {
+	q.Activities = nil
+	return writeJSON(q.path, q)
+}
+
+// summaryID is the ID of an activity summary, or 0 if it has none.
+func summaryID(raw json.RawMessage) int64 {
+	var s syncedSummary
+	_ = json.Unmarshal(raw, &s)
+	return s.ID
+}
@@ -0,0 +1,140 @@
+package store
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// JobsDir is the directory inside the config directory holding one state
+// file and one log file per background job.
+const JobsDir = "jobs"
+
+// Job states. A job is running until its process records how it ended;
+// a job whose process disappeared without doing so is reported by the jobs
+// command as cancelled (if a cancel was requested) or died.
+const (
+	JobRunning   = "running"
+	JobDone      = "done"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+	JobDied      = "died"
+)
+
+// Job is a command started in the background with --detach.
+type Job struct {
+	ID              string    `json:"id"`
+	Args            []string  `json:"args"` // command line, without the program name
+	PID             int       `json:"pid,omitempty"`
+	State           string    `json:"state"`
+	Error           string    `json:"error,omitempty"`
+	CancelRequested bool      `json:"cancel_requested,omitempty"`
+	StartedAt       time.Time `json:"started_at"`
+	FinishedAt      time.Time `json:"finished_at,omitzero"`
+}
+
+// Command returns the job's command line for display.
+func (j Job) Command() string { return strings.Join(j.Args, " ") }
+
+// Jobs is the directory of background job records.
+type Jobs struct {
+	dir string
+}
+
+// OpenJobs returns the job records in dir. Pass "" to use the default
+// location.
+func OpenJobs(dir string) (*Jobs, error) {
+	if dir == "" {
+		p, err := Path(JobsDir)
+		if err != nil {
+			return nil, err
+		}
+		dir = p
+	}
+	return &Jobs{dir: dir}, nil
+}
+
+// New records a new running job for args and returns it.
+func (s *Jobs) New(args []string) (*Job, error) {
+	b := make([]byte, 3)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	j := &Job{
+		ID:        hex.EncodeToString(b),
+		Args:      args,
+		State:     JobRunning,
+		StartedAt: time.Now().UTC(),
+	}
+	return j, s.Save(j)
+}
+
+// Save writes a job's record.
+func (s *Jobs) Save(j *Job) error {
+	return writeJSON(filepath.Join(s.dir, j.ID+".json"), j)
+}
+
+// Update loads a job, applies fn and saves it, so that the job's own process
+// and the jobs command each change only the fields they own.
+func (s *Jobs) Update(id string, fn func(*Job)) error {
+	j, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	fn(j)
+	return s.Save(j)
+}
+
+// Get loads a job by ID.
+func (s *Jobs) Get(id string) (*Job, error) {
+	if id == "" || strings.ContainsAny(id, `/\.`) {
+		return nil, fmt.Errorf("invalid job ID %q", id)
+	}
+	path := filepath.Join(s.dir, id+".json")
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, fmt.Errorf("no job %s (see: strava jobs list)", id)
+	}
+	j := &Job{}
+	if err := readJSON(path, j); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// List returns every job, most recently started first.
+func (s *Jobs) List() ([]Job, error) {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]Job, 0, len(paths))
+	for _, p := range paths {
+		var j Job
+		if err := readJSON(p, &j); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, j)
+	}
+	sort.Slice(jobs, func(a, b int) bool { return jobs[a].StartedAt.After(jobs[b].StartedAt) })
+	return jobs, nil
+}
+
+// Remove deletes a job's record and log.
+func (s *Jobs) Remove(id string) error {
+	for _, p := range []string{filepath.Join(s.dir, id+".json"), s.LogPath(id)} {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// LogPath returns the file a job's output is written to.
+func (s *Jobs) LogPath(id string) string {
+	return filepath.Join(s.dir, id+".log")
+}
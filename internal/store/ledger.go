@@ -0,0 +1,57 @@
+package store
+
+import "time"
+
+// LedgerFile is the name of the upload ledger inside the config directory.
+const LedgerFile = "uploads.json"
+
+// LedgerEntry records the outcome of uploading one file.
+type LedgerEntry struct {
+	Path       string    `json:"path"`
+	UploadID   int64     `json:"upload_id,omitempty"`
+	ActivityID int64     `json:"activity_id,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	UploadedAt time.Time `json:"uploaded_at"`
+}
+
+// Ledger maps file content hashes to upload outcomes so the same file is
+// never sent to Strava twice, even if it is renamed or copied again.
+type Ledger struct {
+	path    string
+	Entries map[string]LedgerEntry `json:"entries"`
+}
+
+// OpenLedger loads the ledger at path, or returns an empty one if the file
+// does not exist yet. Pass "" to use the default location.
+func OpenLedger(path string) (*Ledger, error) {
+	if path == "" {
+		p, err := Path(LedgerFile)
+		if err != nil {
+			return nil, err
+		}
+		path = p
+	}
+	l := &Ledger{path: path}
+	if err := readJSON(path, l); err != nil {
+		return nil, err
+	}
+	if l.Entries == nil {
+		l.Entries = map[string]LedgerEntry{}
+	}
+	return l, nil
+}
+
+// Lookup returns the entry recorded for a content hash.
+func (l *Ledger) Lookup(hash string) (LedgerEntry, bool) {
+	e, ok := l.Entries[hash]
+	return e, ok
+}
+
+// Record stores the entry for hash and saves the ledger to disk.
+func (l *Ledger) Record(hash string, e LedgerEntry) error {
+	if e.UploadedAt.IsZero() {
+		e.UploadedAt = time.Now().UTC()
+	}
+	l.Entries[hash] = e
+	return writeJSON(l.path, l)
+}
@@ -0,0 +1,86 @@
+package store
+
+import (
+	"maps"
+	"slices"
+	"strconv"
+)
+
+// MetaFile is the name of the local activity metadata inside the config
+// directory.
+const MetaFile = "meta.json"
+
+// Meta holds key/value fields attached to activities locally, for what
+// Strava's model has no place for (which shoe rotation, race or not, how the
+// legs felt). It never leaves the machine.
+type Meta struct {
+	path       string
+	Activities map[string]map[string]string `json:"activities"` // keyed by activity ID
+}
+
+// OpenMeta loads the metadata at path, or returns an empty store if there is
+// none. Pass "" to use the default location.
+func OpenMeta(path string) (*Meta, error) {
+	if path == "" {
+		p, err := Path(MetaFile)
+		if err != nil {
+			return nil, err
+		}
+		path = p
+	}
+	m := &Meta{path: path}
+	if err := readJSON(path, m); err != nil {
+		return nil, err
+	}
+	if m.Activities == nil {
+		m.Activities = map[string]map[string]string{}
+	}
+	return m, nil
+}
+
+// Get returns an activity's fields, or nil if it has none.
+func (m *Meta) Get(id int64) map[string]string {
+	return m.Activities[strconv.FormatInt(id, 10)]
+}
+
+// Set sets a field of an activity. Call Save to persist it.
+func (m *Meta) Set(id int64, key, value string) {
+	k := strconv.FormatInt(id, 10)
+	if m.Activities[k] == nil {
+		m.Activities[k] = map[string]string{}
+	}
+	m.Activities[k][key] = value
+}
+
+// Unset removes a field of an activity and reports whether it was set.
+func (m *Meta) Unset(id int64, key string) bool {
+	k := strconv.FormatInt(id, 10)
+	fields, ok := m.Activities[k]
+	if !ok {
+		return false
+	}
+	if _, ok := fields[key]; !ok {
+		return false
+	}
+	delete(fields, key)
+	if len(fields) == 0 {
+		delete(m.Activities, k)
+	}
+	return true
+}
+
+// Keys returns every field name in use, sorted.
+func (m *Meta) Keys() []string {
+	seen := map[string]bool{}
+	for _, fields := range m.Activities {
+		for k := range fields {
+			seen[k] = true
+		}
+	}
+	return slices.Sorted(maps.Keys(seen))
+}
+
+// Save writes the metadata to disk.
+func (m *Meta) Save() error {
+	return writeJSON(m.path, m)
+}
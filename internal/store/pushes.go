@@ -0,0 +1,84 @@
+package store
+
+import "time"
+
+// PushesFile is the name of the push ledger inside the config directory.
+const PushesFile = "pushes.json"
+
+// Pushes records which activities "push" has forwarded to each service, so
+// every activity is sent once, and from which date each service gets them.
+type Pushes struct {
+	path    string
+	Targets map[string]*PushTarget `json:"targets"`
+}
+
+// PushTarget is what has been pushed to one service.
+type PushTarget struct {
+	Since      time.Time           `json:"since"` // activities that started before are not pushed
+	Activities map[int64]PushEntry `json:"activities"`
+}
+
+// PushEntry records an activity pushed to a service.
+type PushEntry struct {
+	RemoteID string    `json:"remote_id,omitempty"`
+	PushedAt time.Time `json:"pushed_at"`
+}
+
+// OpenPushes loads the push ledger at path, or returns an empty one if the
+// file does not exist yet. Pass "" to use the default location.
+func OpenPushes(path string) (*Pushes, error) {
+	if path == "" {
+		p, err := Path(PushesFile)
+		if err != nil {
+			return nil, err
+		}
+		path = p
+	}
+	p := &Pushes{path: path}
+	if err := readJSON(path, p); err != nil {
+		return nil, err
+	}
+	if p.Targets == nil {
+		p.Targets = map[string]*PushTarget{}
+	}
+	return p, nil
+}
+
+// Target returns what has been pushed to a service, or nil if nothing has.
+func (p *Pushes) Target(name string) *PushTarget { return p.Targets[name] }
+
+// Pushed reports whether activity id has been pushed to a service.
+func (p *Pushes) Pushed(name string, id int64) bool {
+	t := p.Targets[name]
+	if t == nil {
+		return false
+	}
+	_, ok := t.Activities[id]
+	return ok
+}
+
+// Start sets the date from which a service gets activities. Call Save to
+// persist it.
+func (p *Pushes) Start(name string, since time.Time) {
+	t := p.Targets[name]
+	if t == nil {
+		t = &PushTarget{Activities: map[int64]PushEntry{}}
+		p.Targets[name] = t
+	}
+	t.Since = since.UTC()
+}
+
+// Record stores that activity id was pushed to a service, as remoteID
+// there, and saves the ledger to disk.
+func (p *Pushes) Record(name string, id int64, remoteID string) error {
+	t := p.Targets[name]
+	if t == nil {
+		p.Start(name, time.Time{})
+		t = p.Targets[name]
+	}
+	t.Activities[id] = PushEntry{RemoteID: remoteID, PushedAt: time.Now().UTC()}
+	return p.Save()
+}
+
+// Save writes the ledger to disk.
+func (p *Pushes) Save() error { return writeJSON(p.path, p) }
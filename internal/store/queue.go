@@ -0,0 +1,101 @@
+package store
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// QueueFile is the name of the upload queue inside the config directory.
+const QueueFile = "upload-queue.json"
+
+// QueuedUpload is a file accepted for upload whose outcome is not known yet:
+// not sent, or sent (UploadID set) and not yet processed by Strava. It keeps
+// everything needed to send it again.
+type QueuedUpload struct {
+	Path        string    `json:"path"` // absolute
+	Hash        string    `json:"hash"`
+	DataType    string    `json:"data_type"`
+	Name        string    `json:"name,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Trainer     bool      `json:"trainer,omitempty"`
+	Commute     bool      `json:"commute,omitempty"`
+	ExternalID  string    `json:"external_id"`
+	NoGzip      bool      `json:"no_gzip,omitempty"` // send large GPX/TCX files uncompressed
+	UploadID    int64     `json:"upload_id,omitempty"`
+	Attempts    int       `json:"attempts"`
+	LastError   string    `json:"last_error,omitempty"`
+	QueuedAt    time.Time `json:"queued_at"`
+}
+
+// UploadQueue lists the uploads in progress, oldest first, so that a batch
+// cut short by a lost connection or Ctrl-C can be resumed. Each change is
+// saved at once.
+type UploadQueue struct {
+	path  string
+	Items []QueuedUpload `json:"items"`
+}
+
+// OpenUploadQueue loads the queue at path, or returns an empty one if the
+// file does not exist yet. Pass "" to use the default location.
+func OpenUploadQueue(path string) (*UploadQueue, error) {
+	if path == "" {
+		p, err := Path(QueueFile)
+		if err != nil {
+			return nil, err
+		}
+		path = p
+	}
+	q := &UploadQueue{path: path}
+	if err := readJSON(path, q); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// Put adds an upload to the end of the queue, or replaces the queued upload
+// of the same file contents, and saves the queue.
+func (q *UploadQueue) Put(u QueuedUpload) error {
+	if u.QueuedAt.IsZero() {
+		u.QueuedAt = time.Now().UTC()
+	}
+	for i := range q.Items {
+		if q.Items[i].Hash == u.Hash {
+			q.Items[i] = u
+			return writeJSON(q.path, q)
+		}
+	}
+	q.Items = append(q.Items, u)
+	return writeJSON(q.path, q)
+}
+
+// Remove takes the upload of the given file contents off the queue, if
+// queued, and saves the queue.
+func (q *UploadQueue) Remove(hash string) error {
+	for i := range q.Items {
+		if q.Items[i].Hash == hash {
+			q.Items = append(q.Items[:i], q.Items[i+1:]...)
+			return writeJSON(q.path, q)
+		}
+	}
+	return nil
+}
+
+// Clear empties the queue and saves it.
+func (q *UploadQueue) Clear() error {
+	q.Items = nil
+	return writeJSON(q.path, q)
+}
+
+// Orphans returns the queued uploads that were never sent and whose file no
+// longer exists, so they can never be sent.
+func (q *UploadQueue) Orphans() []QueuedUpload {
+	var out []QueuedUpload
+	for _, u := range q.Items {
+		if _, err := os.Stat(u.Path); u.UploadID == 0 && errors.Is(err, fs.ErrNotExist) {
+			out = append(out, u)
+		}
+	}
+	return out
+}
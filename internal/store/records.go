@@ -0,0 +1,64 @@
+package store
+
+import (
+	"time"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/analysis"
+)
+
+// RecordsFile is the name of the personal records store inside the config
+// directory.
+const RecordsFile = "records.json"
+
+// Records holds the personal records found by "prs", so later runs and
+// syncs can tell which are new.
+type Records struct {
+	path      string
+	UpdatedAt time.Time              `json:"updated_at"`
+	PRs       map[string]analysis.PR `json:"prs"` // keyed by PR.Key
+}
+
+// OpenRecords loads the records at path, or returns an empty store if none
+// have been kept yet. Pass "" to use the default location.
+func OpenRecords(path string) (*Records, error) {
+	if path == "" {
+		p, err := Path(RecordsFile)
+		if err != nil {
+			return nil, err
+		}
+		path = p
+	}
+	r := &Records{path: path}
+	if err := readJSON(path, r); err != nil {
+		return nil, err
+	}
+	if r.PRs == nil {
+		r.PRs = map[string]analysis.PR{}
+	}
+	return r, nil
+}
+
+// Tracked reports whether records have been kept before.
+func (r *Records) Tracked() bool { return !r.UpdatedAt.IsZero() }
+
+// Update replaces the stored records of each sport and effort in prs and
+// returns prs with New set on those that beat the stored one, or that had
+// none while others were tracked. Records of sports and efforts not in prs
+// are kept. Call Save to persist them.
+func (r *Records) Update(prs []analysis.PR) []analysis.PR {
+	out := make([]analysis.PR, len(prs))
+	for i, p := range prs {
+		p.New = false
+		old, ok := r.PRs[p.Key()]
+		r.PRs[p.Key()] = p
+		p.New = r.Tracked() && (!ok || p.Beats(old))
+		out[i] = p
+	}
+	r.UpdatedAt = time.Now().UTC()
+	return out
+}
+
+// Save writes the records to disk.
+func (r *Records) Save() error {
+	return writeJSON(r.path, r)
+}
@@ -0,0 +1,127 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/fsutil"
+)
+
+// SpillDir is the directory inside the config directory that holds streams
+// downloaded so far, one subdirectory per activity.
+const SpillDir = "streams-partial"
+
+// StreamSpill keeps the streams of one activity on disk as they are
+// downloaded one key at a time, so that a download cut short resumes with
+// the keys still missing instead of starting over.
+type StreamSpill struct {
+	dir string
+}
+
+// OpenStreamSpill returns the spill for an activity, inside dir (or the
+// default location when dir is ""). Nothing is created until Put.
+func OpenStreamSpill(activityID int64, dir string) (*StreamSpill, error) {
+	if dir == "" {
+		p, err := Path(SpillDir)
+		if err != nil {
+			return nil, err
+		}
+		dir = p
+	}
+	return &StreamSpill{dir: filepath.Join(dir, strconv.FormatInt(activityID, 10))}, nil
+}
+
+// Get returns the stream stored for key: its raw JSON, or nil if the
+// activity has no such stream. ok is false when key was not downloaded yet.
+func (s *StreamSpill) Get(key string) (raw json.RawMessage, ok bool, err error) {
+	data, err := os.ReadFile(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if string(data) == "null" {
+		return nil, true, nil
+	}
+	return data, true, nil
+}
+
+// Put stores the stream downloaded for key; nil records that the activity
+// has no such stream.
+func (s *StreamSpill) Put(key string, raw json.RawMessage) error {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("create %s: %w", SpillDir, err)
+	}
+	if raw == nil {
+		raw = json.RawMessage("null")
+	}
+	if err := fsutil.WriteFile(s.path(key), raw, 0600); err != nil {
+		return fmt.Errorf("write %s stream: %w", key, err)
+	}
+	return nil
+}
+
+// Remove deletes the activity's spill once its download is complete, and the
+// spill directory with it when no other download is pending.
+func (s *StreamSpill) Remove() error {
+	if err := os.RemoveAll(s.dir); err != nil {
+		return err
+	}
+	os.Remove(filepath.Dir(s.dir)) // fails, harmlessly, unless empty
+	return nil
+}
+
+// StaleStreamSpills returns the spills, inside dir (or the default location
+// when dir is ""), of downloads not resumed since cutoff: one entry per
+// activity, with the total size of its streams.
+func StaleStreamSpills(dir string, cutoff time.Time) ([]fsutil.Entry, error) {
+	if dir == "" {
+		p, err := Path(SpillDir)
+		if err != nil {
+			return nil, err
+		}
+		dir = p
+	}
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var out []fsutil.Entry
+	for _, d := range entries {
+		if !d.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, d.Name())
+		files, err := fsutil.List(path)
+		if err != nil {
+			return nil, err
+		}
+		e := fsutil.Entry{Path: path}
+		if info, err := d.Info(); err == nil {
+			e.ModTime = info.ModTime()
+		}
+		for _, f := range files {
+			e.Size += f.Size
+			if f.ModTime.After(e.ModTime) {
+				e.ModTime = f.ModTime
+			}
+		}
+		if e.ModTime.Before(cutoff) {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (s *StreamSpill) path(key string) string {
+	return filepath.Join(s.dir, key+".json")
+}
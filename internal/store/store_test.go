@@ -0,0 +1,630 @@
+package store_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/analysis"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/store"
+)
+
+func TestHashFile_ContentAddressed(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.fit")
+	b := filepath.Join(dir, "renamed.fit")
+	os.WriteFile(a, []byte("same bytes"), 0600)
+	os.WriteFile(b, []byte("same bytes"), 0600)
+
+	ha, err := store.HashFile(a)
+	if err != nil {
+		t.Fatalf("HashFile: %v", err)
+	}
+	hb, _ := store.HashFile(b)
+	if ha != hb {
+		t.Errorf("identical content hashed differently: %s vs %s", ha, hb)
+	}
+	if len(ha) != 64 {
+		t.Errorf("hash length = %d, want 64 hex chars", len(ha))
+	}
+}
+
+func TestLedger_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "uploads.json")
+
+	l, err := store.OpenLedger(path)
+	if err != nil {
+		t.Fatalf("OpenLedger (missing file): %v", err)
+	}
+	if _, ok := l.Lookup("abc"); ok {
+		t.Fatal("empty ledger should not contain entries")
+	}
+	if err := l.Record("abc", store.LedgerEntry{Path: "/x/a.fit", UploadID: 7, ActivityID: 99}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+
+	reloaded, err := store.OpenLedger(path)
+	if err != nil {
+		t.Fatalf("OpenLedger: %v", err)
+	}
+	e, ok := reloaded.Lookup("abc")
+	if !ok {
+		t.Fatal("entry not persisted")
+	}
+	if e.ActivityID != 99 || e.UploadID != 7 || e.UploadedAt.IsZero() {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("ledger mode = %o, want 600", info.Mode().Perm())
+	}
+}
+
+func TestWeatherCache_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "weather.json")
+	c, err := store.OpenWeatherCache(path)
+	if err != nil {
+		t.Fatalf("OpenWeatherCache (missing file): %v", err)
+	}
+	c.Set(42, analysis.Weather{TempC: 21.5, WindSpeed: 3})
+	if err := c.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	reloaded, err := store.OpenWeatherCache(path)
+	if err != nil {
+		t.Fatalf("OpenWeatherCache: %v", err)
+	}
+	if w, ok := reloaded.Lookup(42); !ok || w.TempC != 21.5 || w.WindSpeed != 3 {
+		t.Errorf("Lookup(42) = %+v, %v", w, ok)
+	}
+	if _, ok := reloaded.Lookup(43); ok {
+		t.Error("Lookup(43) should miss")
+	}
+}
+
+func TestActivities_PutListLatest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "activities.json")
+	a, err := store.OpenActivities(path)
+	if err != nil {
+		t.Fatalf("OpenActivities (missing file): %v", err)
+	}
+	for _, raw := range []string{
+		`{"id":1,"name":"old","start_date":"2024-01-01T07:00:00Z"}`,
+		`{"id":3,"name":"new","start_date":"2024-03-01T07:00:00Z"}`,
+		`{"id":2,"name":"mid","start_date":"2024-02-01T07:00:00Z"}`,
+	} {
+		if _, added, err := a.Put(json.RawMessage(raw)); err != nil || !added {
+			t.Fatalf("Put(%s) = %v, %v", raw, added, err)
+		}
+	}
+	if _, added, _ := a.Put(json.RawMessage(`{"id":2,"name":"renamed","start_date":"2024-02-01T07:00:00Z"}`)); added {
+		t.Error("Put of a stored ID reported it as new")
+	}
+	a.SetDescription(2, "")
+	if err := a.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	reloaded, err := store.OpenActivities(path)
+	if err != nil {
+		t.Fatalf("OpenActivities: %v", err)
+	}
+	latest, err := reloaded.Latest()
+	if err != nil || !latest.Equal(time.Date(2024, 3, 1, 7, 0, 0, 0, time.UTC)) {
+		t.Errorf("Latest() = %v, %v", latest, err)
+	}
+	items, err := reloaded.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	var names []string
+	for _, raw := range items {
+		var s struct{ Name string }
+		json.Unmarshal(raw, &s)
+		names = append(names, s.Name)
+	}
+	if got := strings.Join(names, ","); got != "new,renamed,old" {
+		t.Errorf("List() order = %s, want new,renamed,old", got)
+	}
+	if _, ok := reloaded.Description(2); !ok {
+		t.Error("empty description should still count as fetched")
+	}
+	if _, ok := reloaded.Description(1); ok {
+		t.Error("Description(1) should miss")
+	}
+	reloaded.Remove(2)
+	if reloaded.Len() != 2 {
+		t.Errorf("Len() after Remove = %d, want 2", reloaded.Len())
+	}
+	if reloaded.Has(2) || !reloaded.Has(1) {
+		t.Error("Has should report 1 stored and 2 removed")
+	}
+	if raw, ok := reloaded.Get(1); !ok || !strings.Contains(string(raw), `"old"`) {
+		t.Errorf("Get(1) = %s, %v", raw, ok)
+	}
+}
+
+func TestJobs_NewUpdateList(t *testing.T) {
+	jobs, err := store.OpenJobs(filepath.Join(t.TempDir(), "jobs"))
+	if err != nil {
+		t.Fatalf("OpenJobs: %v", err)
+	}
+	first, err := jobs.New([]string{"sync"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	second, _ := jobs.New([]string{"sync", "--full"})
+	if first.ID == second.ID || first.State != store.JobRunning {
+		t.Fatalf("New returned %+v and %+v", first, second)
+	}
+	if err := jobs.Update(first.ID, func(j *store.Job) { j.PID, j.State = 42, store.JobDone }); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	got, err := jobs.Get(first.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.PID != 42 || got.State != store.JobDone || got.Command() != "sync" {
+		t.Errorf("Get = %+v", got)
+	}
+	list, err := jobs.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("List returned %d jobs, want 2", len(list))
+	}
+	if _, err := jobs.Get("../config"); err == nil {
+		t.Error("Get accepted a path as a job ID")
+	}
+	if err := jobs.Remove(first.ID); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if _, err := jobs.Get(first.ID); err == nil {
+		t.Error("removed job still found")
+	}
+}
+
+func TestFeatures_FreshAndLookup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "features.json")
+	f, err := store.OpenFeatures(path)
+	if err != nil {
+		t.Fatalf("OpenFeatures (missing file): %v", err)
+	}
+	if f.Fresh("read", time.Hour) {
+		t.Error("an empty probe should not be fresh")
+	}
+	f.Scope = "read,activity:write"
+	f.CheckedAt = time.Now().Add(-2 * time.Hour)
+	f.Features = []store.Feature{{Name: "segment-efforts", Status: store.FeatureNeedsSummit, HTTP: 402}}
+	if err := f.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	reloaded, err := store.OpenFeatures(path)
+	if err != nil {
+		t.Fatalf("OpenFeatures: %v", err)
+	}
+	if !reloaded.Fresh("read,activity:write", 3*time.Hour) {
+		t.Error("probe within maxAge with the same scopes should be fresh")
+	}
+	if reloaded.Fresh("read,activity:write", time.Hour) || reloaded.Fresh("read", 3*time.Hour) {
+		t.Error("an old probe, or one made with other scopes, should not be fresh")
+	}
+	if ft, ok := reloaded.Lookup("segment-efforts"); !ok || ft.Status != store.FeatureNeedsSummit {
+		t.Errorf("Lookup = %+v, %v", ft, ok)
+	}
+	if _, ok := reloaded.Lookup("routes"); ok {
+		t.Error("Lookup(routes) should miss")
+	}
+}
+
+func TestUploadQueue_PutReplaceRemove(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "upload-queue.json")
+	q, err := store.OpenUploadQueue(path)
+	if err != nil {
+		t.Fatalf("OpenUploadQueue (missing file): %v", err)
+	}
+	q.Put(store.QueuedUpload{Path: "/a.fit", Hash: "aa"})
+	q.Put(store.QueuedUpload{Path: "/b.fit", Hash: "bb"})
+	if err := q.Put(store.QueuedUpload{Path: "/a.fit", Hash: "aa", UploadID: 7, Attempts: 1}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	q2, err := store.OpenUploadQueue(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if len(q2.Items) != 2 || q2.Items[0].UploadID != 7 || q2.Items[1].Path != "/b.fit" {
+		t.Fatalf("items = %+v, want a.fit (sent as upload 7) then b.fit", q2.Items)
+	}
+	if q2.Items[0].QueuedAt.IsZero() {
+		t.Error("QueuedAt not set")
+	}
+
+	q2.Remove("aa")
+	q3, _ := store.OpenUploadQueue(path)
+	if len(q3.Items) != 1 || q3.Items[0].Hash != "bb" {
+		t.Errorf("after Remove: %+v, want only bb", q3.Items)
+	}
+}
+
+func TestHookQueue_AddDedupesClear(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pending.json")
+	q, err := store.OpenHookQueue(path)
+	if err != nil || len(q.Activities) != 0 {
+		t.Fatalf("OpenHookQueue (missing file) = %+v, %v", q, err)
+	}
+	if err := q.Add([]json.RawMessage{json.RawMessage(`{"id":1,"name":"a"}`), json.RawMessage(`{"id":2}`)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Add([]json.RawMessage{json.RawMessage(`{"id":1,"name":"renamed"}`), json.RawMessage(`{"id":3}`)}); err != nil {
+		t.Fatal(err)
+	}
+	q, err = store.OpenHookQueue(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var held []struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	}
+	data, _ := json.Marshal(q.Activities)
+	if err := json.Unmarshal(data, &held); err != nil {
+		t.Fatal(err)
+	}
+	if len(held) != 3 || held[0].ID != 1 || held[0].Name != "renamed" || held[1].ID != 2 || held[2].ID != 3 {
+		t.Errorf("after adding 1, 2 then 1, 3: %+v", held)
+	}
+	if err := q.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if q, _ = store.OpenHookQueue(path); len(q.Activities) != 0 {
+		t.Errorf("after Clear: %s", q.Activities)
+	}
+}
+
+func TestMeta_SetUnsetRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "meta.json")
+	m, err := store.OpenMeta(path)
+	if err != nil {
+		t.Fatalf("OpenMeta (missing file): %v", err)
+	}
+	m.Set(42, "shoes", "B")
+	m.Set(42, "race", "yes")
+	m.Set(7, "shoes", "A")
+	if err := m.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	m2, err := store.OpenMeta(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if got := m2.Get(42); got["shoes"] != "B" || got["race"] != "yes" {
+		t.Errorf("Get(42) = %v", got)
+	}
+	if keys := m2.Keys(); strings.Join(keys, ",") != "race,shoes" {
+		t.Errorf("Keys = %v, want [race shoes]", keys)
+	}
+	if !m2.Unset(7, "shoes") || m2.Unset(7, "shoes") {
+		t.Error("Unset should report the field only while it is set")
+	}
+	if m2.Get(7) != nil {
+		t.Error("activity without fields should be dropped")
+	}
+}
+
+func TestClubFeed_RecordsNewEntriesOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "club-feed.json")
+	f, err := store.OpenClubFeed(path)
+	if err != nil {
+		t.Fatalf("OpenClubFeed (missing file): %v", err)
+	}
+	run := analysis.ClubEntry{Athlete: "Jane D.", Name: "Lunch Run", Distance: 5000, MovingTime: 1500}
+	day1 := time.Date(2025, 3, 3, 12, 0, 0, 0, time.UTC)
+	if n := f.Record(42, []analysis.ClubEntry{run, run}, day1); n != 2 {
+		t.Errorf("first Record added %d, want 2 (identical entries are both kept)", n)
+	}
+	ride := analysis.ClubEntry{Athlete: "Bob S.", Name: "Commute", Distance: 12000, MovingTime: 2400}
+	day2 := day1.AddDate(0, 0, 1)
+	if n := f.Record(42, []analysis.ClubEntry{ride, run, run}, day2); n != 1 {
+		t.Errorf("second Record added %d, want 1", n)
+	}
+	if err := f.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	f2, err := store.OpenClubFeed(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	got := f2.Entries(42)
+	if len(got) != 3 || !got[0].FirstSeen.Equal(day1) || !got[2].FirstSeen.Equal(day2) {
+		t.Errorf("entries = %+v", got)
+	}
+	f2.Record(42, nil, day2.Add(store.ClubFeedRetention).Add(time.Hour))
+	if n := len(f2.Entries(42)); n != 0 {
+		t.Errorf("%d entries left after the retention period, want 0", n)
+	}
+}
+
+func TestStreamSpill_GetPutRemove(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "streams-partial")
+	s, err := store.OpenStreamSpill(42, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := s.Get("time"); ok || err != nil {
+		t.Fatalf("Get before Put: ok=%v err=%v, want not downloaded", ok, err)
+	}
+	if err := s.Put("time", json.RawMessage(`{"data":[0,1,2]}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put("watts", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	s2, _ := store.OpenStreamSpill(42, dir)
+	if raw, ok, err := s2.Get("time"); !ok || err != nil || string(raw) != `{"data":[0,1,2]}` {
+		t.Errorf("time = %s, %v, %v; want the stored stream", raw, ok, err)
+	}
+	if raw, ok, _ := s2.Get("watts"); !ok || raw != nil {
+		t.Errorf("watts = %s, %v; want downloaded but absent", raw, ok)
+	}
+	if err := s2.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("spill directory left behind: %v", err)
+	}
+}
+
+func TestUploadQueue_Orphans(t *testing.T) {
+	dir := t.TempDir()
+	kept := filepath.Join(dir, "kept.fit")
+	os.WriteFile(kept, []byte("fit"), 0600)
+	q, _ := store.OpenUploadQueue(filepath.Join(dir, "upload-queue.json"))
+	q.Put(store.QueuedUpload{Path: kept, Hash: "aa"})
+	q.Put(store.QueuedUpload{Path: filepath.Join(dir, "gone.fit"), Hash: "bb"})
+	q.Put(store.QueuedUpload{Path: filepath.Join(dir, "sent.fit"), Hash: "cc", UploadID: 9})
+
+	orphans := q.Orphans()
+	if len(orphans) != 1 || orphans[0].Hash != "bb" {
+		t.Errorf("Orphans = %+v, want only the unsent upload of the missing file", orphans)
+	}
+}
+
+func TestStaleStreamSpills(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "streams-partial")
+	for _, id := range []int64{1, 2} {
+		s, _ := store.OpenStreamSpill(id, dir)
+		if err := s.Put("time", json.RawMessage(`[1,2,3]`)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := time.Now().Add(-48 * time.Hour)
+	os.Chtimes(filepath.Join(dir, "1", "time.json"), old, old)
+	os.Chtimes(filepath.Join(dir, "1"), old, old)
+
+	stale, err := store.StaleStreamSpills(dir, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stale) != 1 || stale[0].Path != filepath.Join(dir, "1") || stale[0].Size != 7 {
+		t.Errorf("stale = %+v, want activity 1's spill of 7 bytes", stale)
+	}
+	if missing, err := store.StaleStreamSpills(filepath.Join(dir, "none"), time.Now()); missing != nil || err != nil {
+		t.Errorf("missing dir: %v, %v; want none", missing, err)
+	}
+}
+
+func TestRecords_UpdateMarksNew(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "records.json")
+	r, _ := store.OpenRecords(path)
+	first := []analysis.PR{
+		{Sport: "Run", Effort: "5k", Meters: 5000, Seconds: 1300, ActivityID: 1},
+		{Sport: "Ride", Effort: "20m", Watts: 250, ActivityID: 2},
+	}
+	for _, p := range r.Update(first) {
+		if p.New {
+			t.Errorf("first update marked %s new", p.Key())
+		}
+	}
+	if err := r.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, _ = store.OpenRecords(path)
+	if !r.Tracked() || len(r.PRs) != 2 {
+		t.Fatalf("reopened records = %+v, want 2 tracked", r)
+	}
+	got := r.Update([]analysis.PR{
+		{Sport: "Run", Effort: "5k", Meters: 5000, Seconds: 1250, ActivityID: 3},
+		{Sport: "Run", Effort: "10k", Meters: 10000, Seconds: 2700, ActivityID: 3},
+	})
+	if !got[0].New || !got[1].New {
+		t.Errorf("Update = %+v, want the faster 5k and the first 10k new", got)
+	}
+	if p := r.PRs["Ride/20m"]; p.Watts != 250 {
+		t.Errorf("ride record dropped: %+v", r.PRs)
+	}
+	if r.PRs["Run/5k"].New {
+		t.Errorf("stored record marked new")
+	}
+}
+
+func TestClubFeed_Purge(t *testing.T) {
+	f, err := store.OpenClubFeed(filepath.Join(t.TempDir(), "club-feed.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	day1 := time.Date(2025, 3, 3, 12, 0, 0, 0, time.UTC)
+	f.Record(1, []analysis.ClubEntry{{Athlete: "Jane D.", Name: "Old"}}, day1)
+	f.Record(2, []analysis.ClubEntry{{Athlete: "Bob S.", Name: "Old"}}, day1)
+	f.Record(2, []analysis.ClubEntry{{Athlete: "Bob S.", Name: "New"}}, day1.AddDate(0, 0, 10))
+	if n := f.Purge(day1.AddDate(0, 0, 7)); n != 2 {
+		t.Errorf("Purge dropped %d entries, want 2", n)
+	}
+	if f.Len() != 1 || len(f.Entries(2)) != 1 || f.Entries(2)[0].Name != "New" {
+		t.Errorf("left %+v", f.Clubs)
+	}
+	if _, ok := f.Clubs["1"]; ok {
+		t.Error("club 1 kept with no entries")
+	}
+}
+
+func TestActivities_NeedsDetails(t *testing.T) {
+	a, err := store.OpenActivities(filepath.Join(t.TempDir(), "activities.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.Put(json.RawMessage(`{"id":1,"name":"Morning Run","distance":5000,"kudos_count":0}`))
+	a.Put(json.RawMessage(`{"id":2,"name":"Lunch Ride","distance":20000}`))
+	if !a.NeedsDetails(1) || a.MissingDetails() != 2 {
+		t.Fatalf("NeedsDetails(1) = %v, MissingDetails() = %d; want true, 2", a.NeedsDetails(1), a.MissingDetails())
+	}
+	a.SetDescription(1, "easy")
+	a.SetDescription(2, "")
+	a.Put(json.RawMessage(`{"id":1,"name":"Morning Run","distance":5000,"kudos_count":7}`))
+	if a.NeedsDetails(1) {
+		t.Error("a new kudos should not make the description out of date")
+	}
+	a.Put(json.RawMessage(`{"id":2,"name":"Commute","distance":20000}`))
+	if !a.NeedsDetails(2) || a.MissingDetails() != 1 {
+		t.Errorf("NeedsDetails(2) after a rename = %v, MissingDetails() = %d; want true, 1", a.NeedsDetails(2), a.MissingDetails())
+	}
+	a.SetDescription(2, "to work")
+	if a.NeedsDetails(2) {
+		t.Error("NeedsDetails(2) after fetching again = true")
+	}
+}
+
+func TestPushes_RecordAndReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pushes.json")
+	p, err := store.OpenPushes(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Target("intervals") != nil || p.Pushed("intervals", 1) {
+		t.Fatal("empty ledger has pushes")
+	}
+	since := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	p.Start("intervals", since)
+	if err := p.Record("intervals", 1, "i42"); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := store.OpenPushes(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tgt := reloaded.Target("intervals")
+	if tgt == nil || !tgt.Since.Equal(since) || tgt.Activities[1].RemoteID != "i42" {
+		t.Fatalf("target = %+v", tgt)
+	}
+	if !reloaded.Pushed("intervals", 1) || reloaded.Pushed("intervals", 2) || reloaded.Pushed("other", 1) {
+		t.Error("Pushed does not match what was recorded")
+	}
+}
+
+func TestCommandLog_AppendListPrune(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "commands.jsonl")
+	l, err := store.OpenCommandLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runs, err := l.List(); err != nil || len(runs) != 0 {
+		t.Fatalf("List (missing file) = %v, %v", runs, err)
+	}
+	day := time.Date(2024, 6, 1, 12, 0, 0, 0, time.Local)
+	for _, inv := range []store.Invocation{
+		{Command: "sync", StartedAt: day, Requests: 40, Bytes: 4000, DurationMS: 2000},
+		{Command: "activities list", StartedAt: day.Add(time.Hour), Requests: 1, Bytes: 100},
+		{Command: "activities list", StartedAt: day.Add(2 * time.Hour), Requests: 1, Bytes: 100},
+		{Command: "stats", StartedAt: day.AddDate(0, 0, -40), Requests: 2},
+	} {
+		if err := l.Append(inv); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runs, err := l.List()
+	if err != nil || len(runs) != 4 || runs[0].Command != "stats" {
+		t.Fatalf("List = %v, %v", runs, err)
+	}
+
+	days := store.UsageByDay(runs)
+	if len(days) != 2 || days[0].Date != "2024-06-01" {
+		t.Fatalf("UsageByDay = %+v", days)
+	}
+	d := days[0]
+	if d.Runs != 3 || d.Requests != 42 || d.Bytes != 4200 || d.DurationMS != 2000 {
+		t.Errorf("day totals = %+v", d)
+	}
+	if len(d.Commands) != 2 || d.Commands[0].Command != "sync" || d.Commands[1].Runs != 2 {
+		t.Errorf("day commands = %+v", d.Commands)
+	}
+
+	if n, err := l.Prune(day.AddDate(0, 0, -30), true); err != nil || n != 1 {
+		t.Fatalf("Prune (dry run) = %d, %v", n, err)
+	}
+	if runs, _ := l.List(); len(runs) != 4 {
+		t.Error("dry-run prune removed runs")
+	}
+	if n, err := l.Prune(day.AddDate(0, 0, -30), false); err != nil || n != 1 {
+		t.Fatalf("Prune = %d, %v", n, err)
+	}
+	if runs, _ := l.List(); len(runs) != 3 || runs[0].Command != "sync" {
+		t.Errorf("after prune: %v", runs)
+	}
+}
+
+func TestCommandLog_PruneKeepsConcurrentAppends(t *testing.T) {
+	l, err := store.OpenCommandLog(filepath.Join(t.TempDir(), "commands.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().AddDate(0, 0, -100)
+	for range 20 {
+		if err := l.Append(store.Invocation{Command: "old", StartedAt: old}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Go(func() {
+			if err := l.Append(store.Invocation{Command: "new", StartedAt: time.Now()}); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+	wg.Go(func() {
+		if _, err := l.Prune(time.Now().AddDate(0, 0, -90), false); err != nil {
+			t.Error(err)
+		}
+	})
+	wg.Wait()
+	runs, err := l.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for _, r := range runs {
+		if r.Command == "new" {
+			n++
+		}
+	}
+	if n != 20 || len(runs) != 20 {
+		t.Errorf("after a concurrent prune: %d runs, %d new; want 20 new", len(runs), n)
+	}
+}
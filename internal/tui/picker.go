@@ -0,0 +1,159 @@
+package tui
+
+// This file implements the inline fuzzy picker used when a command that takes
+// an activity ID is run without one.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ErrCanceled is returned by Pick when the user leaves without choosing.
+var ErrCanceled = errors.New("nothing selected")
+
+// pickerRows is how many matches the picker shows at once.
+const pickerRows = 10
+
+// Pick shows items in an inline list on stderr that can be narrowed by typing
+// a fuzzy query, and returns the index of the chosen item.
+func Pick(ctx context.Context, prompt string, items []string) (int, error) {
+	final, err := tea.NewProgram(NewPicker(prompt, items),
+		tea.WithOutput(os.Stderr), tea.WithContext(ctx)).Run()
+	if err != nil {
+		return -1, err
+	}
+	i := final.(Picker).Chosen()
+	if i < 0 {
+		return -1, ErrCanceled
+	}
+	return i, nil
+}
+
+// Picker is the bubbletea model behind Pick.
+type Picker struct {
+	prompt  string
+	items   []string
+	query   string
+	matches []int // indexes into items, best match first
+	cursor  int
+	chosen  int
+	done    bool
+}
+
+// NewPicker returns a picker over items with an empty query.
+func NewPicker(prompt string, items []string) Picker {
+	p := Picker{prompt: prompt, items: items, chosen: -1}
+	p.filter()
+	return p
+}
+
+// Chosen returns the index of the selected item, or -1 if none was chosen.
+func (p Picker) Chosen() int { return p.chosen }
+
+func (p Picker) Init() tea.Cmd { return nil }
+
+func (p Picker) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return p, nil
+	}
+	switch key.Type {
+	case tea.KeyCtrlC, tea.KeyEsc:
+		p.done = true
+		return p, tea.Quit
+	case tea.KeyEnter:
+		if len(p.matches) > 0 {
+			p.chosen = p.matches[p.cursor]
+		}
+		p.done = true
+		return p, tea.Quit
+	case tea.KeyUp, tea.KeyCtrlP:
+		p.cursor = max(0, p.cursor-1)
+	case tea.KeyDown, tea.KeyCtrlN:
+		p.cursor = min(len(p.matches)-1, p.cursor+1)
+	case tea.KeyBackspace:
+		if r := []rune(p.query); len(r) > 0 {
+			p.query = string(r[:len(r)-1])
+			p.filter()
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		p.query += string(key.Runes)
+		p.filter()
+	}
+	return p, nil
+}
+
+// filter recomputes the matches for the current query and resets the cursor.
+func (p *Picker) filter() {
+	type scored struct{ i, score int }
+	var hits []scored
+	for i, item := range p.items {
+		if s, ok := Match(p.query, item); ok {
+			hits = append(hits, scored{i, s})
+		}
+	}
+	// Stable so that equal scores keep the caller's order (newest first).
+	sort.SliceStable(hits, func(a, b int) bool { return hits[a].score > hits[b].score })
+	p.matches = p.matches[:0]
+	for _, h := range hits {
+		p.matches = append(p.matches, h.i)
+	}
+	p.cursor = 0
+}
+
+func (p Picker) View() string {
+	if p.done {
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s█\n", titleStyle.Render(p.prompt), p.query)
+	top := max(0, p.cursor-pickerRows+1)
+	for row, i := range p.matches[top:min(len(p.matches), top+pickerRows)] {
+		if top+row == p.cursor {
+			b.WriteString(selectedStyle.Render("> "+p.items[i]) + "\n")
+		} else {
+			b.WriteString("  " + p.items[i] + "\n")
+		}
+	}
+	b.WriteString(helpStyle.Render(fmt.Sprintf("%d/%d • type to filter • ↑/↓ move • enter select • esc cancel",
+		len(p.matches), len(p.items))))
+	return b.String()
+}
+
+// Match reports whether every character of query appears in s in order
+// (case-insensitively, ignoring spaces in the query) and scores the match:
+// consecutive characters and characters at the start of a word score higher.
+// An empty query matches everything with score 0.
+func Match(query, s string) (score int, ok bool) {
+	q := []rune(strings.ToLower(strings.ReplaceAll(query, " ", "")))
+	if len(q) == 0 {
+		return 0, true
+	}
+	text := []rune(strings.ToLower(s))
+	qi, prev := 0, -2
+	for i, r := range text {
+		if qi == len(q) {
+			break
+		}
+		if r != q[qi] {
+			continue
+		}
+		score++
+		if i == prev+1 {
+			score += 2
+		}
+		if i == 0 || !unicode.IsLetter(text[i-1]) && !unicode.IsDigit(text[i-1]) {
+			score += 3
+		}
+		prev = i
+		qi++
+	}
+	return score, qi == len(q)
+}
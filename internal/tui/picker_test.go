@@ -0,0 +1,58 @@
+package tui_test
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/tui"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		query, s string
+		ok       bool
+	}{
+		{"", "anything", true},
+		{"mrn", "Morning Run", true},
+		{"morning run", "Morning Run", true},
+		{"RIDE", "Evening Ride", true},
+		{"nrm", "Morning Run", false},
+		{"swim", "Morning Run", false},
+	}
+	for _, tt := range tests {
+		if _, ok := tui.Match(tt.query, tt.s); ok != tt.ok {
+			t.Errorf("Match(%q, %q) ok = %v, want %v", tt.query, tt.s, ok, tt.ok)
+		}
+	}
+
+	// A contiguous match at a word start beats a scattered one.
+	word, _ := tui.Match("run", "Lunch Run")
+	scattered, _ := tui.Match("run", "Recovery Lunch")
+	if word <= scattered {
+		t.Errorf("score(word start) = %d, want > score(scattered) = %d", word, scattered)
+	}
+}
+
+func TestPickerFiltersAndSelects(t *testing.T) {
+	items := []string{"2024-11-13 Evening Walk", "2024-11-09 Morning Swim", "2024-11-06 Lunch Run"}
+	var m tea.Model = tui.NewPicker("Activity:", items)
+	for _, r := range "swim" {
+		m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatal("enter should quit the picker")
+	}
+	if got := m.(tui.Picker).Chosen(); got != 1 {
+		t.Errorf("chosen = %d, want 1 (Morning Swim)", got)
+	}
+}
+
+func TestPickerEscCancels(t *testing.T) {
+	var m tea.Model = tui.NewPicker("Activity:", []string{"a", "b"})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if got := m.(tui.Picker).Chosen(); got != -1 {
+		t.Errorf("chosen = %d after esc, want -1", got)
+	}
+}
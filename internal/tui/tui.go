@@ -0,0 +1,356 @@
+// Package tui implements the interactive terminal interfaces, built on
+// bubbletea: `strava tui`, a full-screen activity browser, and the fuzzy
+// picker offered when a command is run without an activity ID.
+//
+// The browser only handles navigation and layout. Data comes from a Source,
+// which renders detail views as plain text so that the TUI shows exactly what
+// the equivalent CLI command would print.
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/analysis"
+)
+
+// PageSize is how many activities Source.Activities returns per page. A page
+// shorter than this marks the end of the history.
+const PageSize = 50
+
+// Metrics are the chart metrics the browser cycles through, in order.
+var Metrics = []string{"altitude", "heartrate", "watts", "pace"}
+
+// Source loads the data shown in the browser.
+type Source interface {
+	// Activities returns one page (1-based) of the athlete's activities, newest first.
+	Activities(ctx context.Context, page int) ([]analysis.Activity, error)
+	// Detail, Laps and Chart return rendered text for one activity.
+	Detail(ctx context.Context, id int64) (string, error)
+	Laps(ctx context.Context, id int64) (string, error)
+	Chart(ctx context.Context, id int64, metric string) (string, error)
+}
+
+// Run starts the browser on the terminal and blocks until the user quits.
+// row formats one activity for the list.
+func Run(ctx context.Context, src Source, row func(analysis.Activity) string) error {
+	_, err := tea.NewProgram(New(ctx, src, row), tea.WithAltScreen(), tea.WithContext(ctx)).Run()
+	if err == tea.ErrProgramKilled && ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+type view int
+
+const (
+	listView view = iota
+	detailView
+	lapsView
+	chartView
+)
+
+// Messages delivered by the asynchronous loads.
+type (
+	pageMsg struct {
+		page int
+		acts []analysis.Activity
+		err  error
+	}
+	textMsg struct {
+		view view
+		id   int64
+		text string
+		err  error
+	}
+)
+
+var (
+	selectedStyle = lipgloss.NewStyle().Reverse(true)
+	titleStyle    = lipgloss.NewStyle().Bold(true)
+	helpStyle     = lipgloss.NewStyle().Faint(true)
+	errorStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+)
+
+// Model is the bubbletea model of the browser.
+type Model struct {
+	ctx context.Context
+	src Source
+	row func(analysis.Activity) string
+
+	width, height int
+
+	acts    []analysis.Activity
+	cursor  int
+	top     int // first visible list row
+	page    int // last page loaded
+	more    bool
+	loading bool
+	err     error
+
+	view   view
+	text   string // rendered detail, laps or chart
+	scroll int    // first visible line of text
+	metric int    // index into Metrics
+}
+
+// New returns a browser model. Use Run to display it.
+func New(ctx context.Context, src Source, row func(analysis.Activity) string) Model {
+	return Model{ctx: ctx, src: src, row: row, more: true, loading: true, height: 24, width: 80}
+}
+
+// Init loads the first page of activities.
+func (m Model) Init() tea.Cmd {
+	return m.loadPage(1)
+}
+
+func (m Model) loadPage(page int) tea.Cmd {
+	return func() tea.Msg {
+		acts, err := m.src.Activities(m.ctx, page)
+		return pageMsg{page: page, acts: acts, err: err}
+	}
+}
+
+func (m Model) loadText(v view, id int64) tea.Cmd {
+	metric := Metrics[m.metric]
+	return func() tea.Msg {
+		var text string
+		var err error
+		switch v {
+		case detailView:
+			text, err = m.src.Detail(m.ctx, id)
+		case lapsView:
+			text, err = m.src.Laps(m.ctx, id)
+		case chartView:
+			text, err = m.src.Chart(m.ctx, id, metric)
+		}
+		return textMsg{view: v, id: id, text: text, err: err}
+	}
+}
+
+// Update handles key presses, window resizes and completed loads.
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.clampList()
+		return m, nil
+
+	case pageMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		m.err = nil
+		m.page = msg.page
+		m.acts = append(m.acts, msg.acts...)
+		m.more = len(msg.acts) >= PageSize
+		return m, nil
+
+	case textMsg:
+		// Ignore results for a view the user has already left.
+		if m.view != msg.view || m.selectedID() != msg.id {
+			return m, nil
+		}
+		m.loading = false
+		m.err = msg.err
+		m.text = strings.TrimRight(msg.text, "\n")
+		m.scroll = 0
+		return m, nil
+
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			return m, tea.Quit
+		}
+		if m.view == listView {
+			return m.updateList(msg)
+		}
+		return m.updateText(msg)
+	}
+	return m, nil
+}
+
+func (m Model) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "esc":
+		return m, tea.Quit
+	case "up", "k":
+		m.cursor--
+	case "down", "j":
+		m.cursor++
+	case "pgup":
+		m.cursor -= m.listHeight()
+	case "pgdown", " ":
+		m.cursor += m.listHeight()
+	case "home", "g":
+		m.cursor = 0
+	case "end", "G":
+		m.cursor = len(m.acts) - 1
+	case "enter", "d":
+		return m.open(detailView)
+	case "l":
+		return m.open(lapsView)
+	case "c":
+		return m.open(chartView)
+	}
+	m.clampList()
+
+	// Fetch the next page as the cursor approaches the end of the list.
+	if m.more && !m.loading && m.cursor >= len(m.acts)-m.listHeight()/2 {
+		m.loading = true
+		return m, m.loadPage(m.page + 1)
+	}
+	return m, nil
+}
+
+func (m Model) updateText(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q":
+		return m, tea.Quit
+	case "esc", "backspace", "left", "h":
+		m.view, m.text, m.err, m.loading = listView, "", nil, false
+	case "up", "k":
+		m.scroll--
+	case "down", "j":
+		m.scroll++
+	case "pgup":
+		m.scroll -= m.textHeight()
+	case "pgdown", " ":
+		m.scroll += m.textHeight()
+	case "enter", "d":
+		return m.open(detailView)
+	case "l":
+		return m.open(lapsView)
+	case "c":
+		if m.view == chartView {
+			m.metric = (m.metric + 1) % len(Metrics)
+		}
+		return m.open(chartView)
+	}
+	m.scroll = max(0, min(m.scroll, strings.Count(m.text, "\n")+1-m.textHeight()))
+	return m, nil
+}
+
+// open switches to v for the selected activity and starts loading it.
+func (m Model) open(v view) (tea.Model, tea.Cmd) {
+	if len(m.acts) == 0 {
+		return m, nil
+	}
+	m.view, m.text, m.err, m.scroll, m.loading = v, "", nil, 0, true
+	return m, m.loadText(v, m.selectedID())
+}
+
+func (m Model) selectedID() int64 {
+	if m.cursor < 0 || m.cursor >= len(m.acts) {
+		return 0
+	}
+	return m.acts[m.cursor].ID
+}
+
+// clampList keeps the cursor on an activity and scrolls it into view.
+func (m *Model) clampList() {
+	m.cursor = max(0, min(m.cursor, len(m.acts)-1))
+	h := m.listHeight()
+	if m.cursor < m.top {
+		m.top = m.cursor
+	}
+	if m.cursor >= m.top+h {
+		m.top = m.cursor - h + 1
+	}
+}
+
+// listHeight and textHeight are the rows left after the title and help lines.
+func (m Model) listHeight() int { return max(1, m.height-3) }
+func (m Model) textHeight() int { return max(1, m.height-3) }
+
+// View renders the current screen.
+func (m Model) View() string {
+	var b strings.Builder
+	if m.view == listView {
+		m.viewList(&b)
+	} else {
+		m.viewText(&b)
+	}
+	return b.String()
+}
+
+func (m Model) viewList(b *strings.Builder) {
+	title := fmt.Sprintf("Activities (%d loaded)", len(m.acts))
+	if m.more {
+		title = fmt.Sprintf("Activities (%d loaded, more below)", len(m.acts))
+	}
+	b.WriteString(titleStyle.Render(title) + "\n\n")
+
+	end := min(len(m.acts), m.top+m.listHeight())
+	for i := m.top; i < end; i++ {
+		line := m.fit(m.row(m.acts[i]))
+		if i == m.cursor {
+			line = selectedStyle.Render(line)
+		}
+		b.WriteString(line + "\n")
+	}
+	for i := end - m.top; i < m.listHeight(); i++ {
+		b.WriteString("\n")
+	}
+	b.WriteString(m.status("↑/↓ move • enter details • l laps • c chart • q quit"))
+}
+
+func (m Model) viewText(b *strings.Builder) {
+	a := m.acts[m.cursor]
+	title := fmt.Sprintf("%s — %d", a.Name, a.ID)
+	switch m.view {
+	case lapsView:
+		title += " — laps"
+	case chartView:
+		title += " — " + Metrics[m.metric]
+	}
+	b.WriteString(titleStyle.Render(m.fit(title)) + "\n\n")
+
+	lines := strings.Split(m.text, "\n")
+	start := min(m.scroll, len(lines))
+	end := min(len(lines), start+m.textHeight())
+	shown := 0
+	if m.text != "" {
+		for _, line := range lines[start:end] {
+			b.WriteString(m.fit(line) + "\n")
+			shown++
+		}
+	}
+	for ; shown < m.textHeight(); shown++ {
+		b.WriteString("\n")
+	}
+	help := "esc back • ↑/↓ scroll • d details • l laps • c chart • q quit"
+	if m.view == chartView {
+		help = "esc back • c next metric • d details • l laps • q quit"
+	}
+	b.WriteString(m.status(help))
+}
+
+// status renders the bottom line: an error, a loading note or the key help.
+func (m Model) status(help string) string {
+	switch {
+	case m.err != nil:
+		return errorStyle.Render(m.fit("error: " + firstLine(m.err.Error())))
+	case m.loading:
+		return helpStyle.Render("loading…")
+	}
+	return helpStyle.Render(m.fit(help))
+}
+
+// fit truncates s to the terminal width.
+func (m Model) fit(s string) string {
+	r := []rune(s)
+	if m.width <= 0 || len(r) <= m.width {
+		return s
+	}
+	return string(r[:m.width])
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
@@ -0,0 +1,121 @@
+package tui_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/analysis"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/tui"
+)
+
+// fakeSource serves total activities with IDs counting down from total.
+type fakeSource struct {
+	total int
+	pages []int
+}
+
+func (f *fakeSource) Activities(_ context.Context, page int) ([]analysis.Activity, error) {
+	f.pages = append(f.pages, page)
+	var acts []analysis.Activity
+	for i := (page - 1) * tui.PageSize; i < min(f.total, page*tui.PageSize); i++ {
+		id := int64(f.total - i)
+		acts = append(acts, analysis.Activity{ID: id, Name: fmt.Sprintf("Ride %d", id)})
+	}
+	return acts, nil
+}
+
+func (f *fakeSource) Detail(_ context.Context, id int64) (string, error) {
+	return fmt.Sprintf("detail of %d", id), nil
+}
+
+func (f *fakeSource) Laps(_ context.Context, id int64) (string, error) {
+	return fmt.Sprintf("laps of %d", id), nil
+}
+
+func (f *fakeSource) Chart(_ context.Context, id int64, metric string) (string, error) {
+	return fmt.Sprintf("%s chart of %d", metric, id), nil
+}
+
+func row(a analysis.Activity) string { return a.Name }
+
+// step sends msg to m and runs any resulting command to completion, feeding
+// its message back, as the bubbletea runtime would.
+func step(t *testing.T, m tea.Model, msg tea.Msg) tea.Model {
+	t.Helper()
+	m, cmd := m.Update(msg)
+	for cmd != nil {
+		next := cmd()
+		if _, quit := next.(tea.QuitMsg); quit || next == nil {
+			return m
+		}
+		m, cmd = m.Update(next)
+	}
+	return m
+}
+
+func key(s string) tea.KeyMsg {
+	switch s {
+	case "down":
+		return tea.KeyMsg{Type: tea.KeyDown}
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	case "end":
+		return tea.KeyMsg{Type: tea.KeyEnd}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func start(t *testing.T, src *fakeSource) tea.Model {
+	t.Helper()
+	var m tea.Model = tui.New(context.Background(), src, row)
+	m = step(t, m, tea.WindowSizeMsg{Width: 80, Height: 10})
+	return step(t, m, m.Init()())
+}
+
+func TestBrowserNavigateAndOpenDetail(t *testing.T) {
+	m := start(t, &fakeSource{total: 3})
+	if !strings.Contains(m.View(), "Ride 3") {
+		t.Fatalf("list missing first activity:\n%s", m.View())
+	}
+
+	m = step(t, m, key("down"))
+	m = step(t, m, key("enter"))
+	if !strings.Contains(m.View(), "detail of 2") {
+		t.Errorf("expected detail of the second activity:\n%s", m.View())
+	}
+
+	m = step(t, m, key("c"))
+	if !strings.Contains(m.View(), "altitude chart of 2") {
+		t.Errorf("expected altitude chart:\n%s", m.View())
+	}
+	m = step(t, m, key("c"))
+	if !strings.Contains(m.View(), "heartrate chart of 2") {
+		t.Errorf("second c should cycle to heart rate:\n%s", m.View())
+	}
+
+	m = step(t, m, key("esc"))
+	if !strings.Contains(m.View(), "Activities (3 loaded)") {
+		t.Errorf("esc should return to the list:\n%s", m.View())
+	}
+}
+
+func TestBrowserLoadsMorePages(t *testing.T) {
+	src := &fakeSource{total: tui.PageSize + 5}
+	m := start(t, src)
+	m = step(t, m, key("end"))
+	if len(src.pages) != 2 || src.pages[1] != 2 {
+		t.Fatalf("pages fetched = %v, want [1 2]", src.pages)
+	}
+	m = step(t, m, key("end"))
+	if len(src.pages) != 2 {
+		t.Errorf("short page should end the history, fetched %v", src.pages)
+	}
+	if !strings.Contains(m.View(), fmt.Sprintf("Activities (%d loaded)", tui.PageSize+5)) {
+		t.Errorf("title should count all activities:\n%s", m.View())
+	}
+}
@@ -0,0 +1,93 @@
+// Package weather looks up historical weather for activities from the
+// Open-Meteo archive API (https://open-meteo.com), which needs no API key.
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/analysis"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/geo"
+)
+
+// DefaultBaseURL is the Open-Meteo historical weather endpoint.
+const DefaultBaseURL = "https://archive-api.open-meteo.com/v1/archive"
+
+// Client fetches hourly reanalysis data.
+type Client struct {
+	HTTP    *http.Client
+	BaseURL string
+}
+
+// New returns a Client using hc (http.DefaultClient if nil).
+func New(hc *http.Client) *Client {
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	return &Client{HTTP: hc, BaseURL: DefaultBaseURL}
+}
+
+type archiveResponse struct {
+	Hourly struct {
+		Time        []string   `json:"time"`
+		Temperature []*float64 `json:"temperature_2m"`
+		WindSpeed   []*float64 `json:"wind_speed_10m"`
+	} `json:"hourly"`
+	Reason string `json:"reason"`
+}
+
+// At returns the temperature and wind speed at p during the hour containing t.
+// The archive lags real time by a few days; recent hours have no data and
+// yield an error.
+func (c *Client) At(ctx context.Context, p geo.Point, t time.Time) (analysis.Weather, error) {
+	t = t.UTC()
+	day := t.Format("2006-01-02")
+	q := url.Values{
+		"latitude":        {fmt.Sprintf("%.4f", p.Lat)},
+		"longitude":       {fmt.Sprintf("%.4f", p.Lng)},
+		"start_date":      {day},
+		"end_date":        {day},
+		"hourly":          {"temperature_2m,wind_speed_10m"},
+		"wind_speed_unit": {"ms"},
+		"timezone":        {"GMT"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"?"+q.Encode(), nil)
+	if err != nil {
+		return analysis.Weather{}, fmt.Errorf("build request: %w", err)
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return analysis.Weather{}, fmt.Errorf("fetch weather: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return analysis.Weather{}, fmt.Errorf("read weather: %w", err)
+	}
+
+	var ar archiveResponse
+	if err := json.Unmarshal(body, &ar); err != nil {
+		return analysis.Weather{}, fmt.Errorf("parse weather (HTTP %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return analysis.Weather{}, fmt.Errorf("weather API error (HTTP %d): %s", resp.StatusCode, ar.Reason)
+	}
+
+	hour := t.Truncate(time.Hour).Format("2006-01-02T15:04")
+	h := ar.Hourly
+	for i, ts := range h.Time {
+		if ts != hour {
+			continue
+		}
+		if i >= len(h.Temperature) || i >= len(h.WindSpeed) || h.Temperature[i] == nil || h.WindSpeed[i] == nil {
+			break
+		}
+		return analysis.Weather{TempC: *h.Temperature[i], WindSpeed: *h.WindSpeed[i]}, nil
+	}
+	return analysis.Weather{}, fmt.Errorf("no weather data for %s yet", hour)
+}
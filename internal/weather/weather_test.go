@@ -0,0 +1,54 @@
+package weather_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Brainsoft-Raxat/strava-cli/internal/geo"
+	"github.com/Brainsoft-Raxat/strava-cli/internal/weather"
+)
+
+func TestAt_PicksStartHour(t *testing.T) {
+	var query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		w.Write([]byte(`{"hourly":{
+			"time":["2024-07-01T06:00","2024-07-01T07:00","2024-07-01T08:00"],
+			"temperature_2m":[18.0,19.5,21.0],
+			"wind_speed_10m":[1.0,2.5,4.0]}}`))
+	}))
+	defer srv.Close()
+
+	c := weather.New(srv.Client())
+	c.BaseURL = srv.URL
+	start := time.Date(2024, 7, 1, 7, 42, 0, 0, time.UTC)
+	w, err := c.At(context.Background(), geo.Point{Lat: 51.5, Lng: -0.12}, start)
+	if err != nil {
+		t.Fatalf("At: %v", err)
+	}
+	if w.TempC != 19.5 || w.WindSpeed != 2.5 {
+		t.Errorf("weather = %+v, want the 07:00 values", w)
+	}
+	for _, want := range []string{"start_date=2024-07-01", "wind_speed_unit=ms", "latitude=51.5000"} {
+		if !strings.Contains(query, want) {
+			t.Errorf("query %q missing %q", query, want)
+		}
+	}
+}
+
+func TestAt_MissingHour(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"hourly":{"time":["2024-07-01T07:00"],"temperature_2m":[null],"wind_speed_10m":[null]}}`))
+	}))
+	defer srv.Close()
+
+	c := weather.New(srv.Client())
+	c.BaseURL = srv.URL
+	if _, err := c.At(context.Background(), geo.Point{}, time.Date(2024, 7, 1, 7, 0, 0, 0, time.UTC)); err == nil {
+		t.Error("expected an error when the archive has no data for the hour")
+	}
+}